@@ -0,0 +1,246 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"lowercode-go-server/bootstrap"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/ws"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnnouncementsPerMinute 单个 IP 每分钟允许发布的系统公告数
+const AnnouncementsPerMinute = 5
+
+// maxAnnouncementMinutes 系统公告有效期上限（7 天）
+const maxAnnouncementMinutes = 7 * 24 * 60
+
+// AdminController 运行时配置查询 HTTP 控制器，供值班人员确认实例实际生效的配置
+type AdminController struct {
+	env       bootstrap.RedactedEnv
+	hub       *ws.Hub
+	startedAt time.Time
+}
+
+// NewAdminController 创建 AdminController 实例，env 在创建时脱敏
+func NewAdminController(env *bootstrap.Env, hub *ws.Hub) *AdminController {
+	return &AdminController{env: env.Redacted(), hub: hub, startedAt: time.Now()}
+}
+
+// ConfigResponse 运行时配置响应结构
+type ConfigResponse struct {
+	Env      bootstrap.RedactedEnv `json:"env"`
+	Limits   ConfigLimits          `json:"limits"`
+	Features ws.HubFeatures        `json:"features"`
+	Runtime  ConfigRuntime         `json:"runtime"`
+}
+
+// ConfigLimits 各层生效的限制
+type ConfigLimits struct {
+	ws.HubLimits
+	GuestConnectsPerMinute int `json:"guestConnectsPerMinute"`
+	AnnouncementsPerMinute int `json:"announcementsPerMinute"`
+}
+
+// ConfigRuntime 进程信息
+type ConfigRuntime struct {
+	GoVersion  string    `json:"goVersion"`
+	StartedAt  time.Time `json:"startedAt"`
+	Goroutines int       `json:"goroutines"`
+	Rooms      int       `json:"rooms"`
+
+	InstanceState string `json:"instanceState"` // ready / overloaded / maintenance / draining，见 GET /ready
+
+	Watchdog *ws.WatchdogStatus `json:"watchdog,omitempty"` // 过载保护最近一次采样，未启用时省略
+}
+
+// GetConfig 获取当前实例的运行时配置（密钥已脱敏）
+// GET /api/admin/config
+func (ac *AdminController) GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, ConfigResponse{
+		Env: ac.env,
+		Limits: ConfigLimits{
+			HubLimits:              ac.hub.Limits(),
+			GuestConnectsPerMinute: guestConnectsPerMinute,
+			AnnouncementsPerMinute: AnnouncementsPerMinute,
+		},
+		Features: ac.hub.Features(),
+		Runtime: ConfigRuntime{
+			GoVersion:  runtime.Version(),
+			StartedAt:  ac.startedAt,
+			Goroutines: runtime.NumGoroutine(),
+			Rooms:      len(ac.hub.RoomStates()),
+			Watchdog:   ac.hub.WatchdogStatus(),
+
+			InstanceState: ac.hub.InstanceState(),
+		},
+	})
+}
+
+// RoomsResponse 房间运行计数响应结构
+type RoomsResponse struct {
+	Rooms []ws.RoomStats `json:"rooms"`
+}
+
+// GetRooms 获取各房间的 Patch 与刷盘计数，按繁忙程度排序
+// GET /api/admin/rooms
+func (ac *AdminController) GetRooms(c *gin.Context) {
+	c.JSON(http.StatusOK, RoomsResponse{Rooms: ac.hub.RoomStats()})
+}
+
+// GetReproBundle 导出房间的复现包（快照 + 最近操作），用 go run ./cmd/replay 在本地回放
+// GET /api/admin/rooms/:pageId/repro
+func (ac *AdminController) GetReproBundle(c *gin.Context) {
+	pageID := c.Param("pageId")
+
+	bundle, err := ac.hub.ReproBundle(pageID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErrors.ErrReproDisabled):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "未启用复现日志（REPRO_JOURNAL_OPS）"})
+		case errors.Is(err, domainErrors.ErrRoomNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "房间不在本实例中，只能导出正在编辑的页面"})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "导出复现包失败", Details: err.Error()})
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="repro-%s-%d.json"`, pageID, bundle.Version))
+	c.JSON(http.StatusOK, bundle)
+}
+
+// AnnounceRequest 发布系统公告请求结构
+type AnnounceRequest struct {
+	Message          string `json:"message" binding:"required"`
+	Severity         string `json:"severity"`         // info（默认）/ warning / critical
+	PageID           string `json:"pageId"`           // 为空时发往所有房间
+	ExpiresInMinutes int    `json:"expiresInMinutes"` // 有效期（分钟），0 表示只发给当前在线用户，最长 7 天
+}
+
+// AnnounceResponse 发布系统公告响应结构
+type AnnounceResponse struct {
+	Notice ws.SystemNoticePayload `json:"notice"`
+	Rooms  int                    `json:"rooms"` // 送达的房间数
+}
+
+// Announce 向编辑器内的在线用户发布系统公告（如维护预告），带有效期的公告到期前新加入的用户也会收到
+// POST /api/admin/announce
+// 请求体: { "message": "今晚 23:00 停机维护", "severity": "warning", "expiresInMinutes": 120 }
+func (ac *AdminController) Announce(c *gin.Context) {
+	var req AnnounceRequest
+	if !bindJSON(c, &req, "请求参数错误") {
+		return
+	}
+
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" || len([]rune(req.Message)) > ws.NoticeMaxLength {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "公告内容不能为空且不能超过 500 个字符"})
+		return
+	}
+	if req.Severity == "" {
+		req.Severity = ws.NoticeInfo
+	}
+	if !ws.ValidNoticeSeverity(req.Severity) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "severity 只能是 info、warning 或 critical"})
+		return
+	}
+	if req.ExpiresInMinutes < 0 || req.ExpiresInMinutes > maxAnnouncementMinutes {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "有效期需在 0 ~ 10080 分钟之间"})
+		return
+	}
+
+	notice := ws.SystemNoticePayload{
+		Severity: req.Severity,
+		Message:  req.Message,
+		PageID:   req.PageID,
+	}
+	if req.ExpiresInMinutes > 0 {
+		notice.ExpiresAt = time.Now().Add(time.Duration(req.ExpiresInMinutes) * time.Minute).UnixMilli()
+	}
+
+	notice, rooms := ac.hub.Announce(notice)
+	c.JSON(http.StatusOK, AnnounceResponse{Notice: notice, Rooms: rooms})
+}
+
+// ReadyResponse 就绪检查响应结构
+type ReadyResponse struct {
+	Status string `json:"status"` // ready / overloaded / maintenance / draining
+}
+
+// drainRetryAfter 实例排空或维护中时 /ready 返回的 Retry-After（秒）
+const drainRetryAfter = "30"
+
+// Ready 就绪检查，供负载均衡判断是否向本实例分配新流量：
+// ready 和 overloaded 返回 200（过载只是软提示，通过 X-Instance-State 头告知，避免所有实例同时过载时全部摘除），
+// maintenance 和 draining 返回 503，负载均衡把实例摘除，已有的 WebSocket 连接由 server-draining 引导重连
+// GET /ready
+func (ac *AdminController) Ready(c *gin.Context) {
+	state := ac.hub.InstanceState()
+	switch state {
+	case ws.InstanceReady, ws.InstanceOverloaded:
+		c.JSON(http.StatusOK, ReadyResponse{Status: state})
+	default:
+		c.Header("Retry-After", drainRetryAfter)
+		c.JSON(http.StatusServiceUnavailable, ReadyResponse{Status: state})
+	}
+}
+
+// MaintenanceRequest 开关维护模式请求结构
+type MaintenanceRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+
+	// ReconnectWithinSeconds 客户端分散重连的时间窗口（秒），默认 30，最长 600
+	ReconnectWithinSeconds int `json:"reconnectWithinSeconds"`
+}
+
+// MaintenanceResponse 维护模式响应结构
+type MaintenanceResponse struct {
+	Enabled       bool   `json:"enabled"`
+	InstanceState string `json:"instanceState"`
+	Rooms         int    `json:"rooms"` // 本次通知的房间数，仅开启时有值
+}
+
+// maxReconnectWindowSeconds 维护模式重连窗口上限（10 分钟）
+const maxReconnectWindowSeconds = 600
+
+// GetMaintenance 查询本实例的维护模式
+// GET /api/admin/maintenance
+func (ac *AdminController) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, MaintenanceResponse{
+		Enabled:       ac.hub.Maintenance(),
+		InstanceState: ac.hub.InstanceState(),
+	})
+}
+
+// SetMaintenance 开启或关闭本实例的维护模式。开启后 /ready 返回 503，负载均衡摘除实例，
+// 在线客户端收到 server-draining 后分散重连到其他实例；只作用于处理请求的实例，需直接访问实例地址
+// PUT /api/admin/maintenance
+// 请求体: { "enabled": true, "reconnectWithinSeconds": 60 }
+func (ac *AdminController) SetMaintenance(c *gin.Context) {
+	var req MaintenanceRequest
+	if !bindJSON(c, &req, "请求参数错误") {
+		return
+	}
+	if req.ReconnectWithinSeconds < 0 || req.ReconnectWithinSeconds > maxReconnectWindowSeconds {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "重连窗口需在 0 ~ 600 秒之间"})
+		return
+	}
+	window := ws.DefaultReconnectWindow
+	if req.ReconnectWithinSeconds > 0 {
+		window = time.Duration(req.ReconnectWithinSeconds) * time.Second
+	}
+
+	rooms := ac.hub.SetMaintenance(*req.Enabled, window)
+	c.JSON(http.StatusOK, MaintenanceResponse{
+		Enabled:       ac.hub.Maintenance(),
+		InstanceState: ac.hub.InstanceState(),
+		Rooms:         rooms,
+	})
+}
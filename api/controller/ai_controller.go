@@ -0,0 +1,358 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"lowercode-go-server/api/middleware"
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/ws"
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AIGenerationsPerMinute 单个 IP 每分钟允许发起的 AI 生成次数，每次都会调用付费的模型服务
+const AIGenerationsPerMinute = 10
+
+// AIGenerateRequest AI 生成请求结构
+type AIGenerateRequest struct {
+	Prompt string `json:"prompt" binding:"required"` // 修改需求，最长 2000 个字符
+	DryRun bool   `json:"dryRun"`                    // 可选，为 true 时只校验并返回将要应用的 Patch，不提交
+}
+
+// AIProposalRequest 生成 AI 修改建议请求结构
+type AIProposalRequest struct {
+	Prompt string `json:"prompt" binding:"required"` // 修改需求，最长 2000 个字符
+}
+
+// AIProviderRequest 设置租户模型服务请求结构
+type AIProviderRequest struct {
+	Kind       string `json:"kind"`                       // openai（默认）/ azure / local
+	BaseURL    string `json:"baseUrl" binding:"required"` // 服务地址
+	Model      string `json:"model" binding:"required"`   // 模型名，Azure 为部署名
+	APIVersion string `json:"apiVersion"`                 // 仅 Azure，默认 2024-06-01
+	APIKey     string `json:"apiKey"`                     // 为空时保留已保存的密钥
+}
+
+// AIUsageEntry 单日、单个来源和模型的 AI 用量
+type AIUsageEntry struct {
+	Day              string `json:"day"`    // UTC 日期，如 2026-10-18
+	Source           string `json:"source"` // platform / tenant
+	Model            string `json:"model"`
+	Requests         int64  `json:"requests"`
+	Failures         int64  `json:"failures"`
+	PromptTokens     int64  `json:"promptTokens"`
+	CompletionTokens int64  `json:"completionTokens"`
+	RequestBytes     int64  `json:"requestBytes"`
+	ResponseBytes    int64  `json:"responseBytes"`
+}
+
+// AIUsageResponse 租户 AI 用量响应结构
+type AIUsageResponse struct {
+	TenantID string         `json:"tenantId"`
+	Days     int            `json:"days"`
+	Total    AIUsageEntry   `json:"total"` // 全部来源和模型的合计，day / source / model 为空
+	Daily    []AIUsageEntry `json:"daily"`
+}
+
+// AIController AI 生成 HTTP 控制器
+type AIController struct {
+	ai *usecase.AIUseCase
+}
+
+// NewAIController 创建 AIController 实例
+func NewAIController(ai *usecase.AIUseCase) *AIController {
+	return &AIController{ai: ai}
+}
+
+// Generate 按提示词生成页面修改，校验后作为一个版本提交并以 op-patch 广播给在线用户
+// POST /api/pages/:pageId/ai/generate
+// 请求体: { "prompt": "在表单底部添加一个提交按钮", "dryRun": false }
+func (ac *AIController) Generate(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	var req AIGenerateRequest
+	if !bindJSON(c, &req, "prompt 不能为空") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	result, err := ac.ai.Generate(c.Request.Context(), pageID, userID.(string), req.Prompt, req.DryRun)
+	if err != nil {
+		writeAIError(c, err)
+		return
+	}
+
+	resp := gin.H{"pageId": pageID, "version": result.Version, "patches": result.Patches, "label": ws.LabelAIGenerate}
+	if req.DryRun {
+		resp["dryRun"] = true
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateProposal 按提示词生成修改建议，返回 Patch 和按组件汇总的修改供用户审阅，不修改页面
+// POST /api/pages/:pageId/ai/proposals
+// 请求体: { "prompt": "在表单底部添加一个提交按钮" }
+func (ac *AIController) CreateProposal(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	var req AIProposalRequest
+	if !bindJSON(c, &req, "prompt 不能为空") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	proposal, err := ac.ai.Propose(c.Request.Context(), pageID, userID.(string), req.Prompt)
+	if err != nil {
+		writeAIError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, proposal)
+}
+
+// AcceptProposal 接受修改建议，按建议的基准版本提交并以 op-patch 广播给在线用户
+// POST /api/pages/:pageId/ai/proposals/:proposalId/accept
+func (ac *AIController) AcceptProposal(c *gin.Context) {
+	pageID, proposalID := c.Param("pageId"), c.Param("proposalId")
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	result, err := ac.ai.AcceptProposal(pageID, proposalID, userID.(string))
+	if err != nil {
+		writeAIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pageId": pageID, "version": result.Version, "patches": result.Patches, "label": ws.LabelAIGenerate})
+}
+
+// DiscardProposal 放弃修改建议
+// DELETE /api/pages/:pageId/ai/proposals/:proposalId
+func (ac *AIController) DiscardProposal(c *gin.Context) {
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	if err := ac.ai.DiscardProposal(c.Param("pageId"), c.Param("proposalId"), userID.(string)); err != nil {
+		writeAIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, MessageResponse{Message: "已放弃修改建议", PageID: c.Param("pageId")})
+}
+
+// GetProvider 获取当前租户自带的模型服务配置：激活组织时为组织（仅组织管理员），否则为个人
+// GET /api/ai/provider
+func (ac *AIController) GetProvider(c *gin.Context) {
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	settings, err := ac.ai.ProviderSettings(userID.(string), orgClaims(c))
+	if err != nil {
+		writeAIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// SetProvider 设置当前租户自带的模型服务，之后租户页面上的 AI 生成都调用该服务
+// PUT /api/ai/provider
+// 请求体: { "kind": "azure", "baseUrl": "https://acme.openai.azure.com", "model": "gpt-4o", "apiKey": "..." }
+func (ac *AIController) SetProvider(c *gin.Context) {
+	var req AIProviderRequest
+	if !bindJSON(c, &req, "baseUrl 和 model 不能为空") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	settings, err := ac.ai.SetProvider(userID.(string), orgClaims(c), usecase.AIProviderInput{
+		Kind:       req.Kind,
+		BaseURL:    req.BaseURL,
+		Model:      req.Model,
+		APIVersion: req.APIVersion,
+		APIKey:     req.APIKey,
+	})
+	if err != nil {
+		writeAIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// DeleteProvider 删除当前租户自带的模型服务，改用平台默认服务
+// DELETE /api/ai/provider
+func (ac *AIController) DeleteProvider(c *gin.Context) {
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	if err := ac.ai.DeleteProvider(userID.(string), orgClaims(c)); err != nil {
+		writeAIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, MessageResponse{Message: "已删除模型服务配置"})
+}
+
+// GetUsage 获取当前租户最近 days 天的 AI 用量
+// GET /api/ai/usage?days=30
+func (ac *AIController) GetUsage(c *gin.Context) {
+	days, ok := parseUsageDays(c)
+	if !ok {
+		return
+	}
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	tenantID := userID.(string)
+	org := orgClaims(c)
+	if org.OrgID != "" {
+		tenantID = org.OrgID
+	}
+	usage, err := ac.ai.Usage(userID.(string), org, days)
+	if err != nil {
+		writeAIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toAIUsageResponse(tenantID, days, usage))
+}
+
+// GetTenantUsage 获取指定租户（组织 ID 或用户 ID）最近 days 天的 AI 用量
+// GET /api/admin/tenants/:tenantId/ai-usage?days=30
+func (ac *AIController) GetTenantUsage(c *gin.Context) {
+	days, ok := parseUsageDays(c)
+	if !ok {
+		return
+	}
+
+	usage, err := ac.ai.TenantUsage(c.Param("tenantId"), days)
+	if err != nil {
+		writeAIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toAIUsageResponse(c.Param("tenantId"), days, usage))
+}
+
+// parseUsageDays 解析 days 参数，缺省时为 DefaultAIUsageDays，超过上限时截断
+func parseUsageDays(c *gin.Context) (int, bool) {
+	days := usecase.DefaultAIUsageDays
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "days 必须为正整数", Field: "days"})
+			return 0, false
+		}
+		days = min(parsed, usecase.MaxAIUsageDays)
+	}
+	return days, true
+}
+
+// toAIUsageResponse 转换为响应结构并计算合计
+func toAIUsageResponse(tenantID string, days int, usage []*entity.AIUsage) AIUsageResponse {
+	resp := AIUsageResponse{TenantID: tenantID, Days: days, Daily: make([]AIUsageEntry, 0, len(usage))}
+	for _, u := range usage {
+		resp.Daily = append(resp.Daily, AIUsageEntry{
+			Day:              u.Day.UTC().Format(time.DateOnly),
+			Source:           u.Source,
+			Model:            u.Model,
+			Requests:         u.Requests,
+			Failures:         u.Failures,
+			PromptTokens:     u.PromptTokens,
+			CompletionTokens: u.CompletionTokens,
+			RequestBytes:     u.RequestBytes,
+			ResponseBytes:    u.ResponseBytes,
+		})
+		resp.Total.Requests += u.Requests
+		resp.Total.Failures += u.Failures
+		resp.Total.PromptTokens += u.PromptTokens
+		resp.Total.CompletionTokens += u.CompletionTokens
+		resp.Total.RequestBytes += u.RequestBytes
+		resp.Total.ResponseBytes += u.ResponseBytes
+	}
+	return resp
+}
+
+// writeAIError 将 AI 生成相关的业务错误转换为 HTTP 响应
+func writeAIError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domainErrors.ErrPageNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+	case errors.Is(err, domainErrors.ErrUnauthorized):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "无权编辑此页面"})
+	case errors.Is(err, domainErrors.ErrSessionClosed):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "当前不在页面的协作时段内，页面只读"})
+	case errors.Is(err, domainErrors.ErrPresentationActive):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "演示模式下只有演示者可以编辑"})
+	case errors.Is(err, domainErrors.ErrInvalidAIPrompt):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "提示词无效", Details: err.Error()})
+	case errors.Is(err, domainErrors.ErrAIProvider):
+		c.JSON(http.StatusBadGateway, ErrorResponse{Error: "模型服务调用失败，请稍后重试", Details: err.Error()})
+	case errors.Is(err, domainErrors.ErrAIInvalidPatch):
+		c.JSON(http.StatusBadGateway, ErrorResponse{Error: "模型生成的修改无效，请调整提示词后重试", Details: err.Error()})
+	case errors.Is(err, domainErrors.ErrAIProposalNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "修改建议不存在或已过期"})
+	case errors.Is(err, domainErrors.ErrAIProposalStale):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "生成建议后页面已被修改，请重新生成"})
+	case errors.Is(err, domainErrors.ErrInvalidAIProvider):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "模型服务配置无效", Details: err.Error()})
+	case errors.Is(err, domainErrors.ErrAIDisabled):
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "服务端未配置模型服务（LLM_API_KEY），请配置自带的模型服务"})
+	case errors.Is(err, domainErrors.ErrSecretsDisabled):
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "服务端未配置密钥加密（SECRET_PROPS_KEY）"})
+	case errors.Is(err, domainErrors.ErrOptimisticLock):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "页面编辑频繁，请稍后重试"})
+	case errors.Is(err, domainErrors.ErrUsageLimitExceeded):
+		writeUsageLimitError(c, err)
+	case errors.Is(err, domainErrors.ErrRoomClosing):
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "房间正在关闭，请稍后重试"})
+	case errors.Is(err, domainErrors.ErrRoomOwnedElsewhere), errors.Is(err, domainErrors.ErrRoomLockUnavailable):
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "页面所在节点正在切换，请稍后重试"})
+	case errors.Is(err, domainErrors.ErrServerShuttingDown):
+		c.Header("Retry-After", "5")
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "服务正在重启，请稍后重试"})
+	case errors.Is(err, domainErrors.ErrServerOverloaded):
+		c.Header("Retry-After", overloadRetryAfter)
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "服务繁忙，请稍后重试"})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"lowercode-go-server/api/middleware"
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAPIKeyRequest 创建 API Key 请求结构
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes"`        // pages:read / pages:write，省略时只读
+	ExpiresInDays int      `json:"expiresInDays"` // 有效期（天），0 或省略为永不过期，最长 365 天
+}
+
+// APIKeyResponse API Key 响应结构，不含明文
+type APIKeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // 明文的前几位，用于辨认密钥
+	Scopes     []string   `json:"scopes"`
+	OrgID      string     `json:"orgId,omitempty"` // 密钥所属组织，为空时只能访问创建者的个人页面和被授权的页面
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	Active     bool       `json:"active"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreateAPIKeyResponse 创建 API Key 响应结构，Key 为明文，只返回这一次
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// APIKeyListResponse API Key 列表响应结构
+type APIKeyListResponse struct {
+	Keys []APIKeyResponse `json:"keys"`
+}
+
+// APIKeyController 服务端集成 API Key HTTP 控制器
+type APIKeyController struct {
+	apiKeys *usecase.APIKeyUseCase
+}
+
+// NewAPIKeyController 创建 APIKeyController 实例
+func NewAPIKeyController(apiKeys *usecase.APIKeyUseCase) *APIKeyController {
+	return &APIKeyController{apiKeys: apiKeys}
+}
+
+// CreateAPIKey 创建 API Key，激活组织时密钥属于该组织
+// POST /api/api-keys
+// 请求体: { "name": "CI", "scopes": ["pages:write"], "expiresInDays": 90 }
+func (kc *APIKeyController) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if !bindJSON(c, &req, "name 不能为空") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	key, secret, err := kc.apiKeys.Create(userID.(string), orgClaims(c), req.Name, req.Scopes, ttl)
+	if err != nil {
+		writeAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateAPIKeyResponse{APIKeyResponse: toAPIKeyResponse(key), Key: secret})
+}
+
+// ListAPIKeys 获取当前用户的全部 API Key，包括已过期和已撤销的
+// GET /api/api-keys
+func (kc *APIKeyController) ListAPIKeys(c *gin.Context) {
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	keys, err := kc.apiKeys.List(userID.(string))
+	if err != nil {
+		writeAPIKeyError(c, err)
+		return
+	}
+
+	resp := APIKeyListResponse{Keys: make([]APIKeyResponse, 0, len(keys))}
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, toAPIKeyResponse(key))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RevokeAPIKey 撤销当前用户的 API Key，立即生效
+// DELETE /api/api-keys/:keyId
+func (kc *APIKeyController) RevokeAPIKey(c *gin.Context) {
+	keyID := c.Param("keyId")
+	if keyID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "keyId 不能为空"})
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	if err := kc.apiKeys.Revoke(userID.(string), keyID); err != nil {
+		writeAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "API Key 已撤销"})
+}
+
+// toAPIKeyResponse 构造 API Key 响应
+func toAPIKeyResponse(key *entity.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.ScopeList(),
+		OrgID:      key.OrgID,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		Active:     key.Active(time.Now()),
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+// writeAPIKeyError 将 API Key 相关的业务错误转换为 HTTP 响应
+func writeAPIKeyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domainErrors.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "API Key 不存在或已被撤销"})
+	case errors.Is(err, domainErrors.ErrInvalidAPIKey):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "API Key 参数无效", Details: err.Error()})
+	case errors.Is(err, domainErrors.ErrAPIKeyLimit):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "有效的 API Key 数量已达上限，请先撤销不再使用的密钥"})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
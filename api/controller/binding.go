@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// unknownFieldPrefix encoding/json 在 DisallowUnknownFields 下报告未知字段的错误前缀，
+// 标准库没有导出对应的错误类型，只能按错误文本识别
+const unknownFieldPrefix = `json: unknown field "`
+
+// bindJSON 严格解析 JSON 请求体并执行 binding 校验，请求体包含 DTO 未声明的字段时拒绝，
+// 避免 page_id 之类的拼写错误被静默忽略、按默认值处理。
+// 未知字段返回 422 并在 field 中指出字段名；其余解析或校验错误返回 400，error 为 message。
+// 失败时已写入响应，返回 false。
+func bindJSON(c *gin.Context, obj interface{}, message string) bool {
+	err := decodeStrictJSON(c.Request, obj)
+	if err == nil {
+		err = binding.Validator.ValidateStruct(obj)
+	}
+	if err == nil {
+		return true
+	}
+
+	if field, ok := unknownField(err); ok {
+		c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+			Error:   "请求包含未知字段",
+			Details: err.Error(),
+			Field:   field,
+		})
+		return false
+	}
+	c.JSON(http.StatusBadRequest, ErrorResponse{Error: message, Details: err.Error()})
+	return false
+}
+
+// decodeStrictJSON 解析请求体，拒绝未声明的字段。
+// 只检查 obj 中声明为结构体的层级，interface{} / json.RawMessage 字段（如 schema）的内容不受限制
+func decodeStrictJSON(r *http.Request, obj interface{}) error {
+	if r.Body == nil {
+		return errors.New("请求体为空")
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	return dec.Decode(obj)
+}
+
+// unknownField 从 DisallowUnknownFields 的错误中取出字段名
+func unknownField(err error) (string, bool) {
+	rest, ok := strings.CutPrefix(err.Error(), unknownFieldPrefix)
+	if !ok {
+		return "", false
+	}
+	field, _, _ := strings.Cut(rest, `"`)
+	return field, true
+}
@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"lowercode-go-server/api/middleware"
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateBranchRequest 创建草稿分支请求结构
+type CreateBranchRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// BranchResponse 草稿分支响应结构
+type BranchResponse struct {
+	ID           string    `json:"id"`
+	PageID       string    `json:"pageId"`
+	BranchPageID string    `json:"branchPageId"` // 分支内容所在的页面，编辑分支时以它作为 pageId 连接 WebSocket
+	Name         string    `json:"name"`
+	BaseVersion  int64     `json:"baseVersion"` // 分叉时页面的版本
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// BranchListResponse 草稿分支列表响应结构
+type BranchListResponse struct {
+	PageID   string           `json:"pageId"`
+	Branches []BranchResponse `json:"branches"`
+}
+
+// BranchMergeResponse 分支合并预览 / 合并结果响应结构
+type BranchMergeResponse struct {
+	Branch        *BranchResponse `json:"branch,omitempty"` // 仅预览时返回
+	PageVersion   int64           `json:"pageVersion"`
+	BranchVersion int64           `json:"branchVersion"`
+	Changes       []string        `json:"changes"`   // 分支修改的位置（JSON Pointer）
+	Conflicts     []string        `json:"conflicts"` // 与页面分叉后的修改冲突的位置
+}
+
+// BranchConflictResponse 合并冲突响应结构
+type BranchConflictResponse struct {
+	Error     string   `json:"error"`
+	Conflicts []string `json:"conflicts"`
+}
+
+// BranchController 页面私有草稿分支 HTTP 控制器
+type BranchController struct {
+	branches *usecase.BranchUseCase
+}
+
+// NewBranchController 创建 BranchController 实例
+func NewBranchController(branches *usecase.BranchUseCase) *BranchController {
+	return &BranchController{branches: branches}
+}
+
+// CreateBranch 以页面最新状态创建私有草稿分支（所有者和编辑者）
+// POST /api/pages/:pageId/branches
+// 请求体: { "name": "尝试新布局" }
+func (bc *BranchController) CreateBranch(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	var req CreateBranchRequest
+	if !bindJSON(c, &req, "name 不能为空") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	branch, err := bc.branches.Fork(pageID, userID.(string), req.Name)
+	if err != nil {
+		writeBranchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toBranchResponse(branch))
+}
+
+// ListBranches 获取当前用户在页面上的草稿分支
+// GET /api/pages/:pageId/branches
+func (bc *BranchController) ListBranches(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	branches, err := bc.branches.List(pageID, userID.(string))
+	if err != nil {
+		writeBranchError(c, err)
+		return
+	}
+
+	resp := BranchListResponse{PageID: pageID, Branches: make([]BranchResponse, 0, len(branches))}
+	for _, branch := range branches {
+		resp.Branches = append(resp.Branches, toBranchResponse(branch))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetBranch 预览分支合并：分支修改的位置和与页面冲突的位置
+// GET /api/pages/:pageId/branches/:branchId
+func (bc *BranchController) GetBranch(c *gin.Context) {
+	pageID, branchID, ok := branchParams(c)
+	if !ok {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	branch, preview, err := bc.branches.Preview(pageID, branchID, userID.(string))
+	if err != nil {
+		writeBranchError(c, err)
+		return
+	}
+
+	resp := toBranchMergeResponse(preview)
+	branchResp := toBranchResponse(branch)
+	resp.Branch = &branchResp
+	c.JSON(http.StatusOK, resp)
+}
+
+// MergeBranch 将分支合并回页面，合并成功后分支被删除；存在冲突时返回 409 和冲突位置
+// POST /api/pages/:pageId/branches/:branchId/merge
+func (bc *BranchController) MergeBranch(c *gin.Context) {
+	pageID, branchID, ok := branchParams(c)
+	if !ok {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	merged, err := bc.branches.Merge(pageID, branchID, userID.(string))
+	if errors.Is(err, domainErrors.ErrBranchConflict) {
+		c.JSON(http.StatusConflict, BranchConflictResponse{
+			Error:     "分支与页面修改了同一处，无法自动合并",
+			Conflicts: merged.Conflicts,
+		})
+		return
+	}
+	if err != nil {
+		writeBranchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toBranchMergeResponse(merged))
+}
+
+// DeleteBranch 放弃草稿分支，分支内容一并删除
+// DELETE /api/pages/:pageId/branches/:branchId
+func (bc *BranchController) DeleteBranch(c *gin.Context) {
+	pageID, branchID, ok := branchParams(c)
+	if !ok {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	if err := bc.branches.Delete(pageID, branchID, userID.(string)); err != nil {
+		writeBranchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "分支已删除", PageID: pageID})
+}
+
+// toBranchResponse 构造分支响应
+func toBranchResponse(branch *entity.PageBranch) BranchResponse {
+	return BranchResponse{
+		ID:           branch.ID,
+		PageID:       branch.PageID,
+		BranchPageID: branch.BranchPageID,
+		Name:         branch.Name,
+		BaseVersion:  branch.BaseVersion,
+		CreatedAt:    branch.CreatedAt,
+	}
+}
+
+// toBranchMergeResponse 构造合并预览 / 合并结果响应
+func toBranchMergeResponse(merged *usecase.BranchMerge) BranchMergeResponse {
+	return BranchMergeResponse{
+		PageVersion:   merged.PageVersion,
+		BranchVersion: merged.BranchVersion,
+		Changes:       merged.Changes,
+		Conflicts:     merged.Conflicts,
+	}
+}
+
+// branchParams 解析 pageId 和 branchId 路径参数，失败时已写入 400 响应
+func branchParams(c *gin.Context) (string, string, bool) {
+	pageID := c.Param("pageId")
+	branchID := c.Param("branchId")
+	if pageID == "" || branchID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 和 branchId 不能为空"})
+		return "", "", false
+	}
+	return pageID, branchID, true
+}
+
+// writeBranchError 将草稿分支业务错误映射为 HTTP 响应
+func writeBranchError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domainErrors.ErrPageNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+	case errors.Is(err, domainErrors.ErrBranchNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "分支不存在"})
+	case errors.Is(err, domainErrors.ErrUnauthorized):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "无权编辑此页面"})
+	case errors.Is(err, domainErrors.ErrPresentationActive):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "演示模式下只有演示者可以编辑"})
+	case errors.Is(err, domainErrors.ErrInvalidBranch):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "分支参数无效", Details: err.Error()})
+	case errors.Is(err, domainErrors.ErrBranchLimit):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "分支数量已达上限，请先合并或删除不再使用的分支"})
+	case errors.Is(err, domainErrors.ErrStorageQuotaExceeded):
+		writeQuotaError(c, err)
+	case errors.Is(err, domainErrors.ErrOptimisticLock):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "页面编辑频繁，请稍后重试"})
+	case errors.Is(err, domainErrors.ErrRoomClosing):
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "房间正在关闭，请稍后重试"})
+	case errors.Is(err, domainErrors.ErrRoomOwnedElsewhere), errors.Is(err, domainErrors.ErrRoomLockUnavailable):
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "页面所在节点正在切换，请稍后重试"})
+	case errors.Is(err, domainErrors.ErrServerShuttingDown):
+		c.Header("Retry-After", "5")
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "服务正在重启，请稍后重试"})
+	case errors.Is(err, domainErrors.ErrServerOverloaded):
+		c.Header("Retry-After", overloadRetryAfter)
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "服务繁忙，请稍后重试"})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
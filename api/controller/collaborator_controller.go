@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"lowercode-go-server/api/middleware"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CollaboratorResponse 协作者响应结构
+type CollaboratorResponse struct {
+	UserID    string    `json:"userId"`
+	Role      string    `json:"role"`
+	AddedBy   string    `json:"addedBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CollaboratorListResponse 协作者列表响应结构
+type CollaboratorListResponse struct {
+	PageID        string                 `json:"pageId"`
+	Collaborators []CollaboratorResponse `json:"collaborators"`
+}
+
+// AddCollaboratorRequest 添加协作者请求结构
+type AddCollaboratorRequest struct {
+	Role string `json:"role" binding:"required"` // editor 或 viewer
+}
+
+// CollaboratorController 页面协作者 HTTP 控制器
+type CollaboratorController struct {
+	pageUseCase *usecase.PageUseCase
+}
+
+// NewCollaboratorController 创建 CollaboratorController 实例
+func NewCollaboratorController(pageUseCase *usecase.PageUseCase) *CollaboratorController {
+	return &CollaboratorController{pageUseCase: pageUseCase}
+}
+
+// ListCollaborators 获取页面协作者（不含创建者）
+// GET /api/pages/:pageId/collaborators
+func (cc *CollaboratorController) ListCollaborators(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	collaborators, err := cc.pageUseCase.ListCollaborators(pageID, userID.(string))
+	if err != nil {
+		writeCollaboratorError(c, err, "无权访问此页面")
+		return
+	}
+
+	resp := CollaboratorListResponse{PageID: pageID, Collaborators: make([]CollaboratorResponse, 0, len(collaborators))}
+	for _, collaborator := range collaborators {
+		resp.Collaborators = append(resp.Collaborators, CollaboratorResponse{
+			UserID:    collaborator.UserID,
+			Role:      collaborator.Role,
+			AddedBy:   collaborator.AddedBy,
+			CreatedAt: collaborator.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// AddCollaborator 添加协作者或修改角色（仅所有者）
+// PUT /api/pages/:pageId/collaborators/:userId
+// 请求体: { "role": "editor" }，role 为 editor（可编辑）或 viewer（只读）；角色变化在对方下次连接时生效
+func (cc *CollaboratorController) AddCollaborator(c *gin.Context) {
+	pageID := c.Param("pageId")
+	targetUserID := c.Param("userId")
+	if pageID == "" || targetUserID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 和 userId 不能为空"})
+		return
+	}
+
+	var req AddCollaboratorRequest
+	if !bindJSON(c, &req, "role 不能为空") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	collaborator, err := cc.pageUseCase.AddCollaborator(pageID, userID.(string), targetUserID, req.Role)
+	if err != nil {
+		writeCollaboratorError(c, err, "只有页面所有者可以管理协作者")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pageId": pageID, "userId": collaborator.UserID, "role": collaborator.Role})
+}
+
+// RemoveCollaborator 移除协作者
+// DELETE /api/pages/:pageId/collaborators/:userId
+// 所有者可以移除任何协作者，协作者可以移除自己（角色由路由上的 RequirePageRole 解析）；对方在线时立即被移出协同房间
+func (cc *CollaboratorController) RemoveCollaborator(c *gin.Context) {
+	pageID := c.Param("pageId")
+	targetUserID := c.Param("userId")
+	if pageID == "" || targetUserID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 和 userId 不能为空"})
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	if err := cc.pageUseCase.RemoveCollaborator(pageID, userID.(string), middleware.PageRole(c), targetUserID); err != nil {
+		writeCollaboratorError(c, err, "只有页面所有者可以移除其他协作者")
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "协作者已移除", PageID: pageID})
+}
+
+// writeCollaboratorError 将协作者相关的业务错误转换为 HTTP 响应，forbidden 为 403 时的提示
+func writeCollaboratorError(c *gin.Context, err error, forbidden string) {
+	switch {
+	case errors.Is(err, domainErrors.ErrPageNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+	case errors.Is(err, domainErrors.ErrCollaboratorNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "该用户不是页面协作者"})
+	case errors.Is(err, domainErrors.ErrUnauthorized):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: forbidden})
+	case errors.Is(err, domainErrors.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "角色无效", Details: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
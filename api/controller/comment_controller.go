@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"lowercode-go-server/api/middleware"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/ws"
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CommentListResponse 评论列表响应结构
+type CommentListResponse struct {
+	PageID   string              `json:"pageId"`
+	Comments []ws.CommentPayload `json:"comments"`
+}
+
+// CreateCommentRequest 发表评论请求结构
+type CreateCommentRequest struct {
+	ComponentID string `json:"componentId"`        // 回复时可省略，以线程为准
+	ParentID    uint   `json:"parentId,omitempty"` // 回复的线程首条评论 ID
+	Text        string `json:"text" binding:"required"`
+}
+
+// UpdateCommentRequest 修改评论请求结构
+type UpdateCommentRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// ResolveCommentRequest 解决线程请求结构
+type ResolveCommentRequest struct {
+	Resolved *bool `json:"resolved" binding:"required"`
+}
+
+// CommentController 组件评论 HTTP 控制器
+type CommentController struct {
+	commentUseCase *usecase.CommentUseCase
+}
+
+// NewCommentController 创建 CommentController 实例
+func NewCommentController(commentUseCase *usecase.CommentUseCase) *CommentController {
+	return &CommentController{commentUseCase: commentUseCase}
+}
+
+// ListComments 获取页面评论
+// GET /api/pages/:pageId/comments?componentId=xxx
+// componentId 可选，缺省返回整个页面的评论（按创建时间升序）
+func (cc *CommentController) ListComments(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	comments, err := cc.commentUseCase.List(pageID, userID.(string), c.Query("componentId"))
+	if err != nil {
+		writeCommentError(c, err)
+		return
+	}
+
+	payloads := make([]ws.CommentPayload, 0, len(comments))
+	for _, comment := range comments {
+		payloads = append(payloads, usecase.CommentPayload(comment))
+	}
+	c.JSON(http.StatusOK, CommentListResponse{PageID: pageID, Comments: payloads})
+}
+
+// CreateComment 发表评论或回复
+// POST /api/pages/:pageId/comments
+// 请求体: { "componentId": "xxx", "text": "..." } 或 { "parentId": 1, "text": "..." }
+// 房间在线时广播 comment-added
+func (cc *CommentController) CreateComment(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	var req CreateCommentRequest
+	if !bindJSON(c, &req, "text 不能为空") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	comment, err := cc.commentUseCase.Create(pageID, req.ComponentID, userID.(string), req.Text, req.ParentID)
+	if err != nil {
+		writeCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, usecase.CommentPayload(comment))
+}
+
+// UpdateComment 修改评论内容，只有作者可以修改
+// PUT /api/pages/:pageId/comments/:commentId
+// 请求体: { "text": "..." }
+func (cc *CommentController) UpdateComment(c *gin.Context) {
+	pageID, commentID, ok := commentParams(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateCommentRequest
+	if !bindJSON(c, &req, "text 不能为空") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	comment, err := cc.commentUseCase.UpdateText(pageID, commentID, userID.(string), req.Text)
+	if err != nil {
+		writeCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, usecase.CommentPayload(comment))
+}
+
+// ResolveComment 解决或重新打开线程
+// PUT /api/pages/:pageId/comments/:commentId/resolve
+// 请求体: { "resolved": true }，commentId 为回复时作用于其所属线程
+func (cc *CommentController) ResolveComment(c *gin.Context) {
+	pageID, commentID, ok := commentParams(c)
+	if !ok {
+		return
+	}
+
+	var req ResolveCommentRequest
+	if !bindJSON(c, &req, "resolved 不能为空") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	comment, err := cc.commentUseCase.SetResolved(pageID, commentID, userID.(string), *req.Resolved)
+	if err != nil {
+		writeCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, usecase.CommentPayload(comment))
+}
+
+// DeleteComment 删除评论，作者或页面所有者可以删除
+// DELETE /api/pages/:pageId/comments/:commentId
+func (cc *CommentController) DeleteComment(c *gin.Context) {
+	pageID, commentID, ok := commentParams(c)
+	if !ok {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	if err := cc.commentUseCase.Delete(pageID, commentID, userID.(string), middleware.PageRole(c)); err != nil {
+		writeCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "评论已删除", PageID: pageID})
+}
+
+// commentParams 解析 pageId 和 commentId 路径参数，失败时已写入 400 响应
+func commentParams(c *gin.Context) (string, uint, bool) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return "", 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("commentId"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "commentId 必须为正整数"})
+		return "", 0, false
+	}
+	return pageID, uint(id), true
+}
+
+// writeCommentError 将评论业务错误映射为 HTTP 响应
+func writeCommentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domainErrors.ErrPageNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+	case errors.Is(err, domainErrors.ErrCommentNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "评论不存在"})
+	case errors.Is(err, domainErrors.ErrInvalidComment):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "评论内容不能为空且不超过 2000 字，新评论需指定 componentId"})
+	case errors.Is(err, domainErrors.ErrUnauthorized):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "无权限操作该评论"})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"lowercode-go-server/api/middleware"
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConflictBackupResponse 冲突备份响应结构，列表中不含 schema
+type ConflictBackupResponse struct {
+	ID          uint            `json:"id"`
+	PageID      string          `json:"pageId"`
+	UserID      string          `json:"userId"`
+	UserName    string          `json:"userName"`
+	BaseVersion int64           `json:"baseVersion"`
+	Reason      string          `json:"reason"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+// ConflictBackupListResponse 冲突备份列表响应结构
+type ConflictBackupListResponse struct {
+	PageID  string                   `json:"pageId"`
+	Backups []ConflictBackupResponse `json:"backups"`
+}
+
+// ConflictBackupController 冲突备份 HTTP 控制器
+type ConflictBackupController struct {
+	backups *usecase.ConflictBackupUseCase
+}
+
+// NewConflictBackupController 创建 ConflictBackupController 实例
+func NewConflictBackupController(backups *usecase.ConflictBackupUseCase) *ConflictBackupController {
+	return &ConflictBackupController{backups: backups}
+}
+
+// ListConflictBackups 获取页面的冲突备份列表（不含 Schema）
+// GET /api/pages/:pageId/conflict-backups
+func (bc *ConflictBackupController) ListConflictBackups(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	backups, err := bc.backups.List(pageID, userID.(string))
+	if err != nil {
+		writeConflictBackupError(c, err)
+		return
+	}
+
+	resp := ConflictBackupListResponse{PageID: pageID, Backups: make([]ConflictBackupResponse, 0, len(backups))}
+	for _, backup := range backups {
+		resp.Backups = append(resp.Backups, toConflictBackupResponse(backup))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetConflictBackup 获取一条冲突备份，包含上传时的完整 Schema
+// GET /api/pages/:pageId/conflict-backups/:backupId
+func (bc *ConflictBackupController) GetConflictBackup(c *gin.Context) {
+	pageID, backupID, ok := conflictBackupParams(c)
+	if !ok {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	backup, err := bc.backups.Get(pageID, userID.(string), backupID)
+	if err != nil {
+		writeConflictBackupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toConflictBackupResponse(backup))
+}
+
+// DeleteConflictBackup 删除冲突备份，上传者本人或页面所有者可以删除
+// DELETE /api/pages/:pageId/conflict-backups/:backupId
+func (bc *ConflictBackupController) DeleteConflictBackup(c *gin.Context) {
+	pageID, backupID, ok := conflictBackupParams(c)
+	if !ok {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	if err := bc.backups.Delete(pageID, userID.(string), backupID); err != nil {
+		writeConflictBackupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "冲突备份已删除", PageID: pageID})
+}
+
+// toConflictBackupResponse 构造冲突备份响应，Schema 为空时省略
+func toConflictBackupResponse(backup *entity.ConflictBackup) ConflictBackupResponse {
+	resp := ConflictBackupResponse{
+		ID:          backup.ID,
+		PageID:      backup.PageID,
+		UserID:      backup.UserID,
+		UserName:    backup.UserName,
+		BaseVersion: backup.BaseVersion,
+		Reason:      backup.Reason,
+		CreatedAt:   backup.CreatedAt,
+	}
+	if len(backup.Schema) > 0 {
+		resp.Schema = json.RawMessage(backup.Schema)
+	}
+	return resp
+}
+
+// conflictBackupParams 解析 pageId 和 backupId 路径参数，失败时已写入 400 响应
+func conflictBackupParams(c *gin.Context) (string, uint, bool) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return "", 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("backupId"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "backupId 必须为正整数"})
+		return "", 0, false
+	}
+	return pageID, uint(id), true
+}
+
+// writeConflictBackupError 将冲突备份业务错误映射为 HTTP 响应
+func writeConflictBackupError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domainErrors.ErrPageNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+	case errors.Is(err, domainErrors.ErrConflictBackupNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "冲突备份不存在"})
+	case errors.Is(err, domainErrors.ErrUnauthorized):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "无权限操作该冲突备份"})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"net/http"
+
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConsistencyController 数据一致性巡检 HTTP 控制器
+type ConsistencyController struct {
+	consistencyUseCase *usecase.ConsistencyUseCase
+}
+
+// NewConsistencyController 创建 ConsistencyController 实例
+func NewConsistencyController(consistencyUseCase *usecase.ConsistencyUseCase) *ConsistencyController {
+	return &ConsistencyController{consistencyUseCase: consistencyUseCase}
+}
+
+// GetReport 获取最近一次巡检报告
+// GET /ops/consistency
+func (cc *ConsistencyController) GetReport(c *gin.Context) {
+	report := cc.consistencyUseCase.LastReport()
+	if report == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "尚未执行过巡检"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// RunCheck 立即执行一次巡检并返回报告
+// POST /ops/consistency/run
+func (cc *ConsistencyController) RunCheck(c *gin.Context) {
+	report, err := cc.consistencyUseCase.Run()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
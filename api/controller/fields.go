@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fieldSet ?fields= 指定返回的顶层 JSON 字段
+type fieldSet map[string]bool
+
+// parseFields 解析 ?fields=pageId,version，字段名必须是 T 声明的 JSON 字段。
+// 未传 fields 时返回 nil，表示返回全部字段；包含未知字段时写入 400 并返回 false。
+func parseFields[T any](c *gin.Context) (fieldSet, bool) {
+	raw := c.Query("fields")
+	if raw == "" {
+		return nil, true
+	}
+
+	known := jsonFieldNames(reflect.TypeOf((*T)(nil)).Elem())
+	fields := make(fieldSet)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "fields 包含未知字段", Field: name})
+			return nil, false
+		}
+		fields[name] = true
+	}
+	if len(fields) == 0 {
+		return nil, true
+	}
+	return fields, true
+}
+
+// project 只保留 v 序列化后 fields 中的顶层字段，fields 为 nil 时原样返回 v
+func project(v interface{}, fields fieldSet) (interface{}, error) {
+	if fields == nil {
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]json.RawMessage, len(fields))
+	for name := range fields {
+		if value, ok := all[name]; ok {
+			selected[name] = value
+		}
+	}
+	return selected, nil
+}
+
+// projectEach 对列表中的每一项执行 project
+func projectEach[T any](items []T, fields fieldSet) (interface{}, error) {
+	if fields == nil {
+		return items, nil
+	}
+	out := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		projected, err := project(item, fields)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, projected)
+	}
+	return out, nil
+}
+
+// jsonFieldNames 返回结构体类型序列化后的顶层 JSON 字段名
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
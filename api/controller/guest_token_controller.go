@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateGuestTokenRequest 签发临时访客 Token 请求结构，请求体可省略
+type CreateGuestTokenRequest struct {
+	Role             string `json:"role"`             // editor 或 viewer，省略为 viewer
+	ExpiresInMinutes int    `json:"expiresInMinutes"` // 有效期（分钟），0 或省略为 2 小时，最长 24 小时
+}
+
+// GuestTokenResponse 临时访客 Token 响应结构
+type GuestTokenResponse struct {
+	usecase.GuestGrant
+	Token string `json:"token"` // 连接 WebSocket 时作为 guestToken 参数
+}
+
+// GuestTokenController 临时访客 Token HTTP 控制器
+type GuestTokenController struct {
+	guestTokens *usecase.GuestTokenUseCase
+}
+
+// NewGuestTokenController 创建 GuestTokenController 实例
+func NewGuestTokenController(guestTokens *usecase.GuestTokenUseCase) *GuestTokenController {
+	return &GuestTokenController{guestTokens: guestTokens}
+}
+
+// CreateGuestToken 签发协同演示用的临时访客 Token，持有者无需登录即可以指定角色加入协同房间；
+// 路由上的 RequirePageRole 已确认调用者是所有者
+// POST /api/pages/:pageId/guest-token
+// 请求体可选: { "role": "editor", "expiresInMinutes": 30 }
+func (gc *GuestTokenController) CreateGuestToken(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	var req CreateGuestTokenRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req, "请求参数错误") {
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInMinutes) * time.Minute
+	grant, token, err := gc.guestTokens.Mint(pageID, req.Role, ttl)
+	if err != nil {
+		if errors.Is(err, domainErrors.ErrInvalidGuestTokenRequest) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "role 需为 editor 或 viewer，有效期需在 1 ~ 1440 分钟之间"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, GuestTokenResponse{GuestGrant: *grant, Token: token})
+}
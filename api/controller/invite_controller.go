@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"lowercode-go-server/api/middleware"
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateInviteRequest 邀请协作者请求结构
+type CreateInviteRequest struct {
+	Email string `json:"email" binding:"required"`
+	Role  string `json:"role" binding:"required"` // editor 或 viewer
+}
+
+// InviteResponse 协作邀请响应结构
+type InviteResponse struct {
+	ID         uint       `json:"id"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	Status     string     `json:"status"` // pending / accepted / expired
+	InvitedBy  string     `json:"invitedBy"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	AcceptedBy string     `json:"acceptedBy,omitempty"`
+	AcceptedAt *time.Time `json:"acceptedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// InviteListResponse 协作邀请列表响应结构
+type InviteListResponse struct {
+	PageID  string           `json:"pageId"`
+	Invites []InviteResponse `json:"invites"`
+}
+
+// InviteController 协作邀请 HTTP 控制器
+type InviteController struct {
+	invites *usecase.InviteUseCase
+}
+
+// NewInviteController 创建 InviteController 实例
+func NewInviteController(invites *usecase.InviteUseCase) *InviteController {
+	return &InviteController{invites: invites}
+}
+
+// CreateInvite 通过邮箱邀请协作者，邮箱已注册时直接添加为协作者；路由上的 RequirePageRole 已确认调用者是所有者
+// POST /api/pages/:pageId/invites
+// 请求体: { "email": "someone@example.com", "role": "editor" }
+func (ic *InviteController) CreateInvite(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	var req CreateInviteRequest
+	if !bindJSON(c, &req, "email 和 role 不能为空") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	invite, err := ic.invites.Create(pageID, userID.(string), req.Email, req.Role)
+	if err != nil {
+		writeInviteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toInviteResponse(invite, time.Now()))
+}
+
+// ListInvites 获取页面的协作邀请（路由上已限定为所有者）
+// GET /api/pages/:pageId/invites
+func (ic *InviteController) ListInvites(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	invites, err := ic.invites.List(pageID)
+	if err != nil {
+		writeInviteError(c, err)
+		return
+	}
+
+	now := time.Now()
+	resp := InviteListResponse{PageID: pageID, Invites: make([]InviteResponse, 0, len(invites))}
+	for _, invite := range invites {
+		resp.Invites = append(resp.Invites, toInviteResponse(invite, now))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RevokeInvite 撤销协作邀请，已接受的邀请撤销后不影响协作者（路由上已限定为所有者）
+// DELETE /api/pages/:pageId/invites/:inviteId
+func (ic *InviteController) RevokeInvite(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("inviteId"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "inviteId 必须为正整数"})
+		return
+	}
+
+	if err := ic.invites.Revoke(pageID, uint(id)); err != nil {
+		writeInviteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "邀请已撤销", PageID: pageID})
+}
+
+// toInviteResponse 转换为响应结构，now 用于判断邀请是否过期
+func toInviteResponse(invite *entity.PageInvite, now time.Time) InviteResponse {
+	status := "pending"
+	switch {
+	case invite.AcceptedAt != nil:
+		status = "accepted"
+	case !invite.Pending(now):
+		status = "expired"
+	}
+	return InviteResponse{
+		ID:         invite.ID,
+		Email:      invite.Email,
+		Role:       invite.Role,
+		Status:     status,
+		InvitedBy:  invite.InvitedBy,
+		ExpiresAt:  invite.ExpiresAt,
+		AcceptedBy: invite.AcceptedBy,
+		AcceptedAt: invite.AcceptedAt,
+		CreatedAt:  invite.CreatedAt,
+	}
+}
+
+// writeInviteError 将协作邀请业务错误映射为 HTTP 响应
+func writeInviteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domainErrors.ErrPageNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+	case errors.Is(err, domainErrors.ErrInviteNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "邀请不存在"})
+	case errors.Is(err, domainErrors.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "角色无效", Details: err.Error(), Field: "role"})
+	case errors.Is(err, domainErrors.ErrInvalidInvite):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "邮箱地址无效", Field: "email"})
+	case errors.Is(err, domainErrors.ErrInviteLimit):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "待接受的邀请数量已达上限，请先撤销不再需要的邀请"})
+	case errors.Is(err, domainErrors.ErrMailDelivery):
+		c.JSON(http.StatusBadGateway, ErrorResponse{Error: "邀请已保存，但邮件发送失败，请稍后重新邀请以重发", Details: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
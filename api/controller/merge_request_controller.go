@@ -0,0 +1,394 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"lowercode-go-server/api/middleware"
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/jsondiff"
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenMergeRequestRequest 发起合并请求请求结构
+type OpenMergeRequestRequest struct {
+	BranchID    string `json:"branchId" binding:"required"`
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+}
+
+// MergeMergeRequestRequest 合并请求结构，strategy 为空时存在冲突则拒绝合并
+type MergeMergeRequestRequest struct {
+	Strategy string          `json:"strategy"`          // ours / theirs / manual
+	Patch    json.RawMessage `json:"patch,omitempty"`   // manual 时必填：基于页面 version 版本的 RFC 6902 Patch
+	Version  int64           `json:"version,omitempty"` // manual 时必填：patch 对应的页面版本
+}
+
+// MergeRequestCommentRequest 合并请求讨论请求结构
+type MergeRequestCommentRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// MergeRequestResponse 合并请求响应结构
+type MergeRequestResponse struct {
+	ID            uint       `json:"id"`
+	PageID        string     `json:"pageId"`
+	BranchID      string     `json:"branchId"`
+	BranchPageID  string     `json:"branchPageId"`
+	BranchName    string     `json:"branchName"`
+	AuthorID      string     `json:"authorId"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	Status        string     `json:"status"` // open / merged / closed
+	Strategy      string     `json:"strategy,omitempty"`
+	MergedBy      string     `json:"mergedBy,omitempty"`
+	BaseVersion   int64      `json:"baseVersion,omitempty"`   // 合并前页面的版本
+	MergedVersion int64      `json:"mergedVersion,omitempty"` // 合并产生的页面版本
+	MergedAt      *time.Time `json:"mergedAt,omitempty"`
+	ClosedAt      *time.Time `json:"closedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// MergeRequestListResponse 合并请求列表响应结构
+type MergeRequestListResponse struct {
+	PageID        string                 `json:"pageId"`
+	MergeRequests []MergeRequestResponse `json:"mergeRequests"`
+}
+
+// MergePreviewResponse 合并请求差异预览响应结构
+type MergePreviewResponse struct {
+	PageVersion   int64                `json:"pageVersion"`
+	BranchVersion int64                `json:"branchVersion"`
+	Changes       []string             `json:"changes"`
+	Conflicts     []string             `json:"conflicts"`
+	Diff          []jsondiff.Operation `json:"diff"` // 合并时将应用到页面的 Patch
+}
+
+// MergeRequestDetailResponse 合并请求详情响应结构，仅打开的合并请求带差异预览
+type MergeRequestDetailResponse struct {
+	MergeRequest MergeRequestResponse  `json:"mergeRequest"`
+	Preview      *MergePreviewResponse `json:"preview,omitempty"`
+}
+
+// MergeRequestMergeResponse 合并结果响应结构
+type MergeRequestMergeResponse struct {
+	MergeRequest MergeRequestResponse `json:"mergeRequest"`
+	Result       BranchMergeResponse  `json:"result"`
+}
+
+// MergeRequestCommentResponse 合并请求讨论响应结构
+type MergeRequestCommentResponse struct {
+	ID        uint      `json:"id"`
+	AuthorID  string    `json:"authorId"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MergeRequestCommentListResponse 合并请求讨论列表响应结构
+type MergeRequestCommentListResponse struct {
+	MergeRequestID uint                          `json:"mergeRequestId"`
+	Comments       []MergeRequestCommentResponse `json:"comments"`
+}
+
+// MergeRequestController 分支合并请求 HTTP 控制器
+type MergeRequestController struct {
+	mrs *usecase.MergeRequestUseCase
+}
+
+// NewMergeRequestController 创建 MergeRequestController 实例
+func NewMergeRequestController(mrs *usecase.MergeRequestUseCase) *MergeRequestController {
+	return &MergeRequestController{mrs: mrs}
+}
+
+// OpenMergeRequest 为自己的草稿分支发起合并请求，返回合并请求和差异预览
+// POST /api/pages/:pageId/merge-requests
+// 请求体: { "branchId": "...", "title": "新版首页", "description": "..." }
+func (mc *MergeRequestController) OpenMergeRequest(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	var req OpenMergeRequestRequest
+	if !bindJSON(c, &req, "branchId 和 title 不能为空") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	mr, preview, err := mc.mrs.Open(pageID, req.BranchID, userID.(string), req.Title, req.Description)
+	if err != nil {
+		writeMergeRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, MergeRequestDetailResponse{
+		MergeRequest: toMergeRequestResponse(mr),
+		Preview:      toMergePreviewResponse(preview),
+	})
+}
+
+// ListMergeRequests 获取页面的合并请求
+// GET /api/pages/:pageId/merge-requests?status=open
+func (mc *MergeRequestController) ListMergeRequests(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	mrs, err := mc.mrs.List(pageID, userID.(string), c.Query("status"))
+	if err != nil {
+		writeMergeRequestError(c, err)
+		return
+	}
+
+	resp := MergeRequestListResponse{PageID: pageID, MergeRequests: make([]MergeRequestResponse, 0, len(mrs))}
+	for _, mr := range mrs {
+		resp.MergeRequests = append(resp.MergeRequests, toMergeRequestResponse(mr))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetMergeRequest 获取合并请求，仍打开时附带差异预览
+// GET /api/pages/:pageId/merge-requests/:mrId?strategy=theirs
+func (mc *MergeRequestController) GetMergeRequest(c *gin.Context) {
+	pageID, id, ok := mergeRequestParams(c)
+	if !ok {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	mr, preview, err := mc.mrs.Get(pageID, id, userID.(string), c.Query("strategy"))
+	if err != nil {
+		writeMergeRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MergeRequestDetailResponse{
+		MergeRequest: toMergeRequestResponse(mr),
+		Preview:      toMergePreviewResponse(preview),
+	})
+}
+
+// MergeMergeRequest 按策略合并，合并成功后分支被删除；未指定策略且存在冲突时返回 409 和冲突位置
+// POST /api/pages/:pageId/merge-requests/:mrId/merge
+// 请求体（可选）: { "strategy": "theirs" } 或 { "strategy": "manual", "patch": [...], "version": 45 }
+func (mc *MergeRequestController) MergeMergeRequest(c *gin.Context) {
+	pageID, id, ok := mergeRequestParams(c)
+	if !ok {
+		return
+	}
+
+	var req MergeMergeRequestRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req, "请求参数错误") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	mr, merged, err := mc.mrs.Merge(pageID, id, userID.(string), req.Strategy, req.Patch, req.Version)
+	if errors.Is(err, domainErrors.ErrBranchConflict) {
+		c.JSON(http.StatusConflict, BranchConflictResponse{
+			Error:     "分支与页面修改了同一处，请选择合并策略",
+			Conflicts: merged.Conflicts,
+		})
+		return
+	}
+	if err != nil {
+		writeMergeRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MergeRequestMergeResponse{
+		MergeRequest: toMergeRequestResponse(mr),
+		Result:       toBranchMergeResponse(merged),
+	})
+}
+
+// CloseMergeRequest 关闭合并请求而不合并（发起者或页面所有者），分支保留
+// POST /api/pages/:pageId/merge-requests/:mrId/close
+func (mc *MergeRequestController) CloseMergeRequest(c *gin.Context) {
+	pageID, id, ok := mergeRequestParams(c)
+	if !ok {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	mr, err := mc.mrs.Close(pageID, id, userID.(string))
+	if err != nil {
+		writeMergeRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toMergeRequestResponse(mr))
+}
+
+// ListMergeRequestComments 获取合并请求的讨论
+// GET /api/pages/:pageId/merge-requests/:mrId/comments
+func (mc *MergeRequestController) ListMergeRequestComments(c *gin.Context) {
+	pageID, id, ok := mergeRequestParams(c)
+	if !ok {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	comments, err := mc.mrs.Comments(pageID, id, userID.(string))
+	if err != nil {
+		writeMergeRequestError(c, err)
+		return
+	}
+
+	resp := MergeRequestCommentListResponse{MergeRequestID: id, Comments: make([]MergeRequestCommentResponse, 0, len(comments))}
+	for _, comment := range comments {
+		resp.Comments = append(resp.Comments, toMergeRequestCommentResponse(comment))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateMergeRequestComment 在合并请求下发表讨论
+// POST /api/pages/:pageId/merge-requests/:mrId/comments
+// 请求体: { "text": "按钮颜色再确认一下" }
+func (mc *MergeRequestController) CreateMergeRequestComment(c *gin.Context) {
+	pageID, id, ok := mergeRequestParams(c)
+	if !ok {
+		return
+	}
+
+	var req MergeRequestCommentRequest
+	if !bindJSON(c, &req, "text 不能为空") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	comment, err := mc.mrs.Comment(pageID, id, userID.(string), req.Text)
+	if err != nil {
+		writeMergeRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toMergeRequestCommentResponse(comment))
+}
+
+// toMergeRequestResponse 构造合并请求响应
+func toMergeRequestResponse(mr *entity.MergeRequest) MergeRequestResponse {
+	return MergeRequestResponse{
+		ID:            mr.ID,
+		PageID:        mr.PageID,
+		BranchID:      mr.BranchID,
+		BranchPageID:  mr.BranchPageID,
+		BranchName:    mr.BranchName,
+		AuthorID:      mr.AuthorID,
+		Title:         mr.Title,
+		Description:   mr.Description,
+		Status:        mr.Status,
+		Strategy:      mr.Strategy,
+		MergedBy:      mr.MergedBy,
+		BaseVersion:   mr.BaseVersion,
+		MergedVersion: mr.MergedVersion,
+		MergedAt:      mr.MergedAt,
+		ClosedAt:      mr.ClosedAt,
+		CreatedAt:     mr.CreatedAt,
+		UpdatedAt:     mr.UpdatedAt,
+	}
+}
+
+// toMergePreviewResponse 构造差异预览响应，preview 为 nil 时返回 nil
+func toMergePreviewResponse(preview *usecase.MergePreview) *MergePreviewResponse {
+	if preview == nil {
+		return nil
+	}
+	return &MergePreviewResponse{
+		PageVersion:   preview.PageVersion,
+		BranchVersion: preview.BranchVersion,
+		Changes:       preview.Changes,
+		Conflicts:     preview.Conflicts,
+		Diff:          preview.Diff,
+	}
+}
+
+// toMergeRequestCommentResponse 构造讨论响应
+func toMergeRequestCommentResponse(comment *entity.MergeRequestComment) MergeRequestCommentResponse {
+	return MergeRequestCommentResponse{
+		ID:        comment.ID,
+		AuthorID:  comment.AuthorID,
+		Text:      comment.Text,
+		CreatedAt: comment.CreatedAt,
+	}
+}
+
+// mergeRequestParams 解析 pageId 和 mrId 路径参数，失败时已写入 400 响应
+func mergeRequestParams(c *gin.Context) (string, uint, bool) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return "", 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("mrId"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "mrId 必须为正整数"})
+		return "", 0, false
+	}
+	return pageID, uint(id), true
+}
+
+// writeMergeRequestError 将合并请求业务错误映射为 HTTP 响应
+func writeMergeRequestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domainErrors.ErrMergeRequestNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "合并请求不存在"})
+	case errors.Is(err, domainErrors.ErrInvalidMergeRequest):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "合并请求参数无效", Details: err.Error()})
+	case errors.Is(err, domainErrors.ErrMergeRequestExists):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "该分支已有打开的合并请求"})
+	case errors.Is(err, domainErrors.ErrMergeRequestNotOpen):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "合并请求已合并或已关闭"})
+	case errors.Is(err, domainErrors.ErrOptimisticLock):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "页面已更新，请基于最新版本重新解决冲突"})
+	default:
+		writeBranchError(c, err)
+	}
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ModerationFlagResponse 内容审核记录响应结构
+type ModerationFlagResponse struct {
+	ID         uint       `json:"id"`
+	PageID     string     `json:"pageId"`
+	UserID     string     `json:"userId"`
+	UserName   string     `json:"userName"`
+	Guest      bool       `json:"guest"`
+	Source     string     `json:"source"`         // chat / prop
+	Path       string     `json:"path,omitempty"` // 属性的 JSON Pointer，聊天为空
+	Text       string     `json:"text"`           // 超过 500 字符时截断
+	Reasons    []string   `json:"reasons"`        // 命中的词或审核服务返回的类别
+	Action     string     `json:"action"`         // rejected / allowed
+	ReviewedAt *time.Time `json:"reviewedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// ModerationFlagListResponse 内容审核记录列表响应结构
+type ModerationFlagListResponse struct {
+	Flags []ModerationFlagResponse `json:"flags"`
+}
+
+// ModerationController 内容审核记录 HTTP 控制器（运维接口）
+type ModerationController struct {
+	moderation *usecase.ModerationUseCase
+}
+
+// NewModerationController 创建 ModerationController 实例
+func NewModerationController(moderation *usecase.ModerationUseCase) *ModerationController {
+	return &ModerationController{moderation: moderation}
+}
+
+// ListFlags 按时间倒序查询审核记录，before 为上一页最后一条记录的 id
+// GET /api/admin/moderation/flags?pageId=&pending=true&before=&limit=50
+func (mc *ModerationController) ListFlags(c *gin.Context) {
+	filter := repository.ModerationFlagFilter{PageID: c.Query("pageId")}
+
+	if raw := c.Query("pending"); raw != "" {
+		pending, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pending 必须为 true 或 false"})
+			return
+		}
+		filter.Pending = pending
+	}
+	if raw := c.Query("before"); raw != "" {
+		before, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || before == 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "before 必须为正整数"})
+			return
+		}
+		filter.BeforeID = uint(before)
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "limit 必须为正整数"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	flags, err := mc.moderation.ListFlags(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	resp := ModerationFlagListResponse{Flags: make([]ModerationFlagResponse, 0, len(flags))}
+	for _, flag := range flags {
+		resp.Flags = append(resp.Flags, toModerationFlagResponse(flag))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ReviewFlag 把审核记录标记为已复核，重复标记不改变复核时间
+// POST /api/admin/moderation/flags/:flagId/review
+func (mc *ModerationController) ReviewFlag(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("flagId"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "flagId 必须为正整数"})
+		return
+	}
+
+	flag, err := mc.moderation.ReviewFlag(uint(id))
+	if err != nil {
+		if errors.Is(err, domainErrors.ErrModerationFlagNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "审核记录不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toModerationFlagResponse(flag))
+}
+
+// toModerationFlagResponse 转换为响应结构
+func toModerationFlagResponse(flag *entity.ModerationFlag) ModerationFlagResponse {
+	return ModerationFlagResponse{
+		ID:         flag.ID,
+		PageID:     flag.PageID,
+		UserID:     flag.UserID,
+		UserName:   flag.UserName,
+		Guest:      flag.Guest,
+		Source:     flag.Source,
+		Path:       flag.Path,
+		Text:       flag.Text,
+		Reasons:    flag.ReasonList(),
+		Action:     flag.Action,
+		ReviewedAt: flag.ReviewedAt,
+		CreatedAt:  flag.CreatedAt,
+	}
+}
@@ -1,12 +1,18 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"lowercode-go-server/api/middleware"
+	"lowercode-go-server/domain/entity"
 	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/bulkops"
+	"lowercode-go-server/internal/ws"
 	"lowercode-go-server/usecase"
 
 	"github.com/gin-gonic/gin"
@@ -21,10 +27,49 @@ type PageResponse struct {
 	Version int64       `json:"version"`
 }
 
+// PublishedPageResponse 发布副本响应结构
+type PublishedPageResponse struct {
+	PageID      string      `json:"pageId"`
+	Schema      interface{} `json:"schema"`
+	Version     int64       `json:"version"`
+	PublishedAt time.Time   `json:"publishedAt"`
+}
+
+// PresenceResponse 在线用户响应结构
+type PresenceResponse struct {
+	PageID string        `json:"pageId"`
+	Count  int           `json:"count"`
+	Users  []ws.UserInfo `json:"users"`
+}
+
+// PageSummary 页面列表项，不含 Schema
+type PageSummary struct {
+	PageID           string    `json:"pageId"`
+	Version          int64     `json:"version"`
+	CreatorID        string    `json:"creatorId"`
+	OrgID            string    `json:"orgId,omitempty"` // 为空时为个人页面
+	PublishedVersion int64     `json:"publishedVersion"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// PageListResponse 页面列表响应结构
+type PageListResponse struct {
+	OrgID string        `json:"orgId,omitempty"` // 请求激活的组织，为空时列出的是个人页面
+	Pages []PageSummary `json:"pages"`
+}
+
 // ErrorResponse 错误响应结构
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Details string `json:"details,omitempty"`
+	Field   string `json:"field,omitempty"` // 请求体包含未知字段时为该字段名
+}
+
+// DeleteConfirmResponse 删除页面需要确认时的响应结构
+type DeleteConfirmResponse struct {
+	Error  string                `json:"error"`
+	Impact *usecase.DeleteImpact `json:"impact"`
 }
 
 // MessageResponse 消息响应结构
@@ -38,16 +83,61 @@ type MessageResponse struct {
 // PageController 页面 HTTP 控制器
 type PageController struct {
 	pageUseCase *usecase.PageUseCase
+	shareLinks  *usecase.ShareLinkUseCase
 }
 
 // NewPageController 创建 PageController 实例
-func NewPageController(pageUseCase *usecase.PageUseCase) *PageController {
-	return &PageController{pageUseCase: pageUseCase}
+func NewPageController(pageUseCase *usecase.PageUseCase, shareLinks *usecase.ShareLinkUseCase) *PageController {
+	return &PageController{pageUseCase: pageUseCase, shareLinks: shareLinks}
 }
 
-// GetPage 获取页面
-// GET /api/pages/:pageId
+// ListPages 获取页面列表，按最近更新时间倒序
+// GET /api/pages?limit=50
+// Token 激活了 Clerk 组织时返回该组织的页面，否则返回当前用户的个人页面
+func (pc *PageController) ListPages(c *gin.Context) {
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "limit 必须为正整数"})
+			return
+		}
+		limit = parsed
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	org := orgClaims(c)
+	pages, err := pc.pageUseCase.ListPages(userID.(string), org, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	resp := PageListResponse{OrgID: org.OrgID, Pages: make([]PageSummary, 0, len(pages))}
+	for _, page := range pages {
+		resp.Pages = append(resp.Pages, PageSummary{
+			PageID:           page.PageID,
+			Version:          page.Version,
+			CreatorID:        page.CreatorID,
+			OrgID:            page.OrgID,
+			PublishedVersion: page.PublishedVersion,
+			CreatedAt:        page.CreatedAt,
+			UpdatedAt:        page.UpdatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetPage 获取页面（创建者、协作者、组织成员或分享链接持有者）
+// GET /api/pages/:pageId?fields=pageId,version
 // 支持 Hub 内存优先读取，回退到数据库
+// fields 可选，只返回指定的字段，不需要 schema 的调用方可省去大部分响应体积
+// 携带 X-Share-Token 请求头或 shareToken 参数时免登录只读访问
 func (pc *PageController) GetPage(c *gin.Context) {
 	pageID := c.Param("pageId")
 	if pageID == "" {
@@ -55,9 +145,34 @@ func (pc *PageController) GetPage(c *gin.Context) {
 		return
 	}
 
-	page, err := pc.pageUseCase.GetPage(pageID)
+	fields, ok := parseFields[PageResponse](c)
+	if !ok {
+		return
+	}
+
+	var page *entity.Page
+	var err error
+	if shareToken, shared := c.Get(middleware.ContextKeyShareToken); shared {
+		page, err = pc.shareLinks.GetSharedPage(pageID, shareToken.(string))
+	} else {
+		userID, exists := c.Get(middleware.ContextKeyUserID)
+		if !exists {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+			return
+		}
+		page, err = pc.pageUseCase.GetPage(pageID, userID.(string))
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		switch {
+		case errors.Is(err, domainErrors.ErrShareLinkInvalid):
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "分享链接无效、已过期或已被撤销"})
+		case errors.Is(err, domainErrors.ErrPageNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+		case errors.Is(err, domainErrors.ErrUnauthorized):
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "无权访问此页面"})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
 		return
 	}
 
@@ -66,13 +181,217 @@ func (pc *PageController) GetPage(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, PageResponse{
+	resp, err := project(PageResponse{
 		PageID:  page.PageID,
 		Schema:  page.Schema,
 		Version: page.Version,
+	}, fields)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetPresence 获取页面在线用户
+// GET /api/pages/:pageId/presence
+// 只读取内存中的房间，无人编辑时返回空列表，供列表页展示"N 人正在编辑"
+func (pc *PageController) GetPresence(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	users, err := pc.pageUseCase.GetPresence(pageID, userID.(string))
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErrors.ErrPageNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+		case errors.Is(err, domainErrors.ErrUnauthorized):
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "无权访问此页面"})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, PresenceResponse{
+		PageID: pageID,
+		Count:  len(users),
+		Users:  users,
+	})
+}
+
+// PollPage 长轮询页面的 Patch，供 WebSocket 被网络策略拦截的客户端降级协同
+// GET /api/pages/:pageId/poll?sinceVersion=42
+// sinceVersion 之后有新版本时立即返回 { "version": 45, "patches": [...] }，patches 依次对应 43-45；
+// 等待 ws.LongPollTimeout 仍无新版本时返回 204，客户端应立即重新发起轮询。
+// 无法提供连续 Patch 时返回 "resync": true，客户端需通过 GET /api/pages/:pageId 重新拉取全量。
+// 修改页面使用 POST /api/pages/:pageId/ops
+func (pc *PageController) PollPage(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	since, err := strconv.ParseInt(c.Query("sinceVersion"), 10, 64)
+	if err != nil || since <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "sinceVersion 必须为正整数"})
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), ws.LongPollTimeout)
+	defer cancel()
+
+	result, err := pc.pageUseCase.PollPage(ctx, pageID, userID.(string), since)
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErrors.ErrPageNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+		case errors.Is(err, domainErrors.ErrUnauthorized):
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "无权访问此页面"})
+		case errors.Is(err, domainErrors.ErrRoomClosing):
+			c.Header("Retry-After", "1")
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "房间正在关闭，请稍后重试"})
+		case errors.Is(err, domainErrors.ErrRoomOwnedElsewhere), errors.Is(err, domainErrors.ErrRoomLockUnavailable):
+			c.Header("Retry-After", "1")
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "页面所在节点正在切换，请稍后重试"})
+		case errors.Is(err, domainErrors.ErrServerShuttingDown):
+			c.Header("Retry-After", "5")
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "服务正在重启，请稍后重试"})
+		case errors.Is(err, domainErrors.ErrServerOverloaded):
+			c.Header("Retry-After", overloadRetryAfter)
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "服务繁忙，请稍后重试"})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	if result == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"pageId":       pageID,
+		"sinceVersion": result.SinceVersion,
+		"version":      result.Version,
+		"patches":      result.Patches,
+		"resync":       result.Resync,
 	})
 }
 
+// KickUser 将用户移出协同房间，路由上的 RequirePageRole 已确认调用者是所有者
+// DELETE /api/pages/:pageId/presence/:userId
+// 目标用户的全部连接收到 KICKED 错误后被断开，其持有的组件锁立即释放
+func (pc *PageController) KickUser(c *gin.Context) {
+	pageID := c.Param("pageId")
+	targetUserID := c.Param("userId")
+	if pageID == "" || targetUserID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 和 userId 不能为空"})
+		return
+	}
+
+	kicked, err := pc.pageUseCase.KickUser(pageID, targetUserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErrors.ErrPageNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+		case errors.Is(err, domainErrors.ErrUserNotInRoom):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "该用户不在协同房间内"})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pageId": pageID, "userId": targetUserID, "connections": kicked})
+}
+
+// BulkOpsRequest 批量操作请求结构
+type BulkOpsRequest struct {
+	Version int64        `json:"version"` // 可选，基准版本号，与当前版本不一致时返回 409
+	Ops     []bulkops.Op `json:"ops" binding:"required"`
+	DryRun  bool         `json:"dryRun"` // 可选，为 true 时只校验并返回将要应用的 Patch，不提交
+}
+
+// RunBulkOps 在服务端执行批量操作（复制子树、批量编号、对齐属性）
+// POST /api/pages/:pageId/ops
+// 请求体: { "version": 42, "ops": [{ "type": "duplicate", "componentId": 100, "count": 5 }, ...] }
+// 整组操作作为一个版本原子应用，并以一条 op-patch 广播给在线用户；
+// dryRun 为 true 时返回相同的结果但不修改页面，响应中带 "dryRun": true
+func (pc *PageController) RunBulkOps(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	var req BulkOpsRequest
+	if !bindJSON(c, &req, "ops 不能为空") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	result, err := pc.pageUseCase.RunBulkOps(pageID, userID.(string), req.Version, req.Ops, req.DryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErrors.ErrPageNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+		case errors.Is(err, domainErrors.ErrUnauthorized):
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "无权编辑此页面"})
+		case errors.Is(err, domainErrors.ErrSessionClosed):
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "当前不在页面的协作时段内，页面只读"})
+		case errors.Is(err, domainErrors.ErrPresentationActive):
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "演示模式下只有演示者可以编辑"})
+		case errors.Is(err, domainErrors.ErrInvalidBulkOps):
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "批量操作无效", Details: err.Error()})
+		case errors.Is(err, domainErrors.ErrOptimisticLock):
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "页面版本已变化，请刷新后重试"})
+		case errors.Is(err, domainErrors.ErrRoomClosing):
+			c.Header("Retry-After", "1")
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "房间正在关闭，请稍后重试"})
+		case errors.Is(err, domainErrors.ErrRoomOwnedElsewhere), errors.Is(err, domainErrors.ErrRoomLockUnavailable):
+			c.Header("Retry-After", "1")
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "页面所在节点正在切换，请稍后重试"})
+		case errors.Is(err, domainErrors.ErrServerShuttingDown):
+			c.Header("Retry-After", "5")
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "服务正在重启，请稍后重试"})
+		case errors.Is(err, domainErrors.ErrServerOverloaded):
+			c.Header("Retry-After", overloadRetryAfter)
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "服务繁忙，请稍后重试"})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	resp := gin.H{"pageId": pageID, "version": result.Version, "patches": result.Patches}
+	if req.DryRun {
+		resp["dryRun"] = true
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
 // CreatePageRequest 创建页面请求结构
 type CreatePageRequest struct {
 	PageID string      `json:"pageId" binding:"required"`
@@ -85,8 +404,7 @@ type CreatePageRequest struct {
 // schema 可选，不传则使用默认空白 schema
 func (pc *PageController) CreatePage(c *gin.Context) {
 	var req CreatePageRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+	if !bindJSON(c, &req, "pageId 不能为空") {
 		return
 	}
 
@@ -107,13 +425,18 @@ func (pc *PageController) CreatePage(c *gin.Context) {
 		}
 	}
 
-	page, err := pc.pageUseCase.CreatePage(req.PageID, userID.(string), schemaBytes)
+	page, err := pc.pageUseCase.CreatePage(req.PageID, userID.(string), orgClaims(c), schemaBytes)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrPageAlreadyExists) {
+		switch {
+		case errors.Is(err, domainErrors.ErrPageAlreadyExists):
 			c.JSON(http.StatusConflict, ErrorResponse{Error: "页面已存在"})
-			return
+		case errors.Is(err, domainErrors.ErrStorageQuotaExceeded):
+			writeQuotaError(c, err)
+		case errors.Is(err, domainErrors.ErrQuotaExceeded):
+			writePageQuotaError(c, err)
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		}
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
 
@@ -124,10 +447,84 @@ func (pc *PageController) CreatePage(c *gin.Context) {
 	})
 }
 
-// DeletePage 删除页面
-// DELETE /api/pages/:pageId
-// 注意：此操作会强制关闭协同编辑房间，踢出所有在线用户
-func (pc *PageController) DeletePage(c *gin.Context) {
+// PublishPage 发布页面，路由上的 RequirePageRole 已确认调用者是所有者
+// POST /api/pages/:pageId/publish
+// 将当前草稿复制为发布副本，协同编辑继续修改草稿
+func (pc *PageController) PublishPage(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	page, err := pc.pageUseCase.PublishPage(pageID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErrors.ErrPageNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+		case errors.Is(err, domainErrors.ErrInvalidSecret):
+			c.JSON(http.StatusUnprocessableEntity, ErrorResponse{Error: "页面中有无法解密的密钥属性，请重新设置后再发布", Details: err.Error()})
+		case errors.Is(err, domainErrors.ErrSecretsDisabled):
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "页面含有密钥属性，但服务端未配置密钥加密，无法发布"})
+		case errors.Is(err, domainErrors.ErrDanglingReferences):
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "页面引用了不存在的页面，请修复后再发布", Details: err.Error()})
+		case errors.Is(err, domainErrors.ErrStorageQuotaExceeded):
+			writeQuotaError(c, err)
+		case errors.Is(err, domainErrors.ErrUsageLimitExceeded):
+			writeUsageLimitError(c, err)
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, PublishedPageResponse{
+		PageID:      page.PageID,
+		Schema:      page.PublishedSchema,
+		Version:     page.PublishedVersion,
+		PublishedAt: *page.PublishedAt,
+	})
+}
+
+// GetReferences 查询页面的出站引用和入站引用
+// GET /api/pages/:pageId/references
+// 出站引用中 dangling 为 true 的引用指向不存在的页面，修复前页面不能发布；
+// 入站引用在来源页面刷盘后更新，只列出用户能读取的来源页面，其余计入 hiddenInbound
+func (pc *PageController) GetReferences(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	refs, err := pc.pageUseCase.References(pageID, userID.(string))
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErrors.ErrPageNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+		case errors.Is(err, domainErrors.ErrUnauthorized):
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "无权访问此页面"})
+		case errors.Is(err, domainErrors.ErrReferencesDisabled):
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "未启用页面引用索引"})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, refs)
+}
+
+// GetUsages 查询页面被哪些其他页面使用（嵌入或链接），供删除前提示
+// GET /api/pages/:pageId/usages
+// 只列出用户能读取的来源页面，其余计入 hiddenPages；草稿分支中的引用不计入
+func (pc *PageController) GetUsages(c *gin.Context) {
 	pageID := c.Param("pageId")
 	if pageID == "" {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
@@ -140,20 +537,378 @@ func (pc *PageController) DeletePage(c *gin.Context) {
 		return
 	}
 
-	if err := pc.pageUseCase.DeletePage(pageID, userID.(string)); err != nil {
+	usages, err := pc.pageUseCase.Usages(pageID, userID.(string))
+	if err != nil {
 		switch {
 		case errors.Is(err, domainErrors.ErrPageNotFound):
 			c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
 		case errors.Is(err, domainErrors.ErrUnauthorized):
-			c.JSON(http.StatusForbidden, ErrorResponse{Error: "无权限删除此页面"})
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "无权访问此页面"})
+		case errors.Is(err, domainErrors.ErrReferencesDisabled):
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "未启用页面引用索引"})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, usages)
+}
+
+// GetPublishedPage 获取页面的发布副本（公开访问，无需登录）
+// GET /public/pages/:pageId?fields=version,publishedAt
+// fields 可选，只返回指定的字段
+func (pc *PageController) GetPublishedPage(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	fields, ok := parseFields[PublishedPageResponse](c)
+	if !ok {
+		return
+	}
+
+	page, err := pc.pageUseCase.GetPublishedPage(pageID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErrors.ErrPageNotFound), errors.Is(err, domainErrors.ErrPageNotPublished):
+			// 未发布的页面对公开访问者等同于不存在
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在或尚未发布"})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	var publishedAt time.Time
+	if page.PublishedAt != nil {
+		publishedAt = *page.PublishedAt
+	}
+	resp, err := project(PublishedPageResponse{
+		PageID:      page.PageID,
+		Schema:      page.PublishedSchema,
+		Version:     page.PublishedVersion,
+		PublishedAt: publishedAt,
+	}, fields)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// SharingRequest 分享设置请求结构
+type SharingRequest struct {
+	LinkEdit *bool `json:"linkEdit" binding:"required"`
+}
+
+// UpdateSharing 更新分享设置，路由上的 RequirePageRole 已确认调用者是所有者
+// PUT /api/pages/:pageId/sharing
+// 请求体: { "linkEdit": true }，开启后持有链接的未登录用户可以以访客身份协同编辑
+func (pc *PageController) UpdateSharing(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	var req SharingRequest
+	if !bindJSON(c, &req, "linkEdit 不能为空") {
+		return
+	}
+
+	if err := pc.pageUseCase.SetLinkEdit(pageID, *req.LinkEdit); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pageId": pageID, "linkEdit": *req.LinkEdit})
+}
+
+// ChatSettingsRequest 聊天设置请求结构
+type ChatSettingsRequest struct {
+	Persist *bool `json:"persist" binding:"required"`
+}
+
+// UpdateChatSettings 更新房间聊天设置，路由上的 RequirePageRole 已确认调用者是所有者
+// PUT /api/pages/:pageId/chat
+// 请求体: { "persist": false }，关闭后聊天只保存在房间内存中，房间关闭即清除
+func (pc *PageController) UpdateChatSettings(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	var req ChatSettingsRequest
+	if !bindJSON(c, &req, "persist 不能为空") {
+		return
+	}
+
+	if err := pc.pageUseCase.SetChatPersistence(pageID, *req.Persist); err != nil {
+		switch {
+		case errors.Is(err, domainErrors.ErrPageNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pageId": pageID, "persist": *req.Persist})
+}
+
+// CollabSettingsRequest 协同设置请求结构，省略的字段保持不变
+type CollabSettingsRequest struct {
+	Cursors              *bool                    `json:"cursors"`
+	Selections           *bool                    `json:"selections"`
+	Chat                 *bool                    `json:"chat"`
+	FlushIntervalSeconds *int                     `json:"flushIntervalSeconds"`
+	FlushThreshold       *int                     `json:"flushThreshold"`
+	Conflict             *entity.ConflictStrategy `json:"conflict"` // strict/rebase/lww/crdt
+}
+
+// GetCollabSettings 获取页面级协同设置
+// GET /api/pages/:pageId/collab-settings
+func (pc *PageController) GetCollabSettings(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	settings, err := pc.pageUseCase.GetCollabSettings(pageID, userID.(string))
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErrors.ErrPageNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+		case errors.Is(err, domainErrors.ErrUnauthorized):
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "无权访问此页面"})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pageId": pageID, "settings": settings})
+}
+
+// UpdateCollabSettings 修改页面级协同设置，路由上的 RequirePageRole 已确认调用者是所有者
+// PUT /api/pages/:pageId/collab-settings
+// 请求体: { "cursors": false, "flushIntervalSeconds": 60 }，省略的字段保持不变，房间在线时立即生效
+func (pc *PageController) UpdateCollabSettings(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	var req CollabSettingsRequest
+	if !bindJSON(c, &req, "请求格式错误") {
+		return
+	}
+
+	settings, err := pc.pageUseCase.UpdateCollabSettings(pageID, usecase.CollabSettingsUpdate{
+		Cursors:              req.Cursors,
+		Selections:           req.Selections,
+		Chat:                 req.Chat,
+		FlushIntervalSeconds: req.FlushIntervalSeconds,
+		FlushThreshold:       req.FlushThreshold,
+		Conflict:             req.Conflict,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErrors.ErrPageNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+		case errors.Is(err, domainErrors.ErrInvalidCollabSettings):
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "协同设置超出允许范围", Details: err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		}
 		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{"pageId": pageID, "settings": settings})
+}
+
+// SessionWindowRequest 协作时段请求结构
+type SessionWindowRequest struct {
+	StartsAt time.Time `json:"startsAt" binding:"required"`
+	EndsAt   time.Time `json:"endsAt" binding:"required"`
+}
+
+// SetSessionWindow 设置页面的协作时段（仅所有者）
+// PUT /api/pages/:pageId/session
+// 请求体: { "startsAt": "2026-05-01T14:00:00+08:00", "endsAt": "2026-05-01T16:00:00+08:00" }
+// 时段外所有者以外的用户不能加入房间，已在房间内的只读；房间在线时立即生效
+func (pc *PageController) SetSessionWindow(c *gin.Context) {
+	var req SessionWindowRequest
+	if !bindJSON(c, &req, "startsAt 和 endsAt 不能为空，格式为 RFC 3339") {
+		return
+	}
+	pc.updateSessionWindow(c, &entity.SessionWindow{StartsAt: req.StartsAt, EndsAt: req.EndsAt})
+}
+
+// ClearSessionWindow 取消页面的协作时段（仅所有者）
+// DELETE /api/pages/:pageId/session
+func (pc *PageController) ClearSessionWindow(c *gin.Context) {
+	pc.updateSessionWindow(c, nil)
+}
+
+// updateSessionWindow 设置或取消（window 为 nil）协作时段并写入响应，路由上的 RequirePageRole 已确认调用者是所有者
+func (pc *PageController) updateSessionWindow(c *gin.Context, window *entity.SessionWindow) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	settings, err := pc.pageUseCase.SetSessionWindow(pageID, window)
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErrors.ErrPageNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+		case errors.Is(err, domainErrors.ErrInvalidSessionWindow):
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "协作时段无效", Details: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pageId": pageID, "settings": settings})
+}
+
+// ImportLegacyRequest 导入旧版页面请求结构
+type ImportLegacyRequest struct {
+	PageID string          `json:"pageId" binding:"required"`
+	Data   json.RawMessage `json:"data" binding:"required"` // localStorage 中的原始数据，对象或字符串均可
+}
+
+// ImportLegacy 导入纯前端时代保存在 localStorage 的页面
+// POST /api/pages/import-legacy
+// 请求体: { "pageId": "xxx", "data": <localStorage 数据> }
+// 字段映射见 internal/legacy 包文档
+func (pc *PageController) ImportLegacy(c *gin.Context) {
+	var req ImportLegacyRequest
+	if !bindJSON(c, &req, "pageId 和 data 不能为空") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	page, err := pc.pageUseCase.ImportLegacyPage(req.PageID, userID.(string), orgClaims(c), req.Data)
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErrors.ErrInvalidLegacyData):
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "旧版数据格式无效", Details: err.Error()})
+		case errors.Is(err, domainErrors.ErrPageAlreadyExists):
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "页面已存在"})
+		case errors.Is(err, domainErrors.ErrStorageQuotaExceeded):
+			writeQuotaError(c, err)
+		case errors.Is(err, domainErrors.ErrQuotaExceeded):
+			writePageQuotaError(c, err)
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, PageResponse{
+		PageID:  page.PageID,
+		Schema:  page.Schema,
+		Version: page.Version,
+	})
+}
+
+// DeletePage 删除页面，路由上的 RequirePageRole 已确认调用者是所有者
+// DELETE /api/pages/:pageId?confirm=<token>
+// 页面有其他人在线或被其他页面嵌入、链接时分两步删除：不带 confirm 时返回 409、影响范围和确认 Token，
+// 用户确认后带上 Token 重新请求；Token 绑定页面和操作者，5 分钟内有效。
+// 注意：此操作会强制关闭协同编辑房间，踢出所有在线用户
+func (pc *PageController) DeletePage(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+	userID := c.GetString(middleware.ContextKeyUserID)
+
+	var err error
+	if token := c.Query("confirm"); token != "" {
+		err = pc.pageUseCase.ConfirmDelete(pageID, userID, token)
+	} else {
+		impact, impactErr := pc.pageUseCase.DeleteImpact(pageID, userID)
+		if impactErr != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: impactErr.Error()})
+			return
+		}
+		if impact.RequiresConfirm() {
+			c.JSON(http.StatusConflict, DeleteConfirmResponse{Error: "页面有其他人正在编辑或被其他页面引用，请确认后删除", Impact: impact})
+			return
+		}
+		err = pc.pageUseCase.DeletePage(pageID)
+	}
+
+	switch {
+	case errors.Is(err, domainErrors.ErrInvalidDeleteConfirmation):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "删除确认已失效，请重新发起删除"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, MessageResponse{
 		Message: "页面已删除",
 		PageID:  pageID,
 	})
 }
+
+// PageQuotaResponse 当前用户页面数配额响应结构
+type PageQuotaResponse struct {
+	Pages    int64 `json:"pages"`    // 已创建的页面数，包括组织页面，不含草稿分支
+	MaxPages int   `json:"maxPages"` // 0 表示不限
+	Exceeded bool  `json:"exceeded"` // 为 true 时不能再创建或导入页面
+}
+
+// GetQuota 获取当前用户的页面数配额与用量
+// GET /api/me/quota
+func (pc *PageController) GetQuota(c *gin.Context) {
+	quota, err := pc.pageUseCase.PageQuota(c.GetString(middleware.ContextKeyUserID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, PageQuotaResponse{
+		Pages:    quota.Used,
+		MaxPages: quota.Limit,
+		Exceeded: quota.Exceeded(),
+	})
+}
+
+// writePageQuotaError 用户的页面数已达上限
+func writePageQuotaError(c *gin.Context, err error) {
+	c.JSON(http.StatusPaymentRequired, ErrorResponse{Error: "页面数已达上限，请删除不用的页面或联系管理员提高上限", Details: err.Error()})
+}
+
+// orgClaims 读取 ClerkAuth 注入的激活组织，未激活组织时返回零值
+func orgClaims(c *gin.Context) usecase.OrgClaims {
+	return usecase.OrgClaims{
+		OrgID:   c.GetString(middleware.ContextKeyOrgID),
+		OrgRole: c.GetString(middleware.ContextKeyOrgRole),
+	}
+}
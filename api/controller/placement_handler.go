@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"lowercode-go-server/api/middleware"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/placement"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CollabEndpointResponse 页面协同连接应连接的节点
+type CollabEndpointResponse struct {
+	PageID       string `json:"pageId"`
+	Node         string `json:"node,omitempty"`         // 承载页面的节点，单节点部署时为空
+	WSURL        string `json:"wsUrl,omitempty"`        // 该节点的 WebSocket 地址（含 pageId），为空时使用默认地址
+	StickyCookie string `json:"stickyCookie,omitempty"` // 负载均衡按此 Cookie 的值（节点 ID）路由，已通过 Set-Cookie 设置
+}
+
+// misplacedResponse 连接到了不承载页面的节点时的 421 响应
+type misplacedResponse struct {
+	Error string `json:"error"`
+	CollabEndpointResponse
+}
+
+// collabPlacement 页面到节点的固定分配，见 EnablePlacement
+type collabPlacement struct {
+	ring   *placement.Ring
+	self   string // 本节点 ID
+	cookie string // 粘性 Cookie 名，为空时不设置
+}
+
+// EnablePlacement 多节点部署时把同一页面的所有协同连接固定到 ring 分配的节点，节点之间不需要消息总线：
+// GET /api/pages/:pageId/collab-endpoint 返回应连接的节点，连接到其他节点时握手返回 421。
+// self 为本节点 ID；cookie 非空时同时以该名称设置值为节点 ID 的 Cookie，供负载均衡按 Cookie 路由
+func (h *WSHandler) EnablePlacement(ring *placement.Ring, self, cookie string) {
+	h.placement = &collabPlacement{ring: ring, self: self, cookie: cookie}
+}
+
+// GetCollabEndpoint 返回页面协同连接应连接的节点，客户端每次连接（包括重连）前调用
+// GET /api/pages/:pageId/collab-endpoint
+func (h *WSHandler) GetCollabEndpoint(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+	if h.access != nil {
+		if _, err := h.access.PageRole(pageID, userID.(string)); err != nil {
+			switch {
+			case errors.Is(err, domainErrors.ErrPageNotFound):
+				c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+			case errors.Is(err, domainErrors.ErrUnauthorized):
+				c.JSON(http.StatusForbidden, ErrorResponse{Error: "无权访问此页面"})
+			default:
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			}
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, h.collabEndpoint(c, pageID))
+}
+
+// placedHere 页面是否由本节点承载；不是时写入 421 和应连接的节点，返回 false。
+// 未启用分配或请求已由房间租约转发过来时总是返回 true
+func (h *WSHandler) placedHere(c *gin.Context, pageID string) bool {
+	if h.placement == nil || c.GetHeader(middleware.HeaderRoomForwardedBy) != "" {
+		return true
+	}
+	if h.placement.ring.Locate(pageID).ID == h.placement.self {
+		return true
+	}
+
+	endpoint := h.collabEndpoint(c, pageID)
+	logging.Debugf("[WS] 页面 %s 由节点 %s 承载，拒绝在本节点 %s 连接", pageID, endpoint.Node, h.placement.self)
+	c.JSON(http.StatusMisdirectedRequest, misplacedResponse{
+		Error:                  "页面由其他协同节点承载，请连接到返回的节点",
+		CollabEndpointResponse: endpoint,
+	})
+	return false
+}
+
+// collabEndpoint 计算页面应连接的节点，配置了粘性 Cookie 时同时写入 Set-Cookie
+func (h *WSHandler) collabEndpoint(c *gin.Context, pageID string) CollabEndpointResponse {
+	resp := CollabEndpointResponse{PageID: pageID}
+	if h.placement == nil {
+		return resp
+	}
+
+	node := h.placement.ring.Locate(pageID)
+	resp.Node = node.ID
+	if node.URL != "" {
+		resp.WSURL = node.URL + "/ws?pageId=" + url.QueryEscape(pageID)
+	}
+	if h.placement.cookie != "" {
+		resp.StickyCookie = h.placement.cookie
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name:     h.placement.cookie,
+			Value:    node.ID,
+			Path:     "/ws",
+			HttpOnly: true,
+			Secure:   c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https",
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	return resp
+}
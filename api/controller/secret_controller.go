@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"lowercode-go-server/api/middleware"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetSecretRequest 设置密钥属性请求结构
+type SetSecretRequest struct {
+	ComponentID int64  `json:"componentId" binding:"required"`
+	Prop        string `json:"prop" binding:"required"`
+	Value       string `json:"value" binding:"required"` // 明文，只在本次请求中出现
+}
+
+// SecretPropResponse 设置密钥属性响应结构
+type SecretPropResponse struct {
+	PageID      string `json:"pageId"`
+	ComponentID int64  `json:"componentId"`
+	Prop        string `json:"prop"`
+	Mask        string `json:"mask"`
+	Version     int64  `json:"version"`
+}
+
+// SecretValueResponse 密钥明文响应结构
+type SecretValueResponse struct {
+	Path  string `json:"path"` // JSON Pointer，如 /components/3/props/apiKey
+	Mask  string `json:"mask"`
+	Value string `json:"value,omitempty"`
+	Valid bool   `json:"valid"` // 为 false 时无法解密，需要重新设置
+}
+
+// SecretListResponse 密钥明文列表响应结构
+type SecretListResponse struct {
+	PageID  string                `json:"pageId"`
+	Secrets []SecretValueResponse `json:"secrets"`
+}
+
+// SecretController 组件密钥属性 HTTP 控制器
+type SecretController struct {
+	secrets *usecase.SecretUseCase
+}
+
+// NewSecretController 创建 SecretController 实例
+func NewSecretController(secrets *usecase.SecretUseCase) *SecretController {
+	return &SecretController{secrets: secrets}
+}
+
+// SetSecret 把组件属性设为密钥，加密后写入页面并广播给在线用户（只有密文）
+// PUT /api/pages/:pageId/secrets
+// 请求体: { "componentId": 3, "prop": "apiKey", "value": "sk-..." }
+func (sc *SecretController) SetSecret(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	var req SetSecretRequest
+	if !bindJSON(c, &req, "componentId、prop 和 value 不能为空") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	secret, err := sc.secrets.Set(pageID, userID.(string), req.ComponentID, req.Prop, req.Value)
+	if err != nil {
+		writeSecretError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SecretPropResponse{
+		PageID:      pageID,
+		ComponentID: secret.ComponentID,
+		Prop:        secret.Prop,
+		Mask:        secret.Mask,
+		Version:     secret.Version,
+	})
+}
+
+// RevealSecrets 查看页面中全部密钥属性的明文（仅所有者）
+// GET /api/pages/:pageId/secrets
+func (sc *SecretController) RevealSecrets(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	values, err := sc.secrets.Reveal(pageID, userID.(string))
+	if err != nil {
+		writeSecretError(c, err)
+		return
+	}
+
+	resp := SecretListResponse{PageID: pageID, Secrets: make([]SecretValueResponse, 0, len(values))}
+	for _, v := range values {
+		resp.Secrets = append(resp.Secrets, SecretValueResponse{Path: v.Path, Mask: v.Mask, Value: v.Value, Valid: v.Valid})
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, resp)
+}
+
+// writeSecretError 将密钥属性业务错误映射为 HTTP 响应
+func writeSecretError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domainErrors.ErrPageNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+	case errors.Is(err, domainErrors.ErrUnauthorized):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "无权访问此页面的密钥属性"})
+	case errors.Is(err, domainErrors.ErrPresentationActive):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "演示模式下只有演示者可以编辑"})
+	case errors.Is(err, domainErrors.ErrInvalidSecret):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "密钥属性参数无效", Details: err.Error()})
+	case errors.Is(err, domainErrors.ErrSecretsDisabled):
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "服务端未配置密钥加密（SECRET_PROPS_KEY）"})
+	case errors.Is(err, domainErrors.ErrOptimisticLock):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "页面编辑频繁，请稍后重试"})
+	case errors.Is(err, domainErrors.ErrRoomClosing):
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "房间正在关闭，请稍后重试"})
+	case errors.Is(err, domainErrors.ErrRoomOwnedElsewhere), errors.Is(err, domainErrors.ErrRoomLockUnavailable):
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "页面所在节点正在切换，请稍后重试"})
+	case errors.Is(err, domainErrors.ErrServerShuttingDown):
+		c.Header("Retry-After", "5")
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "服务正在重启，请稍后重试"})
+	case errors.Is(err, domainErrors.ErrServerOverloaded):
+		c.Header("Retry-After", overloadRetryAfter)
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "服务繁忙，请稍后重试"})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
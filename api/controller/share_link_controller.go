@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"lowercode-go-server/api/middleware"
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateShareLinkRequest 创建分享链接请求结构，请求体可省略
+type CreateShareLinkRequest struct {
+	ExpiresInHours int `json:"expiresInHours"` // 有效期（小时），0 或省略为 7 天，最长 30 天
+}
+
+// ShareLinkResponse 分享链接响应结构
+type ShareLinkResponse struct {
+	ID        string     `json:"id"`
+	PageID    string     `json:"pageId"`
+	Token     string     `json:"token"` // 读取页面时放在 X-Share-Token 请求头，连接 WebSocket 时作为 shareToken 参数
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// ShareLinkListResponse 分享链接列表响应结构
+type ShareLinkListResponse struct {
+	PageID string              `json:"pageId"`
+	Links  []ShareLinkResponse `json:"links"`
+}
+
+// ShareLinkController 页面分享链接 HTTP 控制器
+type ShareLinkController struct {
+	shareLinks *usecase.ShareLinkUseCase
+}
+
+// NewShareLinkController 创建 ShareLinkController 实例
+func NewShareLinkController(shareLinks *usecase.ShareLinkUseCase) *ShareLinkController {
+	return &ShareLinkController{shareLinks: shareLinks}
+}
+
+// CreateShareLink 创建公开只读分享链接，路由上的 RequirePageRole 已确认调用者是所有者
+// POST /api/pages/:pageId/share-links
+// 请求体可选: { "expiresInHours": 72 }
+func (sc *ShareLinkController) CreateShareLink(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	var req CreateShareLinkRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req, "请求参数错误") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInHours) * time.Hour
+	link, _, err := sc.shareLinks.Create(pageID, userID.(string), ttl)
+	if err != nil {
+		writeShareLinkError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, sc.toResponse(link))
+}
+
+// ListShareLinks 获取页面的全部分享链接，包括已过期和已撤销的（路由上已限定为所有者）
+// GET /api/pages/:pageId/share-links
+func (sc *ShareLinkController) ListShareLinks(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	links, err := sc.shareLinks.List(pageID)
+	if err != nil {
+		writeShareLinkError(c, err)
+		return
+	}
+
+	resp := ShareLinkListResponse{PageID: pageID, Links: make([]ShareLinkResponse, 0, len(links))}
+	for _, link := range links {
+		resp.Links = append(resp.Links, sc.toResponse(link))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RevokeShareLink 撤销分享链接，通过该链接观看的在线连接立即断开（路由上已限定为所有者）
+// DELETE /api/pages/:pageId/share-links/:linkId
+func (sc *ShareLinkController) RevokeShareLink(c *gin.Context) {
+	pageID := c.Param("pageId")
+	linkID := c.Param("linkId")
+	if pageID == "" || linkID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 和 linkId 不能为空"})
+		return
+	}
+
+	if err := sc.shareLinks.Revoke(pageID, linkID); err != nil {
+		writeShareLinkError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "分享链接已撤销", PageID: pageID})
+}
+
+// toResponse 构造分享链接响应，Token 由链接 ID 重新签名得到
+func (sc *ShareLinkController) toResponse(link *entity.ShareLink) ShareLinkResponse {
+	return ShareLinkResponse{
+		ID:        link.ID,
+		PageID:    link.PageID,
+		Token:     sc.shareLinks.Token(link),
+		ExpiresAt: link.ExpiresAt,
+		RevokedAt: link.RevokedAt,
+		Active:    link.Active(time.Now()),
+		CreatedAt: link.CreatedAt,
+	}
+}
+
+// writeShareLinkError 将分享链接相关的业务错误转换为 HTTP 响应
+func writeShareLinkError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domainErrors.ErrPageNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+	case errors.Is(err, domainErrors.ErrShareLinkNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "分享链接不存在或已被撤销"})
+	case errors.Is(err, domainErrors.ErrInvalidShareLinkTTL):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "有效期需在 1 ~ 720 小时之间"})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
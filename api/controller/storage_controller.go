@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"lowercode-go-server/api/middleware"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StorageBreakdown 按类别的存储用量（字节）
+type StorageBreakdown struct {
+	Schemas   int64 `json:"schemas"`   // 草稿与发布副本
+	Snapshots int64 `json:"snapshots"` // 历史版本快照
+	Assets    int64 `json:"assets"`    // 冲突备份
+	Journals  int64 `json:"journals"`  // 操作日志与差量
+}
+
+// StorageResponse 租户存储用量响应结构
+type StorageResponse struct {
+	TenantID   string           `json:"tenantId"`
+	Plan       string           `json:"plan"`
+	LimitBytes int64            `json:"limitBytes"` // 0 表示不限
+	UsedBytes  int64            `json:"usedBytes"`
+	Exceeded   bool             `json:"exceeded"` // 为 true 时不能创建页面、分支或发布
+	Pages      int64            `json:"pages"`
+	Breakdown  StorageBreakdown `json:"breakdown"`
+}
+
+// StorageListResponse 租户存储用量列表响应结构
+type StorageListResponse struct {
+	Tenants []StorageResponse `json:"tenants"`
+}
+
+// SetPlanRequest 设置租户套餐请求结构
+type SetPlanRequest struct {
+	Plan string `json:"plan" binding:"required"` // free / pro / enterprise
+}
+
+// RecountResponse 重新统计存储用量响应结构
+type RecountResponse struct {
+	Pages int64 `json:"pages"`
+}
+
+// StorageController 租户存储用量 HTTP 控制器
+type StorageController struct {
+	storage *usecase.StorageUseCase
+}
+
+// NewStorageController 创建 StorageController 实例
+func NewStorageController(storage *usecase.StorageUseCase) *StorageController {
+	return &StorageController{storage: storage}
+}
+
+// GetStorage 获取当前租户的存储用量：激活组织时为组织，否则为个人
+// GET /api/storage
+func (sc *StorageController) GetStorage(c *gin.Context) {
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	tenantID := userID.(string)
+	if org := orgClaims(c); org.OrgID != "" {
+		tenantID = org.OrgID
+	}
+
+	tenant, err := sc.storage.Get(tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toStorageResponse(tenant))
+}
+
+// ListTenantStorage 按总用量降序列出用量最大的租户
+// GET /api/admin/storage?limit=20
+func (sc *StorageController) ListTenantStorage(c *gin.Context) {
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "limit 必须为正整数"})
+			return
+		}
+		limit = parsed
+	}
+
+	tenants, err := sc.storage.ListTop(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	resp := StorageListResponse{Tenants: make([]StorageResponse, 0, len(tenants))}
+	for _, tenant := range tenants {
+		resp.Tenants = append(resp.Tenants, toStorageResponse(tenant))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetTenantStorage 获取指定租户（组织 ID 或用户 ID）的存储用量
+// GET /api/admin/tenants/:tenantId/storage
+func (sc *StorageController) GetTenantStorage(c *gin.Context) {
+	tenant, err := sc.storage.Get(c.Param("tenantId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toStorageResponse(tenant))
+}
+
+// SetTenantPlan 设置租户的存储套餐
+// PUT /api/admin/tenants/:tenantId/plan
+// 请求体: { "plan": "pro" }
+func (sc *StorageController) SetTenantPlan(c *gin.Context) {
+	var req SetPlanRequest
+	if !bindJSON(c, &req, "plan 不能为空") {
+		return
+	}
+
+	tenant, err := sc.storage.SetPlan(c.Param("tenantId"), req.Plan)
+	if err != nil {
+		if errors.Is(err, domainErrors.ErrInvalidPlan) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "套餐无效，可选 free、pro、enterprise", Field: "plan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toStorageResponse(tenant))
+}
+
+// RecountStorage 按源数据表重新统计全部页面的存储用量，校正统计偏差
+// POST /api/admin/storage/recount
+func (sc *StorageController) RecountStorage(c *gin.Context) {
+	pages, err := sc.storage.Recount()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, RecountResponse{Pages: pages})
+}
+
+// toStorageResponse 转换为响应结构
+func toStorageResponse(tenant *usecase.TenantStorage) StorageResponse {
+	usage := tenant.Usage
+	return StorageResponse{
+		TenantID:   usage.TenantID,
+		Plan:       tenant.Plan,
+		LimitBytes: tenant.LimitBytes,
+		UsedBytes:  usage.Total(),
+		Exceeded:   tenant.Exceeded(),
+		Pages:      usage.Pages,
+		Breakdown: StorageBreakdown{
+			Schemas:   usage.SchemaBytes,
+			Snapshots: usage.SnapshotBytes,
+			Assets:    usage.AssetBytes,
+			Journals:  usage.JournalBytes,
+		},
+	}
+}
+
+// writeQuotaError 存储用量已达套餐上限
+func writeQuotaError(c *gin.Context, err error) {
+	c.JSON(http.StatusInsufficientStorage, ErrorResponse{Error: "存储用量已达套餐上限，请清理页面或升级套餐", Details: err.Error()})
+}
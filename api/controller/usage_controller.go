@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"lowercode-go-server/api/middleware"
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CodeUpgradeRequired 超出套餐额度时响应中的 code，前端据此展示升级套餐的入口
+const CodeUpgradeRequired = "UPGRADE_REQUIRED"
+
+// UsageAllowanceResponse 一类调用在本月的用量与额度
+type UsageAllowanceResponse struct {
+	Kind      string `json:"kind"` // ai / publish
+	Used      int64  `json:"used"`
+	Limit     int64  `json:"limit"` // 0 表示不限
+	Remaining int64  `json:"remaining,omitempty"`
+	Exceeded  bool   `json:"exceeded"`
+}
+
+// UsageResponse 租户本月的计量用量响应结构
+type UsageResponse struct {
+	TenantID    string                   `json:"tenantId"`
+	Plan        string                   `json:"plan"`
+	PeriodStart time.Time                `json:"periodStart"`
+	ResetAt     time.Time                `json:"resetAt"` // 额度恢复的时间
+	Allowances  []UsageAllowanceResponse `json:"allowances"`
+}
+
+// UsageLimitResponse 超出套餐额度时的 429 响应
+type UsageLimitResponse struct {
+	Error   string    `json:"error"`
+	Code    string    `json:"code"` // UPGRADE_REQUIRED
+	Kind    string    `json:"kind"`
+	Plan    string    `json:"plan"`
+	Limit   int64     `json:"limit"`
+	ResetAt time.Time `json:"resetAt"`
+}
+
+// UsageController 按套餐计量的调用用量 HTTP 控制器
+type UsageController struct {
+	usage *usecase.UsageUseCase
+}
+
+// NewUsageController 创建 UsageController 实例
+func NewUsageController(usage *usecase.UsageUseCase) *UsageController {
+	return &UsageController{usage: usage}
+}
+
+// GetUsage 获取当前租户本月的计量用量：激活组织时为组织，否则为个人
+// GET /api/usage
+func (uc *UsageController) GetUsage(c *gin.Context) {
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	tenantID := userID.(string)
+	if org := orgClaims(c); org.OrgID != "" {
+		tenantID = org.OrgID
+	}
+	uc.writeUsage(c, tenantID)
+}
+
+// GetTenantUsage 获取指定租户（组织 ID 或用户 ID）本月的计量用量
+// GET /api/admin/tenants/:tenantId/usage
+func (uc *UsageController) GetTenantUsage(c *gin.Context) {
+	uc.writeUsage(c, c.Param("tenantId"))
+}
+
+// writeUsage 查询并写入租户的计量用量
+func (uc *UsageController) writeUsage(c *gin.Context, tenantID string) {
+	usage, err := uc.usage.Get(tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	resp := UsageResponse{
+		TenantID:    usage.TenantID,
+		Plan:        usage.Plan,
+		PeriodStart: usage.PeriodStart,
+		ResetAt:     usage.ResetAt,
+		Allowances:  make([]UsageAllowanceResponse, 0, len(usage.Allowances)),
+	}
+	for _, a := range usage.Allowances {
+		allowance := UsageAllowanceResponse{Kind: a.Kind, Used: a.Used, Limit: a.Limit}
+		if a.Limit > 0 {
+			allowance.Remaining = max(a.Limit-a.Used, 0)
+			allowance.Exceeded = a.Used >= a.Limit
+		}
+		resp.Allowances = append(resp.Allowances, allowance)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// writeUsageLimitError 本月调用次数已达套餐额度，Retry-After 为距额度恢复的秒数
+func writeUsageLimitError(c *gin.Context, err error) {
+	var limitErr *usecase.UsageLimitError
+	if !errors.As(err, &limitErr) {
+		c.JSON(http.StatusTooManyRequests, UsageLimitResponse{Error: "调用次数已达套餐额度，请升级套餐", Code: CodeUpgradeRequired})
+		return
+	}
+	c.Header("Retry-After", strconv.FormatInt(int64(time.Until(limitErr.ResetAt).Seconds())+1, 10))
+	c.JSON(http.StatusTooManyRequests, UsageLimitResponse{
+		Error:   "本月调用次数已达套餐额度，请升级套餐或等待额度恢复",
+		Code:    CodeUpgradeRequired,
+		Kind:    limitErr.Kind,
+		Plan:    limitErr.Plan,
+		Limit:   limitErr.Limit,
+		ResetAt: limitErr.ResetAt,
+	})
+}
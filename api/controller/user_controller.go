@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"lowercode-go-server/api/middleware"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserProfileResponse 当前用户资料响应结构
+type UserProfileResponse struct {
+	UserID      string `json:"userId"`
+	Email       string `json:"email"`
+	Name        string `json:"name"`
+	AvatarURL   string `json:"avatarUrl"`
+	CursorColor string `json:"cursorColor"`
+}
+
+// CursorColorRequest 自定义光标颜色请求结构
+type CursorColorRequest struct {
+	Color string `json:"color" binding:"required"`
+}
+
+// UserController 用户资料与偏好 HTTP 控制器
+type UserController struct {
+	userUseCase *usecase.UserUseCase
+}
+
+// NewUserController 创建 UserController 实例
+func NewUserController(userUseCase *usecase.UserUseCase) *UserController {
+	return &UserController{userUseCase: userUseCase}
+}
+
+// GetMe 获取当前用户资料
+// GET /api/users/me
+func (uc *UserController) GetMe(c *gin.Context) {
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	user, err := uc.userUseCase.GetProfile(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, UserProfileResponse{
+		UserID:      user.ID,
+		Email:       user.Email,
+		Name:        user.Name,
+		AvatarURL:   user.AvatarURL,
+		CursorColor: user.CursorColor,
+	})
+}
+
+// RecentPagesResponse 最近页面响应结构
+type RecentPagesResponse struct {
+	Pages interface{} `json:"pages"` // []repository.RecentPage，指定 fields 时每项只含所选字段
+}
+
+// GetRecentPages 获取当前用户最近打开或编辑过的页面
+// GET /api/me/recent-pages?limit=20&fields=pageId,lastEditedAt&include=schema
+// 打开时间在加入协同房间时记录，编辑时间在编辑刷盘后记录，按两者中较晚者倒序
+// fields 可选，每项只返回指定的字段
+// 默认不返回 Schema；include=schema 时附带，条数与总大小受限，见 UserUseCase.RecentPagesWithSchema
+func (uc *UserController) GetRecentPages(c *gin.Context) {
+	fields, ok := parseFields[repository.RecentPage](c)
+	if !ok {
+		return
+	}
+
+	includeSchema := false
+	switch include := c.Query("include"); include {
+	case "":
+	case "schema":
+		includeSchema = true
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "include 只支持 schema"})
+		return
+	}
+
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "limit 必须为正整数"})
+			return
+		}
+		limit = parsed
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	recent := uc.userUseCase.RecentPages
+	if includeSchema {
+		recent = uc.userUseCase.RecentPagesWithSchema
+	}
+	pages, err := recent(userID.(string), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	projected, err := projectEach(pages, fields)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, RecentPagesResponse{Pages: projected})
+}
+
+// UpdateCursorColor 自定义协作光标颜色
+// PUT /api/users/me/cursor-color
+// 请求体: { "color": "#RRGGBB" }，下次连接协同房间时生效
+func (uc *UserController) UpdateCursorColor(c *gin.Context) {
+	var req CursorColorRequest
+	if !bindJSON(c, &req, "color 不能为空") {
+		return
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	color, err := uc.userUseCase.SetCursorColor(userID.(string), req.Color)
+	if err != nil {
+		if errors.Is(err, domainErrors.ErrInvalidColor) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "颜色格式应为 #RRGGBB"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cursorColor": color})
+}
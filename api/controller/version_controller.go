@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"lowercode-go-server/api/middleware"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/jsondiff"
+	"lowercode-go-server/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DiffResponse 版本对比响应结构
+type DiffResponse struct {
+	PageID  string               `json:"pageId"`
+	From    int64                `json:"from"`
+	To      int64                `json:"to"`
+	Patches []jsondiff.Operation `json:"patches"`
+
+	// Labels 区间内各标签的编辑次数（未标注的编辑不计入），未启用操作日志时省略
+	Labels map[string]int64 `json:"labels,omitempty"`
+}
+
+// HistoryResponse 编辑记录响应结构
+type HistoryResponse struct {
+	PageID  string                 `json:"pageId"`
+	Entries []usecase.HistoryEntry `json:"entries"`
+}
+
+// VersionController 页面历史版本 HTTP 控制器
+type VersionController struct {
+	versionUseCase *usecase.VersionUseCase
+}
+
+// NewVersionController 创建 VersionController 实例
+func NewVersionController(versionUseCase *usecase.VersionUseCase) *VersionController {
+	return &VersionController{versionUseCase: versionUseCase}
+}
+
+// GetDiff 计算两个版本之间的 JSON Patch
+// GET /api/pages/:pageId/diff?from=3&to=7
+// to 可选，缺省为当前最新版本
+func (vc *VersionController) GetDiff(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	from, err := strconv.ParseInt(c.Query("from"), 10, 64)
+	if err != nil || from <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "from 必须为正整数"})
+		return
+	}
+
+	var to int64
+	if raw := c.Query("to"); raw != "" {
+		to, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || to <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "to 必须为正整数"})
+			return
+		}
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	patches, to, err := vc.versionUseCase.Diff(pageID, userID.(string), from, to)
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErrors.ErrPageNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+		case errors.Is(err, domainErrors.ErrUnauthorized):
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "无权访问此页面"})
+		case errors.Is(err, domainErrors.ErrVersionNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "版本不存在"})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	// 标签统计只是附加信息，失败时仍返回对比结果
+	labels, err := vc.versionUseCase.EditLabels(pageID, from, to)
+	if err != nil {
+		log.Printf("[Version] 统计页面 [%s] 编辑标签失败: %v", pageID, err)
+	}
+
+	c.JSON(http.StatusOK, DiffResponse{
+		PageID:  pageID,
+		From:    from,
+		To:      to,
+		Patches: patches,
+		Labels:  labels,
+	})
+}
+
+// GetHistory 查询页面的编辑记录（版本号、作者、标签），不含 Patch 内容
+// GET /api/pages/:pageId/history?since=3&limit=50
+// since 可选，缺省为 0；limit 可选，缺省为 50，最大 200
+func (vc *VersionController) GetHistory(c *gin.Context) {
+	pageID := c.Param("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "pageId 不能为空"})
+		return
+	}
+
+	var since int64
+	if raw := c.Query("since"); raw != "" {
+		var err error
+		since, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || since < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "since 必须为非负整数"})
+			return
+		}
+	}
+
+	limit := 50
+	if raw := c.Query("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "limit 必须为正整数"})
+			return
+		}
+	}
+
+	userID, exists := c.Get(middleware.ContextKeyUserID)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "未获取到用户信息"})
+		return
+	}
+
+	entries, err := vc.versionUseCase.History(pageID, userID.(string), since, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErrors.ErrPageNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "页面不存在"})
+		case errors.Is(err, domainErrors.ErrUnauthorized):
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "无权访问此页面"})
+		case errors.Is(err, domainErrors.ErrOpHistoryDisabled):
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "未启用操作日志"})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, HistoryResponse{PageID: pageID, Entries: entries})
+}
@@ -9,6 +9,8 @@ import (
 
 	"lowercode-go-server/domain/entity"
 	domainRepo "lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/usecase"
 
 	"github.com/gin-gonic/gin"
 	svix "github.com/svix/svix-webhooks/go"
@@ -17,14 +19,27 @@ import (
 // WebhookController 处理 Clerk Webhook 回调
 type WebhookController struct {
 	userRepo      domainRepo.UserRepository
+	orgMembers    domainRepo.OrgMemberRepository
+	orgs          domainRepo.OrganizationRepository
+	invites       *usecase.InviteUseCase      // 为 nil 时不处理协作邀请
+	revocations   *usecase.RevocationUseCase  // 为 nil 时不吊销登录会话
+	cleanup       *usecase.UserCleanupUseCase // 为 nil 时用户删除后不清理其数据
 	webhookSecret string
+	allowUnsigned bool // 未配置密钥时是否处理未签名请求，仅限开发环境
 }
 
 // NewWebhookController 创建 WebhookController 实例
-func NewWebhookController(userRepo domainRepo.UserRepository, webhookSecret string) *WebhookController {
+// webhookSecret 为空且 allowUnsigned 为 false 时拒绝所有回调（503）
+func NewWebhookController(userRepo domainRepo.UserRepository, orgMembers domainRepo.OrgMemberRepository, orgs domainRepo.OrganizationRepository, invites *usecase.InviteUseCase, revocations *usecase.RevocationUseCase, cleanup *usecase.UserCleanupUseCase, webhookSecret string, allowUnsigned bool) *WebhookController {
 	return &WebhookController{
 		userRepo:      userRepo,
+		orgMembers:    orgMembers,
+		orgs:          orgs,
+		invites:       invites,
+		revocations:   revocations,
+		cleanup:       cleanup,
 		webhookSecret: webhookSecret,
+		allowUnsigned: allowUnsigned,
 	}
 }
 
@@ -39,20 +54,50 @@ type ClerkUserData struct {
 	ID             string `json:"id"`
 	EmailAddresses []struct {
 		EmailAddress string `json:"email_address"`
+		Verification struct {
+			Status string `json:"status"`
+		} `json:"verification"`
 	} `json:"email_addresses"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	ImageURL  string `json:"image_url"`
 }
 
+// ClerkOrgData Clerk 组织数据结构
+type ClerkOrgData struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	ImageURL  string `json:"image_url"`
+	CreatedBy string `json:"created_by"`
+}
+
+// ClerkOrgMembershipData Clerk 组织成员关系数据结构
+type ClerkOrgMembershipData struct {
+	Role           string       `json:"role"`
+	Organization   ClerkOrgData `json:"organization"`
+	PublicUserData struct {
+		UserID string `json:"user_id"`
+	} `json:"public_user_data"`
+}
+
+// ClerkSessionData Clerk 会话数据结构
+type ClerkSessionData struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	Status string `json:"status"`
+}
+
 // HandleClerkWebhook 处理 Clerk Webhook 回调
 // POST /webhook/clerk
-// 处理 user.created, user.updated, user.deleted 事件
+// 处理 user.created, user.updated, user.deleted 事件，
+// session.ended/removed/revoked 事件，
+// 以及 organization.created/updated/deleted、organizationMembership.created/updated/deleted 事件
 func (wc *WebhookController) HandleClerkWebhook(c *gin.Context) {
 	// 读取请求体
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		log.Printf("[Webhook] 读取请求体失败: %v", err)
+		logging.Warnf("[Webhook] 读取请求体失败: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无法读取请求体"})
 		return
 	}
@@ -61,7 +106,7 @@ func (wc *WebhookController) HandleClerkWebhook(c *gin.Context) {
 	if wc.webhookSecret != "" {
 		wh, err := svix.NewWebhook(wc.webhookSecret)
 		if err != nil {
-			log.Printf("[Webhook] 初始化 Webhook 验证器失败: %v", err)
+			logging.Errorf("[Webhook] 初始化 Webhook 验证器失败: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Webhook 配置错误"})
 			return
 		}
@@ -72,18 +117,22 @@ func (wc *WebhookController) HandleClerkWebhook(c *gin.Context) {
 		headers.Set("svix-signature", c.GetHeader("svix-signature"))
 
 		if err := wh.Verify(body, headers); err != nil {
-			log.Printf("[Webhook] 签名验证失败: %v", err)
+			logging.Warnf("[Webhook] 签名验证失败: %v", err)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "签名验证失败"})
 			return
 		}
+	} else if wc.allowUnsigned {
+		logging.Warnf("[Webhook] 未配置 CLERK_WEBHOOK_SECRET，已按 CLERK_WEBHOOK_ALLOW_UNSIGNED 跳过签名验证（仅限开发环境）")
 	} else {
-		log.Println("[Webhook] 警告: 未配置 CLERK_WEBHOOK_SECRET，跳过签名验证（仅限开发环境）")
+		logging.Errorf("[Webhook] 未配置 CLERK_WEBHOOK_SECRET，拒绝未签名的回调")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Webhook 未配置签名密钥"})
+		return
 	}
 
 	// 解析事件
 	var payload ClerkWebhookPayload
 	if err := json.Unmarshal(body, &payload); err != nil {
-		log.Printf("[Webhook] 解析 Webhook 失败: %v", err)
+		logging.Warnf("[Webhook] 解析 Webhook 失败: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 JSON 格式"})
 		return
 	}
@@ -96,6 +145,16 @@ func (wc *WebhookController) HandleClerkWebhook(c *gin.Context) {
 		wc.handleUserUpsert(payload.Data)
 	case "user.deleted":
 		wc.handleUserDeleted(payload.Data)
+	case "session.ended", "session.removed", "session.revoked":
+		wc.handleSessionEnded(payload.Data)
+	case "organization.created", "organization.updated":
+		wc.handleOrgUpsert(payload.Data)
+	case "organizationMembership.created", "organizationMembership.updated":
+		wc.handleOrgMembershipUpsert(payload.Data)
+	case "organizationMembership.deleted":
+		wc.handleOrgMembershipDeleted(payload.Data)
+	case "organization.deleted":
+		wc.handleOrgDeleted(payload.Data)
 	default:
 		log.Printf("[Webhook] 忽略事件: %s", payload.Type)
 	}
@@ -107,7 +166,7 @@ func (wc *WebhookController) HandleClerkWebhook(c *gin.Context) {
 func (wc *WebhookController) handleUserUpsert(data json.RawMessage) {
 	var userData ClerkUserData
 	if err := json.Unmarshal(data, &userData); err != nil {
-		log.Printf("[Webhook] 解析用户数据失败: %v", err)
+		logging.Warnf("[Webhook] 解析用户数据失败: %v", err)
 		return
 	}
 
@@ -136,11 +195,30 @@ func (wc *WebhookController) handleUserUpsert(data json.RawMessage) {
 	}
 
 	if err := wc.userRepo.Upsert(user); err != nil {
-		log.Printf("[Webhook] 用户 Upsert 失败: %v", err)
+		logging.Errorf("[Webhook] 用户 Upsert 失败: %v", err)
 		return
 	}
 
 	log.Printf("[Webhook] 用户同步成功: %s (%s)", user.ID, user.Email)
+
+	// 发给已验证邮箱的协作邀请转为协作者；未验证的邮箱不能证明归属，不接受邀请
+	if wc.invites == nil {
+		return
+	}
+	var verified []string
+	for _, addr := range userData.EmailAddresses {
+		if addr.Verification.Status == "verified" {
+			verified = append(verified, addr.EmailAddress)
+		}
+	}
+	accepted, err := wc.invites.AcceptForUser(user.ID, verified)
+	if err != nil {
+		logging.Errorf("[Webhook] 用户 %s 接受协作邀请失败: %v", user.ID, err)
+		return
+	}
+	if accepted > 0 {
+		log.Printf("[Webhook] 用户 %s 已接受 %d 个协作邀请", user.ID, accepted)
+	}
 }
 
 // handleUserDeleted 处理用户删除事件
@@ -149,10 +227,164 @@ func (wc *WebhookController) handleUserDeleted(data json.RawMessage) {
 		ID string `json:"id"`
 	}
 	if err := json.Unmarshal(data, &userData); err != nil {
-		log.Printf("[Webhook] 解析删除事件数据失败: %v", err)
+		logging.Warnf("[Webhook] 解析删除事件数据失败: %v", err)
+		return
+	}
+
+	// 已签发的 Token 在过期前仍能通过验签，需要吊销并断开在线连接
+	if wc.revocations != nil && userData.ID != "" {
+		if err := wc.revocations.RevokeUser(userData.ID); err != nil {
+			logging.Errorf("[Webhook] 吊销用户 %s 的登录会话失败: %v", userData.ID, err)
+		}
+	}
+
+	if wc.cleanup == nil || userData.ID == "" {
+		return
+	}
+	plan, err := wc.cleanup.DeleteUser(userData.ID)
+	if err != nil {
+		logging.Errorf("[Webhook] 清理用户 %s 的数据失败: %v", userData.ID, err)
+		return
+	}
+	if plan.DryRun {
+		for _, t := range plan.Transfers {
+			log.Printf("[Webhook] dry-run：页面 %s 将转给 %s", t.PageID, t.NewOwnerID)
+		}
+		for _, pageID := range plan.DeletedPages {
+			log.Printf("[Webhook] dry-run：页面 %s 将被删除", pageID)
+		}
+		return
+	}
+	log.Printf("[Webhook] 用户已删除: %s", userData.ID)
+}
+
+// handleSessionEnded 处理会话结束事件（退出登录、被移除或被吊销），
+// 拒绝该会话尚未过期的 Token，并断开使用该会话建立的协同连接
+func (wc *WebhookController) handleSessionEnded(data json.RawMessage) {
+	var session ClerkSessionData
+	if err := json.Unmarshal(data, &session); err != nil {
+		logging.Warnf("[Webhook] 解析会话数据失败: %v", err)
+		return
+	}
+	if session.ID == "" {
+		logging.Warnf("[Webhook] 会话事件缺少 id")
+		return
+	}
+	if wc.revocations == nil {
+		return
+	}
+
+	if err := wc.revocations.RevokeSession(session.ID, session.UserID); err != nil {
+		logging.Errorf("[Webhook] 吊销会话 %s 失败: %v", session.ID, err)
+		return
+	}
+	log.Printf("[Webhook] 会话已吊销: %s (用户 %s, %s)", session.ID, session.UserID, session.Status)
+}
+
+// handleOrgMembershipUpsert 处理组织成员加入/角色变更事件
+func (wc *WebhookController) handleOrgMembershipUpsert(data json.RawMessage) {
+	var membership ClerkOrgMembershipData
+	if err := json.Unmarshal(data, &membership); err != nil {
+		logging.Warnf("[Webhook] 解析组织成员数据失败: %v", err)
+		return
+	}
+	orgID, userID := membership.Organization.ID, membership.PublicUserData.UserID
+	if orgID == "" || userID == "" {
+		logging.Warnf("[Webhook] 组织成员事件缺少 organization.id 或 user_id")
+		return
+	}
+
+	if err := wc.orgMembers.Upsert(orgID, userID, membership.Role); err != nil {
+		logging.Errorf("[Webhook] 组织成员 Upsert 失败: %v", err)
+		return
+	}
+
+	log.Printf("[Webhook] 组织成员同步成功: %s/%s (%s)", orgID, userID, membership.Role)
+
+	// 接入 Webhook 之前创建的组织没有 organization.created 事件，从成员关系中补齐组织资料
+	org, err := wc.orgs.GetByID(orgID)
+	if err != nil {
+		logging.Errorf("[Webhook] 查询组织 %s 失败: %v", orgID, err)
+		return
+	}
+	if org == nil {
+		wc.upsertOrg(membership.Organization)
+	}
+}
+
+// handleOrgMembershipDeleted 处理组织成员移除事件，成员随即失去组织页面的访问权限
+func (wc *WebhookController) handleOrgMembershipDeleted(data json.RawMessage) {
+	var membership ClerkOrgMembershipData
+	if err := json.Unmarshal(data, &membership); err != nil {
+		logging.Warnf("[Webhook] 解析组织成员数据失败: %v", err)
+		return
+	}
+
+	orgID, userID := membership.Organization.ID, membership.PublicUserData.UserID
+	if err := wc.orgMembers.Remove(orgID, userID); err != nil {
+		logging.Errorf("[Webhook] 移除组织成员失败: %v", err)
+		return
+	}
+
+	log.Printf("[Webhook] 组织成员已移除: %s/%s", orgID, userID)
+}
+
+// handleOrgUpsert 处理组织创建/更新事件
+func (wc *WebhookController) handleOrgUpsert(data json.RawMessage) {
+	var orgData ClerkOrgData
+	if err := json.Unmarshal(data, &orgData); err != nil {
+		logging.Warnf("[Webhook] 解析组织数据失败: %v", err)
+		return
+	}
+	if orgData.ID == "" {
+		logging.Warnf("[Webhook] 组织事件缺少 id")
+		return
+	}
+
+	if wc.upsertOrg(orgData) {
+		log.Printf("[Webhook] 组织同步成功: %s (%s)", orgData.ID, orgData.Name)
+	}
+}
+
+// upsertOrg 写入组织资料，返回是否成功
+func (wc *WebhookController) upsertOrg(orgData ClerkOrgData) bool {
+	now := time.Now()
+	org := &entity.Organization{
+		ID:        orgData.ID,
+		Name:      orgData.Name,
+		Slug:      orgData.Slug,
+		ImageURL:  orgData.ImageURL,
+		CreatedBy: orgData.CreatedBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := wc.orgs.Upsert(org); err != nil {
+		logging.Errorf("[Webhook] 组织 %s Upsert 失败: %v", orgData.ID, err)
+		return false
+	}
+	return true
+}
+
+// handleOrgDeleted 处理组织删除事件，清除全部成员关系和组织资料；
+// 组织页面保留，此后只有页面创建者和协作者可以访问
+func (wc *WebhookController) handleOrgDeleted(data json.RawMessage) {
+	var orgData struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &orgData); err != nil {
+		logging.Warnf("[Webhook] 解析组织删除事件数据失败: %v", err)
+		return
+	}
+
+	// 先清除成员关系：即使删除组织资料失败，成员也已失去组织页面的访问权限
+	if err := wc.orgMembers.RemoveOrg(orgData.ID); err != nil {
+		logging.Errorf("[Webhook] 清除组织成员失败: %v", err)
+		return
+	}
+	if err := wc.orgs.Delete(orgData.ID); err != nil {
+		logging.Errorf("[Webhook] 删除组织 %s 失败: %v", orgData.ID, err)
 		return
 	}
 
-	// TODO: 实现用户删除逻辑（可能需要级联删除用户的页面）
-	log.Printf("[Webhook] 用户删除事件: %s（暂未实现删除逻辑）", userData.ID)
+	log.Printf("[Webhook] 组织已删除，成员关系已清除: %s", orgData.ID)
 }
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/ws"
+
+	"github.com/gin-gonic/gin"
+	"github.com/quic-go/webtransport-go"
+)
+
+// webTransportAcceptTimeout 会话建立后等待客户端打开协同消息流的最长时间
+const webTransportAcceptTimeout = 10 * time.Second
+
+// EnableWebTransport 开启实验性 WebTransport 入口，来源检查与 WebSocket 相同。
+// server 需另行在 UDP 端口上启动，其 H3.Handler 应为注册了 /wt 路由的 Gin 引擎
+func (h *WSHandler) EnableWebTransport(server *webtransport.Server) {
+	server.CheckOrigin = h.upgrader.CheckOrigin
+	h.webTransport = server
+}
+
+// HandleWebTransport 处理 WebTransport 会话请求（实验性，HTTP/3 扩展 CONNECT）
+// CONNECT /wt?pageId=xxx&token=xxx&capabilities=text-ot&sinceVersion=42&deviceId=d-1a2b&deviceName=iPad
+// 鉴权、访客规则、握手限流、连接数上限与房间上限与 /ws 相同；会话建立后客户端打开一条双向流，
+// 流上每条消息为 4 字节大端长度前缀 + JSON，消息类型与 WebSocket 协议一致
+func (h *WSHandler) HandleWebTransport(c *gin.Context) {
+	if h.webTransport == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "WebTransport 未开启"})
+		return
+	}
+
+	pageID := c.Query("pageId")
+	if pageID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pageId 不能为空"})
+		return
+	}
+	if !h.placedHere(c, pageID) {
+		return
+	}
+	if !h.allowHandshake(c) {
+		return
+	}
+
+	userInfo, ok := h.authenticate(c, pageID)
+	if !ok {
+		return
+	}
+	userInfo.DeviceID, userInfo.DeviceName = declaredDevice(c)
+	release, ok := h.acquireConnection(c, userInfo)
+	if !ok {
+		return
+	}
+	admitted := false
+	defer func() {
+		if !admitted {
+			release()
+		}
+	}()
+
+	room, ok := h.joinRoom(c, pageID)
+	if !ok {
+		return
+	}
+
+	// Upgrade 需要 HTTP/3 原始 ResponseWriter 接管请求流
+	session, err := h.webTransport.Upgrade(unwrapResponseWriter(c.Writer), c.Request)
+	if err != nil {
+		logging.Warnf("[WT] 建立 WebTransport 会话失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "WebTransport 会话建立失败"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(session.Context(), webTransportAcceptTimeout)
+	defer cancel()
+	stream, err := session.AcceptStream(ctx)
+	if err != nil {
+		logging.Debugf("[WT] 等待客户端打开消息流失败: %v", err)
+		session.CloseWithError(0, "no stream")
+		return
+	}
+
+	client := ws.NewClient(h.hub, ws.NewStreamTransport(&sessionStream{Stream: stream, session: session}), pageID, userInfo)
+	client.Capabilities = ws.NegotiateCapabilities(c.Query("capabilities"))
+	if since, err := strconv.ParseInt(c.Query("sinceVersion"), 10, 64); err == nil && since > 0 {
+		client.SinceVersion = since
+	}
+
+	if err := room.Register(client); err != nil {
+		if errors.Is(err, ws.ErrRoomCapacity) || errors.Is(err, domainErrors.ErrSessionClosed) {
+			// 发送通道中已有 ROOM_FULL 或 SESSION_CLOSED 错误，写协程发送后关闭会话
+			go client.WritePump()
+			return
+		}
+		logging.Warnf("[WT] 注册客户端失败: %v", err)
+		session.CloseWithError(0, "register failed")
+		return
+	}
+
+	log.Printf("[WT] 用户 [%s] 通过 WebTransport 连接到页面 [%s]", userInfo.UserID, pageID)
+
+	admitted = true
+	go client.WritePump()
+	go func() {
+		client.ReadPump()
+		release()
+	}()
+}
+
+// unwrapResponseWriter 取出 Gin 包装下的原始 ResponseWriter
+func unwrapResponseWriter(w gin.ResponseWriter) http.ResponseWriter {
+	if u, ok := w.(interface{ Unwrap() http.ResponseWriter }); ok {
+		return u.Unwrap()
+	}
+	return w
+}
+
+// sessionStream 协同消息流，关闭时一并关闭所属会话
+type sessionStream struct {
+	*webtransport.Stream
+	session *webtransport.Session
+}
+
+func (s *sessionStream) Close() error {
+	return s.session.CloseWithError(0, "")
+}
@@ -1,32 +1,109 @@
 package controller
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"lowercode-go-server/api/middleware"
+	"lowercode-go-server/domain/entity"
 	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/authn"
+	"lowercode-go-server/internal/jwkscache"
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/ratelimit"
 	"lowercode-go-server/internal/ws"
+	"lowercode-go-server/usecase"
 
-	"github.com/clerk/clerk-sdk-go/v2/jwt"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/quic-go/webtransport-go"
 )
 
+// guestConnectsPerMinute 单个 IP 每分钟允许建立的访客连接数
+const guestConnectsPerMinute = 10
+
+// overloadRetryAfter 服务过载拒绝创建房间时建议的重试间隔（秒），过载保护的采样间隔为秒级
+const overloadRetryAfter = "10"
+
+// connCapRetryAfter 用户连接数达到上限时建议的重试间隔（秒），需要等已有连接关闭
+const connCapRetryAfter = "30"
+
+// GuestPolicy 判断页面是否允许未登录访客连接
+type GuestPolicy interface {
+	GuestEditAllowed(pageID string) (bool, error)
+}
+
+// PageAccess 查询登录用户在页面上的角色（owner/editor/viewer）
+type PageAccess interface {
+	PageRole(pageID, userID string) (string, error)
+}
+
+// ShareLinkVerifier 校验页面的公开分享链接 Token
+type ShareLinkVerifier interface {
+	Verify(pageID, token string) (*entity.ShareLink, error)
+}
+
+// GuestTokenVerifier 校验页面的临时访客 Token
+type GuestTokenVerifier interface {
+	Verify(pageID, token string) (*usecase.GuestGrant, error)
+}
+
+// UserProfiles 查询登录用户在协同房间中展示的显示名、头像和光标颜色
+type UserProfiles interface {
+	Presence(userID string) usecase.Presence
+}
+
 // WSHandler WebSocket 连接处理器
 type WSHandler struct {
-	hub      *ws.Hub
-	upgrader websocket.Upgrader
+	hub          *ws.Hub
+	guestPolicy  GuestPolicy
+	access       PageAccess
+	profiles     UserProfiles
+	guestLimiter *ratelimit.Limiter
+	auth         middleware.AuthVerifier
+	upgrader     websocket.Upgrader
+	compression  ws.CompressionConfig
+
+	// webTransport 实验性 WebTransport 入口，为 nil 时未开启，见 EnableWebTransport
+	webTransport *webtransport.Server
+
+	// shareLinks 分享链接校验，为 nil 时不接受分享链接连接，见 EnableShareLinks
+	shareLinks ShareLinkVerifier
+
+	// guestTokens 临时访客 Token 校验，为 nil 时不接受临时访客 Token 连接，见 EnableGuestTokens
+	guestTokens GuestTokenVerifier
+
+	// handshakes 按 IP 的握手限流，userConns 按用户的并发连接数上限，为 nil 时不限制，见 EnableConnectionLimits
+	handshakes *ratelimit.Limiter
+	userConns  *ratelimit.Concurrency
+
+	// placement 页面到节点的固定分配，为 nil 时任意节点都可以承载页面，见 EnablePlacement
+	placement *collabPlacement
 }
 
 // NewWSHandler 创建 WSHandler 实例
-func NewWSHandler(hub *ws.Hub, allowedOrigins []string) *WSHandler {
+// guestPolicy 为 nil 时不接受访客连接；access 为 nil 时不校验页面权限；profiles 为 nil 时只使用 Token 中的用户资料，按用户 ID 分配颜色
+// compression.Enabled 为 true 时与声明支持的客户端协商 permessage-deflate
+func NewWSHandler(hub *ws.Hub, guestPolicy GuestPolicy, access PageAccess, profiles UserProfiles, auth middleware.AuthVerifier, allowedOrigins []string, compression ws.CompressionConfig) *WSHandler {
 	return &WSHandler{
-		hub: hub,
+		hub:          hub,
+		guestPolicy:  guestPolicy,
+		access:       access,
+		profiles:     profiles,
+		guestLimiter: ratelimit.PerMinute(guestConnectsPerMinute),
+		auth:         auth,
+		compression:  compression,
 		upgrader: websocket.Upgrader{
-			ReadBufferSize:  1024,
-			WriteBufferSize: 1024,
+			ReadBufferSize:    1024,
+			WriteBufferSize:   1024,
+			Subprotocols:      ws.Subprotocols(),
+			EnableCompression: compression.Enabled,
 			CheckOrigin: func(r *http.Request) bool {
 				origin := r.Header.Get("Origin")
 				// 开发环境允许 localhost
@@ -39,7 +116,7 @@ func NewWSHandler(hub *ws.Hub, allowedOrigins []string) *WSHandler {
 						return true
 					}
 				}
-				log.Printf("[WS] 拒绝来自 %s 的连接", origin)
+				logging.Warnf("[WS] 拒绝来自 %s 的连接", origin)
 				return false
 			},
 		},
@@ -47,42 +124,182 @@ func NewWSHandler(hub *ws.Hub, allowedOrigins []string) *WSHandler {
 }
 
 // HandleWS 处理 WebSocket 升级请求
-// GET /ws?pageId=xxx
-// 需要在 URL 查询参数或 Sec-WebSocket-Protocol 中携带 JWT Token
+// GET /ws?pageId=xxx&capabilities=text-ot&sinceVersion=42&readonly=true&deviceId=d-1a2b&deviceName=iPad
+// 需要在 URL 查询参数或 Sec-WebSocket-Protocol 中携带 JWT Token；
+// Sec-WebSocket-Protocol 中包含 lowcode.msgpack 时，该连接的消息使用 MessagePack 二进制帧；
+// 页面开启了"持有链接即可编辑"时，未携带 Token 的连接以访客身份加入
+// capabilities 可选，声明客户端支持的可选能力（逗号分隔）
+// sinceVersion 可选，重连时客户端已有的版本号，服务端尽量只补发之后的 Patch（catch-up）
+// readonly=true 可选，以观看者身份加入（如"别人编辑时预览"），不能编辑，在线列表中标记为 spectator
+// deviceId、deviceName 可选，客户端保存的稳定设备标识和展示名称，同一用户的多个设备在在线列表中分别显示，
+// 同一设备的连接（如多个标签页）共享组件锁
+// 未携带 JWT 但携带 shareToken 时凭分享链接以匿名观看者身份加入，
+// 携带 guestToken 时凭临时访客 Token 以其授予的角色（editor / viewer）加入
 func (h *WSHandler) HandleWS(c *gin.Context) {
 	pageID := c.Query("pageId")
 	if pageID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "pageId 不能为空"})
 		return
 	}
+	if !h.placedHere(c, pageID) {
+		return
+	}
+	if !h.allowHandshake(c) {
+		return
+	}
+
+	userInfo, ok := h.authenticate(c, pageID)
+	if !ok {
+		return
+	}
+	userInfo.DeviceID, userInfo.DeviceName = declaredDevice(c)
+	release, ok := h.acquireConnection(c, userInfo)
+	if !ok {
+		return
+	}
+	admitted := false
+	defer func() {
+		if !admitted {
+			release()
+		}
+	}()
+
+	room, ok := h.joinRoom(c, pageID)
+	if !ok {
+		return
+	}
+
+	// 升级为 WebSocket 连接，底层连接经 WireCounter 写出，用于统计压缩后的字节数
+	writer, wire := ws.CountWireBytes(c.Writer)
+	conn, err := h.upgrader.Upgrade(writer, c.Request, nil)
+	if err != nil {
+		logging.Warnf("[WS] 升级 WebSocket 失败: %v", err)
+		return
+	}
+
+	// 创建客户端并注册到房间
+	client := ws.NewClient(h.hub, conn, pageID, userInfo)
+	client.Capabilities = ws.NegotiateCapabilities(c.Query("capabilities"))
+	client.Codec, _ = ws.CodecFor(conn.Subprotocol())
+	if h.compression.Enabled && ws.CompressionOffered(c.Request) {
+		if err := client.EnableCompression(h.compression, wire); err != nil {
+			logging.Warnf("[WS] 开启压缩失败，该连接不压缩: %v", err)
+		}
+	}
+	if since, err := strconv.ParseInt(c.Query("sinceVersion"), 10, 64); err == nil && since > 0 {
+		client.SinceVersion = since
+	}
+
+	if err := room.Register(client); err != nil {
+		if errors.Is(err, ws.ErrRoomCapacity) || errors.Is(err, domainErrors.ErrSessionClosed) {
+			// 发送通道中已有 ROOM_FULL 或 SESSION_CLOSED 错误，WritePump 发送后关闭连接
+			go client.WritePump()
+			return
+		}
+		logging.Warnf("[WS] 注册客户端失败: %v", err)
+		conn.Close()
+		return
+	}
+
+	if userInfo.Guest {
+		log.Printf("[WS] 访客 [%s] 从 %s 连接到页面 [%s]", userInfo.UserID, c.ClientIP(), pageID)
+	} else {
+		log.Printf("[WS] 用户 [%s] 连接到页面 [%s]", userInfo.UserID, pageID)
+	}
 
+	// 启动读写协程，读协程退出（连接关闭）时释放连接名额
+	admitted = true
+	go client.WritePump()
+	go func() {
+		client.ReadPump()
+		release()
+	}()
+}
+
+// authenticate 校验连接身份：携带 Token 时通过 AuthVerifier 验证 JWT，否则按访客规则放行。
+// 拒绝时已写入响应，返回 false
+func (h *WSHandler) authenticate(c *gin.Context, pageID string) (ws.UserInfo, bool) {
 	// 获取 JWT Token（WebSocket 不支持自定义 Header，从 URL 参数获取）
 	token := c.Query("token")
 	if token == "" {
-		token = c.GetHeader("Sec-WebSocket-Protocol")
+		token = subprotocolToken(c.Request)
 	}
 
 	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "缺少认证 token"})
-		return
+		if shareToken := c.Query("shareToken"); shareToken != "" {
+			return h.admitShareLink(c, pageID, shareToken)
+		}
+		if guestToken := c.Query("guestToken"); guestToken != "" {
+			return h.admitGuestToken(c, pageID, guestToken)
+		}
+		return h.admitGuest(c, pageID)
 	}
 
-	// 验证 Clerk JWT
-	claims, err := jwt.Verify(c.Request.Context(), &jwt.VerifyParams{
-		Token: token,
-	})
+	// 验证 JWT，与 /api 的 ClerkAuth 使用同一个验证器
+	claims, err := h.auth.Verify(c.Request.Context(), token)
+	if errors.Is(err, jwkscache.ErrUnavailable) {
+		logging.Warnf("[WS] 无法获取验签公钥: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "认证服务暂不可用，请稍后重试"})
+		return ws.UserInfo{}, false
+	}
 	if err != nil {
-		log.Printf("[WS] Token 验证失败: %v", err)
+		logging.Debugf("[WS] Token 验证失败: %v", err)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token 无效", "details": err.Error()})
-		return
+		return ws.UserInfo{}, false
+	}
+
+	role := ""
+	if h.access != nil {
+		role, err = h.access.PageRole(pageID, claims.Subject)
+		switch {
+		case errors.Is(err, domainErrors.ErrPageNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "页面不存在"})
+			return ws.UserInfo{}, false
+		case errors.Is(err, domainErrors.ErrUnauthorized):
+			c.JSON(http.StatusForbidden, gin.H{"error": "无权访问此页面"})
+			return ws.UserInfo{}, false
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return ws.UserInfo{}, false
+		}
 	}
 
-	// 获取或创建房间
+	presence := h.presence(claims)
+	return ws.UserInfo{
+		UserID:    claims.Subject,
+		UserName:  presence.Name,
+		AvatarURL: presence.AvatarURL,
+		Color:     presence.Color,
+		Role:      role, // viewer 可以加入房间查看实时变更，但不能提交编辑
+		// viewer 总是以观看者身份加入，其他角色可通过 readonly=true 主动只读
+		Spectator: role == entity.RoleViewer || readOnlyRequested(c),
+		SessionID: claims.SessionID, // 会话被吊销时断开连接
+	}, true
+}
+
+// joinRoom 获取或创建页面房间，失败时已写入响应，返回 false
+func (h *WSHandler) joinRoom(c *gin.Context, pageID string) (*ws.Room, bool) {
 	room, err := h.hub.GetOrCreateRoom(pageID)
 	if err != nil {
 		if errors.Is(err, domainErrors.ErrPageNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "页面不存在"})
-			return
+			return nil, false
+		}
+		if errors.Is(err, domainErrors.ErrServerShuttingDown) {
+			c.Header("Retry-After", "5")
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":      "服务正在重启，请稍后重试",
+				"retryAfter": 5000,
+			})
+			return nil, false
+		}
+		if errors.Is(err, domainErrors.ErrServerOverloaded) {
+			c.Header("Retry-After", overloadRetryAfter)
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":      "服务繁忙，请稍后重试",
+				"retryAfter": 10000,
+			})
+			return nil, false
 		}
 		if errors.Is(err, domainErrors.ErrRoomClosing) {
 			c.Header("Retry-After", "0.1")
@@ -90,61 +307,229 @@ func (h *WSHandler) HandleWS(c *gin.Context) {
 				"error":      "房间正在关闭，请稍后重试",
 				"retryAfter": 100,
 			})
-			return
+			return nil, false
+		}
+		if errors.Is(err, domainErrors.ErrRoomOwnedElsewhere) || errors.Is(err, domainErrors.ErrRoomLockUnavailable) {
+			// 转发后租约又发生变化，或锁服务暂不可用，重连后转发到新的承载节点
+			c.Header("Retry-After", "1")
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":      "页面所在节点正在切换，请稍后重试",
+				"retryAfter": 1000,
+			})
+			return nil, false
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, false
+	}
+	return room, true
+}
+
+// subprotocolToken 从 Sec-WebSocket-Protocol 中取出 JWT Token，跳过编码子协议
+func subprotocolToken(r *http.Request) string {
+	for _, protocol := range websocket.Subprotocols(r) {
+		if _, ok := ws.CodecFor(protocol); !ok {
+			return protocol
+		}
 	}
+	return ""
+}
 
-	// 升级为 WebSocket 连接
-	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+// admitGuest 校验访客连接：按 IP 限流，且页面需开启"持有链接即可编辑"。
+// 拒绝时已写入响应，返回 false。
+func (h *WSHandler) admitGuest(c *gin.Context, pageID string) (ws.UserInfo, bool) {
+	if h.guestPolicy == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "缺少认证 token"})
+		return ws.UserInfo{}, false
+	}
+
+	if allowed, retryAfter := h.guestLimiter.Allow(c.ClientIP()); !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "访客连接过于频繁，请稍后重试"})
+		return ws.UserInfo{}, false
+	}
+
+	allowed, err := h.guestPolicy.GuestEditAllowed(pageID)
 	if err != nil {
-		log.Printf("[WS] 升级 WebSocket 失败: %v", err)
-		return
+		if errors.Is(err, domainErrors.ErrPageNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "页面不存在"})
+			return ws.UserInfo{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return ws.UserInfo{}, false
+	}
+	if !allowed {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "缺少认证 token"})
+		return ws.UserInfo{}, false
 	}
 
-	// 创建客户端并注册到房间
-	userInfo := ws.UserInfo{
-		UserID:   claims.Subject,
-		UserName: claims.Subject, // TODO: 从 Clerk 获取用户名
-		Color:    generateUserColor(claims.Subject),
+	identity := newGuestIdentity()
+	identity.Spectator = readOnlyRequested(c)
+	return identity, true
+}
+
+// declaredDevice 连接通过 deviceId、deviceName 声明的设备，格式不合法时忽略
+func declaredDevice(c *gin.Context) (string, string) {
+	return ws.NormalizeDevice(c.Query("deviceId"), c.Query("deviceName"))
+}
+
+// readOnlyRequested 连接是否通过 readonly=true 请求只读观看模式
+func readOnlyRequested(c *gin.Context) bool {
+	readOnly, _ := strconv.ParseBool(c.Query("readonly"))
+	return readOnly
+}
+
+// EnableShareLinks 接受凭公开分享链接 Token 加入的匿名观看者连接
+func (h *WSHandler) EnableShareLinks(verifier ShareLinkVerifier) {
+	h.shareLinks = verifier
+}
+
+// admitShareLink 校验分享链接连接，与访客共用按 IP 的连接限流。
+// 通过时以该链接的观看者身份加入，只能观看不能编辑；拒绝时已写入响应，返回 false
+func (h *WSHandler) admitShareLink(c *gin.Context, pageID, token string) (ws.UserInfo, bool) {
+	if h.shareLinks == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "缺少认证 token"})
+		return ws.UserInfo{}, false
 	}
 
-	client := ws.NewClient(h.hub, conn, pageID, userInfo)
+	if allowed, retryAfter := h.guestLimiter.Allow(c.ClientIP()); !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "访客连接过于频繁，请稍后重试"})
+		return ws.UserInfo{}, false
+	}
 
-	if err := room.Register(client); err != nil {
-		log.Printf("[WS] 注册客户端失败: %v", err)
-		conn.Close()
-		return
+	link, err := h.shareLinks.Verify(pageID, token)
+	if err != nil {
+		if errors.Is(err, domainErrors.ErrShareLinkInvalid) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "分享链接无效、已过期或已被撤销"})
+			return ws.UserInfo{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return ws.UserInfo{}, false
 	}
 
-	log.Printf("[WS] 用户 [%s] 连接到页面 [%s]", userInfo.UserID, pageID)
+	visitorID := link.VisitorID()
+	return ws.UserInfo{
+		UserID:    visitorID,
+		UserName:  "分享链接访客",
+		Color:     usecase.DefaultCursorColor(visitorID),
+		Guest:     true,
+		Spectator: true,
+	}, true
+}
 
-	// 启动读写协程
-	go client.WritePump()
-	go client.ReadPump()
+// EnableGuestTokens 接受凭临时访客 Token 加入的连接
+func (h *WSHandler) EnableGuestTokens(verifier GuestTokenVerifier) {
+	h.guestTokens = verifier
+}
+
+// admitGuestToken 校验临时访客 Token 连接，与访客共用按 IP 的连接限流。
+// 通过时以 Token 中的访客身份和角色加入，viewer 只能观看；Token 只在建立连接时校验，
+// 过期后已建立的连接不受影响。拒绝时已写入响应，返回 false
+func (h *WSHandler) admitGuestToken(c *gin.Context, pageID, token string) (ws.UserInfo, bool) {
+	if h.guestTokens == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "缺少认证 token"})
+		return ws.UserInfo{}, false
+	}
+
+	if allowed, retryAfter := h.guestLimiter.Allow(c.ClientIP()); !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "访客连接过于频繁，请稍后重试"})
+		return ws.UserInfo{}, false
+	}
+
+	grant, err := h.guestTokens.Verify(pageID, token)
+	if err != nil {
+		if errors.Is(err, domainErrors.ErrGuestTokenInvalid) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "访客 Token 无效或已过期"})
+			return ws.UserInfo{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return ws.UserInfo{}, false
+	}
+
+	return ws.UserInfo{
+		UserID:    grant.GuestID,
+		UserName:  "访客 " + strings.ToUpper(grant.GuestID[len(grant.GuestID)-4:]),
+		Color:     usecase.DefaultCursorColor(grant.GuestID),
+		Guest:     true,
+		Role:      grant.Role,
+		Spectator: grant.Role == entity.RoleViewer || readOnlyRequested(c),
+	}, true
 }
 
-// generateUserColor 根据用户 ID 生成协作光标颜色
-func generateUserColor(userID string) string {
-	colors := []string{
-		"#FF6B6B", // 红色
-		"#4ECDC4", // 青色
-		"#45B7D1", // 蓝色
-		"#96CEB4", // 绿色
-		"#FFEAA7", // 黄色
-		"#DDA0DD", // 梅红
-		"#98D8C8", // 薄荷
-		"#F7DC6F", // 金色
+// EnableConnectionLimits 限制单个 IP 每分钟的握手次数和单个用户同时打开的连接数，为 0 时对应项不限制。
+// 访客没有固定身份，按 IP 计算连接数
+func (h *WSHandler) EnableConnectionLimits(handshakesPerMinute, maxConnsPerUser int) {
+	if handshakesPerMinute > 0 {
+		h.handshakes = ratelimit.PerMinute(handshakesPerMinute)
 	}
+	if maxConnsPerUser > 0 {
+		h.userConns = ratelimit.NewConcurrency(maxConnsPerUser)
+	}
+}
 
-	hash := 0
-	for _, c := range userID {
-		hash = hash*31 + int(c)
+// allowHandshake 按 IP 限制握手频率，在鉴权之前执行，拒绝时已写入 429 响应
+func (h *WSHandler) allowHandshake(c *gin.Context) bool {
+	if h.handshakes == nil {
+		return true
+	}
+	if allowed, retryAfter := h.handshakes.Allow(c.ClientIP()); !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "连接过于频繁，请稍后重试"})
+		return false
+	}
+	return true
+}
+
+// acquireConnection 为连接占用用户的并发名额，返回连接关闭时调用的释放函数；
+// 已达上限时写入 429 响应并返回 false
+func (h *WSHandler) acquireConnection(c *gin.Context, userInfo ws.UserInfo) (func(), bool) {
+	if h.userConns == nil {
+		return func() {}, true
+	}
+
+	key := userInfo.UserID
+	if userInfo.Guest {
+		key = "ip:" + c.ClientIP()
+	}
+	if !h.userConns.Acquire(key) {
+		c.Header("Retry-After", connCapRetryAfter)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "同时打开的连接过多，请关闭其他标签页后重试"})
+		return nil, false
 	}
-	if hash < 0 {
-		hash = -hash
+
+	return func() { h.userConns.Release(key) }, true
+}
+
+// newGuestIdentity 为访客分配临时身份，断开后即失效
+func newGuestIdentity() ws.UserInfo {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	id := "guest-" + hex.EncodeToString(buf)
+
+	return ws.UserInfo{
+		UserID:   id,
+		UserName: "访客 " + strings.ToUpper(id[len(id)-4:]),
+		Color:    usecase.DefaultCursorColor(id),
+		Guest:    true,
 	}
+}
 
-	return colors[hash%len(colors)]
+// presence 登录用户的展示信息：显示名和头像优先使用 Webhook 同步到数据库的资料，
+// 用户尚未同步时使用 Token 中的 name / picture 声明，都没有时以用户 ID 作为显示名
+func (h *WSHandler) presence(claims *authn.Claims) usecase.Presence {
+	presence := usecase.Presence{Color: usecase.DefaultCursorColor(claims.Subject)}
+	if h.profiles != nil {
+		presence = h.profiles.Presence(claims.Subject)
+	}
+	if presence.Name == "" {
+		presence.Name = claims.Name
+	}
+	if presence.Name == "" {
+		presence.Name = claims.Subject
+	}
+	if presence.AvatarURL == "" {
+		presence.AvatarURL = claims.Picture
+	}
+	return presence
 }
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader 服务端集成携带 API Key 的请求头
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator 校验 API Key 明文，由 usecase.APIKeyUseCase 实现；
+// 密钥无效时返回 ErrAPIKeyInvalid
+type APIKeyAuthenticator interface {
+	Authenticate(secret string) (*entity.APIKey, error)
+}
+
+// APIKeyOrClerkAuth 请求携带 X-API-Key 时按 API Key 认证，否则与 ClerkAuth 相同。
+// API Key 以创建者身份访问：GET/HEAD 请求需要 pages:read，其余请求需要 pages:write；
+// 属于组织的密钥同时注入组织 ID 和创建者当前的组织角色。apiKeys 为 nil 时不接受 API Key
+func APIKeyOrClerkAuth(verifier AuthVerifier, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
+	clerkAuth := ClerkAuth(verifier)
+	return func(c *gin.Context) {
+		secret := c.GetHeader(APIKeyHeader)
+		if secret == "" || apiKeys == nil {
+			clerkAuth(c)
+			return
+		}
+
+		key, err := apiKeys.Authenticate(secret)
+		if errors.Is(err, domainErrors.ErrAPIKeyInvalid) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API Key 无效、已过期或已被撤销"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "API Key 校验失败"})
+			return
+		}
+
+		scope := entity.APIKeyScopeWrite
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = entity.APIKeyScopeRead
+		}
+		if !key.Allows(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API Key 缺少 " + scope + " 权限"})
+			return
+		}
+
+		c.Set(ContextKeyUserID, key.UserID)
+		c.Set(ContextKeyAPIKeyID, key.ID)
+		if key.OrgID != "" {
+			c.Set(ContextKeyOrgID, key.OrgID)
+			c.Set(ContextKeyOrgRole, key.OrgRole)
+		}
+		c.Next()
+	}
+}
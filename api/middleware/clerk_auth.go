@@ -1,11 +1,15 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"os"
 	"strings"
 
-	"github.com/clerk/clerk-sdk-go/v2/jwt"
+	"lowercode-go-server/internal/authn"
+	"lowercode-go-server/internal/jwkscache"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -14,7 +18,15 @@ func isDebugMode() bool {
 	return os.Getenv("GIN_MODE") != "release"
 }
 
-func ClerkAuth() gin.HandlerFunc {
+// AuthVerifier 验证登录用户的 JWT，由 authn.Clerk 或 authn.OIDC 实现，部署时通过 AUTH_PROVIDER 选择。
+// 验签公钥不可用时返回 jwkscache.ErrUnavailable，调用方应返回 503 而非 401
+type AuthVerifier interface {
+	Verify(ctx context.Context, token string) (*authn.Claims, error)
+}
+
+// ClerkAuth 要求请求携带 Bearer Token，验证通过后注入用户 ID 和激活的组织。
+// 名称沿用 Clerk，实际验证由 verifier 完成，也可以是通用 OIDC 身份提供方
+func ClerkAuth(verifier AuthVerifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 1. 获取 Token (支持 Bearer Token)
 		authHeader := c.GetHeader("Authorization")
@@ -26,10 +38,12 @@ func ClerkAuth() gin.HandlerFunc {
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 
 		// 2. 验证 Token (核心)
-		// Clerk SDK 会自动拉取公钥并验证签名、过期时间
-		claims, err := jwt.Verify(c.Request.Context(), &jwt.VerifyParams{
-			Token: token,
-		})
+		// 公钥来自 JWKS 缓存，身份提供方短暂不可用时不影响验签
+		claims, err := verifier.Verify(c.Request.Context(), token)
+		if errors.Is(err, jwkscache.ErrUnavailable) {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "认证服务暂不可用，请稍后重试"})
+			return
+		}
 		if err != nil {
 			// 生产环境不暴露错误详情
 			if isDebugMode() {
@@ -42,7 +56,34 @@ func ClerkAuth() gin.HandlerFunc {
 
 		// 3. 将用户信息注入上下文，供后续 Controller 使用
 		c.Set(ContextKeyUserID, claims.Subject)
+		// 用户在前端切换到某个组织时，Token 携带该组织的 ID 和角色
+		if claims.OrgID != "" {
+			c.Set(ContextKeyOrgID, claims.OrgID)
+			c.Set(ContextKeyOrgRole, claims.OrgRole)
+		}
+
+		c.Next()
+	}
+}
+
+// ShareTokenHeader 分享链接 Token 的请求头，也可通过 shareToken 查询参数传递
+const ShareTokenHeader = "X-Share-Token"
+
+// ShareTokenOrClerkAuth 请求携带分享链接 Token 时免登录放行，Token 写入 Context 由 Controller 校验；
+// 否则与 APIKeyOrClerkAuth 相同，要求 API Key 或 Clerk JWT
+func ShareTokenOrClerkAuth(verifier AuthVerifier, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
+	clerkAuth := APIKeyOrClerkAuth(verifier, apiKeys)
+	return func(c *gin.Context) {
+		token := c.GetHeader(ShareTokenHeader)
+		if token == "" {
+			token = c.Query("shareToken")
+		}
+		if token == "" {
+			clerkAuth(c)
+			return
+		}
 
+		c.Set(ContextKeyShareToken, token)
 		c.Next()
 	}
 }
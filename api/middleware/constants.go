@@ -6,4 +6,19 @@ package middleware
 const (
 	// ContextKeyUserID 存储 Clerk 用户 ID 的 Context key
 	ContextKeyUserID = "userID"
+
+	// ContextKeyShareToken 存储请求携带的分享链接 Token 的 Context key，由 Controller 交给 UseCase 校验
+	ContextKeyShareToken = "shareToken"
+
+	// ContextKeyOrgID 存储 Token 中激活的 Clerk 组织 ID 的 Context key，未激活组织时不设置
+	ContextKeyOrgID = "orgID"
+
+	// ContextKeyOrgRole 存储用户在激活组织中的角色（如 org:admin）的 Context key
+	ContextKeyOrgRole = "orgRole"
+
+	// ContextKeyAPIKeyID 请求通过 API Key 认证时存储密钥 ID 的 Context key
+	ContextKeyAPIKeyID = "apiKeyID"
+
+	// ContextKeyPageRole 存储 RequirePageRole 解析出的页面角色（owner / editor / viewer）的 Context key
+	ContextKeyPageRole = "pageRole"
 )
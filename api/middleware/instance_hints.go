@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderInstanceState 实例不处于 ready 状态时附加的响应头，值为 overloaded / maintenance / draining，
+// 负载均衡和客户端据此优先选择其他实例
+const HeaderInstanceState = "X-Instance-State"
+
+// InstanceStateReporter 报告实例当前的状态，由 ws.Hub 实现（见 ws.InstanceReady 等常量）
+type InstanceStateReporter interface {
+	InstanceState() string
+}
+
+// InstanceHints 在响应上附加实例状态提示：非 ready 时设置 X-Instance-State；
+// 排空或维护中（实例即将退出负载均衡）同时设置 Connection: close，客户端的下一个请求建立新连接，
+// 由负载均衡分配到其他实例，避免 keep-alive 连接一直停留在本实例。reporter 为 nil 时不做任何事
+func InstanceHints(reporter InstanceStateReporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if reporter == nil {
+			c.Next()
+			return
+		}
+		switch state := reporter.InstanceState(); state {
+		case "ready":
+		case "overloaded":
+			c.Header(HeaderInstanceState, state)
+		default:
+			c.Header(HeaderInstanceState, state)
+			c.Header("Connection", "close")
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpsAuth 运维接口鉴权，校验 Authorization: Bearer <token> 与配置的 OPS_TOKEN 一致
+func OpsAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "运维 Token 无效"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PageRoleResolver 解析用户在页面上的角色，由 usecase.PageUseCase 实现；
+// 页面不存在时返回 ErrPageNotFound，无权访问时返回 ErrUnauthorized
+type PageRoleResolver interface {
+	PageRole(pageID, userID string) (string, error)
+}
+
+// RequirePageRole 解析当前用户在 :pageId 页面上的角色，低于 role 时返回 403，
+// 通过时把角色存入 ContextKeyPageRole，后续 Handler 无需再次查询（见 PageRole）。
+// 页面管理操作的所有者权限统一由路由上的 RequirePageRole 校验，UseCase 不再重复检查。
+// 必须注册在 ClerkAuth / APIKeyOrClerkAuth 之后
+func RequirePageRole(pages PageRoleResolver, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString(ContextKeyUserID)
+		if userID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "未获取到用户信息"})
+			return
+		}
+
+		actual, err := pages.PageRole(c.Param("pageId"), userID)
+		switch {
+		case errors.Is(err, domainErrors.ErrPageNotFound):
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "页面不存在"})
+			return
+		case errors.Is(err, domainErrors.ErrUnauthorized):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "无权访问此页面"})
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "页面权限校验失败"})
+			return
+		}
+		if entity.RoleRank(actual) < entity.RoleRank(role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "需要页面 " + role + " 权限"})
+			return
+		}
+
+		c.Set(ContextKeyPageRole, actual)
+		c.Next()
+	}
+}
+
+// PageRole 返回 RequirePageRole 解析出的页面角色，路由未注册 RequirePageRole 时返回空字符串
+func PageRole(c *gin.Context) string {
+	return c.GetString(ContextKeyPageRole)
+}
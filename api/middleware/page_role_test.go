@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== RequirePageRole 单元测试 ==========
+
+// fakePageRoles 按 "pageID/userID" 返回预设的角色或错误
+type fakePageRoles map[string]any
+
+func (f fakePageRoles) PageRole(pageID, userID string) (string, error) {
+	switch v := f[pageID+"/"+userID].(type) {
+	case string:
+		return v, nil
+	case error:
+		return "", v
+	}
+	return "", domainErrors.ErrUnauthorized
+}
+
+// servePageRole 以 userID 身份请求经 RequirePageRole(role) 保护的 DELETE /pages/:pageId，
+// 返回响应和后续 Handler 通过 PageRole 读到的角色（未执行时为空）
+func servePageRole(pages PageRoleResolver, role, userID, pageID string) (*httptest.ResponseRecorder, string) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	reached := ""
+	router.DELETE("/pages/:pageId", func(c *gin.Context) {
+		if userID != "" {
+			c.Set(ContextKeyUserID, userID)
+		}
+	}, RequirePageRole(pages, role), func(c *gin.Context) {
+		reached = PageRole(c)
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/pages/"+pageID, nil))
+	return w, reached
+}
+
+func TestRequirePageRole(t *testing.T) {
+	// 测试场景：未认证 401，页面不存在 404，无权访问或角色不够 403，其他错误 500，
+	// 角色足够时放行，后续 Handler 可读到调用者的实际角色
+
+	pages := fakePageRoles{
+		"page-1/alice":  entity.RoleOwner,
+		"page-1/bob":    entity.RoleEditor,
+		"page-1/carol":  domainErrors.ErrUnauthorized,
+		"missing/alice": domainErrors.ErrPageNotFound,
+		"broken/alice":  assert.AnError,
+	}
+
+	tests := []struct {
+		name    string
+		userID  string
+		pageID  string
+		status  int
+		reached string
+	}{
+		{"未获取到用户", "", "page-1", http.StatusUnauthorized, ""},
+		{"页面不存在", "alice", "missing", http.StatusNotFound, ""},
+		{"无权访问", "carol", "page-1", http.StatusForbidden, ""},
+		{"角色不够", "bob", "page-1", http.StatusForbidden, ""},
+		{"查询失败", "alice", "broken", http.StatusInternalServerError, ""},
+		{"所有者放行", "alice", "page-1", http.StatusNoContent, entity.RoleOwner},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, reached := servePageRole(pages, entity.RoleOwner, tt.userID, tt.pageID)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.reached, reached)
+		})
+	}
+
+	// 要求 editor 时 owner 与 editor 都放行，上下文中是各自的实际角色而非要求的最低角色
+	for userID, role := range map[string]string{"alice": entity.RoleOwner, "bob": entity.RoleEditor} {
+		w, reached := servePageRole(pages, entity.RoleEditor, userID, "page-1")
+		assert.Equal(t, http.StatusNoContent, w.Code, userID)
+		assert.Equal(t, role, reached, userID)
+	}
+}
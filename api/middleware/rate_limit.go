@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderCaptchaToken 客户端提交人机验证结果的请求头
+const HeaderCaptchaToken = "X-Captcha-Token"
+
+// CaptchaVerifier 人机验证（hCaptcha / Turnstile 等）的接入点。
+// 实现方负责调用第三方服务校验 token，返回是否通过。
+type CaptchaVerifier interface {
+	Verify(ctx context.Context, token, remoteIP string) (bool, error)
+}
+
+// RateLimitByIP 按客户端 IP 限流，超限直接返回 429
+func RateLimitByIP(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return SoftRateLimitByIP(limiter, nil)
+}
+
+// SoftRateLimitByIP 按客户端 IP 的软限流，面向未登录的公开创建入口（如模板"试一试"）。
+//
+// 额度内的请求直接放行；超出额度后：
+//   - 配置了 verifier 且请求携带有效的 X-Captcha-Token 时放行（人机验证兜底）
+//   - 否则返回 429，并通过 captchaRequired 提示前端展示验证码
+func SoftRateLimitByIP(limiter *ratelimit.Limiter, verifier CaptchaVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+
+		allowed, retryAfter := limiter.Allow(ip)
+		if allowed {
+			c.Next()
+			return
+		}
+
+		if verifier != nil {
+			if token := c.GetHeader(HeaderCaptchaToken); token != "" {
+				ok, err := verifier.Verify(c.Request.Context(), token, ip)
+				if err != nil {
+					logging.Warnf("[RateLimit] 人机验证调用失败: %v", err)
+				}
+				if ok {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		c.Header("Retry-After", retryAfterSeconds(retryAfter))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error":           "请求过于频繁，请稍后重试",
+			"captchaRequired": verifier != nil,
+		})
+	}
+}
+
+// retryAfterSeconds 将等待时间转换为 Retry-After 头的秒数（向上取整，至少 1 秒）
+func retryAfterSeconds(d time.Duration) string {
+	secs := int64(math.Ceil(d.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	return strconv.FormatInt(secs, 10)
+}
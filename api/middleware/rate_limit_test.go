@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lowercode-go-server/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== SoftRateLimitByIP 单元测试 ==========
+
+// fakeCaptcha 只认可 valid 这个 token，err 非空时模拟第三方服务调用失败
+type fakeCaptcha struct {
+	err error
+}
+
+func (f fakeCaptcha) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	return token == "valid", nil
+}
+
+// serveRateLimited 以同一 IP 请求经 SoftRateLimitByIP 保护的 POST /pages，token 非空时携带人机验证头
+func serveRateLimited(limiter *ratelimit.Limiter, verifier CaptchaVerifier, token string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/pages", SoftRateLimitByIP(limiter, verifier), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/pages", nil)
+	req.RemoteAddr = "203.0.113.7:12345"
+	if token != "" {
+		req.Header.Set(HeaderCaptchaToken, token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// captchaRequired 解析 429 响应中的 captchaRequired 字段
+func captchaRequired(t *testing.T, w *httptest.ResponseRecorder) bool {
+	t.Helper()
+	var body struct {
+		CaptchaRequired bool `json:"captchaRequired"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body.CaptchaRequired
+}
+
+func TestSoftRateLimitByIP_NoVerifier(t *testing.T) {
+	// 测试场景：额度内放行；超出额度且未配置人机验证时返回 429 和 Retry-After，
+	// captchaRequired 为 false，携带 token 也不放行
+
+	limiter := ratelimit.NewLimiter(0.001, 1)
+
+	assert.Equal(t, http.StatusCreated, serveRateLimited(limiter, nil, "").Code)
+
+	w := serveRateLimited(limiter, nil, "valid")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.False(t, captchaRequired(t, w))
+}
+
+func TestSoftRateLimitByIP_Captcha(t *testing.T) {
+	// 测试场景：超出额度后，有效 token 放行；缺少 token、token 无效或验证服务出错时返回 429，
+	// captchaRequired 为 true 提示前端展示验证码
+
+	limiter := ratelimit.NewLimiter(0.001, 1)
+	verifier := fakeCaptcha{}
+
+	assert.Equal(t, http.StatusCreated, serveRateLimited(limiter, verifier, "").Code)
+	assert.Equal(t, http.StatusCreated, serveRateLimited(limiter, verifier, "valid").Code)
+
+	for _, token := range []string{"", "forged"} {
+		w := serveRateLimited(limiter, verifier, token)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, token)
+		assert.True(t, captchaRequired(t, w), token)
+	}
+
+	w := serveRateLimited(limiter, fakeCaptcha{err: assert.AnError}, "valid")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.True(t, captchaRequired(t, w))
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+
+	"lowercode-go-server/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderRoomForwardedBy 转发到承载房间的节点时附加的请求头，值为目标节点地址。
+// 已转发过的请求不再转发，避免租约变化期间节点之间来回转发
+const HeaderRoomForwardedBy = "X-Room-Forwarded-By"
+
+// RoomOwnerLocator 查找承载页面房间的其他节点，由 ws.Hub 实现；
+// 房间在本节点或没有节点承载时返回空字符串
+type RoomOwnerLocator interface {
+	RoomOwner(pageID string) (string, error)
+}
+
+// ProxyToRoomOwner 启用房间租约的多节点部署中，把页面请求反向代理到承载该页面房间的节点（包括 WebSocket 升级）。
+// 页面 ID 取自路由参数 :pageId 或查询参数 pageId，两者都没有时在本节点处理。
+// 查询持有者失败时在本节点处理，由 GetOrCreateRoom 在创建房间时再次确认租约。
+// 注册在认证之前，由承载节点完成认证；locator 为 nil 时不做任何事
+func ProxyToRoomOwner(locator RoomOwnerLocator) gin.HandlerFunc {
+	if locator == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	var mu sync.Mutex
+	proxies := make(map[string]*httputil.ReverseProxy)
+	proxyFor := func(owner string) (*httputil.ReverseProxy, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if proxy, ok := proxies[owner]; ok {
+			return proxy, nil
+		}
+		target, err := url.Parse(owner)
+		if err != nil {
+			return nil, err
+		}
+		proxy := httputil.NewSingleHostReverseProxy(target)
+		proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			// 承载节点不可达：租约到期后其他节点即可接管，客户端稍后重试
+			logging.Warnf("[RoomOwner] 转发到节点 %s 失败: %v", owner, err)
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":"页面所在节点暂不可用，请稍后重试"}`))
+		}
+		proxies[owner] = proxy
+		return proxy, nil
+	}
+
+	return func(c *gin.Context) {
+		pageID := c.Param("pageId")
+		if pageID == "" {
+			pageID = c.Query("pageId")
+		}
+		if pageID == "" || c.GetHeader(HeaderRoomForwardedBy) != "" {
+			c.Next()
+			return
+		}
+
+		owner, err := locator.RoomOwner(pageID)
+		if err != nil {
+			logging.Warnf("[RoomOwner] 查询页面 %s 的承载节点失败，在本节点处理: %v", pageID, err)
+			c.Next()
+			return
+		}
+		if owner == "" {
+			c.Next()
+			return
+		}
+
+		proxy, err := proxyFor(owner)
+		if err != nil {
+			logging.Errorf("[RoomOwner] 节点地址 %q 无效: %v", owner, err)
+			c.AbortWithStatusJSON(http.StatusBadGateway, gin.H{"error": "页面所在节点地址无效"})
+			return
+		}
+		c.Request.Header.Set(HeaderRoomForwardedBy, owner)
+		proxy.ServeHTTP(c.Writer, c.Request)
+		c.Abort()
+	}
+}
@@ -1,8 +1,13 @@
 package route
 
 import (
+	"expvar"
+	"net/http"
+
 	"lowercode-go-server/api/controller"
 	"lowercode-go-server/api/middleware"
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/ratelimit"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,14 +15,53 @@ import (
 // Dependencies 路由依赖注入结构
 type Dependencies struct {
 	PageController    *controller.PageController
+	VersionController *controller.VersionController
+	CommentController *controller.CommentController
 	WSHandler         *controller.WSHandler
 	WebhookController *controller.WebhookController
+	UserController    *controller.UserController
+	Auth              middleware.AuthVerifier // 登录用户的 JWT 验证，Clerk 或通用 OIDC
+
+	// 页面角色解析，供 RequirePageRole 在路由上统一校验页面权限
+	PageRoles middleware.PageRoleResolver
+
+	CollaboratorController *controller.CollaboratorController
+	InviteController       *controller.InviteController
+	SecretController       *controller.SecretController
+	AIController           *controller.AIController
+	ShareLinkController    *controller.ShareLinkController
+	GuestTokenController   *controller.GuestTokenController
+
+	ConflictBackupController *controller.ConflictBackupController
+	BranchController         *controller.BranchController
+	MergeRequestController   *controller.MergeRequestController
+	StorageController        *controller.StorageController
+	UsageController          *controller.UsageController
+
+	// 服务端集成 API Key，APIKeys 为 nil 时业务接口只接受 Clerk JWT
+	APIKeyController *controller.APIKeyController
+	APIKeys          middleware.APIKeyAuthenticator
+
+	// 运维接口，OpsToken 为空时不注册
+	ConsistencyController *controller.ConsistencyController
+	AdminController       *controller.AdminController
+	ModerationController  *controller.ModerationController
+	OpsToken              string
+
+	// 房间租约，启用后页面请求转发到承载该页面房间的节点；nil 时在本节点处理
+	RoomOwners middleware.RoomOwnerLocator
+
+	// 实例状态，非 ready 时在响应上附加 X-Instance-State 提示；nil 时不附加
+	Instance middleware.InstanceStateReporter
 }
 
 // Setup 配置所有路由
 func Setup(router *gin.Engine, deps *Dependencies) {
 	// --- 公开路由 ---
 
+	// 实例排空、维护或过载时在所有响应上附加 X-Instance-State 提示
+	router.Use(middleware.InstanceHints(deps.Instance))
+
 	// 健康检查
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -26,20 +70,173 @@ func Setup(router *gin.Engine, deps *Dependencies) {
 		})
 	})
 
+	// 就绪检查，排空或维护中返回 503，供负载均衡摘除实例
+	router.GET("/ready", deps.AdminController.Ready)
+
+	// 已发布页面（只读取发布副本）
+	router.GET("/public/pages/:pageId", deps.PageController.GetPublishedPage)
+
 	// Clerk Webhook（使用签名验证，不使用 JWT）
 	router.POST("/webhook/clerk", deps.WebhookController.HandleClerkWebhook)
 
+	// 页面房间由其他节点承载时转发过去，由承载节点认证
+	toRoomOwner := middleware.ProxyToRoomOwner(deps.RoomOwners)
+
 	// --- WebSocket 路由 ---
 	// WebSocket 自行在 Handler 中验证 Token
-	router.GET("/ws", deps.WSHandler.HandleWS)
+	router.GET("/ws", toRoomOwner, deps.WSHandler.HandleWS)
+
+	// 实验性 WebTransport 入口，只在 HTTP/3 服务上可用，未开启时返回 404
+	router.Handle(http.MethodConnect, "/wt", deps.WSHandler.HandleWebTransport)
+
+	// 页面读取：携带分享链接 Token 时免登录只读访问，否则需要 API Key 或 Clerk JWT
+	router.GET("/api/pages/:pageId", toRoomOwner, middleware.ShareTokenOrClerkAuth(deps.Auth, deps.APIKeys), deps.PageController.GetPage)
+
+	// API Key 管理只接受 Clerk JWT，泄露的密钥不能用来签发新密钥
+	apiKeys := router.Group("/api/api-keys")
+	apiKeys.Use(middleware.ClerkAuth(deps.Auth))
+	{
+		apiKeys.GET("", deps.APIKeyController.ListAPIKeys)
+		apiKeys.POST("", deps.APIKeyController.CreateAPIKey)
+		apiKeys.DELETE("/:keyId", deps.APIKeyController.RevokeAPIKey)
+	}
 
-	// --- API 路由（需要 Clerk JWT 认证）---
+	// --- API 路由（需要 Clerk JWT 或 API Key 认证）---
 	api := router.Group("/api")
-	api.Use(middleware.ClerkAuth())
+	api.Use(toRoomOwner, middleware.APIKeyOrClerkAuth(deps.Auth, deps.APIKeys))
+	// 页面管理操作只允许所有者；作者或所有者均可执行的操作注册 anyRole，由 UseCase 按解析出的角色判断
+	ownerOnly := middleware.RequirePageRole(deps.PageRoles, entity.RoleOwner)
+	anyRole := middleware.RequirePageRole(deps.PageRoles, entity.RoleViewer)
 	{
 		// 页面 CRUD
-		api.GET("/pages/:pageId", deps.PageController.GetPage)
+		api.GET("/pages/:pageId/presence", deps.PageController.GetPresence)
+		api.GET("/pages/:pageId/poll", deps.PageController.PollPage)
+		api.GET("/pages/:pageId/collab-endpoint", deps.WSHandler.GetCollabEndpoint)
+		api.DELETE("/pages/:pageId/presence/:userId", ownerOnly, deps.PageController.KickUser)
+		api.GET("/pages", deps.PageController.ListPages)
 		api.POST("/pages", deps.PageController.CreatePage)
-		api.DELETE("/pages/:pageId", deps.PageController.DeletePage)
+		api.POST("/pages/import-legacy", deps.PageController.ImportLegacy)
+		api.DELETE("/pages/:pageId", ownerOnly, deps.PageController.DeletePage)
+		api.POST("/pages/:pageId/publish", ownerOnly, deps.PageController.PublishPage)
+		api.GET("/pages/:pageId/references", deps.PageController.GetReferences)
+		api.GET("/pages/:pageId/usages", deps.PageController.GetUsages)
+		api.PUT("/pages/:pageId/sharing", ownerOnly, deps.PageController.UpdateSharing)
+		api.PUT("/pages/:pageId/chat", ownerOnly, deps.PageController.UpdateChatSettings)
+		api.GET("/pages/:pageId/collab-settings", deps.PageController.GetCollabSettings)
+		api.PUT("/pages/:pageId/collab-settings", ownerOnly, deps.PageController.UpdateCollabSettings)
+		api.PUT("/pages/:pageId/session", ownerOnly, deps.PageController.SetSessionWindow)
+		api.DELETE("/pages/:pageId/session", ownerOnly, deps.PageController.ClearSessionWindow)
+		api.POST("/pages/:pageId/ops", deps.PageController.RunBulkOps)
+
+		// 页面协作者
+		api.GET("/pages/:pageId/collaborators", deps.CollaboratorController.ListCollaborators)
+		api.PUT("/pages/:pageId/collaborators/:userId", ownerOnly, deps.CollaboratorController.AddCollaborator)
+		api.DELETE("/pages/:pageId/collaborators/:userId", anyRole, deps.CollaboratorController.RemoveCollaborator)
+
+		// 组件密钥属性
+		api.GET("/pages/:pageId/secrets", deps.SecretController.RevealSecrets)
+		api.PUT("/pages/:pageId/secrets", deps.SecretController.SetSecret)
+
+		// AI 生成，每次调用模型服务，直接生成和生成修改建议共用按 IP 的限流
+		aiLimit := middleware.RateLimitByIP(ratelimit.PerMinute(controller.AIGenerationsPerMinute))
+		api.POST("/pages/:pageId/ai/generate", aiLimit, deps.AIController.Generate)
+		api.POST("/pages/:pageId/ai/proposals", aiLimit, deps.AIController.CreateProposal)
+		api.POST("/pages/:pageId/ai/proposals/:proposalId/accept", deps.AIController.AcceptProposal)
+		api.DELETE("/pages/:pageId/ai/proposals/:proposalId", deps.AIController.DiscardProposal)
+
+		// 通过邮箱邀请协作者
+		api.GET("/pages/:pageId/invites", ownerOnly, deps.InviteController.ListInvites)
+		api.POST("/pages/:pageId/invites", ownerOnly, deps.InviteController.CreateInvite)
+		api.DELETE("/pages/:pageId/invites/:inviteId", ownerOnly, deps.InviteController.RevokeInvite)
+
+		// 公开只读分享链接
+		api.GET("/pages/:pageId/share-links", ownerOnly, deps.ShareLinkController.ListShareLinks)
+		api.POST("/pages/:pageId/share-links", ownerOnly, deps.ShareLinkController.CreateShareLink)
+		api.DELETE("/pages/:pageId/share-links/:linkId", ownerOnly, deps.ShareLinkController.RevokeShareLink)
+		api.POST("/pages/:pageId/guest-token", ownerOnly, deps.GuestTokenController.CreateGuestToken)
+
+		// 冲突备份（协同连接上传的被拒绝的本地状态）
+		api.GET("/pages/:pageId/conflict-backups", deps.ConflictBackupController.ListConflictBackups)
+		api.GET("/pages/:pageId/conflict-backups/:backupId", deps.ConflictBackupController.GetConflictBackup)
+		api.DELETE("/pages/:pageId/conflict-backups/:backupId", deps.ConflictBackupController.DeleteConflictBackup)
+
+		// 私有草稿分支
+		api.GET("/pages/:pageId/branches", deps.BranchController.ListBranches)
+		api.POST("/pages/:pageId/branches", deps.BranchController.CreateBranch)
+		api.GET("/pages/:pageId/branches/:branchId", deps.BranchController.GetBranch)
+		api.POST("/pages/:pageId/branches/:branchId/merge", deps.BranchController.MergeBranch)
+		api.DELETE("/pages/:pageId/branches/:branchId", deps.BranchController.DeleteBranch)
+		api.GET("/pages/:pageId/merge-requests", deps.MergeRequestController.ListMergeRequests)
+		api.POST("/pages/:pageId/merge-requests", deps.MergeRequestController.OpenMergeRequest)
+		api.GET("/pages/:pageId/merge-requests/:mrId", deps.MergeRequestController.GetMergeRequest)
+		api.POST("/pages/:pageId/merge-requests/:mrId/merge", deps.MergeRequestController.MergeMergeRequest)
+		api.POST("/pages/:pageId/merge-requests/:mrId/close", deps.MergeRequestController.CloseMergeRequest)
+		api.GET("/pages/:pageId/merge-requests/:mrId/comments", deps.MergeRequestController.ListMergeRequestComments)
+		api.POST("/pages/:pageId/merge-requests/:mrId/comments", deps.MergeRequestController.CreateMergeRequestComment)
+
+		// 当前用户资料与偏好
+		api.GET("/users/me", deps.UserController.GetMe)
+		api.PUT("/users/me/cursor-color", deps.UserController.UpdateCursorColor)
+		api.GET("/me/recent-pages", deps.UserController.GetRecentPages)
+		api.GET("/me/quota", deps.PageController.GetQuota)
+
+		// 当前租户（组织或个人）的存储用量与套餐
+		api.GET("/storage", deps.StorageController.GetStorage)
+		// 当前租户本月按套餐计量的调用次数与额度
+		api.GET("/usage", deps.UsageController.GetUsage)
+
+		// 当前租户自带的模型服务与 AI 用量，激活组织时仅组织管理员
+		api.GET("/ai/provider", deps.AIController.GetProvider)
+		api.PUT("/ai/provider", deps.AIController.SetProvider)
+		api.DELETE("/ai/provider", deps.AIController.DeleteProvider)
+		api.GET("/ai/usage", deps.AIController.GetUsage)
+
+		// 历史版本
+		api.GET("/pages/:pageId/diff", deps.VersionController.GetDiff)
+		api.GET("/pages/:pageId/history", deps.VersionController.GetHistory)
+
+		// 组件评论
+		api.GET("/pages/:pageId/comments", deps.CommentController.ListComments)
+		api.POST("/pages/:pageId/comments", deps.CommentController.CreateComment)
+		api.PUT("/pages/:pageId/comments/:commentId", deps.CommentController.UpdateComment)
+		api.PUT("/pages/:pageId/comments/:commentId/resolve", deps.CommentController.ResolveComment)
+		api.DELETE("/pages/:pageId/comments/:commentId", anyRole, deps.CommentController.DeleteComment)
+	}
+
+	// --- 运维路由（需要 OPS_TOKEN）---
+	if deps.OpsToken != "" {
+		ops := router.Group("/ops")
+		ops.Use(middleware.OpsAuth(deps.OpsToken))
+		{
+			ops.GET("/metrics", gin.WrapH(expvar.Handler()))
+			ops.GET("/consistency", deps.ConsistencyController.GetReport)
+			ops.POST("/consistency/run", deps.ConsistencyController.RunCheck)
+		}
+
+		// 运行时配置，与 /api 下的业务接口分组，使用运维 Token 而非 Clerk JWT
+		admin := router.Group("/api/admin")
+		admin.Use(middleware.OpsAuth(deps.OpsToken))
+		{
+			admin.GET("/config", deps.AdminController.GetConfig)
+			admin.GET("/rooms", deps.AdminController.GetRooms)
+			admin.GET("/rooms/:pageId/repro", deps.AdminController.GetReproBundle)
+			admin.POST("/announce",
+				middleware.RateLimitByIP(ratelimit.PerMinute(controller.AnnouncementsPerMinute)),
+				deps.AdminController.Announce)
+			admin.GET("/maintenance", deps.AdminController.GetMaintenance)
+			admin.PUT("/maintenance", deps.AdminController.SetMaintenance)
+
+			// 租户存储用量与套餐
+			admin.GET("/storage", deps.StorageController.ListTenantStorage)
+			admin.POST("/storage/recount", deps.StorageController.RecountStorage)
+			admin.GET("/tenants/:tenantId/storage", deps.StorageController.GetTenantStorage)
+			admin.PUT("/tenants/:tenantId/plan", deps.StorageController.SetTenantPlan)
+			admin.GET("/tenants/:tenantId/ai-usage", deps.AIController.GetTenantUsage)
+			admin.GET("/tenants/:tenantId/usage", deps.UsageController.GetTenantUsage)
+
+			// 内容审核记录
+			admin.GET("/moderation/flags", deps.ModerationController.ListFlags)
+			admin.POST("/moderation/flags/:flagId/review", deps.ModerationController.ReviewFlag)
+		}
 	}
 }
@@ -0,0 +1,65 @@
+package bootstrap
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"lowercode-go-server/api/middleware"
+	"lowercode-go-server/internal/authn"
+	"lowercode-go-server/internal/jwkscache"
+	"lowercode-go-server/internal/logging"
+)
+
+// oidcHTTPTimeout 请求 OIDC 发现文档和 JWKS 的超时时间
+const oidcHTTPTimeout = 5 * time.Second
+
+// NewAuthVerifier 按 AUTH_PROVIDER 创建登录用户的 JWT 验证器，并完成对应的启动自检。
+// clerk 需要 CLERK_SECRET_KEY；oidc 从 OIDC_ISSUER 的发现文档（或 OIDC_JWKS_URL）拉取公钥。
+// 验证通过的 Token 属于 revocations 中已吊销的会话时被拒绝
+func NewAuthVerifier(env *Env, revocations *authn.Revocations) middleware.AuthVerifier {
+	return authn.WithRevocations(newProviderVerifier(env), revocations)
+}
+
+// newProviderVerifier 创建 AUTH_PROVIDER 对应的验证器
+func newProviderVerifier(env *Env) middleware.AuthVerifier {
+	if env.AuthProvider == authn.ProviderOIDC {
+		return newOIDCVerifier(env)
+	}
+
+	InitClerk()
+
+	// Clerk 验签公钥缓存，上游不可用时在容忍期内继续使用旧公钥
+	keys := NewClerkKeyCache(env)
+
+	// 启动自检：校验 Clerk 密钥并预热公钥缓存，检查 Webhook 签名密钥
+	CheckClerk(env, keys)
+	return authn.NewClerk(keys)
+}
+
+// newOIDCVerifier 创建通用 OIDC 验证器并预热公钥缓存；拉取失败只告警，首次验签时重试
+func newOIDCVerifier(env *Env) middleware.AuthVerifier {
+	client := &http.Client{Timeout: oidcHTTPTimeout}
+	keys := jwkscache.New(authn.FetchJWKS(client, env.OIDCIssuer, env.OIDCJWKSURL),
+		env.ClerkJWKSTTL, env.ClerkJWKSMaxStale)
+
+	ctx, cancel := context.WithTimeout(context.Background(), clerkCheckTimeout)
+	defer cancel()
+	if err := keys.Refresh(ctx); err != nil {
+		logging.Warnf("[Auth] 启动时无法获取 OIDC 验签公钥，将在首次验签时重试: %v", err)
+	} else {
+		log.Printf("[Auth] 使用 OIDC 身份提供方 %s，验签公钥已缓存", env.OIDCIssuer)
+	}
+	if env.OIDCAudience == "" {
+		logging.Warnf("[Auth] 未配置 OIDC_AUDIENCE，同一身份提供方为其他应用签发的 Token 也会被接受")
+	}
+
+	return authn.NewOIDC(authn.OIDCConfig{
+		Issuer:       env.OIDCIssuer,
+		Audience:     env.OIDCAudience,
+		JWKSURL:      env.OIDCJWKSURL,
+		OrgClaim:     env.OIDCOrgClaim,
+		OrgRoleClaim: env.OIDCOrgRoleClaim,
+	}, keys)
+}
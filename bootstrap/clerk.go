@@ -1,18 +1,86 @@
 package bootstrap
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"strings"
+	"time"
+
+	"lowercode-go-server/internal/jwkscache"
+	"lowercode-go-server/internal/logging"
 
 	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/clerk/clerk-sdk-go/v2/jwks"
 )
 
+// clerkCheckTimeout 启动自检请求 Clerk 的超时时间
+const clerkCheckTimeout = 10 * time.Second
+
 func InitClerk() {
 	secret := os.Getenv("CLERK_SECRET_KEY")
 	if secret == "" {
-		log.Fatal("未找到CLERK_SECRET_KEY")
+		logging.Fatalf("未找到CLERK_SECRET_KEY")
 	}
 	clerk.SetKey(secret)
 
 	log.Println("Clerk初始化成功")
 }
+
+// NewClerkKeyCache 创建 Clerk 验签公钥缓存，需在 InitClerk 之后调用
+func NewClerkKeyCache(env *Env) *jwkscache.Cache {
+	return jwkscache.New(func(ctx context.Context) (*clerk.JSONWebKeySet, error) {
+		return jwks.Get(ctx, &jwks.GetParams{})
+	}, env.ClerkJWKSTTL, env.ClerkJWKSMaxStale)
+}
+
+// CheckClerk 启动自检：拉取一次 JWKS 以验证 CLERK_SECRET_KEY（同时预热公钥缓存），
+// 并检查生产环境是否配置了 CLERK_WEBHOOK_SECRET。
+// 开启 CLERK_STRICT_STARTUP 时，密钥被 Clerk 拒绝或生产环境缺少 Webhook 密钥将拒绝启动；
+// 网络故障只告警，避免 Clerk 短暂不可用时服务无法重启。
+func CheckClerk(env *Env, keys *jwkscache.Cache) {
+	release := os.Getenv("GIN_MODE") == "release"
+	var problems []string
+
+	ctx, cancel := context.WithTimeout(context.Background(), clerkCheckTimeout)
+	defer cancel()
+
+	var apiErr *clerk.APIErrorResponse
+	err := keys.Refresh(ctx)
+	switch {
+	case err == nil:
+		log.Println("[Clerk] 密钥校验通过，验签公钥已缓存")
+	case errors.As(err, &apiErr) &&
+		(apiErr.HTTPStatusCode == http.StatusUnauthorized || apiErr.HTTPStatusCode == http.StatusForbidden):
+		problems = append(problems, fmt.Sprintf(
+			"CLERK_SECRET_KEY 被 Clerk 拒绝 (HTTP %d)，登录用户的请求将全部失败", apiErr.HTTPStatusCode))
+	default:
+		logging.Warnf("[Clerk] 启动时无法连接 Clerk，将在首次验签时重试: %v", err)
+	}
+
+	if release && strings.HasPrefix(env.ClerkSecretKey, "sk_test_") {
+		logging.Warnf("[Clerk] 生产环境使用的是测试密钥 (sk_test_)")
+	}
+	if release && env.WebhookSecret == "" {
+		problems = append(problems,
+			"生产环境未配置 CLERK_WEBHOOK_SECRET，/webhook/clerk 将拒绝所有回调（503），用户信息不会同步")
+	}
+
+	if !release && env.WebhookSecret == "" && !env.WebhookAllowUnsigned {
+		log.Println("[Clerk] 未配置 CLERK_WEBHOOK_SECRET，/webhook/clerk 将返回 503；本地调试可设置 CLERK_WEBHOOK_ALLOW_UNSIGNED=true")
+	}
+
+	if len(problems) == 0 {
+		return
+	}
+	for _, p := range problems {
+		logging.Errorf("[Clerk] !!!!!!!! %s", p)
+	}
+	if env.ClerkStrictStartup {
+		logging.Fatalf("[Clerk] CLERK_STRICT_STARTUP 已开启，自检未通过，拒绝启动")
+	}
+	log.Println("[Clerk] 设置 CLERK_STRICT_STARTUP=true 可在自检未通过时拒绝启动")
+}
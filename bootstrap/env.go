@@ -2,7 +2,17 @@ package bootstrap
 
 import (
 	"log"
+	"net/url"
 	"os"
+	"strconv"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/authn"
+	"lowercode-go-server/internal/llm"
+	"lowercode-go-server/internal/moderation"
+	"lowercode-go-server/internal/placement"
+	"lowercode-go-server/internal/ws"
 
 	"github.com/joho/godotenv"
 )
@@ -13,6 +23,189 @@ type Env struct {
 	ClerkSecretKey string // Clerk API 密钥
 	WebhookSecret  string // Clerk Webhook 签名密钥
 	Port           string // 服务端口
+	OpsToken       string // 运维接口 Token，为空时不开放 /ops 路由
+
+	// 分享链接、删除确认和临时访客 Token 的签名密钥，为空时启动时随机生成，重启后已发出的链接全部失效
+	ShareLinkSecret string
+
+	// 密钥属性的 AES-256 加密密钥（base64 编码的 32 字节），为空时不能设置密钥属性
+	SecretPropsKey string
+
+	ConsistencyCheckHour int // 每日一致性巡检的执行时刻（0-23，本地时间）
+	SnapshotEveryFlushes int // 每 N 次刷盘写一次全量快照，其余刷盘只追加差量；<= 1 时每次全量
+
+	// 自适应刷盘的边界：编辑稀疏时按最短间隔定时刷盘，编辑密集时阈值在上下限之间随速率调整
+	FlushMinInterval  time.Duration
+	FlushMaxInterval  time.Duration
+	FlushMinThreshold int
+	FlushMaxThreshold int
+
+	// 刷盘协程池：同时写数据库的房间数上限，刷盘失败后的最大重试次数
+	FlushWorkers    int
+	FlushMaxRetries int
+
+	// 历史版本保留策略
+	VersionRetainAll       time.Duration // 全量保留的时间窗口
+	VersionRetainHourly    time.Duration // 按小时保留的时间窗口，更早的按天保留
+	VersionCompactInterval time.Duration // 压缩任务执行间隔
+
+	// 操作日志上限，超出部分在压缩时折叠进检查点快照
+	OpJournalMaxOps int           // 每个页面保留的最近操作条数，<= 0 不限
+	OpJournalMaxAge time.Duration // 操作日志保留时长
+
+	// 租户存储配额，按套餐限制总用量（MB），0 表示不限
+	StorageDefaultPlan      string // 未单独设置套餐的租户使用的套餐
+	StoragePlanFreeMB       int
+	StoragePlanProMB        int
+	StoragePlanEnterpriseMB int
+
+	// 按套餐计量的每月调用次数，格式如 "ai=100,publish=300"，0 或未列出的类型不限；套餐与存储配额共用
+	UsagePlanFree       string
+	UsagePlanPro        string
+	UsagePlanEnterprise string
+
+	// 每个用户最多创建的页面数（不含草稿分支），0 表示不限
+	MaxPagesPerUser int
+
+	// 房间归档到 S3 兼容对象存储，ArchiveS3Bucket 为空时不归档
+	ArchiveS3Bucket       string
+	ArchiveS3Region       string
+	ArchiveS3Endpoint     string // 为空时使用 AWS 默认地址
+	ArchiveS3AccessKey    string
+	ArchiveS3SecretKey    string
+	ArchiveS3Prefix       string // 对象键前缀，便于配置存储桶生命周期规则
+	ArchiveS3StorageClass string
+	ArchiveS3PathStyle    bool // MinIO 等自建服务需要开启
+
+	// 协作邀请邮件，SMTPHost 为空时邮件只写入日志、不实际发送
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string // 发件人，如 "LowCode <noreply@example.com>"
+	InviteURL    string // 邀请邮件中的页面链接，{pageId} 替换为页面 ID
+
+	// AI 生成使用的平台默认模型服务，LLMAPIKey 为空（且不是 local）时只能使用租户自带的模型服务
+	LLMProvider   string // openai（默认，OpenAI 兼容接口）/ azure / local
+	LLMBaseURL    string
+	LLMAPIKey     string
+	LLMModel      string // Azure 为部署名
+	LLMAPIVersion string // 仅 Azure
+
+	// 平台和租户模型服务共用的请求 / 响应大小上限
+	LLMMaxRequestKB  int
+	LLMMaxResponseKB int
+
+	// 允许租户的模型服务指向内网地址和 http，仅用于私有化部署
+	LLMAllowPrivateEndpoints bool
+
+	// 内容审核：审核协同房间内的聊天消息和组件属性文本，词表和审核服务都未配置时不审核
+	ModerationWordlistFile string        // 词表文件，每行一个词，# 开头的行为注释
+	ModerationAPIURL       string        // 外部审核服务地址
+	ModerationAPIKey       string        // 外部审核服务的 Bearer Token
+	ModerationAPITimeout   time.Duration // 外部审核服务超时时间
+	ModerationActions      string        // 命中后的处理方式，逗号分隔：reject / flag / notify
+	ModerationFailClosed   bool          // 审核服务不可用时拒绝提交，默认放行
+
+	// 登录用户的 JWT 验证：clerk（默认）或 oidc
+	AuthProvider string
+
+	// 通用 OIDC 身份提供方，AUTH_PROVIDER=oidc 时生效
+	OIDCIssuer       string // 与 Token 的 iss 完全一致
+	OIDCAudience     string // 非空时校验 Token 的 aud
+	OIDCJWKSURL      string // 为空时从发现文档获取
+	OIDCOrgClaim     string // 组织 ID 所在的声明，为空时不读取组织
+	OIDCOrgRoleClaim string // 组织角色所在的声明
+
+	// 验签公钥缓存，Clerk 与 OIDC 共用
+	ClerkJWKSTTL      time.Duration // 缓存有效期，过期后刷新
+	ClerkJWKSMaxStale time.Duration // 刷新失败时过期缓存的额外可用时长
+
+	// 登录会话吊销（Clerk session.ended / session.revoked / user.deleted Webhook）
+	TokenRevocationTTL          time.Duration // 吊销记录保留时长，应不短于 Token 的最长有效期
+	TokenRevocationSyncInterval time.Duration // 各实例同步吊销记录、断开对应连接的间隔
+
+	ClerkStrictStartup bool // 启动自检发现密钥被拒或生产环境缺少 Webhook 密钥时拒绝启动
+
+	// WebhookAllowUnsigned 未配置 Webhook 密钥时处理未签名的回调，仅开发环境生效
+	WebhookAllowUnsigned bool
+
+	// UserDeleteDryRun 收到 user.deleted 时只记录清理计划（转移、删除哪些页面），不修改数据
+	UserDeleteDryRun bool
+
+	// WebSocket permessage-deflate 压缩
+	WSCompression        bool // 与声明支持的客户端协商压缩
+	WSCompressionLevel   int  // flate 压缩级别，1（最快）到 9（压缩率最高）
+	WSCompressionMinSize int  // 小于该字节数的消息不压缩
+
+	// WSLatencyTracking 记录编辑链路各阶段的耗时直方图，并在 ack 中带回服务端耗时
+	WSLatencyTracking bool
+
+	// WebSocket 连接心跳与限制
+	WSPongWait       time.Duration // 等待 Pong 的最大时间，超时断开
+	WSPingPeriod     time.Duration // Ping 发送间隔，默认为 WSPongWait 的 90%
+	WSWriteWait      time.Duration // 写消息超时时间
+	WSMaxMessageSize int           // 单条入站消息的最大字节数
+	WSSendBufferSize int           // 每个连接发送缓冲区可容纳的消息数
+
+	WSMaxClientsPerRoom int // 单个房间的连接数上限，0 表示不限制
+	WSHubShards         int // Hub 房间表的分片数，房间很多时减少锁争用
+
+	// 停机前的排空时间：收到停机信号后 /ready 先返回 503，客户端在此时间内分散重连到其他实例，之后再关闭房间；0 表示不排空
+	DrainGrace time.Duration
+
+	// 握手限流与连接数上限，0 表示不限制
+	WSHandshakesPerMinute int // 单个 IP 每分钟允许的握手次数（/ws 与 /wt 合计）
+	WSMaxConnsPerUser     int // 单个用户同时打开的连接数，访客按 IP 计算
+
+	// 过载保护：任一指标超过阈值时拒绝创建新房间并对光标广播降频，阈值为 0 时不检查
+	WatchdogEnabled        bool
+	WatchdogInterval       time.Duration // 采样间隔
+	WatchdogMaxGoroutines  int
+	WatchdogMaxHeapMB      int
+	WatchdogMaxLoopLag     time.Duration // 调度延迟上限
+	WatchdogCursorInterval time.Duration // 过载期间每个连接光标、视口广播的最小间隔
+
+	ReproJournalOps int // 每个房间为复现包保留的最近操作数，0 表示不记录
+
+	// 多节点部署时通过 Redis Pub/Sub 在节点之间转发房间消息，RedisURL 为空时房间只在本节点内协作
+	RedisURL           string // 如 redis://:password@localhost:6379/0
+	RedisChannelPrefix string // 频道名和缓存键的前缀，多个环境共用一个 Redis 时用于隔离
+
+	// 配置 RedisURL 时在 Redis 中缓存页面读取结果的有效期，0 表示不缓存
+	PageCacheTTL time.Duration
+
+	// 配置 RedisURL 时空闲房间休眠到 Redis 的保留时间，期间再次加入无需读取数据库，0 表示不休眠
+	RoomHibernateTTL time.Duration
+
+	// 房间租约：同一页面只由一个节点承载权威房间，其他节点把页面请求转发过去，需要配置 RedisURL
+	RoomLockEnabled  bool
+	RoomLockTTL      time.Duration // 租约有效期，节点失联后其他节点最多等待该时长接管页面
+	NodeAdvertiseURL string        // 本节点供其他节点转发请求的地址，如 http://10.0.0.12:8080
+
+	// 不依赖 Redis 的多节点部署：按页面 ID 把协同连接固定到节点列表中的某个节点，各节点配置相同的 CollabNodes
+	CollabNodes        string // 如 node-a=wss://a.example.com,node-b=wss://b.example.com，为空时不分配
+	NodeID             string // 本节点在 CollabNodes 中的 ID
+	CollabStickyCookie string // 负载均衡按 Cookie 路由时的 Cookie 名，有节点没有直连地址时必须配置
+
+	// WebSocket 入站消息限流，突发量为每秒速率的 2 倍，速率为 0 时不限制
+	WSEditRate          int // 每个连接每秒允许的 op-patch、text-op 条数
+	WSCursorRate        int // 每个连接每秒允许的 cursor-move 条数
+	WSRateMaxViolations int // 被限流消息的容忍量，每秒恢复 1 条，耗尽后断开连接；0 表示只限流不断开
+
+	// 实验性 WebTransport 入口，基于 HTTP/3 (UDP)，需要 TLS 证书
+	WebTransportEnabled  bool
+	WebTransportPort     string // UDP 端口
+	WebTransportCertFile string
+	WebTransportKeyFile  string
+
+	// 日志
+	LogLevel      string // debug / info / warn / error，默认开发环境 debug、生产环境 info
+	LogFormat     string // text / json
+	LogOutput     string // stdout / stderr / file / syslog
+	LogFile       string // LOG_OUTPUT=file 时的文件路径
+	LogMaxSizeMB  int    // 单个日志文件上限，超出后轮转
+	LogMaxBackups int    // 保留的历史日志文件个数
 }
 
 // LoadEnv 加载环境变量
@@ -27,13 +220,337 @@ func LoadEnv() *Env {
 		ClerkSecretKey: os.Getenv("CLERK_SECRET_KEY"),
 		WebhookSecret:  os.Getenv("CLERK_WEBHOOK_SECRET"),
 		Port:           os.Getenv("PORT"),
+		OpsToken:       os.Getenv("OPS_TOKEN"),
+
+		ShareLinkSecret: os.Getenv("SHARE_LINK_SECRET"),
+		SecretPropsKey:  os.Getenv("SECRET_PROPS_KEY"),
+
+		ConsistencyCheckHour: getEnvInt("CONSISTENCY_CHECK_HOUR", 3),
+		SnapshotEveryFlushes: getEnvInt("SNAPSHOT_EVERY_FLUSHES", 10),
+
+		FlushMinInterval:  getEnvDuration("FLUSH_MIN_INTERVAL", 2*time.Second),
+		FlushMaxInterval:  getEnvDuration("FLUSH_MAX_INTERVAL", 30*time.Second),
+		FlushMinThreshold: getEnvInt("FLUSH_MIN_THRESHOLD", 20),
+		FlushMaxThreshold: getEnvInt("FLUSH_MAX_THRESHOLD", 500),
+
+		FlushWorkers:    getEnvInt("FLUSH_WORKERS", ws.DefaultFlushWorkers.Workers),
+		FlushMaxRetries: getEnvInt("FLUSH_MAX_RETRIES", ws.DefaultFlushWorkers.MaxRetries),
+
+		VersionRetainAll:       getEnvDuration("VERSION_RETAIN_ALL", 24*time.Hour),
+		VersionRetainHourly:    getEnvDuration("VERSION_RETAIN_HOURLY", 7*24*time.Hour),
+		VersionCompactInterval: getEnvDuration("VERSION_COMPACT_INTERVAL", time.Hour),
+
+		OpJournalMaxOps: getEnvInt("OP_JOURNAL_MAX_OPS", 10000),
+		OpJournalMaxAge: getEnvDuration("OP_JOURNAL_MAX_AGE", 24*time.Hour),
+
+		StorageDefaultPlan:      getEnv("STORAGE_DEFAULT_PLAN", entity.PlanFree),
+		StoragePlanFreeMB:       getEnvInt("STORAGE_PLAN_FREE_MB", 500),
+		StoragePlanProMB:        getEnvInt("STORAGE_PLAN_PRO_MB", 20*1024),
+		StoragePlanEnterpriseMB: getEnvInt("STORAGE_PLAN_ENTERPRISE_MB", 0),
+
+		UsagePlanFree:       getEnv("USAGE_PLAN_FREE", "ai=100,publish=300"),
+		UsagePlanPro:        getEnv("USAGE_PLAN_PRO", "ai=5000"),
+		UsagePlanEnterprise: getEnv("USAGE_PLAN_ENTERPRISE", ""),
+
+		MaxPagesPerUser: getEnvInt("MAX_PAGES_PER_USER", 0),
+
+		ArchiveS3Bucket:       os.Getenv("ARCHIVE_S3_BUCKET"),
+		ArchiveS3Region:       getEnv("ARCHIVE_S3_REGION", "us-east-1"),
+		ArchiveS3Endpoint:     os.Getenv("ARCHIVE_S3_ENDPOINT"),
+		ArchiveS3AccessKey:    os.Getenv("ARCHIVE_S3_ACCESS_KEY"),
+		ArchiveS3SecretKey:    os.Getenv("ARCHIVE_S3_SECRET_KEY"),
+		ArchiveS3Prefix:       getEnv("ARCHIVE_S3_PREFIX", "rooms/"),
+		ArchiveS3StorageClass: os.Getenv("ARCHIVE_S3_STORAGE_CLASS"),
+		ArchiveS3PathStyle:    getEnvBool("ARCHIVE_S3_PATH_STYLE", false),
+
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     getEnvInt("SMTP_PORT", 587),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		MailFrom:     os.Getenv("MAIL_FROM"),
+		InviteURL:    getEnv("INVITE_URL", "http://localhost:5173/?pageId={pageId}"),
+
+		LLMProvider:   getEnv("LLM_PROVIDER", llm.KindOpenAI),
+		LLMBaseURL:    getEnv("LLM_BASE_URL", "https://api.openai.com/v1"),
+		LLMAPIKey:     os.Getenv("LLM_API_KEY"),
+		LLMModel:      getEnv("LLM_MODEL", "gpt-4o-mini"),
+		LLMAPIVersion: os.Getenv("LLM_API_VERSION"),
+
+		LLMMaxRequestKB:  getEnvInt("LLM_MAX_REQUEST_KB", llm.DefaultMaxRequestBytes>>10),
+		LLMMaxResponseKB: getEnvInt("LLM_MAX_RESPONSE_KB", llm.DefaultMaxResponseBytes>>10),
+
+		LLMAllowPrivateEndpoints: getEnvBool("LLM_ALLOW_PRIVATE_ENDPOINTS", false),
+
+		ModerationWordlistFile: os.Getenv("MODERATION_WORDLIST_FILE"),
+		ModerationAPIURL:       os.Getenv("MODERATION_API_URL"),
+		ModerationAPIKey:       os.Getenv("MODERATION_API_KEY"),
+		ModerationAPITimeout:   getEnvDuration("MODERATION_API_TIMEOUT", moderation.DefaultAPITimeout),
+		ModerationActions:      getEnv("MODERATION_ACTIONS", "reject,flag"),
+		ModerationFailClosed:   getEnvBool("MODERATION_FAIL_CLOSED", false),
+
+		AuthProvider: getEnv("AUTH_PROVIDER", authn.ProviderClerk),
+
+		OIDCIssuer:       os.Getenv("OIDC_ISSUER"),
+		OIDCAudience:     os.Getenv("OIDC_AUDIENCE"),
+		OIDCJWKSURL:      os.Getenv("OIDC_JWKS_URL"),
+		OIDCOrgClaim:     os.Getenv("OIDC_ORG_CLAIM"),
+		OIDCOrgRoleClaim: os.Getenv("OIDC_ORG_ROLE_CLAIM"),
+
+		ClerkJWKSTTL:      getEnvDuration("CLERK_JWKS_TTL", time.Hour),
+		ClerkJWKSMaxStale: getEnvDuration("CLERK_JWKS_MAX_STALE", 24*time.Hour),
+
+		TokenRevocationTTL:          getEnvDuration("TOKEN_REVOCATION_TTL", time.Hour),
+		TokenRevocationSyncInterval: getEnvDuration("TOKEN_REVOCATION_SYNC_INTERVAL", 15*time.Second),
+
+		ClerkStrictStartup: getEnvBool("CLERK_STRICT_STARTUP", false),
+
+		WebhookAllowUnsigned: getEnvBool("CLERK_WEBHOOK_ALLOW_UNSIGNED", false),
+
+		UserDeleteDryRun: getEnvBool("USER_DELETE_DRY_RUN", false),
+
+		WSCompression:        getEnvBool("WS_COMPRESSION", true),
+		WSCompressionLevel:   getEnvInt("WS_COMPRESSION_LEVEL", 1),
+		WSCompressionMinSize: getEnvInt("WS_COMPRESSION_MIN_SIZE", 1024),
+
+		WSLatencyTracking: getEnvBool("WS_LATENCY_TRACKING", false),
+
+		WSPongWait:       getEnvDuration("WS_PONG_WAIT", 60*time.Second),
+		WSWriteWait:      getEnvDuration("WS_WRITE_WAIT", 10*time.Second),
+		WSMaxMessageSize: getEnvInt("WS_MAX_MESSAGE_SIZE", 512*1024),
+		WSSendBufferSize: getEnvInt("WS_SEND_BUFFER_SIZE", 256),
+
+		WSMaxClientsPerRoom: getEnvInt("WS_MAX_CLIENTS_PER_ROOM", 100),
+		WSHubShards:         getEnvInt("WS_HUB_SHARDS", ws.DefaultHubShards),
+
+		DrainGrace: getEnvDuration("DRAIN_GRACE", 0),
+
+		WSHandshakesPerMinute: getEnvInt("WS_HANDSHAKES_PER_MINUTE", 60),
+		WSMaxConnsPerUser:     getEnvInt("WS_MAX_CONNS_PER_USER", 20),
+
+		WatchdogEnabled:        getEnvBool("WATCHDOG_ENABLED", true),
+		WatchdogInterval:       getEnvDuration("WATCHDOG_INTERVAL", 2*time.Second),
+		WatchdogMaxGoroutines:  getEnvInt("WATCHDOG_MAX_GOROUTINES", 100000),
+		WatchdogMaxHeapMB:      getEnvInt("WATCHDOG_MAX_HEAP_MB", 1024),
+		WatchdogMaxLoopLag:     getEnvDuration("WATCHDOG_MAX_LOOP_LAG", 500*time.Millisecond),
+		WatchdogCursorInterval: getEnvDuration("WATCHDOG_CURSOR_INTERVAL", 250*time.Millisecond),
+
+		ReproJournalOps: getEnvInt("REPRO_JOURNAL_OPS", 500),
+
+		RedisURL:           os.Getenv("REDIS_URL"),
+		RedisChannelPrefix: getEnv("REDIS_CHANNEL_PREFIX", "lowcode:"),
+		PageCacheTTL:       getEnvDuration("PAGE_CACHE_TTL", 5*time.Minute),
+		RoomHibernateTTL:   getEnvDuration("ROOM_HIBERNATE_TTL", ws.DefaultHibernationTTL),
+		RoomLockEnabled:    getEnvBool("ROOM_LOCK_ENABLED", false),
+		RoomLockTTL:        getEnvDuration("ROOM_LOCK_TTL", 15*time.Second),
+		NodeAdvertiseURL:   os.Getenv("NODE_ADVERTISE_URL"),
+
+		CollabNodes:        os.Getenv("COLLAB_NODES"),
+		NodeID:             os.Getenv("NODE_ID"),
+		CollabStickyCookie: os.Getenv("COLLAB_STICKY_COOKIE"),
+
+		WSEditRate:          getEnvInt("WS_EDIT_RATE", 20),
+		WSCursorRate:        getEnvInt("WS_CURSOR_RATE", 30),
+		WSRateMaxViolations: getEnvInt("WS_RATE_MAX_VIOLATIONS", 50),
+
+		WebTransportEnabled:  getEnvBool("WEBTRANSPORT_ENABLED", false),
+		WebTransportPort:     getEnv("WEBTRANSPORT_PORT", "8443"),
+		WebTransportCertFile: os.Getenv("WEBTRANSPORT_CERT_FILE"),
+		WebTransportKeyFile:  os.Getenv("WEBTRANSPORT_KEY_FILE"),
+
+		LogLevel:      getEnv("LOG_LEVEL", defaultLogLevel()),
+		LogFormat:     getEnv("LOG_FORMAT", "text"),
+		LogOutput:     getEnv("LOG_OUTPUT", "stdout"),
+		LogFile:       getEnv("LOG_FILE", "logs/server.log"),
+		LogMaxSizeMB:  getEnvInt("LOG_MAX_SIZE_MB", 100),
+		LogMaxBackups: getEnvInt("LOG_MAX_BACKUPS", 5),
 	}
 
+	// Ping 间隔默认跟随 Pong 超时，只调整 WS_PONG_WAIT 时无需同时修改
+	env.WSPingPeriod = getEnvDuration("WS_PING_PERIOD", env.WSPongWait*9/10)
+
 	// 默认端口
 	if env.Port == "" {
 		env.Port = "8080"
 	}
 
+	if env.ConsistencyCheckHour < 0 || env.ConsistencyCheckHour > 23 {
+		log.Fatalf("[Env] CONSISTENCY_CHECK_HOUR 必须在 0-23 之间: %d", env.ConsistencyCheckHour)
+	}
+
+	if env.VersionRetainHourly < env.VersionRetainAll {
+		log.Fatalf("[Env] VERSION_RETAIN_HOURLY (%s) 不能小于 VERSION_RETAIN_ALL (%s)",
+			env.VersionRetainHourly, env.VersionRetainAll)
+	}
+
+	switch env.AuthProvider {
+	case authn.ProviderClerk:
+	case authn.ProviderOIDC:
+		if env.OIDCIssuer == "" {
+			log.Fatal("[Env] AUTH_PROVIDER=oidc 时必须配置 OIDC_ISSUER")
+		}
+	default:
+		log.Fatalf("[Env] AUTH_PROVIDER 必须为 clerk 或 oidc: %s", env.AuthProvider)
+	}
+
+	if env.ClerkJWKSTTL <= 0 || env.ClerkJWKSMaxStale < 0 {
+		log.Fatalf("[Env] CLERK_JWKS_TTL (%s) 必须大于 0，CLERK_JWKS_MAX_STALE (%s) 不能为负",
+			env.ClerkJWKSTTL, env.ClerkJWKSMaxStale)
+	}
+
+	if env.TokenRevocationTTL <= 0 || env.TokenRevocationSyncInterval <= 0 {
+		log.Fatalf("[Env] TOKEN_REVOCATION_TTL (%s) 和 TOKEN_REVOCATION_SYNC_INTERVAL (%s) 必须大于 0",
+			env.TokenRevocationTTL, env.TokenRevocationSyncInterval)
+	}
+
+	if env.WSCompressionLevel < 1 || env.WSCompressionLevel > 9 || env.WSCompressionMinSize < 0 {
+		log.Fatalf("[Env] WS_COMPRESSION_LEVEL 必须在 1-9 之间 (%d)，WS_COMPRESSION_MIN_SIZE 不能为负 (%d)",
+			env.WSCompressionLevel, env.WSCompressionMinSize)
+	}
+
+	if env.WSPingPeriod >= env.WSPongWait {
+		log.Fatalf("[Env] WS_PING_PERIOD (%s) 必须小于 WS_PONG_WAIT (%s)", env.WSPingPeriod, env.WSPongWait)
+	}
+
+	if env.PageCacheTTL < 0 {
+		log.Fatalf("[Env] PAGE_CACHE_TTL 不能为负: %s", env.PageCacheTTL)
+	}
+	if env.RoomHibernateTTL < 0 {
+		log.Fatalf("[Env] ROOM_HIBERNATE_TTL 不能为负: %s", env.RoomHibernateTTL)
+	}
+
+	if env.RoomLockEnabled {
+		if env.RedisURL == "" {
+			log.Fatal("[Env] ROOM_LOCK_ENABLED 需要配置 REDIS_URL")
+		}
+		if u, err := url.Parse(env.NodeAdvertiseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			log.Fatalf("[Env] ROOM_LOCK_ENABLED 时 NODE_ADVERTISE_URL 必须为本节点的 http(s) 地址: %q", env.NodeAdvertiseURL)
+		}
+		if env.RoomLockTTL < 3*time.Second {
+			log.Fatalf("[Env] ROOM_LOCK_TTL 不能小于 3s: %s", env.RoomLockTTL)
+		}
+	}
+
+	if env.CollabNodes != "" {
+		nodes, err := placement.ParseNodes(env.CollabNodes)
+		if err != nil {
+			log.Fatalf("[Env] COLLAB_NODES 无效: %v", err)
+		}
+		if _, ok := placement.New(nodes).Node(env.NodeID); !ok {
+			log.Fatalf("[Env] 配置 COLLAB_NODES 时 NODE_ID 必须为其中的节点: %q", env.NodeID)
+		}
+		for _, node := range nodes {
+			if node.URL == "" && env.CollabStickyCookie == "" {
+				log.Fatalf("[Env] COLLAB_NODES 中的节点 %s 没有直连地址，需要配置 COLLAB_STICKY_COOKIE", node.ID)
+			}
+		}
+	}
+
+	if !llm.ValidKind(env.LLMProvider) {
+		log.Fatalf("[Env] LLM_PROVIDER 必须为 openai、azure 或 local: %s", env.LLMProvider)
+	}
+
+	if env.LLMMaxRequestKB <= 0 || env.LLMMaxResponseKB <= 0 {
+		log.Fatalf("[Env] LLM_MAX_REQUEST_KB (%d) 和 LLM_MAX_RESPONSE_KB (%d) 必须大于 0",
+			env.LLMMaxRequestKB, env.LLMMaxResponseKB)
+	}
+
+	if _, err := moderation.ParseActions(env.ModerationActions); err != nil {
+		log.Fatalf("[Env] MODERATION_ACTIONS 只能包含 reject、flag、notify: %s", env.ModerationActions)
+	}
+	if env.ModerationAPIURL != "" {
+		if u, err := url.Parse(env.ModerationAPIURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			log.Fatalf("[Env] MODERATION_API_URL 必须为 http(s) 地址: %q", env.ModerationAPIURL)
+		}
+	}
+	if env.ModerationAPITimeout <= 0 {
+		log.Fatalf("[Env] MODERATION_API_TIMEOUT 必须大于 0: %s", env.ModerationAPITimeout)
+	}
+
+	if env.WSMaxMessageSize <= 0 || env.WSSendBufferSize <= 0 {
+		log.Fatalf("[Env] WS_MAX_MESSAGE_SIZE (%d) 和 WS_SEND_BUFFER_SIZE (%d) 必须大于 0",
+			env.WSMaxMessageSize, env.WSSendBufferSize)
+	}
+
+	if env.FlushMinInterval <= 0 || env.FlushMaxInterval < env.FlushMinInterval {
+		log.Fatalf("[Env] FLUSH_MIN_INTERVAL (%s) 必须大于 0 且不大于 FLUSH_MAX_INTERVAL (%s)",
+			env.FlushMinInterval, env.FlushMaxInterval)
+	}
+	if env.FlushMinThreshold <= 0 || env.FlushMaxThreshold < env.FlushMinThreshold {
+		log.Fatalf("[Env] FLUSH_MIN_THRESHOLD (%d) 必须大于 0 且不大于 FLUSH_MAX_THRESHOLD (%d)",
+			env.FlushMinThreshold, env.FlushMaxThreshold)
+	}
+	if env.FlushWorkers < 1 || env.FlushMaxRetries < 0 {
+		log.Fatalf("[Env] FLUSH_WORKERS (%d) 必须为正数，FLUSH_MAX_RETRIES (%d) 不能为负",
+			env.FlushWorkers, env.FlushMaxRetries)
+	}
+
+	if env.WSMaxClientsPerRoom < 0 {
+		log.Fatalf("[Env] WS_MAX_CLIENTS_PER_ROOM 不能为负: %d", env.WSMaxClientsPerRoom)
+	}
+	if env.WSHubShards < 1 {
+		log.Fatalf("[Env] WS_HUB_SHARDS 必须为正数: %d", env.WSHubShards)
+	}
+	if env.DrainGrace < 0 {
+		log.Fatalf("[Env] DRAIN_GRACE 不能为负: %s", env.DrainGrace)
+	}
+
+	if env.WSHandshakesPerMinute < 0 || env.WSMaxConnsPerUser < 0 {
+		log.Fatalf("[Env] WS_HANDSHAKES_PER_MINUTE (%d) 和 WS_MAX_CONNS_PER_USER (%d) 不能为负",
+			env.WSHandshakesPerMinute, env.WSMaxConnsPerUser)
+	}
+
+	if env.WSEditRate < 0 || env.WSCursorRate < 0 || env.WSRateMaxViolations < 0 {
+		log.Fatalf("[Env] WS_EDIT_RATE (%d)、WS_CURSOR_RATE (%d) 和 WS_RATE_MAX_VIOLATIONS (%d) 不能为负",
+			env.WSEditRate, env.WSCursorRate, env.WSRateMaxViolations)
+	}
+
+	if env.WatchdogEnabled && (env.WatchdogInterval <= 0 || env.WatchdogMaxGoroutines < 0 || env.WatchdogMaxHeapMB < 0 ||
+		env.WatchdogMaxLoopLag < 0 || env.WatchdogCursorInterval < 0) {
+		log.Fatalf("[Env] WATCHDOG_INTERVAL (%s) 必须大于 0，WATCHDOG_MAX_GOROUTINES (%d)、WATCHDOG_MAX_HEAP_MB (%d)、WATCHDOG_MAX_LOOP_LAG (%s) 和 WATCHDOG_CURSOR_INTERVAL (%s) 不能为负",
+			env.WatchdogInterval, env.WatchdogMaxGoroutines, env.WatchdogMaxHeapMB, env.WatchdogMaxLoopLag, env.WatchdogCursorInterval)
+	}
+
+	if env.ReproJournalOps < 0 {
+		log.Fatalf("[Env] REPRO_JOURNAL_OPS 不能为负: %d", env.ReproJournalOps)
+	}
+
+	if env.WebTransportEnabled && (env.WebTransportCertFile == "" || env.WebTransportKeyFile == "") {
+		log.Fatal("[Env] 启用 WEBTRANSPORT_ENABLED 时必须配置 WEBTRANSPORT_CERT_FILE 和 WEBTRANSPORT_KEY_FILE")
+	}
+
+	if env.WebhookAllowUnsigned && os.Getenv("GIN_MODE") == "release" {
+		log.Println("[Env] 生产环境忽略 CLERK_WEBHOOK_ALLOW_UNSIGNED，未签名的 Webhook 一律拒绝")
+		env.WebhookAllowUnsigned = false
+	}
+
+	if env.ArchiveS3Bucket != "" && (env.ArchiveS3AccessKey == "" || env.ArchiveS3SecretKey == "") {
+		log.Fatal("[Env] 启用 ARCHIVE_S3_BUCKET 时必须配置 ARCHIVE_S3_ACCESS_KEY 和 ARCHIVE_S3_SECRET_KEY")
+	}
+
+	if !entity.ValidPlan(env.StorageDefaultPlan) {
+		log.Fatalf("[Env] STORAGE_DEFAULT_PLAN 必须为 free、pro 或 enterprise: %s", env.StorageDefaultPlan)
+	}
+	if env.StoragePlanFreeMB < 0 || env.StoragePlanProMB < 0 || env.StoragePlanEnterpriseMB < 0 {
+		log.Fatalf("[Env] STORAGE_PLAN_FREE_MB (%d)、STORAGE_PLAN_PRO_MB (%d) 和 STORAGE_PLAN_ENTERPRISE_MB (%d) 不能为负",
+			env.StoragePlanFreeMB, env.StoragePlanProMB, env.StoragePlanEnterpriseMB)
+	}
+	for key, spec := range map[string]string{
+		"USAGE_PLAN_FREE":       env.UsagePlanFree,
+		"USAGE_PLAN_PRO":        env.UsagePlanPro,
+		"USAGE_PLAN_ENTERPRISE": env.UsagePlanEnterprise,
+	} {
+		if _, err := parseUsageAllowance(spec); err != nil {
+			log.Fatalf("[Env] %s 无效: %v", key, err)
+		}
+	}
+	if env.MaxPagesPerUser < 0 {
+		log.Fatalf("[Env] MAX_PAGES_PER_USER 不能为负: %d", env.MaxPagesPerUser)
+	}
+
+	if env.SMTPHost != "" && env.MailFrom == "" {
+		log.Fatal("[Env] 配置 SMTP_HOST 时必须配置 MAIL_FROM")
+	}
+
 	// 必需变量检查
 	if env.DatabaseURL == "" {
 		log.Fatal("[Env] 缺少必需环境变量: DATABASE_URL")
@@ -42,3 +559,380 @@ func LoadEnv() *Env {
 	log.Printf("[Env] 环境变量加载完成, 端口: %s", env.Port)
 	return env
 }
+
+// getEnv 读取字符串环境变量，未设置时返回默认值
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// defaultLogLevel 开发环境输出调试日志，生产环境默认不输出逐条 Patch 等调试信息
+func defaultLogLevel() string {
+	if os.Getenv("GIN_MODE") == "release" {
+		return "info"
+	}
+	return "debug"
+}
+
+// getEnvInt 读取整数环境变量，未设置时返回默认值
+func getEnvInt(key string, defaultValue int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Fatalf("[Env] 环境变量 %s 不是合法整数: %s", key, raw)
+	}
+	return value
+}
+
+// getEnvBool 读取布尔环境变量（true/false/1/0），未设置时返回默认值
+func getEnvBool(key string, defaultValue bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Fatalf("[Env] 环境变量 %s 不是合法布尔值: %s", key, raw)
+	}
+	return value
+}
+
+// getEnvDuration 读取时长环境变量（如 "24h"、"30m"），未设置时返回默认值
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value <= 0 {
+		log.Fatalf("[Env] 环境变量 %s 不是合法时长: %s", key, raw)
+	}
+	return value
+}
+
+// RedactedEnv 可对外展示的环境配置，密钥只显示是否已配置
+type RedactedEnv struct {
+	DatabaseURL    string `json:"databaseUrl"` // 隐藏密码
+	ClerkSecretKey string `json:"clerkSecretKey"`
+	WebhookSecret  string `json:"webhookSecret"`
+	Port           string `json:"port"`
+	OpsToken       string `json:"opsToken"`
+
+	ShareLinkSecret string `json:"shareLinkSecret"`
+	SecretPropsKey  string `json:"secretPropsKey"`
+
+	ConsistencyCheckHour int `json:"consistencyCheckHour"`
+	SnapshotEveryFlushes int `json:"snapshotEveryFlushes"`
+
+	FlushMinInterval  string `json:"flushMinInterval"`
+	FlushMaxInterval  string `json:"flushMaxInterval"`
+	FlushMinThreshold int    `json:"flushMinThreshold"`
+	FlushMaxThreshold int    `json:"flushMaxThreshold"`
+	FlushWorkers      int    `json:"flushWorkers"`
+	FlushMaxRetries   int    `json:"flushMaxRetries"`
+
+	VersionRetainAll       string `json:"versionRetainAll"`
+	VersionRetainHourly    string `json:"versionRetainHourly"`
+	VersionCompactInterval string `json:"versionCompactInterval"`
+
+	OpJournalMaxOps int    `json:"opJournalMaxOps"`
+	OpJournalMaxAge string `json:"opJournalMaxAge"`
+
+	StorageDefaultPlan      string `json:"storageDefaultPlan"`
+	StoragePlanFreeMB       int    `json:"storagePlanFreeMb"`
+	StoragePlanProMB        int    `json:"storagePlanProMb"`
+	StoragePlanEnterpriseMB int    `json:"storagePlanEnterpriseMb"`
+
+	UsagePlanFree       string `json:"usagePlanFree"`
+	UsagePlanPro        string `json:"usagePlanPro"`
+	UsagePlanEnterprise string `json:"usagePlanEnterprise"`
+
+	MaxPagesPerUser int `json:"maxPagesPerUser"`
+
+	ArchiveS3Bucket       string `json:"archiveS3Bucket"`
+	ArchiveS3Region       string `json:"archiveS3Region"`
+	ArchiveS3Endpoint     string `json:"archiveS3Endpoint"`
+	ArchiveS3AccessKey    string `json:"archiveS3AccessKey"`
+	ArchiveS3SecretKey    string `json:"archiveS3SecretKey"`
+	ArchiveS3Prefix       string `json:"archiveS3Prefix"`
+	ArchiveS3StorageClass string `json:"archiveS3StorageClass"`
+	ArchiveS3PathStyle    bool   `json:"archiveS3PathStyle"`
+
+	SMTPHost     string `json:"smtpHost"`
+	SMTPPort     int    `json:"smtpPort"`
+	SMTPUsername string `json:"smtpUsername"`
+	SMTPPassword string `json:"smtpPassword"`
+	MailFrom     string `json:"mailFrom"`
+	InviteURL    string `json:"inviteUrl"`
+
+	LLMProvider   string `json:"llmProvider"`
+	LLMBaseURL    string `json:"llmBaseUrl"`
+	LLMAPIKey     string `json:"llmApiKey"`
+	LLMModel      string `json:"llmModel"`
+	LLMAPIVersion string `json:"llmApiVersion"`
+
+	LLMMaxRequestKB  int `json:"llmMaxRequestKb"`
+	LLMMaxResponseKB int `json:"llmMaxResponseKb"`
+
+	LLMAllowPrivateEndpoints bool `json:"llmAllowPrivateEndpoints"`
+
+	ModerationWordlistFile string `json:"moderationWordlistFile"`
+	ModerationAPIURL       string `json:"moderationApiUrl"`
+	ModerationAPIKey       string `json:"moderationApiKey"`
+	ModerationAPITimeout   string `json:"moderationApiTimeout"`
+	ModerationActions      string `json:"moderationActions"`
+	ModerationFailClosed   bool   `json:"moderationFailClosed"`
+
+	AuthProvider string `json:"authProvider"`
+
+	OIDCIssuer       string `json:"oidcIssuer"`
+	OIDCAudience     string `json:"oidcAudience"`
+	OIDCJWKSURL      string `json:"oidcJwksUrl"`
+	OIDCOrgClaim     string `json:"oidcOrgClaim"`
+	OIDCOrgRoleClaim string `json:"oidcOrgRoleClaim"`
+
+	ClerkJWKSTTL      string `json:"clerkJwksTtl"`
+	ClerkJWKSMaxStale string `json:"clerkJwksMaxStale"`
+
+	TokenRevocationTTL          string `json:"tokenRevocationTtl"`
+	TokenRevocationSyncInterval string `json:"tokenRevocationSyncInterval"`
+
+	ClerkStrictStartup bool `json:"clerkStrictStartup"`
+
+	WebhookAllowUnsigned bool `json:"webhookAllowUnsigned"`
+
+	UserDeleteDryRun bool `json:"userDeleteDryRun"`
+
+	WSCompression        bool `json:"wsCompression"`
+	WSCompressionLevel   int  `json:"wsCompressionLevel"`
+	WSCompressionMinSize int  `json:"wsCompressionMinSize"`
+
+	WSLatencyTracking bool `json:"wsLatencyTracking"`
+
+	WSPongWait       string `json:"wsPongWait"`
+	WSPingPeriod     string `json:"wsPingPeriod"`
+	WSWriteWait      string `json:"wsWriteWait"`
+	WSMaxMessageSize int    `json:"wsMaxMessageSize"`
+	WSSendBufferSize int    `json:"wsSendBufferSize"`
+
+	WSMaxClientsPerRoom int `json:"wsMaxClientsPerRoom"`
+	WSHubShards         int `json:"wsHubShards"`
+
+	DrainGrace string `json:"drainGrace"`
+
+	WSHandshakesPerMinute int `json:"wsHandshakesPerMinute"`
+	WSMaxConnsPerUser     int `json:"wsMaxConnsPerUser"`
+
+	WatchdogEnabled        bool   `json:"watchdogEnabled"`
+	WatchdogInterval       string `json:"watchdogInterval"`
+	WatchdogMaxGoroutines  int    `json:"watchdogMaxGoroutines"`
+	WatchdogMaxHeapMB      int    `json:"watchdogMaxHeapMb"`
+	WatchdogMaxLoopLag     string `json:"watchdogMaxLoopLag"`
+	WatchdogCursorInterval string `json:"watchdogCursorInterval"`
+
+	ReproJournalOps int `json:"reproJournalOps"`
+
+	RedisURL           string `json:"redisUrl"` // 隐藏密码
+	RedisChannelPrefix string `json:"redisChannelPrefix"`
+	PageCacheTTL       string `json:"pageCacheTtl"`
+	RoomHibernateTTL   string `json:"roomHibernateTtl"`
+	RoomLockEnabled    bool   `json:"roomLockEnabled"`
+	RoomLockTTL        string `json:"roomLockTtl"`
+	NodeAdvertiseURL   string `json:"nodeAdvertiseUrl"`
+
+	CollabNodes        string `json:"collabNodes"`
+	NodeID             string `json:"nodeId"`
+	CollabStickyCookie string `json:"collabStickyCookie"`
+
+	WSEditRate          int `json:"wsEditRate"`
+	WSCursorRate        int `json:"wsCursorRate"`
+	WSRateMaxViolations int `json:"wsRateMaxViolations"`
+
+	WebTransportEnabled  bool   `json:"webTransportEnabled"`
+	WebTransportPort     string `json:"webTransportPort"`
+	WebTransportCertFile string `json:"webTransportCertFile"`
+	WebTransportKeyFile  string `json:"webTransportKeyFile"`
+
+	LogLevel      string `json:"logLevel"`
+	LogFormat     string `json:"logFormat"`
+	LogOutput     string `json:"logOutput"`
+	LogFile       string `json:"logFile"`
+	LogMaxSizeMB  int    `json:"logMaxSizeMb"`
+	LogMaxBackups int    `json:"logMaxBackups"`
+}
+
+// Redacted 返回脱敏后的配置，供运维接口展示
+func (e *Env) Redacted() RedactedEnv {
+	return RedactedEnv{
+		DatabaseURL:    redactDSN(e.DatabaseURL),
+		ClerkSecretKey: redactSecret(e.ClerkSecretKey),
+		WebhookSecret:  redactSecret(e.WebhookSecret),
+		Port:           e.Port,
+		OpsToken:       redactSecret(e.OpsToken),
+
+		ShareLinkSecret: redactSecret(e.ShareLinkSecret),
+		SecretPropsKey:  redactSecret(e.SecretPropsKey),
+
+		ConsistencyCheckHour: e.ConsistencyCheckHour,
+		SnapshotEveryFlushes: e.SnapshotEveryFlushes,
+
+		FlushMinInterval:  e.FlushMinInterval.String(),
+		FlushMaxInterval:  e.FlushMaxInterval.String(),
+		FlushMinThreshold: e.FlushMinThreshold,
+		FlushMaxThreshold: e.FlushMaxThreshold,
+		FlushWorkers:      e.FlushWorkers,
+		FlushMaxRetries:   e.FlushMaxRetries,
+
+		VersionRetainAll:       e.VersionRetainAll.String(),
+		VersionRetainHourly:    e.VersionRetainHourly.String(),
+		VersionCompactInterval: e.VersionCompactInterval.String(),
+
+		OpJournalMaxOps: e.OpJournalMaxOps,
+		OpJournalMaxAge: e.OpJournalMaxAge.String(),
+
+		StorageDefaultPlan:      e.StorageDefaultPlan,
+		StoragePlanFreeMB:       e.StoragePlanFreeMB,
+		StoragePlanProMB:        e.StoragePlanProMB,
+		StoragePlanEnterpriseMB: e.StoragePlanEnterpriseMB,
+
+		UsagePlanFree:       e.UsagePlanFree,
+		UsagePlanPro:        e.UsagePlanPro,
+		UsagePlanEnterprise: e.UsagePlanEnterprise,
+
+		MaxPagesPerUser: e.MaxPagesPerUser,
+
+		ArchiveS3Bucket:       e.ArchiveS3Bucket,
+		ArchiveS3Region:       e.ArchiveS3Region,
+		ArchiveS3Endpoint:     e.ArchiveS3Endpoint,
+		ArchiveS3AccessKey:    redactSecret(e.ArchiveS3AccessKey),
+		ArchiveS3SecretKey:    redactSecret(e.ArchiveS3SecretKey),
+		ArchiveS3Prefix:       e.ArchiveS3Prefix,
+		ArchiveS3StorageClass: e.ArchiveS3StorageClass,
+		ArchiveS3PathStyle:    e.ArchiveS3PathStyle,
+
+		SMTPHost:     e.SMTPHost,
+		SMTPPort:     e.SMTPPort,
+		SMTPUsername: e.SMTPUsername,
+		SMTPPassword: redactSecret(e.SMTPPassword),
+		MailFrom:     e.MailFrom,
+		InviteURL:    e.InviteURL,
+
+		LLMProvider:   e.LLMProvider,
+		LLMBaseURL:    e.LLMBaseURL,
+		LLMAPIKey:     redactSecret(e.LLMAPIKey),
+		LLMModel:      e.LLMModel,
+		LLMAPIVersion: e.LLMAPIVersion,
+
+		LLMMaxRequestKB:  e.LLMMaxRequestKB,
+		LLMMaxResponseKB: e.LLMMaxResponseKB,
+
+		LLMAllowPrivateEndpoints: e.LLMAllowPrivateEndpoints,
+
+		ModerationWordlistFile: e.ModerationWordlistFile,
+		ModerationAPIURL:       e.ModerationAPIURL,
+		ModerationAPIKey:       redactSecret(e.ModerationAPIKey),
+		ModerationAPITimeout:   e.ModerationAPITimeout.String(),
+		ModerationActions:      e.ModerationActions,
+		ModerationFailClosed:   e.ModerationFailClosed,
+
+		AuthProvider: e.AuthProvider,
+
+		OIDCIssuer:       e.OIDCIssuer,
+		OIDCAudience:     e.OIDCAudience,
+		OIDCJWKSURL:      e.OIDCJWKSURL,
+		OIDCOrgClaim:     e.OIDCOrgClaim,
+		OIDCOrgRoleClaim: e.OIDCOrgRoleClaim,
+
+		ClerkJWKSTTL:      e.ClerkJWKSTTL.String(),
+		ClerkJWKSMaxStale: e.ClerkJWKSMaxStale.String(),
+
+		TokenRevocationTTL:          e.TokenRevocationTTL.String(),
+		TokenRevocationSyncInterval: e.TokenRevocationSyncInterval.String(),
+
+		ClerkStrictStartup: e.ClerkStrictStartup,
+
+		WebhookAllowUnsigned: e.WebhookAllowUnsigned,
+
+		UserDeleteDryRun: e.UserDeleteDryRun,
+
+		WSCompression:        e.WSCompression,
+		WSCompressionLevel:   e.WSCompressionLevel,
+		WSCompressionMinSize: e.WSCompressionMinSize,
+
+		WSLatencyTracking: e.WSLatencyTracking,
+
+		WSPongWait:       e.WSPongWait.String(),
+		WSPingPeriod:     e.WSPingPeriod.String(),
+		WSWriteWait:      e.WSWriteWait.String(),
+		WSMaxMessageSize: e.WSMaxMessageSize,
+		WSSendBufferSize: e.WSSendBufferSize,
+
+		WSMaxClientsPerRoom: e.WSMaxClientsPerRoom,
+		WSHubShards:         e.WSHubShards,
+
+		DrainGrace: e.DrainGrace.String(),
+
+		WSHandshakesPerMinute: e.WSHandshakesPerMinute,
+		WSMaxConnsPerUser:     e.WSMaxConnsPerUser,
+
+		WatchdogEnabled:        e.WatchdogEnabled,
+		WatchdogInterval:       e.WatchdogInterval.String(),
+		WatchdogMaxGoroutines:  e.WatchdogMaxGoroutines,
+		WatchdogMaxHeapMB:      e.WatchdogMaxHeapMB,
+		WatchdogMaxLoopLag:     e.WatchdogMaxLoopLag.String(),
+		WatchdogCursorInterval: e.WatchdogCursorInterval.String(),
+
+		ReproJournalOps: e.ReproJournalOps,
+
+		RedisURL:           redactDSN(e.RedisURL),
+		RedisChannelPrefix: e.RedisChannelPrefix,
+		PageCacheTTL:       e.PageCacheTTL.String(),
+		RoomHibernateTTL:   e.RoomHibernateTTL.String(),
+		RoomLockEnabled:    e.RoomLockEnabled,
+		RoomLockTTL:        e.RoomLockTTL.String(),
+		NodeAdvertiseURL:   e.NodeAdvertiseURL,
+
+		CollabNodes:        e.CollabNodes,
+		NodeID:             e.NodeID,
+		CollabStickyCookie: e.CollabStickyCookie,
+
+		WSEditRate:          e.WSEditRate,
+		WSCursorRate:        e.WSCursorRate,
+		WSRateMaxViolations: e.WSRateMaxViolations,
+
+		WebTransportEnabled:  e.WebTransportEnabled,
+		WebTransportPort:     e.WebTransportPort,
+		WebTransportCertFile: e.WebTransportCertFile,
+		WebTransportKeyFile:  e.WebTransportKeyFile,
+
+		LogLevel:      e.LogLevel,
+		LogFormat:     e.LogFormat,
+		LogOutput:     e.LogOutput,
+		LogFile:       e.LogFile,
+		LogMaxSizeMB:  e.LogMaxSizeMB,
+		LogMaxBackups: e.LogMaxBackups,
+	}
+}
+
+// redactSecret 密钥只暴露是否已配置
+func redactSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "[redacted]"
+}
+
+// redactDSN 保留 URL 形式 DSN 的主机和库名，隐藏密码；无法解析时整体隐藏
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return redactSecret(dsn)
+	}
+	u.RawQuery = ""
+	return u.Redacted()
+}
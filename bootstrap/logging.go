@@ -0,0 +1,37 @@
+package bootstrap
+
+import (
+	"io"
+	"log"
+
+	"lowercode-go-server/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitLogging 按 LOG_* 环境变量配置日志级别、格式和输出目的地，
+// Gin 的请求日志也写入同一目的地。返回的 Closer 需在进程退出前关闭。
+func InitLogging(env *Env) io.Closer {
+	level, err := logging.ParseLevel(env.LogLevel)
+	if err != nil {
+		log.Fatalf("[Env] LOG_LEVEL 无效: %v", err)
+	}
+
+	closer, err := logging.Setup(logging.Config{
+		Level:      level,
+		Format:     env.LogFormat,
+		Output:     env.LogOutput,
+		File:       env.LogFile,
+		MaxSizeMB:  env.LogMaxSizeMB,
+		MaxBackups: env.LogMaxBackups,
+	})
+	if err != nil {
+		log.Fatalf("[Log] 初始化日志失败: %v", err)
+	}
+
+	gin.DefaultWriter = log.Writer()
+	gin.DefaultErrorWriter = log.Writer()
+
+	log.Printf("[Log] 日志级别: %s, 格式: %s, 输出: %s", level, env.LogFormat, env.LogOutput)
+	return closer
+}
@@ -0,0 +1,34 @@
+package bootstrap
+
+import (
+	"log"
+
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/moderation"
+)
+
+// ModerationFilter 根据配置组合词表和外部审核服务，先查词表再调用审核服务；
+// 都未配置时返回 nil（不审核）。词表文件读取失败时退出，避免以为开启了审核实际却没有
+func ModerationFilter(env *Env) moderation.Filter {
+	var chain moderation.Chain
+	if env.ModerationWordlistFile != "" {
+		wordlist, err := moderation.LoadWordlist(env.ModerationWordlistFile)
+		if err != nil {
+			logging.Fatalf("读取 MODERATION_WORDLIST_FILE 失败: %v", err)
+		}
+		log.Printf("[Moderation] 已加载审核词表: %d 个词", wordlist.Len())
+		chain = append(chain, wordlist)
+	}
+	if env.ModerationAPIURL != "" {
+		chain = append(chain, moderation.NewAPI(moderation.APIConfig{
+			URL:     env.ModerationAPIURL,
+			APIKey:  env.ModerationAPIKey,
+			Timeout: env.ModerationAPITimeout,
+		}))
+	}
+	if len(chain) == 0 {
+		log.Printf("[Moderation] 未配置 MODERATION_WORDLIST_FILE 和 MODERATION_API_URL，内容审核未启用")
+		return nil
+	}
+	return chain
+}
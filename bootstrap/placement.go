@@ -0,0 +1,18 @@
+package bootstrap
+
+import (
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/placement"
+)
+
+// NewCollabPlacement 根据 COLLAB_NODES 创建页面到节点的分配，未配置时返回 nil（任意节点都可以承载页面）
+func NewCollabPlacement(env *Env) *placement.Ring {
+	if env.CollabNodes == "" {
+		return nil
+	}
+	nodes, err := placement.ParseNodes(env.CollabNodes)
+	if err != nil {
+		logging.Fatalf("[Env] COLLAB_NODES 无效: %v", err)
+	}
+	return placement.New(nodes)
+}
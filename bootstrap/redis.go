@@ -0,0 +1,66 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/pagecache"
+	"lowercode-go-server/internal/redisbridge"
+	"lowercode-go-server/internal/redishibernate"
+	"lowercode-go-server/internal/redislock"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewRedisClient 连接 REDIS_URL，未配置时返回 nil。
+// 启动时 Redis 不可用直接退出：各节点在桥接缺失时会各自接受编辑，状态静默分叉
+func NewRedisClient(env *Env) *redis.Client {
+	if env.RedisURL == "" {
+		return nil
+	}
+	opts, err := redis.ParseURL(env.RedisURL)
+	if err != nil {
+		logging.Fatalf("[Env] REDIS_URL 格式错误: %v", err)
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		logging.Fatalf("Redis 连接失败: %v", err)
+	}
+	return client
+}
+
+// NewRoomBridge 创建跨节点房间桥接，client 为 nil 时返回 nil。桥接关闭时一并关闭 client
+func NewRoomBridge(env *Env, client *redis.Client) *redisbridge.Bridge {
+	if client == nil {
+		return nil
+	}
+	return redisbridge.New(client, env.RedisChannelPrefix)
+}
+
+// NewPageCache 创建页面读取缓存，client 为 nil 或 PAGE_CACHE_TTL 为 0 时返回 nil
+func NewPageCache(env *Env, client *redis.Client) *pagecache.Cache {
+	if client == nil || env.PageCacheTTL <= 0 {
+		return nil
+	}
+	return pagecache.New(client, env.RedisChannelPrefix, env.PageCacheTTL)
+}
+
+// NewRoomLock 创建房间租约锁，client 为 nil 或未启用 ROOM_LOCK_ENABLED 时返回 nil
+func NewRoomLock(env *Env, client *redis.Client) *redislock.Lock {
+	if client == nil || !env.RoomLockEnabled {
+		return nil
+	}
+	return redislock.New(client, env.RedisChannelPrefix)
+}
+
+// NewRoomHibernation 创建房间休眠状态存储，client 为 nil 或 ROOM_HIBERNATE_TTL 为 0 时返回 nil
+func NewRoomHibernation(env *Env, client *redis.Client) *redishibernate.Store {
+	if client == nil || env.RoomHibernateTTL <= 0 {
+		return nil
+	}
+	return redishibernate.New(client, env.RedisChannelPrefix)
+}
@@ -0,0 +1,28 @@
+package bootstrap
+
+import (
+	"encoding/base64"
+	"log"
+
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/secretprop"
+)
+
+// SecretPropsBox 返回密钥属性的加解密器，未配置 SECRET_PROPS_KEY 时返回 nil（不能设置密钥属性）。
+// 密钥更换后已保存的密钥属性无法解密，需要重新设置
+func SecretPropsBox(env *Env) *secretprop.Box {
+	if env.SecretPropsKey == "" {
+		log.Printf("[SecretProps] 未配置 SECRET_PROPS_KEY，密钥属性不可用")
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(env.SecretPropsKey)
+	if err != nil {
+		logging.Fatalf("SECRET_PROPS_KEY 不是合法的 base64: %v", err)
+	}
+	box, err := secretprop.NewBox(key)
+	if err != nil {
+		logging.Fatalf("SECRET_PROPS_KEY 无效: %v", err)
+	}
+	return box
+}
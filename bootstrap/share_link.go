@@ -0,0 +1,22 @@
+package bootstrap
+
+import (
+	"crypto/rand"
+
+	"lowercode-go-server/internal/logging"
+)
+
+// ShareLinkSecret 返回分享链接 Token 的签名密钥。
+// 未配置 SHARE_LINK_SECRET 时随机生成，重启或多实例部署时已发出的链接会失效，生产环境应显式配置
+func ShareLinkSecret(env *Env) []byte {
+	if env.ShareLinkSecret != "" {
+		return []byte(env.ShareLinkSecret)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		logging.Fatalf("生成分享链接签名密钥失败: %v", err)
+	}
+	logging.Warnf("[ShareLink] 未配置 SHARE_LINK_SECRET，使用随机密钥，重启后已发出的分享链接将失效")
+	return secret
+}
@@ -0,0 +1,13 @@
+package bootstrap
+
+import "lowercode-go-server/domain/entity"
+
+// StoragePlanLimits 返回各套餐的存储上限（字节），0 表示不限
+func StoragePlanLimits(env *Env) map[string]int64 {
+	const mb = 1 << 20
+	return map[string]int64{
+		entity.PlanFree:       int64(env.StoragePlanFreeMB) * mb,
+		entity.PlanPro:        int64(env.StoragePlanProMB) * mb,
+		entity.PlanEnterprise: int64(env.StoragePlanEnterpriseMB) * mb,
+	}
+}
@@ -0,0 +1,45 @@
+package bootstrap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"lowercode-go-server/domain/entity"
+)
+
+// UsagePlanLimits 返回各套餐每月允许的调用次数（套餐 → 计量类型 → 次数），0 或未列出表示不限
+func UsagePlanLimits(env *Env) map[string]map[string]int64 {
+	limits := make(map[string]map[string]int64)
+	for plan, spec := range map[string]string{
+		entity.PlanFree:       env.UsagePlanFree,
+		entity.PlanPro:        env.UsagePlanPro,
+		entity.PlanEnterprise: env.UsagePlanEnterprise,
+	} {
+		// 格式已在 LoadEnv 中校验
+		limits[plan], _ = parseUsageAllowance(spec)
+	}
+	return limits
+}
+
+// parseUsageAllowance 解析 "ai=100,publish=300" 形式的额度，类型必须是 entity.UsageKinds 之一，次数不能为负
+func parseUsageAllowance(spec string) (map[string]int64, error) {
+	allowance := make(map[string]int64)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kind, raw, ok := strings.Cut(item, "=")
+		kind = strings.TrimSpace(kind)
+		if !ok || !entity.ValidUsageKind(kind) {
+			return nil, fmt.Errorf("%q 格式应为 <类型>=<次数>，类型可选 %s", item, strings.Join(entity.UsageKinds, "、"))
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%q 的次数必须为非负整数", item)
+		}
+		allowance[kind] = n
+	}
+	return allowance, nil
+}
@@ -88,6 +88,12 @@ func main() {
 // 注意：顺序很重要！先删除有外键依赖的表
 func getAllTables() []string {
 	return []string{
+		getTableName(&entity.OutboxEvent{}),
+		getTableName(&entity.ChatMessage{}),
+		getTableName(&entity.Comment{}),
+		getTableName(&entity.PageOp{}),
+		getTableName(&entity.PageDelta{}),
+		getTableName(&entity.PageVersion{}),
 		getTableName(&entity.Page{}),
 		getTableName(&entity.User{}),
 	}
@@ -96,6 +102,18 @@ func getAllTables() []string {
 // getTableName 获取实体对应的表名
 func getTableName(model interface{}) string {
 	switch model.(type) {
+	case *entity.OutboxEvent:
+		return "outbox_events"
+	case *entity.ChatMessage:
+		return "chat_messages"
+	case *entity.Comment:
+		return "comments"
+	case *entity.PageOp:
+		return "page_ops"
+	case *entity.PageDelta:
+		return "page_deltas"
+	case *entity.PageVersion:
+		return "page_versions"
 	case *entity.Page:
 		return "pages"
 	case *entity.User:
@@ -10,14 +10,23 @@ import (
 	"time"
 
 	"lowercode-go-server/api/controller"
+	"lowercode-go-server/api/middleware"
 	"lowercode-go-server/api/route"
 	"lowercode-go-server/bootstrap"
+	"lowercode-go-server/internal/authn"
+	"lowercode-go-server/internal/llm"
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/mailer"
+	"lowercode-go-server/internal/moderation"
+	"lowercode-go-server/internal/objectstore"
 	"lowercode-go-server/internal/ws"
 	"lowercode-go-server/repository"
 	"lowercode-go-server/usecase"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/webtransport-go"
 )
 
 func main() {
@@ -26,32 +35,329 @@ func main() {
 	// 加载环境变量
 	env := bootstrap.LoadEnv()
 
-	// 初始化 Clerk
-	bootstrap.InitClerk()
+	// 日志级别与输出目的地
+	logCloser := bootstrap.InitLogging(env)
+	defer logCloser.Close()
+
+	// 登录用户的 JWT 验证（Clerk 或通用 OIDC），含启动自检与公钥预热；拒绝已吊销会话的 Token
+	revocations := authn.NewRevocations()
+	authVerifier := bootstrap.NewAuthVerifier(env, revocations)
 
 	// 连接数据库
 	db := bootstrap.NewDatabase(env.DatabaseURL)
 
+	// 多节点部署的 Redis（可选），房间桥接与页面读取缓存共用
+	redisClient := bootstrap.NewRedisClient(env)
+
 	// 依赖注入 - Repository 层
 	pageRepo := repository.NewPageRepository(db)
+	if pageCache := bootstrap.NewPageCache(env, redisClient); pageCache != nil {
+		pageRepo = repository.NewCachedPageRepository(db, pageCache)
+		log.Printf("[Server] 页面读取缓存已启用，有效期 %s", env.PageCacheTTL)
+	}
 	userRepo := repository.NewUserRepository(db)
+	versionRepo := repository.NewPageVersionRepository(db)
+	consistencyRepo := repository.NewConsistencyRepository(db)
+	opRepo := repository.NewPageOpRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	activityRepo := repository.NewActivityRepository(db)
+	collaboratorRepo := repository.NewCollaboratorRepository(db)
+	shareLinkRepo := repository.NewShareLinkRepository(db)
+	conflictBackupRepo := repository.NewConflictBackupRepository(db)
+	orgMemberRepo := repository.NewOrgMemberRepository(db)
+	orgRepo := repository.NewOrganizationRepository(db)
+	userCleanupRepo := repository.NewUserCleanupRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	branchRepo := repository.NewBranchRepository(db)
+	mergeRequestRepo := repository.NewMergeRequestRepository(db)
+	inviteRepo := repository.NewInviteRepository(db)
+	storageRepo := repository.NewStorageRepository(db)
+	usageRepo := repository.NewUsageRepository(db)
+	revokedSessionRepo := repository.NewRevokedSessionRepository(db)
+	referenceRepo := repository.NewPageReferenceRepository(db)
+	aiRepo := repository.NewAIRepository(db)
+	moderationRepo := repository.NewModerationRepository(db)
+
+	// 补齐存储统计上线前已有页面的用量
+	if n, err := storageRepo.Recount(true); err != nil {
+		logging.Fatalf("[Storage] 回填存储用量失败: %v", err)
+	} else if n > 0 {
+		log.Printf("[Storage] 已回填 %d 个页面的存储用量", n)
+	}
+
+	// 操作日志异步写入器
+	opLogWriter := ws.NewOpLogWriter(opRepo.(ws.OpStore))
+	go opLogWriter.Run()
+
+	// 用户最近打开、编辑页面的时间，合并后异步写入
+	activityWriter := ws.NewActivityWriter(activityRepo.(ws.ActivityStore))
+	go activityWriter.Run()
+
+	// 页面引用索引：房间刷盘后异步重建，供查询入站引用
+	referenceIndexer := ws.NewReferenceIndexer(referenceRepo.(ws.ReferenceStore))
+	go referenceIndexer.Run()
+
+	// 房间生命周期事件写入 Outbox，供外部巡检核对版本
+	lifecycleSink := ws.NewOutboxSink(repository.NewOutboxRepository(db).(ws.OutboxStore))
 
 	// WebSocket Hub
-	hub := ws.NewHub(pageRepo.(ws.PageService))
+	// 差量持久化：每 SNAPSHOT_EVERY_FLUSHES 次刷盘写一次全量快照
+	hubOptions := []ws.HubOption{
+		ws.WithOpLog(opLogWriter),
+		ws.WithEventSink(lifecycleSink),
+		ws.WithDeltaPersistence(pageRepo.(ws.DeltaStore), env.SnapshotEveryFlushes),
+		ws.WithFlushPolicy(ws.FlushPolicy{
+			MinInterval:  env.FlushMinInterval,
+			MaxInterval:  env.FlushMaxInterval,
+			MinThreshold: int64(env.FlushMinThreshold),
+			MaxThreshold: int64(env.FlushMaxThreshold),
+		}),
+		ws.WithFlushWorkers(ws.FlushWorkerConfig{
+			Workers:    env.FlushWorkers,
+			MaxRetries: env.FlushMaxRetries,
+		}),
+		ws.WithChatStore(repository.NewChatRepository(db).(ws.ChatStore)),
+		ws.WithSettingsStore(pageRepo.(ws.SettingsStore)),
+		ws.WithConflictBackups(conflictBackupRepo.(ws.ConflictBackupStore)),
+		ws.WithActivity(activityWriter),
+		ws.WithReferenceIndex(referenceIndexer),
+		ws.WithCatchUp(opRepo.(ws.OpReader)),
+		ws.WithConnConfig(ws.ConnConfig{
+			PongWait:       env.WSPongWait,
+			PingPeriod:     env.WSPingPeriod,
+			WriteWait:      env.WSWriteWait,
+			MaxMessageSize: int64(env.WSMaxMessageSize),
+			SendBufferSize: env.WSSendBufferSize,
+		}),
+		ws.WithMaxClientsPerRoom(env.WSMaxClientsPerRoom),
+		ws.WithShards(env.WSHubShards),
+		ws.WithReproJournal(env.ReproJournalOps),
+		ws.WithRateLimit(ws.RateLimitConfig{
+			EditRate:      float64(env.WSEditRate),
+			EditBurst:     2 * env.WSEditRate,
+			CursorRate:    float64(env.WSCursorRate),
+			CursorBurst:   2 * env.WSCursorRate,
+			MaxViolations: env.WSRateMaxViolations,
+		}),
+	}
+
+	// 过载保护：资源紧张时拒绝创建新房间、对光标广播降频，避免进程被 OOM 杀死
+	var watchdog *ws.Watchdog
+	if env.WatchdogEnabled {
+		watchdog = ws.NewWatchdog(ws.WatchdogConfig{
+			Interval:       env.WatchdogInterval,
+			MaxGoroutines:  env.WatchdogMaxGoroutines,
+			MaxHeapBytes:   uint64(env.WatchdogMaxHeapMB) << 20,
+			MaxLoopLag:     env.WatchdogMaxLoopLag,
+			CursorInterval: env.WatchdogCursorInterval,
+		})
+		hubOptions = append(hubOptions, ws.WithWatchdog(watchdog))
+	}
+
+	// 编辑链路延迟追踪：各阶段耗时记入 ws_latency，ack 中带回服务端耗时（可选）
+	if env.WSLatencyTracking {
+		hubOptions = append(hubOptions, ws.WithLatencyTracking())
+		log.Printf("[Server] 编辑延迟追踪已启用")
+	}
+
+	// 房间销毁时归档最终快照和操作日志到对象存储（可选）
+	var archiveWriter *ws.ArchiveWriter
+	if env.ArchiveS3Bucket != "" {
+		archiveWriter = ws.NewArchiveWriter(opRepo.(ws.OpReader), objectstore.NewS3(objectstore.S3Config{
+			Endpoint:     env.ArchiveS3Endpoint,
+			Region:       env.ArchiveS3Region,
+			Bucket:       env.ArchiveS3Bucket,
+			AccessKey:    env.ArchiveS3AccessKey,
+			SecretKey:    env.ArchiveS3SecretKey,
+			StorageClass: env.ArchiveS3StorageClass,
+			PathStyle:    env.ArchiveS3PathStyle,
+		}), env.ArchiveS3Prefix)
+		go archiveWriter.Run()
+		hubOptions = append(hubOptions, ws.WithArchive(archiveWriter))
+		log.Printf("[Server] 房间归档已启用: s3://%s/%s", env.ArchiveS3Bucket, env.ArchiveS3Prefix)
+	}
+
+	// 多节点部署：通过 Redis Pub/Sub 与其他节点同步同一页面的房间（可选）
+	roomBridge := bootstrap.NewRoomBridge(env, redisClient)
+	if roomBridge != nil {
+		hubOptions = append(hubOptions, ws.WithRoomBridge(roomBridge))
+		log.Printf("[Server] 跨节点房间桥接已启用: %s", env.Redacted().RedisURL)
+	}
+
+	// 空闲房间休眠到 Redis，再次加入时直接恢复，无需读取数据库（可选）
+	if hibernationStore := bootstrap.NewRoomHibernation(env, redisClient); hibernationStore != nil {
+		hubOptions = append(hubOptions, ws.WithHibernation(hibernationStore, env.RoomHibernateTTL))
+		log.Printf("[Server] 房间休眠已启用，保留 %s", env.RoomHibernateTTL)
+	}
+
+	// 多节点部署：同一页面只由取得租约的节点承载房间，其他节点把页面请求转发过去（可选）
+	if roomLock := bootstrap.NewRoomLock(env, redisClient); roomLock != nil {
+		hubOptions = append(hubOptions, ws.WithRoomOwnership(roomLock, env.NodeAdvertiseURL, env.RoomLockTTL))
+		log.Printf("[Server] 房间租约已启用: 本节点 %s，有效期 %s", env.NodeAdvertiseURL, env.RoomLockTTL)
+	}
+
+	// 邀请邮件与审核通知邮件：未配置 SMTP 时只写日志，便于本地开发
+	var inviteMailer mailer.Mailer = mailer.Log{}
+	if env.SMTPHost != "" {
+		inviteMailer = mailer.NewSMTP(mailer.SMTPConfig{
+			Host:     env.SMTPHost,
+			Port:     env.SMTPPort,
+			Username: env.SMTPUsername,
+			Password: env.SMTPPassword,
+			From:     env.MailFrom,
+		})
+	}
+
+	// 内容审核：聊天消息和组件属性文本在应用前审核（可选），未启用时仍可查询已有的审核记录
+	moderationFilter := bootstrap.ModerationFilter(env)
+	moderationActions, _ := moderation.ParseActions(env.ModerationActions)
+	moderationUseCase := usecase.NewModerationUseCase(moderationFilter, usecase.ModerationPolicy{
+		Actions:    moderationActions,
+		FailClosed: env.ModerationFailClosed,
+		PageURL:    env.InviteURL,
+	}, moderationRepo, pageRepo, userRepo, inviteMailer)
+	if moderationFilter != nil {
+		hubOptions = append(hubOptions, ws.WithModeration(moderationUseCase))
+		log.Printf("[Server] 内容审核已启用，处理方式: %s", env.ModerationActions)
+	}
+
+	hub := ws.NewHub(pageRepo.(ws.PageService), hubOptions...)
 
 	// 依赖注入 - UseCase 层
-	pageUseCase := usecase.NewPageUseCase(pageRepo, userRepo, hub)
+	pageUseCase := usecase.NewPageUseCase(pageRepo, userRepo, collaboratorRepo, orgMemberRepo, hub)
+	versionUseCase := usecase.NewVersionUseCase(pageRepo, versionRepo, pageUseCase, hub)
+	versionUseCase.EnableOpHistory(opRepo)
+	commentUseCase := usecase.NewCommentUseCase(commentRepo, pageRepo, pageUseCase, hub)
+	userUseCase := usecase.NewUserUseCase(userRepo, activityRepo, pageUseCase)
+	shareLinkSecret := bootstrap.ShareLinkSecret(env)
+	shareLinkUseCase := usecase.NewShareLinkUseCase(shareLinkRepo, pageUseCase, hub, shareLinkSecret)
+	// 临时访客 Token 与分享链接共用签名密钥，签名带有用途前缀，不会被误用为分享 Token
+	guestTokenUseCase := usecase.NewGuestTokenUseCase(shareLinkSecret)
+	conflictBackupUseCase := usecase.NewConflictBackupUseCase(conflictBackupRepo, pageUseCase)
+	apiKeyUseCase := usecase.NewAPIKeyUseCase(apiKeyRepo, orgMemberRepo)
+	branchUseCase := usecase.NewBranchUseCase(branchRepo, pageRepo, pageUseCase, hub)
+	mergeRequestUseCase := usecase.NewMergeRequestUseCase(mergeRequestRepo, branchUseCase, versionRepo)
+
+	// 租户存储配额：创建页面、导入、创建分支和发布前检查
+	storageUseCase := usecase.NewStorageUseCase(storageRepo, bootstrap.StoragePlanLimits(env), env.StorageDefaultPlan)
+	pageUseCase.EnableQuota(storageUseCase)
+	pageUseCase.EnablePageQuota(env.MaxPagesPerUser)
+	branchUseCase.EnableQuota(storageUseCase)
+	// 按套餐计量 AI 生成与发布，套餐与存储配额共用
+	usageUseCase := usecase.NewUsageUseCase(usageRepo, storageUseCase, bootstrap.UsagePlanLimits(env))
+	pageUseCase.EnableUsageLimits(usageUseCase)
+
+	secretBox := bootstrap.SecretPropsBox(env)
+	pageUseCase.EnableSecrets(secretBox)
+	pageUseCase.EnableReferences(referenceRepo)
+	// 删除确认 Token 与分享链接共用签名密钥，Token 内容带有用途前缀，不会被误用为分享 Token
+	pageUseCase.EnableDeleteConfirmation(shareLinkSecret)
+	if n, err := pageUseCase.BackfillReferences(); err != nil {
+		logging.Warnf("[References] 回填页面引用索引失败: %v", err)
+	} else if n > 0 {
+		log.Printf("[References] 已回填 %d 个页面的引用索引", n)
+	}
+	secretUseCase := usecase.NewSecretUseCase(pageUseCase, hub, secretBox)
+
+	// AI 生成：平台默认模型服务未配置（LLM_API_KEY 为空且不是 local）时，只有配置了自带模型服务的租户可以使用
+	llmLimits := llm.Limits{MaxRequestBytes: env.LLMMaxRequestKB << 10, MaxResponseBytes: env.LLMMaxResponseKB << 10}
+	var aiProvider llm.Provider
+	if env.LLMAPIKey != "" || env.LLMProvider == llm.KindLocal {
+		client, err := llm.New(llm.Config{
+			Kind:       env.LLMProvider,
+			BaseURL:    env.LLMBaseURL,
+			APIKey:     env.LLMAPIKey,
+			Model:      env.LLMModel,
+			APIVersion: env.LLMAPIVersion,
+			Limits:     llmLimits,
+		})
+		if err != nil {
+			log.Fatalf("[AI] 平台模型服务配置无效: %v", err)
+		}
+		aiProvider = client
+	}
+	aiUseCase := usecase.NewAIUseCase(pageUseCase, hub, aiProvider)
+	aiUseCase.EnableTenantProviders(pageRepo, aiRepo, secretBox, usecase.AIProviderPolicy{
+		Limits:                llmLimits,
+		PlatformModel:         env.LLMModel,
+		AllowPrivateEndpoints: env.LLMAllowPrivateEndpoints,
+	})
+	aiUseCase.EnableUsageLimits(usageUseCase)
+	// 协同连接内的流式 AI 生成（ai-generate），每个用户的额度与 HTTP 生成接口相同
+	hub.EnableAIStreaming(aiUseCase, controller.AIGenerationsPerMinute)
+	inviteUseCase := usecase.NewInviteUseCase(inviteRepo, userRepo, pageUseCase, inviteMailer, env.InviteURL)
+	consistencyUseCase := usecase.NewConsistencyUseCase(consistencyRepo, hub)
+	retentionUseCase := usecase.NewRetentionUseCase(versionRepo, opRepo, usecase.RetentionPolicy{
+		KeepAll:    env.VersionRetainAll,
+		KeepHourly: env.VersionRetainHourly,
+
+		JournalMaxOps: env.OpJournalMaxOps,
+		JournalMaxAge: env.OpJournalMaxAge,
+	})
+
+	revocationUseCase := usecase.NewRevocationUseCase(revokedSessionRepo, revocations, hub, env.TokenRevocationTTL)
+	if err := revocationUseCase.Sync(time.Now()); err != nil {
+		logging.Warnf("[Revocation] 启动时加载吊销记录失败，将在下次同步时重试: %v", err)
+	}
+
+	// 用户账号删除后转移或删除其页面；USER_DELETE_DRY_RUN 时只记录计划
+	userCleanupUseCase := usecase.NewUserCleanupUseCase(userCleanupRepo, pageRepo, hub, env.UserDeleteDryRun)
 
 	// 依赖注入 - Controller 层
-	pageController := controller.NewPageController(pageUseCase)
-	wsHandler := controller.NewWSHandler(hub, []string{
+	pageController := controller.NewPageController(pageUseCase, shareLinkUseCase)
+	versionController := controller.NewVersionController(versionUseCase)
+	commentController := controller.NewCommentController(commentUseCase)
+	collaboratorController := controller.NewCollaboratorController(pageUseCase)
+	inviteController := controller.NewInviteController(inviteUseCase)
+	secretController := controller.NewSecretController(secretUseCase)
+	aiController := controller.NewAIController(aiUseCase)
+	shareLinkController := controller.NewShareLinkController(shareLinkUseCase)
+	guestTokenController := controller.NewGuestTokenController(guestTokenUseCase)
+	conflictBackupController := controller.NewConflictBackupController(conflictBackupUseCase)
+	apiKeyController := controller.NewAPIKeyController(apiKeyUseCase)
+	branchController := controller.NewBranchController(branchUseCase)
+	mergeRequestController := controller.NewMergeRequestController(mergeRequestUseCase)
+	storageController := controller.NewStorageController(storageUseCase)
+	usageController := controller.NewUsageController(usageUseCase)
+	userController := controller.NewUserController(userUseCase)
+	consistencyController := controller.NewConsistencyController(consistencyUseCase)
+	adminController := controller.NewAdminController(env, hub)
+	moderationController := controller.NewModerationController(moderationUseCase)
+	wsHandler := controller.NewWSHandler(hub, pageUseCase, pageUseCase, userUseCase, authVerifier, []string{
 		"https://xxmudcloudxx.github.io",
+	}, ws.CompressionConfig{
+		Enabled: env.WSCompression,
+		Level:   env.WSCompressionLevel,
+		MinSize: env.WSCompressionMinSize,
 	})
-	webhookController := controller.NewWebhookController(userRepo, env.WebhookSecret)
+	wsHandler.EnableShareLinks(shareLinkUseCase)
+	wsHandler.EnableGuestTokens(guestTokenUseCase)
+	wsHandler.EnableConnectionLimits(env.WSHandshakesPerMinute, env.WSMaxConnsPerUser)
+
+	// 不依赖 Redis 的多节点部署：同一页面的协同连接固定到同一节点（可选）
+	if ring := bootstrap.NewCollabPlacement(env); ring != nil {
+		wsHandler.EnablePlacement(ring, env.NodeID, env.CollabStickyCookie)
+		log.Printf("[Server] 协同节点分配已启用: 本节点 %s，共 %d 个节点", env.NodeID, len(ring.Nodes()))
+	}
+	webhookController := controller.NewWebhookController(userRepo, orgMemberRepo, orgRepo, inviteUseCase, revocationUseCase, userCleanupUseCase, env.WebhookSecret, env.WebhookAllowUnsigned)
 
 	// 启动 Hub 事件循环
 	go hub.Run()
 
+	// 每日一致性巡检
+	stopJobs := make(chan struct{})
+	go consistencyUseCase.RunNightly(env.ConsistencyCheckHour, stopJobs)
+
+	// 历史版本压缩
+	go retentionUseCase.RunPeriodic(env.VersionCompactInterval, stopJobs)
+
+	// 同步其他实例收到的会话吊销，断开对应连接
+	go revocationUseCase.RunPeriodic(env.TokenRevocationSyncInterval, stopJobs)
+
+	if watchdog != nil {
+		go watchdog.Run(stopJobs)
+	}
+
 	// 配置 Gin 路由
 	router := gin.Default()
 
@@ -66,12 +372,64 @@ func main() {
 	}))
 
 	// 设置路由
+	var roomOwners middleware.RoomOwnerLocator
+	if env.RoomLockEnabled {
+		roomOwners = hub
+	}
 	route.Setup(router, &route.Dependencies{
 		PageController:    pageController,
+		VersionController: versionController,
+		CommentController: commentController,
 		WSHandler:         wsHandler,
 		WebhookController: webhookController,
+		UserController:    userController,
+		Auth:              authVerifier,
+		PageRoles:         pageUseCase,
+
+		CollaboratorController: collaboratorController,
+		InviteController:       inviteController,
+		SecretController:       secretController,
+		AIController:           aiController,
+		ShareLinkController:    shareLinkController,
+		GuestTokenController:   guestTokenController,
+
+		ConflictBackupController: conflictBackupController,
+		BranchController:         branchController,
+		MergeRequestController:   mergeRequestController,
+		StorageController:        storageController,
+		UsageController:          usageController,
+
+		APIKeyController: apiKeyController,
+		APIKeys:          apiKeyUseCase,
+
+		ConsistencyController: consistencyController,
+		AdminController:       adminController,
+		ModerationController:  moderationController,
+		OpsToken:              env.OpsToken,
+		Instance:              hub,
+
+		RoomOwners: roomOwners,
 	})
 
+	// 实验性 WebTransport 入口，与 HTTP 服务共用路由，在 UDP 端口上提供 HTTP/3
+	var wtServer *webtransport.Server
+	if env.WebTransportEnabled {
+		wtServer = &webtransport.Server{
+			H3: http3.Server{
+				Addr:    ":" + env.WebTransportPort,
+				Handler: router,
+			},
+		}
+		wsHandler.EnableWebTransport(wtServer)
+
+		go func() {
+			log.Printf("[Server] WebTransport 已开启（实验性）: https://localhost:%s/wt (UDP)", env.WebTransportPort)
+			if err := wtServer.ListenAndServeTLS(env.WebTransportCertFile, env.WebTransportKeyFile); err != nil && err != http.ErrServerClosed {
+				logging.Errorf("[Server] WebTransport 服务启动失败: %v", err)
+			}
+		}()
+	}
+
 	// 启动 HTTP 服务
 	srv := &http.Server{
 		Addr:    ":" + env.Port,
@@ -82,14 +440,86 @@ func main() {
 		log.Printf("[Server] 服务已启动: http://localhost:%s", env.Port)
 		log.Printf("[Server] API 端点:")
 		log.Printf("   GET  /health              - 健康检查")
-		log.Printf("   GET  /api/pages/:pageId   - 获取页面")
-		log.Printf("   POST /api/pages           - 创建页面")
-		log.Printf("   DELETE /api/pages/:pageId - 删除页面")
+		log.Printf("   GET  /ready               - 就绪检查（排空或维护中返回 503）")
+		log.Printf("   GET  /api/pages/:pageId   - 获取页面（支持 X-Share-Token 免登录只读访问）")
+		log.Printf("   GET  /api/pages/:pageId/presence - 在线用户")
+		log.Printf("   GET  /api/pages/:pageId/poll?sinceVersion= - 长轮询增量（WebSocket 不可用时降级）")
+		log.Printf("   GET  /api/pages/:pageId/collab-endpoint - 页面协同连接应连接的节点")
+		log.Printf("   DELETE /api/pages/:pageId/presence/:userId - 移出协同用户")
+		log.Printf("   GET  /api/pages           - 页面列表（激活组织时为组织页面，否则为个人页面）")
+		log.Printf("   POST /api/pages           - 创建页面（激活组织时属于该组织）")
+		log.Printf("   POST /api/pages/import-legacy - 导入旧版 localStorage 页面")
+		log.Printf("   DELETE /api/pages/:pageId?confirm= - 删除页面（有人在线或被引用时需确认）")
+		log.Printf("   POST /api/pages/:pageId/publish - 发布页面")
+		log.Printf("   GET  /api/pages/:pageId/references - 页面的出站/入站引用")
+		log.Printf("   GET  /api/pages/:pageId/usages - 页面被哪些页面使用（删除前提示）")
+		log.Printf("   PUT  /api/pages/:pageId/sharing - 分享设置")
+		log.Printf("   GET  /api/pages/:pageId/collaborators - 协作者列表")
+		log.Printf("   PUT|DELETE /api/pages/:pageId/collaborators/:userId - 添加/移除协作者")
+		log.Printf("   GET|PUT /api/pages/:pageId/secrets - 查看（仅所有者）/ 设置组件密钥属性")
+		log.Printf("   POST /api/pages/:pageId/ai/generate - AI 生成页面修改")
+		log.Printf("   POST /api/pages/:pageId/ai/proposals - AI 生成修改建议（审阅后接受）")
+		log.Printf("   POST /api/pages/:pageId/ai/proposals/:proposalId/accept - 接受 AI 修改建议")
+		log.Printf("   DELETE /api/pages/:pageId/ai/proposals/:proposalId - 放弃 AI 修改建议")
+		log.Printf("   GET|POST /api/pages/:pageId/invites - 通过邮箱邀请协作者")
+		log.Printf("   DELETE /api/pages/:pageId/invites/:inviteId - 撤销邀请")
+		log.Printf("   GET|POST /api/pages/:pageId/share-links - 公开只读分享链接")
+		log.Printf("   DELETE /api/pages/:pageId/share-links/:linkId - 撤销分享链接")
+		log.Printf("   POST /api/pages/:pageId/guest-token - 签发临时访客 Token")
+		log.Printf("   GET|DELETE /api/pages/:pageId/conflict-backups[/:backupId] - 冲突备份")
+		log.Printf("   GET|POST /api/pages/:pageId/branches - 私有草稿分支")
+		log.Printf("   GET|DELETE /api/pages/:pageId/branches/:branchId - 合并预览 / 放弃分支")
+		log.Printf("   POST /api/pages/:pageId/branches/:branchId/merge - 合并分支")
+		log.Printf("   GET|POST /api/pages/:pageId/merge-requests - 合并请求")
+		log.Printf("   GET /api/pages/:pageId/merge-requests/:mrId - 合并请求详情与差异预览")
+		log.Printf("   POST /api/pages/:pageId/merge-requests/:mrId/merge|close - 按策略合并 / 关闭合并请求")
+		log.Printf("   GET|POST /api/pages/:pageId/merge-requests/:mrId/comments - 合并请求讨论")
+		log.Printf("   GET|POST /api/api-keys    - 服务端集成 API Key（业务接口可用 X-API-Key 认证）")
+		log.Printf("   DELETE /api/api-keys/:keyId - 撤销 API Key")
+		log.Printf("   PUT  /api/pages/:pageId/chat - 聊天设置")
+		log.Printf("   GET|PUT /api/pages/:pageId/collab-settings - 协同设置（光标、选中、聊天开关与刷盘节奏）")
+		log.Printf("   PUT|DELETE /api/pages/:pageId/session - 设置或取消协作时段")
+		log.Printf("   POST /api/pages/:pageId/ops - 批量操作（复制子树、编号、对齐属性）")
+		log.Printf("   GET  /public/pages/:pageId - 获取已发布页面（公开）")
+		log.Printf("   GET  /api/users/me        - 当前用户资料")
+		log.Printf("   PUT  /api/users/me/cursor-color - 自定义协作光标颜色")
+		log.Printf("   GET  /api/me/recent-pages - 最近打开 / 编辑的页面")
+		log.Printf("   GET  /api/me/quota - 当前用户的页面数配额")
+		log.Printf("   GET  /api/storage         - 当前租户的存储用量与套餐")
+		log.Printf("   GET  /api/usage           - 当前租户本月的计量用量与套餐额度")
+		log.Printf("   GET/PUT/DELETE /api/ai/provider - 当前租户自带的模型服务")
+		log.Printf("   GET  /api/ai/usage        - 当前租户的 AI 用量")
+		log.Printf("   GET  /api/pages/:pageId/diff?from=&to= - 版本对比")
+		log.Printf("   GET  /api/pages/:pageId/history?since=&limit= - 编辑记录")
+		log.Printf("   GET|POST /api/pages/:pageId/comments - 组件评论")
+		log.Printf("   PUT|DELETE /api/pages/:pageId/comments/:commentId - 修改/删除评论")
+		log.Printf("   PUT  /api/pages/:pageId/comments/:commentId/resolve - 解决评论线程")
 		log.Printf("   GET  /ws?pageId=xxx&token=xxx - WebSocket 连接")
+		if env.WebTransportEnabled {
+			log.Printf("   CONNECT /wt?pageId=xxx&token=xxx - WebTransport 连接（实验性，HTTP/3）")
+		}
 		log.Printf("   POST /webhook/clerk       - Clerk Webhook")
+		if env.OpsToken != "" {
+			log.Printf("   GET  /ops/metrics         - 运行指标 (expvar)")
+			log.Printf("   GET  /ops/consistency     - 最近一致性巡检报告")
+			log.Printf("   POST /ops/consistency/run - 立即执行一致性巡检")
+			log.Printf("   GET  /api/admin/config    - 运行时配置（已脱敏）")
+			log.Printf("   GET  /api/admin/rooms     - 各房间 Patch / 刷盘计数")
+			log.Printf("   GET  /api/admin/rooms/:pageId/repro - 导出房间复现包")
+			log.Printf("   POST /api/admin/announce  - 发布系统公告（限流）")
+			log.Printf("   GET|PUT /api/admin/maintenance - 本实例的维护模式")
+			log.Printf("   GET  /api/admin/storage?limit= - 存储用量最大的租户")
+			log.Printf("   POST /api/admin/storage/recount - 重新统计存储用量")
+			log.Printf("   GET  /api/admin/tenants/:tenantId/storage - 租户存储用量")
+			log.Printf("   GET  /api/admin/moderation/flags?pageId=&pending= - 内容审核记录")
+			log.Printf("   POST /api/admin/moderation/flags/:flagId/review - 标记审核记录已复核")
+			log.Printf("   PUT  /api/admin/tenants/:tenantId/plan - 设置租户套餐")
+			log.Printf("   GET  /api/admin/tenants/:tenantId/ai-usage - 租户 AI 用量")
+			log.Printf("   GET  /api/admin/tenants/:tenantId/usage - 租户本月的计量用量")
+		}
 
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("[Server] 服务启动失败: %v", err)
+			logging.Fatalf("[Server] 服务启动失败: %v", err)
 		}
 	}()
 
@@ -99,12 +529,47 @@ func main() {
 	<-quit
 
 	log.Println("[Server] 收到停机信号，正在优雅关闭...")
+	close(stopJobs)
+
+	// 排空：/ready 先返回 503 让负载均衡摘除本实例，协同客户端在 DRAIN_GRACE 内分散重连到其他实例，
+	// 滚动发布时长连接不会在同一时刻全部断开重连
+	if env.DrainGrace > 0 {
+		hub.Drain(env.DrainGrace)
+		time.Sleep(env.DrainGrace)
+	}
+
+	// 先通知协同客户端并刷盘关闭房间，HTTP Shutdown 不会等待已升级的 WebSocket 连接
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelDrain()
+	if err := hub.Shutdown(drainCtx); err != nil {
+		logging.Errorf("[Server] 房间未能在超时前全部刷盘: %v", err)
+	}
+
+	if wtServer != nil {
+		wtServer.Close()
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("[Server] 服务强制关闭: %v", err)
+		logging.Fatalf("[Server] 服务强制关闭: %v", err)
+	}
+
+	// 房间刷盘时会通知其他节点，处理中的请求修改页面后会使缓存失效，
+	// 桥接（连同共用的 Redis 连接）需在 Hub 停机和 HTTP 关闭之后关闭
+	if roomBridge != nil {
+		roomBridge.Close()
+	}
+
+	// 写入剩余的操作日志、页面活动和引用索引
+	opLogWriter.Close()
+	activityWriter.Close()
+	referenceIndexer.Close()
+
+	// 上传已销毁房间的剩余归档，需在操作日志落盘之后
+	if archiveWriter != nil {
+		archiveWriter.Close()
 	}
 
 	log.Println("[Server] 服务已安全停止")
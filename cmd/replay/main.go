@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"lowercode-go-server/internal/ws"
+)
+
+// 在新房间中回放复现包（GET /api/admin/rooms/:pageId/repro 导出），检查能否得到与导出时一致的状态。
+//
+//	go run ./cmd/replay -bundle repro.json
+//	go run ./cmd/replay -bundle repro.json -until 1234 -out state.json
+func main() {
+	// 命令行参数
+	bundlePath := flag.String("bundle", "", "复现包文件路径，支持 gzip 压缩")
+	until := flag.Int64("until", 0, "回放到指定版本为止，0 表示回放全部操作")
+	out := flag.String("out", "", "将回放结束时的状态写入该文件")
+	verbose := flag.Bool("v", false, "逐条打印回放的操作")
+	flag.Parse()
+
+	if *bundlePath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	bundle, err := readBundle(*bundlePath)
+	if err != nil {
+		log.Fatalf("[Replay] 读取复现包失败: %v", err)
+	}
+	fmt.Printf("页面 %s：快照版本 %d，%d 条操作，导出版本 %d（%s）\n",
+		bundle.PageID, bundle.BaseVersion, len(bundle.Ops), bundle.Version, bundle.CreatedAt.Format("2006-01-02 15:04:05"))
+
+	if *verbose {
+		for _, op := range bundle.Ops {
+			if *until > 0 && op.Version > *until {
+				break
+			}
+			fmt.Printf("  v%d %s %s\n", op.Version, op.AuthorID, op.Patch)
+		}
+	}
+
+	result, err := ws.Replay(bundle, *until)
+	if err != nil {
+		log.Fatalf("[Replay] 复现包无效: %v", err)
+	}
+
+	if *out != "" {
+		if err := os.WriteFile(*out, result.State, 0o644); err != nil {
+			log.Fatalf("[Replay] 写入 %s 失败: %v", *out, err)
+		}
+		fmt.Printf("已将版本 %d 的状态写入 %s\n", result.Version, *out)
+	}
+
+	switch {
+	case result.Err != nil:
+		fmt.Printf("回放失败：已应用 %d 条操作，版本 %d 应用失败: %v\n", result.Applied, result.FailedVersion, result.Err)
+		os.Exit(1)
+	case *until > 0 && result.Version < bundle.Version:
+		fmt.Printf("已回放到版本 %d（%d 条操作）\n", result.Version, result.Applied)
+	case result.Match:
+		fmt.Printf("回放成功：%d 条操作，版本 %d，状态与导出时一致\n", result.Applied, result.Version)
+	default:
+		fmt.Printf("回放结果与导出时不一致：回放版本 %d，导出版本 %d\n", result.Version, bundle.Version)
+		os.Exit(1)
+	}
+}
+
+// readBundle 读取复现包，按文件头识别 gzip 压缩
+func readBundle(path string) (*ws.ReproBundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = io.ReadAll(gz); err != nil {
+			return nil, err
+		}
+	}
+
+	var bundle ws.ReproBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// PageActivity 用户最近一次打开、编辑页面的时间，用于"继续上次的编辑"
+// 打开时间在加入协同房间时记录，编辑时间在包含该用户编辑的刷盘成功后记录
+type PageActivity struct {
+	UserID       string `gorm:"primaryKey;size:64"`
+	PageID       string `gorm:"primaryKey;size:64;index"`
+	LastOpenedAt *time.Time
+	LastEditedAt *time.Time
+}
@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+// AI 用量的调用来源
+const (
+	AIUsageSourcePlatform = "platform" // 服务端配置的默认模型服务（LLM_API_KEY），费用由平台承担
+	AIUsageSourceTenant   = "tenant"   // 租户自带的模型服务，费用由租户承担
+)
+
+// AIProviderConfig 租户自带的模型服务配置，配置后该租户页面上的 AI 生成都调用这里的服务。
+// API Key 与密钥属性使用同一把加密密钥（SECRET_PROPS_KEY），密文绑定租户 ID，不能挪给其他租户使用
+type AIProviderConfig struct {
+	TenantID   string `gorm:"primaryKey;size:64"`
+	Kind       string `gorm:"size:16"`  // openai / azure / local，见 llm.Kind*
+	BaseURL    string `gorm:"size:512"` // 服务地址
+	Model      string `gorm:"size:128"` // 模型名，Azure 为部署名
+	APIVersion string `gorm:"size:32"`  // 仅 Azure
+	APIKey     string `gorm:"type:text"`
+	APIKeyMask string `gorm:"size:32"` // 掩码，供设置页面展示
+	UpdatedBy  string `gorm:"size:64"`
+	UpdatedAt  time.Time
+}
+
+// AIUsage 租户每天的 AI 调用用量，按来源和模型汇总
+type AIUsage struct {
+	TenantID         string    `gorm:"primaryKey;size:64"`
+	Day              time.Time `gorm:"primaryKey;type:date"` // UTC 日期
+	Source           string    `gorm:"primaryKey;size:16"`   // platform / tenant
+	Model            string    `gorm:"primaryKey;size:128"`
+	Requests         int64
+	Failures         int64 // 调用失败或超出大小上限的请求
+	PromptTokens     int64 // 服务端返回的 Token 数，部分本地服务不返回
+	CompletionTokens int64
+	RequestBytes     int64
+	ResponseBytes    int64
+}
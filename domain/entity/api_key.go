@@ -0,0 +1,67 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// API Key 权限
+const (
+	APIKeyScopeRead  = "pages:read"  // 只能调用 GET 接口
+	APIKeyScopeWrite = "pages:write" // 可以调用全部页面接口，包含 pages:read
+)
+
+// API Key 限制
+const (
+	APIKeyPrefix        = "lck_" // 密钥明文前缀，便于在日志和代码仓库中识别泄露的密钥
+	MaxAPIKeysPerUser   = 20     // 每个用户同时有效的密钥上限
+	MaxAPIKeyTTL        = 365 * 24 * time.Hour
+	APIKeyTouchInterval = time.Minute // LastUsedAt 的更新间隔，避免每个请求都写库
+)
+
+// ValidAPIKeyScope 检查是否为支持的权限
+func ValidAPIKeyScope(scope string) bool {
+	return scope == APIKeyScopeRead || scope == APIKeyScopeWrite
+}
+
+// APIKey 供 CI、自动化脚本等外部服务调用页面 API 的密钥，以创建者的身份访问页面。
+// 只保存密钥的 SHA-256，明文仅在创建时返回一次
+type APIKey struct {
+	ID         string `gorm:"primaryKey;size:32"`
+	UserID     string `gorm:"size:64;index"`
+	OrgID      string `gorm:"size:64"` // 创建时激活的组织，非空时请求按该组织成员身份处理，创建者离开组织后密钥不可用
+	Name       string `gorm:"size:100"`
+	KeyHash    string `gorm:"size:64;uniqueIndex"`
+	Prefix     string `gorm:"size:16"`  // 明文的前几位，用于在列表中辨认密钥
+	Scopes     string `gorm:"size:128"` // 逗号分隔的权限
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+
+	// OrgRole 认证时查得的创建者当前组织角色，不持久化
+	OrgRole string `gorm:"-"`
+}
+
+// ScopeList 返回密钥的权限列表
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+// Allows 密钥是否拥有 scope 权限，pages:write 包含 pages:read
+func (k *APIKey) Allows(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope || (s == APIKeyScopeWrite && scope == APIKeyScopeRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// Active 密钥在 now 时是否仍可使用：未撤销且未过期
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// 草稿分支限制
+const (
+	BranchPagePrefix    = "branch-" // 分支页面 ID 前缀，分支页面即 BranchPagePrefix + 分支 ID
+	MaxBranchesPerUser  = 10        // 每个用户在同一页面上同时存在的分支上限
+	MaxBranchNameLength = 100
+)
+
+// PageBranch 用户从页面分叉出的私有草稿分支。
+// 分支内容保存在独立的页面（BranchPageID）中，拥有自己的协同房间和存储，只有分支所有者可以访问；
+// 合并时以 BaseSchema 为共同祖先，把分支上的修改移植到页面的最新状态上
+type PageBranch struct {
+	ID           string `gorm:"primaryKey;size:32"`
+	PageID       string `gorm:"size:64;index"`       // 被分叉的页面
+	BranchPageID string `gorm:"size:64;uniqueIndex"` // 分支内容所在的页面，协同连接时作为 pageId
+	OwnerID      string `gorm:"size:64;index"`
+	Name         string `gorm:"size:100"`
+
+	// 分叉时页面的版本和 Schema，作为三方合并的共同祖先
+	BaseVersion int64
+	BaseSchema  datatypes.JSON `gorm:"type:jsonb"`
+
+	CreatedAt time.Time
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// ChatMessage 房间内的聊天消息，仅在页面开启聊天持久化时写入
+type ChatMessage struct {
+	ID        uint      `gorm:"primaryKey"`
+	PageID    string    `gorm:"size:64;index:idx_chat_page_time"`
+	UserID    string    `gorm:"size:64"`
+	UserName  string    `gorm:"size:128"`
+	Guest     bool      // 是否由免登录访客发送
+	Text      string    `gorm:"type:text"`
+	CreatedAt time.Time `gorm:"index:idx_chat_page_time"`
+}
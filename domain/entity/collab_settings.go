@@ -0,0 +1,123 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 刷盘节奏覆盖值的取值范围，0 表示使用服务默认值
+const (
+	MinFlushInterval  = 5 * time.Second
+	MaxFlushInterval  = 10 * time.Minute
+	MaxFlushThreshold = 1000
+)
+
+// MaxSessionDuration 协作时段的最长时长
+const MaxSessionDuration = 7 * 24 * time.Hour
+
+// ConflictStrategy 基于旧版本的编辑（op-patch 的 version 落后于房间当前版本）的处理方式
+type ConflictStrategy string
+
+const (
+	ConflictStrict ConflictStrategy = "strict" // 乐观锁：直接拒绝，客户端重新同步后重试（默认）
+	ConflictRebase ConflictStrategy = "rebase" // 服务端变基：与期间其他人的修改不重叠时在最新状态上应用
+	ConflictLWW    ConflictStrategy = "lww"    // 组件级后写者胜出：直接在最新状态上应用，覆盖期间其他人对同一组件的修改
+	ConflictCRDT   ConflictStrategy = "crdt"   // CRDT 模式：逐个字段合并，并发修改同一字段时按确定性的规则选出胜者
+)
+
+// Valid 检查是否为已知的冲突策略，空值表示默认策略
+func (s ConflictStrategy) Valid() bool {
+	switch s {
+	case "", ConflictStrict, ConflictRebase, ConflictLWW, ConflictCRDT:
+		return true
+	}
+	return false
+}
+
+// SessionWindow 页面的协作时段（如 2 小时的工作坊），时段外房间对所有者以外的用户只读，且拒绝其加入
+type SessionWindow struct {
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+}
+
+// Open 判断 now 是否在协作时段内，w 为 nil（未设置时段）时总是 true
+func (w *SessionWindow) Open(now time.Time) bool {
+	return w == nil || (!now.Before(w.StartsAt) && now.Before(w.EndsAt))
+}
+
+// NextBoundary 返回 now 之后时段开始或结束的时间，之后不再变化时返回零值
+func (w *SessionWindow) NextBoundary(now time.Time) time.Time {
+	switch {
+	case w == nil:
+		return time.Time{}
+	case now.Before(w.StartsAt):
+		return w.StartsAt
+	case now.Before(w.EndsAt):
+		return w.EndsAt
+	}
+	return time.Time{}
+}
+
+// Valid 检查时段是否非空且不超过 MaxSessionDuration
+func (w SessionWindow) Valid() bool {
+	return w.EndsAt.After(w.StartsAt) && w.EndsAt.Sub(w.StartsAt) <= MaxSessionDuration
+}
+
+// CollabSettings 页面级协同设置，以 JSON 保存在 pages.collab_settings 中。
+// 大型直播、课堂等场景下创建者可以关闭高频广播，并调整刷盘节奏
+type CollabSettings struct {
+	Cursors    bool `json:"cursors"`    // 广播光标位置与视口
+	Selections bool `json:"selections"` // 广播选中组件与选中冲突
+	Chat       bool `json:"chat"`       // 房间聊天
+
+	// 刷盘节奏覆盖，0 表示使用服务默认值
+	FlushIntervalSeconds int `json:"flushIntervalSeconds,omitempty"` // 定时刷盘间隔
+	FlushThreshold       int `json:"flushThreshold,omitempty"`       // 触发刷盘的未保存版本数
+
+	// Conflict 冲突处理策略，为空时使用 strict
+	Conflict ConflictStrategy `json:"conflict,omitempty"`
+
+	// Session 协作时段，为 nil 时不限制
+	Session *SessionWindow `json:"session,omitempty"`
+}
+
+// DefaultCollabSettings 返回默认设置：所有协同功能开启，刷盘节奏使用服务默认值
+func DefaultCollabSettings() CollabSettings {
+	return CollabSettings{Cursors: true, Selections: true, Chat: true}
+}
+
+// ParseCollabSettings 解析保存的设置，缺省的字段取默认值，为空或无法解析时返回默认设置
+func ParseCollabSettings(data []byte) CollabSettings {
+	settings := DefaultCollabSettings()
+	if len(data) == 0 {
+		return settings
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return DefaultCollabSettings()
+	}
+	return settings
+}
+
+// FlushInterval 返回定时刷盘间隔，未覆盖时返回 0
+func (s CollabSettings) FlushInterval() time.Duration {
+	return time.Duration(s.FlushIntervalSeconds) * time.Second
+}
+
+// ConflictStrategy 返回生效的冲突处理策略，未设置时为 strict
+func (s CollabSettings) ConflictStrategy() ConflictStrategy {
+	if s.Conflict == "" {
+		return ConflictStrict
+	}
+	return s.Conflict
+}
+
+// Valid 检查刷盘节奏覆盖值是否在允许范围内、冲突策略是否已知
+func (s CollabSettings) Valid() bool {
+	if !s.Conflict.Valid() {
+		return false
+	}
+	if interval := s.FlushInterval(); interval != 0 && (interval < MinFlushInterval || interval > MaxFlushInterval) {
+		return false
+	}
+	return s.FlushThreshold >= 0 && s.FlushThreshold <= MaxFlushThreshold
+}
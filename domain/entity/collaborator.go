@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// 页面协作角色。创建者即所有者，不写入 page_collaborators
+const (
+	RoleOwner  = "owner"  // 页面创建者，可管理协作者和页面设置
+	RoleEditor = "editor" // 可读取页面并协同编辑
+	RoleViewer = "viewer" // 可读取页面、加入房间查看实时变化，不能编辑
+)
+
+// PageCollaborator 页面协作者，同一页面同一用户只有一条记录
+type PageCollaborator struct {
+	ID        uint   `gorm:"primaryKey"`
+	PageID    string `gorm:"size:64;uniqueIndex:idx_page_collaborator"`
+	UserID    string `gorm:"size:64;uniqueIndex:idx_page_collaborator;index"`
+	Role      string `gorm:"size:16"`
+	AddedBy   string `gorm:"size:64"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
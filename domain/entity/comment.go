@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// Comment 组件评论。ParentID 为 0 的是线程首条评论，其余为该线程的回复；
+// 解决状态只记录在线程首条评论上。
+type Comment struct {
+	ID          uint       `gorm:"primaryKey"`
+	PageID      string     `gorm:"size:64;index:idx_comment_page_component"`
+	ComponentID string     `gorm:"size:64;index:idx_comment_page_component"`
+	ParentID    uint       `gorm:"index"`
+	AuthorID    string     `gorm:"size:64"`
+	Text        string     `gorm:"type:text"`
+	Resolved    bool       `gorm:"default:false"`
+	ResolvedBy  string     `gorm:"size:64"`
+	ResolvedAt  *time.Time // 为空表示未解决
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
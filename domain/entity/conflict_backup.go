@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// MaxConflictBackupsPerPage 每个页面保留的冲突备份条数，超出时删除最早的
+const MaxConflictBackupsPerPage = 20
+
+// 冲突备份的产生原因
+const (
+	ConflictBackupReasonConflict = "conflict" // 连续多次版本冲突
+	ConflictBackupReasonResync   = "resync"   // 本地状态被服务端 sync 覆盖
+)
+
+// ConflictBackup 服务端拒绝的客户端本地状态。
+// 客户端连续冲突或被强制重新同步时上传，供用户手动找回未能提交的修改。
+type ConflictBackup struct {
+	ID          uint           `gorm:"primaryKey"`
+	PageID      string         `gorm:"size:64;index:idx_conflict_backup_page_time"`
+	UserID      string         `gorm:"size:64"`
+	UserName    string         `gorm:"size:128"`
+	BaseVersion int64          // 客户端本地状态所基于的服务端版本
+	Reason      string         `gorm:"size:16"`
+	Schema      datatypes.JSON `gorm:"type:jsonb"`
+	CreatedAt   time.Time      `gorm:"index:idx_conflict_backup_page_time"`
+}
@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+// 邀请限制
+const (
+	InviteTTL                = 14 * 24 * time.Hour // 邀请有效期，重新邀请同一邮箱时重新计算
+	MaxPendingInvitesPerPage = 50
+)
+
+// PageInvite 通过邮箱邀请尚未注册的用户成为页面协作者。
+// 同一页面同一邮箱只有一条记录，重新邀请时更新角色和有效期；
+// 受邀者注册（Clerk user.created Webhook）后按邮箱匹配，转为 PageCollaborator 并记录接受者
+type PageInvite struct {
+	ID         uint       `gorm:"primaryKey"`
+	PageID     string     `gorm:"size:64;uniqueIndex:idx_page_invite"`
+	Email      string     `gorm:"size:255;uniqueIndex:idx_page_invite;index"` // 小写
+	Role       string     `gorm:"size:16"`
+	InvitedBy  string     `gorm:"size:64"`
+	ExpiresAt  time.Time  `gorm:"index"`
+	AcceptedBy string     `gorm:"size:64"`
+	AcceptedAt *time.Time // 为空表示尚未接受
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Pending 邀请是否仍在等待接受
+func (i *PageInvite) Pending(now time.Time) bool {
+	return i.AcceptedAt == nil && now.Before(i.ExpiresAt)
+}
@@ -0,0 +1,68 @@
+package entity
+
+import "time"
+
+// 合并请求状态
+const (
+	MergeRequestOpen   = "open"
+	MergeRequestMerged = "merged"
+	MergeRequestClosed = "closed"
+)
+
+// 合并冲突的处理策略。ours 保留页面的值，theirs 采用分支的值，
+// manual 由评审者提交基于页面当前版本的 Patch 自行解决
+const (
+	MergeStrategyOurs   = "ours"
+	MergeStrategyTheirs = "theirs"
+	MergeStrategyManual = "manual"
+)
+
+// 合并请求限制
+const (
+	MaxMergeRequestTitleLength       = 200
+	MaxMergeRequestDescriptionLength = 5000
+)
+
+// ValidMergeStrategy 是否为支持的合并策略，空字符串表示存在冲突时拒绝合并
+func ValidMergeStrategy(strategy string) bool {
+	switch strategy {
+	case "", MergeStrategyOurs, MergeStrategyTheirs, MergeStrategyManual:
+		return true
+	}
+	return false
+}
+
+// MergeRequest 把草稿分支合并回页面的请求，页面的协作者都可以查看、讨论，编辑者可以合并。
+// 合并后分支被删除，记录保留分支 ID 和名称，并记下合并前后的页面版本，可用版本对比查看合并的内容
+type MergeRequest struct {
+	ID           uint   `gorm:"primaryKey"`
+	PageID       string `gorm:"size:64;index"`
+	BranchID     string `gorm:"size:32;index"`
+	BranchPageID string `gorm:"size:64;index"`
+	BranchName   string `gorm:"size:100"`
+	AuthorID     string `gorm:"size:64"`
+	Title        string `gorm:"size:200"`
+	Description  string `gorm:"type:text"`
+	Status       string `gorm:"size:16;index;default:open"`
+
+	// 合并信息，仅 Status 为 merged 时有效
+	Strategy      string `gorm:"size:16"`
+	MergedBy      string `gorm:"size:64"`
+	BaseVersion   int64  // 合并前页面的版本
+	MergedVersion int64  // 合并产生的页面版本，分支没有可合并的修改时等于 BaseVersion
+	MergedAt      *time.Time
+
+	ClosedAt  *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// MergeRequestComment 合并请求下的讨论
+type MergeRequestComment struct {
+	ID             uint   `gorm:"primaryKey"`
+	MergeRequestID uint   `gorm:"index"`
+	PageID         string `gorm:"size:64;index"`
+	AuthorID       string `gorm:"size:64"`
+	Text           string `gorm:"type:text"`
+	CreatedAt      time.Time
+}
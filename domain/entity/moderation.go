@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// MaxModerationFlagTextLength 审核记录保存的文本上限（字符数），超出部分截断
+const MaxModerationFlagTextLength = 500
+
+// 审核记录的处理结果
+const (
+	ModerationActionRejected = "rejected" // 已拒绝，内容未应用
+	ModerationActionAllowed  = "allowed"  // 只记录，内容已应用
+)
+
+// ModerationFlag 命中内容审核规则的聊天消息或组件属性文本，供运维人员复核。
+// 页面删除后保留，便于事后追溯
+type ModerationFlag struct {
+	ID       uint   `gorm:"primaryKey"`
+	PageID   string `gorm:"size:64;index"`
+	UserID   string `gorm:"size:64"`
+	UserName string `gorm:"size:128"`
+	Guest    bool   // 是否由免登录访客提交
+	Source   string `gorm:"size:16"`  // chat / prop
+	Path     string `gorm:"size:512"` // 属性的 JSON Pointer，聊天为空
+	Text     string `gorm:"type:text"`
+	Reasons  string `gorm:"size:512"` // 逗号分隔的命中词或审核服务返回的类别
+	Action   string `gorm:"size:16"`
+
+	ReviewedAt *time.Time // 复核时间，为空表示待复核
+	CreatedAt  time.Time  `gorm:"index"`
+}
+
+// ReasonList 返回命中原因列表
+func (f *ModerationFlag) ReasonList() []string {
+	if f.Reasons == "" {
+		return []string{}
+	}
+	return strings.Split(f.Reasons, ",")
+}
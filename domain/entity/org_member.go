@@ -0,0 +1,45 @@
+package entity
+
+import "time"
+
+// Clerk 组织角色，对应 JWT 的 org_role 声明
+const (
+	OrgRoleAdmin  = "org:admin"  // 组织管理员，对组织页面拥有所有者权限
+	OrgRoleMember = "org:member" // 组织成员，可以编辑组织页面
+)
+
+// OrgMember Clerk 组织成员关系，由 Webhook 和携带组织声明的请求同步，同一组织同一用户只有一条记录
+type OrgMember struct {
+	ID        uint   `gorm:"primaryKey"`
+	OrgID     string `gorm:"size:64;uniqueIndex:idx_org_member"`
+	UserID    string `gorm:"size:64;uniqueIndex:idx_org_member;index"`
+	Role      string `gorm:"size:64"`
+	UpdatedAt time.Time
+}
+
+// PageRoleForOrgRole 组织角色在组织页面上对应的页面角色：
+// 管理员为 owner，成员为 editor，其他自定义角色为 viewer
+func PageRoleForOrgRole(orgRole string) string {
+	switch orgRole {
+	case OrgRoleAdmin:
+		return RoleOwner
+	case OrgRoleMember:
+		return RoleEditor
+	default:
+		return RoleViewer
+	}
+}
+
+// RoleRank 页面角色的权限高低，用于合并组织角色与协作者角色，未知角色为 0
+func RoleRank(role string) int {
+	switch role {
+	case RoleOwner:
+		return 3
+	case RoleEditor:
+		return 2
+	case RoleViewer:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// Organization Clerk 组织同步表，由 organization.* 和 organizationMembership.* Webhook 维护；
+// 成员关系保存在 OrgMember，组织删除时一并清除。组织页面的访问权限只取决于成员关系，
+// 组织资料用于展示，缺失时（如 Webhook 上线前创建的组织）不影响授权
+type Organization struct {
+	ID        string `gorm:"primaryKey;size:64"` // Clerk org_id
+	Name      string `gorm:"size:255"`
+	Slug      string `gorm:"size:255"`
+	ImageURL  string `gorm:"size:500"`
+	CreatedBy string `gorm:"size:64"` // 创建组织的用户，成员关系事件中没有该字段时为空
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// OutboxEvent 待外部系统消费的事件（Outbox 模式）。
+// 只追加不修改，由外部消费者按 ID 顺序拉取。
+type OutboxEvent struct {
+	ID        uint           `gorm:"primaryKey"`
+	Topic     string         `gorm:"size:64;index"`
+	Key       string         `gorm:"size:64;index"` // 聚合标识，如 pageId
+	Payload   datatypes.JSON `gorm:"type:jsonb"`
+	CreatedAt time.Time      `gorm:"index"`
+}
@@ -2,9 +2,13 @@ package entity
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 // --- Schema 结构定义 ---
@@ -13,6 +17,9 @@ import (
 type PageSchema struct {
 	RootID     int64                `json:"rootId"`
 	Components map[string]Component `json:"components"`
+
+	// EditedBy 组件 ID → 最后编辑者，由协同服务在应用 Patch 时写入，新建页面时为空
+	EditedBy map[string]json.RawMessage `json:"editedBy,omitempty"`
 }
 
 // Component 组件结构
@@ -56,6 +63,50 @@ func (s *PageSchema) ToBytes() ([]byte, error) {
 	return json.Marshal(s)
 }
 
+// Problems 检查组件树的结构一致性，返回发现的问题，为空表示一致：
+// 根组件存在；组件的 key 与 id 一致且 name 不为空；children 引用的组件存在且 parentId 指回父组件；
+// 根组件以外的组件都有存在的父组件。按组件 key 排序检查，结果顺序稳定
+func (s *PageSchema) Problems() []string {
+	var problems []string
+	if _, ok := s.Components[strconv.FormatInt(s.RootID, 10)]; !ok {
+		problems = append(problems, fmt.Sprintf("根组件 %d 不存在", s.RootID))
+	}
+
+	keys := make([]string, 0, len(s.Components))
+	for key := range s.Components {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		c := s.Components[key]
+		if key != strconv.FormatInt(c.ID, 10) {
+			problems = append(problems, fmt.Sprintf("组件 %s 的 id 为 %d", key, c.ID))
+		}
+		if c.Name == "" {
+			problems = append(problems, fmt.Sprintf("组件 %s 缺少 name", key))
+		}
+		for _, childID := range c.Children {
+			child, ok := s.Components[strconv.FormatInt(childID, 10)]
+			switch {
+			case !ok:
+				problems = append(problems, fmt.Sprintf("组件 %s 的子组件 %d 不存在", key, childID))
+			case child.ParentID == nil || *child.ParentID != c.ID:
+				problems = append(problems, fmt.Sprintf("组件 %d 的 parentId 未指向 %s", childID, key))
+			}
+		}
+		if c.ID == s.RootID {
+			continue
+		}
+		if c.ParentID == nil {
+			problems = append(problems, fmt.Sprintf("组件 %s 缺少 parentId", key))
+		} else if _, ok := s.Components[strconv.FormatInt(*c.ParentID, 10)]; !ok {
+			problems = append(problems, fmt.Sprintf("组件 %s 的父组件 %d 不存在", key, *c.ParentID))
+		}
+	}
+	return problems
+}
+
 // --- Page 数据库模型 ---
 
 // Page 页面数据库模型
@@ -66,7 +117,68 @@ type Page struct {
 	Version   int64          `gorm:"default:0"`
 	CreatorID string         `gorm:"size:64;index"` // Clerk user_id
 
+	// OrgID 页面所属的 Clerk 组织，为空时为创建者的个人页面；组织成员按组织角色访问（见 PageRoleForOrgRole）
+	OrgID string `gorm:"size:64;index"`
+
+	// BranchOf 非空表示该页面是 BranchOf 的草稿分支（见 PageBranch），不出现在页面列表中
+	BranchOf string `gorm:"size:64;index"`
+
+	// 差量持久化：Schema 只是 SnapshotVersion 时的全量快照，
+	// SnapshotVersion 小于 Version 时需回放 page_deltas 才能得到最新状态
+	SnapshotVersion int64 `gorm:"default:0"`
+
+	// 发布副本：协同编辑只修改草稿（Schema），公开访问只读取发布副本
+	PublishedSchema  datatypes.JSON `gorm:"type:jsonb"`
+	PublishedVersion int64          `gorm:"default:0"` // 0 表示从未发布
+	PublishedAt      *time.Time
+
+	// LinkEditEnabled 开启后，持有链接的未登录用户可以以访客身份协同编辑
+	LinkEditEnabled bool `gorm:"default:false"`
+
+	// ChatPersisted 开启后房间内的聊天消息写入数据库；关闭时只保存在房间内存中，房间关闭即清除
+	ChatPersisted bool `gorm:"default:false"`
+
+	// CollabSettings 页面级协同设置（见 CollabSettings），为空时使用默认设置
+	CollabSettings datatypes.JSON `gorm:"type:jsonb"`
+
 	Creator   User `gorm:"foreignKey:CreatorID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
+
+	// DeletedAt 软删除时间：删除用户时没有接手人的页面只做标记并保留全部数据，可由管理员恢复。
+	// GORM 查询自动排除已软删除的页面，手写 SQL 需要自行过滤 deleted_at IS NULL
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// PageVersion 页面历史版本快照，每次刷盘写入一条
+type PageVersion struct {
+	ID        uint           `gorm:"primaryKey"`
+	PageID    string         `gorm:"size:64;uniqueIndex:idx_page_version"`
+	Version   int64          `gorm:"uniqueIndex:idx_page_version"`
+	Schema    datatypes.JSON `gorm:"type:jsonb"`
+	CreatedAt time.Time      `gorm:"index"`
+}
+
+// PageOp 已应用的 Patch 操作日志（只追加），用于审计、归因和增量重连
+// Version 为应用该 Patch 之后的页面版本号
+type PageOp struct {
+	ID        uint           `gorm:"primaryKey"`
+	PageID    string         `gorm:"size:64;uniqueIndex:idx_page_op"`
+	Version   int64          `gorm:"uniqueIndex:idx_page_op"`
+	Patch     datatypes.JSON `gorm:"type:jsonb"`
+	AuthorID  string         `gorm:"size:64"` // 提交该操作的用户，服务端内部产生的操作为空
+	Guest     bool           // 审计水印：是否由免登录访客提交
+	Label     string         `gorm:"size:32"` // 产生该操作的编辑器功能（如 drag-resize、undo），客户端未标注时为空
+	CreatedAt time.Time      `gorm:"index"`
+}
+
+// PageDelta 两次刷盘之间累积的 Patch（只追加），用于差量持久化
+// Patches 为 JSON 数组，第 i 个元素把版本 BaseVersion+i 变为 BaseVersion+i+1，最后得到 Version
+type PageDelta struct {
+	ID          uint           `gorm:"primaryKey"`
+	PageID      string         `gorm:"size:64;uniqueIndex:idx_page_delta"`
+	Version     int64          `gorm:"uniqueIndex:idx_page_delta"`
+	BaseVersion int64          `gorm:"not null"`
+	Patches     datatypes.JSON `gorm:"type:jsonb"`
+	CreatedAt   time.Time      `gorm:"index"`
 }
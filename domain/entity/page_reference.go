@@ -0,0 +1,12 @@
+package entity
+
+// PageReference 页面 Schema 中对其他页面的一个引用（链接、嵌入等），由协同房间刷盘后异步重建，
+// 用于查询哪些页面引用了某个页面；页面当前的出站引用以 Schema 为准
+type PageReference struct {
+	ID           uint   `gorm:"primaryKey"`
+	SourcePageID string `gorm:"size:64;index"` // 引用所在的页面
+	TargetPageID string `gorm:"size:64;index"` // 被引用的页面
+	Path         string `gorm:"size:512"`      // 引用对象在 Schema 中的 JSON Pointer
+	ComponentID  int64  // 引用所在的组件，不在组件中时为 0
+	Kind         string `gorm:"size:16"` // link（跳转）或 embed（嵌入），见 pageref.KindLink
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// RevokedSession 被吊销的登录会话，由 Clerk Webhook 写入，各实例定期同步后拒绝其 Token 并断开在线连接。
+// SessionID 为空时吊销该用户在 RevokedAt 及之前签发的全部 Token（如账号被删除）
+type RevokedSession struct {
+	ID        uint   `gorm:"primaryKey"`
+	SessionID string `gorm:"size:64;index"`
+	UserID    string `gorm:"size:64"`
+	RevokedAt time.Time
+	ExpiresAt time.Time `gorm:"index"` // 此后该会话签发的 Token 均已过期，记录可以清理
+}
@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+// 分享链接有效期
+const (
+	DefaultShareLinkTTL = 7 * 24 * time.Hour  // 未指定有效期时使用
+	MaxShareLinkTTL     = 30 * 24 * time.Hour // 允许设置的最长有效期
+)
+
+// ShareLink 页面的公开只读分享链接，持有签名 Token 即可免登录读取页面、以观看者身份加入协同房间
+type ShareLink struct {
+	ID        string `gorm:"primaryKey;size:32"`
+	PageID    string `gorm:"size:64;index"`
+	CreatedBy string `gorm:"size:64"`
+	ExpiresAt time.Time
+	RevokedAt *time.Time // 非空表示已撤销
+	CreatedAt time.Time
+}
+
+// Active 链接在 now 时是否仍可使用：未撤销且未过期
+func (l *ShareLink) Active(now time.Time) bool {
+	return l.RevokedAt == nil && now.Before(l.ExpiresAt)
+}
+
+// VisitorID 通过该链接加入协同房间的观看者身份，撤销链接时据此移出在线连接
+func (l *ShareLink) VisitorID() string {
+	return "share-" + l.ID
+}
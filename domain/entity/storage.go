@@ -0,0 +1,59 @@
+package entity
+
+import "time"
+
+// 存储套餐
+const (
+	PlanFree       = "free"
+	PlanPro        = "pro"
+	PlanEnterprise = "enterprise"
+)
+
+// ValidPlan 检查是否为支持的套餐
+func ValidPlan(plan string) bool {
+	return plan == PlanFree || plan == PlanPro || plan == PlanEnterprise
+}
+
+// TenantOf 页面所属的租户：组织页面为组织 ID，个人页面（含草稿分支）为创建者 ID。
+// Clerk 的组织 ID（org_）与用户 ID（user_）前缀不同，不会冲突
+func TenantOf(page *Page) string {
+	if page.OrgID != "" {
+		return page.OrgID
+	}
+	return page.CreatorID
+}
+
+// PageStorage 单个页面占用的存储字节数，由各写入路径在同一事务中更新。
+// 按 JSON 在数据库中的文本长度统计，与实际磁盘占用（压缩、索引）不完全一致
+type PageStorage struct {
+	PageID         string `gorm:"primaryKey;size:64"`
+	TenantID       string `gorm:"size:64;index"`
+	SchemaBytes    int64  // 草稿 Schema
+	PublishedBytes int64  // 发布副本
+	SnapshotBytes  int64  // 历史版本快照
+	AssetBytes     int64  // 页面附带的文件，目前为冲突备份
+	JournalBytes   int64  // 操作日志与差量
+	UpdatedAt      time.Time
+}
+
+// TenantPlan 租户的存储套餐，没有记录时使用默认套餐
+type TenantPlan struct {
+	TenantID  string `gorm:"primaryKey;size:64"`
+	Plan      string `gorm:"size:32"`
+	UpdatedAt time.Time
+}
+
+// StorageUsage 租户的存储用量汇总
+type StorageUsage struct {
+	TenantID      string
+	Pages         int64
+	SchemaBytes   int64 // 草稿与发布副本
+	SnapshotBytes int64
+	AssetBytes    int64
+	JournalBytes  int64
+}
+
+// Total 总用量
+func (u *StorageUsage) Total() int64 {
+	return u.SchemaBytes + u.SnapshotBytes + u.AssetBytes + u.JournalBytes
+}
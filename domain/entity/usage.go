@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+// 按套餐计量的调用类型
+const (
+	UsageKindAI      = "ai"      // AI 生成和修改建议（HTTP 与协同连接内的流式生成），只计平台默认模型服务的调用
+	UsageKindPublish = "publish" // 发布页面
+)
+
+// UsageKinds 全部计量类型，按展示顺序
+var UsageKinds = []string{UsageKindAI, UsageKindPublish}
+
+// ValidUsageKind 检查是否为支持的计量类型
+func ValidUsageKind(kind string) bool {
+	for _, k := range UsageKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// UsagePeriod 计量周期：按 UTC 自然月，返回 t 所在周期的第一天
+func UsagePeriod(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// UsageCounter 租户在一个计量周期内某类调用的次数
+type UsageCounter struct {
+	TenantID  string    `gorm:"primaryKey;size:64"`
+	Kind      string    `gorm:"primaryKey;size:16"`
+	Period    time.Time `gorm:"primaryKey;type:date"` // 周期第一天，见 UsagePeriod
+	Count     int64
+	UpdatedAt time.Time
+}
@@ -8,6 +8,8 @@ type User struct {
     Email     string    `gorm:"size:255"`
     Name      string    `gorm:"size:100"`
     AvatarURL string    `gorm:"size:500"`
+
+    CursorColor string `gorm:"size:7"` // 协作光标颜色（#RRGGBB），首次连接时分配，用户可自定义
     CreatedAt time.Time
     UpdatedAt time.Time
 }
\ No newline at end of file
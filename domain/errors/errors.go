@@ -19,3 +19,186 @@ var ErrUnauthorized = errors.New("unauthorized: you don't have permission to per
 
 // ErrRoomClosing 房间正在关闭错误，客户端应重试
 var ErrRoomClosing = errors.New("room is closing, please retry")
+
+// ErrServerShuttingDown 服务正在优雅停机，客户端应稍后连接到新实例
+var ErrServerShuttingDown = errors.New("server is shutting down")
+
+// ErrServerOverloaded 服务资源紧张，暂不创建新房间，客户端应稍后重试
+var ErrServerOverloaded = errors.New("server is overloaded")
+
+// ErrRoomOwnedElsewhere 启用房间租约时，页面的房间由其他节点承载，请求应转发给该节点
+var ErrRoomOwnedElsewhere = errors.New("room is owned by another node")
+
+// ErrRoomLockUnavailable 启用房间租约时，锁服务不可用，无法确认由哪个节点承载房间，客户端应稍后重试
+var ErrRoomLockUnavailable = errors.New("room lock is unavailable")
+
+// ErrVersionNotFound 历史版本不存在错误
+var ErrVersionNotFound = errors.New("page version not found")
+
+// ErrInvalidLegacyData 旧版本地数据格式无法识别
+var ErrInvalidLegacyData = errors.New("invalid legacy page data")
+
+// ErrPageNotPublished 页面尚未发布错误
+var ErrPageNotPublished = errors.New("page has not been published")
+
+// ErrCommentNotFound 评论不存在错误
+var ErrCommentNotFound = errors.New("comment not found")
+
+// ErrInvalidComment 评论内容为空或过长
+var ErrInvalidComment = errors.New("invalid comment text")
+
+// ErrUserNotInRoom 目标用户当前不在协同房间内
+var ErrUserNotInRoom = errors.New("user is not in the room")
+
+// ErrInvalidColor 颜色不是 #RRGGBB 格式
+var ErrInvalidColor = errors.New("invalid color, expected #RRGGBB")
+
+// ErrInvalidBulkOps 批量操作参数非法或无法应用到当前页面
+var ErrInvalidBulkOps = errors.New("invalid bulk operations")
+
+// ErrInvalidCollabSettings 刷盘节奏覆盖值超出允许范围
+var ErrInvalidCollabSettings = errors.New("invalid collaboration settings")
+
+// ErrInvalidRole 协作角色不是 editor 或 viewer
+var ErrInvalidRole = errors.New("invalid collaborator role, expected editor or viewer")
+
+// ErrCollaboratorNotFound 用户不是该页面的协作者
+var ErrCollaboratorNotFound = errors.New("collaborator not found")
+
+// ErrShareLinkNotFound 分享链接不存在或已被撤销
+var ErrShareLinkNotFound = errors.New("share link not found")
+
+// ErrShareLinkInvalid 分享链接 Token 签名错误、已过期或已被撤销
+var ErrShareLinkInvalid = errors.New("share link is invalid, expired or revoked")
+
+// ErrInvalidShareLinkTTL 分享链接有效期超出允许范围
+var ErrInvalidShareLinkTTL = errors.New("invalid share link expiry")
+
+// ErrGuestTokenInvalid 临时访客 Token 签名错误、已过期或不属于该页面
+var ErrGuestTokenInvalid = errors.New("guest token is invalid or expired")
+
+// ErrInvalidGuestTokenRequest 临时访客 Token 的角色或有效期超出允许范围
+var ErrInvalidGuestTokenRequest = errors.New("invalid guest token role or expiry")
+
+// ErrConflictBackupNotFound 冲突备份不存在
+var ErrConflictBackupNotFound = errors.New("conflict backup not found")
+
+// ErrAPIKeyNotFound API Key 不存在或已被撤销
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// ErrAPIKeyInvalid API Key 不存在、已过期、已撤销，或创建者已离开密钥所属的组织
+var ErrAPIKeyInvalid = errors.New("api key is invalid, expired or revoked")
+
+// ErrInvalidAPIKey API Key 的名称、权限或有效期无效
+var ErrInvalidAPIKey = errors.New("invalid api key request")
+
+// ErrAPIKeyLimit 用户有效的 API Key 数量已达上限
+var ErrAPIKeyLimit = errors.New("api key limit reached")
+
+// ErrBranchNotFound 草稿分支不存在或不属于当前用户
+var ErrBranchNotFound = errors.New("branch not found")
+
+// ErrInvalidBranch 分支名称无效，或试图从分支再分叉
+var ErrInvalidBranch = errors.New("invalid branch request")
+
+// ErrBranchLimit 用户在页面上的分支数量已达上限
+var ErrBranchLimit = errors.New("branch limit reached")
+
+// ErrBranchConflict 分支与页面的最新状态修改了同一处，无法自动合并
+var ErrBranchConflict = errors.New("branch conflicts with page")
+
+// ErrMergeRequestNotFound 合并请求不存在
+var ErrMergeRequestNotFound = errors.New("merge request not found")
+
+// ErrInvalidMergeRequest 合并请求的标题、描述、评论、合并策略或 Patch 无效
+var ErrInvalidMergeRequest = errors.New("invalid merge request")
+
+// ErrMergeRequestExists 分支已有打开的合并请求
+var ErrMergeRequestExists = errors.New("branch already has an open merge request")
+
+// ErrMergeRequestNotOpen 合并请求已合并或已关闭
+var ErrMergeRequestNotOpen = errors.New("merge request is not open")
+
+// ErrInviteNotFound 邀请不存在
+var ErrInviteNotFound = errors.New("invite not found")
+
+// ErrInvalidInvite 邀请的邮箱无效
+var ErrInvalidInvite = errors.New("invalid invite email")
+
+// ErrInviteLimit 页面待接受的邀请数量已达上限
+var ErrInviteLimit = errors.New("pending invite limit reached")
+
+// ErrMailDelivery 邮件发送失败
+var ErrMailDelivery = errors.New("failed to deliver email")
+
+// ErrSecretsDisabled 未配置密钥属性的加密密钥
+var ErrSecretsDisabled = errors.New("secret props are not enabled")
+
+// ErrInvalidSecret 密钥属性的位置或值无效，或 Schema 中的密钥无法解密
+var ErrInvalidSecret = errors.New("invalid secret prop")
+
+// ErrInvalidPlan 不支持的存储套餐
+var ErrInvalidPlan = errors.New("invalid storage plan")
+
+// ErrStorageQuotaExceeded 租户的存储用量已达套餐上限
+var ErrStorageQuotaExceeded = errors.New("storage quota exceeded")
+
+// ErrQuotaExceeded 用户创建的页面数已达上限
+var ErrQuotaExceeded = errors.New("page quota exceeded")
+
+// ErrDanglingReferences 页面引用了不存在的页面，修复前不能发布
+var ErrDanglingReferences = errors.New("page has dangling references")
+
+// ErrReferencesDisabled 未启用页面引用索引
+var ErrReferencesDisabled = errors.New("page references are not enabled")
+
+// ErrOpHistoryDisabled 未启用操作日志，无法查询编辑记录
+var ErrOpHistoryDisabled = errors.New("op history is not enabled")
+
+// ErrRoomNotFound 房间不在本实例的内存中（无人编辑或位于其他实例）
+var ErrRoomNotFound = errors.New("room is not active on this instance")
+
+// ErrReproDisabled 未启用复现日志
+var ErrReproDisabled = errors.New("repro journal is not enabled")
+
+// ErrSessionClosed 不在页面的协作时段内，所有者以外的用户不能加入或编辑
+var ErrSessionClosed = errors.New("collaboration session is not active")
+
+// ErrPresentationActive 页面处于演示模式，只有演示者可以编辑
+var ErrPresentationActive = errors.New("page is in presentation mode")
+
+// ErrInvalidSessionWindow 协作时段无效（结束时间不晚于开始时间、已结束或超过最长时长）
+var ErrInvalidSessionWindow = errors.New("invalid session window")
+
+// ErrInvalidDeleteConfirmation 删除页面的确认 Token 无效或已过期，需要重新发起删除
+var ErrInvalidDeleteConfirmation = errors.New("delete confirmation is invalid or expired")
+
+// ErrAIDisabled 未配置大语言模型服务，不能使用 AI 生成
+var ErrAIDisabled = errors.New("ai generation is not enabled")
+
+// ErrInvalidAIPrompt 提示词为空或过长，或页面 Schema 超出模型上下文上限
+var ErrInvalidAIPrompt = errors.New("invalid ai prompt")
+
+// ErrAIProvider 调用大语言模型服务失败
+var ErrAIProvider = errors.New("ai provider request failed")
+
+// ErrAIInvalidPatch 模型返回的不是合法的组件 Patch，或应用后页面结构不一致
+var ErrAIInvalidPatch = errors.New("ai returned an invalid patch")
+
+// ErrAIProposalNotFound AI 修改建议不存在、已过期、已处理或不属于当前用户
+var ErrAIProposalNotFound = errors.New("ai proposal not found")
+
+// ErrAIProposalStale 生成建议后页面已被修改，建议无法按原版本应用，需要重新生成
+var ErrAIProposalStale = errors.New("ai proposal is stale")
+
+// ErrInvalidAIProvider 租户的模型服务配置无效（类型不支持、地址不允许、缺少模型名或 API Key）
+var ErrInvalidAIProvider = errors.New("invalid ai provider config")
+
+// ErrContentRejected 聊天或组件属性文本未通过内容审核，未应用
+var ErrContentRejected = errors.New("content rejected by moderation")
+
+// ErrModerationFlagNotFound 审核记录不存在
+var ErrModerationFlagNotFound = errors.New("moderation flag not found")
+
+// ErrUsageLimitExceeded 租户本周期内某类调用的次数已达套餐额度，需要升级套餐
+var ErrUsageLimitExceeded = errors.New("usage limit exceeded")
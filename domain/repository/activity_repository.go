@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+)
+
+// RecentPage 用户最近打开或编辑过的页面
+type RecentPage struct {
+	PageID       string     `json:"pageId"`
+	CreatorID    string     `json:"creatorId"`
+	Version      int64      `json:"version"`
+	LastOpenedAt *time.Time `json:"lastOpenedAt"`
+	LastEditedAt *time.Time `json:"lastEditedAt"`
+
+	// 仅在请求 include=schema 时填充，见 UserUseCase.RecentPagesWithSchema
+	Schema        json.RawMessage `json:"schema,omitempty" gorm:"-"`
+	SchemaOmitted bool            `json:"schemaOmitted,omitempty" gorm:"-"` // 超出响应体积上限，未附带 Schema
+}
+
+// ActivityRepository 用户页面活动仓库接口
+type ActivityRepository interface {
+	// Record 批量记录活动，每条只更新非空的时间字段，且只会让时间前进
+	Record(activities []*entity.PageActivity) error
+
+	// ListRecent 按最近活动时间倒序返回用户的页面，已删除的页面不返回
+	ListRecent(userID string, limit int) ([]RecentPage, error)
+}
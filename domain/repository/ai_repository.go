@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"time"
+
+	"lowercode-go-server/domain/entity"
+)
+
+// AIRepository 租户模型服务配置与 AI 用量仓库接口
+type AIRepository interface {
+	// GetProvider 读取租户自带的模型服务配置，未配置时返回 nil, nil
+	GetProvider(tenantID string) (*entity.AIProviderConfig, error)
+
+	// SaveProvider 创建或覆盖租户的模型服务配置
+	SaveProvider(config *entity.AIProviderConfig) error
+
+	// DeleteProvider 删除租户的模型服务配置，未配置时不报错
+	DeleteProvider(tenantID string) error
+
+	// RecordUsage 把一次或多次调用的用量累加到对应租户、日期、来源和模型的记录上
+	RecordUsage(usage *entity.AIUsage) error
+
+	// ListUsage 按日期升序返回租户 since 当天及之后的用量
+	ListUsage(tenantID string, since time.Time) ([]*entity.AIUsage, error)
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"time"
+
+	"lowercode-go-server/domain/entity"
+)
+
+// APIKeyRepository API Key 仓库接口
+type APIKeyRepository interface {
+	// Create 保存新的密钥
+	Create(key *entity.APIKey) error
+
+	// GetByHash 按密钥哈希读取，不存在时返回 nil, nil
+	GetByHash(hash string) (*entity.APIKey, error)
+
+	// ListByUser 按创建时间降序返回用户的密钥，包括已过期和已撤销的
+	ListByUser(userID string) ([]*entity.APIKey, error)
+
+	// CountActive 统计用户未撤销且未过期的密钥数
+	CountActive(userID string, now time.Time) (int64, error)
+
+	// Revoke 撤销用户的密钥，返回是否确有未撤销的密钥被撤销
+	Revoke(userID, id string, at time.Time) (bool, error)
+
+	// Touch 记录密钥最近一次使用时间
+	Touch(id string, at time.Time) error
+}
@@ -0,0 +1,18 @@
+package repository
+
+import "lowercode-go-server/domain/entity"
+
+// BranchRepository 页面草稿分支仓库接口
+type BranchRepository interface {
+	// Create 在同一事务中保存分支记录和分支内容所在的页面
+	Create(branch *entity.PageBranch, page *entity.Page) error
+
+	// Get 读取页面的一个分支（含 BaseSchema），不存在时返回 nil, nil
+	Get(pageID, id string) (*entity.PageBranch, error)
+
+	// ListByOwner 按创建时间降序返回用户在页面上的分支，不加载 BaseSchema
+	ListByOwner(pageID, ownerID string) ([]*entity.PageBranch, error)
+
+	// CountByOwner 统计用户在页面上的分支数
+	CountByOwner(pageID, ownerID string) (int64, error)
+}
@@ -0,0 +1,15 @@
+package repository
+
+import "lowercode-go-server/domain/entity"
+
+// ChatRepository 聊天记录仓库接口
+type ChatRepository interface {
+	// Save 保存一条聊天消息
+	Save(msg *entity.ChatMessage) error
+
+	// ListRecent 按时间升序返回页面最近的 limit 条聊天消息
+	ListRecent(pageID string, limit int) ([]*entity.ChatMessage, error)
+
+	// ChatPersisted 返回页面是否开启了聊天持久化，页面不存在时返回 false
+	ChatPersisted(pageID string) (bool, error)
+}
@@ -0,0 +1,18 @@
+package repository
+
+import "lowercode-go-server/domain/entity"
+
+// CollaboratorRepository 页面协作者仓库接口
+type CollaboratorRepository interface {
+	// Upsert 添加协作者，已存在时更新角色
+	Upsert(collaborator *entity.PageCollaborator) error
+
+	// Remove 移除协作者，返回是否确有记录被删除
+	Remove(pageID, userID string) (bool, error)
+
+	// GetRole 返回用户在页面上的协作角色，不是协作者时返回空字符串
+	GetRole(pageID, userID string) (string, error)
+
+	// ListByPage 按添加时间升序返回页面的协作者
+	ListByPage(pageID string) ([]*entity.PageCollaborator, error)
+}
@@ -0,0 +1,24 @@
+package repository
+
+import "lowercode-go-server/domain/entity"
+
+// CommentRepository 组件评论仓库接口
+type CommentRepository interface {
+	// Create 创建评论
+	Create(comment *entity.Comment) error
+
+	// GetByID 根据 ID 获取评论，不存在时返回 nil, nil
+	GetByID(id uint) (*entity.Comment, error)
+
+	// ListByPage 按创建时间升序返回页面的评论，componentID 非空时只返回该组件的评论
+	ListByPage(pageID, componentID string) ([]*entity.Comment, error)
+
+	// UpdateText 修改评论内容
+	UpdateText(id uint, text string) error
+
+	// SetResolved 设置线程的解决状态，userID 为操作者
+	SetResolved(id uint, resolved bool, userID string) error
+
+	// Delete 删除评论及其回复
+	Delete(id uint) error
+}
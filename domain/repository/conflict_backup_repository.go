@@ -0,0 +1,18 @@
+package repository
+
+import "lowercode-go-server/domain/entity"
+
+// ConflictBackupRepository 冲突备份仓库接口
+type ConflictBackupRepository interface {
+	// Save 保存一条冲突备份，页面的备份超过 entity.MaxConflictBackupsPerPage 条时删除最早的
+	Save(backup *entity.ConflictBackup) error
+
+	// Get 读取页面的一条冲突备份（含 Schema），不存在时返回 nil, nil
+	Get(pageID string, id uint) (*entity.ConflictBackup, error)
+
+	// ListByPage 按创建时间降序返回页面的冲突备份，不含 Schema
+	ListByPage(pageID string) ([]*entity.ConflictBackup, error)
+
+	// Delete 删除页面的一条冲突备份，返回是否确有记录被删除
+	Delete(pageID string, id uint) (bool, error)
+}
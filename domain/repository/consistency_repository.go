@@ -0,0 +1,23 @@
+package repository
+
+// SnapshotAheadRow 页面版本落后于其最新快照的记录
+type SnapshotAheadRow struct {
+	PageID          string `json:"pageId"`
+	PageVersion     int64  `json:"pageVersion"`
+	SnapshotVersion int64  `json:"snapshotVersion"`
+}
+
+// ConsistencyRepository 数据一致性巡检查询接口
+type ConsistencyRepository interface {
+	// FindPagesBehindSnapshots 返回 pages.version 小于其最新 page_versions 版本的页面
+	FindPagesBehindSnapshots() ([]SnapshotAheadRow, error)
+
+	// FindOrphanedSnapshots 返回存在历史快照但页面已不存在的 pageId
+	FindOrphanedSnapshots() ([]string, error)
+
+	// FindOrphanedOps 返回存在操作日志但页面已不存在的 pageId
+	FindOrphanedOps() ([]string, error)
+
+	// GetPageVersions 批量读取页面在数据库中的版本号，不存在的页面不出现在结果中
+	GetPageVersions(pageIDs []string) (map[string]int64, error)
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"time"
+
+	"lowercode-go-server/domain/entity"
+)
+
+// InviteRepository 页面协作邀请仓库接口
+type InviteRepository interface {
+	// Upsert 创建邀请，同一页面同一邮箱已有邀请时以本次邀请覆盖角色、邀请人、有效期和接受状态
+	Upsert(invite *entity.PageInvite) error
+
+	// ListByPage 按创建时间降序返回页面的邀请
+	ListByPage(pageID string) ([]*entity.PageInvite, error)
+
+	// CountPending 统计页面在 now 时仍待接受的邀请数
+	CountPending(pageID string, now time.Time) (int64, error)
+
+	// ListPendingByEmail 返回发给该邮箱、在 now 时仍待接受的邀请
+	ListPendingByEmail(email string, now time.Time) ([]*entity.PageInvite, error)
+
+	// MarkAccepted 记录邀请已被 userID 接受
+	MarkAccepted(id uint, userID string, at time.Time) error
+
+	// Delete 删除页面的一条邀请，返回是否确有记录被删除
+	Delete(pageID string, id uint) (bool, error)
+}
@@ -0,0 +1,27 @@
+package repository
+
+import "lowercode-go-server/domain/entity"
+
+// MergeRequestRepository 分支合并请求仓库接口
+type MergeRequestRepository interface {
+	// Create 保存新的合并请求
+	Create(mr *entity.MergeRequest) error
+
+	// Get 读取页面的一个合并请求，不存在时返回 nil, nil
+	Get(pageID string, id uint) (*entity.MergeRequest, error)
+
+	// GetOpenByBranch 读取分支打开的合并请求，没有时返回 nil, nil
+	GetOpenByBranch(branchID string) (*entity.MergeRequest, error)
+
+	// ListByPage 按创建时间降序返回页面的合并请求，status 为空时返回全部
+	ListByPage(pageID, status string) ([]*entity.MergeRequest, error)
+
+	// Finish 将打开的合并请求更新为已合并或已关闭（状态及合并信息），已不是打开状态时返回 false
+	Finish(mr *entity.MergeRequest) (bool, error)
+
+	// CreateComment 保存合并请求的讨论
+	CreateComment(comment *entity.MergeRequestComment) error
+
+	// ListComments 按时间升序返回合并请求的讨论
+	ListComments(mergeRequestID uint) ([]*entity.MergeRequestComment, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import "lowercode-go-server/domain/entity"
+
+// ModerationFlagFilter 查询审核记录的条件
+type ModerationFlagFilter struct {
+	PageID   string // 为空时不限页面
+	Pending  bool   // 只返回待复核的记录
+	BeforeID uint   // 大于 0 时只返回 ID 小于它的记录，用于翻页
+	Limit    int
+}
+
+// ModerationRepository 内容审核记录仓库接口
+type ModerationRepository interface {
+	// CreateFlags 批量保存审核记录
+	CreateFlags(flags []*entity.ModerationFlag) error
+
+	// ListFlags 按 ID 倒序（最新在前）返回符合条件的审核记录
+	ListFlags(filter ModerationFlagFilter) ([]*entity.ModerationFlag, error)
+
+	// MarkReviewed 把审核记录标记为已复核，记录不存在时返回 ErrModerationFlagNotFound
+	MarkReviewed(id uint) (*entity.ModerationFlag, error)
+}
@@ -0,0 +1,16 @@
+package repository
+
+// OrgMemberRepository Clerk 组织成员关系仓库接口
+type OrgMemberRepository interface {
+	// Upsert 写入或更新用户在组织中的角色
+	Upsert(orgID, userID, role string) error
+
+	// GetRole 返回用户在组织中的角色，不是成员时返回空字符串
+	GetRole(orgID, userID string) (string, error)
+
+	// Remove 移除用户的组织成员关系
+	Remove(orgID, userID string) error
+
+	// RemoveOrg 移除组织的全部成员关系，组织页面之后只有创建者和协作者可以访问
+	RemoveOrg(orgID string) error
+}
@@ -0,0 +1,15 @@
+package repository
+
+import "lowercode-go-server/domain/entity"
+
+// OrganizationRepository Clerk 组织仓库接口
+type OrganizationRepository interface {
+	// Upsert 创建或更新组织资料，created_by 为空时不覆盖已有的值
+	Upsert(org *entity.Organization) error
+
+	// GetByID 根据 Clerk org_id 获取组织，不存在时返回 nil, nil
+	GetByID(orgID string) (*entity.Organization, error)
+
+	// Delete 删除组织资料，成员关系由 OrgMemberRepository.RemoveOrg 清除
+	Delete(orgID string) error
+}
@@ -0,0 +1,9 @@
+package repository
+
+import "lowercode-go-server/domain/entity"
+
+// OutboxRepository 事件 Outbox 仓库接口
+type OutboxRepository interface {
+	// Append 追加一条事件
+	Append(event *entity.OutboxEvent) error
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"time"
+
+	"lowercode-go-server/domain/entity"
+)
+
+// JournalSize 单个页面操作日志的规模
+type JournalSize struct {
+	PageID     string
+	Count      int64
+	MaxVersion int64
+}
+
+// PageOpRepository 页面操作日志仓库接口
+type PageOpRepository interface {
+	// CreateBatch 批量写入操作日志，已存在的 (pageID, version) 会被覆盖
+	CreateBatch(ops []*entity.PageOp) error
+
+	// ListSince 按版本升序返回 sinceVersion 之后的操作，最多 limit 条
+	ListSince(pageID string, sinceVersion int64, limit int) ([]*entity.PageOp, error)
+
+	// DeleteBefore 删除早于 cutoff 的操作日志，返回删除条数
+	DeleteBefore(cutoff time.Time) (int64, error)
+
+	// ListOversized 返回操作日志超过 maxOps 条的页面
+	ListOversized(maxOps int) ([]JournalSize, error)
+
+	// DeleteAfter 删除页面版本大于 version 的操作日志（从未刷盘的版本），返回删除条数
+	DeleteAfter(pageID string, version int64) (int64, error)
+
+	// DeleteThrough 删除页面版本不超过 version 的操作日志，返回删除条数
+	DeleteThrough(pageID string, version int64) (int64, error)
+
+	// CountLabels 按标签统计版本在 (from, to] 之间的操作数，未标注的操作不计入
+	CountLabels(pageID string, from, to int64) (map[string]int64, error)
+}
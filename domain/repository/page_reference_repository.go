@@ -0,0 +1,20 @@
+package repository
+
+import "lowercode-go-server/domain/entity"
+
+// PageReferenceRepository 页面引用索引仓库接口
+type PageReferenceRepository interface {
+	// ReplaceForPage 用 refs 替换 sourcePageID 的全部出站引用
+	ReplaceForPage(sourcePageID string, refs []*entity.PageReference) error
+
+	// ListInbound 返回引用 targetPageID 的全部记录，按来源页面和路径排序；
+	// 不含草稿分支中的引用，分支合并后才算作页面的引用
+	ListInbound(targetPageID string) ([]*entity.PageReference, error)
+
+	// UnindexedPages 返回 Schema 中可能含有引用、但还没有任何索引记录的页面（不含草稿分支），
+	// 用于回填引用索引上线前已有的页面
+	UnindexedPages() ([]string, error)
+
+	// MissingPages 返回 pageIDs 中不存在的页面 ID
+	MissingPages(pageIDs []string) ([]string, error)
+}
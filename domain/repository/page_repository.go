@@ -7,6 +7,19 @@ type PageRepository interface {
 	// GetByPageID 根据业务 ID 获取页面
 	GetByPageID(pageID string) (*entity.Page, error)
 
+	// GetAccessInfo 只读取页面的创建者、所属组织和分享设置，用于权限检查，不加载 Schema
+	// 页面不存在时返回 nil, nil
+	GetAccessInfo(pageID string) (*entity.Page, error)
+
+	// ListByCreator 按最近更新时间倒序返回用户的个人页面（不属于任何组织，不含草稿分支），不加载 Schema
+	ListByCreator(creatorID string, limit int) ([]*entity.Page, error)
+
+	// CountByCreator 返回用户创建的页面数（包括组织页面，不含草稿分支）
+	CountByCreator(creatorID string) (int64, error)
+
+	// ListByOrg 按最近更新时间倒序返回组织的页面，不加载 Schema
+	ListByOrg(orgID string, limit int) ([]*entity.Page, error)
+
 	// Create 创建新页面
 	// 注意：禁止使用 GORM Save，它会覆盖 schema 和 version
 	Create(page *entity.Page) error
@@ -17,7 +30,32 @@ type PageRepository interface {
 	// 如果版本不匹配，返回 ErrOptimisticLock
 	UpdateSchema(pageID string, schema []byte, oldVersion, newVersion int64) error
 
-	// Delete 删除页面
-	// 注意：删除前必须先通过 Hub.CloseRoom 关闭内存中的协同房间
+	// Publish 将指定版本的 Schema 写入发布副本，不影响草稿
+	// 页面不存在时返回 ErrPageNotFound
+	Publish(pageID string, schema []byte, version int64) error
+
+	// SetLinkEdit 开启或关闭"持有链接即可编辑"
+	// 页面不存在时返回 ErrPageNotFound
+	SetLinkEdit(pageID string, enabled bool) error
+
+	// SetChatPersisted 开启或关闭聊天记录持久化
+	// 页面不存在时返回 ErrPageNotFound
+	SetChatPersisted(pageID string, enabled bool) error
+
+	// SetCollabSettings 保存页面级协同设置
+	// 页面不存在时返回 ErrPageNotFound
+	SetCollabSettings(pageID string, settings entity.CollabSettings) error
+
+	// BranchPageIDs 返回页面全部草稿分支的页面 ID
+	BranchPageIDs(pageID string) ([]string, error)
+
+	// Delete 删除页面，连同其草稿分支；删除分支页面时同时删除分支记录
+	// 注意：删除前必须先通过 Hub.CloseRoom 关闭内存中的协同房间（包括分支的房间）
 	Delete(pageID string) error
 }
+
+// PageCacheInvalidator 由带读取缓存的 PageRepository 实现。
+// 绕过 PageRepository 直接修改页面的操作（如账号删除时转移所有者）完成后调用，使页面的缓存失效
+type PageCacheInvalidator interface {
+	InvalidatePages(pageIDs ...string)
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"time"
+
+	"lowercode-go-server/domain/entity"
+)
+
+// PageVersionRepository 页面历史版本仓库接口
+// 版本快照由 PageRepository 在创建页面和全量刷盘时同事务写入，
+// 这里负责读取、清理，以及截断操作日志前补写检查点快照
+type PageVersionRepository interface {
+	// GetByVersion 获取指定版本的快照，不存在时返回 (nil, nil)
+	// 没有该版本的全量快照时，从更早的快照回放差量得到
+	GetByVersion(pageID string, version int64) (*entity.PageVersion, error)
+
+	// ListPageIDsBefore 返回存在早于 cutoff 的快照的页面
+	ListPageIDsBefore(cutoff time.Time) ([]string, error)
+
+	// ListMetaBefore 按版本升序返回页面早于 cutoff 的快照（不含 Schema）
+	ListMetaBefore(pageID string, cutoff time.Time) ([]*entity.PageVersion, error)
+
+	// DeleteByIDs 批量删除快照
+	DeleteByIDs(ids []uint) error
+
+	// DeleteDeltasBefore 删除早于 cutoff 且已被全量快照覆盖的差量，返回删除条数
+	DeleteDeltasBefore(cutoff time.Time) (int64, error)
+
+	// SaveCheckpoint 保存检查点快照，该版本已有快照时忽略
+	SaveCheckpoint(v *entity.PageVersion) error
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"time"
+
+	"lowercode-go-server/domain/entity"
+)
+
+// RevokedSessionRepository 登录会话吊销记录仓库接口
+type RevokedSessionRepository interface {
+	// Create 保存吊销记录，写入后 ID 被填充
+	Create(revoked *entity.RevokedSession) error
+
+	// ListAfter 按 ID 升序返回 ID 大于 afterID 且在 now 时尚未到期的记录
+	ListAfter(afterID uint, now time.Time) ([]*entity.RevokedSession, error)
+
+	// DeleteExpired 删除 now 时已到期的记录，返回删除的条数
+	DeleteExpired(now time.Time) (int64, error)
+}
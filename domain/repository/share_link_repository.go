@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"time"
+
+	"lowercode-go-server/domain/entity"
+)
+
+// ShareLinkRepository 页面分享链接仓库接口
+type ShareLinkRepository interface {
+	// Create 保存新的分享链接
+	Create(link *entity.ShareLink) error
+
+	// Get 按 ID 读取分享链接，不存在时返回 nil, nil
+	Get(id string) (*entity.ShareLink, error)
+
+	// ListByPage 按创建时间降序返回页面的分享链接，包括已过期和已撤销的
+	ListByPage(pageID string) ([]*entity.ShareLink, error)
+
+	// Revoke 撤销页面的分享链接，返回是否确有未撤销的链接被撤销
+	Revoke(pageID, id string, at time.Time) (bool, error)
+}
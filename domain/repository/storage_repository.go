@@ -0,0 +1,23 @@
+package repository
+
+import "lowercode-go-server/domain/entity"
+
+// StorageRepository 租户存储用量仓库接口
+// 页面的用量由各仓库的写入路径在同一事务中维护，这里负责汇总、套餐和校正
+type StorageRepository interface {
+	// TenantUsage 汇总租户全部页面的用量，没有页面时返回零用量
+	TenantUsage(tenantID string) (*entity.StorageUsage, error)
+
+	// ListTopTenants 按总用量降序返回用量最大的 limit 个租户
+	ListTopTenants(limit int) ([]*entity.StorageUsage, error)
+
+	// GetPlan 读取租户的套餐，未设置时返回空字符串
+	GetPlan(tenantID string) (string, error)
+
+	// SetPlan 设置租户的套餐
+	SetPlan(tenantID, plan string) error
+
+	// Recount 按源数据表重新统计页面用量，missingOnly 为 true 时只补齐没有用量记录的页面，
+	// 返回统计的页面数
+	Recount(missingOnly bool) (int64, error)
+}
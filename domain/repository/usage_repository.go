@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"time"
+
+	"lowercode-go-server/domain/entity"
+)
+
+// UsageRepository 按套餐计量的调用次数仓库接口
+type UsageRepository interface {
+	// Consume 计数未达 limit 时加一并返回加一后的计数和 true；已达 limit 时不修改，返回当前计数和 false。
+	// 检查和累加在一条语句中完成，多实例并发调用时不会超出额度；limit <= 0 表示不限
+	Consume(tenantID, kind string, period time.Time, limit int64) (int64, bool, error)
+
+	// ListUsage 返回租户在 period 周期内各类调用的计数，没有调用的类型不返回
+	ListUsage(tenantID string, period time.Time) ([]*entity.UsageCounter, error)
+}
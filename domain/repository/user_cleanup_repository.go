@@ -0,0 +1,36 @@
+package repository
+
+import "lowercode-go-server/domain/entity"
+
+// PageTransfer 把页面转给新的所有者
+type PageTransfer struct {
+	PageID     string `json:"pageId"`
+	NewOwnerID string `json:"newOwnerId"`
+}
+
+// UserDeletionPlan 删除用户时对其数据的处理计划
+type UserDeletionPlan struct {
+	UserID    string         `json:"userId"`
+	Transfers []PageTransfer `json:"transfers"`
+
+	// DeletedPages 没有接手人而删除的页面和用户自己的草稿分支；
+	// 没有接手人的页面连同其上其他用户的草稿分支一起软删除（不单独列出），可以恢复；
+	// 用户在其他页面上的草稿分支直接删除
+	DeletedPages []string `json:"deletedPages"`
+
+	DryRun bool `json:"dryRun"` // 只生成计划，未做任何修改
+}
+
+// UserCleanupRepository 删除用户时的级联清理
+type UserCleanupRepository interface {
+	// OwnedPages 返回用户创建的全部页面（含草稿分支），不加载 Schema
+	OwnedPages(userID string) ([]*entity.Page, error)
+
+	// Successor 返回页面除 userID 之外的接手人：组织页面优先取最早加入的组织管理员，
+	// 其次取最早添加的编辑者协作者；只考虑已同步到 users 表的用户，没有时返回空字符串
+	Successor(page *entity.Page, userID string) (string, error)
+
+	// DeleteUser 在一个事务中执行计划：转移页面，软删除没有接手人的页面，删除用户的协作者记录、组织成员关系、
+	// API Key、页面活动和用户记录。用户在生成计划之后又创建了页面时回滚并返回错误
+	DeleteUser(plan *UserDeletionPlan) error
+}
@@ -8,4 +8,10 @@ type UserRepository interface {
 
 	// 根据 Clerk user_id 获取用户
     GetByID(userID string) (*entity.User, error)
+
+	// 根据邮箱获取用户（不区分大小写），不存在时返回 nil, nil
+    GetByEmail(email string) (*entity.User, error)
+
+	// 更新用户的协作光标颜色
+    UpdateCursorColor(userID, color string) error
 }
\ No newline at end of file
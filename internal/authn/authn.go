@@ -0,0 +1,102 @@
+// Package authn 验证登录用户的 JWT。
+// Clerk 与通用 OIDC 身份提供方共用 jwkscache 缓存验签公钥，上游不可用时返回 jwkscache.ErrUnavailable，
+// 调用方应返回 503 而非 401
+package authn
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"lowercode-go-server/internal/jwkscache"
+
+	"github.com/clerk/clerk-sdk-go/v2/jwt"
+)
+
+// 身份提供方
+const (
+	ProviderClerk = "clerk"
+	ProviderOIDC  = "oidc"
+)
+
+// ErrInvalidToken Token 格式、签名或声明无效
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims 验签通过的 Token 中与授权相关的声明
+type Claims struct {
+	Subject string // 用户 ID
+	OrgID   string // 激活的组织，未激活时为空
+	OrgRole string // 用户在激活组织中的角色，如 org:admin
+
+	SessionID string    // 登录会话 ID（sid 声明），身份提供方未签发时为空
+	IssuedAt  time.Time // 签发时间（iat 声明），未签发时为零值
+
+	// 用户资料（name / picture 声明），身份提供方未签发时为空，用户表中没有资料时作为协同房间中的显示名和头像
+	Name    string
+	Picture string
+}
+
+// profileClaims Token 中的用户资料声明，Clerk 需要在 Session Token 模板中自定义
+// （如 {"name": "{{user.full_name}}", "picture": "{{user.image_url}}"}）
+type profileClaims struct {
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	Picture           string `json:"picture"`
+}
+
+// displayName 优先使用 name，没有时使用 preferred_username
+func (p *profileClaims) displayName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.PreferredUsername
+}
+
+// Clerk 验证 Clerk 签发的会话 Token
+type Clerk struct {
+	keys *jwkscache.Cache
+}
+
+// NewClerk 创建 Clerk 验证器，公钥来自 keys
+func NewClerk(keys *jwkscache.Cache) *Clerk {
+	return &Clerk{keys: keys}
+}
+
+// Verify 使用缓存的公钥验证 Clerk JWT 的签名和过期时间
+func (v *Clerk) Verify(ctx context.Context, token string) (*Claims, error) {
+	unverified, err := jwt.Decode(ctx, &jwt.DecodeParams{Token: token})
+	if err != nil {
+		return nil, err
+	}
+
+	jwk, err := v.keys.Key(ctx, unverified.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := jwt.Verify(ctx, &jwt.VerifyParams{
+		Token: token,
+		JWK:   jwk,
+		CustomClaimsConstructor: func(context.Context) any {
+			return &profileClaims{}
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 用户在前端切换到某个组织时，Token 携带该组织的 ID 和角色
+	result := &Claims{
+		Subject:   claims.Subject,
+		OrgID:     claims.ActiveOrganizationID,
+		OrgRole:   claims.ActiveOrganizationRole,
+		SessionID: claims.SessionID,
+	}
+	if claims.IssuedAt != nil {
+		result.IssuedAt = time.Unix(*claims.IssuedAt, 0)
+	}
+	if profile, ok := claims.Custom.(*profileClaims); ok {
+		result.Name, result.Picture = profile.displayName(), profile.Picture
+	}
+	return result, nil
+}
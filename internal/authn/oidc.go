@@ -0,0 +1,137 @@
+package authn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"lowercode-go-server/internal/jwkscache"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/go-jose/go-jose/v3/jwt"
+)
+
+// clockLeeway 校验 exp、nbf、iat 时容忍的时钟偏差
+const clockLeeway = time.Minute
+
+// OIDCConfig 通用 OIDC 身份提供方配置
+type OIDCConfig struct {
+	Issuer   string // 与 Token 的 iss 完全一致
+	Audience string // 非空时 Token 的 aud 必须包含该值
+	JWKSURL  string // 为空时从 {Issuer}/.well-known/openid-configuration 的 jwks_uri 获取
+
+	// 组织声明的名称，为空时不读取组织；值为字符串的顶层声明
+	OrgClaim     string
+	OrgRoleClaim string
+}
+
+// OIDC 验证通用 OIDC 身份提供方（Auth0、Keycloak、Okta 等）签发的 JWT
+type OIDC struct {
+	cfg  OIDCConfig
+	keys *jwkscache.Cache
+	now  func() time.Time
+}
+
+// NewOIDC 创建 OIDC 验证器，公钥来自 keys（通常由 FetchJWKS 拉取）
+func NewOIDC(cfg OIDCConfig, keys *jwkscache.Cache) *OIDC {
+	return &OIDC{cfg: cfg, keys: keys, now: time.Now}
+}
+
+// Verify 校验签名、iss、aud 和有效期，要求 Token 带有 sub 和 exp
+func (v *OIDC) Verify(ctx context.Context, token string) (*Claims, error) {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if len(parsed.Headers) == 0 {
+		return nil, fmt.Errorf("%w: missing JWT headers", ErrInvalidToken)
+	}
+	header := parsed.Headers[0]
+
+	jwk, err := v.keys.Key(ctx, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if jwk.Algorithm != "" && jwk.Algorithm != header.Algorithm {
+		return nil, fmt.Errorf("%w: unexpected signing algorithm %s", ErrInvalidToken, header.Algorithm)
+	}
+
+	var standard jwt.Claims
+	var profile profileClaims
+	custom := map[string]interface{}{}
+	if err := parsed.Claims(jwk.Key, &standard, &profile, &custom); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	expected := jwt.Expected{Issuer: v.cfg.Issuer, Time: v.now()}
+	if v.cfg.Audience != "" {
+		expected.Audience = jwt.Audience{v.cfg.Audience}
+	}
+	if err := standard.ValidateWithLeeway(expected, clockLeeway); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if standard.Subject == "" || standard.Expiry == nil {
+		return nil, fmt.Errorf("%w: missing sub or exp", ErrInvalidToken)
+	}
+
+	claims := &Claims{Subject: standard.Subject, Name: profile.displayName(), Picture: profile.Picture}
+	// 支持 OIDC 登出的身份提供方会签发 sid
+	claims.SessionID, _ = custom["sid"].(string)
+	if standard.IssuedAt != nil {
+		claims.IssuedAt = standard.IssuedAt.Time()
+	}
+	if v.cfg.OrgClaim != "" {
+		claims.OrgID, _ = custom[v.cfg.OrgClaim].(string)
+	}
+	if claims.OrgID != "" && v.cfg.OrgRoleClaim != "" {
+		claims.OrgRole, _ = custom[v.cfg.OrgRoleClaim].(string)
+	}
+	return claims, nil
+}
+
+// FetchJWKS 返回拉取 OIDC 公钥的 jwkscache.FetchFunc。
+// jwksURL 为空时先通过 issuer 的发现文档获取 jwks_uri，成功后复用；
+// jwkscache.Cache 串行调用 FetchFunc，无需额外加锁
+func FetchJWKS(client *http.Client, issuer, jwksURL string) jwkscache.FetchFunc {
+	return func(ctx context.Context) (*clerk.JSONWebKeySet, error) {
+		if jwksURL == "" {
+			var discovery struct {
+				JWKSURI string `json:"jwks_uri"`
+			}
+			if err := getJSON(ctx, client, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+				return nil, fmt.Errorf("oidc discovery: %w", err)
+			}
+			if discovery.JWKSURI == "" {
+				return nil, fmt.Errorf("oidc discovery: jwks_uri is empty")
+			}
+			jwksURL = discovery.JWKSURI
+		}
+
+		var set clerk.JSONWebKeySet
+		if err := getJSON(ctx, client, jwksURL, &set); err != nil {
+			return nil, fmt.Errorf("oidc jwks: %w", err)
+		}
+		return &set, nil
+	}
+}
+
+// getJSON 请求 url 并解析 JSON 响应
+func getJSON(ctx context.Context, client *http.Client, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: HTTP %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,167 @@
+package authn
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"lowercode-go-server/internal/jwkscache"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== OIDC 验证器单元测试 ==========
+
+// testIssuer 模拟的 OIDC 身份提供方，提供发现文档和 JWKS
+type testIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	iss := &testIssuer{key: key, kid: "key-1"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"jwks_uri": iss.server.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: iss.kid, Algorithm: string(jose.RS256), Use: "sig"},
+		}})
+	})
+	iss.server = httptest.NewServer(mux)
+	t.Cleanup(iss.server.Close)
+	return iss
+}
+
+// sign 用身份提供方的私钥签发 Token
+func (iss *testIssuer) sign(t *testing.T, kid string, claims map[string]interface{}) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: iss.key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", kid))
+	require.NoError(t, err)
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+	return token
+}
+
+func (iss *testIssuer) verifier(cfg OIDCConfig) *OIDC {
+	cfg.Issuer = iss.server.URL
+	keys := jwkscache.New(FetchJWKS(iss.server.Client(), iss.server.URL, ""), time.Hour, time.Hour)
+	return NewOIDC(cfg, keys)
+}
+
+func TestOIDC_Verify(t *testing.T) {
+	// 测试场景：通过发现文档获取公钥，校验签名、iss、aud 和有效期，按配置读取组织声明，读取 sid、iat 和用户资料
+
+	iss := newTestIssuer(t)
+	v := iss.verifier(OIDCConfig{Audience: "lowcode", OrgClaim: "org_id", OrgRoleClaim: "org_role"})
+	now := time.Now()
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":      iss.server.URL,
+			"sub":      "user-1",
+			"aud":      "lowcode",
+			"exp":      now.Add(time.Hour).Unix(),
+			"iat":      now.Unix(),
+			"sid":      "sess-1",
+			"org_id":   "org-1",
+			"org_role": "org:admin",
+			"name":     "张三",
+			"picture":  "https://img.example.com/u1.png",
+		}
+	}
+
+	claims, err := v.Verify(context.Background(), iss.sign(t, iss.kid, valid()))
+	require.NoError(t, err)
+	assert.Equal(t, &Claims{
+		Subject:   "user-1",
+		OrgID:     "org-1",
+		OrgRole:   "org:admin",
+		SessionID: "sess-1",
+		IssuedAt:  time.Unix(now.Unix(), 0),
+		Name:      "张三",
+		Picture:   "https://img.example.com/u1.png",
+	}, claims)
+
+	cases := map[string]func(c map[string]interface{}){
+		"签发方不一致": func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" },
+		"受众不一致":  func(c map[string]interface{}) { c["aud"] = "other-app" },
+		"已过期":    func(c map[string]interface{}) { c["exp"] = now.Add(-time.Hour).Unix() },
+		"缺少 exp": func(c map[string]interface{}) { delete(c, "exp") },
+		"缺少 sub": func(c map[string]interface{}) { delete(c, "sub") },
+	}
+	for name, mutate := range cases {
+		c := valid()
+		mutate(c)
+		_, err := v.Verify(context.Background(), iss.sign(t, iss.kid, c))
+		assert.ErrorIs(t, err, ErrInvalidToken, name)
+	}
+
+	_, err = v.Verify(context.Background(), "not-a-jwt")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = v.Verify(context.Background(), iss.sign(t, "unknown-kid", valid()))
+	assert.ErrorIs(t, err, jwkscache.ErrKeyNotFound)
+}
+
+func TestOIDC_Verify_OtherKey(t *testing.T) {
+	// 测试场景：kid 相同但由其他私钥签发的 Token 验签失败
+
+	iss := newTestIssuer(t)
+	v := iss.verifier(OIDCConfig{})
+
+	forger := newTestIssuer(t)
+	token := forger.sign(t, iss.kid, map[string]interface{}{
+		"iss": iss.server.URL,
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	_, err := v.Verify(context.Background(), token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestOIDC_Verify_NoOrgClaim(t *testing.T) {
+	// 测试场景：未配置组织声明时忽略 Token 中的组织；未配置 aud 时不校验受众；没有 name 时使用 preferred_username
+
+	iss := newTestIssuer(t)
+	v := iss.verifier(OIDCConfig{})
+
+	claims, err := v.Verify(context.Background(), iss.sign(t, iss.kid, map[string]interface{}{
+		"iss":    iss.server.URL,
+		"sub":    "user-1",
+		"aud":    "anything",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"org_id": "org-1",
+
+		"preferred_username": "zhangsan",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, &Claims{Subject: "user-1", Name: "zhangsan"}, claims)
+}
+
+func TestFetchJWKS_Unavailable(t *testing.T) {
+	// 测试场景：发现文档不可用时返回错误，缓存据此返回 ErrUnavailable
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	keys := jwkscache.New(FetchJWKS(server.Client(), server.URL, ""), time.Hour, time.Hour)
+	assert.Error(t, keys.Refresh(context.Background()))
+
+	_, err := keys.Key(context.Background(), "key-1")
+	assert.ErrorIs(t, err, jwkscache.ErrUnavailable)
+}
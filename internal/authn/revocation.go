@@ -0,0 +1,117 @@
+package authn
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrSessionRevoked Token 所属的登录会话已被吊销，或用户在 Token 签发后被整体吊销
+var ErrSessionRevoked = fmt.Errorf("%w: session revoked", ErrInvalidToken)
+
+// Revocations 内存中已吊销的登录会话和用户。
+// 每条记录带有到期时间，到期后该会话签发的 Token 均已过期，记录可以丢弃
+type Revocations struct {
+	mu       sync.RWMutex
+	sessions map[string]time.Time // 会话 ID -> 记录到期时间
+	users    map[string]userRevocation
+}
+
+// userRevocation 吊销用户在 revokedAt 及之前签发的全部 Token
+type userRevocation struct {
+	revokedAt time.Time
+	expiresAt time.Time
+}
+
+// NewRevocations 创建空的吊销列表
+func NewRevocations() *Revocations {
+	return &Revocations{
+		sessions: make(map[string]time.Time),
+		users:    make(map[string]userRevocation),
+	}
+}
+
+// RevokeSession 吊销登录会话，expiresAt 前该会话的 Token 均被拒绝
+func (l *Revocations) RevokeSession(sessionID string, expiresAt time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if expiresAt.After(l.sessions[sessionID]) {
+		l.sessions[sessionID] = expiresAt
+	}
+}
+
+// RevokeUser 吊销用户在 revokedAt 及之前签发的全部 Token，之后重新登录签发的 Token 不受影响
+func (l *Revocations) RevokeUser(userID string, revokedAt, expiresAt time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if prev, ok := l.users[userID]; ok && !revokedAt.After(prev.revokedAt) {
+		return
+	}
+	l.users[userID] = userRevocation{revokedAt: revokedAt, expiresAt: expiresAt}
+}
+
+// Revoked 判断 claims 在 now 时是否已被吊销。没有 iat 的 Token 无法区分签发先后，用户被吊销时一并拒绝
+func (l *Revocations) Revoked(claims *Claims, now time.Time) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if claims.SessionID != "" {
+		if expiresAt, ok := l.sessions[claims.SessionID]; ok && now.Before(expiresAt) {
+			return true
+		}
+	}
+	if user, ok := l.users[claims.Subject]; ok && now.Before(user.expiresAt) {
+		return !claims.IssuedAt.After(user.revokedAt)
+	}
+	return false
+}
+
+// Prune 丢弃 now 时已到期的记录，返回剩余记录数
+func (l *Revocations) Prune(now time.Time) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for id, expiresAt := range l.sessions {
+		if !now.Before(expiresAt) {
+			delete(l.sessions, id)
+		}
+	}
+	for id, user := range l.users {
+		if !now.Before(user.expiresAt) {
+			delete(l.users, id)
+		}
+	}
+	return len(l.sessions) + len(l.users)
+}
+
+// verifier 与 middleware.AuthVerifier 相同，避免反向依赖
+type verifier interface {
+	Verify(ctx context.Context, token string) (*Claims, error)
+}
+
+// RevocationChecker 在 Token 验签通过后拒绝已吊销的会话，包装 Clerk 或 OIDC 验证器
+type RevocationChecker struct {
+	inner verifier
+	list  *Revocations
+	now   func() time.Time
+}
+
+// WithRevocations 包装 inner，验证通过的 Token 属于 list 中已吊销的会话或用户时返回 ErrSessionRevoked
+func WithRevocations(inner verifier, list *Revocations) *RevocationChecker {
+	return &RevocationChecker{inner: inner, list: list, now: time.Now}
+}
+
+// Verify 先由 inner 验证 Token，再检查吊销列表
+func (v *RevocationChecker) Verify(ctx context.Context, token string) (*Claims, error) {
+	claims, err := v.inner.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if v.list.Revoked(claims, v.now()) {
+		return nil, ErrSessionRevoked
+	}
+	return claims, nil
+}
@@ -0,0 +1,67 @@
+package authn
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 会话吊销单元测试 ==========
+
+// stubVerifier 返回固定结果的验证器
+type stubVerifier struct {
+	claims *Claims
+	err    error
+}
+
+func (s stubVerifier) Verify(context.Context, string) (*Claims, error) {
+	return s.claims, s.err
+}
+
+func TestRevocations(t *testing.T) {
+	// 测试场景：吊销会话只影响该会话；吊销用户只影响此前签发的 Token；记录到期后失效并被清理
+
+	now := time.Now()
+	list := NewRevocations()
+	list.RevokeSession("sess-1", now.Add(time.Hour))
+	list.RevokeUser("user-2", now, now.Add(time.Hour))
+
+	assert.True(t, list.Revoked(&Claims{Subject: "user-1", SessionID: "sess-1"}, now))
+	assert.False(t, list.Revoked(&Claims{Subject: "user-1", SessionID: "sess-9"}, now))
+
+	assert.True(t, list.Revoked(&Claims{Subject: "user-2", IssuedAt: now.Add(-time.Minute)}, now))
+	assert.True(t, list.Revoked(&Claims{Subject: "user-2"}, now), "没有 iat 时无法区分签发先后")
+	assert.False(t, list.Revoked(&Claims{Subject: "user-2", IssuedAt: now.Add(time.Minute)}, now), "重新登录签发的 Token")
+
+	// 较早的用户吊销不会覆盖较晚的
+	list.RevokeUser("user-2", now.Add(-time.Hour), now.Add(time.Hour))
+	assert.True(t, list.Revoked(&Claims{Subject: "user-2", IssuedAt: now.Add(-time.Minute)}, now))
+
+	later := now.Add(2 * time.Hour)
+	assert.False(t, list.Revoked(&Claims{Subject: "user-1", SessionID: "sess-1"}, later))
+	assert.Equal(t, 2, list.Prune(now))
+	assert.Equal(t, 0, list.Prune(later))
+}
+
+func TestWithRevocations(t *testing.T) {
+	// 测试场景：验签失败时原样返回错误；已吊销会话的 Token 返回 ErrSessionRevoked（属于 ErrInvalidToken）
+
+	list := NewRevocations()
+	list.RevokeSession("sess-1", time.Now().Add(time.Hour))
+
+	upstream := errors.New("upstream")
+	_, err := WithRevocations(stubVerifier{err: upstream}, list).Verify(context.Background(), "t")
+	assert.ErrorIs(t, err, upstream)
+
+	_, err = WithRevocations(stubVerifier{claims: &Claims{Subject: "user-1", SessionID: "sess-1"}}, list).Verify(context.Background(), "t")
+	assert.ErrorIs(t, err, ErrSessionRevoked)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	claims, err := WithRevocations(stubVerifier{claims: &Claims{Subject: "user-1", SessionID: "sess-2"}}, list).Verify(context.Background(), "t")
+	require.NoError(t, err)
+	assert.Equal(t, "sess-2", claims.SessionID)
+}
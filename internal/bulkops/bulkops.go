@@ -0,0 +1,386 @@
+// Package bulkops 在服务端对 PageSchema 执行批量操作（复制子树、批量编号、对齐属性），
+// 供高级用户一次提交整组修改，避免前端逐条发送成百上千个 Patch。
+//
+// 操作按顺序作用在同一份 Schema 上，任一操作失败时整组作废；
+// 调用方用 jsondiff 计算前后差异，作为一个版本提交到协同房间。
+//
+// Schema 以通用 JSON 对象处理，未识别的字段原样保留，数字按原始文本保存。
+package bulkops
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 操作类型
+const (
+	OpDuplicate  = "duplicate"   // 把组件及其子树复制 count 份，依次插入原组件之后
+	OpRenumber   = "renumber"    // 按顺序给组件的子组件编号，写入指定属性
+	OpAlignProps = "align-props" // 把源组件的指定 props / styles 复制到目标组件
+)
+
+// 批量操作限制
+const (
+	MaxOps            = 50   // 单次请求最多包含的操作数
+	MaxDuplicateCount = 100  // 单个 duplicate 操作最多复制的份数
+	MaxNewComponents  = 2000 // 单次请求最多新建的组件数
+)
+
+// numberPlaceholder renumber 模板中的序号占位符
+const numberPlaceholder = "{n}"
+
+// Op 单个批量操作，按 Type 使用不同字段
+type Op struct {
+	Type string `json:"type"`
+
+	// duplicate：被复制的组件；renumber：被编号子组件的父组件
+	ComponentID int64 `json:"componentId,omitempty"`
+
+	// duplicate：复制份数
+	Count int `json:"count,omitempty"`
+
+	// renumber：写入的属性名、模板（{n} 替换为序号，默认 "{n}"）和起始序号（默认 1）
+	Prop     string `json:"prop,omitempty"`
+	Template string `json:"template,omitempty"`
+	Start    *int   `json:"start,omitempty"`
+
+	// align-props：源组件、目标组件，以及需要对齐的 props / styles 字段名。
+	// 源组件没有某个字段时，目标组件的该字段被删除
+	SourceID  int64    `json:"sourceId,omitempty"`
+	TargetIDs []int64  `json:"targetIds,omitempty"`
+	Props     []string `json:"props,omitempty"`
+	Styles    []string `json:"styles,omitempty"`
+}
+
+// ErrNoOps 请求中没有操作
+var ErrNoOps = errors.New("ops 不能为空")
+
+// OpError 第 Index 个操作执行失败
+type OpError struct {
+	Index  int
+	Type   string
+	Reason string
+}
+
+func (e *OpError) Error() string {
+	return fmt.Sprintf("第 %d 个操作（%s）失败: %s", e.Index+1, e.Type, e.Reason)
+}
+
+// Apply 在 schema 上依次执行 ops，返回修改后的 Schema。
+// 任一操作失败时返回 *OpError，schema 本身不会被修改。
+func Apply(schema []byte, ops []Op) ([]byte, error) {
+	if len(ops) == 0 {
+		return nil, ErrNoOps
+	}
+	if len(ops) > MaxOps {
+		return nil, fmt.Errorf("单次最多 %d 个操作", MaxOps)
+	}
+
+	doc, err := parse(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		if err := doc.apply(op); err != nil {
+			return nil, &OpError{Index: i, Type: op.Type, Reason: err.Error()}
+		}
+	}
+	return json.Marshal(doc.root)
+}
+
+// document 解析后的 Schema
+type document struct {
+	root       map[string]interface{}
+	components map[string]interface{}
+	rootID     string
+	nextID     int64 // 下一个可分配的组件 ID
+	created    int   // 本次请求已新建的组件数
+}
+
+func parse(schema []byte) (*document, error) {
+	dec := json.NewDecoder(bytes.NewReader(schema))
+	dec.UseNumber()
+	var root map[string]interface{}
+	if err := dec.Decode(&root); err != nil {
+		return nil, fmt.Errorf("Schema 解析失败: %w", err)
+	}
+
+	components, ok := root["components"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("Schema 缺少 components")
+	}
+
+	doc := &document{root: root, components: components, rootID: idString(root["rootId"])}
+	for key, value := range components {
+		if id, err := strconv.ParseInt(key, 10, 64); err == nil && id >= doc.nextID {
+			doc.nextID = id + 1
+		}
+		if c, ok := value.(map[string]interface{}); ok {
+			if id, err := strconv.ParseInt(idString(c["id"]), 10, 64); err == nil && id >= doc.nextID {
+				doc.nextID = id + 1
+			}
+		}
+	}
+	return doc, nil
+}
+
+func (d *document) apply(op Op) error {
+	switch op.Type {
+	case OpDuplicate:
+		return d.duplicate(op)
+	case OpRenumber:
+		return d.renumber(op)
+	case OpAlignProps:
+		return d.alignProps(op)
+	default:
+		return fmt.Errorf("未知的操作类型 %q", op.Type)
+	}
+}
+
+// duplicate 复制组件子树，副本依次插入原组件之后
+func (d *document) duplicate(op Op) error {
+	if op.Count < 1 || op.Count > MaxDuplicateCount {
+		return fmt.Errorf("count 必须在 1 到 %d 之间", MaxDuplicateCount)
+	}
+
+	key := strconv.FormatInt(op.ComponentID, 10)
+	src, err := d.component(key)
+	if err != nil {
+		return err
+	}
+	if key == d.rootID {
+		return errors.New("不能复制根组件")
+	}
+
+	parentKey := idString(src["parentId"])
+	parent, err := d.component(parentKey)
+	if err != nil {
+		return fmt.Errorf("父组件: %w", err)
+	}
+	siblings := idList(parent["children"])
+	pos := indexOf(siblings, key)
+	if pos < 0 {
+		return fmt.Errorf("组件 %s 不在父组件 %s 的 children 中", key, parentKey)
+	}
+
+	size, err := d.subtreeSize(key, make(map[string]bool))
+	if err != nil {
+		return err
+	}
+	if d.created+size*op.Count > MaxNewComponents {
+		return fmt.Errorf("单次最多新建 %d 个组件", MaxNewComponents)
+	}
+
+	copies := make([]string, 0, op.Count)
+	for i := 0; i < op.Count; i++ {
+		copies = append(copies, d.cloneSubtree(key, json.Number(parentKey)))
+	}
+
+	children := make([]string, 0, len(siblings)+len(copies))
+	children = append(children, siblings[:pos+1]...)
+	children = append(children, copies...)
+	children = append(children, siblings[pos+1:]...)
+	parent["children"] = numberList(children)
+	return nil
+}
+
+// subtreeSize 统计子树组件数，同时检查子树完整且无循环
+func (d *document) subtreeSize(key string, visited map[string]bool) (int, error) {
+	if visited[key] {
+		return 0, fmt.Errorf("组件树在 %s 处存在循环", key)
+	}
+	visited[key] = true
+
+	c, err := d.component(key)
+	if err != nil {
+		return 0, err
+	}
+	size := 1
+	for _, child := range idList(c["children"]) {
+		n, err := d.subtreeSize(child, visited)
+		if err != nil {
+			return 0, err
+		}
+		size += n
+	}
+	return size, nil
+}
+
+// cloneSubtree 深拷贝子树并分配新 ID，返回副本根组件的 ID。调用前需已通过 subtreeSize 检查
+func (d *document) cloneSubtree(key string, parentID json.Number) string {
+	src := d.components[key].(map[string]interface{})
+	clone := deepCopy(src).(map[string]interface{})
+
+	id := json.Number(strconv.FormatInt(d.nextID, 10))
+	d.nextID++
+	d.created++
+
+	clone["id"] = id
+	clone["parentId"] = parentID
+	if children := idList(src["children"]); len(children) > 0 {
+		cloned := make([]string, 0, len(children))
+		for _, child := range children {
+			cloned = append(cloned, d.cloneSubtree(child, id))
+		}
+		clone["children"] = numberList(cloned)
+	}
+
+	d.components[id.String()] = clone
+	return id.String()
+}
+
+// renumber 按 children 顺序给子组件的 prop 属性写入编号
+func (d *document) renumber(op Op) error {
+	if op.Prop == "" {
+		return errors.New("prop 不能为空")
+	}
+	template := op.Template
+	if template == "" {
+		template = numberPlaceholder
+	}
+	if !strings.Contains(template, numberPlaceholder) {
+		return fmt.Errorf("template 必须包含 %s", numberPlaceholder)
+	}
+	start := 1
+	if op.Start != nil {
+		start = *op.Start
+	}
+
+	parent, err := d.component(strconv.FormatInt(op.ComponentID, 10))
+	if err != nil {
+		return err
+	}
+	for i, key := range idList(parent["children"]) {
+		child, err := d.component(key)
+		if err != nil {
+			return err
+		}
+		props := objectField(child, "props")
+		props[op.Prop] = strings.ReplaceAll(template, numberPlaceholder, strconv.Itoa(start+i))
+	}
+	return nil
+}
+
+// alignProps 把源组件的指定字段复制到目标组件
+func (d *document) alignProps(op Op) error {
+	if len(op.TargetIDs) == 0 {
+		return errors.New("targetIds 不能为空")
+	}
+	if len(op.Props) == 0 && len(op.Styles) == 0 {
+		return errors.New("props 和 styles 不能同时为空")
+	}
+
+	source, err := d.component(strconv.FormatInt(op.SourceID, 10))
+	if err != nil {
+		return err
+	}
+	for _, targetID := range op.TargetIDs {
+		if targetID == op.SourceID {
+			continue
+		}
+		target, err := d.component(strconv.FormatInt(targetID, 10))
+		if err != nil {
+			return err
+		}
+		copyFields(source, target, "props", op.Props)
+		copyFields(source, target, "styles", op.Styles)
+	}
+	return nil
+}
+
+// copyFields 把 source[field] 中的 keys 复制到 target[field]，source 中不存在的 key 从 target 删除
+func copyFields(source, target map[string]interface{}, field string, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	from, _ := source[field].(map[string]interface{})
+	to := objectField(target, field)
+	for _, key := range keys {
+		if value, ok := from[key]; ok {
+			to[key] = deepCopy(value)
+		} else {
+			delete(to, key)
+		}
+	}
+}
+
+func (d *document) component(key string) (map[string]interface{}, error) {
+	c, ok := d.components[key].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("组件 %s 不存在", key)
+	}
+	return c, nil
+}
+
+// objectField 返回 c[field] 对象，不存在时创建
+func objectField(c map[string]interface{}, field string) map[string]interface{} {
+	if m, ok := c[field].(map[string]interface{}); ok {
+		return m
+	}
+	m := make(map[string]interface{})
+	c[field] = m
+	return m
+}
+
+// idString 把 JSON 中的组件 ID（数字或字符串）转为 components 的 key
+func idString(v interface{}) string {
+	switch id := v.(type) {
+	case json.Number:
+		return id.String()
+	case string:
+		return id
+	default:
+		return ""
+	}
+}
+
+func idList(v interface{}) []string {
+	items, _ := v.([]interface{})
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, idString(item))
+	}
+	return ids
+}
+
+func numberList(ids []string) []interface{} {
+	list := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, json.Number(id))
+	}
+	return list
+}
+
+func indexOf(ids []string, key string) int {
+	for i, id := range ids {
+		if id == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// deepCopy 复制 JSON 解码得到的值
+func deepCopy(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			m[k] = deepCopy(item)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, item := range value {
+			list[i] = deepCopy(item)
+		}
+		return list
+	default:
+		return value
+	}
+}
@@ -0,0 +1,143 @@
+package bulkops
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 批量操作单元测试 ==========
+
+// testSchema 根组件 1 下有一个列表 10，列表中有两个卡片 100、200，卡片 100 内有文本 101
+const testSchema = `{
+	"rootId": 1,
+	"components": {
+		"1":   {"id": 1, "name": "Page", "desc": "页面", "children": [10]},
+		"10":  {"id": 10, "name": "List", "desc": "列表", "parentId": 1, "children": [100, 200]},
+		"100": {"id": 100, "name": "Card", "desc": "卡片", "parentId": 10, "children": [101],
+		        "props": {"title": "A", "shadow": true}, "styles": {"width": 200, "color": "red"}},
+		"101": {"id": 101, "name": "Text", "desc": "文本", "parentId": 100, "props": {"text": "hi"}},
+		"200": {"id": 200, "name": "Card", "desc": "卡片", "parentId": 10,
+		        "props": {"title": "B", "extra": 1}, "styles": {"width": 100}}
+	},
+	"editedBy": {"100": {"userId": "alice", "version": 3, "at": 1}}
+}`
+
+func applyToSchema(t *testing.T, ops ...Op) *entity.PageSchema {
+	t.Helper()
+	out, err := Apply([]byte(testSchema), ops)
+	require.NoError(t, err)
+
+	var schema entity.PageSchema
+	require.NoError(t, json.Unmarshal(out, &schema))
+	return &schema
+}
+
+func TestApply_Duplicate(t *testing.T) {
+	// 测试场景：复制带子组件的卡片两份，副本插入原组件之后，ID 从现有最大 ID 之后分配
+
+	schema := applyToSchema(t, Op{Type: OpDuplicate, ComponentID: 100, Count: 2})
+
+	assert.Len(t, schema.Components, 9)
+	assert.Equal(t, []int64{100, 201, 203, 200}, schema.Components["10"].Children)
+
+	first := schema.Components["201"]
+	assert.Equal(t, "Card", first.Name)
+	require.NotNil(t, first.ParentID)
+	assert.Equal(t, int64(10), *first.ParentID)
+	assert.Equal(t, []int64{202}, first.Children)
+	assert.JSONEq(t, `{"title": "A", "shadow": true}`, string(first.Props))
+
+	text := schema.Components["202"]
+	require.NotNil(t, text.ParentID)
+	assert.Equal(t, int64(201), *text.ParentID)
+	assert.JSONEq(t, `{"text": "hi"}`, string(text.Props))
+
+	// 原组件不受影响，编辑归属原样保留
+	assert.Equal(t, []int64{101}, schema.Components["100"].Children)
+	assert.Contains(t, schema.EditedBy, "100")
+}
+
+func TestApply_Renumber(t *testing.T) {
+	// 测试场景：复制后按顺序编号，模板中的 {n} 替换为序号
+
+	start := 0
+	schema := applyToSchema(t,
+		Op{Type: OpDuplicate, ComponentID: 200, Count: 1},
+		Op{Type: OpRenumber, ComponentID: 10, Prop: "title", Template: "卡片 {n}", Start: &start},
+	)
+
+	var titles []string
+	for _, id := range schema.Components["10"].Children {
+		var props map[string]interface{}
+		require.NoError(t, json.Unmarshal(schema.Components[strconv.FormatInt(id, 10)].Props, &props))
+		titles = append(titles, props["title"].(string))
+	}
+	assert.Equal(t, []string{"卡片 0", "卡片 1", "卡片 2"}, titles)
+}
+
+func TestApply_AlignProps(t *testing.T) {
+	// 测试场景：目标组件的指定字段与源组件一致，源组件没有的字段从目标删除，其余字段不变
+
+	schema := applyToSchema(t, Op{
+		Type:      OpAlignProps,
+		SourceID:  100,
+		TargetIDs: []int64{200, 100},
+		Props:     []string{"shadow", "extra"},
+		Styles:    []string{"width"},
+	})
+
+	assert.JSONEq(t, `{"title": "B", "shadow": true}`, string(schema.Components["200"].Props))
+	assert.JSONEq(t, `{"width": 200}`, string(schema.Components["200"].Styles))
+	assert.JSONEq(t, `{"title": "A", "shadow": true}`, string(schema.Components["100"].Props))
+}
+
+func TestApply_Errors(t *testing.T) {
+	// 测试场景：非法操作整组失败，错误指出失败的操作序号
+
+	tests := []struct {
+		name  string
+		ops   []Op
+		index int
+	}{
+		{name: "组件不存在", ops: []Op{{Type: OpDuplicate, ComponentID: 999, Count: 1}}, index: 0},
+		{name: "复制根组件", ops: []Op{{Type: OpDuplicate, ComponentID: 1, Count: 1}}, index: 0},
+		{name: "复制份数超限", ops: []Op{{Type: OpDuplicate, ComponentID: 100, Count: MaxDuplicateCount + 1}}, index: 0},
+		{name: "模板缺少占位符", ops: []Op{
+			{Type: OpDuplicate, ComponentID: 100, Count: 1},
+			{Type: OpRenumber, ComponentID: 10, Prop: "title", Template: "卡片"},
+		}, index: 1},
+		{name: "未知类型", ops: []Op{{Type: "explode"}}, index: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Apply([]byte(testSchema), tt.ops)
+			var opErr *OpError
+			require.ErrorAs(t, err, &opErr)
+			assert.Equal(t, tt.index, opErr.Index)
+		})
+	}
+
+	_, err := Apply([]byte(testSchema), nil)
+	assert.ErrorIs(t, err, ErrNoOps)
+}
+
+func TestApply_NewComponentLimit(t *testing.T) {
+	// 测试场景：多个复制操作累计新建的组件数不能超过上限
+
+	ops := make([]Op, 0, 11)
+	for i := 0; i < 11; i++ {
+		ops = append(ops, Op{Type: OpDuplicate, ComponentID: 100, Count: MaxDuplicateCount})
+	}
+
+	_, err := Apply([]byte(testSchema), ops)
+	var opErr *OpError
+	require.ErrorAs(t, err, &opErr)
+	assert.Equal(t, 10, opErr.Index)
+}
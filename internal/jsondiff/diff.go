@@ -0,0 +1,140 @@
+// Package jsondiff 计算两个 JSON 文档之间的 RFC 6902 JSON Patch。
+// 生成的 Patch 可直接交给 evanphx/json-patch 应用，用于"版本对比"等场景。
+package jsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operation 单条 RFC 6902 操作
+type Operation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON 保证 add/replace 的 value 为 null 时仍然输出 value 字段
+func (o Operation) MarshalJSON() ([]byte, error) {
+	if o.Op == "remove" {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{o.Op, o.Path})
+	}
+	return json.Marshal(struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}{o.Op, o.Path, o.Value})
+}
+
+// Diff 计算将 from 变换为 to 的 JSON Patch。
+// 对象按 key 逐层比较；数组长度相同时按下标比较，否则在公共前缀之后追加或删除元素。
+func Diff(from, to []byte) ([]Operation, error) {
+	a, err := decode(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := decode(to)
+	if err != nil {
+		return nil, err
+	}
+
+	ops := []Operation{}
+	diffValue("", a, b, &ops)
+	return ops, nil
+}
+
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func diffValue(path string, a, b interface{}, ops *[]Operation) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		if bv, ok := b.(map[string]interface{}); ok {
+			diffObject(path, av, bv, ops)
+			return
+		}
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok {
+			diffArray(path, av, bv, ops)
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*ops = append(*ops, Operation{Op: "replace", Path: path, Value: b})
+	}
+}
+
+func diffObject(path string, a, b map[string]interface{}, ops *[]Operation) {
+	for _, key := range sortedKeys(a) {
+		if _, ok := b[key]; !ok {
+			*ops = append(*ops, Operation{Op: "remove", Path: path + "/" + EscapeToken(key)})
+		}
+	}
+	for _, key := range sortedKeys(b) {
+		child := path + "/" + EscapeToken(key)
+		if av, ok := a[key]; ok {
+			diffValue(child, av, b[key], ops)
+		} else {
+			*ops = append(*ops, Operation{Op: "add", Path: child, Value: b[key]})
+		}
+	}
+}
+
+func diffArray(path string, a, b []interface{}, ops *[]Operation) {
+	common := len(a)
+	if len(b) < common {
+		common = len(b)
+	}
+
+	for i := 0; i < common; i++ {
+		diffValue(path+"/"+strconv.Itoa(i), a[i], b[i], ops)
+	}
+
+	// 从尾部开始删除，保证下标在应用过程中始终有效
+	for i := len(a) - 1; i >= common; i-- {
+		*ops = append(*ops, Operation{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+	}
+	for i := common; i < len(b); i++ {
+		*ops = append(*ops, Operation{Op: "add", Path: path + "/" + strconv.Itoa(i), Value: b[i]})
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// EscapeToken 按 RFC 6901 转义 JSON Pointer 中的一段 key（~ → ~0，/ → ~1）
+func EscapeToken(key string) string {
+	key = strings.ReplaceAll(key, "~", "~0")
+	return strings.ReplaceAll(key, "/", "~1")
+}
+
+// Pointer 将若干 key 拼接为 RFC 6901 JSON Pointer，如 Pointer("components", "1") == "/components/1"
+func Pointer(tokens ...string) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteByte('/')
+		b.WriteString(EscapeToken(t))
+	}
+	return b.String()
+}
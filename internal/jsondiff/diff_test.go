@@ -0,0 +1,84 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== Diff 单元测试 ==========
+// 核心断言：将 Diff 结果应用到 from 上必须得到 to
+
+func TestDiff_RoundTrip(t *testing.T) {
+	testCases := []struct {
+		name string
+		from string
+		to   string
+	}{
+		{
+			name: "Identical",
+			from: `{"rootId": 1, "components": {}}`,
+			to:   `{"components": {}, "rootId": 1}`,
+		},
+		{
+			name: "Replace scalar",
+			from: `{"components": {"1": {"name": "Page", "desc": "a"}}}`,
+			to:   `{"components": {"1": {"name": "Page", "desc": "b"}}}`,
+		},
+		{
+			name: "Add and remove component",
+			from: `{"components": {"1": {"children": [2]}, "2": {"name": "Button"}}}`,
+			to:   `{"components": {"1": {"children": [3]}, "3": {"name": "Text"}}}`,
+		},
+		{
+			name: "Array grows and shrinks",
+			from: `{"a": [1, 2, 3, 4], "b": [1]}`,
+			to:   `{"a": [1, 5], "b": [1, 2, 3]}`,
+		},
+		{
+			name: "Null value and type change",
+			from: `{"parentId": 1, "props": {"x": 1}}`,
+			to:   `{"parentId": null, "props": [1]}`,
+		},
+		{
+			name: "Keys needing escape",
+			from: `{"a/b": 1, "c~d": 1}`,
+			to:   `{"a/b": 2, "c~d": 3}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ops, err := Diff([]byte(tc.from), []byte(tc.to))
+			assert.NoError(t, err)
+
+			patchBytes, err := json.Marshal(ops)
+			assert.NoError(t, err)
+
+			patch, err := jsonpatch.DecodePatch(patchBytes)
+			assert.NoError(t, err)
+
+			result, err := patch.Apply([]byte(tc.from))
+			assert.NoError(t, err)
+			assert.True(t, jsonpatch.Equal([]byte(tc.to), result), "got %s", result)
+		})
+	}
+}
+
+func TestDiff_IdenticalIsEmpty(t *testing.T) {
+	ops, err := Diff([]byte(`{"a": 1}`), []byte(`{"a": 1}`))
+	assert.NoError(t, err)
+	assert.Empty(t, ops)
+}
+
+func TestDiff_InvalidJSON(t *testing.T) {
+	_, err := Diff([]byte(`{`), []byte(`{}`))
+	assert.Error(t, err)
+}
+
+func TestPointer_Escape(t *testing.T) {
+	assert.Equal(t, "a~1b~0c", EscapeToken("a/b~c"))
+	assert.Equal(t, "/components/a~1b/props", Pointer("components", "a/b", "props"))
+}
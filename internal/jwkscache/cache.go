@@ -0,0 +1,141 @@
+// Package jwkscache 缓存 JWT 验签公钥（JWKS），Clerk 与通用 OIDC 身份提供方共用。
+// Clerk SDK 默认每次验签都会请求 JWKS，上游抖动时所有 /api 请求和 WebSocket 连接都会失败；
+// Cache 在 TTL 内直接使用缓存，刷新失败时在 MaxStale 容忍期内继续使用旧公钥。
+package jwkscache
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+
+	"lowercode-go-server/internal/logging"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+)
+
+const (
+	retryInterval = 30 * time.Second // 刷新失败或遇到未知 kid 后，再次请求上游的最短间隔
+	fetchTimeout  = 5 * time.Second  // 单次请求上游的超时时间
+)
+
+var (
+	// ErrUnavailable 无法获取公钥：上游不可达且没有可用的缓存
+	ErrUnavailable = errors.New("clerk jwks unavailable")
+
+	// ErrKeyNotFound JWKS 中没有 Token 对应的 kid
+	ErrKeyNotFound = errors.New("json web key not found")
+)
+
+// metrics JWKS 缓存指标，通过 expvar 暴露
+var metrics = expvar.NewMap("clerk_jwks")
+
+// FetchFunc 从身份提供方拉取 JWKS
+type FetchFunc func(ctx context.Context) (*clerk.JSONWebKeySet, error)
+
+// Cache 并发安全的 JWKS 缓存
+type Cache struct {
+	fetch    FetchFunc
+	ttl      time.Duration // 缓存有效期，过期后验签时刷新
+	maxStale time.Duration // 刷新失败时，过期缓存还能继续使用的时长
+
+	mu          sync.Mutex
+	keys        map[string]*clerk.JSONWebKey
+	fetchedAt   time.Time // 最近一次成功拉取的时间
+	lastAttempt time.Time // 最近一次请求上游的时间
+	lastErr     error     // 最近一次请求上游的错误
+	now         func() time.Time
+}
+
+// New 创建 Cache 实例
+func New(fetch FetchFunc, ttl, maxStale time.Duration) *Cache {
+	return &Cache{
+		fetch:    fetch,
+		ttl:      ttl,
+		maxStale: maxStale,
+		keys:     make(map[string]*clerk.JSONWebKey),
+		now:      time.Now,
+	}
+}
+
+// Key 返回 kid 对应的公钥。
+// 缓存未过期时直接返回；否则请求上游刷新，失败时在 ttl+maxStale 内返回旧公钥。
+func (c *Cache) Key(ctx context.Context, kid string) (*clerk.JSONWebKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	key, cached := c.keys[kid]
+	age := now.Sub(c.fetchedAt)
+	if cached && age < c.ttl {
+		return key, nil
+	}
+
+	if c.lastAttempt.IsZero() || now.Sub(c.lastAttempt) >= retryInterval {
+		c.refreshLocked(ctx, now)
+		if c.lastErr == nil {
+			if key, ok := c.keys[kid]; ok {
+				return key, nil
+			}
+			return nil, ErrKeyNotFound
+		}
+	}
+
+	// 刷新失败或处于重试间隔内
+	if cached && age < c.ttl+c.maxStale {
+		metrics.Add("stale_served", 1)
+		return key, nil
+	}
+	if c.lastErr == nil && !cached {
+		return nil, ErrKeyNotFound
+	}
+	return nil, ErrUnavailable
+}
+
+// Refresh 立即请求上游刷新缓存，不受重试间隔限制，用于启动自检和预热
+func (c *Cache) Refresh(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.refreshLocked(ctx, c.now())
+	return c.lastErr
+}
+
+// refreshLocked 请求上游并替换缓存，调用方需持有 mu
+func (c *Cache) refreshLocked(ctx context.Context, now time.Time) {
+	c.lastAttempt = now
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	set, err := c.fetch(ctx)
+	if err == nil && set == nil {
+		err = errors.New("empty jwks response")
+	}
+	if err != nil {
+		c.lastErr = err
+		metrics.Add("fetch_errors", 1)
+		if !c.fetchedAt.IsZero() {
+			logging.Warnf("[JWKS] 刷新失败，使用 %s 前的缓存: %v", now.Sub(c.fetchedAt).Round(time.Second), err)
+		} else {
+			logging.Errorf("[JWKS] 拉取失败，暂无缓存: %v", err)
+		}
+		return
+	}
+
+	keys := make(map[string]*clerk.JSONWebKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k != nil {
+			keys[k.KeyID] = k
+		}
+	}
+	c.keys = keys
+	c.fetchedAt = now
+	c.lastErr = nil
+
+	metrics.Add("fetches", 1)
+	last := new(expvar.Int)
+	last.Set(now.Unix())
+	metrics.Set("last_fetch_unix", last)
+}
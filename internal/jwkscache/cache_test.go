@@ -0,0 +1,137 @@
+package jwkscache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== JWKS Cache 单元测试 ==========
+
+// fakeUpstream 可控的 JWKS 上游
+type fakeUpstream struct {
+	keys  []string
+	err   error
+	calls int
+}
+
+func (f *fakeUpstream) fetch(context.Context) (*clerk.JSONWebKeySet, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	set := &clerk.JSONWebKeySet{}
+	for _, kid := range f.keys {
+		set.Keys = append(set.Keys, &clerk.JSONWebKey{KeyID: kid})
+	}
+	return set, nil
+}
+
+func newTestCache(up *fakeUpstream, ttl, maxStale time.Duration) (*Cache, *time.Time) {
+	now := time.Unix(1700000000, 0)
+	c := New(up.fetch, ttl, maxStale)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestCache_ServesFromCacheWithinTTL(t *testing.T) {
+	// 测试场景：TTL 内重复验签只请求一次上游
+
+	up := &fakeUpstream{keys: []string{"k1"}}
+	c, now := newTestCache(up, time.Hour, 24*time.Hour)
+
+	for i := 0; i < 3; i++ {
+		key, err := c.Key(context.Background(), "k1")
+		require.NoError(t, err)
+		assert.Equal(t, "k1", key.KeyID)
+		*now = now.Add(10 * time.Minute)
+	}
+	assert.Equal(t, 1, up.calls)
+}
+
+func TestCache_StaleDuringOutage(t *testing.T) {
+	// 测试场景：上游故障时在容忍期内使用旧公钥，超出容忍期后返回 ErrUnavailable
+
+	up := &fakeUpstream{keys: []string{"k1"}}
+	c, now := newTestCache(up, time.Hour, 2*time.Hour)
+
+	_, err := c.Key(context.Background(), "k1")
+	require.NoError(t, err)
+
+	up.err = errors.New("upstream down")
+	*now = now.Add(90 * time.Minute)
+	key, err := c.Key(context.Background(), "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", key.KeyID)
+	assert.Equal(t, 2, up.calls)
+
+	// 重试间隔内不再请求上游
+	_, err = c.Key(context.Background(), "k1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, up.calls)
+
+	*now = now.Add(2 * time.Hour)
+	_, err = c.Key(context.Background(), "k1")
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	// 上游恢复后重新可用
+	up.err = nil
+	*now = now.Add(retryInterval)
+	_, err = c.Key(context.Background(), "k1")
+	assert.NoError(t, err)
+}
+
+func TestCache_UnknownKeyID(t *testing.T) {
+	// 测试场景：未知 kid 触发刷新（处理密钥轮换），重试间隔内不重复请求上游
+
+	up := &fakeUpstream{keys: []string{"k1"}}
+	c, now := newTestCache(up, time.Hour, time.Hour)
+
+	_, err := c.Key(context.Background(), "k1")
+	require.NoError(t, err)
+
+	up.keys = []string{"k1", "k2"}
+	_, err = c.Key(context.Background(), "k2")
+	require.Error(t, err, "刚拉取过，重试间隔内不应再请求上游")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	*now = now.Add(retryInterval)
+	key, err := c.Key(context.Background(), "k2")
+	require.NoError(t, err)
+	assert.Equal(t, "k2", key.KeyID)
+
+	_, err = c.Key(context.Background(), "forged")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, 2, up.calls)
+}
+
+func TestCache_NoCacheUpstreamDown(t *testing.T) {
+	// 测试场景：从未拉取成功时返回 ErrUnavailable
+
+	up := &fakeUpstream{err: errors.New("upstream down")}
+	c, _ := newTestCache(up, time.Hour, time.Hour)
+
+	_, err := c.Key(context.Background(), "k1")
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestCache_Refresh(t *testing.T) {
+	// 测试场景：Refresh 忽略重试间隔立即请求上游，并返回上游错误
+
+	up := &fakeUpstream{keys: []string{"k1"}}
+	c, _ := newTestCache(up, time.Hour, time.Hour)
+
+	assert.NoError(t, c.Refresh(context.Background()))
+	_, err := c.Key(context.Background(), "k1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, up.calls)
+
+	up.err = errors.New("unauthorized")
+	assert.EqualError(t, c.Refresh(context.Background()), "unauthorized")
+	assert.Equal(t, 2, up.calls)
+}
@@ -0,0 +1,233 @@
+// Package legacy 将纯前端时代（localStorage 持久化）的页面数据转换为 PageSchema。
+//
+// 旧版编辑器使用 zustand persist 把组件树保存在 localStorage 中，格式为嵌套树：
+//
+//	{
+//	  "state": {
+//	    "components": [
+//	      { "id": 1, "name": "Page", "desc": "页面", "props": {}, "styles": {},
+//	        "children": [ { "id": 1712, "name": "Button", "parentId": 1, ... } ] }
+//	    ]
+//	  },
+//	  "version": 0
+//	}
+//
+// 字段映射（旧 → 新）：
+//
+//	state.components（嵌套树）  → components（按 id 平铺的 map）+ rootId
+//	id                          → id
+//	name / componentName / type → name
+//	desc / description          → desc
+//	props                       → props
+//	styles / style              → styles
+//	children（组件对象数组）    → children（子组件 id 数组）
+//	（由树结构推导）            → parentId
+//
+// 同时兼容没有 state 包装的 { "components": [...] } 以及裸数组。
+// 顶层只有一个 Page 组件时直接作为根节点，否则新建根节点 Page 包裹顶层组件。
+package legacy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"lowercode-go-server/domain/entity"
+)
+
+// rootName 根节点组件名
+const rootName = "Page"
+
+// component 旧版组件结构，字段别名在 UnmarshalJSON 中归并
+type component struct {
+	ID       *int64
+	Name     string
+	Desc     string
+	Props    json.RawMessage
+	Styles   json.RawMessage
+	Children []*component
+}
+
+// UnmarshalJSON 解析旧版组件，兼容字段别名
+func (c *component) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		ID            *int64          `json:"id"`
+		Name          string          `json:"name"`
+		ComponentName string          `json:"componentName"`
+		Type          string          `json:"type"`
+		Desc          string          `json:"desc"`
+		Description   string          `json:"description"`
+		Props         json.RawMessage `json:"props"`
+		Styles        json.RawMessage `json:"styles"`
+		Style         json.RawMessage `json:"style"`
+		Children      []*component    `json:"children"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*c = component{
+		ID:       raw.ID,
+		Name:     firstNonEmpty(raw.Name, raw.ComponentName, raw.Type),
+		Desc:     firstNonEmpty(raw.Desc, raw.Description),
+		Props:    raw.Props,
+		Styles:   raw.Styles,
+		Children: raw.Children,
+	}
+	if len(c.Styles) == 0 {
+		c.Styles = raw.Style
+	}
+	return nil
+}
+
+// Convert 将 localStorage 中的原始数据转换为 PageSchema。
+// data 可以是 JSON 对象 / 数组，也可以是 localStorage 原样导出的 JSON 字符串。
+func Convert(data []byte) (*entity.PageSchema, error) {
+	components, err := parseComponents(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(components) == 0 {
+		return nil, errors.New("legacy: no components found")
+	}
+
+	c := &converter{
+		schema: &entity.PageSchema{Components: make(map[string]entity.Component)},
+	}
+	c.nextID = maxID(components) + 1
+
+	var root *component
+	if len(components) == 1 && components[0].Name == rootName {
+		root = components[0]
+	} else {
+		root = &component{Name: rootName, Desc: "页面根节点", Children: components}
+	}
+
+	rootID, err := c.add(root, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.schema.RootID = rootID
+	return c.schema, nil
+}
+
+// parseComponents 剥离 localStorage 的外层包装，返回顶层组件列表
+func parseComponents(data []byte) ([]*component, error) {
+	data = bytes.TrimSpace(data)
+
+	// localStorage.getItem 导出的是字符串，先解一层
+	if len(data) > 0 && data[0] == '"' {
+		var inner string
+		if err := json.Unmarshal(data, &inner); err != nil {
+			return nil, fmt.Errorf("legacy: %w", err)
+		}
+		data = bytes.TrimSpace([]byte(inner))
+	}
+
+	if len(data) > 0 && data[0] == '[' {
+		var components []*component
+		if err := json.Unmarshal(data, &components); err != nil {
+			return nil, fmt.Errorf("legacy: %w", err)
+		}
+		return components, nil
+	}
+
+	var wrapper struct {
+		State *struct {
+			Components []*component `json:"components"`
+		} `json:"state"`
+		Components []*component `json:"components"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return nil, fmt.Errorf("legacy: %w", err)
+	}
+	if wrapper.State != nil {
+		return wrapper.State.Components, nil
+	}
+	return wrapper.Components, nil
+}
+
+// converter 遍历组件树并写入平铺的 Schema
+type converter struct {
+	schema *entity.PageSchema
+	nextID int64 // 为缺少 id 的组件分配的下一个 id
+}
+
+// add 递归写入组件及其子组件，返回组件 id
+func (c *converter) add(comp *component, parentID *int64) (int64, error) {
+	if comp == nil {
+		return 0, errors.New("legacy: null component")
+	}
+	if comp.Name == "" {
+		return 0, errors.New("legacy: component without name")
+	}
+
+	id := c.nextID
+	if comp.ID != nil {
+		id = *comp.ID
+	} else {
+		c.nextID++
+	}
+
+	key := strconv.FormatInt(id, 10)
+	if _, exists := c.schema.Components[key]; exists {
+		return 0, fmt.Errorf("legacy: duplicate component id %d", id)
+	}
+	// 先占位，防止子树中出现重复 id
+	c.schema.Components[key] = entity.Component{}
+
+	children := make([]int64, 0, len(comp.Children))
+	for _, child := range comp.Children {
+		childID, err := c.add(child, &id)
+		if err != nil {
+			return 0, err
+		}
+		children = append(children, childID)
+	}
+
+	c.schema.Components[key] = entity.Component{
+		ID:       id,
+		Name:     comp.Name,
+		Desc:     comp.Desc,
+		ParentID: parentID,
+		Children: children,
+		Props:    orEmptyObject(comp.Props),
+		Styles:   orEmptyObject(comp.Styles),
+	}
+	return id, nil
+}
+
+// maxID 返回组件树中最大的 id
+func maxID(components []*component) int64 {
+	var max int64
+	for _, comp := range components {
+		if comp == nil {
+			continue
+		}
+		if comp.ID != nil && *comp.ID > max {
+			max = *comp.ID
+		}
+		if childMax := maxID(comp.Children); childMax > max {
+			max = childMax
+		}
+	}
+	return max
+}
+
+func orEmptyObject(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 || string(raw) == "null" {
+		return json.RawMessage(`{}`)
+	}
+	return raw
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
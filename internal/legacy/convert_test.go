@@ -0,0 +1,87 @@
+package legacy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== 旧版数据转换单元测试 ==========
+
+func TestConvert_ZustandPersist(t *testing.T) {
+	// 测试场景：zustand persist 格式的嵌套组件树平铺为 components map
+
+	data := []byte(`{
+		"state": {
+			"components": [{
+				"id": 1, "name": "Page", "desc": "页面", "props": {},
+				"children": [
+					{"id": 100, "name": "Container", "desc": "容器", "children": [
+						{"id": 200, "componentName": "Button", "description": "按钮",
+						 "props": {"text": "提交"}, "style": {"color": "red"}}
+					]}
+				]
+			}],
+			"curComponentId": null
+		},
+		"version": 0
+	}`)
+
+	schema, err := Convert(data)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), schema.RootID)
+	assert.Len(t, schema.Components, 3)
+
+	root := schema.Components["1"]
+	assert.Nil(t, root.ParentID)
+	assert.Equal(t, []int64{100}, root.Children)
+
+	button := schema.Components["200"]
+	assert.Equal(t, "Button", button.Name)
+	assert.Equal(t, "按钮", button.Desc)
+	assert.Equal(t, int64(100), *button.ParentID)
+	assert.JSONEq(t, `{"text": "提交"}`, string(button.Props))
+	assert.JSONEq(t, `{"color": "red"}`, string(button.Styles))
+
+	// 缺省的 styles 补为空对象
+	assert.JSONEq(t, `{}`, string(schema.Components["100"].Styles))
+}
+
+func TestConvert_StringifiedWithoutRoot(t *testing.T) {
+	// 测试场景：localStorage 原样导出的字符串，顶层没有 Page 时自动包裹根节点
+
+	inner := `[{"id": 5, "name": "Text"}, {"name": "Image"}]`
+	encoded, _ := json.Marshal(inner)
+
+	schema, err := Convert(encoded)
+	assert.NoError(t, err)
+	assert.Len(t, schema.Components, 3)
+
+	// 新建的根节点和缺少 id 的组件从现有最大 id 之后依次分配
+	assert.Equal(t, int64(6), schema.RootID)
+	root := schema.Components["6"]
+	assert.Equal(t, "Page", root.Name)
+	assert.Equal(t, []int64{5, 7}, root.Children)
+	assert.Equal(t, "Image", schema.Components["7"].Name)
+	assert.Equal(t, int64(6), *schema.Components["5"].ParentID)
+}
+
+func TestConvert_Invalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{"Not JSON", `{oops`},
+		{"No components", `{"state": {"components": []}}`},
+		{"Duplicate id", `[{"id": 1, "name": "A"}, {"id": 1, "name": "B"}]`},
+		{"Missing name", `[{"id": 1}]`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Convert([]byte(tc.data))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,335 @@
+// Package llm 调用大语言模型补全文本。
+// Client 对接 Chat Completions 接口，支持 OpenAI 兼容服务（OpenAI、DeepSeek、通义千问等）、Azure OpenAI
+// 和本地部署的服务（Ollama、vLLM 等）；调用方依赖 Provider 接口，可以替换为其他模型服务
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	requestTimeout = 60 * time.Second // 单次补全的超时时间，生成较大的 Patch 可能需要数十秒
+	temperature    = 0.2              // 生成结构化输出，取较低的随机性
+
+	// DefaultMaxRequestBytes 默认的请求体上限
+	DefaultMaxRequestBytes = 512 << 10
+	// DefaultMaxResponseBytes 默认的响应体上限
+	DefaultMaxResponseBytes = 1 << 20
+	// DefaultAzureAPIVersion Azure OpenAI 未指定 api-version 时使用的版本
+	DefaultAzureAPIVersion = "2024-06-01"
+)
+
+// 模型服务类型
+const (
+	KindOpenAI = "openai" // OpenAI 兼容接口，请求发往 <BaseURL>/chat/completions，Bearer 认证
+	KindAzure  = "azure"  // Azure OpenAI，请求发往 <BaseURL>/openai/deployments/<Model>/chat/completions，api-key 认证
+	KindLocal  = "local"  // 本地部署的 OpenAI 兼容服务，API Key 可以为空
+)
+
+// ValidKind 检查是否为支持的模型服务类型
+func ValidKind(kind string) bool {
+	return kind == KindOpenAI || kind == KindAzure || kind == KindLocal
+}
+
+// ErrRequestTooLarge 请求体超出 MaxRequestBytes，未发送
+var ErrRequestTooLarge = errors.New("llm: request too large")
+
+// ErrResponseTooLarge 响应体超出 MaxResponseBytes
+var ErrResponseTooLarge = errors.New("llm: response too large")
+
+// Request 一次补全请求
+type Request struct {
+	System string // 系统提示词，约定输出格式
+	Prompt string // 用户输入
+}
+
+// Usage 一次补全的用量，用于计量
+type Usage struct {
+	PromptTokens     int // 服务端返回的 Token 数，未返回时为 0
+	CompletionTokens int
+	RequestBytes     int
+	ResponseBytes    int
+}
+
+// Response 补全结果
+type Response struct {
+	Text  string
+	Usage Usage
+}
+
+// Provider 大语言模型接口
+type Provider interface {
+	Complete(ctx context.Context, req Request) (*Response, error)
+}
+
+// Streamer 支持流式输出的模型服务，onDelta 按顺序收到回复的增量文本，返回值与 Complete 相同。
+// 不支持流式输出的 Provider 由调用方退化为 Complete
+type Streamer interface {
+	Stream(ctx context.Context, req Request, onDelta func(delta string)) (*Response, error)
+}
+
+// Limits 请求和响应的大小上限，<= 0 时使用默认值
+type Limits struct {
+	MaxRequestBytes  int
+	MaxResponseBytes int
+}
+
+// Config 模型服务配置
+type Config struct {
+	Kind       string // openai / azure / local，为空时为 openai
+	BaseURL    string // 如 https://api.openai.com/v1、https://<资源名>.openai.azure.com、http://localhost:11434/v1
+	APIKey     string
+	Model      string // Azure 为部署名
+	APIVersion string // 仅 Azure，为空时使用 DefaultAzureAPIVersion
+	Limits
+}
+
+// Client 通过 Chat Completions 接口补全
+type Client struct {
+	cfg    Config
+	client *http.Client
+}
+
+// New 创建 Client，类型不支持、地址无效或缺少必需的 API Key 时返回错误
+func New(cfg Config) (*Client, error) {
+	if cfg.Kind == "" {
+		cfg.Kind = KindOpenAI
+	}
+	if !ValidKind(cfg.Kind) {
+		return nil, fmt.Errorf("llm: 不支持的模型服务类型 %q", cfg.Kind)
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if u, err := url.Parse(cfg.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("llm: 无效的服务地址 %q", cfg.BaseURL)
+	}
+	if cfg.Model == "" {
+		return nil, errors.New("llm: 缺少模型名")
+	}
+	if cfg.APIKey == "" && cfg.Kind != KindLocal {
+		return nil, errors.New("llm: 缺少 API Key")
+	}
+	if cfg.Kind == KindAzure && cfg.APIVersion == "" {
+		cfg.APIVersion = DefaultAzureAPIVersion
+	}
+	if cfg.MaxRequestBytes <= 0 {
+		cfg.MaxRequestBytes = DefaultMaxRequestBytes
+	}
+	if cfg.MaxResponseBytes <= 0 {
+		cfg.MaxResponseBytes = DefaultMaxResponseBytes
+	}
+	return &Client{cfg: cfg, client: &http.Client{Timeout: requestTimeout}}, nil
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model         string         `json:"model,omitempty"` // Azure 的模型由部署决定
+	Messages      []chatMessage  `json:"messages"`
+	Temperature   float64        `json:"temperature"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *streamOptions `json:"stream_options,omitempty"`
+}
+
+// streamOptions 流式输出时要求在最后一个事件中返回用量
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type chatError struct {
+	Message string `json:"message"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+	Error *chatError `json:"error"`
+}
+
+// chatChunk 流式输出的一个事件
+type chatChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+	Error *chatError `json:"error"`
+}
+
+// Complete 发送系统提示词和用户输入，返回第一个候选回复的内容和用量
+func (c *Client) Complete(ctx context.Context, req Request) (*Response, error) {
+	resp, body, err := c.send(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 多读一个字节以区分恰好达到上限和超出上限
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(c.cfg.MaxResponseBytes)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > c.cfg.MaxResponseBytes {
+		return nil, fmt.Errorf("%w: 上限 %d 字节", ErrResponseTooLarge, c.cfg.MaxResponseBytes)
+	}
+	var reply chatResponse
+	parseErr := json.Unmarshal(data, &reply)
+	if resp.StatusCode/100 != 2 {
+		if parseErr == nil && reply.Error != nil {
+			return nil, fmt.Errorf("llm: %s: %s", resp.Status, reply.Error.Message)
+		}
+		return nil, fmt.Errorf("llm: %s", resp.Status)
+	}
+	if parseErr != nil {
+		return nil, fmt.Errorf("llm: 响应解析失败: %w", parseErr)
+	}
+	if len(reply.Choices) == 0 {
+		return nil, errors.New("llm: 响应中没有候选回复")
+	}
+
+	usage := Usage{RequestBytes: body, ResponseBytes: len(data)}
+	if reply.Usage != nil {
+		usage.PromptTokens, usage.CompletionTokens = reply.Usage.PromptTokens, reply.Usage.CompletionTokens
+	}
+	return &Response{Text: reply.Choices[0].Message.Content, Usage: usage}, nil
+}
+
+// Stream 以流式输出（Server-Sent Events）补全，每收到第一个候选回复的一段增量文本调用一次 onDelta，
+// 结束后返回完整回复和用量。响应体累计超出 MaxResponseBytes 时中止并返回 ErrResponseTooLarge。
+// 服务端不返回流式用量（如部分本地服务）时 Token 数为 0
+func (c *Client) Stream(ctx context.Context, req Request, onDelta func(delta string)) (*Response, error) {
+	resp, body, err := c.send(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, int64(c.cfg.MaxResponseBytes)))
+		var reply chatResponse
+		if json.Unmarshal(data, &reply) == nil && reply.Error != nil {
+			return nil, fmt.Errorf("llm: %s: %s", resp.Status, reply.Error.Message)
+		}
+		return nil, fmt.Errorf("llm: %s", resp.Status)
+	}
+
+	usage := Usage{RequestBytes: body}
+	var text strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), c.cfg.MaxResponseBytes+1)
+	done := false
+	for !done && scanner.Scan() {
+		line := scanner.Bytes()
+		usage.ResponseBytes += len(line) + 1
+		if usage.ResponseBytes > c.cfg.MaxResponseBytes {
+			return nil, fmt.Errorf("%w: 上限 %d 字节", ErrResponseTooLarge, c.cfg.MaxResponseBytes)
+		}
+		data, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			continue // 空行、注释和 event: 等字段
+		}
+		data = bytes.TrimSpace(data)
+		if string(data) == "[DONE]" {
+			done = true
+			continue
+		}
+		var chunk chatChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return nil, fmt.Errorf("llm: 流式响应解析失败: %w", err)
+		}
+		if chunk.Error != nil {
+			return nil, fmt.Errorf("llm: %s", chunk.Error.Message)
+		}
+		if chunk.Usage != nil {
+			usage.PromptTokens, usage.CompletionTokens = chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			text.WriteString(chunk.Choices[0].Delta.Content)
+			onDelta(chunk.Choices[0].Delta.Content)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("%w: 上限 %d 字节", ErrResponseTooLarge, c.cfg.MaxResponseBytes)
+		}
+		return nil, err
+	}
+	if !done && text.Len() == 0 {
+		return nil, errors.New("llm: 流式响应中没有候选回复")
+	}
+	return &Response{Text: text.String(), Usage: usage}, nil
+}
+
+// send 构造并发送 Chat Completions 请求，返回响应和请求体字节数；调用方负责关闭响应体
+func (c *Client) send(ctx context.Context, req Request, stream bool) (*http.Response, int, error) {
+	messages := make([]chatMessage, 0, 2)
+	if req.System != "" {
+		messages = append(messages, chatMessage{Role: "system", Content: req.System})
+	}
+	messages = append(messages, chatMessage{Role: "user", Content: req.Prompt})
+	chat := chatRequest{Messages: messages, Temperature: temperature}
+	if c.cfg.Kind != KindAzure {
+		chat.Model = c.cfg.Model
+	}
+	if stream {
+		chat.Stream = true
+		chat.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+	body, err := json.Marshal(chat)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(body) > c.cfg.MaxRequestBytes {
+		return nil, 0, fmt.Errorf("%w: %d 字节，上限 %d", ErrRequestTooLarge, len(body), c.cfg.MaxRequestBytes)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint(), bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	switch {
+	case c.cfg.Kind == KindAzure:
+		httpReq.Header.Set("api-key", c.cfg.APIKey)
+	case c.cfg.APIKey != "":
+		httpReq.Header.Set("Authorization", "Bearer "+c.cfg.APIKey)
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp, len(body), nil
+}
+
+// endpoint 返回 Chat Completions 接口地址
+func (c *Client) endpoint() string {
+	if c.cfg.Kind == KindAzure {
+		return c.cfg.BaseURL + "/openai/deployments/" + url.PathEscape(c.cfg.Model) +
+			"/chat/completions?api-version=" + url.QueryEscape(c.cfg.APIVersion)
+	}
+	return c.cfg.BaseURL + "/chat/completions"
+}
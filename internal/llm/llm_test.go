@@ -0,0 +1,170 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== Chat Completions 客户端单元测试 ==========
+
+func TestClient_Complete(t *testing.T) {
+	// 测试场景：请求发往 <BaseURL>/chat/completions，带 Bearer 密钥、模型和系统 / 用户消息，返回第一个候选回复和用量
+
+	var got chatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"[]"}},{"message":{"role":"assistant","content":"ignored"}}],
+			"usage":{"prompt_tokens":12,"completion_tokens":3}}`))
+	}))
+	defer server.Close()
+
+	p, err := New(Config{BaseURL: server.URL + "/v1/", APIKey: "sk-test", Model: "gpt-4o-mini"})
+	require.NoError(t, err)
+	reply, err := p.Complete(context.Background(), Request{System: "只输出 JSON", Prompt: "添加一个按钮"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "[]", reply.Text)
+	assert.Equal(t, 12, reply.Usage.PromptTokens)
+	assert.Equal(t, 3, reply.Usage.CompletionTokens)
+	assert.Positive(t, reply.Usage.RequestBytes)
+	assert.Positive(t, reply.Usage.ResponseBytes)
+	assert.Equal(t, "gpt-4o-mini", got.Model)
+	assert.Equal(t, []chatMessage{{Role: "system", Content: "只输出 JSON"}, {Role: "user", Content: "添加一个按钮"}}, got.Messages)
+}
+
+func TestClient_CompleteAzureAndLocal(t *testing.T) {
+	// 测试场景：Azure 请求发往部署地址并带 api-version 和 api-key 头，请求体不带模型；本地服务没有密钥时不带认证头
+
+	var path, query, apiKey, auth string
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query, apiKey, auth = r.URL.Path, r.URL.RawQuery, r.Header.Get("api-key"), r.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	azure, err := New(Config{Kind: KindAzure, BaseURL: server.URL, APIKey: "az-key", Model: "my-gpt4o"})
+	require.NoError(t, err)
+	_, err = azure.Complete(context.Background(), Request{Prompt: "hi"})
+	require.NoError(t, err)
+	assert.Equal(t, "/openai/deployments/my-gpt4o/chat/completions", path)
+	assert.Equal(t, "api-version="+DefaultAzureAPIVersion, query)
+	assert.Equal(t, "az-key", apiKey)
+	assert.Empty(t, auth)
+	assert.NotContains(t, got, "model")
+
+	local, err := New(Config{Kind: KindLocal, BaseURL: server.URL + "/v1", Model: "llama3"})
+	require.NoError(t, err)
+	_, err = local.Complete(context.Background(), Request{Prompt: "hi"})
+	require.NoError(t, err)
+	assert.Equal(t, "/v1/chat/completions", path)
+	assert.Empty(t, auth)
+	assert.Equal(t, "llama3", got["model"])
+}
+
+func TestClient_CompleteErrors(t *testing.T) {
+	// 测试场景：非 2xx 响应带上服务端的错误信息；没有候选回复时返回错误
+
+	status := http.StatusUnauthorized
+	body := `{"error":{"message":"Incorrect API key provided"}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	p, err := New(Config{BaseURL: server.URL, APIKey: "bad", Model: "m"})
+	require.NoError(t, err)
+	_, err = p.Complete(context.Background(), Request{Prompt: "hi"})
+	assert.ErrorContains(t, err, "Incorrect API key provided")
+
+	status, body = http.StatusOK, `{"choices":[]}`
+	_, err = p.Complete(context.Background(), Request{Prompt: "hi"})
+	assert.Error(t, err)
+}
+
+func TestClient_Stream(t *testing.T) {
+	// 测试场景：流式请求带 stream 和 include_usage，按顺序回调增量文本，返回拼接后的回复和最后一个事件中的用量；
+	// 流式响应超出大小上限时中止
+
+	var got chatRequest
+	events := "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n" +
+		": keep-alive\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"content\":\"[{\\\"op\\\"\"}}]}\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"content\":\":1}]\"}}]}\n\n" +
+		"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":20,\"completion_tokens\":4}}\n\n" +
+		"data: [DONE]\n\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(events))
+	}))
+	defer server.Close()
+
+	p, err := New(Config{BaseURL: server.URL, APIKey: "k", Model: "m"})
+	require.NoError(t, err)
+	var deltas []string
+	reply, err := p.Stream(context.Background(), Request{Prompt: "hi"}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	require.NoError(t, err)
+	assert.True(t, got.Stream)
+	require.NotNil(t, got.StreamOptions)
+	assert.True(t, got.StreamOptions.IncludeUsage)
+	assert.Equal(t, []string{`[{"op"`, `:1}]`}, deltas)
+	assert.Equal(t, `[{"op":1}]`, reply.Text)
+	assert.Equal(t, 20, reply.Usage.PromptTokens)
+	assert.Equal(t, 4, reply.Usage.CompletionTokens)
+	assert.Positive(t, reply.Usage.ResponseBytes)
+
+	small, err := New(Config{BaseURL: server.URL, APIKey: "k", Model: "m", Limits: Limits{MaxResponseBytes: 100}})
+	require.NoError(t, err)
+	_, err = small.Stream(context.Background(), Request{Prompt: "hi"}, func(string) {})
+	assert.ErrorIs(t, err, ErrResponseTooLarge)
+}
+
+func TestClient_Limits(t *testing.T) {
+	// 测试场景：请求体超出上限时不发送；响应体超出上限时返回 ErrResponseTooLarge
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"` + strings.Repeat("x", 200) + `"}}]}`))
+	}))
+	defer server.Close()
+
+	p, err := New(Config{BaseURL: server.URL, APIKey: "k", Model: "m", Limits: Limits{MaxRequestBytes: 100, MaxResponseBytes: 100}})
+	require.NoError(t, err)
+	_, err = p.Complete(context.Background(), Request{Prompt: strings.Repeat("长", 100)})
+	assert.ErrorIs(t, err, ErrRequestTooLarge)
+	assert.Equal(t, 0, calls)
+
+	_, err = p.Complete(context.Background(), Request{Prompt: "hi"})
+	assert.ErrorIs(t, err, ErrResponseTooLarge)
+	assert.Equal(t, 1, calls)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	// 测试场景：不支持的类型、无效地址、缺少模型名，以及非本地服务缺少 API Key 时拒绝
+
+	for name, cfg := range map[string]Config{
+		"kind":   {Kind: "bedrock", BaseURL: "https://x", APIKey: "k", Model: "m"},
+		"url":    {BaseURL: "ftp://x", APIKey: "k", Model: "m"},
+		"model":  {BaseURL: "https://x", APIKey: "k"},
+		"apiKey": {Kind: KindAzure, BaseURL: "https://x", Model: "m"},
+	} {
+		_, err := New(cfg)
+		assert.Error(t, err, name)
+	}
+}
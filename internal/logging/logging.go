@@ -0,0 +1,235 @@
+// Package logging 为标准库 log 增加日志级别、JSON 格式和输出目的地（stdout、轮转文件、syslog）。
+//
+// 现有代码中的 log.Printf 视为 info 级别；需要区分级别的地方使用 Debugf / Warnf / Errorf，
+// 它们同样经由标准库 log 输出，由 Setup 安装的 Writer 统一过滤和格式化。
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Level 日志级别
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = [...]string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return "info"
+	}
+	return levelNames[l]
+}
+
+// ParseLevel 解析 debug / info / warn / error（不区分大小写）
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("未知的日志级别: %q", s)
+}
+
+// 级别标记，由 Debugf 等函数写在消息开头，Writer 据此判断级别后去掉。
+// 未安装 Writer 时标记原样输出，仍然可读。
+var levelTags = map[Level]string{
+	LevelDebug: "DEBUG ",
+	LevelWarn:  "WARN ",
+	LevelError: "ERROR ",
+}
+
+// 输出格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// 输出目的地
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+	OutputSyslog = "syslog"
+)
+
+// Config 日志配置
+type Config struct {
+	Level  Level
+	Format string // text / json
+	Output string // stdout / stderr / file / syslog
+
+	// Output 为 file 时生效
+	File       string // 日志文件路径
+	MaxSizeMB  int    // 单个文件达到该大小后轮转
+	MaxBackups int    // 保留的历史文件个数
+}
+
+// minLevel 当前生效的最低级别，Setup 之前不过滤
+var minLevel = LevelDebug
+
+// Setup 按配置替换标准库 log 的输出，返回的 Closer 在进程退出前关闭文件或 syslog 连接
+func Setup(cfg Config) (io.Closer, error) {
+	if cfg.Format != FormatText && cfg.Format != FormatJSON {
+		return nil, fmt.Errorf("未知的日志格式: %q", cfg.Format)
+	}
+
+	var out sink
+	switch cfg.Output {
+	case OutputStdout:
+		out = &streamSink{w: os.Stdout, timestamps: true}
+	case OutputStderr:
+		out = &streamSink{w: os.Stderr, timestamps: true}
+	case OutputFile:
+		f, err := openRotatingFile(cfg.File, int64(cfg.MaxSizeMB)<<20, cfg.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		out = &streamSink{w: f, timestamps: true, closer: f}
+	case OutputSyslog:
+		s, err := newSyslogSink()
+		if err != nil {
+			return nil, err
+		}
+		out = s
+	default:
+		return nil, fmt.Errorf("未知的日志输出: %q", cfg.Output)
+	}
+
+	minLevel = cfg.Level
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(&Writer{min: cfg.Level, format: cfg.Format, out: out, now: time.Now})
+	return out, nil
+}
+
+// Enabled 返回某个级别当前是否会输出
+func Enabled(level Level) bool {
+	return level >= minLevel
+}
+
+// Debugf 输出调试日志，如每条 Patch 的处理结果
+func Debugf(format string, args ...interface{}) {
+	if Enabled(LevelDebug) {
+		output(LevelDebug, format, args...)
+	}
+}
+
+// Warnf 输出警告日志：可自动恢复的异常，如丢弃消息、降级处理
+func Warnf(format string, args ...interface{}) {
+	output(LevelWarn, format, args...)
+}
+
+// Errorf 输出错误日志：需要人工关注的失败，如刷盘、写库失败
+func Errorf(format string, args ...interface{}) {
+	output(LevelError, format, args...)
+}
+
+// Fatalf 输出错误日志后退出进程。Setup 之后应使用它代替 log.Fatalf，
+// 否则在 LOG_LEVEL=error 时退出原因会被过滤掉。
+func Fatalf(format string, args ...interface{}) {
+	output(LevelError, format, args...)
+	os.Exit(1)
+}
+
+func output(level Level, format string, args ...interface{}) {
+	log.Output(3, levelTags[level]+fmt.Sprintf(format, args...))
+}
+
+// sink 日志的最终去向
+type sink interface {
+	io.Closer
+	write(level Level, line []byte) error
+	stamped() bool // 是否需要由 Writer 添加时间戳（syslog 自带时间）
+}
+
+// Writer 安装到标准库 log 的输出，负责级别过滤和格式化
+type Writer struct {
+	min    Level
+	format string
+	out    sink
+	now    func() time.Time
+}
+
+// Write 实现 io.Writer，p 为标准库 log 输出的一行
+func (w *Writer) Write(p []byte) (int, error) {
+	level, msg := splitLevel(bytes.TrimRight(p, "\n"))
+	if level < w.min {
+		return len(p), nil
+	}
+	if err := w.out.write(level, w.render(level, msg)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// render 按格式生成一行日志（含换行）
+func (w *Writer) render(level Level, msg []byte) []byte {
+	if w.format == FormatJSON {
+		entry := struct {
+			Time  string `json:"time,omitempty"`
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}{Level: level.String(), Msg: string(msg)}
+		if w.out.stamped() {
+			entry.Time = w.now().Format(time.RFC3339Nano)
+		}
+		line, _ := json.Marshal(entry)
+		return append(line, '\n')
+	}
+
+	var buf bytes.Buffer
+	if w.out.stamped() {
+		buf.WriteString(w.now().Format("2006/01/02 15:04:05 "))
+	}
+	if tag, ok := levelTags[level]; ok {
+		buf.WriteString(tag)
+	}
+	buf.Write(msg)
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// splitLevel 识别并去掉消息开头的级别标记，没有标记的视为 info
+func splitLevel(p []byte) (Level, []byte) {
+	for level, tag := range levelTags {
+		if bytes.HasPrefix(p, []byte(tag)) {
+			return level, p[len(tag):]
+		}
+	}
+	return LevelInfo, p
+}
+
+// streamSink 写入 stdout、stderr 或轮转文件
+type streamSink struct {
+	w          io.Writer
+	timestamps bool
+	closer     io.Closer
+}
+
+func (s *streamSink) write(_ Level, line []byte) error {
+	_, err := s.w.Write(line)
+	return err
+}
+
+func (s *streamSink) stamped() bool { return s.timestamps }
+
+func (s *streamSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
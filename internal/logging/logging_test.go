@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 日志单元测试 ==========
+
+func newTestWriter(min Level, format string) (*Writer, *bytes.Buffer) {
+	var buf bytes.Buffer
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return &Writer{
+		min:    min,
+		format: format,
+		out:    &streamSink{w: &buf, timestamps: true},
+		now:    func() time.Time { return now },
+	}, &buf
+}
+
+func TestWriter_FiltersByLevel(t *testing.T) {
+	// 测试场景：低于最低级别的日志被丢弃，未标记级别的视为 info
+
+	w, buf := newTestWriter(LevelInfo, FormatText)
+
+	w.Write([]byte("DEBUG [Client] Patch 已应用\n"))
+	w.Write([]byte("[Hub] 已启动\n"))
+	w.Write([]byte("ERROR [Room p1] 刷盘失败\n"))
+
+	assert.Equal(t, "2024/01/02 03:04:05 [Hub] 已启动\n2024/01/02 03:04:05 ERROR [Room p1] 刷盘失败\n", buf.String())
+}
+
+func TestWriter_JSON(t *testing.T) {
+	// 测试场景：JSON 格式输出时间、级别和去掉级别标记后的消息
+
+	w, buf := newTestWriter(LevelDebug, FormatJSON)
+	w.Write([]byte("WARN [OpLog] 队列已满\n"))
+
+	var entry map[string]string
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "warn", entry["level"])
+	assert.Equal(t, "[OpLog] 队列已满", entry["msg"])
+	assert.Equal(t, "2024-01-02T03:04:05Z", entry["time"])
+}
+
+func TestParseLevel(t *testing.T) {
+	// 测试场景：级别名不区分大小写，未知级别报错
+
+	level, err := ParseLevel("WARN")
+	assert.NoError(t, err)
+	assert.Equal(t, LevelWarn, level)
+
+	_, err = ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestRotatingFile(t *testing.T) {
+	// 测试场景：超过大小上限时轮转，只保留 maxBackups 个历史文件
+
+	path := filepath.Join(t.TempDir(), "server.log")
+	f, err := openRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	defer f.Close()
+
+	for _, line := range []string{"first-1\n", "second2\n", "third-3\n", "fourth4\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	read := func(p string) string {
+		data, err := os.ReadFile(p)
+		require.NoError(t, err)
+		return string(data)
+	}
+	assert.Equal(t, "fourth4\n", read(path))
+	assert.Equal(t, "third-3\n", read(path+".1"))
+	assert.Equal(t, "second2\n", read(path+".2"))
+	assert.NoFileExists(t, path+".3")
+}
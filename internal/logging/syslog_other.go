@@ -0,0 +1,9 @@
+//go:build windows || plan9
+
+package logging
+
+import "errors"
+
+func newSyslogSink() (sink, error) {
+	return nil, errors.New("当前平台不支持 syslog")
+}
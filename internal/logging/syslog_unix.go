@@ -0,0 +1,36 @@
+//go:build !windows && !plan9
+
+package logging
+
+import "log/syslog"
+
+// syslogSink 写入本机 syslog，级别映射为对应的 syslog 优先级
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+func newSyslogSink() (sink, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, "lowcode-go-server")
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) write(level Level, line []byte) error {
+	msg := string(line)
+	switch level {
+	case LevelDebug:
+		return s.w.Debug(msg)
+	case LevelWarn:
+		return s.w.Warning(msg)
+	case LevelError:
+		return s.w.Err(msg)
+	default:
+		return s.w.Info(msg)
+	}
+}
+
+func (s *syslogSink) stamped() bool { return false }
+
+func (s *syslogSink) Close() error { return s.w.Close() }
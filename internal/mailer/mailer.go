@@ -0,0 +1,114 @@
+// Package mailer 发送通知邮件。
+// SMTP 通过标准库 net/smtp 发送（服务器支持时自动 STARTTLS）；Log 只把邮件内容写入日志，
+// 供开发环境和未配置 SMTP 的部署使用。调用方依赖 Mailer 接口，可以替换为其他邮件服务
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message 一封纯文本邮件
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// SMTPConfig SMTP 服务配置
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // 为空时不认证
+	Password string
+	From     string // 发件人地址，可带显示名，如 "LowCode <noreply@example.com>"
+}
+
+// SMTP 通过 SMTP 服务器发送邮件
+type SMTP struct {
+	cfg  SMTPConfig
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	now  func() time.Time
+}
+
+// NewSMTP 创建 SMTP 发送器
+func NewSMTP(cfg SMTPConfig) *SMTP {
+	return &SMTP{cfg: cfg, send: smtp.SendMail, now: time.Now}
+}
+
+// Send 发送邮件，收件人或标题包含换行时拒绝，防止头部注入
+func (s *SMTP) Send(msg Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return errors.New("mailer: 收件人或标题包含换行")
+	}
+	from, err := parseAddress(s.cfg.From)
+	if err != nil {
+		return fmt.Errorf("mailer: 发件人地址无效: %w", err)
+	}
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	return s.send(addr, auth, from, []string{msg.To}, s.build(msg))
+}
+
+// build 生成 RFC 5322 邮件：UTF-8 标题按 RFC 2047 编码，正文 base64 编码并按 76 列换行
+func (s *SMTP) build(msg Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", s.now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	body := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(body) > 76 {
+		buf.WriteString(body[:76] + "\r\n")
+		body = body[76:]
+	}
+	buf.WriteString(body + "\r\n")
+	return buf.Bytes()
+}
+
+// parseAddress 取出 "显示名 <地址>" 中的地址
+func parseAddress(from string) (string, error) {
+	if start := strings.LastIndex(from, "<"); start >= 0 {
+		end := strings.LastIndex(from, ">")
+		if end < start {
+			return "", fmt.Errorf("%q", from)
+		}
+		from = from[start+1 : end]
+	}
+	from = strings.TrimSpace(from)
+	if !strings.Contains(from, "@") {
+		return "", fmt.Errorf("%q", from)
+	}
+	return from, nil
+}
+
+// Log 只把邮件写入日志，不实际发送
+type Log struct{}
+
+// Send 记录邮件收件人、标题和正文
+func (Log) Send(msg Message) error {
+	log.Printf("[Mailer] 未配置 SMTP，邮件未发送: to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
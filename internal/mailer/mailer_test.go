@@ -0,0 +1,74 @@
+package mailer
+
+import (
+	"encoding/base64"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== SMTP 单元测试 ==========
+
+func TestSMTP_Send(t *testing.T) {
+	// 测试场景：按配置的地址和发件人发送，标题按 RFC 2047 编码，正文 base64 编码且每行不超过 76 列；
+	// 配置了用户名时使用 PLAIN 认证
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotAuth smtp.Auth
+	var gotMsg []byte
+	s := NewSMTP(SMTPConfig{Host: "smtp.example.com", Port: 587, Username: "u", Password: "p", From: "LowCode <noreply@example.com>"})
+	s.now = func() time.Time { return time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC) }
+	s.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotFrom, gotTo, gotMsg = addr, a, from, to, msg
+		return nil
+	}
+
+	body := strings.Repeat("邀请你协作编辑页面。", 10)
+	err := s.Send(Message{To: "bob@example.com", Subject: "协作邀请", Body: body})
+	assert.NoError(t, err)
+	assert.Equal(t, "smtp.example.com:587", gotAddr)
+	assert.Equal(t, "noreply@example.com", gotFrom)
+	assert.Equal(t, []string{"bob@example.com"}, gotTo)
+	assert.NotNil(t, gotAuth)
+
+	header, encoded, ok := strings.Cut(string(gotMsg), "\r\n\r\n")
+	assert.True(t, ok)
+	assert.Contains(t, header, "From: LowCode <noreply@example.com>\r\n")
+	assert.Contains(t, header, "To: bob@example.com\r\n")
+	assert.Contains(t, header, "Subject: =?UTF-8?b?")
+	assert.Contains(t, header, "Date: Thu, 15 Jan 2026 10:30:00 +0000\r\n")
+
+	lines := strings.Split(strings.TrimSuffix(encoded, "\r\n"), "\r\n")
+	for _, line := range lines {
+		assert.LessOrEqual(t, len(line), 76)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.Join(lines, ""))
+	assert.NoError(t, err)
+	assert.Equal(t, body, string(decoded))
+}
+
+func TestSMTP_SendRejectsInvalid(t *testing.T) {
+	// 测试场景：收件人或标题包含换行、发件人地址无效时拒绝，不连接服务器；未配置用户名时不认证
+
+	called := false
+	s := NewSMTP(SMTPConfig{Host: "localhost", Port: 25, From: "noreply@example.com"})
+	s.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		called = true
+		assert.Nil(t, a)
+		return nil
+	}
+
+	assert.Error(t, s.Send(Message{To: "bob@example.com\r\nBcc: eve@example.com", Subject: "hi"}))
+	assert.Error(t, s.Send(Message{To: "bob@example.com", Subject: "hi\nBcc: eve@example.com"}))
+	assert.False(t, called)
+
+	assert.NoError(t, s.Send(Message{To: "bob@example.com", Subject: "hi"}))
+	assert.True(t, called)
+
+	s.cfg.From = "LowCode"
+	assert.Error(t, s.Send(Message{To: "bob@example.com", Subject: "hi"}))
+}
@@ -0,0 +1,204 @@
+// Package merge 对页面 Schema 做三方合并：以分叉时的 Schema 为共同祖先，
+// 把分支上的修改移植到目标页面的最新状态上（rebase）。
+//
+// 合并的最小单位是组件的一个字段（/components/{id}/{field}），整体新增或删除的组件、
+// 以及 components 之外的顶层字段各自作为一个单位。数组不按下标合并，
+// 避免双方在同一个 children 中插入、删除元素时下标错位。
+package merge
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"lowercode-go-server/internal/jsondiff"
+)
+
+// ignoredKeys 不参与合并的顶层字段：editedBy 由服务端在应用 Patch 时维护，合并后由目标房间重新生成
+var ignoredKeys = map[string]bool{"editedBy": true}
+
+// Result 三方合并结果
+type Result struct {
+	Changes   []string // theirs 相对共同祖先修改的单位（JSON Pointer），已排序
+	Conflicts []string // 双方都修改且结果不同的单位，Merged 中保留 ours 的值
+	Merged    []byte   // ours 加上 theirs 中不冲突的修改
+	Theirs    []byte   // ours 加上 theirs 的全部修改，冲突单位取 theirs 的值
+}
+
+// ThreeWay 以 base 为共同祖先，把 theirs 相对 base 的修改合并到 ours 上
+func ThreeWay(base, ours, theirs []byte) (*Result, error) {
+	theirsUnits, err := changedUnits(base, theirs)
+	if err != nil {
+		return nil, err
+	}
+	oursUnits, err := changedUnits(base, ours)
+	if err != nil {
+		return nil, err
+	}
+	oursDoc, err := decode(ours)
+	if err != nil {
+		return nil, err
+	}
+	theirsDoc, err := decode(theirs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Changes: make([]string, 0, len(theirsUnits)), Conflicts: []string{}}
+	var conflicting [][]string
+	for _, unit := range theirsUnits {
+		result.Changes = append(result.Changes, jsondiff.Pointer(unit...))
+		if conflicts(unit, oursUnits, oursDoc, theirsDoc) || !copyUnit(oursDoc, theirsDoc, unit) {
+			result.Conflicts = append(result.Conflicts, jsondiff.Pointer(unit...))
+			conflicting = append(conflicting, unit)
+		}
+	}
+
+	result.Merged, err = json.Marshal(oursDoc)
+	if err != nil {
+		return nil, err
+	}
+
+	// 冲突单位改用 theirs 的值；ours 删除了所在组件时，连同组件一起取 theirs 的
+	for _, unit := range conflicting {
+		for n := len(unit); n > 0; n-- {
+			if copyUnit(oursDoc, theirsDoc, unit[:n]) {
+				break
+			}
+		}
+	}
+	result.Theirs, err = json.Marshal(oursDoc)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// changedUnits 返回 to 相对 from 修改的合并单位，已去重、排序
+func changedUnits(from, to []byte) ([][]string, error) {
+	ops, err := jsondiff.Diff(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var units [][]string
+	for _, op := range ops {
+		unit := unitOf(op.Path)
+		if len(unit) > 0 && ignoredKeys[unit[0]] {
+			continue
+		}
+		key := jsondiff.Pointer(unit...)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		units = append(units, unit)
+	}
+	sort.Slice(units, func(i, j int) bool {
+		return jsondiff.Pointer(units[i]...) < jsondiff.Pointer(units[j]...)
+	})
+	return units, nil
+}
+
+// unitOf 将 Patch 路径截断为合并单位：/components 下最多保留组件 ID 和字段名，其余顶层字段只保留字段名
+func unitOf(path string) []string {
+	if path == "" {
+		return []string{}
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+
+	depth := 1
+	if tokens[0] == "components" {
+		depth = 3
+	}
+	if len(tokens) > depth {
+		tokens = tokens[:depth]
+	}
+	return tokens
+}
+
+// conflicts theirs 的单位是否与 ours 修改过的单位重叠（相同或互为前缀）且结果不同
+func conflicts(unit []string, oursUnits [][]string, oursDoc, theirsDoc interface{}) bool {
+	for _, other := range oursUnits {
+		if !overlaps(unit, other) {
+			continue
+		}
+		if len(unit) == len(other) {
+			oursValue, oursOK := lookup(oursDoc, unit)
+			theirsValue, theirsOK := lookup(theirsDoc, unit)
+			if oursOK == theirsOK && reflect.DeepEqual(oursValue, theirsValue) {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// overlaps 两个单位是否相同或其中一个是另一个的前缀
+func overlaps(a, b []string) bool {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// copyUnit 把 src 中 unit 的值（不存在时为删除）写入 dst，父级不是对象时返回 false
+func copyUnit(dst, src interface{}, unit []string) bool {
+	if len(unit) == 0 {
+		return false
+	}
+	parent, ok := lookup(dst, unit[:len(unit)-1])
+	if !ok {
+		return false
+	}
+	obj, ok := parent.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	key := unit[len(unit)-1]
+	if value, ok := lookup(src, unit); ok {
+		obj[key] = value
+	} else {
+		delete(obj, key)
+	}
+	return true
+}
+
+// lookup 沿对象 key 逐层取值
+func lookup(doc interface{}, tokens []string) (interface{}, bool) {
+	current := doc
+	for _, t := range tokens {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = obj[t]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
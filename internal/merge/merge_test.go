@@ -0,0 +1,79 @@
+package merge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== ThreeWay 单元测试 ==========
+
+func TestThreeWay_MergesDisjointChanges(t *testing.T) {
+	// 测试场景：双方修改不同组件或同一组件的不同字段时全部合并；新增、删除组件按整体移植；
+	// 双方改成相同的值不算冲突；editedBy 不参与合并
+
+	base := `{"rootId":1,"components":{
+		"1":{"name":"Page","children":[2,3]},
+		"2":{"name":"Button","props":{"text":"a"},"styles":{}},
+		"3":{"name":"Text","props":{}}},
+		"editedBy":{}}`
+	ours := `{"rootId":1,"components":{
+		"1":{"name":"Page","children":[2,3]},
+		"2":{"name":"Button","props":{"text":"a"},"styles":{"color":"red"}},
+		"3":{"name":"Text","props":{"same":true}}},
+		"editedBy":{"2":{"userId":"bob"}}}`
+	theirs := `{"rootId":1,"components":{
+		"1":{"name":"Page","children":[2,4]},
+		"2":{"name":"Button","props":{"text":"b"},"styles":{}},
+		"3":{"name":"Text","props":{"same":true}},
+		"4":{"name":"Image"}},
+		"editedBy":{"2":{"userId":"alice"}}}`
+
+	result, err := ThreeWay([]byte(base), []byte(ours), []byte(theirs))
+	assert.NoError(t, err)
+	assert.Empty(t, result.Conflicts)
+	assert.Equal(t, []string{"/components/1/children", "/components/2/props", "/components/3/props", "/components/4"}, result.Changes)
+	assert.JSONEq(t, `{"rootId":1,"components":{
+		"1":{"name":"Page","children":[2,4]},
+		"2":{"name":"Button","props":{"text":"b"},"styles":{"color":"red"}},
+		"3":{"name":"Text","props":{"same":true}},
+		"4":{"name":"Image"}},
+		"editedBy":{"2":{"userId":"bob"}}}`, string(result.Merged))
+}
+
+func TestThreeWay_Conflicts(t *testing.T) {
+	// 测试场景：双方修改同一字段、一方删除另一方修改的组件时报告冲突，合并结果保留 ours
+
+	base := `{"components":{"1":{"children":[2]},"2":{"props":{"text":"a"}},"3":{"props":{}}}}`
+	ours := `{"components":{"1":{"children":[2]},"2":{"props":{"text":"ours"}}}}`
+	theirs := `{"components":{"1":{"children":[2]},"2":{"props":{"text":"theirs"}},"3":{"props":{"x":1}}}}`
+
+	result, err := ThreeWay([]byte(base), []byte(ours), []byte(theirs))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/components/2/props", "/components/3/props"}, result.Conflicts)
+	assert.JSONEq(t, ours, string(result.Merged))
+}
+
+func TestThreeWay_Theirs(t *testing.T) {
+	// 测试场景：Theirs 中冲突单位取 theirs 的值，ours 已删除的组件连同组件一起恢复；
+	// 不冲突的 ours 修改仍然保留
+
+	base := `{"components":{"1":{"children":[2,3]},"2":{"props":{"text":"a"}},"3":{"props":{}}}}`
+	ours := `{"components":{"1":{"children":[2,3]},"2":{"props":{"text":"ours"},"styles":{"color":"red"}}}}`
+	theirs := `{"components":{"1":{"children":[2,3]},"2":{"props":{"text":"theirs"}},"3":{"props":{"x":1}}}}`
+
+	result, err := ThreeWay([]byte(base), []byte(ours), []byte(theirs))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/components/2/props", "/components/3/props"}, result.Conflicts)
+	assert.JSONEq(t, `{"components":{
+		"1":{"children":[2,3]},
+		"2":{"props":{"text":"theirs"},"styles":{"color":"red"}},
+		"3":{"props":{"x":1}}}}`, string(result.Theirs))
+}
+
+func TestThreeWay_InvalidJSON(t *testing.T) {
+	// 测试场景：任一输入不是合法 JSON 时返回错误
+
+	_, err := ThreeWay([]byte(`{}`), []byte(`{`), []byte(`{}`))
+	assert.Error(t, err)
+}
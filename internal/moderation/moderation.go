@@ -0,0 +1,240 @@
+// Package moderation 检查用户输入的文本是否包含不允许的内容。
+// Wordlist 按词表匹配，API 调用外部审核服务，Chain 依次组合多个过滤器；
+// 命中后如何处理（拒绝、记录、通知）由调用方决定
+package moderation
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// DefaultAPITimeout 外部审核服务的默认超时时间，审核在用户发送消息的路径上同步执行
+	DefaultAPITimeout = 2 * time.Second
+
+	maxAPIResponseBytes = 64 << 10 // 审核服务响应体上限
+)
+
+// 命中后的处理方式
+const (
+	ActionReject = "reject" // 拒绝提交
+	ActionFlag   = "flag"   // 保存审核记录，供运维人员复核
+	ActionNotify = "notify" // 邮件通知页面所有者
+)
+
+// Actions 命中后的处理方式，可以同时开启多项
+type Actions struct {
+	Reject bool
+	Flag   bool
+	Notify bool
+}
+
+// ParseActions 解析逗号分隔的处理方式，如 "reject,flag"；为空或包含不支持的值时返回错误
+func ParseActions(raw string) (Actions, error) {
+	var actions Actions
+	for _, name := range strings.Split(raw, ",") {
+		switch strings.TrimSpace(name) {
+		case ActionReject:
+			actions.Reject = true
+		case ActionFlag:
+			actions.Flag = true
+		case ActionNotify:
+			actions.Notify = true
+		case "":
+		default:
+			return Actions{}, fmt.Errorf("moderation: 不支持的处理方式 %q", strings.TrimSpace(name))
+		}
+	}
+	if actions == (Actions{}) {
+		return Actions{}, errors.New("moderation: 至少需要一种处理方式")
+	}
+	return actions, nil
+}
+
+// Verdict 一段文本的审核结果
+type Verdict struct {
+	Flagged bool
+	Reasons []string // 命中的词或审核服务返回的类别，未命中时为空
+}
+
+// Filter 内容过滤器
+type Filter interface {
+	Check(ctx context.Context, text string) (Verdict, error)
+}
+
+// --- 词表 ---
+
+// Wordlist 按词表做子串匹配，忽略大小写、空白和零宽字符，避免"敏 感 词"之类的简单绕过
+type Wordlist struct {
+	terms []string
+}
+
+// NewWordlist 创建词表过滤器，空词和重复的词被忽略
+func NewWordlist(terms []string) *Wordlist {
+	w := &Wordlist{}
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		term = normalize(term)
+		if term == "" || seen[term] {
+			continue
+		}
+		seen[term] = true
+		w.terms = append(w.terms, term)
+	}
+	return w
+}
+
+// LoadWordlist 从文件读取词表，每行一个词，# 开头的行为注释
+func LoadWordlist(path string) (*Wordlist, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var terms []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		terms = append(terms, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewWordlist(terms), nil
+}
+
+// Len 返回词表中的词数
+func (w *Wordlist) Len() int {
+	return len(w.terms)
+}
+
+// Check 返回文本中出现的全部词
+func (w *Wordlist) Check(_ context.Context, text string) (Verdict, error) {
+	text = normalize(text)
+	var verdict Verdict
+	for _, term := range w.terms {
+		if strings.Contains(text, term) {
+			verdict.Flagged = true
+			verdict.Reasons = append(verdict.Reasons, term)
+		}
+	}
+	return verdict, nil
+}
+
+// normalize 转为小写并去掉空白和零宽字符
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+// --- 外部审核服务 ---
+
+// APIConfig 外部审核服务配置
+type APIConfig struct {
+	URL     string
+	APIKey  string        // 非空时以 Bearer 认证
+	Timeout time.Duration // 为 0 时使用 DefaultAPITimeout
+}
+
+// API 调用外部审核服务。请求为 POST {"text": "..."}，
+// 响应为 {"flagged": true, "categories": ["..."]}，非 2xx 响应视为调用失败
+type API struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewAPI 创建外部审核服务过滤器
+func NewAPI(cfg APIConfig) *API {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultAPITimeout
+	}
+	return &API{url: cfg.URL, apiKey: cfg.APIKey, client: &http.Client{Timeout: timeout}}
+}
+
+type apiRequest struct {
+	Text string `json:"text"`
+}
+
+type apiResponse struct {
+	Flagged    bool     `json:"flagged"`
+	Categories []string `json:"categories"`
+}
+
+// Check 把文本发给审核服务
+func (a *API) Check(ctx context.Context, text string) (Verdict, error) {
+	body, _ := json.Marshal(apiRequest{Text: text})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return Verdict{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.apiKey)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("moderation: 调用审核服务失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAPIResponseBytes))
+	if err != nil {
+		return Verdict{}, fmt.Errorf("moderation: 读取审核服务响应失败: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Verdict{}, fmt.Errorf("moderation: 审核服务返回 %d", resp.StatusCode)
+	}
+
+	var result apiResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return Verdict{}, fmt.Errorf("moderation: 审核服务响应格式错误: %w", err)
+	}
+	verdict := Verdict{Flagged: result.Flagged}
+	if result.Flagged {
+		verdict.Reasons = result.Categories
+	}
+	return verdict, nil
+}
+
+// --- 组合 ---
+
+// Chain 依次执行多个过滤器，遇到第一个命中的结果即返回。
+// 某个过滤器失败时继续执行其余的过滤器，都未命中时返回收集到的错误，由调用方决定放行还是拒绝
+type Chain []Filter
+
+// Check 依次检查，返回第一个命中的结果
+func (c Chain) Check(ctx context.Context, text string) (Verdict, error) {
+	var errs []error
+	for _, filter := range c {
+		verdict, err := filter.Check(ctx, text)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if verdict.Flagged {
+			return verdict, nil
+		}
+	}
+	return Verdict{}, errors.Join(errs...)
+}
@@ -0,0 +1,123 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 内容审核单元测试 ==========
+
+func TestWordlist_Check(t *testing.T) {
+	// 测试场景：忽略大小写、空白和零宽字符匹配子串，返回全部命中的词；未命中时不标记
+
+	w := NewWordlist([]string{"Spam", "违禁词", " ", "spam"})
+	assert.Equal(t, 2, w.Len())
+
+	verdict, err := w.Check(context.Background(), "买 SPAM 送违\u200b禁 词")
+	require.NoError(t, err)
+	assert.True(t, verdict.Flagged)
+	assert.Equal(t, []string{"spam", "违禁词"}, verdict.Reasons)
+
+	verdict, err = w.Check(context.Background(), "提交按钮")
+	require.NoError(t, err)
+	assert.False(t, verdict.Flagged)
+	assert.Empty(t, verdict.Reasons)
+}
+
+func TestLoadWordlist(t *testing.T) {
+	// 测试场景：每行一个词，跳过空行和 # 注释；文件不存在时返回错误
+
+	path := filepath.Join(t.TempDir(), "words.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# 广告\nspam\n\n  违禁词  \n"), 0o600))
+
+	w, err := LoadWordlist(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, w.Len())
+
+	_, err = LoadWordlist(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestAPI_Check(t *testing.T) {
+	// 测试场景：以 Bearer 认证发送文本，命中时返回服务端的类别；非 2xx 响应和格式错误返回错误
+
+	status := http.StatusOK
+	body := `{"flagged":true,"categories":["harassment"]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "Bearer mod-key", r.Header.Get("Authorization"))
+		var req apiRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "hello", req.Text)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	api := NewAPI(APIConfig{URL: server.URL, APIKey: "mod-key"})
+	verdict, err := api.Check(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.True(t, verdict.Flagged)
+	assert.Equal(t, []string{"harassment"}, verdict.Reasons)
+
+	body = `{"flagged":false,"categories":["harassment"]}`
+	verdict, err = api.Check(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.False(t, verdict.Flagged)
+	assert.Empty(t, verdict.Reasons)
+
+	status = http.StatusBadGateway
+	_, err = api.Check(context.Background(), "hello")
+	assert.Error(t, err)
+
+	status, body = http.StatusOK, `not json`
+	_, err = api.Check(context.Background(), "hello")
+	assert.Error(t, err)
+}
+
+// failingFilter 总是调用失败的过滤器
+type failingFilter struct{}
+
+func (failingFilter) Check(context.Context, string) (Verdict, error) {
+	return Verdict{}, errors.New("unavailable")
+}
+
+func TestChain_Check(t *testing.T) {
+	// 测试场景：返回第一个命中的结果，前面的过滤器失败不影响后面的；都未命中时返回收集到的错误
+
+	chain := Chain{failingFilter{}, NewWordlist([]string{"spam"})}
+
+	verdict, err := chain.Check(context.Background(), "spam")
+	require.NoError(t, err)
+	assert.True(t, verdict.Flagged)
+
+	verdict, err = chain.Check(context.Background(), "hello")
+	assert.Error(t, err)
+	assert.False(t, verdict.Flagged)
+
+	verdict, err = Chain{NewWordlist([]string{"spam"})}.Check(context.Background(), "hello")
+	assert.NoError(t, err)
+	assert.False(t, verdict.Flagged)
+}
+
+func TestParseActions(t *testing.T) {
+	// 测试场景：逗号分隔、忽略空白；为空或包含不支持的值时返回错误
+
+	actions, err := ParseActions(" reject, notify ")
+	require.NoError(t, err)
+	assert.Equal(t, Actions{Reject: true, Notify: true}, actions)
+
+	_, err = ParseActions("")
+	assert.Error(t, err)
+	_, err = ParseActions("reject,ban")
+	assert.Error(t, err)
+}
@@ -0,0 +1,307 @@
+// Package msgpack 在 JSON 与 MessagePack 之间转换消息，
+// 供 WebSocket 连接协商二进制编码时在连接边界使用，房间内部仍以 JSON 处理消息。
+//
+// 只支持 JSON 能表示的类型：nil、bool、整数、浮点数、字符串、数组和以字符串为 key 的 map。
+// 解码时 bin 类型按 base64 字符串转为 JSON（与 encoding/json 处理 []byte 一致），ext 类型不支持。
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// maxDepth 解码时允许的最大嵌套深度
+const maxDepth = 100
+
+// ErrTruncated 数据在一个值的中间结束
+var ErrTruncated = errors.New("msgpack: 数据不完整")
+
+// FromJSON 把 JSON 文档编码为 MessagePack。
+// 能以 int64 / uint64 表示的数字编码为整数，其余数字编码为 float64。
+func FromJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return appendValue(make([]byte, 0, len(data)), v)
+}
+
+// ToJSON 把一个 MessagePack 值解码为 JSON 文档，值之后有多余字节时返回错误
+func ToJSON(data []byte) ([]byte, error) {
+	d := &decoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("msgpack: 值之后有 %d 个多余字节", len(d.data)-d.pos)
+	}
+	return json.Marshal(v)
+}
+
+func appendValue(b []byte, v interface{}) ([]byte, error) {
+	switch value := v.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if value {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case json.Number:
+		return appendNumber(b, value)
+	case string:
+		return appendString(b, value), nil
+	case []interface{}:
+		b = appendLength(b, len(value), 0x90, 15, 0xdc, 0xdd)
+		for _, item := range value {
+			var err error
+			if b, err = appendValue(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]interface{}:
+		b = appendLength(b, len(value), 0x80, 15, 0xde, 0xdf)
+		for key, item := range value {
+			b = appendString(b, key)
+			var err error
+			if b, err = appendValue(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("msgpack: 不支持的类型 %T", v)
+	}
+}
+
+func appendNumber(b []byte, n json.Number) ([]byte, error) {
+	if i, err := strconv.ParseInt(n.String(), 10, 64); err == nil {
+		return appendInt(b, i), nil
+	}
+	if u, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), u), nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, err
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f)), nil
+}
+
+func appendInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i <= 0x7f:
+		return append(b, byte(i))
+	case i >= -32 && i < 0:
+		return append(b, byte(0xe0|(i+32)))
+	case i >= 0 && i <= math.MaxUint8:
+		return append(b, 0xcc, byte(i))
+	case i >= 0 && i <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(i))
+	case i >= 0 && i <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(i))
+	case i >= 0:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), uint64(i))
+	case i >= math.MinInt8:
+		return append(b, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(i))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i))
+	}
+}
+
+func appendString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n <= 31:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+// appendLength 写入数组或 map 的头部：不超过 fixMax 时使用 fix 格式，否则使用 16 / 32 位长度
+func appendLength(b []byte, n int, fix byte, fixMax int, tag16, tag32 byte) []byte {
+	switch {
+	case n <= fixMax:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, tag16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, tag32), uint32(n))
+	}
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) take(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, ErrTruncated
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *decoder) uint(size int) (uint64, error) {
+	b, err := d.take(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+func (d *decoder) value(depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, errors.New("msgpack: 嵌套层级过深")
+	}
+	head, err := d.take(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag := head[0]; {
+	case tag <= 0x7f:
+		return int64(tag), nil
+	case tag >= 0xe0:
+		return int64(int8(tag)), nil
+	case tag&0xf0 == 0x80:
+		return d.mapOf(int(tag&0x0f), depth)
+	case tag&0xf0 == 0x90:
+		return d.arrayOf(int(tag&0x0f), depth)
+	case tag&0xe0 == 0xa0:
+		return d.str(int(tag & 0x1f))
+	}
+
+	switch tag := head[0]; tag {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (tag - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.take(int(n))
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 0xca:
+		bits, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(bits))), err
+	case 0xcb:
+		bits, err := d.uint(8)
+		return math.Float64frombits(bits), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return d.uint(1 << (tag - 0xcc))
+	case 0xd0:
+		u, err := d.uint(1)
+		return int64(int8(u)), err
+	case 0xd1:
+		u, err := d.uint(2)
+		return int64(int16(u)), err
+	case 0xd2:
+		u, err := d.uint(4)
+		return int64(int32(u)), err
+	case 0xd3:
+		u, err := d.uint(8)
+		return int64(u), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (tag - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (tag - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.arrayOf(int(n), depth)
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (tag - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(int(n), depth)
+	default:
+		return nil, fmt.Errorf("msgpack: 不支持的类型标记 0x%02x", tag)
+	}
+}
+
+func (d *decoder) str(n int) (string, error) {
+	b, err := d.take(n)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (d *decoder) arrayOf(n int, depth int) ([]interface{}, error) {
+	// 每个元素至少占 1 字节，长度超过剩余数据时提前失败，避免按声明长度分配内存
+	if n > len(d.data)-d.pos {
+		return nil, ErrTruncated
+	}
+	list := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		item, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+func (d *decoder) mapOf(n int, depth int) (map[string]interface{}, error) {
+	if n*2 > len(d.data)-d.pos {
+		return nil, ErrTruncated
+	}
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		k, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("msgpack: map 的 key 必须是字符串，实际为 %T", key)
+		}
+		if m[k], err = d.value(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
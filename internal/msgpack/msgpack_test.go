@@ -0,0 +1,114 @@
+package msgpack
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== MessagePack 转换单元测试 ==========
+
+func TestFromJSON_Encoding(t *testing.T) {
+	// 测试场景：各类型按规范选择最紧凑的格式
+
+	tests := []struct {
+		json string
+		hex  string
+	}{
+		{`null`, "c0"},
+		{`true`, "c3"},
+		{`5`, "05"},
+		{`-3`, "fd"},
+		{`200`, "ccc8"},
+		{`-200`, "d1ff38"},
+		{`70000`, "ce00011170"},
+		{`18446744073709551615`, "cfffffffffffffffff"},
+		{`1.5`, "cb3ff8000000000000"},
+		{`"hi"`, "a26869"},
+		{`[1,"a"]`, "9201a161"},
+		{`{"x":1}`, "81a17801"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			out, err := FromJSON([]byte(tt.json))
+			require.NoError(t, err)
+			assert.Equal(t, tt.hex, hex.EncodeToString(out))
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	// 测试场景：JSON → MessagePack → JSON 语义不变，且编码后体积更小
+
+	doc := `{
+		"type": "cursor-move",
+		"senderId": "user_2abc",
+		"payload": {"x": 1024, "y": -12, "scale": 0.75, "visible": true, "target": null,
+		            "path": ["components", "1712", "props"], "label": "光标"},
+		"ts": 1702345678000
+	}`
+
+	packed, err := FromJSON([]byte(doc))
+	require.NoError(t, err)
+	assert.Less(t, len(packed), len(doc))
+
+	back, err := ToJSON(packed)
+	require.NoError(t, err)
+	assert.JSONEq(t, doc, string(back))
+}
+
+func TestToJSON_LongValues(t *testing.T) {
+	// 测试场景：超过 fix 格式上限的字符串和数组使用 8 / 16 位长度
+
+	long := make([]byte, 40)
+	for i := range long {
+		long[i] = 'a'
+	}
+	items := `[0,1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16]`
+	doc := `{"s":"` + string(long) + `","list":` + items + `}`
+
+	packed, err := FromJSON([]byte(doc))
+	require.NoError(t, err)
+	back, err := ToJSON(packed)
+	require.NoError(t, err)
+	assert.JSONEq(t, doc, string(back))
+}
+
+func TestToJSON_Errors(t *testing.T) {
+	// 测试场景：截断、非字符串 key、不支持的类型和多余字节都返回错误
+
+	tests := []struct {
+		name string
+		hex  string
+	}{
+		{"截断的字符串", "a568"},
+		{"声明长度超过数据", "dc0100"},
+		{"非字符串 key", "810101"},
+		{"ext 类型", "d40100"},
+		{"多余字节", "0101"},
+		{"空数据", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := hex.DecodeString(tt.hex)
+			require.NoError(t, err)
+			_, err = ToJSON(data)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestToJSON_ForeignEncodings(t *testing.T) {
+	// 测试场景：其他编码器可能产生的 float32、负数 int8 和 bin 类型也能解码
+
+	data, err := hex.DecodeString("83" + "a166" + "ca3fc00000" + "a16e" + "d080" + "a162" + "c4026869")
+	require.NoError(t, err)
+
+	out, err := ToJSON(data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"f": 1.5, "n": -128, "b": "aGk="}`, string(out))
+}
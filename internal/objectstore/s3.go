@@ -0,0 +1,198 @@
+// Package objectstore 提供写入 S3 兼容对象存储的最小客户端。
+// 只实现归档所需的 PutObject，请求使用 AWS Signature V4 签名，兼容 AWS S3 与 MinIO 等自建服务。
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	signAlgorithm = "AWS4-HMAC-SHA256"
+	amzDateFormat = "20060102T150405Z"
+	putTimeout    = 30 * time.Second // 单次上传的超时时间
+)
+
+// S3Config S3 连接配置
+type S3Config struct {
+	Endpoint     string // 为空时使用 https://s3.<region>.amazonaws.com
+	Region       string
+	Bucket       string
+	AccessKey    string
+	SecretKey    string
+	StorageClass string // 可选，如 STANDARD_IA、GLACIER_IR
+	PathStyle    bool   // 使用 endpoint/bucket/key 形式的地址，MinIO 等自建服务需要开启
+}
+
+// S3 S3 兼容对象存储客户端
+type S3 struct {
+	cfg    S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3 创建 S3 客户端
+func NewS3(cfg S3Config) *S3 {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Region)
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	return &S3{
+		cfg:    cfg,
+		client: &http.Client{Timeout: putTimeout},
+		now:    time.Now,
+	}
+}
+
+// Put 上传对象，已存在的同名对象会被覆盖
+func (s *S3) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if s.cfg.StorageClass != "" {
+		req.Header.Set("X-Amz-Storage-Class", s.cfg.StorageClass)
+	}
+	sum := sha256.Sum256(body)
+	signRequest(req, hex.EncodeToString(sum[:]), s.cfg.AccessKey, s.cfg.SecretKey, s.cfg.Region, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 put %s: %s: %s", key, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// objectURL 按寻址方式拼出对象地址
+func (s *S3) objectURL(key string) (string, error) {
+	endpoint, err := url.Parse(s.cfg.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return "", fmt.Errorf("invalid s3 endpoint: %q", s.cfg.Endpoint)
+	}
+
+	path := "/" + escapePath(key)
+	if s.cfg.PathStyle {
+		path = "/" + escapePath(s.cfg.Bucket) + path
+	} else {
+		endpoint.Host = s.cfg.Bucket + "." + endpoint.Host
+	}
+	return endpoint.Scheme + "://" + endpoint.Host + endpoint.EscapedPath() + path, nil
+}
+
+// signRequest 为请求添加 Signature V4 签名，签名覆盖 Host 和请求上已设置的所有请求头
+func signRequest(req *http.Request, payloadHash, accessKey, secretKey, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(amzDateFormat)
+	day := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{signAlgorithm, amzDate, scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery 按键排序并编码查询参数
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, escape(k, true)+"="+escape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapePath 编码对象键，保留路径分隔符
+func escapePath(key string) string {
+	return escape(key, false)
+}
+
+// escape 按 S3 规则做 URI 编码：只保留非保留字符，encodeSlash 为 false 时保留 '/'
+func escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
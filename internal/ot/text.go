@@ -0,0 +1,257 @@
+// Package ot 实现纯文本的操作变换（Operational Transformation）。
+//
+// 线上格式与 ot.js 保持一致，一个 TextOperation 序列化为 JSON 数组：
+//   - 正整数 n：保留 n 个字符
+//   - 负整数 -n：删除 n 个字符
+//   - 字符串 s：插入 s
+//
+// 字符长度按 UTF-16 码元计算，与浏览器中 String.length 一致。
+package ot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+// ErrLengthMismatch 操作的基准长度与文档长度不一致
+var ErrLengthMismatch = errors.New("ot: operation base length does not match document")
+
+// Op 单个操作分量，Retain / Delete / Insert 三者只有一个生效
+type Op struct {
+	Retain int
+	Delete int
+	Insert string
+}
+
+func (o Op) isRetain() bool { return o.Retain > 0 }
+func (o Op) isDelete() bool { return o.Delete > 0 }
+func (o Op) isInsert() bool { return o.Insert != "" }
+
+// TextOperation 作用于整段文本的操作序列
+type TextOperation []Op
+
+// MarshalJSON 序列化为 ot.js 兼容格式
+func (t TextOperation) MarshalJSON() ([]byte, error) {
+	out := make([]interface{}, 0, len(t))
+	for _, op := range t {
+		switch {
+		case op.isRetain():
+			out = append(out, op.Retain)
+		case op.isDelete():
+			out = append(out, -op.Delete)
+		default:
+			out = append(out, op.Insert)
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON 解析 ot.js 兼容格式
+func (t *TextOperation) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var op TextOperation
+	for _, item := range raw {
+		var n int
+		if err := json.Unmarshal(item, &n); err == nil {
+			switch {
+			case n > 0:
+				op = op.retain(n)
+			case n < 0:
+				op = op.delete(-n)
+			default:
+				return errors.New("ot: zero-length component")
+			}
+			continue
+		}
+
+		var s string
+		if err := json.Unmarshal(item, &s); err != nil {
+			return fmt.Errorf("ot: invalid component %s", item)
+		}
+		if s == "" {
+			return errors.New("ot: empty insert")
+		}
+		op = op.insert(s)
+	}
+
+	*t = op
+	return nil
+}
+
+// BaseLen 操作要求的输入文本长度
+func (t TextOperation) BaseLen() int {
+	n := 0
+	for _, op := range t {
+		n += op.Retain + op.Delete
+	}
+	return n
+}
+
+// TargetLen 操作应用后的文本长度
+func (t TextOperation) TargetLen() int {
+	n := 0
+	for _, op := range t {
+		n += op.Retain + u16len(op.Insert)
+	}
+	return n
+}
+
+// Apply 将操作应用到文本上
+func (t TextOperation) Apply(doc string) (string, error) {
+	src := utf16.Encode([]rune(doc))
+	if len(src) != t.BaseLen() {
+		return "", ErrLengthMismatch
+	}
+
+	out := make([]uint16, 0, t.TargetLen())
+	pos := 0
+	for _, op := range t {
+		switch {
+		case op.isRetain():
+			out = append(out, src[pos:pos+op.Retain]...)
+			pos += op.Retain
+		case op.isDelete():
+			pos += op.Delete
+		default:
+			out = append(out, utf16.Encode([]rune(op.Insert))...)
+		}
+	}
+	return string(utf16.Decode(out)), nil
+}
+
+// Transform 对并发操作 a、b（基于同一文本）做变换，返回 a'、b'，
+// 满足 apply(apply(doc, a), b') == apply(apply(doc, b), a')。
+// 同一位置的插入以 a 优先。
+func Transform(a, b TextOperation) (TextOperation, TextOperation, error) {
+	if a.BaseLen() != b.BaseLen() {
+		return nil, nil, ErrLengthMismatch
+	}
+
+	var aPrime, bPrime TextOperation
+	i, j := 0, 0
+	op1, op2 := nextOp(a, &i), nextOp(b, &j)
+
+	for op1 != nil || op2 != nil {
+		if op1 != nil && op1.isInsert() {
+			aPrime = aPrime.insert(op1.Insert)
+			bPrime = bPrime.retain(u16len(op1.Insert))
+			op1 = nextOp(a, &i)
+			continue
+		}
+		if op2 != nil && op2.isInsert() {
+			aPrime = aPrime.retain(u16len(op2.Insert))
+			bPrime = bPrime.insert(op2.Insert)
+			op2 = nextOp(b, &j)
+			continue
+		}
+		if op1 == nil || op2 == nil {
+			return nil, nil, ErrLengthMismatch
+		}
+
+		n1, n2 := op1.Retain+op1.Delete, op2.Retain+op2.Delete
+		minLen := n1
+		if n2 < minLen {
+			minLen = n2
+		}
+
+		switch {
+		case op1.isRetain() && op2.isRetain():
+			aPrime = aPrime.retain(minLen)
+			bPrime = bPrime.retain(minLen)
+		case op1.isDelete() && op2.isRetain():
+			aPrime = aPrime.delete(minLen)
+		case op1.isRetain() && op2.isDelete():
+			bPrime = bPrime.delete(minLen)
+		}
+		// 双方都删除同一段文本时，变换后无需再删除
+
+		op1 = shrink(op1, minLen, a, &i)
+		op2 = shrink(op2, minLen, b, &j)
+	}
+
+	return aPrime, bPrime, nil
+}
+
+// nextOp 取出 ops[*idx] 的副本并前移下标，越界时返回 nil
+func nextOp(ops TextOperation, idx *int) *Op {
+	if *idx >= len(ops) {
+		return nil
+	}
+	op := ops[*idx]
+	*idx++
+	return &op
+}
+
+// shrink 消耗掉 op 的前 n 个字符，耗尽时取下一个分量
+func shrink(op *Op, n int, ops TextOperation, idx *int) *Op {
+	if op.Retain > n {
+		op.Retain -= n
+		return op
+	}
+	if op.Delete > n {
+		op.Delete -= n
+		return op
+	}
+	return nextOp(ops, idx)
+}
+
+// --- 构造辅助，保证相邻同类分量合并、插入排在删除之前 ---
+
+func (t TextOperation) retain(n int) TextOperation {
+	if n <= 0 {
+		return t
+	}
+	if last := len(t) - 1; last >= 0 && t[last].isRetain() {
+		t[last].Retain += n
+		return t
+	}
+	return append(t, Op{Retain: n})
+}
+
+func (t TextOperation) delete(n int) TextOperation {
+	if n <= 0 {
+		return t
+	}
+	if last := len(t) - 1; last >= 0 && t[last].isDelete() {
+		t[last].Delete += n
+		return t
+	}
+	return append(t, Op{Delete: n})
+}
+
+func (t TextOperation) insert(s string) TextOperation {
+	if s == "" {
+		return t
+	}
+	last := len(t) - 1
+	if last >= 0 && t[last].isInsert() {
+		t[last].Insert += s
+		return t
+	}
+	if last >= 0 && t[last].isDelete() {
+		// 规范化：插入总是放在紧邻的删除之前
+		if last > 0 && t[last-1].isInsert() {
+			t[last-1].Insert += s
+			return t
+		}
+		t = append(t, t[last])
+		t[last] = Op{Insert: s}
+		return t
+	}
+	return append(t, Op{Insert: s})
+}
+
+// u16len 返回字符串的 UTF-16 码元长度
+func u16len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
@@ -0,0 +1,98 @@
+package ot
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== TextOperation 单元测试 ==========
+
+func mustParse(t *testing.T, s string) TextOperation {
+	t.Helper()
+	var op TextOperation
+	assert.NoError(t, json.Unmarshal([]byte(s), &op))
+	return op
+}
+
+func TestTextOperation_JSONRoundTrip(t *testing.T) {
+	op := mustParse(t, `[3, "ab", -2, 1]`)
+
+	assert.Equal(t, 6, op.BaseLen())
+	assert.Equal(t, 6, op.TargetLen())
+
+	data, err := json.Marshal(op)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[3, "ab", -2, 1]`, string(data))
+}
+
+func TestTextOperation_InvalidJSON(t *testing.T) {
+	var op TextOperation
+	assert.Error(t, json.Unmarshal([]byte(`[0]`), &op))
+	assert.Error(t, json.Unmarshal([]byte(`[""]`), &op))
+	assert.Error(t, json.Unmarshal([]byte(`[true]`), &op))
+}
+
+func TestTextOperation_Apply(t *testing.T) {
+	op := mustParse(t, `[6, -5, "Go"]`)
+
+	result, err := op.Apply("Hello World")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello Go", result)
+
+	_, err = op.Apply("short")
+	assert.ErrorIs(t, err, ErrLengthMismatch)
+}
+
+func TestTextOperation_Apply_UTF16(t *testing.T) {
+	// 测试场景：长度按 UTF-16 码元计算，与浏览器一致
+	// "你好😀" 在 JS 中 length 为 4（emoji 占两个码元）
+
+	op := mustParse(t, `[4, "!"]`)
+	result, err := op.Apply("你好😀")
+	assert.NoError(t, err)
+	assert.Equal(t, "你好😀!", result)
+}
+
+func TestTransform_Convergence(t *testing.T) {
+	// 测试场景：两个并发操作变换后，两种应用顺序得到相同结果
+
+	testCases := []struct {
+		name string
+		doc  string
+		a    string
+		b    string
+	}{
+		{"Insert at same position", "abc", `[1, "X", 2]`, `[1, "Y", 2]`},
+		{"Insert vs delete", "abcdef", `[2, "XY", 4]`, `[1, -3, 2]`},
+		{"Overlapping deletes", "abcdef", `[1, -3, 2]`, `[2, -3, 1]`},
+		{"Append vs prepend", "hello", `[5, " world"]`, `["> ", 5]`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, b := mustParse(t, tc.a), mustParse(t, tc.b)
+
+			aPrime, bPrime, err := Transform(a, b)
+			assert.NoError(t, err)
+
+			afterA, err := a.Apply(tc.doc)
+			assert.NoError(t, err)
+			left, err := bPrime.Apply(afterA)
+			assert.NoError(t, err)
+
+			afterB, err := b.Apply(tc.doc)
+			assert.NoError(t, err)
+			right, err := aPrime.Apply(afterB)
+			assert.NoError(t, err)
+
+			assert.Equal(t, left, right)
+		})
+	}
+}
+
+func TestTransform_LengthMismatch(t *testing.T) {
+	_, _, err := Transform(mustParse(t, `[3]`), mustParse(t, `[4]`))
+	assert.ErrorIs(t, err, ErrLengthMismatch)
+}
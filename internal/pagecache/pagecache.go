@@ -0,0 +1,161 @@
+// Package pagecache 在 Redis 中缓存页面读取结果，吸收已发布页面被大量访问等场景下的读请求，减轻 PostgreSQL 压力。
+// 缓存按页面 ID + 版本号存储，页面被修改（刷盘、发布、修改设置、删除）后由调用方 Invalidate。
+// Redis 不可用时直接读数据库，只记录日志，不影响请求。
+package pagecache
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"strconv"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/logging"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	commandTimeout = 500 * time.Millisecond // 单次缓存读写的超时时间，超时后直接读数据库
+	generationTTL  = 24 * time.Hour         // 失效计数的保留时间，远大于一次数据库读取的耗时
+
+	// MaxEntryBytes 单个页面缓存的上限，超出时不缓存
+	MaxEntryBytes = 4 << 20
+)
+
+// metrics 页面缓存计数，通过 expvar 暴露：
+// hits / misses 为命中和未命中，stale 为读取期间页面被修改、放弃写入的次数，
+// skipped 为超出 MaxEntryBytes 未缓存的次数，invalidations 为失效的页面数，errors 为 Redis 读写失败次数
+var metrics = expvar.NewMap("page_cache")
+
+// readScript 读取页面的失效计数和当前版本的缓存；没有当前版本或缓存已过期时只返回失效计数
+var readScript = redis.NewScript(`
+local generation = redis.call('GET', KEYS[1]) or ''
+local version = redis.call('GET', KEYS[2])
+if not version then return {generation} end
+local data = redis.call('GET', ARGV[1] .. version)
+if not data then return {generation} end
+return {generation, data}
+`)
+
+// fillScript 失效计数与读取数据库前一致时才写入缓存和当前版本，避免读取期间被修改的旧数据覆盖失效结果
+var fillScript = redis.NewScript(`
+local generation = redis.call('GET', KEYS[1]) or ''
+if generation ~= ARGV[1] then return 0 end
+redis.call('SET', KEYS[3], ARGV[3], 'PX', ARGV[4])
+redis.call('SET', KEYS[2], ARGV[2], 'PX', ARGV[4])
+return 1
+`)
+
+// Cache 基于 Redis 的页面读取缓存
+type Cache struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// New 创建缓存。键名为 <prefix>page:{<pageID>}:*，prefix 用于多个环境共用一个 Redis 时隔离；
+// ttl 为缓存的有效期，失效通知丢失（如 Redis 短暂不可用）时也最多在 ttl 后读到最新数据
+func New(client *redis.Client, prefix string, ttl time.Duration) *Cache {
+	return &Cache{client: client, prefix: prefix, ttl: ttl}
+}
+
+// key 返回页面的缓存键，同一页面的键共用哈希标签，Redis Cluster 下落在同一个槽
+func (c *Cache) key(pageID, suffix string) string {
+	return c.prefix + "page:{" + pageID + "}:" + suffix
+}
+
+// Get 返回页面的缓存，未命中时调用 load 读取数据库并写入缓存。
+// 页面不存在（load 返回 nil）时不缓存
+func (c *Cache) Get(pageID string, load func() (*entity.Page, error)) (*entity.Page, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	generationKey, headKey, dataPrefix := c.key(pageID, "gen"), c.key(pageID, "head"), c.key(pageID, "v")
+	generation, hit, ok := c.read(ctx, pageID, generationKey, headKey, dataPrefix)
+	if hit != nil {
+		metrics.Add("hits", 1)
+		return hit, nil
+	}
+	metrics.Add("misses", 1)
+
+	page, err := load()
+	if err != nil || page == nil || !ok {
+		return page, err
+	}
+
+	data, err := json.Marshal(page)
+	if err != nil {
+		return page, nil
+	}
+	if len(data) > MaxEntryBytes {
+		metrics.Add("skipped", 1)
+		return page, nil
+	}
+	version := strconv.FormatInt(page.Version, 10)
+	ctx, cancel = context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	filled, err := fillScript.Run(ctx, c.client,
+		[]string{generationKey, headKey, dataPrefix + version},
+		generation, version, data, c.ttl.Milliseconds()).Int()
+	switch {
+	case err != nil:
+		metrics.Add("errors", 1)
+		logging.Warnf("[PageCache] 写入页面 %s 的缓存失败: %v", pageID, err)
+	case filled == 0:
+		metrics.Add("stale", 1)
+	}
+	return page, nil
+}
+
+// read 读取失效计数和缓存，ok 为 false 表示 Redis 读取失败，此时不应写入缓存
+func (c *Cache) read(ctx context.Context, pageID, generationKey, headKey, dataPrefix string) (generation string, page *entity.Page, ok bool) {
+	result, err := readScript.Run(ctx, c.client, []string{generationKey, headKey}, dataPrefix).StringSlice()
+	if err != nil || len(result) == 0 {
+		metrics.Add("errors", 1)
+		logging.Warnf("[PageCache] 读取页面 %s 的缓存失败: %v", pageID, err)
+		return "", nil, false
+	}
+	if len(result) < 2 {
+		return result[0], nil, true
+	}
+
+	var cached entity.Page
+	if err := json.Unmarshal([]byte(result[1]), &cached); err != nil {
+		// 无法解析的缓存（如实体结构变化）视为未命中，写入时覆盖
+		return result[0], nil, true
+	}
+	// datatypes.JSON 的空值序列化为 null，还原为 nil 以与数据库读取的结果一致
+	for _, field := range []*[]byte{(*[]byte)(&cached.Schema), (*[]byte)(&cached.PublishedSchema), (*[]byte)(&cached.CollabSettings)} {
+		if string(*field) == "null" {
+			*field = nil
+		}
+	}
+	return result[0], &cached, true
+}
+
+// Invalidate 使页面的缓存失效，应在修改页面之后调用；失败只记录日志，缓存最多在 ttl 后过期
+func (c *Cache) Invalidate(pageIDs ...string) {
+	if len(pageIDs) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, pageID := range pageIDs {
+			generationKey := c.key(pageID, "gen")
+			pipe.Incr(ctx, generationKey)
+			pipe.PExpire(ctx, generationKey, generationTTL)
+			pipe.Del(ctx, c.key(pageID, "head"))
+		}
+		return nil
+	})
+	if err != nil {
+		metrics.Add("errors", 1)
+		logging.Warnf("[PageCache] 使页面 %v 的缓存失效失败，最多 %s 后过期: %v", pageIDs, c.ttl, err)
+		return
+	}
+	metrics.Add("invalidations", int64(len(pageIDs)))
+}
@@ -0,0 +1,112 @@
+package pagecache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+// ========== 页面读取缓存单元测试 ==========
+
+// loader 模拟数据库读取，记录调用次数
+type loader struct {
+	page  *entity.Page
+	err   error
+	calls int
+}
+
+func (l *loader) load() (*entity.Page, error) {
+	l.calls++
+	if l.page == nil {
+		return nil, l.err
+	}
+	copied := *l.page
+	return &copied, l.err
+}
+
+func newTestCache(t *testing.T) (*Cache, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return New(client, "test:", time.Minute), server
+}
+
+func TestCache_GetAndInvalidate(t *testing.T) {
+	// 测试场景：首次读取数据库并写入缓存，之后命中缓存不再读数据库；
+	// 失效后重新读取，缓存按新版本存储；空的 JSON 字段还原为 nil
+
+	cache, server := newTestCache(t)
+	db := &loader{page: &entity.Page{PageID: "page-1", Version: 3, Schema: datatypes.JSON(`{"rootId":1}`), CreatorID: "alice"}}
+
+	page, err := cache.Get("page-1", db.load)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), page.Version)
+
+	page, err = cache.Get("page-1", db.load)
+	require.NoError(t, err)
+	assert.Equal(t, 1, db.calls)
+	assert.Equal(t, "alice", page.CreatorID)
+	assert.JSONEq(t, `{"rootId":1}`, string(page.Schema))
+	assert.Nil(t, page.PublishedSchema)
+	assert.Nil(t, page.CollabSettings)
+	assert.True(t, server.Exists("test:page:{page-1}:v3"))
+
+	db.page.Version = 4
+	cache.Invalidate("page-1")
+	page, err = cache.Get("page-1", db.load)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), page.Version)
+	assert.Equal(t, 2, db.calls)
+	assert.True(t, server.Exists("test:page:{page-1}:v4"))
+	assert.Equal(t, time.Minute, server.TTL("test:page:{page-1}:v4"))
+}
+
+func TestCache_StaleFill(t *testing.T) {
+	// 测试场景：读取数据库期间页面被修改并失效，读到的旧数据不写入缓存，下次读取重新查询数据库
+
+	cache, _ := newTestCache(t)
+	db := &loader{page: &entity.Page{PageID: "page-1", Version: 3}}
+
+	_, err := cache.Get("page-1", func() (*entity.Page, error) {
+		page, err := db.load()
+		cache.Invalidate("page-1")
+		return page, err
+	})
+	require.NoError(t, err)
+
+	_, err = cache.Get("page-1", db.load)
+	require.NoError(t, err)
+	assert.Equal(t, 2, db.calls)
+}
+
+func TestCache_NotCached(t *testing.T) {
+	// 测试场景：页面不存在或读取失败时不缓存；Redis 不可用时直接读取数据库
+
+	cache, server := newTestCache(t)
+	missing := &loader{}
+	for range 2 {
+		page, err := cache.Get("missing", missing.load)
+		require.NoError(t, err)
+		assert.Nil(t, page)
+	}
+	assert.Equal(t, 2, missing.calls)
+
+	failing := &loader{err: errors.New("db down")}
+	_, err := cache.Get("page-1", failing.load)
+	assert.Error(t, err)
+
+	server.Close()
+	db := &loader{page: &entity.Page{PageID: "page-1", Version: 1}}
+	page, err := cache.Get("page-1", db.load)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), page.Version)
+	cache.Invalidate("page-1")
+}
@@ -0,0 +1,116 @@
+// Package pageref 处理 Schema 中对其他页面的引用（链接、嵌入等）。
+// 引用在 Schema 中保存为对象 {"$page": "<pageId>"}，可以出现在组件属性的任意位置；
+// "kind": "embed" 表示嵌入目标页面，其余为跳转链接，对象的其他字段（如锚点、打开方式）由前端自行解释。
+// 服务端据此维护页面之间的引用索引，并在发布时拒绝指向不存在页面的引用。
+package pageref
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"lowercode-go-server/internal/jsondiff"
+)
+
+// Key 引用对象中保存目标页面 ID 的字段名
+const Key = "$page"
+
+// 引用方式，由引用对象的 kind 字段指定
+const (
+	KindLink  = "link"  // 跳转链接（默认）
+	KindEmbed = "embed" // 嵌入目标页面的内容
+)
+
+// marker 用于快速判断 Schema 中是否可能含有引用
+var marker = []byte(strconv.Quote(Key))
+
+// Ref Schema 中的一个页面引用
+type Ref struct {
+	Path        string `json:"path"`                  // 引用对象的 JSON Pointer
+	ComponentID int64  `json:"componentId,omitempty"` // 引用所在的组件，不在 /components 下时为 0
+	PageID      string `json:"targetPageId"`          // 被引用的页面
+	Kind        string `json:"kind"`                  // KindLink 或 KindEmbed
+}
+
+// Contains 判断 Schema 中是否可能含有页面引用，不解析 JSON
+func Contains(schema []byte) bool {
+	return bytes.Contains(schema, marker)
+}
+
+// Find 返回 Schema 中的全部页面引用，对象成员按 key 排序遍历，结果顺序稳定。
+// 目标为空字符串或不是字符串的对象不算引用
+func Find(schema []byte) ([]Ref, error) {
+	if !Contains(schema) {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(schema))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var refs []Ref
+	walk(doc, "", func(path, pageID, kind string) {
+		refs = append(refs, Ref{Path: path, ComponentID: componentOf(path), PageID: pageID, Kind: kind})
+	})
+	return refs, nil
+}
+
+// Targets 返回 refs 中去重后的目标页面，按 ID 排序
+func Targets(refs []Ref) []string {
+	seen := make(map[string]bool, len(refs))
+	var targets []string
+	for _, ref := range refs {
+		if !seen[ref.PageID] {
+			seen[ref.PageID] = true
+			targets = append(targets, ref.PageID)
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// walk 深度优先遍历 JSON 值，按 key 排序访问对象成员；引用对象内部不再继续查找
+func walk(value any, path string, visit func(path, pageID, kind string)) {
+	switch v := value.(type) {
+	case map[string]any:
+		if pageID, ok := v[Key].(string); ok && pageID != "" {
+			kind := KindLink
+			if v["kind"] == KindEmbed {
+				kind = KindEmbed
+			}
+			visit(path, pageID, kind)
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walk(v[key], path+"/"+jsondiff.EscapeToken(key), visit)
+		}
+	case []any:
+		for i := range v {
+			walk(v[i], path+"/"+strconv.Itoa(i), visit)
+		}
+	}
+}
+
+// componentOf 从 /components/<id>/... 形式的路径中取出组件 ID
+func componentOf(path string) int64 {
+	rest, ok := strings.CutPrefix(path, "/components/")
+	if !ok {
+		return 0
+	}
+	id, _, _ := strings.Cut(rest, "/")
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,47 @@
+package pageref
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 页面引用单元测试 ==========
+
+func TestFind(t *testing.T) {
+	// 测试场景：组件属性和数组中的引用都能找到，路径按 RFC 6901 转义、顺序稳定，kind 默认为链接，
+	// 目标为空或不是字符串的对象不算引用，引用对象内部不再查找
+
+	schema := []byte(`{
+		"rootId": 1,
+		"components": {
+			"12": {"id": 12, "name": "Link", "props": {"to": {"$page": "page-b", "anchor": "top"}}},
+			"3": {"id": 3, "name": "Menu", "props": {"items": [{"label": "A", "target": {"$page": "page-a"}}, {"label": "B", "target": {"$page": ""}}]}},
+			"4": {"id": 4, "name": "Embed", "props": {"a/b": {"$page": "page-a", "kind": "embed", "nested": {"$page": "page-c"}}, "bad": {"$page": 42}}}
+		},
+		"meta": {"home": {"$page": "page-root"}}
+	}`)
+
+	refs, err := Find(schema)
+	require.NoError(t, err)
+	assert.Equal(t, []Ref{
+		{Path: "/components/12/props/to", ComponentID: 12, PageID: "page-b", Kind: KindLink},
+		{Path: "/components/3/props/items/0/target", ComponentID: 3, PageID: "page-a", Kind: KindLink},
+		{Path: "/components/4/props/a~1b", ComponentID: 4, PageID: "page-a", Kind: KindEmbed},
+		{Path: "/meta/home", PageID: "page-root", Kind: KindLink},
+	}, refs)
+	assert.Equal(t, []string{"page-a", "page-b", "page-root"}, Targets(refs))
+}
+
+func TestFind_NoRefs(t *testing.T) {
+	// 测试场景：没有引用时不解析 Schema；含有标记但 JSON 无效时返回错误
+
+	assert.False(t, Contains([]byte(`{"components": {}}`)))
+	refs, err := Find([]byte(`not json`))
+	assert.NoError(t, err)
+	assert.Empty(t, refs)
+
+	_, err = Find([]byte(`{"$page": `))
+	assert.Error(t, err)
+}
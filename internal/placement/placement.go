@@ -0,0 +1,109 @@
+// Package placement 把页面固定分配到多节点部署中的某个节点，同一页面的所有协同连接都连到这个节点，
+// 节点之间不需要消息总线（Redis 等）即可水平扩展。
+// 分配使用 Rendezvous（最高随机权重）哈希：每个节点对页面 ID 打分，取最高分的节点；
+// 各节点只需配置相同的节点列表即可得到一致的结果，增减节点时只有该节点上的页面需要迁移
+package placement
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"strings"
+)
+
+// Node 协同节点
+type Node struct {
+	ID  string // 节点标识，也是粘性 Cookie 的值
+	URL string // 客户端直连该节点的 WebSocket 地址（如 wss://node-a.example.com），由负载均衡按 Cookie 路由时为空
+}
+
+// ParseNodes 解析逗号分隔的节点列表，每项为 "id=url" 或只有 "id"，如
+// "node-a=wss://a.example.com,node-b=wss://b.example.com"；ID 缺失或重复、地址不是 ws(s) 时返回错误
+func ParseNodes(spec string) ([]Node, error) {
+	var nodes []Node
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, rawURL, _ := strings.Cut(item, "=")
+		id, rawURL = strings.TrimSpace(id), strings.TrimSpace(rawURL)
+		if id == "" {
+			return nil, fmt.Errorf("placement: 节点 %q 缺少 ID", item)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("placement: 节点 ID %q 重复", id)
+		}
+		if rawURL != "" {
+			u, err := url.Parse(rawURL)
+			if err != nil || u.Host == "" {
+				return nil, fmt.Errorf("placement: 节点 %s 的地址 %q 无效", id, rawURL)
+			}
+			if u.Scheme != "ws" && u.Scheme != "wss" {
+				return nil, fmt.Errorf("placement: 节点 %s 的地址必须为 ws(s): %q", id, rawURL)
+			}
+			rawURL = strings.TrimRight(rawURL, "/")
+		}
+		seen[id] = true
+		nodes = append(nodes, Node{ID: id, URL: rawURL})
+	}
+	return nodes, nil
+}
+
+// Ring 页面到节点的分配
+type Ring struct {
+	nodes []Node
+}
+
+// New 创建分配表，nodes 不能为空
+func New(nodes []Node) *Ring {
+	return &Ring{nodes: append([]Node(nil), nodes...)}
+}
+
+// Nodes 返回全部节点
+func (r *Ring) Nodes() []Node {
+	return append([]Node(nil), r.nodes...)
+}
+
+// Node 按 ID 查找节点
+func (r *Ring) Node(id string) (Node, bool) {
+	for _, node := range r.nodes {
+		if node.ID == id {
+			return node, true
+		}
+	}
+	return Node{}, false
+}
+
+// Locate 返回承载页面的节点：对每个节点计算 hash(节点 ID, 页面 ID)，取最大值，相同时取 ID 较小的节点
+func (r *Ring) Locate(pageID string) Node {
+	var best Node
+	var bestScore uint64
+	for i, node := range r.nodes {
+		score := weight(node.ID, pageID)
+		if i == 0 || score > bestScore || (score == bestScore && node.ID < best.ID) {
+			best, bestScore = node, score
+		}
+	}
+	return best
+}
+
+// weight 节点对页面的打分，FNV-1a 在各节点、各版本之间结果一致
+func weight(nodeID, pageID string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(nodeID))
+	h.Write([]byte{0})
+	h.Write([]byte(pageID))
+	return mix(h.Sum64())
+}
+
+// mix 对 FNV 结果再做一次雪崩混合（SplitMix64 的终结步骤），使只差末尾字符的页面 ID 也均匀分布
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,69 @@
+package placement
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 页面分配单元测试 ==========
+
+func TestParseNodes(t *testing.T) {
+	// 测试场景：解析 "id=url" 和只有 id 的节点，去掉空白和地址末尾的 /；ID 缺失、重复或地址无效时返回错误
+
+	nodes, err := ParseNodes(" node-a=wss://a.example.com/ , node-b ,")
+	require.NoError(t, err)
+	assert.Equal(t, []Node{{ID: "node-a", URL: "wss://a.example.com"}, {ID: "node-b"}}, nodes)
+
+	for _, spec := range []string{
+		"=wss://a.example.com",
+		"node-a,node-a",
+		"node-a=a.example.com",
+		"node-a=https://a.example.com",
+	} {
+		_, err := ParseNodes(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestRing_Locate(t *testing.T) {
+	// 测试场景：同一页面总是分配到同一节点，与节点列表顺序无关；页面大致均匀地分布到各节点
+
+	a, b, c := Node{ID: "node-a"}, Node{ID: "node-b"}, Node{ID: "node-c"}
+	ring := New([]Node{a, b, c})
+	reversed := New([]Node{c, b, a})
+
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		pageID := fmt.Sprintf("page-%d", i)
+		node := ring.Locate(pageID)
+		assert.Equal(t, node, reversed.Locate(pageID))
+		counts[node.ID]++
+	}
+	for _, node := range ring.Nodes() {
+		assert.InDelta(t, 1000, counts[node.ID], 150, node.ID)
+	}
+
+	node, ok := ring.Node("node-b")
+	assert.True(t, ok)
+	assert.Equal(t, b, node)
+	_, ok = ring.Node("node-x")
+	assert.False(t, ok)
+}
+
+func TestRing_LocateStable(t *testing.T) {
+	// 测试场景：移除一个节点时只有原本分配到该节点的页面迁移，其他页面保持不变
+
+	full := New([]Node{{ID: "node-a"}, {ID: "node-b"}, {ID: "node-c"}})
+	shrunk := New([]Node{{ID: "node-a"}, {ID: "node-c"}})
+
+	for i := 0; i < 1000; i++ {
+		pageID := fmt.Sprintf("page-%d", i)
+		before := full.Locate(pageID)
+		if before.ID != "node-b" {
+			assert.Equal(t, before, shrunk.Locate(pageID), pageID)
+		}
+	}
+}
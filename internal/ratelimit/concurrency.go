@@ -0,0 +1,48 @@
+package ratelimit
+
+import "sync"
+
+// Concurrency 按 key 限制同时占用的名额，并发安全。
+// 与 Limiter 不同，名额不随时间恢复，需要调用方在使用结束后 Release
+type Concurrency struct {
+	max int
+
+	mu     sync.Mutex
+	active map[string]int
+}
+
+// NewConcurrency 创建 Concurrency，max 为单个 key 允许同时占用的名额
+func NewConcurrency(max int) *Concurrency {
+	return &Concurrency{max: max, active: make(map[string]int)}
+}
+
+// Acquire 为 key 占用一个名额，已达上限时返回 false
+func (c *Concurrency) Acquire(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.active[key] >= c.max {
+		return false
+	}
+	c.active[key]++
+	return true
+}
+
+// Release 释放 key 的一个名额，名额归零时删除 key，避免 key 无限增长
+func (c *Concurrency) Release(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.active[key] <= 1 {
+		delete(c.active, key)
+		return
+	}
+	c.active[key]--
+}
+
+// Active 返回 key 当前占用的名额
+func (c *Concurrency) Active(key string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.active[key]
+}
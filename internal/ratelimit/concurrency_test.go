@@ -0,0 +1,26 @@
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== Concurrency 单元测试 ==========
+
+func TestConcurrency_AcquireRelease(t *testing.T) {
+	// 测试场景：达到上限后拒绝，释放后可以再次占用；不同 key 互不影响；名额归零后 key 被回收
+
+	c := NewConcurrency(2)
+	assert.True(t, c.Acquire("user-1"))
+	assert.True(t, c.Acquire("user-1"))
+	assert.False(t, c.Acquire("user-1"))
+	assert.True(t, c.Acquire("user-2"))
+
+	c.Release("user-1")
+	assert.Equal(t, 1, c.Active("user-1"))
+	assert.True(t, c.Acquire("user-1"))
+
+	c.Release("user-2")
+	assert.NotContains(t, c.active, "user-2")
+}
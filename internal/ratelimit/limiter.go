@@ -0,0 +1,122 @@
+// Package ratelimit 提供令牌桶限流原语。
+// Bucket 用于单个对象（如一条 WebSocket 连接），Limiter 按 key（如 IP、用户 ID）维护一组 Bucket，
+// Concurrency 按 key 限制同时占用的名额（如单个用户同时打开的连接数）。
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepEvery 每处理多少次请求清理一次空闲 Bucket
+const sweepEvery = 1024
+
+// Bucket 令牌桶，非并发安全，由调用方保证串行访问
+type Bucket struct {
+	rate   float64 // 每秒补充的令牌数
+	burst  float64 // 桶容量
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket 创建一个满桶
+func NewBucket(rate float64, burst int) *Bucket {
+	return &Bucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// Take 尝试在 now 时刻消耗一个令牌。
+// 失败时返回下一个令牌可用前需要等待的时间。
+func (b *Bucket) Take(now time.Time) (bool, time.Duration) {
+	b.refill(now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	if b.rate <= 0 {
+		return false, time.Duration(math.MaxInt64)
+	}
+	wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	return false, wait
+}
+
+// full 判断桶在 now 时刻是否已补满（可安全回收）
+func (b *Bucket) full(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= b.burst
+}
+
+func (b *Bucket) refill(now time.Time) {
+	if !b.last.IsZero() {
+		elapsed := now.Sub(b.last).Seconds()
+		if elapsed > 0 {
+			b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+		}
+	}
+	b.last = now
+}
+
+// Limiter 按 key 限流，并发安全。
+// 补满的 Bucket 与新建 Bucket 等价，会被周期性回收，避免 key 无限增长。
+type Limiter struct {
+	rate  float64
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*Bucket
+	calls   int
+
+	now func() time.Time // 便于测试替换
+}
+
+// NewLimiter 创建 Limiter。
+// rate 为每秒补充的令牌数，burst 为单个 key 允许的突发量。
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*Bucket),
+		now:     time.Now,
+	}
+}
+
+// PerMinute 以"每分钟 n 次"的形式创建 Limiter，突发量等于 n
+func PerMinute(n int) *Limiter {
+	return NewLimiter(float64(n)/60, n)
+}
+
+// Allow 为 key 消耗一个令牌。
+// 被限流时返回 false 以及建议的重试等待时间。
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	l.calls++
+	if l.calls%sweepEvery == 0 {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = NewBucket(l.rate, l.burst)
+		l.buckets[key] = b
+	}
+	return b.Take(now)
+}
+
+// sweep 回收已补满的 Bucket，调用方需持有 mu
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.full(now) {
+			delete(l.buckets, key)
+		}
+	}
+}
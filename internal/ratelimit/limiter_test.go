@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== Limiter 单元测试 ==========
+
+func newTestLimiter(rate float64, burst int) (*Limiter, *time.Time) {
+	now := time.Unix(1700000000, 0)
+	l := NewLimiter(rate, burst)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiter_BurstThenThrottle(t *testing.T) {
+	// 测试场景：突发额度用完后被限流，并给出重试等待时间
+
+	l, _ := newTestLimiter(1, 3)
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("1.2.3.4")
+		assert.True(t, ok, "第 %d 次请求应在突发额度内", i+1)
+	}
+
+	ok, retryAfter := l.Allow("1.2.3.4")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+}
+
+func TestLimiter_Refill(t *testing.T) {
+	// 测试场景：时间推进后令牌补充
+
+	l, now := newTestLimiter(1, 1)
+
+	ok, _ := l.Allow("k")
+	assert.True(t, ok)
+	ok, _ = l.Allow("k")
+	assert.False(t, ok)
+
+	*now = now.Add(time.Second)
+	ok, _ = l.Allow("k")
+	assert.True(t, ok)
+}
+
+func TestLimiter_KeysAreIndependent(t *testing.T) {
+	// 测试场景：不同 key 的额度互不影响
+
+	l, _ := newTestLimiter(1, 1)
+
+	ok, _ := l.Allow("a")
+	assert.True(t, ok)
+	ok, _ = l.Allow("b")
+	assert.True(t, ok)
+	ok, _ = l.Allow("a")
+	assert.False(t, ok)
+}
+
+func TestLimiter_SweepFullBuckets(t *testing.T) {
+	// 测试场景：补满的 Bucket 会被回收
+
+	l, now := newTestLimiter(1, 1)
+	l.Allow("a")
+
+	*now = now.Add(time.Minute)
+	l.mu.Lock()
+	l.sweep(*now)
+	l.mu.Unlock()
+
+	assert.Empty(t, l.buckets)
+}
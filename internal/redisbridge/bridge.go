@@ -0,0 +1,196 @@
+// Package redisbridge 通过 Redis Pub/Sub 在多个节点之间转发房间消息，实现 ws.RoomBridge。
+// 每个页面对应一个频道，所有页面共用一条订阅连接；发布经由单个 goroutine 按顺序写入，调用方不会被网络阻塞。
+package redisbridge
+
+import (
+	"context"
+	"expvar"
+	"strings"
+	"sync"
+	"time"
+
+	"lowercode-go-server/internal/logging"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	outboxSize     = 4096            // 待发布消息上限，超出时丢弃，其他节点通过版本校验发现缺失后重新同步
+	commandTimeout = 5 * time.Second // 单次 PUBLISH / SUBSCRIBE 的超时时间
+	closeTimeout   = 5 * time.Second // 关闭时等待剩余消息发布的最长时间
+)
+
+// metrics Redis 桥接计数，通过 expvar 暴露：
+// published 为已发布的消息，received 为收到的消息，dropped 为因队列满丢弃的消息，errors 为发布或订阅失败次数
+var metrics = expvar.NewMap("redis_bridge")
+
+type outgoing struct {
+	channel string
+	data    []byte
+}
+
+// Bridge 基于 Redis Pub/Sub 的房间桥接
+type Bridge struct {
+	client *redis.Client
+	prefix string
+	pubsub *redis.PubSub
+
+	mu       sync.Mutex
+	handlers map[string]map[int]func([]byte) // 频道 -> 订阅者
+	nextID   int
+
+	outbox    chan outgoing
+	closeOnce sync.Once
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// New 创建桥接并启动收发 goroutine。频道名为 <prefix>room:<pageID>，
+// prefix 用于多个环境共用一个 Redis 时隔离频道。client 由调用方创建，Close 时一并关闭
+func New(client *redis.Client, prefix string) *Bridge {
+	b := &Bridge{
+		client:   client,
+		prefix:   prefix,
+		pubsub:   client.Subscribe(context.Background()),
+		handlers: make(map[string]map[int]func([]byte)),
+		outbox:   make(chan outgoing, outboxSize),
+		done:     make(chan struct{}),
+	}
+	b.wg.Add(2)
+	go b.publishLoop()
+	go b.receiveLoop()
+	return b
+}
+
+// channel 返回页面对应的频道名
+func (b *Bridge) channel(pageID string) string {
+	return b.prefix + "room:" + pageID
+}
+
+// Publish 把消息放入发布队列，不阻塞；队列已满或桥接已关闭时丢弃
+func (b *Bridge) Publish(pageID string, data []byte) {
+	select {
+	case <-b.done:
+		return
+	default:
+	}
+	select {
+	case b.outbox <- outgoing{channel: b.channel(pageID), data: data}:
+	default:
+		metrics.Add("dropped", 1)
+		logging.Warnf("[RedisBridge] 发布队列已满，丢弃页面 %s 的消息", pageID)
+	}
+}
+
+// Subscribe 订阅页面频道，同一页面的多个订阅者共用一次 Redis 订阅。
+// handler 在接收 goroutine 中按收到的顺序调用，不应阻塞
+func (b *Bridge) Subscribe(pageID string, handler func(data []byte)) func() {
+	channel := b.channel(pageID)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.handlers[channel] == nil {
+		b.handlers[channel] = make(map[int]func([]byte))
+		b.command("订阅", channel, b.pubsub.Subscribe)
+	}
+	id := b.nextID
+	b.nextID++
+	b.handlers[channel][id] = handler
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { b.unsubscribe(channel, id) })
+	}
+}
+
+// unsubscribe 移除订阅者，频道没有订阅者时取消 Redis 订阅
+func (b *Bridge) unsubscribe(channel string, id int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	handlers := b.handlers[channel]
+	delete(handlers, id)
+	if len(handlers) == 0 && handlers != nil {
+		delete(b.handlers, channel)
+		b.command("取消订阅", channel, b.pubsub.Unsubscribe)
+	}
+}
+
+// command 执行订阅或取消订阅，失败只记录日志：连接恢复后 go-redis 会按当前订阅列表重新订阅
+func (b *Bridge) command(action, channel string, fn func(ctx context.Context, channels ...string) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	if err := fn(ctx, channel); err != nil {
+		metrics.Add("errors", 1)
+		logging.Warnf("[RedisBridge] %s频道 %s 失败: %v", action, channel, err)
+	}
+}
+
+// publishLoop 按入队顺序逐条发布，保证同一节点的消息在其他节点按发布顺序送达
+func (b *Bridge) publishLoop() {
+	defer b.wg.Done()
+	for {
+		select {
+		case msg := <-b.outbox:
+			b.publish(msg)
+		case <-b.done:
+			// 发布关闭前已入队的消息（如停机时的刷盘通知），超时后放弃
+			deadline := time.After(closeTimeout)
+			for {
+				select {
+				case msg := <-b.outbox:
+					b.publish(msg)
+				case <-deadline:
+					return
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (b *Bridge) publish(msg outgoing) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	if err := b.client.Publish(ctx, msg.channel, msg.data).Err(); err != nil {
+		metrics.Add("errors", 1)
+		logging.Warnf("[RedisBridge] 发布到频道 %s 失败: %v", msg.channel, err)
+		return
+	}
+	metrics.Add("published", 1)
+}
+
+// receiveLoop 把收到的消息分发给频道的订阅者，PubSub 关闭后退出
+func (b *Bridge) receiveLoop() {
+	defer b.wg.Done()
+	for msg := range b.pubsub.Channel() {
+		if !strings.HasPrefix(msg.Channel, b.prefix+"room:") {
+			continue
+		}
+		metrics.Add("received", 1)
+
+		b.mu.Lock()
+		handlers := make([]func([]byte), 0, len(b.handlers[msg.Channel]))
+		for _, handler := range b.handlers[msg.Channel] {
+			handlers = append(handlers, handler)
+		}
+		b.mu.Unlock()
+
+		data := []byte(msg.Payload)
+		for _, handler := range handlers {
+			handler(data)
+		}
+	}
+}
+
+// Close 发布剩余消息后关闭订阅和 Redis 连接，应在 Hub 停机（房间刷盘）之后调用
+func (b *Bridge) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.done)
+		b.pubsub.Close()
+		b.wg.Wait()
+		err = b.client.Close()
+	})
+	return err
+}
@@ -0,0 +1,92 @@
+package redisbridge
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== Redis 房间桥接单元测试 ==========
+
+// recorder 记录订阅者收到的消息
+type recorder struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (r *recorder) handle(data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, string(data))
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.messages...)
+}
+
+func newTestBridge(t *testing.T, server *miniredis.Miniredis) *Bridge {
+	b := New(redis.NewClient(&redis.Options{Addr: server.Addr()}), "test:")
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+// waitSubscribed 等待频道在 Redis 上的订阅数达到 n
+func waitSubscribed(t *testing.T, server *miniredis.Miniredis, channel string, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return server.PubSubNumSub(channel)[channel] == n
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestBridge_PublishSubscribe(t *testing.T) {
+	// 测试场景：节点发布的消息按发布顺序送达所有订阅该页面的节点（包括自己），其他页面的订阅者收不到
+
+	server := miniredis.RunT(t)
+	nodeA := newTestBridge(t, server)
+	nodeB := newTestBridge(t, server)
+
+	var gotA, gotB, other recorder
+	nodeA.Subscribe("page-1", gotA.handle)
+	nodeB.Subscribe("page-1", gotB.handle)
+	nodeB.Subscribe("page-2", other.handle)
+	waitSubscribed(t, server, "test:room:page-1", 2)
+
+	for _, msg := range []string{"1", "2", "3"} {
+		nodeA.Publish("page-1", []byte(msg))
+	}
+
+	want := []string{"1", "2", "3"}
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(want, gotA.get()) && assert.ObjectsAreEqual(want, gotB.get())
+	}, time.Second, 5*time.Millisecond)
+	assert.Empty(t, other.get())
+}
+
+func TestBridge_Unsubscribe(t *testing.T) {
+	// 测试场景：同一页面的多个订阅者共用一次 Redis 订阅，最后一个订阅者取消后才取消 Redis 订阅
+
+	server := miniredis.RunT(t)
+	bridge := newTestBridge(t, server)
+
+	var first, second recorder
+	unsubscribeFirst := bridge.Subscribe("page-1", first.handle)
+	unsubscribeSecond := bridge.Subscribe("page-1", second.handle)
+	waitSubscribed(t, server, "test:room:page-1", 1)
+
+	unsubscribeFirst()
+	unsubscribeFirst()
+	bridge.Publish("page-1", []byte("hello"))
+	assert.Eventually(t, func() bool { return len(second.get()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Empty(t, first.get())
+	assert.Equal(t, 1, server.PubSubNumSub("test:room:page-1")["test:room:page-1"])
+
+	unsubscribeSecond()
+	waitSubscribed(t, server, "test:room:page-1", 0)
+}
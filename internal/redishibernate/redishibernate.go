@@ -0,0 +1,86 @@
+// Package redishibernate 基于 Redis 保存休眠房间的状态（ws.HibernationStore）。
+// 每个页面一个哈希键（version、state），过期时间即保留时间；写入和取出通过脚本完成，
+// 不会用旧版本覆盖新版本，取出与删除是原子的，多个节点同时加入时只有一个节点恢复到该状态。
+package redishibernate
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const commandTimeout = 500 * time.Millisecond // 单次读写的超时时间，超时后从数据库加载
+
+// saveScript 已保存的版本不高于 ARGV[1] 时写入状态并设置过期时间，返回 1；否则返回 0
+var saveScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'version')
+if current and tonumber(current) > tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'version', ARGV[1], 'state', ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return 1
+`)
+
+// takeScript 返回 {version, state} 并删除键；键不存在时返回 nil
+var takeScript = redis.NewScript(`
+local entry = redis.call('HMGET', KEYS[1], 'version', 'state')
+if not entry[1] or not entry[2] then
+	return false
+end
+redis.call('DEL', KEYS[1])
+return entry
+`)
+
+// Store 基于 Redis 的房间休眠状态存储
+type Store struct {
+	client *redis.Client
+	prefix string
+}
+
+// New 创建休眠状态存储，键名为 <prefix>room-hibernated:<pageID>，prefix 用于多个环境共用一个 Redis 时隔离
+func New(client *redis.Client, prefix string) *Store {
+	return &Store{client: client, prefix: prefix}
+}
+
+func (s *Store) key(pageID string) string {
+	return s.prefix + "room-hibernated:" + pageID
+}
+
+// Save 保存页面状态，ttl 后过期；已保存更高版本时不覆盖
+func (s *Store) Save(pageID string, state []byte, version int64, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	return saveScript.Run(ctx, s.client, []string{s.key(pageID)}, version, state, ttl.Milliseconds()).Err()
+}
+
+// Take 取出并删除页面的休眠状态，没有时 ok 为 false
+func (s *Store) Take(pageID string) ([]byte, int64, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	result, err := takeScript.Run(ctx, s.client, []string{s.key(pageID)}).StringSlice()
+	if errors.Is(err, redis.Nil) {
+		return nil, 0, false, nil
+	}
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if len(result) != 2 {
+		return nil, 0, false, errors.New("redishibernate: 取出脚本返回值无效")
+	}
+	version, err := strconv.ParseInt(result[0], 10, 64)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return []byte(result[1]), version, true, nil
+}
+
+// Discard 删除页面的休眠状态
+func (s *Store) Discard(pageID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	return s.client.Del(ctx, s.key(pageID)).Err()
+}
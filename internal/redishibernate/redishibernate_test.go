@@ -0,0 +1,80 @@
+package redishibernate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== Redis 房间休眠状态单元测试 ==========
+
+func newTestStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return New(client, "test:"), server
+}
+
+func TestStore_SaveTake(t *testing.T) {
+	// 测试场景：保存后可以取出状态和版本，取出即删除；没有休眠状态时 ok 为 false
+
+	store, server := newTestStore(t)
+
+	require.NoError(t, store.Save("page-1", []byte(`{"root":{}}`), 7, time.Minute))
+	assert.Equal(t, time.Minute, server.TTL("test:room-hibernated:page-1"))
+
+	state, version, ok, err := store.Take("page-1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, `{"root":{}}`, string(state))
+	assert.Equal(t, int64(7), version)
+
+	_, _, ok, err = store.Take("page-1")
+	require.NoError(t, err)
+	assert.False(t, ok, "取出后应已删除")
+}
+
+func TestStore_SaveKeepsNewerVersion(t *testing.T) {
+	// 测试场景：已保存更高版本时旧版本不覆盖；相同或更高版本覆盖
+
+	store, _ := newTestStore(t)
+
+	require.NoError(t, store.Save("page-1", []byte(`"v5"`), 5, time.Minute))
+	require.NoError(t, store.Save("page-1", []byte(`"v3"`), 3, time.Minute))
+	require.NoError(t, store.Save("page-2", []byte(`"v1"`), 1, time.Minute))
+	require.NoError(t, store.Save("page-2", []byte(`"v2"`), 2, time.Minute))
+
+	state, version, ok, err := store.Take("page-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, `"v5"`, string(state))
+	assert.Equal(t, int64(5), version)
+
+	state, _, ok, err = store.Take("page-2")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, `"v2"`, string(state))
+}
+
+func TestStore_ExpireAndDiscard(t *testing.T) {
+	// 测试场景：超过保留时间后取不到；Discard 删除休眠状态，不存在时不报错
+
+	store, server := newTestStore(t)
+
+	require.NoError(t, store.Save("page-1", []byte(`{}`), 1, time.Minute))
+	server.FastForward(2 * time.Minute)
+	_, _, ok, err := store.Take("page-1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, store.Save("page-2", []byte(`{}`), 1, time.Minute))
+	require.NoError(t, store.Discard("page-2"))
+	_, _, ok, err = store.Take("page-2")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.NoError(t, store.Discard("page-3"))
+}
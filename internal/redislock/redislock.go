@@ -0,0 +1,100 @@
+// Package redislock 基于 Redis 实现房间租约锁（ws.RoomLock）。
+// 每个页面一个键，值为持有节点的地址，过期时间即租约有效期；续约和释放通过脚本比较持有者后再修改，不会误删其他节点的租约。
+package redislock
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const commandTimeout = 2 * time.Second // 单次加锁、续约、释放的超时时间
+
+// acquireScript 键不存在时写入 owner；已由 owner 持有时续期。返回 {1, owner} 或 {0, 当前持有者}
+var acquireScript = redis.NewScript(`
+local holder = redis.call('GET', KEYS[1])
+if not holder then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	return {1, ARGV[1]}
+end
+if holder == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return {1, holder}
+end
+return {0, holder}
+`)
+
+// renewScript 仍由 owner 持有时续期，返回 1；否则返回 0
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript 仍由 owner 持有时删除
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Lock 基于 Redis 的房间租约锁
+type Lock struct {
+	client *redis.Client
+	prefix string
+}
+
+// New 创建租约锁，键名为 <prefix>room-owner:<pageID>，prefix 用于多个环境共用一个 Redis 时隔离
+func New(client *redis.Client, prefix string) *Lock {
+	return &Lock{client: client, prefix: prefix}
+}
+
+func (l *Lock) key(pageID string) string {
+	return l.prefix + "room-owner:" + pageID
+}
+
+// Acquire 获取页面的租约，已由 owner 持有时续期；被其他节点持有时返回持有者和 false
+func (l *Lock) Acquire(pageID, owner string, ttl time.Duration) (string, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	result, err := acquireScript.Run(ctx, l.client, []string{l.key(pageID)}, owner, ttl.Milliseconds()).Slice()
+	if err != nil {
+		return "", false, err
+	}
+	if len(result) != 2 {
+		return "", false, errors.New("redislock: 加锁脚本返回值无效")
+	}
+	acquired, _ := result[0].(int64)
+	holder, _ := result[1].(string)
+	return holder, acquired == 1, nil
+}
+
+// Renew 续期 owner 持有的租约，租约已过期或被其他节点取得时返回 false
+func (l *Lock) Renew(pageID, owner string, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	renewed, err := renewScript.Run(ctx, l.client, []string{l.key(pageID)}, owner, ttl.Milliseconds()).Int()
+	return renewed == 1, err
+}
+
+// Release 释放 owner 持有的租约
+func (l *Lock) Release(pageID, owner string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	return releaseScript.Run(ctx, l.client, []string{l.key(pageID)}, owner).Err()
+}
+
+// Owner 返回页面租约的持有者，没有节点持有时返回空字符串
+func (l *Lock) Owner(pageID string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	owner, err := l.client.Get(ctx, l.key(pageID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return owner, err
+}
@@ -0,0 +1,80 @@
+package redislock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== Redis 房间租约锁单元测试 ==========
+
+func newTestLock(t *testing.T) (*Lock, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return New(client, "test:"), server
+}
+
+func TestLock_AcquireRelease(t *testing.T) {
+	// 测试场景：第一个节点取得租约，其他节点得到持有者；持有者重复获取视为续期；
+	// 非持有者释放无效，持有者释放后其他节点可以取得
+
+	lock, server := newTestLock(t)
+
+	holder, ok, err := lock.Acquire("page-1", "http://node-a:8080", 10*time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "http://node-a:8080", holder)
+	assert.Equal(t, 10*time.Second, server.TTL("test:room-owner:page-1"))
+
+	holder, ok, err = lock.Acquire("page-1", "http://node-b:8080", 10*time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "http://node-a:8080", holder)
+
+	server.FastForward(5 * time.Second)
+	_, ok, err = lock.Acquire("page-1", "http://node-a:8080", 10*time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Second, server.TTL("test:room-owner:page-1"))
+
+	require.NoError(t, lock.Release("page-1", "http://node-b:8080"))
+	owner, err := lock.Owner("page-1")
+	require.NoError(t, err)
+	assert.Equal(t, "http://node-a:8080", owner)
+
+	require.NoError(t, lock.Release("page-1", "http://node-a:8080"))
+	owner, err = lock.Owner("page-1")
+	require.NoError(t, err)
+	assert.Empty(t, owner)
+	_, ok, err = lock.Acquire("page-1", "http://node-b:8080", 10*time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestLock_Renew(t *testing.T) {
+	// 测试场景：持有者续约延长有效期；租约过期并被其他节点取得后续约失败
+
+	lock, server := newTestLock(t)
+	_, _, err := lock.Acquire("page-1", "node-a", 3*time.Second)
+	require.NoError(t, err)
+
+	server.FastForward(2 * time.Second)
+	ok, err := lock.Renew("page-1", "node-a", 3*time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, server.TTL("test:room-owner:page-1"))
+
+	server.FastForward(4 * time.Second)
+	_, ok, err = lock.Acquire("page-1", "node-b", 3*time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = lock.Renew("page-1", "node-a", 3*time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,244 @@
+// Package secretprop 处理 Schema 中的密钥属性（如数据源的 API Key）。
+// 密钥属性在 Schema 中保存为信封 {"$secret": "<密文>", "mask": "••••abcd"}，
+// 明文只在服务端加密时出现，协同房间、历史版本和操作日志中都只有密文；
+// 密文与页面 ID 绑定，复制到其他页面后无法解密。
+package secretprop
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"lowercode-go-server/internal/jsondiff"
+)
+
+const (
+	// Key 信封中保存密文的字段名，值为空字符串表示已脱敏
+	Key = "$secret"
+	// MaskKey 信封中保存掩码的字段名，供编辑器展示
+	MaskKey = "mask"
+
+	// MaxLength 密钥明文的最大字节数
+	MaxLength = 4096
+
+	tokenPrefix = "v1."
+	maskPrefix  = "••••"
+	maskMinLen  = 12 // 明文达到该长度时掩码保留末尾 4 个字符
+)
+
+// ErrComponentNotFound Schema 中没有要写入密钥属性的组件
+var ErrComponentNotFound = errors.New("component not found")
+
+// ErrUndecryptable 密文无法解密：已脱敏、被篡改、来自其他页面或加密密钥已更换
+var ErrUndecryptable = errors.New("secret cannot be decrypted")
+
+// Box 使用 AES-256-GCM 加解密密钥属性
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox 创建 Box，key 必须为 32 字节
+func NewBox(key []byte) (*Box, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("密钥长度必须为 32 字节，实际为 %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Seal 加密明文，返回绑定 pageID 的密文
+func (b *Box) Seal(pageID, plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), []byte(pageID))
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open 解密 pageID 页面中的密文，失败时返回 ErrUndecryptable
+func (b *Box) Open(pageID, token string) (string, error) {
+	raw, ok := strings.CutPrefix(token, tokenPrefix)
+	if !ok {
+		return "", ErrUndecryptable
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil || len(sealed) < b.aead.NonceSize() {
+		return "", ErrUndecryptable
+	}
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, []byte(pageID))
+	if err != nil {
+		return "", ErrUndecryptable
+	}
+	return string(plaintext), nil
+}
+
+// Envelope 加密明文并生成写入 Schema 的信封
+func (b *Box) Envelope(pageID, plaintext string) (map[string]string, error) {
+	token, err := b.Seal(pageID, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{Key: token, MaskKey: MaskOf(plaintext)}, nil
+}
+
+// Resolve 将 Schema 中的信封替换为明文字符串，任一信封无法解密时返回带 JSON Pointer 的 ErrUndecryptable
+func (b *Box) Resolve(pageID string, schema []byte) ([]byte, error) {
+	var failed string
+	out, err := rewrite(schema, func(path, token string, _ map[string]any) (any, bool) {
+		plaintext, err := b.Open(pageID, token)
+		if err != nil {
+			if failed == "" {
+				failed = path
+			}
+			return nil, false
+		}
+		return plaintext, true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if failed != "" {
+		return nil, fmt.Errorf("%w: %s", ErrUndecryptable, failed)
+	}
+	return out, nil
+}
+
+// Patch 生成把信封写入组件 prop 属性的 JSON Patch，组件没有 props 时一并创建
+func Patch(schema []byte, componentID int64, prop string, envelope map[string]string) ([]byte, error) {
+	var doc struct {
+		Components map[string]struct {
+			Props json.RawMessage `json:"props"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(schema, &doc); err != nil {
+		return nil, err
+	}
+	id := strconv.FormatInt(componentID, 10)
+	component, ok := doc.Components[id]
+	if !ok {
+		return nil, ErrComponentNotFound
+	}
+
+	path, value := "/components/"+id+"/props", any(map[string]any{prop: envelope})
+	if props := bytes.TrimSpace(component.Props); len(props) > 0 && props[0] == '{' {
+		path, value = path+"/"+jsondiff.EscapeToken(prop), envelope
+	}
+	return json.Marshal([]map[string]any{{"op": "add", "path": path, "value": value}})
+}
+
+// MaskOf 生成明文的掩码：较长的密钥保留末尾 4 个字符，其余只显示掩码前缀
+func MaskOf(plaintext string) string {
+	runes := []rune(plaintext)
+	if len(runes) < maskMinLen {
+		return maskPrefix
+	}
+	return maskPrefix + string(runes[len(runes)-4:])
+}
+
+// Mask 清空 Schema 中所有信封的密文，只保留掩码，供非所有者读取；没有信封时原样返回
+func Mask(schema []byte) ([]byte, error) {
+	return rewrite(schema, func(_, _ string, envelope map[string]any) (any, bool) {
+		masked := map[string]any{Key: ""}
+		if mask, ok := envelope[MaskKey]; ok {
+			masked[MaskKey] = mask
+		}
+		return masked, true
+	})
+}
+
+// Ref Schema 中的一个密钥属性
+type Ref struct {
+	Path  string // JSON Pointer
+	Token string // 密文，已脱敏时为空
+	Mask  string
+}
+
+// Find 返回 Schema 中的全部信封，对象成员按 key 排序遍历，结果顺序稳定
+func Find(schema []byte) ([]Ref, error) {
+	var refs []Ref
+	_, err := rewrite(schema, func(path, token string, envelope map[string]any) (any, bool) {
+		mask, _ := envelope[MaskKey].(string)
+		refs = append(refs, Ref{Path: path, Token: token, Mask: mask})
+		return nil, false
+	})
+	return refs, err
+}
+
+// rewrite 遍历 Schema 中的信封，replace 返回 true 时用其返回值替换信封；
+// 没有信封时不解析 Schema，直接返回原始字节
+func rewrite(schema []byte, replace func(path, token string, envelope map[string]any) (any, bool)) ([]byte, error) {
+	if !bytes.Contains(schema, []byte(strconv.Quote(Key))) {
+		return schema, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(schema))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	changed := false
+	doc = walk(doc, "", func(path, token string, envelope map[string]any) (any, bool) {
+		value, ok := replace(path, token, envelope)
+		changed = changed || ok
+		return value, ok
+	})
+	if !changed {
+		return schema, nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// walk 深度优先遍历 JSON 值，按 key 排序访问对象成员，保证 Find 的结果稳定
+func walk(value any, path string, replace func(path, token string, envelope map[string]any) (any, bool)) any {
+	switch v := value.(type) {
+	case map[string]any:
+		if token, ok := v[Key].(string); ok {
+			if replaced, ok := replace(path, token, v); ok {
+				return replaced
+			}
+			return v
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			v[key] = walk(v[key], path+"/"+jsondiff.EscapeToken(key), replace)
+		}
+		return v
+	case []any:
+		for i := range v {
+			v[i] = walk(v[i], path+"/"+strconv.Itoa(i), replace)
+		}
+		return v
+	default:
+		return v
+	}
+}
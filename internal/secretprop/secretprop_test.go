@@ -0,0 +1,131 @@
+package secretprop
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 密钥属性单元测试 ==========
+
+func newTestBox(t *testing.T) *Box {
+	box, err := NewBox(bytes.Repeat([]byte{7}, 32))
+	require.NoError(t, err)
+	return box
+}
+
+func TestNewBox_KeyLength(t *testing.T) {
+	// 测试场景：密钥不是 32 字节时拒绝创建
+	_, err := NewBox([]byte("short"))
+	assert.Error(t, err)
+}
+
+func TestBox_SealOpen(t *testing.T) {
+	// 测试场景：密文可以在同一页面解密；每次加密的密文不同；
+	// 复制到其他页面、被篡改或已脱敏的密文无法解密
+	box := newTestBox(t)
+
+	token, err := box.Seal("page-1", "sk-live-123456")
+	require.NoError(t, err)
+	assert.NotContains(t, token, "sk-live")
+	again, err := box.Seal("page-1", "sk-live-123456")
+	require.NoError(t, err)
+	assert.NotEqual(t, token, again)
+
+	plaintext, err := box.Open("page-1", token)
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-live-123456", plaintext)
+
+	for _, tc := range []struct{ pageID, token string }{
+		{"page-2", token},
+		{"page-1", token[:len(token)-2] + "AA"},
+		{"page-1", ""},
+		{"page-1", "plain"},
+	} {
+		_, err := box.Open(tc.pageID, tc.token)
+		assert.ErrorIs(t, err, ErrUndecryptable, tc.token)
+	}
+}
+
+func TestMaskOf(t *testing.T) {
+	// 测试场景：较长的密钥保留末尾 4 个字符，较短的只显示掩码前缀，避免泄露大部分明文
+	assert.Equal(t, "••••7890", MaskOf("sk-1234567890"))
+	assert.Equal(t, "••••", MaskOf("short"))
+}
+
+func TestPatch(t *testing.T) {
+	// 测试场景：组件已有 props 时写入单个属性，没有 props 时创建 props 对象；
+	// 属性名中的 / 按 JSON Pointer 转义；组件不存在时返回 ErrComponentNotFound
+	schema := []byte(`{"rootId": 1, "components": {
+		"1": {"id": 1, "name": "Page"},
+		"2": {"id": 2, "name": "Fetch", "props": {"url": "https://api.example.com"}}
+	}}`)
+	envelope := map[string]string{Key: "v1.x", MaskKey: "••••"}
+
+	patch, err := Patch(schema, 2, "headers/auth", envelope)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"op": "add", "path": "/components/2/props/headers~1auth", "value": {"$secret": "v1.x", "mask": "••••"}}]`, string(patch))
+
+	patch, err = Patch(schema, 1, "apiKey", envelope)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"op": "add", "path": "/components/1/props", "value": {"apiKey": {"$secret": "v1.x", "mask": "••••"}}}]`, string(patch))
+
+	_, err = Patch(schema, 3, "apiKey", envelope)
+	assert.ErrorIs(t, err, ErrComponentNotFound)
+}
+
+func TestMaskResolveFind(t *testing.T) {
+	// 测试场景：Mask 清空密文、保留掩码；Resolve 把信封替换为明文；
+	// Find 按路径顺序列出全部信封；任一密文无法解密时 Resolve 返回其路径
+	box := newTestBox(t)
+	a, err := box.Envelope("page-1", "sk-aaaaaaaaaaaa")
+	require.NoError(t, err)
+	b, err := box.Envelope("page-1", "token-b")
+	require.NoError(t, err)
+	schema, err := json.Marshal(map[string]any{
+		"components": map[string]any{
+			"2": map[string]any{"id": 2, "props": map[string]any{"apiKey": a, "url": "https://x?a=1&b=2"}},
+			"3": map[string]any{"id": 3, "props": map[string]any{"list": []any{b}}},
+		},
+	})
+	require.NoError(t, err)
+
+	masked, err := Mask(schema)
+	require.NoError(t, err)
+	assert.NotContains(t, string(masked), a[Key])
+	assert.JSONEq(t, `{"components": {
+		"2": {"id": 2, "props": {"apiKey": {"$secret": "", "mask": "••••aaaa"}, "url": "https://x?a=1&b=2"}},
+		"3": {"id": 3, "props": {"list": [{"$secret": "", "mask": "••••"}]}}
+	}}`, string(masked))
+
+	resolved, err := box.Resolve("page-1", schema)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"components": {
+		"2": {"id": 2, "props": {"apiKey": "sk-aaaaaaaaaaaa", "url": "https://x?a=1&b=2"}},
+		"3": {"id": 3, "props": {"list": ["token-b"]}}
+	}}`, string(resolved))
+
+	refs, err := Find(schema)
+	require.NoError(t, err)
+	require.Len(t, refs, 2)
+	assert.Equal(t, "/components/2/props/apiKey", refs[0].Path)
+	assert.Equal(t, "••••aaaa", refs[0].Mask)
+	assert.Equal(t, "/components/3/props/list/0", refs[1].Path)
+
+	_, err = box.Resolve("page-2", schema)
+	assert.ErrorIs(t, err, ErrUndecryptable)
+	assert.Contains(t, err.Error(), "/components/2/props/apiKey")
+	_, err = box.Resolve("page-1", masked)
+	assert.ErrorIs(t, err, ErrUndecryptable)
+}
+
+func TestRewrite_NoEnvelope(t *testing.T) {
+	// 测试场景：没有信封时原样返回 Schema，不重新编码
+	schema := []byte(`{"b": 1,  "a": 2}`)
+	masked, err := Mask(schema)
+	assert.NoError(t, err)
+	assert.Equal(t, schema, masked)
+}
@@ -0,0 +1,199 @@
+package ws
+
+import (
+	"sync"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/logging"
+)
+
+// 页面活动写入配置
+const (
+	activityQueueSize     = 1024            // 待写入队列容量
+	activityFlushInterval = 5 * time.Second // 合并写入间隔，同一用户同一页面在间隔内只写一次
+)
+
+// ActivityStore 页面活动持久化接口，由 repository 层实现
+type ActivityStore interface {
+	Record(activities []*entity.PageActivity) error
+}
+
+// WithActivity 启用页面活动记录：登录用户加入房间时记录打开时间，其编辑刷盘后记录编辑时间
+func WithActivity(w *ActivityWriter) HubOption {
+	return func(h *Hub) {
+		h.activity = w
+	}
+}
+
+// ActivityWriter 异步合并写入页面活动。
+// Room 在 run() 和刷盘路径中调用 Opened / Edited，因此两者都必须非阻塞。
+type ActivityWriter struct {
+	store ActivityStore
+	queue chan *entity.PageActivity
+
+	mu     sync.RWMutex // 保护 closed，保证 Close 之后不再入队
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewActivityWriter 创建 ActivityWriter，需另起 goroutine 调用 Run
+func NewActivityWriter(store ActivityStore) *ActivityWriter {
+	return &ActivityWriter{
+		store: store,
+		queue: make(chan *entity.PageActivity, activityQueueSize),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// Opened 记录用户打开页面
+func (w *ActivityWriter) Opened(userID, pageID string, at time.Time) {
+	w.append(&entity.PageActivity{UserID: userID, PageID: pageID, LastOpenedAt: &at})
+}
+
+// Edited 记录用户编辑页面，at 为该用户在本次刷盘范围内最后一次编辑的时间
+func (w *ActivityWriter) Edited(userID, pageID string, at time.Time) {
+	w.append(&entity.PageActivity{UserID: userID, PageID: pageID, LastEditedAt: &at})
+}
+
+// append 追加一条活动（非阻塞），队列已满或已关闭时丢弃
+func (w *ActivityWriter) append(activity *entity.PageActivity) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return
+	}
+
+	select {
+	case w.queue <- activity:
+	default:
+		logging.Warnf("[Activity] 队列已满，丢弃用户 %s 在页面 %s 的活动", activity.UserID, activity.PageID)
+	}
+}
+
+// Run 合并写入循环，阻塞直到 Close 被调用且队列清空
+func (w *ActivityWriter) Run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(activityFlushInterval)
+	defer ticker.Stop()
+
+	pending := make(map[[2]string]*entity.PageActivity)
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		batch := make([]*entity.PageActivity, 0, len(pending))
+		for _, activity := range pending {
+			batch = append(batch, activity)
+		}
+		if err := w.store.Record(batch); err != nil {
+			logging.Errorf("[Activity] 写入 %d 条页面活动失败: %v", len(batch), err)
+		}
+		pending = make(map[[2]string]*entity.PageActivity)
+	}
+
+	for {
+		select {
+		case activity := <-w.queue:
+			mergeActivity(pending, activity)
+		case <-ticker.C:
+			flush()
+		case <-w.stop:
+			// Close 之后不会再有新活动入队，取空队列后退出
+			for {
+				select {
+				case activity := <-w.queue:
+					mergeActivity(pending, activity)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close 停止接收新活动，等待剩余活动写入完成。应在所有 Room 停止之后调用。
+func (w *ActivityWriter) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.stop)
+	}
+	w.mu.Unlock()
+
+	<-w.done
+}
+
+// mergeActivity 将活动合并到待写入集合，同一用户同一页面的各时间字段取较晚者
+func mergeActivity(pending map[[2]string]*entity.PageActivity, activity *entity.PageActivity) {
+	key := [2]string{activity.UserID, activity.PageID}
+	existing, ok := pending[key]
+	if !ok {
+		pending[key] = activity
+		return
+	}
+	existing.LastOpenedAt = laterTime(existing.LastOpenedAt, activity.LastOpenedAt)
+	existing.LastEditedAt = laterTime(existing.LastEditedAt, activity.LastEditedAt)
+}
+
+// laterTime 返回两个可空时间中较晚的一个
+func laterTime(a, b *time.Time) *time.Time {
+	if a == nil || (b != nil && b.After(*a)) {
+		return b
+	}
+	return a
+}
+
+// recordOpened 记录登录用户加入房间，访客不记录，仅在 run() 内调用
+func (r *Room) recordOpened(client *Client) {
+	if r.activity == nil || client.UserInfo.Guest || client.UserInfo.UserID == "" {
+		return
+	}
+	r.activity.Opened(client.UserInfo.UserID, r.ID, time.Now())
+}
+
+// noteEditorLocked 记录本次编辑的作者，待包含该版本的刷盘成功后写入编辑时间；
+// 访客和服务端内部操作不记录，调用方需持有 stateMu
+func (r *Room) noteEditorLocked(author UserInfo) {
+	if r.activity == nil || author.Guest || author.UserID == "" {
+		return
+	}
+	if r.pendingEditors == nil {
+		r.pendingEditors = make(map[string]pendingEdit)
+	}
+	r.pendingEditors[author.UserID] = pendingEdit{version: r.Version, at: time.Now()}
+}
+
+// pendingEdit 尚未刷盘的编辑：作者最后一次编辑后的版本与时间
+type pendingEdit struct {
+	version int64
+	at      time.Time
+}
+
+// takePersistedEditorsLocked 取出最后一次编辑已在 version 内落盘的作者，调用方需持有 stateMu
+func (r *Room) takePersistedEditorsLocked(version int64) map[string]time.Time {
+	var editors map[string]time.Time
+	for userID, edit := range r.pendingEditors {
+		if edit.version > version {
+			continue
+		}
+		if editors == nil {
+			editors = make(map[string]time.Time)
+		}
+		editors[userID] = edit.at
+		delete(r.pendingEditors, userID)
+	}
+	return editors
+}
+
+// recordEdited 写入刷盘成功后的编辑时间
+func (r *Room) recordEdited(editors map[string]time.Time) {
+	for userID, at := range editors {
+		r.activity.Edited(userID, r.ID, at)
+	}
+}
@@ -0,0 +1,74 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 页面活动单元测试 ==========
+// 测试重点：登录用户加入时记录打开时间，编辑刷盘后记录编辑时间，访客不记录
+
+func TestRoom_RecordsActivity(t *testing.T) {
+	// 测试场景：Alice 加入并编辑，访客只加入；刷盘后 Alice 有打开和编辑时间，访客没有记录
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", "page-1", mock.Anything, int64(1), int64(3)).Return(nil)
+
+	store := &MockActivityStore{}
+	writer := NewActivityWriter(store)
+	go writer.Run()
+
+	hub := NewHub(mockService, WithActivity(writer))
+	room := NewRoom("page-1", []byte(`{"title": "a"}`), mockService, hub)
+	room.lastPersistedVersion = 1
+
+	alice := &Client{UserInfo: UserInfo{UserID: "alice"}, send: make(chan []byte, 16)}
+	guest := &Client{UserInfo: UserInfo{UserID: "guest-1", Guest: true}, send: make(chan []byte, 16)}
+	require.NoError(t, room.Register(alice))
+	require.NoError(t, room.Register(guest))
+
+	_, err := room.ApplyEdit(alice.UserInfo, []byte(`[{"op": "replace", "path": "/title", "value": "b"}]`), 1)
+	require.NoError(t, err)
+	_, err = room.ApplyEdit(guest.UserInfo, []byte(`[{"op": "replace", "path": "/title", "value": "c"}]`), 2)
+	require.NoError(t, err)
+
+	// 刷盘前编辑时间尚未记录
+	room.stateMu.RLock()
+	assert.Contains(t, room.pendingEditors, "alice")
+	assert.NotContains(t, room.pendingEditors, "guest-1")
+	room.stateMu.RUnlock()
+
+	room.flushToDB("测试")
+	room.Stop()
+	writer.Close()
+
+	activities := store.Activities()
+	assert.Len(t, activities, 1)
+	require.Contains(t, activities, "alice")
+	assert.NotNil(t, activities["alice"].LastOpenedAt)
+	assert.NotNil(t, activities["alice"].LastEditedAt)
+}
+
+func TestMergeActivity(t *testing.T) {
+	// 测试场景：同一用户同一页面的多条活动合并为一条，各字段取较晚的时间
+
+	t1 := time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Minute)
+
+	pending := make(map[[2]string]*entity.PageActivity)
+	mergeActivity(pending, &entity.PageActivity{UserID: "alice", PageID: "p", LastOpenedAt: &t2})
+	mergeActivity(pending, &entity.PageActivity{UserID: "alice", PageID: "p", LastOpenedAt: &t1})
+	mergeActivity(pending, &entity.PageActivity{UserID: "alice", PageID: "p", LastEditedAt: &t1})
+	mergeActivity(pending, &entity.PageActivity{UserID: "alice", PageID: "q", LastOpenedAt: &t1})
+
+	assert.Len(t, pending, 2)
+	merged := pending[[2]string{"alice", "p"}]
+	assert.Equal(t, t2, *merged.LastOpenedAt)
+	assert.Equal(t, t1, *merged.LastEditedAt)
+}
@@ -0,0 +1,282 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/ratelimit"
+)
+
+// AI 生成模式
+const (
+	AIModeApply   = "apply"   // 生成后直接提交到房间，与 POST /ai/generate 相同
+	AIModePropose = "propose" // 生成修改建议供审阅，与 POST /ai/proposals 相同
+)
+
+const (
+	// aiChunkInterval 合并模型增量输出的最小间隔，避免逐 Token 发送
+	aiChunkInterval = 100 * time.Millisecond
+	// maxAIRunsPerClient 单个连接同时进行的 AI 生成数
+	maxAIRunsPerClient = 1
+)
+
+// aiMetrics 流式 AI 生成计数，通过 expvar 暴露：started、completed、failed、canceled、rejected（限流或并发超限）
+var aiMetrics = expvar.NewMap("ws_ai")
+
+// AIStreamer 在协同连接内流式执行 AI 生成，由 usecase.AIUseCase 实现。
+// onChunk 按顺序收到模型输出的增量文本；返回值为最终结果（apply 为提交结果，propose 为修改建议），
+// ctx 被取消时返回 ctx.Err()
+type AIStreamer interface {
+	StreamAI(ctx context.Context, pageID, userID, prompt, mode string, onChunk func(delta string)) (json.RawMessage, error)
+}
+
+// aiStreaming 流式 AI 生成配置
+type aiStreaming struct {
+	streamer AIStreamer
+	limiter  *ratelimit.Limiter // 按用户限流，与 HTTP 生成接口的额度相同；为 nil 时不限制
+}
+
+// EnableAIStreaming 允许客户端通过 ai-generate 在现有连接上发起 AI 生成，增量输出以 ai-chunk 只发给请求者。
+// AIUseCase 依赖 Hub，因此在创建之后设置，需在开始接受连接之前调用；perMinute <= 0 时不限流。
+// 未启用时 ai-generate 收到 FEATURE_DISABLED
+func (h *Hub) EnableAIStreaming(streamer AIStreamer, perMinute int) {
+	ai := &aiStreaming{streamer: streamer}
+	if perMinute > 0 {
+		ai.limiter = ratelimit.PerMinute(perMinute)
+	}
+	h.ai = ai
+}
+
+// AIGeneratePayload ai-generate 消息的 payload 结构（客户端 → 服务端）
+type AIGeneratePayload struct {
+	RequestID string `json:"requestId"` // 客户端生成的请求 ID，ai-chunk、ai-done 和 error 中原样带回
+	Prompt    string `json:"prompt"`
+	Mode      string `json:"mode"` // apply / propose，为空时为 apply
+}
+
+// AICancelPayload ai-cancel 消息的 payload 结构（客户端 → 服务端）
+type AICancelPayload struct {
+	RequestID string `json:"requestId"`
+}
+
+// AIChunkPayload ai-chunk 消息的 payload 结构，仅发给请求者
+type AIChunkPayload struct {
+	RequestID string `json:"requestId"`
+	Seq       int    `json:"seq"` // 从 0 开始递增，缓冲区满时增量可能被丢弃，前端可据此发现缺口
+	Delta     string `json:"delta"`
+}
+
+// AIDonePayload ai-done 消息的 payload 结构，仅发给请求者。
+// 失败时不发送 ai-done，而是发送 clientMsgId 为请求 ID 的 error
+type AIDonePayload struct {
+	RequestID string          `json:"requestId"`
+	Mode      string          `json:"mode"`
+	Result    json.RawMessage `json:"result,omitempty"` // apply 为 {version, patches}，propose 为修改建议
+	Canceled  bool            `json:"canceled,omitempty"`
+}
+
+// handleAIGenerate 在独立 goroutine 中执行 AI 生成，不阻塞 ReadPump；增量输出和结果经房间事件循环只发给本连接
+func (c *Client) handleAIGenerate(payload json.RawMessage) {
+	var req AIGeneratePayload
+	if err := json.Unmarshal(payload, &req); err != nil || req.RequestID == "" ||
+		len(req.RequestID) > MaxClientMsgIDLength {
+		c.sendError(ErrInvalidMessage, "ai-generate 格式错误")
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = AIModeApply
+	}
+	if req.Mode != AIModeApply && req.Mode != AIModePropose {
+		c.sendOpError(req.RequestID, ErrInvalidMessage, fmt.Sprintf("不支持的生成模式 %q", req.Mode))
+		return
+	}
+	if c.Room == nil {
+		c.sendOpError(req.RequestID, ErrRoomNotFound, c.RoomID)
+		return
+	}
+	ai := c.Hub.aiConfig()
+	if ai == nil {
+		c.sendOpError(req.RequestID, ErrFeatureDisabled, "服务端未开启流式 AI 生成")
+		return
+	}
+	if c.UserInfo.Guest {
+		c.sendOpError(req.RequestID, ErrUnauthorized, "访客不能使用 AI 生成")
+		return
+	}
+	if code, reason := c.editDenied(); code != "" {
+		c.sendOpError(req.RequestID, code, reason)
+		return
+	}
+	if ai.limiter != nil {
+		if allowed, retryAfter := ai.limiter.Allow(c.UserInfo.UserID); !allowed {
+			aiMetrics.Add("rejected", 1)
+			c.sendOpError(req.RequestID, ErrRateLimited,
+				fmt.Sprintf("AI 生成过于频繁，请 %d 秒后重试", int(math.Ceil(retryAfter.Seconds()))))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if !c.startAIRun(req.RequestID, cancel) {
+		cancel()
+		aiMetrics.Add("rejected", 1)
+		c.sendOpError(req.RequestID, ErrRateLimited, "当前连接已有进行中的 AI 生成")
+		return
+	}
+	aiMetrics.Add("started", 1)
+	go c.runAI(ctx, ai.streamer, req)
+}
+
+// handleAICancel 取消本连接进行中的 AI 生成；生成已结束时忽略
+func (c *Client) handleAICancel(payload json.RawMessage) {
+	var req AICancelPayload
+	if err := json.Unmarshal(payload, &req); err != nil || req.RequestID == "" {
+		c.sendError(ErrInvalidMessage, "ai-cancel 格式错误")
+		return
+	}
+	c.aiMu.Lock()
+	cancel := c.aiRuns[req.RequestID]
+	c.aiMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// runAI 执行一次生成：增量输出按 aiChunkInterval 合并后发送，结束时发送 ai-done 或 error
+func (c *Client) runAI(ctx context.Context, streamer AIStreamer, req AIGeneratePayload) {
+	room := c.Room
+	defer c.finishAIRun(req.RequestID)
+
+	var (
+		mu       sync.Mutex
+		pending  strings.Builder
+		seq      int
+		lastSent time.Time
+	)
+	flush := func() {
+		if pending.Len() == 0 {
+			return
+		}
+		room.sendAsync(c, encodeServerMessage(TypeAIChunk, AIChunkPayload{
+			RequestID: req.RequestID,
+			Seq:       seq,
+			Delta:     pending.String(),
+		}))
+		seq++
+		pending.Reset()
+		lastSent = time.Now()
+	}
+	onChunk := func(delta string) {
+		mu.Lock()
+		defer mu.Unlock()
+		pending.WriteString(delta)
+		if time.Since(lastSent) >= aiChunkInterval {
+			flush()
+		}
+	}
+
+	result, err := streamer.StreamAI(ctx, c.RoomID, c.UserInfo.UserID, req.Prompt, req.Mode, onChunk)
+	mu.Lock()
+	flush()
+	mu.Unlock()
+
+	switch {
+	case err == nil:
+		aiMetrics.Add("completed", 1)
+		room.sendAsync(c, encodeServerMessage(TypeAIDone, AIDonePayload{RequestID: req.RequestID, Mode: req.Mode, Result: result}))
+	case errors.Is(err, context.Canceled):
+		aiMetrics.Add("canceled", 1)
+		room.sendAsync(c, encodeServerMessage(TypeAIDone, AIDonePayload{RequestID: req.RequestID, Mode: req.Mode, Canceled: true}))
+	default:
+		aiMetrics.Add("failed", 1)
+		code, message := aiError(err)
+		if code == ErrInternalError {
+			logging.Errorf("[Room %s] 用户 [%s] 的 AI 生成失败: %v", c.RoomID, c.UserInfo.UserName, err)
+		}
+		room.sendAsync(c, encodeServerMessage(TypeError, ErrorPayload{Code: code, Message: message, ClientMsgID: req.RequestID}))
+	}
+}
+
+// startAIRun 登记进行中的生成，超出 maxAIRunsPerClient 或请求 ID 重复时返回 false
+func (c *Client) startAIRun(requestID string, cancel context.CancelFunc) bool {
+	c.aiMu.Lock()
+	defer c.aiMu.Unlock()
+	if len(c.aiRuns) >= maxAIRunsPerClient || c.aiRuns[requestID] != nil {
+		return false
+	}
+	if c.aiRuns == nil {
+		c.aiRuns = make(map[string]context.CancelFunc)
+	}
+	c.aiRuns[requestID] = cancel
+	return true
+}
+
+// finishAIRun 移除已结束的生成
+func (c *Client) finishAIRun(requestID string) {
+	c.aiMu.Lock()
+	defer c.aiMu.Unlock()
+	if cancel := c.aiRuns[requestID]; cancel != nil {
+		cancel()
+		delete(c.aiRuns, requestID)
+	}
+}
+
+// cancelAIRuns 连接断开时取消所有进行中的生成，不再调用模型服务
+func (c *Client) cancelAIRuns() {
+	c.aiMu.Lock()
+	defer c.aiMu.Unlock()
+	for _, cancel := range c.aiRuns {
+		cancel()
+	}
+}
+
+// aiConfig 返回流式 AI 生成配置，未启用时为 nil
+func (h *Hub) aiConfig() *aiStreaming {
+	if h == nil {
+		return nil
+	}
+	return h.ai
+}
+
+// aiError 把生成失败的业务错误转换为错误码和可展示的说明
+func aiError(err error) (ErrorCode, string) {
+	switch {
+	case errors.Is(err, domainErrors.ErrUnauthorized):
+		return ErrUnauthorized, "无权编辑此页面"
+	case errors.Is(err, domainErrors.ErrSessionClosed):
+		return ErrSessionClosed, "当前不在页面的协作时段内，页面只读"
+	case errors.Is(err, domainErrors.ErrPresentationActive):
+		return ErrUnauthorized, "演示模式下只有演示者可以编辑"
+	case errors.Is(err, domainErrors.ErrAIDisabled):
+		return ErrFeatureDisabled, "服务端未配置模型服务，请配置自带的模型服务"
+	case errors.Is(err, domainErrors.ErrInvalidAIPrompt):
+		return ErrInvalidMessage, err.Error()
+	case errors.Is(err, domainErrors.ErrAIProvider):
+		return ErrAIFailed, "模型服务调用失败，请稍后重试：" + err.Error()
+	case errors.Is(err, domainErrors.ErrAIInvalidPatch):
+		return ErrAIFailed, "模型生成的修改无效，请调整提示词后重试：" + err.Error()
+	case errors.Is(err, domainErrors.ErrOptimisticLock):
+		return ErrVersionConflict, "页面编辑频繁，请稍后重试"
+	case errors.Is(err, domainErrors.ErrUsageLimitExceeded):
+		return ErrUpgradeRequired, "本月 AI 生成次数已达套餐额度，请升级套餐：" + err.Error()
+	default:
+		return ErrInternalError, "AI 生成失败，请稍后重试"
+	}
+}
+
+// sendAsync 从房间事件循环之外向 client 发送消息，经事件循环投递；client 已离开或房间已关闭时丢弃
+func (r *Room) sendAsync(client *Client, data []byte) {
+	select {
+	case r.broadcast <- &RoomBroadcast{Message: data, Target: client}:
+	case <-r.stopChan:
+	}
+}
@@ -0,0 +1,205 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 流式 AI 生成单元测试 ==========
+
+// newAITestRoom 创建启用流式 AI 生成的房间，alice 为编辑者、bob 为只读协作者
+func newAITestRoom(t *testing.T, streamer AIStreamer, perMinute int) (*Room, *Client, *Client) {
+	service := new(MockPageService)
+	service.On("GetPageState", "page-1").Return([]byte(`{}`), int64(1), nil)
+	service.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	hub := NewHub(service)
+	if streamer != nil {
+		hub.EnableAIStreaming(streamer, perMinute)
+	}
+	room, err := hub.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	t.Cleanup(room.Stop)
+
+	alice := &Client{Hub: hub, RoomID: "page-1", send: make(chan []byte, 64),
+		UserInfo: UserInfo{UserID: "alice", UserName: "Alice", Role: entity.RoleEditor}}
+	bob := &Client{Hub: hub, RoomID: "page-1", send: make(chan []byte, 64),
+		UserInfo: UserInfo{UserID: "bob", UserName: "Bob", Role: entity.RoleViewer}}
+	for _, c := range []*Client{alice, bob} {
+		require.NoError(t, room.Register(c))
+		nextTestMessageOfType(t, c, TypeSync) // 事件循环设置 client.Room 之后才发送 sync
+	}
+	return room, alice, bob
+}
+
+// sendAIMessage 模拟客户端发送 ai-generate / ai-cancel
+func sendAIMessage(c *Client, msgType MessageType, payload string) {
+	c.handleMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"type":%q,"payload":%s}`, msgType, payload)))
+}
+
+// nextAIError 读取客户端收到的下一条 error 消息
+func nextAIError(t *testing.T, c *Client) ErrorPayload {
+	t.Helper()
+	var payload ErrorPayload
+	require.NoError(t, json.Unmarshal(nextTestMessageOfType(t, c, TypeError).Payload, &payload))
+	return payload
+}
+
+// nextAIMessage 读取客户端收到的下一条 ai-chunk 或 ai-done 消息
+func nextAIMessage(t *testing.T, c *Client) WSMessage {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case data := <-c.send:
+			var msg WSMessage
+			require.NoError(t, json.Unmarshal(data, &msg))
+			if msg.Type == TypeAIChunk || msg.Type == TypeAIDone {
+				return msg
+			}
+		case <-timeout:
+			t.Fatal("未收到 AI 生成消息")
+		}
+	}
+}
+
+func TestClient_AIGenerate(t *testing.T) {
+	// 测试场景：增量输出合并后以 ai-chunk 按顺序只发给请求者，结束后发送带结果的 ai-done；其他人收不到
+
+	streamer := &MockAIStreamer{chunks: []string{`[{"op"`, `:"add"`, `}]`}, result: json.RawMessage(`{"version":2}`)}
+	_, alice, bob := newAITestRoom(t, streamer, 0)
+
+	sendAIMessage(alice, TypeAIGenerate, `{"requestId":"req-1","prompt":"添加按钮"}`)
+
+	var text strings.Builder
+	var done AIDonePayload
+	for seq := 0; ; seq++ {
+		msg := nextAIMessage(t, alice)
+		if msg.Type == TypeAIDone {
+			require.NoError(t, json.Unmarshal(msg.Payload, &done))
+			break
+		}
+		var chunk AIChunkPayload
+		require.NoError(t, json.Unmarshal(msg.Payload, &chunk))
+		assert.Equal(t, "req-1", chunk.RequestID)
+		assert.Equal(t, seq, chunk.Seq)
+		text.WriteString(chunk.Delta)
+	}
+	assert.Equal(t, `[{"op":"add"}]`, text.String())
+	assert.Equal(t, "req-1", done.RequestID)
+	assert.Equal(t, AIModeApply, done.Mode)
+	assert.JSONEq(t, `{"version":2}`, string(done.Result))
+
+	for {
+		select {
+		case data := <-bob.send:
+			var msg WSMessage
+			require.NoError(t, json.Unmarshal(data, &msg))
+			assert.NotContains(t, []MessageType{TypeAIChunk, TypeAIDone}, msg.Type)
+			continue
+		default:
+		}
+		break
+	}
+}
+
+func TestClient_AICancel(t *testing.T) {
+	// 测试场景：同一连接同时只能进行一个生成；ai-cancel 后收到 canceled 的 ai-done，之后可以再次发起；
+	// 连接断开时取消进行中的生成
+
+	streamer := &MockAIStreamer{chunks: []string{"["}, wait: true}
+	_, alice, _ := newAITestRoom(t, streamer, 0)
+
+	sendAIMessage(alice, TypeAIGenerate, `{"requestId":"req-1","prompt":"添加按钮","mode":"propose"}`)
+	nextTestMessageOfType(t, alice, TypeAIChunk)
+	sendAIMessage(alice, TypeAIGenerate, `{"requestId":"req-2","prompt":"添加按钮"}`)
+	rejected := nextAIError(t, alice)
+	assert.Equal(t, ErrRateLimited, rejected.Code)
+	assert.Equal(t, "req-2", rejected.ClientMsgID)
+
+	sendAIMessage(alice, TypeAICancel, `{"requestId":"req-1"}`)
+	var done AIDonePayload
+	require.NoError(t, json.Unmarshal(nextTestMessageOfType(t, alice, TypeAIDone).Payload, &done))
+	assert.Equal(t, "req-1", done.RequestID)
+	assert.Equal(t, AIModePropose, done.Mode)
+	assert.True(t, done.Canceled)
+
+	assert.Eventually(t, func() bool {
+		alice.aiMu.Lock()
+		defer alice.aiMu.Unlock()
+		return len(alice.aiRuns) == 0
+	}, time.Second, 10*time.Millisecond)
+	sendAIMessage(alice, TypeAIGenerate, `{"requestId":"req-3","prompt":"添加按钮"}`)
+	nextTestMessageOfType(t, alice, TypeAIChunk)
+	alice.cancelAIRuns()
+	require.NoError(t, json.Unmarshal(nextTestMessageOfType(t, alice, TypeAIDone).Payload, &done))
+	assert.Equal(t, "req-3", done.RequestID)
+	assert.True(t, done.Canceled)
+}
+
+func TestClient_AIGenerateRejects(t *testing.T) {
+	// 测试场景：未启用时返回 FEATURE_DISABLED；只读连接返回 UNAUTHORIZED；模型调用失败返回 AI_FAILED；
+	// 超出每分钟额度时返回 RATE_LIMITED；错误都带回请求 ID
+
+	_, alice, _ := newAITestRoom(t, nil, 0)
+	sendAIMessage(alice, TypeAIGenerate, `{"requestId":"req-1","prompt":"hi"}`)
+	assert.Equal(t, ErrFeatureDisabled, nextAIError(t, alice).Code)
+
+	streamer := &MockAIStreamer{err: fmt.Errorf("%w: timeout", domainErrors.ErrAIProvider)}
+	_, alice, bob := newAITestRoom(t, streamer, 1)
+	sendAIMessage(bob, TypeAIGenerate, `{"requestId":"req-1","prompt":"hi"}`)
+	assert.Equal(t, ErrUnauthorized, nextAIError(t, bob).Code)
+
+	sendAIMessage(alice, TypeAIGenerate, `{"requestId":"req-2","prompt":"hi"}`)
+	failed := nextAIError(t, alice)
+	assert.Equal(t, ErrAIFailed, failed.Code)
+	assert.Equal(t, "req-2", failed.ClientMsgID)
+
+	sendAIMessage(alice, TypeAIGenerate, `{"requestId":"req-3","prompt":"hi"}`)
+	limited := nextAIError(t, alice)
+	assert.Equal(t, ErrRateLimited, limited.Code)
+	assert.Equal(t, "req-3", limited.ClientMsgID)
+
+	sendAIMessage(alice, TypeAIGenerate, `{"requestId":"req-4","prompt":"hi","mode":"rewrite"}`)
+	assert.Equal(t, ErrInvalidMessage, nextAIError(t, alice).Code)
+}
+
+func TestClient_AIGenerateEditDenied(t *testing.T) {
+	// 测试场景：与实时编辑相同的限制适用于 AI 生成：协作时段外编辑者收到 SESSION_CLOSED，
+	// 演示模式下非演示者收到 UNAUTHORIZED，错误都带回请求 ID
+
+	streamer := &MockAIStreamer{result: json.RawMessage(`{"version":2}`)}
+	room, alice, _ := newAITestRoom(t, streamer, 0)
+	start := time.Now().Add(time.Hour)
+	settings := entity.DefaultCollabSettings()
+	settings.Session = &entity.SessionWindow{StartsAt: start, EndsAt: start.Add(time.Hour)}
+	room.SetCollabSettings(settings)
+	require.Eventually(t, func() bool { return !room.SessionOpen() }, time.Second, 10*time.Millisecond)
+
+	sendAIMessage(alice, TypeAIGenerate, `{"requestId":"req-1","prompt":"hi"}`)
+	closed := nextAIError(t, alice)
+	assert.Equal(t, ErrSessionClosed, closed.Code)
+	assert.Equal(t, "req-1", closed.ClientMsgID)
+
+	room, alice, _ = newAITestRoom(t, streamer, 0)
+	carol := &Client{Hub: room.hub, RoomID: "page-1", send: make(chan []byte, 64),
+		UserInfo: UserInfo{UserID: "carol", UserName: "Carol", Role: entity.RoleOwner}}
+	require.NoError(t, room.Register(carol))
+	room.SetPresentation(carol, true, "carol")
+	require.Eventually(t, func() bool { return !room.MayEdit("alice") }, time.Second, 10*time.Millisecond)
+
+	sendAIMessage(alice, TypeAIGenerate, `{"requestId":"req-2","prompt":"hi"}`)
+	denied := nextAIError(t, alice)
+	assert.Equal(t, ErrUnauthorized, denied.Code)
+	assert.Equal(t, "req-2", denied.ClientMsgID)
+}
@@ -0,0 +1,277 @@
+package ws
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/logging"
+)
+
+// 房间归档配置
+const (
+	archiveQueueSize    = 64                     // 待归档队列容量
+	archiveSettleDelay  = 2 * opLogFlushInterval // 等待操作日志写入器落盘最后一批日志
+	archiveOpPageSize   = 1000                   // 分页读取操作日志的每页条数
+	archiveMaxAttempts  = 3                      // 上传失败时的最大尝试次数
+	archiveRetryBackoff = 2 * time.Second        // 重试间隔，按尝试次数线性递增
+)
+
+// archiveMetrics 房间归档计数，通过 expvar 暴露
+var archiveMetrics = expvar.NewMap("room_archive")
+
+// ObjectStore 对象存储接口，由 internal/objectstore 实现
+type ObjectStore interface {
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+}
+
+// OpReader 操作日志读取接口，由 repository 层实现
+type OpReader interface {
+	ListSince(pageID string, sinceVersion int64, limit int) ([]*entity.PageOp, error)
+}
+
+// RoomArchive 房间销毁时写入对象存储的归档：本次会话结束时的全量快照，
+// 以及从房间加载时的版本到最终版本之间的操作日志。
+// 操作日志异步写入且可能丢弃，JournalComplete 为 false 时表示日志存在缺口。
+type RoomArchive struct {
+	PageID           string          `json:"pageId"`
+	FromVersion      int64           `json:"fromVersion"`      // 房间从数据库加载时的版本
+	Version          int64           `json:"version"`          // 房间销毁时的内存版本
+	PersistedVersion int64           `json:"persistedVersion"` // 房间销毁时已确认落盘的版本
+	Reason           string          `json:"reason,omitempty"`
+	ClosedAt         time.Time       `json:"closedAt"`
+	Schema           json.RawMessage `json:"schema"`
+	Ops              []ArchivedOp    `json:"ops"`
+	JournalComplete  bool            `json:"journalComplete"`
+}
+
+// ArchivedOp 归档中的单条操作日志
+type ArchivedOp struct {
+	Version   int64           `json:"version"`
+	Patch     json.RawMessage `json:"patch"`
+	AuthorID  string          `json:"authorId,omitempty"`
+	Guest     bool            `json:"guest,omitempty"`
+	Label     string          `json:"label,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// WithArchive 启用房间归档，房间销毁时将最终快照和操作日志写入对象存储
+func WithArchive(w *ArchiveWriter) HubOption {
+	return func(h *Hub) {
+		h.archive = w
+	}
+}
+
+// ArchiveWriter 异步将房间归档上传到对象存储。
+// Room 在 run() 退出时调用 Enqueue，因此 Enqueue 必须非阻塞。
+type ArchiveWriter struct {
+	ops    OpReader
+	store  ObjectStore
+	prefix string
+	queue  chan *RoomArchive
+
+	mu     sync.RWMutex // 保护 closed，保证 Close 之后不再入队
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewArchiveWriter 创建 ArchiveWriter，需另起 goroutine 调用 Run。
+// prefix 为对象键前缀，便于为归档单独配置存储桶生命周期规则。
+func NewArchiveWriter(ops OpReader, store ObjectStore, prefix string) *ArchiveWriter {
+	return &ArchiveWriter{
+		ops:    ops,
+		store:  store,
+		prefix: prefix,
+		queue:  make(chan *RoomArchive, archiveQueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Enqueue 提交一份待归档的房间状态（非阻塞），队列已满或已关闭时丢弃并记录日志
+func (w *ArchiveWriter) Enqueue(archive *RoomArchive) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		logging.Warnf("[Archive] 写入器已关闭，丢弃页面 %s@%d 的归档", archive.PageID, archive.Version)
+		archiveMetrics.Add("dropped", 1)
+		return
+	}
+
+	select {
+	case w.queue <- archive:
+	default:
+		logging.Warnf("[Archive] 队列已满，丢弃页面 %s@%d 的归档", archive.PageID, archive.Version)
+		archiveMetrics.Add("dropped", 1)
+	}
+}
+
+// Run 归档循环，阻塞直到 Close 被调用且队列清空
+func (w *ArchiveWriter) Run() {
+	defer close(w.done)
+
+	for {
+		select {
+		case archive := <-w.queue:
+			w.settle(archive)
+			w.write(archive)
+		case <-w.stop:
+			// Close 之后不会再有新归档入队，取空队列后退出
+			for {
+				select {
+				case archive := <-w.queue:
+					w.write(archive)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close 停止接收新归档，等待队列中剩余归档上传完成。
+// 应在 OpLogWriter.Close 之后调用，保证归档能读到最后一批操作日志。
+func (w *ArchiveWriter) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.stop)
+	}
+	w.mu.Unlock()
+
+	<-w.done
+}
+
+// settle 等待操作日志写入器处理完房间销毁前的最后一批日志，Close 时立即返回
+func (w *ArchiveWriter) settle(archive *RoomArchive) {
+	wait := time.Until(archive.ClosedAt.Add(archiveSettleDelay))
+	if wait <= 0 {
+		return
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-w.stop:
+	}
+}
+
+// write 补全操作日志并上传，失败按次数重试，最终失败只记录日志
+func (w *ArchiveWriter) write(archive *RoomArchive) {
+	if err := w.loadOps(archive); err != nil {
+		logging.Errorf("[Archive] 读取页面 %s 的操作日志失败，仅归档快照: %v", archive.PageID, err)
+	}
+
+	body, err := encodeArchive(archive)
+	if err != nil {
+		logging.Errorf("[Archive] 页面 %s 归档序列化失败: %v", archive.PageID, err)
+		archiveMetrics.Add("failed", 1)
+		return
+	}
+
+	key := w.key(archive)
+	for attempt := 1; attempt <= archiveMaxAttempts; attempt++ {
+		err = w.store.Put(context.Background(), key, body, "application/gzip")
+		if err == nil {
+			archiveMetrics.Add("archived", 1)
+			log.Printf("[Archive] 页面 %s 已归档: %s（%d 条操作，%d 字节）", archive.PageID, key, len(archive.Ops), len(body))
+			return
+		}
+		if attempt < archiveMaxAttempts {
+			time.Sleep(time.Duration(attempt) * archiveRetryBackoff)
+		}
+	}
+	archiveMetrics.Add("failed", 1)
+	logging.Errorf("[Archive] 页面 %s 归档上传失败（已尝试 %d 次）: %v", archive.PageID, archiveMaxAttempts, err)
+}
+
+// loadOps 分页读取 (FromVersion, Version] 内的操作日志，并检查版本是否连续
+func (w *ArchiveWriter) loadOps(archive *RoomArchive) error {
+	archive.Ops = []ArchivedOp{}
+	next := archive.FromVersion + 1
+
+	for next <= archive.Version {
+		ops, err := w.ops.ListSince(archive.PageID, next-1, archiveOpPageSize)
+		if err != nil {
+			return err
+		}
+		if len(ops) == 0 {
+			break
+		}
+		for _, op := range ops {
+			if op.Version > archive.Version {
+				break
+			}
+			archive.Ops = append(archive.Ops, ArchivedOp{
+				Version:   op.Version,
+				Patch:     json.RawMessage(op.Patch),
+				AuthorID:  op.AuthorID,
+				Guest:     op.Guest,
+				Label:     op.Label,
+				CreatedAt: op.CreatedAt,
+			})
+		}
+		next = ops[len(ops)-1].Version + 1
+	}
+
+	archive.JournalComplete = int64(len(archive.Ops)) == archive.Version-archive.FromVersion
+	for i, op := range archive.Ops {
+		if op.Version != archive.FromVersion+int64(i)+1 {
+			archive.JournalComplete = false
+			break
+		}
+	}
+	return nil
+}
+
+// key 归档对象键：<prefix><pageId>/<fromVersion>-<version>.json.gz，版本补零便于按键排序
+func (w *ArchiveWriter) key(archive *RoomArchive) string {
+	return fmt.Sprintf("%s%s/%012d-%012d.json.gz", w.prefix, archive.PageID, archive.FromVersion, archive.Version)
+}
+
+// encodeArchive 序列化并 gzip 压缩归档
+func encodeArchive(archive *RoomArchive) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(archive); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// enqueueArchive 房间销毁时提交归档，仅在 run() 退出时调用。
+// 未配置归档、本次会话没有编辑或页面已被删除时跳过。
+func (r *Room) enqueueArchive() {
+	if r.archive == nil || r.stopReason == ErrPageDeleted {
+		return
+	}
+
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	if r.Version == r.loadedVersion {
+		return
+	}
+	r.archive.Enqueue(&RoomArchive{
+		PageID:           r.ID,
+		FromVersion:      r.loadedVersion,
+		Version:          r.Version,
+		PersistedVersion: r.lastPersistedVersion,
+		Reason:           string(r.stopReason),
+		ClosedAt:         time.Now(),
+		Schema:           append(json.RawMessage(nil), r.CurrentState...),
+	})
+}
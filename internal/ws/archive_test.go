@@ -0,0 +1,113 @@
+package ws
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 房间归档单元测试 ==========
+// 测试重点：房间销毁时上传最终快照和本次会话的操作日志，无编辑或页面被删除时不归档
+
+// decodeTestArchive 解压并解析归档对象
+func decodeTestArchive(t *testing.T, body []byte) RoomArchive {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	var archive RoomArchive
+	require.NoError(t, json.NewDecoder(gz).Decode(&archive))
+	return archive
+}
+
+func TestRoom_Destroy_ArchivesSnapshotAndJournal(t *testing.T) {
+	// 测试场景：房间从版本 5 加载，编辑两次后销毁，归档包含最终快照和版本 6、7 的操作日志
+
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", "page-1").Return([]byte(`{"title": "a"}`), int64(5), nil)
+	mockService.On("SavePageState", "page-1", mock.Anything, int64(5), int64(7)).Return(nil)
+
+	opStore := &MockOpStore{}
+	opLog := NewOpLogWriter(opStore)
+	go opLog.Run()
+
+	objects := &MockObjectStore{}
+	archiver := NewArchiveWriter(opStore, objects, "rooms/")
+	go archiver.Run()
+
+	hub := NewHub(mockService, WithOpLog(opLog), WithArchive(archiver))
+	room, err := hub.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+
+	require.NoError(t, room.ApplyPatch([]byte(`[{"op": "replace", "path": "/title", "value": "b"}]`), 5))
+	require.NoError(t, room.ApplyPatch([]byte(`[{"op": "replace", "path": "/title", "value": "c"}]`), 6))
+	room.Stop()
+	opLog.Close()
+	archiver.Close()
+
+	body, ok := objects.Objects()["rooms/page-1/000000000005-000000000007.json.gz"]
+	require.True(t, ok)
+
+	archive := decodeTestArchive(t, body)
+	assert.Equal(t, int64(5), archive.FromVersion)
+	assert.Equal(t, int64(7), archive.Version)
+	assert.Equal(t, int64(7), archive.PersistedVersion)
+	assert.JSONEq(t, `{"title": "c"}`, string(archive.Schema))
+	require.Len(t, archive.Ops, 2)
+	assert.Equal(t, int64(6), archive.Ops[0].Version)
+	assert.Equal(t, int64(7), archive.Ops[1].Version)
+	assert.True(t, archive.JournalComplete)
+}
+
+func TestRoom_Destroy_SkipsArchiveWithoutEdits(t *testing.T) {
+	// 测试场景：会话内没有编辑，或页面已被删除时不归档
+
+	objects := &MockObjectStore{}
+	archiver := NewArchiveWriter(&MockOpStore{}, objects, "rooms/")
+	go archiver.Run()
+
+	unchanged := newTestRoom("page-1", []byte(`{}`), new(MockPageService))
+	unchanged.archive = archiver
+	unchanged.loadedVersion = 1
+	unchanged.enqueueArchive()
+
+	deleted := newTestRoom("page-2", []byte(`{}`), new(MockPageService))
+	deleted.archive = archiver
+	deleted.Version = 3
+	deleted.stopReason = ErrPageDeleted
+	deleted.enqueueArchive()
+
+	archiver.Close()
+	assert.Empty(t, objects.Objects())
+}
+
+func TestArchiveWriter_MarksJournalGaps(t *testing.T) {
+	// 测试场景：操作日志缺少版本 3，归档仍上传，但标记日志不完整
+
+	opStore := &MockOpStore{}
+	_ = opStore.CreateBatch([]*entity.PageOp{
+		{PageID: "page-1", Version: 2, Patch: []byte(`[]`)},
+		{PageID: "page-1", Version: 4, Patch: []byte(`[]`)},
+		{PageID: "page-1", Version: 5, Patch: []byte(`[]`)}, // 超出归档范围
+	})
+	objects := &MockObjectStore{}
+	archiver := NewArchiveWriter(opStore, objects, "")
+	go archiver.Run()
+
+	archiver.Enqueue(&RoomArchive{PageID: "page-1", FromVersion: 1, Version: 4, Schema: json.RawMessage(`{}`), ClosedAt: time.Now()})
+	archiver.Close()
+
+	body, ok := objects.Objects()["page-1/000000000001-000000000004.json.gz"]
+	require.True(t, ok)
+
+	archive := decodeTestArchive(t, body)
+	assert.Len(t, archive.Ops, 2)
+	assert.False(t, archive.JournalComplete)
+}
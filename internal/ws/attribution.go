@@ -0,0 +1,175 @@
+package ws
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"lowercode-go-server/internal/jsondiff"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// editedByKey Schema 顶层记录组件最后编辑者的字段，由服务端维护，随 Patch 一起持久化
+const editedByKey = "editedBy"
+
+// EditAttribution 组件的最后编辑者，保存在 Schema 的 editedBy.<componentId> 中
+type EditAttribution struct {
+	UserID   string `json:"userId"`
+	UserName string `json:"userName,omitempty"`
+	Guest    bool   `json:"guest,omitempty"`
+	Version  int64  `json:"version"` // 产生该编辑的页面版本号
+	At       int64  `json:"at"`      // 毫秒时间戳
+}
+
+// PatchResult Patch 应用结果
+type PatchResult struct {
+	Version     int64           // 应用后的页面版本号
+	Patches     json.RawMessage // 实际应用的 Patch：客户端 Patch 加上服务端追加的编辑归属
+	Attribution json.RawMessage // 服务端追加的编辑归属操作，未追加时为 nil
+	Rebased     bool            // Patch 基于旧版本，已按页面的冲突策略改写后应用到最新状态
+}
+
+// touchedComponents 返回 patch 修改的组件 ID（已去重、排序）和被整体删除的组件 ID。
+// 路径不在 /components/{id} 之下的操作（如整体替换 /components）不计入。
+func touchedComponents(patch jsonpatch.Patch) (edited, removed []string) {
+	editedSet := make(map[string]bool)
+	removedSet := make(map[string]bool)
+	for _, op := range patch {
+		path, err := op.Path()
+		if err != nil {
+			continue
+		}
+		id, whole, ok := componentOfPath(path)
+		if !ok {
+			continue
+		}
+		if whole && op.Kind() == "remove" {
+			removedSet[id] = true
+			delete(editedSet, id)
+			continue
+		}
+		editedSet[id] = true
+		delete(removedSet, id)
+	}
+	return sortedSet(editedSet), sortedSet(removedSet)
+}
+
+// componentOfPath 解析 /components/{id}[/...]，whole 表示路径正好指向组件本身
+func componentOfPath(path string) (id string, whole bool, ok bool) {
+	const prefix = "/components/"
+	if !strings.HasPrefix(path, prefix) {
+		return "", false, false
+	}
+	rest := path[len(prefix):]
+	token, _, hasMore := strings.Cut(rest, "/")
+	if token == "" {
+		return "", false, false
+	}
+	token = strings.ReplaceAll(token, "~1", "/")
+	token = strings.ReplaceAll(token, "~0", "~")
+	return token, !hasMore, true
+}
+
+func sortedSet(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// touchesEditedBy 判断 patch 是否直接修改 editedBy，编辑归属只能由服务端写入
+func touchesEditedBy(patch jsonpatch.Patch) bool {
+	root := "/" + editedByKey
+	for _, op := range patch {
+		for _, get := range []func() (string, error){op.Path, op.From} {
+			if p, err := get(); err == nil && (p == root || strings.HasPrefix(p, root+"/")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// attributionOps 生成将 edited 组件的最后编辑者设为 author、并清理 removed 组件归属的操作。
+// state 为应用客户端 Patch 之后的状态。
+func attributionOps(state []byte, edited, removed []string, author EditAttribution) []jsondiff.Operation {
+	var doc struct {
+		EditedBy *map[string]json.RawMessage `json:"editedBy"`
+	}
+	if err := json.Unmarshal(state, &doc); err != nil {
+		return nil
+	}
+
+	var ops []jsondiff.Operation
+	if doc.EditedBy == nil {
+		if len(edited) == 0 {
+			return nil
+		}
+		ops = append(ops, jsondiff.Operation{Op: "add", Path: jsondiff.Pointer(editedByKey), Value: map[string]interface{}{}})
+	}
+	for _, id := range edited {
+		ops = append(ops, jsondiff.Operation{Op: "add", Path: jsondiff.Pointer(editedByKey, id), Value: author})
+	}
+	if doc.EditedBy != nil {
+		for _, id := range removed {
+			if _, ok := (*doc.EditedBy)[id]; ok {
+				ops = append(ops, jsondiff.Operation{Op: "remove", Path: jsondiff.Pointer(editedByKey, id)})
+			}
+		}
+	}
+	return ops
+}
+
+// attributeLocked 在 modified 上追加编辑归属，返回追加后的状态和追加的操作（JSON）。
+// author 为空（系统操作）或 Patch 未涉及组件时原样返回。调用方需持有 stateMu 写锁。
+func (r *Room) attributeLocked(modified []byte, patch jsonpatch.Patch, author UserInfo, version int64) ([]byte, json.RawMessage) {
+	if author.UserID == "" {
+		return modified, nil
+	}
+	edited, removed := touchedComponents(patch)
+	ops := attributionOps(modified, edited, removed, EditAttribution{
+		UserID:   author.UserID,
+		UserName: author.UserName,
+		Guest:    author.Guest,
+		Version:  version,
+		At:       time.Now().UnixMilli(),
+	})
+	if len(ops) == 0 {
+		return modified, nil
+	}
+
+	opsBytes, _ := json.Marshal(ops)
+	attribution, err := jsonpatch.DecodePatch(opsBytes)
+	if err != nil {
+		return modified, nil
+	}
+	attributed, err := attribution.Apply(modified)
+	if err != nil {
+		return modified, nil
+	}
+	return attributed, opsBytes
+}
+
+// concatPatches 拼接两个 JSON Patch 数组
+func concatPatches(a, b json.RawMessage) json.RawMessage {
+	if len(b) == 0 {
+		return a
+	}
+	var ops []json.RawMessage
+	if err := json.Unmarshal(a, &ops); err != nil {
+		return a
+	}
+	var extra []json.RawMessage
+	if err := json.Unmarshal(b, &extra); err != nil {
+		return a
+	}
+	combined, _ := json.Marshal(append(ops, extra...))
+	return combined
+}
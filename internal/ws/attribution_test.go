@@ -0,0 +1,126 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== 编辑归属单元测试 ==========
+// 测试重点：服务端在同一版本内写入 editedBy，转发时使用服务端确认的发送者身份
+
+const attributionState = `{"rootId": 1, "components": {"1": {"id": 1, "children": [2]}, "2": {"id": 2, "desc": "a"}}}`
+
+func readEditedBy(t *testing.T, state []byte) map[string]EditAttribution {
+	t.Helper()
+	var doc struct {
+		EditedBy map[string]EditAttribution `json:"editedBy"`
+	}
+	assert.NoError(t, json.Unmarshal(state, &doc))
+	return doc.EditedBy
+}
+
+func TestRoom_ApplyEdit_Attribution(t *testing.T) {
+	// 测试场景：Alice 修改组件 2，editedBy 被创建并记录 Alice 和新版本号；
+	// 随后 Bob 删除组件 2，其归属记录一并删除，Bob 成为组件 1 的最后编辑者
+
+	room := newTestRoom("test-room", []byte(attributionState), new(MockPageService))
+	alice := UserInfo{UserID: "alice", UserName: "Alice"}
+	bob := UserInfo{UserID: "bob", UserName: "Bob", Guest: true}
+
+	result, err := room.ApplyEdit(alice, []byte(`[{"op": "replace", "path": "/components/2/desc", "value": "b"}]`), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), result.Version)
+	assert.NotNil(t, result.Attribution)
+
+	var ops []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(result.Patches, &ops))
+	assert.Len(t, ops, 3) // 客户端操作 + 创建 editedBy + 组件 2 的归属
+
+	state, _ := room.GetSnapshot()
+	editedBy := readEditedBy(t, state)
+	assert.Len(t, editedBy, 1)
+	assert.Equal(t, "alice", editedBy["2"].UserID)
+	assert.Equal(t, "Alice", editedBy["2"].UserName)
+	assert.Equal(t, int64(2), editedBy["2"].Version)
+
+	_, err = room.ApplyEdit(bob, []byte(`[
+		{"op": "remove", "path": "/components/1/children/0"},
+		{"op": "remove", "path": "/components/2"}
+	]`), 2)
+	assert.NoError(t, err)
+
+	state, _ = room.GetSnapshot()
+	editedBy = readEditedBy(t, state)
+	assert.NotContains(t, editedBy, "2")
+	assert.Equal(t, EditAttribution{UserID: "bob", UserName: "Bob", Guest: true, Version: 3, At: editedBy["1"].At}, editedBy["1"])
+}
+
+func TestRoom_ApplyEdit_RejectsEditedBy(t *testing.T) {
+	// 测试场景：客户端不能直接伪造 editedBy；系统操作（无作者）不写入归属
+
+	room := newTestRoom("test-room", []byte(attributionState), new(MockPageService))
+
+	_, err := room.ApplyEdit(UserInfo{UserID: "mallory"}, []byte(`[{"op": "add", "path": "/editedBy", "value": {}}]`), 1)
+	var patchErr *PatchError
+	assert.ErrorAs(t, err, &patchErr)
+
+	assert.NoError(t, room.ApplyPatch([]byte(`[{"op": "replace", "path": "/components/2/desc", "value": "b"}]`), 1))
+	state, _ := room.GetSnapshot()
+	assert.Nil(t, readEditedBy(t, state))
+}
+
+func TestClient_OpPatch_StampsSender(t *testing.T) {
+	// 测试场景：客户端自填的 senderId 被忽略，广播使用认证身份和基准版本；
+	// 发送者收到 ack，包含新版本号和服务端追加的归属操作
+
+	room := newTestRoom("test-room", []byte(attributionState), new(MockPageService))
+	alice := &Client{
+		UserInfo: UserInfo{UserID: "alice", UserName: "Alice"},
+		RoomID:   "test-room",
+		send:     make(chan []byte, 8),
+		Room:     room,
+	}
+
+	alice.handleOpPatch([]byte(`{"type": "op-patch", "senderId": "bob", "payload": {
+		"patches": [{"op": "replace", "path": "/components/2/desc", "value": "b"}],
+		"version": 1
+	}}`))
+
+	var ack WSMessage
+	assert.NoError(t, json.Unmarshal(<-alice.send, &ack))
+	assert.Equal(t, TypeAck, ack.Type)
+	var ackPayload AckPayload
+	assert.NoError(t, json.Unmarshal(ack.Payload, &ackPayload))
+	assert.Equal(t, int64(2), ackPayload.Version)
+	assert.NotEmpty(t, ackPayload.Patches)
+
+	b := <-room.broadcast
+	assert.True(t, b.IsCritical)
+	var msg WSMessage
+	assert.NoError(t, json.Unmarshal(b.Message, &msg))
+	assert.Equal(t, TypeOpPatch, msg.Type)
+	assert.Equal(t, "alice", msg.SenderID)
+	var payload OpPatchPayload
+	assert.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, int64(1), payload.Version)
+}
+
+func TestTouchedComponents(t *testing.T) {
+	// 测试场景：解析组件路径（含转义），整体删除单独列出，非组件路径忽略
+
+	patch, err := jsonpatch.DecodePatch([]byte(`[
+		{"op": "replace", "path": "/components/1/props/text", "value": "x"},
+		{"op": "add", "path": "/components/a~1b", "value": {}},
+		{"op": "remove", "path": "/components/3"},
+		{"op": "replace", "path": "/rootId", "value": 1},
+		{"op": "replace", "path": "/components", "value": {}}
+	]`))
+	assert.NoError(t, err)
+
+	edited, removed := touchedComponents(patch)
+	assert.Equal(t, []string{"1", "a/b"}, edited)
+	assert.Equal(t, []string{"3"}, removed)
+}
@@ -0,0 +1,113 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/logging"
+)
+
+// ConflictBackupThreshold 连续版本冲突达到该次数后，连接可以上传一次冲突备份
+const ConflictBackupThreshold = 3
+
+// ConflictBackupStore 冲突备份持久化接口，由 repository 层实现
+type ConflictBackupStore interface {
+	Save(backup *entity.ConflictBackup) error
+}
+
+// WithConflictBackups 启用冲突备份：客户端连续冲突或被强制重新同步后，
+// 可以上传被服务端拒绝的本地状态，供用户之后手动找回。未配置时上传收到 FEATURE_DISABLED。
+func WithConflictBackups(store ConflictBackupStore) HubOption {
+	return func(h *Hub) {
+		h.backups = store
+	}
+}
+
+// ConflictBackupPayload conflict-backup 消息的 payload 结构
+type ConflictBackupPayload struct {
+	BaseVersion int64           `json:"baseVersion"` // 本地状态所基于的服务端版本
+	Reason      string          `json:"reason"`      // conflict / resync
+	Schema      json.RawMessage `json:"schema"`      // 客户端本地的完整 Schema
+}
+
+// ConflictBackupSavedPayload conflict-backup-saved 消息的 payload 结构
+type ConflictBackupSavedPayload struct {
+	ID          uint  `json:"id"`
+	BaseVersion int64 `json:"baseVersion"`
+}
+
+// allowConflictBackup 标记连接可以上传一次冲突备份，可在任意 goroutine 中调用
+func (c *Client) allowConflictBackup() {
+	c.backupAllowed.Store(true)
+}
+
+// trackConflict 记录 op-patch 的处理结果，只在 ReadPump 中调用。
+// 连续版本冲突达到 ConflictBackupThreshold 次时允许上传冲突备份，成功应用后重新计数。
+func (c *Client) trackConflict(conflicted bool) {
+	if !conflicted {
+		c.conflicts = 0
+		return
+	}
+	c.conflicts++
+	if c.conflicts >= ConflictBackupThreshold {
+		c.allowConflictBackup()
+	}
+}
+
+// handleConflictBackup 保存客户端上传的冲突备份，在客户端 goroutine 内同步写入，不经过房间事件循环。
+// 每次连续冲突或强制重新同步只接受一份备份，防止客户端反复上传整页 Schema。
+func (c *Client) handleConflictBackup(payload json.RawMessage) {
+	if c.Room == nil {
+		c.sendError(ErrRoomNotFound, c.RoomID)
+		return
+	}
+	if c.Room.backups == nil {
+		c.sendError(ErrFeatureDisabled, "服务端未开启冲突备份")
+		return
+	}
+	if c.UserInfo.ReadOnly() {
+		c.sendError(ErrUnauthorized, "只读连接不能上传冲突备份")
+		return
+	}
+
+	var backup ConflictBackupPayload
+	if err := json.Unmarshal(payload, &backup); err != nil || !validBackupSchema(backup.Schema) ||
+		(backup.Reason != entity.ConflictBackupReasonConflict && backup.Reason != entity.ConflictBackupReasonResync) {
+		c.sendError(ErrInvalidMessage, "conflict-backup 格式错误")
+		return
+	}
+	if !c.backupAllowed.CompareAndSwap(true, false) {
+		c.sendError(ErrInvalidMessage, "当前连接没有需要备份的冲突")
+		return
+	}
+
+	record := &entity.ConflictBackup{
+		PageID:      c.RoomID,
+		UserID:      c.UserInfo.UserID,
+		UserName:    c.UserInfo.UserName,
+		BaseVersion: backup.BaseVersion,
+		Reason:      backup.Reason,
+		Schema:      []byte(backup.Schema),
+	}
+	if err := c.Room.backups.Save(record); err != nil {
+		c.allowConflictBackup()
+		logging.Errorf("[Room %s] 保存用户 [%s] 的冲突备份失败: %v", c.RoomID, c.UserInfo.UserName, err)
+		c.sendError(ErrInternalError, fmt.Sprintf("保存冲突备份失败: %v", err))
+		return
+	}
+
+	c.send <- encodeServerMessage(TypeConflictBackupSaved, ConflictBackupSavedPayload{
+		ID:          record.ID,
+		BaseVersion: record.BaseVersion,
+	})
+	log.Printf("[Room %s] 已保存用户 [%s] 的冲突备份 #%d（%s，基于版本 %d）",
+		c.RoomID, c.UserInfo.UserName, record.ID, record.Reason, record.BaseVersion)
+}
+
+// validBackupSchema 冲突备份必须是 JSON 对象
+func validBackupSchema(schema json.RawMessage) bool {
+	var obj map[string]json.RawMessage
+	return json.Unmarshal(schema, &obj) == nil && obj != nil
+}
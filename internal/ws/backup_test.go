@@ -0,0 +1,148 @@
+package ws
+
+import (
+	"errors"
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 冲突备份单元测试 ==========
+
+const testBackupPayload = `{"baseVersion": 1, "reason": "conflict", "schema": {"title": "mine"}}`
+
+// sendStalePatch 以过期版本提交一次 op-patch 并读掉 VERSION_CONFLICT 错误
+func sendStalePatch(t *testing.T, c *Client) {
+	t.Helper()
+	c.handleOpPatch([]byte(`{"type": "op-patch", "payload": {
+		"patches": [{"op": "replace", "path": "/title", "value": "x"}],
+		"version": 99
+	}}`))
+	var conflict ErrorPayload
+	require.Equal(t, TypeError, readTestMessage(t, c, &conflict))
+	require.Equal(t, ErrVersionConflict, conflict.Code)
+}
+
+func TestClient_ConflictBackup_AfterRepeatedConflicts(t *testing.T) {
+	// 测试场景：连续冲突未达到阈值时拒绝上传；达到阈值后可以上传一份，
+	// 收到带 ID 的 conflict-backup-saved，再次上传被拒绝
+
+	store := &MockConflictBackupStore{}
+	room := newTestRoom("test-room", []byte(`{"title": "a"}`), new(MockPageService))
+	room.backups = store
+	alice := newAckTestClient(room)
+
+	for i := 0; i < ConflictBackupThreshold-1; i++ {
+		sendStalePatch(t, alice)
+	}
+	alice.handleConflictBackup([]byte(`{"baseVersion": 1, "reason": "conflict", "schema": {}}`))
+	var rejected ErrorPayload
+	assert.Equal(t, TypeError, readTestMessage(t, alice, &rejected))
+	assert.Equal(t, ErrInvalidMessage, rejected.Code)
+
+	sendStalePatch(t, alice)
+	alice.handleConflictBackup([]byte(testBackupPayload))
+	var saved ConflictBackupSavedPayload
+	assert.Equal(t, TypeConflictBackupSaved, readTestMessage(t, alice, &saved))
+	assert.Equal(t, ConflictBackupSavedPayload{ID: 1, BaseVersion: 1}, saved)
+
+	require.Len(t, store.saved, 1)
+	assert.Equal(t, "test-room", store.saved[0].PageID)
+	assert.Equal(t, "alice", store.saved[0].UserID)
+	assert.Equal(t, entity.ConflictBackupReasonConflict, store.saved[0].Reason)
+	assert.JSONEq(t, `{"title": "mine"}`, string(store.saved[0].Schema))
+
+	alice.handleConflictBackup([]byte(testBackupPayload))
+	assert.Equal(t, TypeError, readTestMessage(t, alice, &rejected))
+	assert.Equal(t, ErrInvalidMessage, rejected.Code)
+	assert.Len(t, store.saved, 1)
+}
+
+func TestClient_ConflictBackup_ConflictCountResets(t *testing.T) {
+	// 测试场景：中间有一次编辑成功时重新计数，不会因累计冲突而允许上传
+
+	room := newTestRoom("test-room", []byte(`{"title": "a"}`), new(MockPageService))
+	room.backups = &MockConflictBackupStore{}
+	alice := newAckTestClient(room)
+
+	sendStalePatch(t, alice)
+	sendStalePatch(t, alice)
+	alice.handleOpPatch([]byte(`{"type": "op-patch", "payload": {
+		"patches": [{"op": "replace", "path": "/title", "value": "b"}],
+		"version": 1
+	}}`))
+	var ack AckPayload
+	require.Equal(t, TypeAck, readTestMessage(t, alice, &ack))
+	<-room.broadcast
+	sendStalePatch(t, alice)
+
+	assert.False(t, alice.backupAllowed.Load())
+}
+
+func TestClient_ConflictBackup_Rejected(t *testing.T) {
+	// 测试场景：未开启冲突备份时收到 FEATURE_DISABLED；只读连接收到 UNAUTHORIZED；
+	// Schema 不是对象或 reason 未知时收到 INVALID_MESSAGE；保存失败后仍可重试
+
+	room := newTestRoom("test-room", []byte(`{}`), new(MockPageService))
+	alice := newAckTestClient(room)
+	alice.allowConflictBackup()
+
+	var errPayload ErrorPayload
+	alice.handleConflictBackup([]byte(testBackupPayload))
+	assert.Equal(t, TypeError, readTestMessage(t, alice, &errPayload))
+	assert.Equal(t, ErrFeatureDisabled, errPayload.Code)
+
+	store := &MockConflictBackupStore{err: errors.New("db down")}
+	room.backups = store
+
+	viewer := newAckTestClient(room)
+	viewer.UserInfo.Role = entity.RoleViewer
+	viewer.allowConflictBackup()
+	viewer.handleConflictBackup([]byte(testBackupPayload))
+	assert.Equal(t, TypeError, readTestMessage(t, viewer, &errPayload))
+	assert.Equal(t, ErrUnauthorized, errPayload.Code)
+
+	for _, payload := range []string{
+		`{"baseVersion": 1, "reason": "conflict", "schema": [1, 2]}`,
+		`{"baseVersion": 1, "reason": "other", "schema": {}}`,
+	} {
+		alice.handleConflictBackup([]byte(payload))
+		assert.Equal(t, TypeError, readTestMessage(t, alice, &errPayload))
+		assert.Equal(t, ErrInvalidMessage, errPayload.Code, payload)
+	}
+
+	alice.handleConflictBackup([]byte(testBackupPayload))
+	assert.Equal(t, TypeError, readTestMessage(t, alice, &errPayload))
+	assert.Equal(t, ErrInternalError, errPayload.Code)
+
+	store.err = nil
+	alice.handleConflictBackup([]byte(testBackupPayload))
+	var saved ConflictBackupSavedPayload
+	assert.Equal(t, TypeConflictBackupSaved, readTestMessage(t, alice, &saved))
+}
+
+func TestClient_ConflictBackup_AfterResync(t *testing.T) {
+	// 测试场景：请求重新同步或重连无法追赶而收到全量 sync 后，连接可以上传冲突备份
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	room := NewRoom("test-room", []byte(`{}`), mockService, nil)
+	defer room.Stop()
+
+	alice := newAckTestClient(room)
+	require.NoError(t, room.Register(alice))
+	nextTestMessageOfType(t, alice, TypeSync)
+	assert.False(t, alice.backupAllowed.Load())
+	alice.handleRequestSync()
+	assert.True(t, alice.backupAllowed.Load())
+
+	bob := newAckTestClient(room)
+	bob.SinceVersion = 5
+	require.NoError(t, room.Register(bob))
+	nextTestMessageOfType(t, bob, TypeSync)
+	assert.True(t, bob.backupAllowed.Load())
+}
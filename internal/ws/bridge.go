@@ -0,0 +1,371 @@
+package ws
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"expvar"
+	"log"
+	"time"
+
+	"lowercode-go-server/internal/logging"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// bridgeRemoteBuffer 房间待处理的远端消息上限，超出时丢弃，丢失的编辑由版本校验发现后重新同步
+const bridgeRemoteBuffer = 256
+
+// bridgeResyncInterval 向同一房间的其他节点请求完整状态的最小间隔，等待回复期间的后续不一致不再重复请求
+const bridgeResyncInterval = time.Second
+
+// bridgeMetrics 跨节点桥接计数，通过 expvar 暴露：
+// applied 为应用的远端编辑，dropped 为因缓冲区满丢弃的远端消息，forks 为发现的状态分叉，adopted 为采用其他节点的完整状态
+var bridgeMetrics = expvar.NewMap("ws_bridge")
+
+// RoomBridge 在多个节点之间转发同一页面的房间消息，使负载均衡后的多个副本可以同时承载同一页面。
+// 实现只负责按页面发布和订阅消息（如 Redis Pub/Sub），消息格式和状态一致性由房间处理
+type RoomBridge interface {
+	// Publish 向页面频道发布消息。房间在持有状态锁时调用，实现不得阻塞；
+	// 同一节点发布的消息必须按发布顺序送达其他节点
+	Publish(pageID string, data []byte)
+
+	// Subscribe 订阅页面频道，handler 在实现自己的 goroutine 中调用，可能收到本节点发布的消息；
+	// 返回取消订阅函数
+	Subscribe(pageID string, handler func(data []byte)) (unsubscribe func())
+}
+
+// WithRoomBridge 启用跨节点房间桥接。
+// 房间内已应用的编辑和光标等感知类消息会发布给承载同一页面的其他节点，其他节点按版本顺序应用并转发给本地连接；
+// 节点之间的状态分叉（如网络分区期间各自接受了编辑）通过哈希链发现，版本较高（相同时节点 ID 较小）的一方胜出，
+// 另一方采用其完整状态并向本地连接重新同步，分叉期间在落败一方提交的编辑会丢失
+func WithRoomBridge(bridge RoomBridge) HubOption {
+	return func(h *Hub) {
+		h.bridge = bridge
+		h.nodeID = newNodeID()
+	}
+}
+
+// newNodeID 生成本进程在桥接消息中的节点 ID
+func newNodeID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// 桥接消息类型
+const (
+	bridgePatch        = "patch"         // 已应用的编辑，Version 为应用后的版本，Hash 为应用后的哈希链
+	bridgeCursor       = "cursor"        // 光标等感知类消息，原样投递给本地连接
+	bridgePersisted    = "persisted"     // 刷盘成功，Version / Hash 为已落盘的版本
+	bridgeStateRequest = "state-request" // 请求完整状态，Target 为空时由所有节点回复
+	bridgeState        = "state"         // 完整状态，回复给 Target
+)
+
+// bridgeMessage 节点之间转发的房间消息
+type bridgeMessage struct {
+	Kind      string          `json:"kind"`
+	Node      string          `json:"node"`             // 发布节点
+	Target    string          `json:"target,omitempty"` // 非空时只有该节点处理
+	Version   int64           `json:"version,omitempty"`
+	Hash      string          `json:"hash,omitempty"`
+	Persisted int64           `json:"persisted,omitempty"` // state：发布节点已落盘的版本
+	Patches   json.RawMessage `json:"patches,omitempty"`
+	State     json.RawMessage `json:"state,omitempty"`
+	Label     string          `json:"label,omitempty"`
+	Author    *UserInfo       `json:"author,omitempty"`
+	Message   json.RawMessage `json:"message,omitempty"` // cursor：原始消息
+}
+
+// chainHash 在哈希链上追加一个 Patch。两个节点从相同的状态出发、按相同顺序应用相同的 Patch 时哈希链相同，
+// 版本号相同而哈希链不同说明状态已经分叉。
+// Patch 随桥接消息序列化时会被压缩并转义 HTML 字符，哈希前做同样的规范化，使发送和接收两端的输入一致
+func chainHash(prev string, patch []byte) string {
+	var compact, canonical bytes.Buffer
+	if err := json.Compact(&compact, patch); err == nil {
+		json.HTMLEscape(&canonical, compact.Bytes())
+		patch = canonical.Bytes()
+	}
+	sum := sha256.New()
+	sum.Write([]byte(prev))
+	sum.Write(patch)
+	return hex.EncodeToString(sum.Sum(nil)[:8])
+}
+
+// joinBridge 订阅页面频道并向其他节点请求完整状态。其他节点的内存中可能有尚未刷盘的编辑，
+// 回复的状态版本更高时采用。调用方需在设置好初始版本后、房间对外可见前调用
+func (r *Room) joinBridge() {
+	if r.bridge == nil {
+		return
+	}
+	r.stateMu.Lock()
+	r.chain = chainHash("", r.CurrentState)
+	r.stateMu.Unlock()
+
+	r.unsubscribe = r.bridge.Subscribe(r.ID, r.receiveBridge)
+	r.publishBridge(&bridgeMessage{Kind: bridgeStateRequest})
+}
+
+// leaveBridge 取消订阅，房间事件循环退出时调用
+func (r *Room) leaveBridge() {
+	if r.unsubscribe != nil {
+		r.unsubscribe()
+	}
+}
+
+// publishBridge 发布桥接消息，未启用桥接时忽略
+func (r *Room) publishBridge(msg *bridgeMessage) {
+	if r.bridge == nil {
+		return
+	}
+	msg.Node = r.nodeID
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logging.Errorf("[Room %s] 桥接消息序列化失败: %v", r.ID, err)
+		return
+	}
+	r.bridge.Publish(r.ID, data)
+}
+
+// relayPatchLocked 发布刚应用的编辑，调用方需持有 stateMu 且已推进 Version 和哈希链，保证发布顺序与版本顺序一致
+func (r *Room) relayPatchLocked(patch []byte, author UserInfo, label string) {
+	if r.bridge == nil {
+		return
+	}
+	r.publishBridge(&bridgeMessage{
+		Kind:    bridgePatch,
+		Version: r.Version,
+		Hash:    r.chain,
+		Patches: patch,
+		Label:   label,
+		Author:  &author,
+	})
+}
+
+// receiveBridge 在桥接实现的 goroutine 中接收消息，转交 run() 处理；忽略本节点发布的和发给其他节点的消息
+func (r *Room) receiveBridge(data []byte) {
+	var msg bridgeMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		logging.Warnf("[Room %s] 忽略无法解析的桥接消息: %v", r.ID, err)
+		return
+	}
+	if msg.Node == r.nodeID || (msg.Target != "" && msg.Target != r.nodeID) {
+		return
+	}
+	select {
+	case r.remote <- &msg:
+	default:
+		bridgeMetrics.Add("dropped", 1)
+	}
+}
+
+// handleBridgeMessage 处理其他节点的消息，仅在 run() 内调用
+func (r *Room) handleBridgeMessage(msg *bridgeMessage) {
+	switch msg.Kind {
+	case bridgePatch:
+		r.applyRemotePatch(msg)
+	case bridgeCursor:
+		r.deliver(&RoomBroadcast{Message: msg.Message, Cursor: true})
+	case bridgePersisted:
+		r.notePeerPersisted(msg.Version, msg.Hash)
+	case bridgeStateRequest:
+		r.publishState(msg.Node)
+	case bridgeState:
+		r.adoptState(msg)
+	}
+}
+
+// applyRemotePatch 应用其他节点的编辑并转发给本地连接。
+// 只有紧接当前版本且哈希链一致的编辑才会应用，否则按 reconcileLocked 的结果重新同步。
+// 远端编辑的操作日志和页面活动由发起节点记录
+func (r *Room) applyRemotePatch(msg *bridgeMessage) {
+	r.stateMu.Lock()
+	if msg.Version == r.Version+1 && chainHash(r.chain, msg.Patches) == msg.Hash {
+		patch, err := jsonpatch.DecodePatch(msg.Patches)
+		var modified []byte
+		if err == nil {
+			modified, err = patch.Apply(r.CurrentState)
+		}
+		if err == nil {
+			author := UserInfo{}
+			if msg.Author != nil {
+				author = *msg.Author
+			}
+			r.CurrentState = modified
+			r.Version++
+			r.rememberPatchLocked(msg.Patches, author.UserID)
+			r.bufferPatchLocked(msg.Patches)
+			r.invalidateTextDocs(patch)
+			r.stateMu.Unlock()
+
+			bridgeMetrics.Add("applied", 1)
+			r.deliver(&RoomBroadcast{
+				Message: encodeMessage(TypeOpPatch, author.UserID, OpPatchPayload{
+					Patches: msg.Patches,
+					Version: msg.Version - 1,
+					Label:   msg.Label,
+				}),
+				IsCritical: true,
+			})
+			return
+		}
+		logging.Errorf("[Room %s] 远端编辑 %d 应用失败: %v", r.ID, msg.Version, err)
+	}
+	action := r.reconcileLocked(msg.Version, msg.Hash, msg.Node)
+	r.stateMu.Unlock()
+
+	r.reconcile(action, msg.Node)
+}
+
+// bridgeAction 发现与其他节点不一致后的处理
+type bridgeAction int
+
+const (
+	bridgeIgnore       bridgeAction = iota // 本节点已有该版本
+	bridgeRequestState                     // 本节点落后或分叉落败，请求对方的完整状态
+	bridgeSendState                        // 分叉胜出，把完整状态发给对方
+)
+
+// reconcileLocked 判断对远端版本 version（哈希链为 hash）的处理，调用方需持有 stateMu
+func (r *Room) reconcileLocked(version int64, hash, node string) bridgeAction {
+	if r.hasVersionLocked(version, hash) {
+		return bridgeIgnore
+	}
+	if version > r.Version {
+		return bridgeRequestState
+	}
+	bridgeMetrics.Add("forks", 1)
+	logging.Warnf("[Room %s] 与节点 %s 的状态分叉（本节点版本 %d，对方版本 %d）", r.ID, node, r.Version, version)
+	if version < r.Version || r.nodeID < node {
+		return bridgeSendState
+	}
+	return bridgeRequestState
+}
+
+// hasVersionLocked 判断本节点是否已有哈希链为 hash 的 version，调用方需持有 stateMu
+func (r *Room) hasVersionLocked(version int64, hash string) bool {
+	if version == r.Version {
+		return hash == r.chain
+	}
+	for _, p := range r.recentPatches {
+		if p.version == version {
+			return p.hash == hash
+		}
+	}
+	return false
+}
+
+// reconcile 执行 reconcileLocked 的结果，仅在 run() 内调用
+func (r *Room) reconcile(action bridgeAction, node string) {
+	switch action {
+	case bridgeSendState:
+		r.publishState(node)
+	case bridgeRequestState:
+		now := time.Now()
+		if now.Sub(r.bridgeRequestedAt) < bridgeResyncInterval {
+			return
+		}
+		r.bridgeRequestedAt = now
+		r.publishBridge(&bridgeMessage{Kind: bridgeStateRequest, Target: node})
+	}
+}
+
+// publishState 把完整状态发给节点 target，仅在 run() 内调用
+func (r *Room) publishState(target string) {
+	r.stateMu.RLock()
+	msg := &bridgeMessage{
+		Kind:      bridgeState,
+		Target:    target,
+		Version:   r.Version,
+		Hash:      r.chain,
+		Persisted: r.lastPersistedVersion,
+		State:     append(json.RawMessage(nil), r.CurrentState...),
+	}
+	r.stateMu.RUnlock()
+	r.publishBridge(msg)
+}
+
+// adoptState 对方的状态版本更高，或版本相同、内容不同且对方节点 ID 较小时，采用对方的完整状态并向本地连接重新同步。
+// 内存中的 Patch 窗口和差量缓存随之清空，之后的重连追赶从操作日志读取，下次刷盘写全量快照。仅在 run() 内调用
+func (r *Room) adoptState(msg *bridgeMessage) {
+	r.stateMu.Lock()
+	adopt := msg.Version > r.Version
+	if msg.Version == r.Version && msg.Hash != r.chain {
+		if jsonpatch.Equal(r.CurrentState, msg.State) {
+			// 内容相同，只是哈希链的起点不同（如本节点刚从数据库加载），对齐后不必重新同步
+			r.chain = msg.Hash
+		} else if msg.Node < r.nodeID {
+			adopt = true
+			logging.Warnf("[Room %s] 采用节点 %s 的状态，丢弃本节点分叉的版本 %d", r.ID, msg.Node, r.Version)
+		}
+	}
+	if adopt {
+		r.CurrentState = msg.State
+		r.Version = msg.Version
+		r.chain = msg.Hash
+		r.recentPatches = nil
+		r.pendingPatches = nil
+		r.textDocs = make(map[string]*textDoc)
+		r.notifyVersionLocked()
+	}
+	// 数据库版本是所有节点成功刷盘的最大版本，按它做下一次刷盘的乐观锁校验
+	if msg.Hash == r.chain && msg.Persisted > r.lastPersistedVersion {
+		r.lastPersistedVersion = min(msg.Persisted, r.Version)
+	}
+	r.stateMu.Unlock()
+
+	if !adopt {
+		return
+	}
+	bridgeMetrics.Add("adopted", 1)
+	log.Printf("[Room %s] 已采用节点 %s 的状态，版本: %d", r.ID, msg.Node, msg.Version)
+	for client := range r.clients {
+		r.resyncClient(client)
+	}
+}
+
+// notePeerPersisted 其他节点已把 version 刷盘，本节点的同一版本（哈希链一致）无需再写，仅在 run() 内调用
+func (r *Room) notePeerPersisted(version int64, hash string) {
+	r.stateMu.Lock()
+	if version <= r.lastPersistedVersion || !r.hasVersionLocked(version, hash) {
+		r.stateMu.Unlock()
+		return
+	}
+	editors := r.takePersistedEditorsLocked(version)
+	r.trimPendingLocked(version - r.lastPersistedVersion)
+	r.lastPersistedVersion = version
+	r.stateMu.Unlock()
+
+	r.recordEdited(editors)
+}
+
+// relayCursor 发布本地连接的光标等感知类消息，仅在 run() 内调用
+func (r *Room) relayCursor(msg *RoomBroadcast) {
+	if r.bridge == nil || !msg.Cursor || msg.Sender == nil || !r.collabSettings().Cursors {
+		return
+	}
+	r.publishBridge(&bridgeMessage{Kind: bridgeCursor, Message: msg.Message})
+}
+
+// relayPersisted 通知其他节点 version 已刷盘
+func (r *Room) relayPersisted(version int64) {
+	if r.bridge == nil {
+		return
+	}
+	r.stateMu.RLock()
+	hash, ok := r.chain, version == r.Version
+	if !ok {
+		for _, p := range r.recentPatches {
+			if p.version == version {
+				hash, ok = p.hash, true
+				break
+			}
+		}
+	}
+	r.stateMu.RUnlock()
+	if ok {
+		r.publishBridge(&bridgeMessage{Kind: bridgePersisted, Version: version, Hash: hash})
+	}
+}
@@ -0,0 +1,179 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 跨节点房间桥接单元测试 ==========
+
+// newBridgeTestHub 创建通过 bridge 互联、节点 ID 为 nodeID 的 Hub，数据库中的页面 page-1 为版本 1
+func newBridgeTestHub(bridge RoomBridge, nodeID string) (*Hub, *MockPageService) {
+	service := new(MockPageService)
+	service.On("GetPageState", "page-1").Return([]byte(`{"title": "v"}`), int64(1), nil)
+	service.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	hub := NewHub(service, WithRoomBridge(bridge))
+	hub.nodeID = nodeID
+	return hub, service
+}
+
+// assertConverged 等待两个房间的版本和状态一致
+func assertConverged(t *testing.T, a, b *Room, version int64, state string) {
+	t.Helper()
+	assert.Eventually(t, func() bool {
+		stateA, versionA := a.GetSnapshot()
+		stateB, versionB := b.GetSnapshot()
+		return versionA == version && versionB == version &&
+			string(stateA) == string(stateB) && jsonEqual(stateA, state)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func jsonEqual(data []byte, expected string) bool {
+	var a, b interface{}
+	return json.Unmarshal(data, &a) == nil && json.Unmarshal([]byte(expected), &b) == nil && assert.ObjectsAreEqual(a, b)
+}
+
+func TestRoomBridge_ReplicatesEdits(t *testing.T) {
+	// 测试场景：一个节点上的编辑按版本应用到另一个节点并作为 op-patch 转发给本地连接；光标消息原样转发
+
+	bridge := NewMockRoomBridge()
+	hubA, _ := newBridgeTestHub(bridge, "a")
+	hubB, _ := newBridgeTestHub(bridge, "b")
+	roomA, err := hubA.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	defer roomA.Stop()
+	roomB, err := hubB.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	defer roomB.Stop()
+
+	alice := &Client{UserInfo: UserInfo{UserID: "alice"}, RoomID: "page-1", send: make(chan []byte, 16)}
+	require.NoError(t, roomA.Register(alice))
+	bob := &Client{UserInfo: UserInfo{UserID: "bob"}, RoomID: "page-1", send: make(chan []byte, 16)}
+	require.NoError(t, roomB.Register(bob))
+
+	require.NoError(t, roomA.ApplyPatchAs(alice.UserInfo, []byte(`[{"op": "replace", "path": "/title", "value": "a"}]`), 1))
+	require.NoError(t, roomA.ApplyPatchAs(alice.UserInfo, []byte(`[{"op": "replace", "path": "/title", "value": "b"}]`), 2))
+	assertConverged(t, roomA, roomB, 3, `{"title": "b"}`)
+
+	msg := nextTestMessageOfType(t, bob, TypeOpPatch)
+	assert.Equal(t, "alice", msg.SenderID)
+	var payload OpPatchPayload
+	require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, int64(1), payload.Version)
+
+	roomA.broadcastCursor(encodeMessage(TypeCursorMove, "alice", map[string]int{"x": 1}), alice)
+	msg = nextTestMessageOfType(t, bob, TypeCursorMove)
+	assert.Equal(t, "alice", msg.SenderID)
+}
+
+func TestRoomBridge_JoinAdoptsPeerState(t *testing.T) {
+	// 测试场景：新建房间时数据库中的版本落后于其他节点内存中的状态，加入后采用其他节点的状态
+
+	bridge := NewMockRoomBridge()
+	hubA, _ := newBridgeTestHub(bridge, "a")
+	hubB, _ := newBridgeTestHub(bridge, "b")
+	roomA, err := hubA.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	defer roomA.Stop()
+
+	require.NoError(t, roomA.ApplyPatch([]byte(`[{"op": "replace", "path": "/title", "value": "a"}]`), 1))
+	require.NoError(t, roomA.ApplyPatch([]byte(`[{"op": "replace", "path": "/title", "value": "b"}]`), 2))
+
+	roomB, err := hubB.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	defer roomB.Stop()
+	assertConverged(t, roomA, roomB, 3, `{"title": "b"}`)
+
+	// 采用后哈希链一致，之后的编辑照常应用
+	require.NoError(t, roomB.ApplyPatch([]byte(`[{"op": "replace", "path": "/title", "value": "c"}]`), 3))
+	assertConverged(t, roomA, roomB, 4, `{"title": "c"}`)
+}
+
+func TestRoomBridge_ForkConverges(t *testing.T) {
+	// 测试场景：网络分区期间两个节点各自接受编辑，恢复后版本较高的一方胜出，
+	// 另一方采用其状态并向本地连接重新同步
+
+	bridge := NewMockRoomBridge()
+	hubA, _ := newBridgeTestHub(bridge, "a")
+	hubB, _ := newBridgeTestHub(bridge, "b")
+	roomA, err := hubA.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	defer roomA.Stop()
+	roomB, err := hubB.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	defer roomB.Stop()
+	assertConverged(t, roomA, roomB, 1, `{"title": "v"}`)
+
+	alice := &Client{UserInfo: UserInfo{UserID: "alice"}, RoomID: "page-1", send: make(chan []byte, 16)}
+	require.NoError(t, roomA.Register(alice))
+	nextTestMessageOfType(t, alice, TypeSync)
+
+	bridge.SetPartitioned(true)
+	require.NoError(t, roomA.ApplyPatch([]byte(`[{"op": "replace", "path": "/title", "value": "x"}]`), 1))
+	require.NoError(t, roomB.ApplyPatch([]byte(`[{"op": "replace", "path": "/title", "value": "y"}]`), 1))
+	bridge.SetPartitioned(false)
+
+	require.NoError(t, roomB.ApplyPatch([]byte(`[{"op": "replace", "path": "/title", "value": "z"}]`), 2))
+	assertConverged(t, roomA, roomB, 3, `{"title": "z"}`)
+
+	msg := nextTestMessageOfType(t, alice, TypeSync)
+	var payload SyncPayload
+	require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, int64(3), payload.Version)
+}
+
+func TestRoomBridge_PeerPersisted(t *testing.T) {
+	// 测试场景：一个节点刷盘后通知其他节点，哈希链一致的节点不再重复写入同一版本
+
+	bridge := NewMockRoomBridge()
+	hubA, _ := newBridgeTestHub(bridge, "a")
+	hubB, serviceB := newBridgeTestHub(bridge, "b")
+	roomA, err := hubA.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	defer roomA.Stop()
+	roomB, err := hubB.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	defer roomB.Stop()
+
+	require.NoError(t, roomA.ApplyPatch([]byte(`[{"op": "replace", "path": "/title", "value": "a"}]`), 1))
+	assertConverged(t, roomA, roomB, 2, `{"title": "a"}`)
+
+	roomA.persist("测试", false)
+	assert.Eventually(t, func() bool {
+		roomB.stateMu.RLock()
+		defer roomB.stateMu.RUnlock()
+		return roomB.lastPersistedVersion == 2
+	}, time.Second, 10*time.Millisecond)
+
+	roomB.persist("测试", false)
+	serviceB.AssertNotCalled(t, "SavePageState", "page-1", mock.Anything, int64(1), int64(2))
+}
+
+func TestRoom_ReconcileLocked(t *testing.T) {
+	// 测试场景：已有的版本忽略；对方版本更高时请求状态；同版本分叉时节点 ID 较小的一方胜出
+
+	room := &Room{ID: "page-1", nodeID: "b", Version: 3, chain: "h3",
+		recentPatches: []versionedPatch{{version: 2, hash: "h2"}, {version: 3, hash: "h3"}}}
+
+	assert.Equal(t, bridgeIgnore, room.reconcileLocked(2, "h2", "a"))
+	assert.Equal(t, bridgeIgnore, room.reconcileLocked(3, "h3", "a"))
+	assert.Equal(t, bridgeRequestState, room.reconcileLocked(5, "x5", "c"))
+	assert.Equal(t, bridgeSendState, room.reconcileLocked(2, "x2", "a"))
+	assert.Equal(t, bridgeRequestState, room.reconcileLocked(3, "x3", "a"))
+	assert.Equal(t, bridgeSendState, room.reconcileLocked(3, "x3", "c"))
+}
+
+func TestChainHash_Canonical(t *testing.T) {
+	// 测试场景：格式不同但序列化后相同的 Patch 哈希一致，内容不同时哈希不同
+
+	a := chainHash("h", []byte(`[{"op": "add", "path": "/a", "value": "<b>"}]`))
+	b := chainHash("h", []byte(`[{"op":"add","path":"/a","value":"<b>"}]`))
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, chainHash("h", []byte(`[{"op":"add","path":"/a","value":"<c>"}]`)))
+	assert.NotEqual(t, a, chainHash("g", []byte(`[{"op":"add","path":"/a","value":"<b>"}]`)))
+}
@@ -0,0 +1,177 @@
+package ws
+
+import (
+	"encoding/json"
+	"expvar"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/logging"
+)
+
+// 重连追赶配置
+const (
+	CatchUpWindow = 256  // 房间在内存中保留的最近 Patch 数
+	CatchUpMaxOps = 1000 // 单次追赶最多补发的版本数，超过时回退为全量同步
+)
+
+// catchUpMetrics 重连追赶计数，通过 expvar 暴露：served 为补发差量，fallback 为回退全量同步
+var catchUpMetrics = expvar.NewMap("ws_catch_up")
+
+// versionedPatch 内存窗口中的一条 Patch，version 为应用后的版本号
+type versionedPatch struct {
+	version  int64
+	authorID string // 编辑者，供冲突策略区分并发修改
+	patch    json.RawMessage
+	hash     string // 应用后的哈希链，仅启用跨节点桥接时计算，见 bridge.go
+}
+
+// WithCatchUp 允许重连追赶读取内存窗口之外的操作日志。
+// 未启用时只能从房间内存中最近 CatchUpWindow 个版本追赶，房间重建后总是全量同步。
+func WithCatchUp(ops OpReader) HubOption {
+	return func(h *Hub) {
+		h.catchUpOps = ops
+	}
+}
+
+// rememberPatchLocked 把刚应用的 Patch 加入内存窗口，调用方需持有 stateMu 且已推进 Version
+func (r *Room) rememberPatchLocked(patch []byte, authorID string) {
+	if r.bridge != nil {
+		r.chain = chainHash(r.chain, patch)
+	}
+	r.recentPatches = append(r.recentPatches, versionedPatch{
+		version:  r.Version,
+		authorID: authorID,
+		patch:    append(json.RawMessage(nil), patch...),
+		hash:     r.chain,
+	})
+	if drop := len(r.recentPatches) - CatchUpWindow; drop > 0 {
+		r.recentPatches = r.recentPatches[drop:]
+	}
+	r.notifyVersionLocked()
+}
+
+// windowFloorLocked 返回内存窗口中最早的版本号，窗口为空时返回 Version+1，调用方需持有 stateMu
+func (r *Room) windowFloorLocked() int64 {
+	if len(r.recentPatches) == 0 {
+		return r.Version + 1
+	}
+	return r.recentPatches[0].version
+}
+
+// prefetchCatchUp 内存窗口覆盖不到 client.SinceVersion 时，预先读取操作日志。
+// 在 Register 的调用方 goroutine 中执行，避免数据库读取阻塞 run()；失败时回退全量同步。
+func (r *Room) prefetchCatchUp(client *Client) {
+	client.catchUp = r.prefetchOps(client.SinceVersion, client.UserInfo.UserName)
+}
+
+// prefetchOps 内存窗口覆盖不到 since 时读取其后的操作日志，无需读取或读取失败时返回 nil。
+// 不能在 run() 或持有 stateMu 时调用
+func (r *Room) prefetchOps(since int64, reader string) []*entity.PageOp {
+	if since <= 0 || r.catchUpOps == nil {
+		return nil
+	}
+
+	r.stateMu.RLock()
+	version, floor := r.Version, r.windowFloorLocked()
+	r.stateMu.RUnlock()
+
+	if since >= version || version-since > CatchUpMaxOps || since+1 >= floor {
+		return nil
+	}
+
+	ops, err := r.catchUpOps.ListSince(r.ID, since, CatchUpMaxOps)
+	if err != nil {
+		logging.Warnf("[Room %s] 读取操作日志失败，[%s] 回退全量同步: %v", r.ID, reader, err)
+		return nil
+	}
+	return ops
+}
+
+// catchUpPatchesLocked 拼出 client.SinceVersion 之后直到当前版本的连续 Patch，调用方需持有 stateMu
+func (r *Room) catchUpPatchesLocked(client *Client) []json.RawMessage {
+	return r.patchesSinceLocked(client.SinceVersion, client.catchUp)
+}
+
+// patchesSinceLocked 拼出 since 之后直到当前版本的连续 Patch，调用方需持有 stateMu。
+// 内存窗口优先，窗口之前的版本取自预取的操作日志 ops；版本不合法、超过上限或存在缺口时返回 nil。
+func (r *Room) patchesSinceLocked(since int64, ops []*entity.PageOp) []json.RawMessage {
+	if since <= 0 || since > r.Version || r.Version-since > CatchUpMaxOps {
+		return nil
+	}
+
+	floor := r.windowFloorLocked()
+	patches := make([]json.RawMessage, 0, r.Version-since)
+	next := since + 1
+
+	for _, op := range ops {
+		if op.Version >= floor || op.Version > next {
+			break
+		}
+		if op.Version == next {
+			patches = append(patches, json.RawMessage(op.Patch))
+			next++
+		}
+	}
+	for _, p := range r.recentPatches {
+		if p.version > next {
+			break
+		}
+		if p.version == next {
+			patches = append(patches, p.patch)
+			next++
+		}
+	}
+
+	if next != r.Version+1 {
+		return nil
+	}
+	return patches
+}
+
+// sendInitialState 向新加入的客户端发送初始状态：能追赶时发送 catch-up，否则发送全量 sync
+func (r *Room) sendInitialState(client *Client) {
+	defer func() { client.catchUp = nil }()
+
+	r.stateMu.RLock()
+	patches := r.catchUpPatchesLocked(client)
+	version := r.Version
+	revisions := r.textRevisionsLocked(client)
+	r.stateMu.RUnlock()
+
+	if patches == nil {
+		if client.SinceVersion > 0 {
+			catchUpMetrics.Add("fallback", 1)
+			client.allowConflictBackup()
+			logging.Debugf("[Room %s] 无法从版本 %d 追赶 [%s]，发送全量同步",
+				r.ID, client.SinceVersion, client.UserInfo.UserName)
+		}
+		r.sendSyncToClient(client)
+		return
+	}
+
+	payload, _ := json.Marshal(CatchUpPayload{
+		SinceVersion:  client.SinceVersion,
+		Version:       version,
+		ClientID:      client.UserInfo.ClientID,
+		Patches:       patches,
+		Users:         r.peersOf(client),
+		Capabilities:  client.CapabilityList(),
+		TextRevisions: revisions,
+		Selections:    r.selections.snapshot(client),
+		Locks:         r.locks.snapshot(time.Now()),
+		Presentation:  r.presentationState(),
+		Session:       r.sessionState(),
+	})
+	data, _ := json.Marshal(WSMessage{
+		Type:      TypeCatchUp,
+		SenderID:  "server",
+		Payload:   payload,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	client.send <- data
+
+	catchUpMetrics.Add("served", 1)
+	logging.Debugf("[Room %s] 已发送 catch-up 给 [%s], 版本: %d → %d（%d 个 Patch）",
+		r.ID, client.UserInfo.UserName, client.SinceVersion, version, len(patches))
+}
@@ -0,0 +1,157 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 重连追赶单元测试 ==========
+
+// newCatchUpTestRoom 创建一个版本号为 version 的房间，模拟从数据库加载
+func newCatchUpTestRoom(t *testing.T, version int64, ops OpReader) *Room {
+	t.Helper()
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	room := NewRoom("test-room", []byte(`{"title": "v"}`), mockService, nil)
+
+	room.stateMu.Lock()
+	room.Version = version
+	room.lastPersistedVersion = version
+	room.catchUpOps = ops
+	room.stateMu.Unlock()
+	return room
+}
+
+// setTitle 把标题改为 title，基准版本为 version
+func setTitle(t *testing.T, room *Room, title string, version int64) {
+	t.Helper()
+	patch := `[{"op": "replace", "path": "/title", "value": "` + title + `"}]`
+	require.NoError(t, room.ApplyPatch([]byte(patch), version))
+}
+
+func TestRoom_CatchUp_FromMemoryWindow(t *testing.T) {
+	// 测试场景：重连客户端的版本仍在内存窗口内，只收到缺失的 Patch
+
+	room := newCatchUpTestRoom(t, 1, nil)
+	defer room.Stop()
+
+	setTitle(t, room, "a", 1)
+	setTitle(t, room, "b", 2)
+	setTitle(t, room, "c", 3)
+
+	client := &Client{UserInfo: UserInfo{UserID: "alice"}, RoomID: "test-room", send: make(chan []byte, 16)}
+	client.SinceVersion = 2
+	require.NoError(t, room.Register(client))
+
+	msg := nextTestMessageOfType(t, client, TypeCatchUp)
+	var payload CatchUpPayload
+	require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, int64(2), payload.SinceVersion)
+	assert.Equal(t, int64(4), payload.Version)
+	require.Len(t, payload.Patches, 2)
+	assert.JSONEq(t, `[{"op": "replace", "path": "/title", "value": "b"}]`, string(payload.Patches[0]))
+	assert.JSONEq(t, `[{"op": "replace", "path": "/title", "value": "c"}]`, string(payload.Patches[1]))
+}
+
+func TestRoom_CatchUp_UpToDate(t *testing.T) {
+	// 测试场景：客户端已是最新版本，收到不含 Patch 的 catch-up
+
+	room := newCatchUpTestRoom(t, 7, nil)
+	defer room.Stop()
+
+	client := &Client{UserInfo: UserInfo{UserID: "alice"}, RoomID: "test-room", send: make(chan []byte, 16)}
+	client.SinceVersion = 7
+	require.NoError(t, room.Register(client))
+
+	msg := nextTestMessageOfType(t, client, TypeCatchUp)
+	var payload CatchUpPayload
+	require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, int64(7), payload.Version)
+	assert.Empty(t, payload.Patches)
+}
+
+func TestRoom_CatchUp_FromOpLog(t *testing.T) {
+	// 测试场景：房间从数据库加载后，窗口之前的版本从操作日志补齐，之后的版本取自内存
+
+	ops := &MockOpStore{}
+	require.NoError(t, ops.CreateBatch([]*entity.PageOp{
+		{PageID: "test-room", Version: 4, Patch: []byte(`[{"op": "replace", "path": "/title", "value": "v4"}]`)},
+		{PageID: "test-room", Version: 5, Patch: []byte(`[{"op": "replace", "path": "/title", "value": "v"}]`)},
+	}))
+
+	room := newCatchUpTestRoom(t, 5, ops)
+	defer room.Stop()
+	setTitle(t, room, "v6", 5)
+
+	client := &Client{UserInfo: UserInfo{UserID: "alice"}, RoomID: "test-room", send: make(chan []byte, 16)}
+	client.SinceVersion = 3
+	require.NoError(t, room.Register(client))
+
+	msg := nextTestMessageOfType(t, client, TypeCatchUp)
+	var payload CatchUpPayload
+	require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, int64(6), payload.Version)
+	require.Len(t, payload.Patches, 3)
+	assert.JSONEq(t, `[{"op": "replace", "path": "/title", "value": "v4"}]`, string(payload.Patches[0]))
+	assert.JSONEq(t, `[{"op": "replace", "path": "/title", "value": "v6"}]`, string(payload.Patches[2]))
+}
+
+func TestRoom_CatchUp_FallsBackToSync(t *testing.T) {
+	// 测试场景：操作日志有缺口、版本超前或无法读取日志时，回退为全量 sync
+
+	ops := &MockOpStore{}
+	require.NoError(t, ops.CreateBatch([]*entity.PageOp{
+		{PageID: "test-room", Version: 5, Patch: []byte(`[{"op": "replace", "path": "/title", "value": "v"}]`)},
+	}))
+
+	tests := []struct {
+		name  string
+		since int64
+		ops   OpReader
+	}{
+		{name: "日志缺口", since: 3, ops: ops},
+		{name: "版本超前", since: 9, ops: ops},
+		{name: "未启用日志读取", since: 4, ops: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := newCatchUpTestRoom(t, 5, tt.ops)
+			defer room.Stop()
+
+			client := &Client{UserInfo: UserInfo{UserID: "alice"}, RoomID: "test-room", send: make(chan []byte, 16)}
+			client.SinceVersion = tt.since
+			require.NoError(t, room.Register(client))
+
+			msg := nextTestMessageOfType(t, client, TypeSync)
+			var payload SyncPayload
+			require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+			assert.Equal(t, int64(5), payload.Version)
+		})
+	}
+}
+
+func TestRoom_RememberPatch_KeepsWindow(t *testing.T) {
+	// 测试场景：内存窗口只保留最近 CatchUpWindow 个版本
+
+	room := newCatchUpTestRoom(t, 0, nil)
+	defer room.Stop()
+
+	room.stateMu.Lock()
+	for i := 0; i < CatchUpWindow+10; i++ {
+		room.Version++
+		room.rememberPatchLocked([]byte(`[]`), "")
+	}
+	floor := room.windowFloorLocked()
+	size := len(room.recentPatches)
+	room.stateMu.Unlock()
+
+	assert.Equal(t, CatchUpWindow, size)
+	assert.Equal(t, int64(11), floor)
+}
@@ -0,0 +1,176 @@
+package ws
+
+import (
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/logging"
+)
+
+// 聊天配置常量
+const (
+	ChatHistoryLimit = 200  // 房间内存中保留、加入时下发的最近聊天条数
+	ChatMaxLength    = 2000 // 单条聊天的最大字符数
+)
+
+// ChatStore 聊天持久化接口，由 repository 层实现
+type ChatStore interface {
+	Save(msg *entity.ChatMessage) error
+	ListRecent(pageID string, limit int) ([]*entity.ChatMessage, error)
+	ChatPersisted(pageID string) (bool, error)
+}
+
+// WithChatStore 启用聊天持久化能力，是否实际写入由页面的 ChatPersisted 设置决定。
+// 未配置时所有房间的聊天都只保存在内存中。
+func WithChatStore(store ChatStore) HubOption {
+	return func(h *Hub) {
+		h.chat = store
+	}
+}
+
+// ChatPayload chat 消息的 payload 结构，客户端发送时只需填写 text
+type ChatPayload struct {
+	User UserInfo `json:"user"`
+	Text string   `json:"text"`
+	Ts   int64    `json:"ts"`
+}
+
+// ChatHistoryPayload chat-history 消息的 payload 结构
+type ChatHistoryPayload struct {
+	Persisted bool          `json:"persisted"` // 为 false 时聊天只保存在房间内存中，房间关闭即清除
+	Messages  []ChatPayload `json:"messages"`
+}
+
+// chatOp 发往 Room 的聊天操作：client 非空时为发送消息，否则为切换持久化设置
+type chatOp struct {
+	client  *Client
+	text    string
+	persist bool
+}
+
+// loadChat 读取页面的持久化设置和最近的聊天记录，在 run() 启动前调用
+func (r *Room) loadChat() {
+	if r.chatStore == nil {
+		return
+	}
+
+	persisted, err := r.chatStore.ChatPersisted(r.ID)
+	if err != nil {
+		logging.Warnf("[Room %s] 读取聊天设置失败，按临时模式处理: %v", r.ID, err)
+		return
+	}
+	r.chatPersisted = persisted
+	if !persisted {
+		return
+	}
+
+	messages, err := r.chatStore.ListRecent(r.ID, ChatHistoryLimit)
+	if err != nil {
+		logging.Warnf("[Room %s] 加载聊天记录失败: %v", r.ID, err)
+		return
+	}
+	for _, m := range messages {
+		r.chatHistory = append(r.chatHistory, ChatPayload{
+			User: UserInfo{UserID: m.UserID, UserName: m.UserName, Guest: m.Guest},
+			Text: m.Text,
+			Ts:   m.CreatedAt.UnixMilli(),
+		})
+	}
+}
+
+// handleChatOp 处理聊天消息或持久化设置变更，仅在 run() 内调用
+func (r *Room) handleChatOp(op *chatOp) {
+	if op.client == nil {
+		r.chatPersisted = op.persist
+		r.broadcastChatHistory()
+		log.Printf("[Room %s] 聊天持久化已切换为: %v", r.ID, op.persist)
+		return
+	}
+	if _, ok := r.clients[op.client]; !ok {
+		return
+	}
+	if !r.collabSettings().Chat {
+		r.sendToClient(op.client, encodeServerMessage(TypeError, ErrorPayload{
+			Code:    ErrFeatureDisabled,
+			Message: "页面已关闭聊天",
+		}))
+		return
+	}
+
+	now := time.Now()
+	msg := ChatPayload{User: op.client.UserInfo, Text: op.text, Ts: now.UnixMilli()}
+
+	r.chatHistory = append(r.chatHistory, msg)
+	if len(r.chatHistory) > ChatHistoryLimit {
+		r.chatHistory = r.chatHistory[len(r.chatHistory)-ChatHistoryLimit:]
+	}
+
+	if r.chatPersisted && r.chatStore != nil {
+		go r.saveChat(&entity.ChatMessage{
+			PageID:    r.ID,
+			UserID:    msg.User.UserID,
+			UserName:  msg.User.UserName,
+			Guest:     msg.User.Guest,
+			Text:      msg.Text,
+			CreatedAt: now,
+		})
+	}
+
+	// 发送者也会收到，作为服务端确认
+	data := encodeMessage(TypeChat, msg.User.UserID, msg)
+	for client := range r.clients {
+		r.sendToClient(client, data)
+	}
+}
+
+// saveChat 写入一条聊天消息，失败只记录日志
+func (r *Room) saveChat(msg *entity.ChatMessage) {
+	if err := r.chatStore.Save(msg); err != nil {
+		logging.Errorf("[Room %s] 保存聊天消息失败: %v", r.ID, err)
+	}
+}
+
+// sendChatHistory 向客户端下发聊天记录，仅在 run() 内调用
+func (r *Room) sendChatHistory(client *Client) {
+	r.sendToClient(client, encodeServerMessage(TypeChatHistory, r.chatHistoryPayload()))
+}
+
+// broadcastChatHistory 向所有客户端下发聊天记录，仅在 run() 内调用
+func (r *Room) broadcastChatHistory() {
+	data := encodeServerMessage(TypeChatHistory, r.chatHistoryPayload())
+	for client := range r.clients {
+		r.sendToClient(client, data)
+	}
+}
+
+func (r *Room) chatHistoryPayload() ChatHistoryPayload {
+	messages := r.chatHistory
+	if messages == nil {
+		messages = []ChatPayload{}
+	}
+	return ChatHistoryPayload{Persisted: r.chatPersisted, Messages: messages}
+}
+
+// Chat 提交一条聊天消息
+func (r *Room) Chat(client *Client, text string) {
+	select {
+	case r.chatOps <- &chatOp{client: client, text: text}:
+	case <-r.stopChan:
+	}
+}
+
+// SetChatPersisted 切换聊天持久化设置，房间内所有人会收到新的 chat-history。
+// 开启前发送的消息不会补写到数据库；关闭后已保存的记录保留，直到页面被删除。
+func (r *Room) SetChatPersisted(enabled bool) {
+	select {
+	case r.chatOps <- &chatOp{persist: enabled}:
+	case <-r.stopChan:
+	}
+}
+
+// validChatText 校验聊天内容：非空且不超过 ChatMaxLength 个字符
+func validChatText(text string) bool {
+	return text != "" && utf8.RuneCountInString(text) <= ChatMaxLength
+}
@@ -0,0 +1,109 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== 聊天单元测试 ==========
+// 测试重点：临时模式只保存在内存，持久化模式写入 ChatStore，设置切换即时生效
+
+func newChatTestRoom(store *MockChatStore) (*Room, *Client, *Client) {
+	room := newTestRoom("page-1", []byte(`{}`), new(MockPageService))
+	room.chatStore = store
+	room.loadChat()
+
+	alice := &Client{UserInfo: UserInfo{UserID: "alice", UserName: "Alice"}, send: make(chan []byte, 8), Room: room}
+	bob := &Client{UserInfo: UserInfo{UserID: "bob", UserName: "Bob"}, send: make(chan []byte, 8), Room: room}
+	room.clients[alice] = true
+	room.clients[bob] = true
+	return room, alice, bob
+}
+
+// readMessage 读取客户端收到的下一条消息并校验类型
+func readMessage(t *testing.T, c *Client, msgType MessageType, payload interface{}) {
+	t.Helper()
+	select {
+	case data := <-c.send:
+		var msg WSMessage
+		assert.NoError(t, json.Unmarshal(data, &msg))
+		assert.Equal(t, msgType, msg.Type)
+		assert.NoError(t, json.Unmarshal(msg.Payload, payload))
+	default:
+		t.Fatalf("%s 未收到 %s 消息", c.UserInfo.UserID, msgType)
+	}
+}
+
+func TestRoom_Chat_Ephemeral(t *testing.T) {
+	// 测试场景：未开启持久化时，消息广播给所有人（含发送者）并保存在内存，不写入 ChatStore
+
+	store := &MockChatStore{saved: make(chan *entity.ChatMessage, 1)}
+	room, alice, bob := newChatTestRoom(store)
+
+	room.handleChatOp(&chatOp{client: alice, text: "hello"})
+
+	for _, c := range []*Client{alice, bob} {
+		var chat ChatPayload
+		readMessage(t, c, TypeChat, &chat)
+		assert.Equal(t, "hello", chat.Text)
+		assert.Equal(t, "alice", chat.User.UserID)
+	}
+	assert.Len(t, room.chatHistory, 1)
+
+	select {
+	case <-store.saved:
+		t.Fatal("临时模式不应写入 ChatStore")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRoom_Chat_Persisted(t *testing.T) {
+	// 测试场景：开启持久化的页面加载历史记录，新消息写入 ChatStore；
+	// 切换为临时模式后所有人收到新的 chat-history，之后的消息不再写入
+
+	store := &MockChatStore{
+		persisted: true,
+		history:   []*entity.ChatMessage{{UserID: "carol", UserName: "Carol", Text: "earlier", CreatedAt: time.Now()}},
+		saved:     make(chan *entity.ChatMessage, 2),
+	}
+	room, alice, bob := newChatTestRoom(store)
+	assert.True(t, room.chatPersisted)
+	assert.Len(t, room.chatHistory, 1)
+
+	room.handleChatOp(&chatOp{client: alice, text: "hello"})
+	select {
+	case saved := <-store.saved:
+		assert.Equal(t, "page-1", saved.PageID)
+		assert.Equal(t, "hello", saved.Text)
+	case <-time.After(time.Second):
+		t.Fatal("持久化模式应写入 ChatStore")
+	}
+	var chat ChatPayload
+	readMessage(t, bob, TypeChat, &chat)
+
+	room.handleChatOp(&chatOp{persist: false})
+	var history ChatHistoryPayload
+	readMessage(t, bob, TypeChatHistory, &history)
+	assert.False(t, history.Persisted)
+	assert.Len(t, history.Messages, 2)
+
+	room.handleChatOp(&chatOp{client: alice, text: "off the record"})
+	select {
+	case <-store.saved:
+		t.Fatal("关闭持久化后不应写入 ChatStore")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestValidChatText(t *testing.T) {
+	// 测试场景：空内容和超长内容被拒绝，长度按字符计算
+
+	assert.False(t, validChatText(""))
+	assert.True(t, validChatText("你好"))
+	assert.False(t, validChatText(string(make([]rune, ChatMaxLength+1))))
+}
@@ -1,72 +1,144 @@
 package ws
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/gorilla/websocket"
-)
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/logging"
 
-// 心跳配置
-const (
-	pongWait       = 60 * time.Second    // 等待 Pong 响应的最大时间
-	pingPeriod     = (pongWait * 9) / 10 // Ping 发送间隔，必须小于 pongWait
-	writeWait      = 10 * time.Second    // 写消息超时时间
-	maxMessageSize = 512 * 1024          // 最大消息大小，防止恶意攻击
+	"github.com/gorilla/websocket"
 )
 
-// Client 代表一个 WebSocket 客户端连接
+// Client 代表一个协同客户端连接，底层传输见 Transport
 type Client struct {
-	Hub      *Hub
-	Conn     *websocket.Conn
-	RoomID   string
-	UserInfo UserInfo
-	Room     *Room       // 所属房间引用
-	send     chan []byte // 发送消息缓冲区
+	Hub       *Hub
+	Transport Transport
+	RoomID    string
+	UserInfo  UserInfo
+	Room      *Room       // 所属房间引用
+	send      chan []byte // 发送消息缓冲区
+
+	// Capabilities 连接时协商成功的可选能力，见 NegotiateCapabilities
+	Capabilities map[string]bool
+
+	// Codec 连接时通过子协议协商的帧编码，为 nil 时使用 JSONCodec
+	Codec Codec
+
+	// lastSyncRequest 上次请求重新同步的时间，只在 ReadPump 中访问
+	lastSyncRequest time.Time
+
+	// lastCursorBroadcast 过载期间上次广播光标、视口的时间，只在 ReadPump 中访问，见 watchdog.go
+	lastCursorBroadcast time.Time
+
+	// conflicts 连续版本冲突次数，只在 ReadPump 中访问；backupAllowed 为 true 时可以上传一次冲突备份，见 backup.go
+	conflicts     int
+	backupAllowed atomic.Bool
+
+	// SinceVersion 重连时客户端已有的版本号，大于 0 时房间尝试只补发之后的 Patch，见 catchup.go
+	SinceVersion int64
+	catchUp      []*entity.PageOp // Register 时预取的操作日志，只在 run() 内读取
+
+	// compression 已协商 permessage-deflate 时的压缩状态，为 nil 时不压缩，见 compression.go
+	compression *connCompression
+
+	// config 心跳与消息大小限制，为 nil 时使用 DefaultConnConfig
+	config *ConnConfig
+
+	// limiter 入站消息限流，为 nil 时不限制，见 ratelimit.go
+	limiter *clientLimiter
+
+	// closeMessage 发送通道关闭后发出的关闭帧内容，为 nil 时发送空关闭帧；在 run() 中关闭 send 前写入
+	closeMessage []byte
+
+	// receivedAt 当前处理的入站消息读到的时间，只在 ReadPump 中访问，见 latency.go
+	receivedAt time.Time
+
+	// appliedAt 已放入发送缓冲区、带应用完成时间的广播，按消息首字节地址索引，受 appliedMu 保护，见 latency.go
+	appliedMu sync.Mutex
+	appliedAt map[*byte]time.Time
+
+	// aiRuns 进行中的流式 AI 生成，按请求 ID 索引取消函数，受 aiMu 保护，见 ai.go
+	aiMu   sync.Mutex
+	aiRuns map[string]context.CancelFunc
 }
 
-// NewClient 创建客户端实例
-func NewClient(hub *Hub, conn *websocket.Conn, roomID string, userInfo UserInfo) *Client {
+// NewClient 创建客户端实例，心跳与限制使用 hub 的连接配置（见 WithConnConfig、WithRateLimit）；
+// userInfo 未指定 ClientID 时生成一个
+func NewClient(hub *Hub, transport Transport, roomID string, userInfo UserInfo) *Client {
+	cfg := DefaultConnConfig
+	if hub != nil && hub.connConfig != nil {
+		cfg = *hub.connConfig
+	}
+	rateLimit := DefaultRateLimit
+	if hub != nil && hub.rateLimit != nil {
+		rateLimit = *hub.rateLimit
+	}
+	if userInfo.ClientID == "" {
+		userInfo.ClientID = newClientID()
+	}
 	return &Client{
-		Hub:      hub,
-		Conn:     conn,
-		RoomID:   roomID,
-		UserInfo: userInfo,
-		send:     make(chan []byte, 256),
+		Hub:       hub,
+		Transport: transport,
+		RoomID:    roomID,
+		UserInfo:  userInfo,
+		send:      make(chan []byte, cfg.SendBufferSize),
+		config:    &cfg,
+		limiter:   newClientLimiter(rateLimit),
+	}
+}
+
+// connConfig 返回连接配置，未设置时使用默认值
+func (c *Client) connConfig() ConnConfig {
+	if c.config == nil {
+		return DefaultConnConfig
 	}
+	return *c.config
 }
 
 // WritePump 负责写消息和发送心跳 Ping
 func (c *Client) WritePump() {
-	ticker := time.NewTicker(pingPeriod)
+	cfg := c.connConfig()
+	ticker := time.NewTicker(cfg.PingPeriod)
 
 	defer func() {
 		ticker.Stop()
-		c.Conn.Close()
+		c.Transport.Close()
 	}()
 
 	for {
 		select {
 		case message, ok := <-c.send:
-			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			c.Transport.SetWriteDeadline(time.Now().Add(cfg.WriteWait))
 
 			if !ok {
 				// send channel 已关闭，发送关闭帧
-				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.Transport.WriteMessage(websocket.CloseMessage, c.closeMessage)
 				return
 			}
 
-			if err := c.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			frameType, frame, err := c.codec().Encode(message)
+			if err != nil {
+				logging.Warnf("[Client] 用户 [%s] 消息编码失败，已丢弃: %v", c.UserInfo.UserName, err)
+				continue
+			}
+			if err := c.writeFrame(frameType, frame); err != nil {
 				return
 			}
+			msgType := outboundType(message)
+			c.recordMessage(DirectionOut, msgType, len(frame))
+			c.observeDelivered(message)
 
 		case <-ticker.C:
 			// 定时发送 Ping 保活
-			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+			c.Transport.SetWriteDeadline(time.Now().Add(cfg.WriteWait))
+			if err := c.Transport.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
 		}
@@ -76,23 +148,27 @@ func (c *Client) WritePump() {
 // ReadPump 负责读消息和处理心跳 Pong
 func (c *Client) ReadPump() {
 	defer func() {
+		c.cancelAIRuns()
 		if c.Room != nil {
 			c.Room.Unregister(c)
 		}
-		c.Conn.Close()
+		c.Transport.Close()
 	}()
 
-	c.Conn.SetReadLimit(maxMessageSize)
-	c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+	cfg := c.connConfig()
+	c.Transport.SetReadLimit(cfg.MaxMessageSize)
+	c.Transport.SetReadDeadline(time.Now().Add(cfg.PongWait))
 
 	// 收到 Pong 时重置读超时
-	c.Conn.SetPongHandler(func(string) error {
-		c.Conn.SetReadDeadline(time.Now().Add(pongWait))
-		return nil
-	})
+	if pinged, ok := c.Transport.(pongTransport); ok {
+		pinged.SetPongHandler(func(string) error {
+			c.Transport.SetReadDeadline(time.Now().Add(cfg.PongWait))
+			return nil
+		})
+	}
 
 	for {
-		_, message, err := c.Conn.ReadMessage()
+		frameType, frame, err := c.Transport.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("[Client] 连接异常关闭: %v", err)
@@ -101,18 +177,95 @@ func (c *Client) ReadPump() {
 		}
 
 		// 收到消息也重置读超时
-		c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+		c.Transport.SetReadDeadline(time.Now().Add(cfg.PongWait))
+
+		if disconnect := c.handleMessage(frameType, frame); disconnect {
+			c.disconnectRateLimited()
+			break
+		}
+	}
+}
 
-		var msg WSMessage
-		json.Unmarshal(message, &msg)
+// handleMessage 解码并分发一条入站消息，返回 true 表示持续超出速率限制，调用方应断开连接
+func (c *Client) handleMessage(frameType int, frame []byte) (disconnect bool) {
+	c.receivedAt = time.Now()
+	message, err := c.decodeFrame(frameType, frame)
+	if err != nil {
+		c.recordMessage(DirectionIn, typeUnknown, len(frame))
+		c.sendError(ErrInvalidMessage, "消息解码失败")
+		return false
+	}
+
+	var msg WSMessage
+	json.Unmarshal(message, &msg)
+	c.recordMessage(DirectionIn, inboundType(msg.Type), len(frame))
+
+	if allowed, disconnect := c.limiter.allow(msg.Type, time.Now()); !allowed {
+		if disconnect {
+			return true
+		}
+		c.rejectRateLimited(msg)
+		return false
+	}
 
-		switch msg.Type {
-		case TypeOpPatch:
-			c.handleOpPatch(message)
-		case TypeCursorMove:
-			c.handleCursorMove(message)
+	if isEditMessage(msg.Type) {
+		if code, reason := c.editDenied(); reason != "" {
+			c.rejectReadOnly(msg, code, reason)
+			return false
 		}
 	}
+
+	switch msg.Type {
+	case TypeOpPatch:
+		c.handleOpPatch(message)
+	case TypeCursorMove:
+		c.handleCursorMove(message)
+	case TypeLockComponent:
+		c.handleLock(lockOpAcquire, msg.Payload)
+	case TypeUnlockComponent:
+		c.handleLock(lockOpRelease, msg.Payload)
+	case TypeLockSteal:
+		c.handleLock(lockOpSteal, msg.Payload)
+	case TypeTextOp:
+		c.handleTextOp(msg.Payload)
+	case TypeSelect:
+		c.handleSelect(msg.Payload)
+	case TypeSelectionChange:
+		c.handleSelectionChange(msg.Payload)
+	case TypeChat:
+		c.handleChat(msg.Payload)
+	case TypeViewportUpdate:
+		c.handleViewportUpdate(msg.Payload)
+	case TypeRequestSync:
+		c.handleRequestSync()
+	case TypeOpValidate:
+		c.handleOpValidate(msg.Payload)
+	case TypePresentation:
+		c.handlePresentation(msg.Payload)
+	case TypeConflictBackup:
+		c.handleConflictBackup(msg.Payload)
+	case TypeAIGenerate:
+		c.handleAIGenerate(msg.Payload)
+	case TypeAICancel:
+		c.handleAICancel(msg.Payload)
+	}
+	return false
+}
+
+// codec 返回连接协商的帧编码
+func (c *Client) codec() Codec {
+	if c.Codec == nil {
+		return JSONCodec
+	}
+	return c.Codec
+}
+
+// decodeFrame 把收到的帧转为 JSON 消息：文本帧总是 JSON，二进制帧按协商的编码解码
+func (c *Client) decodeFrame(frameType int, frame []byte) ([]byte, error) {
+	if frameType == websocket.TextMessage {
+		return frame, nil
+	}
+	return c.codec().Decode(frame)
 }
 
 // handleOpPatch 处理增量编辑补丁消息
@@ -125,50 +278,299 @@ func (c *Client) handleOpPatch(message []byte) {
 	var wsMsg WSMessage
 	json.Unmarshal(message, &wsMsg)
 
-	var patchPayload struct {
-		Patches json.RawMessage `json:"patches"`
-		Version int64           `json:"version"`
-	}
+	var patchPayload OpPatchPayload
 	json.Unmarshal(wsMsg.Payload, &patchPayload)
 
+	msgID := patchPayload.ClientMsgID
+	if len(msgID) > MaxClientMsgIDLength {
+		c.sendError(ErrInvalidMessage, fmt.Sprintf("clientMsgId 不能超过 %d 个字符", MaxClientMsgIDLength))
+		return
+	}
+	if !validPatchLabel(patchPayload.Label) {
+		c.sendOpError(msgID, ErrInvalidMessage,
+			fmt.Sprintf("label 只能包含字母、数字和 - _ . :，不能超过 %d 个字符，且不能使用服务端保留的标签", MaxPatchLabelLength))
+		return
+	}
+
+	if err := c.moderatePatch(patchPayload.Patches); err != nil {
+		c.sendOpError(msgID, ErrContentRejected, contentRejectedMessage)
+		return
+	}
+
 	// 应用 Patch，版本检查在锁保护下进行
-	if err := c.Room.ApplyPatch(patchPayload.Patches, patchPayload.Version); err != nil {
-		var versionErr *VersionConflictError
+	applyStarted := time.Now()
+	result, err := c.Room.ApplyLabeledEdit(c.UserInfo, patchPayload.Label, patchPayload.Patches, patchPayload.Version)
+	if err != nil {
+		code, reason := editErrorCode(err)
+		c.trackConflict(code == ErrVersionConflict)
+		c.sendOpError(msgID, code, reason)
+		c.Room.logSampled(&c.Room.failLog, "[Room %s] 用户 [%s] Patch 处理失败: %v",
+			c.RoomID, c.UserInfo.UserName, err)
+		return
+	}
+
+	applied := time.Now()
+	c.trackConflict(false)
+
+	// 发送者只需补上服务端追加的编辑归属
+	c.send <- encodeServerMessage(TypeAck, AckPayload{
+		ClientMsgID: msgID,
+		Version:     result.Version,
+		Patches:     result.Attribution,
+		Rebased:     result.Rebased,
+		Timing:      c.observeApplied(c.receivedAt, applyStarted, applied),
+	})
+	if result.Rebased {
+		c.Room.RequestSync(c)
+	}
+
+	// 以服务端确认的身份和版本广播给房间内其他用户（关键消息，阻塞时断开连接），
+	// 不转发客户端自填的 senderId
+	broadcast := &RoomBroadcast{
+		Message: encodeMessage(TypeOpPatch, c.UserInfo.UserID, OpPatchPayload{
+			Patches: result.Patches,
+			Version: result.Version - 1,
+			Label:   patchPayload.Label,
+		}),
+		Sender:     c,
+		IsCritical: true,
+	}
+	if c.latencyTracking() {
+		broadcast.AppliedAt = applied
+	}
+	c.Room.broadcast <- broadcast
+	c.Room.logSampled(&c.Room.patchLog, "[Room %s] 用户 [%s] Patch 已应用，新版本: %d",
+		c.RoomID, c.UserInfo.UserName, result.Version)
+}
+
+// editErrorCode 把 ApplyEdit / ValidateEdit 的错误转换为错误码和描述
+func editErrorCode(err error) (ErrorCode, string) {
+	var versionErr *VersionConflictError
+	var patchErr *PatchError
+
+	switch {
+	case errors.As(err, &versionErr):
+		return ErrVersionConflict, fmt.Sprintf("current: %d, expected: %d",
+			versionErr.CurrentVersion, versionErr.ExpectedVersion)
+	case errors.As(err, &patchErr):
+		return ErrPatchFailed, patchErr.Reason
+	default:
+		return ErrInternalError, err.Error()
+	}
+}
+
+// handleCursorMove 处理光标移动消息
+// 光标是非关键消息，阻塞时静默跳过
+func (c *Client) handleCursorMove(message []byte) {
+	if c.Room != nil {
+		c.Room.broadcastCursor(message, c)
+	}
+}
+
+// handleLock 处理获取、释放、抢占组件锁请求
+func (c *Client) handleLock(kind lockOpKind, payload json.RawMessage) {
+	if c.Room == nil {
+		c.sendError(ErrRoomNotFound, c.RoomID)
+		return
+	}
+
+	var lockPayload LockPayload
+	if err := json.Unmarshal(payload, &lockPayload); err != nil || lockPayload.ComponentID == "" {
+		c.sendError(ErrInvalidMessage, "componentId 不能为空")
+		return
+	}
+
+	switch kind {
+	case lockOpAcquire:
+		c.Room.AcquireLock(c, lockPayload.ComponentID)
+	case lockOpRelease:
+		c.Room.ReleaseLock(c, lockPayload.ComponentID)
+	case lockOpSteal:
+		c.Room.StealLock(c, lockPayload.ComponentID)
+	}
+}
+
+// handleSelect 处理选中组件消息，空 componentId 表示取消选中
+func (c *Client) handleSelect(payload json.RawMessage) {
+	if c.Room == nil {
+		c.sendError(ErrRoomNotFound, c.RoomID)
+		return
+	}
+
+	var selection SelectionPayload
+	if err := json.Unmarshal(payload, &selection); err != nil {
+		c.sendError(ErrInvalidMessage, "select 格式错误")
+		return
+	}
+
+	var componentIDs []string
+	if selection.ComponentID != "" {
+		componentIDs = []string{selection.ComponentID}
+	}
+	c.Room.Select(c, componentIDs)
+}
+
+// handleSelectionChange 处理选中组件列表变化，由 Room 记录并转发给其他用户
+func (c *Client) handleSelectionChange(payload json.RawMessage) {
+	if c.Room == nil {
+		c.sendError(ErrRoomNotFound, c.RoomID)
+		return
+	}
+
+	var selection SelectionChangePayload
+	if err := json.Unmarshal(payload, &selection); err != nil {
+		c.sendError(ErrInvalidMessage, "selection-change 格式错误")
+		return
+	}
+
+	c.Room.Select(c, selection.ComponentIDs)
+}
+
+// handleChat 处理聊天消息，内容校验在客户端 goroutine 内完成
+func (c *Client) handleChat(payload json.RawMessage) {
+	if c.Room == nil {
+		c.sendError(ErrRoomNotFound, c.RoomID)
+		return
+	}
+
+	var chat ChatPayload
+	if err := json.Unmarshal(payload, &chat); err != nil || !validChatText(chat.Text) {
+		c.sendError(ErrInvalidMessage, fmt.Sprintf("chat 内容不能为空且不超过 %d 个字符", ChatMaxLength))
+		return
+	}
+	if err := c.moderateChat(chat.Text); err != nil {
+		c.sendError(ErrContentRejected, contentRejectedMessage)
+		return
+	}
+
+	c.Room.Chat(c, chat.Text)
+}
+
+// handleViewportUpdate 处理画布视口变化，校验后以服务端确认的发送者身份转发给其他用户。
+// 与光标一样是非关键消息，阻塞时静默跳过，服务端不保存视口状态。
+func (c *Client) handleViewportUpdate(payload json.RawMessage) {
+	if c.Room == nil {
+		c.sendError(ErrRoomNotFound, c.RoomID)
+		return
+	}
+
+	var viewport ViewportPayload
+	if err := json.Unmarshal(payload, &viewport); err != nil || !viewport.valid() {
+		c.sendError(ErrInvalidMessage, "viewport-update 格式错误")
+		return
+	}
+
+	c.Room.broadcastCursor(encodeMessage(TypeViewportUpdate, c.UserInfo.UserID, viewport), c)
+}
+
+// handleTextOp 处理文本属性的 OT 操作
+// 发送者收到 text-ack；支持 text-ot 的客户端收到变换后的 text-op，其余客户端收到等价的 op-patch
+func (c *Client) handleTextOp(payload json.RawMessage) {
+	if c.Room == nil {
+		c.sendError(ErrRoomNotFound, c.RoomID)
+		return
+	}
+	if !c.HasCapability(CapabilityTextOT) {
+		c.sendError(ErrCapability, "未协商 text-ot 能力")
+		return
+	}
+
+	var textPayload TextOpPayload
+	if err := json.Unmarshal(payload, &textPayload); err != nil ||
+		textPayload.ComponentID == "" || textPayload.Prop == "" {
+		c.sendError(ErrInvalidMessage, "text-op 格式错误")
+		return
+	}
+	if err := c.moderateTextOp(textPayload.ComponentID, textPayload.Prop, textPayload.Ops); err != nil {
+		c.sendError(ErrContentRejected, contentRejectedMessage)
+		return
+	}
+
+	result, err := c.Room.ApplyTextOpAs(c.UserInfo, textPayload.ComponentID, textPayload.Prop, textPayload.Revision, textPayload.Ops)
+	if err != nil {
+		var revisionErr *TextRevisionError
 		var patchErr *PatchError
 
 		switch {
-		case errors.As(err, &versionErr):
-			c.sendError(ErrVersionConflict, fmt.Sprintf("current: %d, expected: %d",
-				versionErr.CurrentVersion, versionErr.ExpectedVersion))
+		case errors.As(err, &revisionErr):
+			c.sendTextRevisionError(revisionErr)
 		case errors.As(err, &patchErr):
 			c.sendError(ErrPatchFailed, patchErr.Reason)
 		default:
 			c.sendError(ErrInternalError, err.Error())
 		}
-		log.Printf("[Client] Patch 处理失败: %v", err)
 		return
 	}
 
-	// 广播给房间内其他用户（关键消息，阻塞时断开连接）
-	c.Room.Broadcast(message, c, true)
-	log.Printf("[Client] 用户 [%s] Patch 已应用，新版本: %d",
-		c.UserInfo.UserName, c.Room.Version)
+	c.send <- encodeServerMessage(TypeTextAck, TextOpPayload{
+		ComponentID: textPayload.ComponentID,
+		Prop:        textPayload.Prop,
+		Revision:    result.Revision,
+		Version:     result.Version,
+		Attribution: result.Attribution,
+	})
+
+	fallback := OpPatchPayload{Patches: result.Patch, Version: result.Version - 1}
+
+	c.Room.broadcast <- &RoomBroadcast{
+		Message: encodeMessage(TypeTextOp, c.UserInfo.UserID, TextOpPayload{
+			ComponentID: textPayload.ComponentID,
+			Prop:        textPayload.Prop,
+			Revision:    result.Revision,
+			Ops:         result.Ops,
+			Version:     result.Version,
+			Attribution: result.Attribution,
+		}),
+		Sender:     c,
+		IsCritical: true,
+		Capability: CapabilityTextOT,
+		Fallback:   encodeMessage(TypeOpPatch, c.UserInfo.UserID, fallback),
+	}
 }
 
-// handleCursorMove 处理光标移动消息
-// 光标是非关键消息，阻塞时静默跳过
-func (c *Client) handleCursorMove(message []byte) {
-	if c.Room != nil {
-		c.Room.Broadcast(message, c, false)
+// HasCapability 判断连接是否协商了指定能力
+func (c *Client) HasCapability(name string) bool {
+	return c.Capabilities[name]
+}
+
+// CapabilityList 返回已协商的能力列表
+func (c *Client) CapabilityList() []string {
+	var list []string
+	for _, name := range SupportedCapabilities {
+		if c.Capabilities[name] {
+			list = append(list, name)
+		}
 	}
+	return list
 }
 
 // sendError 发送结构化错误消息
 func (c *Client) sendError(code ErrorCode, message string) {
-	errPayload, _ := json.Marshal(ErrorPayload{
-		Code:    code,
-		Message: message,
+	c.sendOpError("", code, message)
+}
+
+// sendOpError 发送 op-patch 处理失败的错误消息，带回客户端消息 ID
+func (c *Client) sendOpError(clientMsgID string, code ErrorCode, message string) {
+	c.sendErrorPayload(ErrorPayload{
+		Code:        code,
+		Message:     message,
+		ClientMsgID: clientMsgID,
 	})
+}
+
+// sendTextRevisionError 发送 TEXT_REVISION 错误，带回该属性的当前修订号，客户端据此重新同步
+func (c *Client) sendTextRevisionError(err *TextRevisionError) {
+	current := err.CurrentRevision
+	c.sendErrorPayload(ErrorPayload{
+		Code:            ErrTextRevision,
+		Message:         fmt.Sprintf("current: %d, base: %d", err.CurrentRevision, err.BaseRevision),
+		CurrentRevision: &current,
+	})
+}
+
+// sendErrorPayload 发送错误消息
+func (c *Client) sendErrorPayload(payload ErrorPayload) {
+	errPayload, _ := json.Marshal(payload)
 	msg := WSMessage{
 		Type:      TypeError,
 		SenderID:  "server",
@@ -0,0 +1,198 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 客户端消息处理单元测试 ==========
+// 测试重点：op-patch 的 ack / error 带回客户端消息 ID，且不广播给其他人
+
+func newAckTestClient(room *Room) *Client {
+	return &Client{
+		UserInfo: UserInfo{UserID: "alice", UserName: "Alice"},
+		RoomID:   room.ID,
+		send:     make(chan []byte, 8),
+		Room:     room,
+	}
+}
+
+// readTestMessage 读取发给客户端的下一条消息并解析 payload
+func readTestMessage(t *testing.T, client *Client, payload interface{}) MessageType {
+	t.Helper()
+	var msg WSMessage
+	require.NoError(t, json.Unmarshal(<-client.send, &msg))
+	require.NoError(t, json.Unmarshal(msg.Payload, payload))
+	return msg.Type
+}
+
+func TestClient_OpPatch_AckEchoesClientMsgID(t *testing.T) {
+	// 测试场景：成功的 op-patch 收到带 clientMsgId 和新版本号的 ack，广播中不包含 clientMsgId
+
+	room := newTestRoom("test-room", []byte(`{"title": "a"}`), new(MockPageService))
+	alice := newAckTestClient(room)
+
+	alice.handleOpPatch([]byte(`{"type": "op-patch", "payload": {
+		"patches": [{"op": "replace", "path": "/title", "value": "b"}],
+		"version": 1,
+		"clientMsgId": "m-1"
+	}}`))
+
+	var ack AckPayload
+	assert.Equal(t, TypeAck, readTestMessage(t, alice, &ack))
+	assert.Equal(t, "m-1", ack.ClientMsgID)
+	assert.Equal(t, int64(2), ack.Version)
+
+	b := <-room.broadcast
+	assert.NotContains(t, string(b.Message), "m-1")
+}
+
+func TestClient_OpPatch_ErrorEchoesClientMsgID(t *testing.T) {
+	// 测试场景：版本冲突和 Patch 失败的错误带回 clientMsgId，前端据此回滚对应的乐观更新
+
+	room := newTestRoom("test-room", []byte(`{"title": "a"}`), new(MockPageService))
+	alice := newAckTestClient(room)
+
+	alice.handleOpPatch([]byte(`{"type": "op-patch", "payload": {
+		"patches": [{"op": "replace", "path": "/title", "value": "b"}],
+		"version": 7,
+		"clientMsgId": "m-2"
+	}}`))
+	var conflict ErrorPayload
+	assert.Equal(t, TypeError, readTestMessage(t, alice, &conflict))
+	assert.Equal(t, ErrVersionConflict, conflict.Code)
+	assert.Equal(t, "m-2", conflict.ClientMsgID)
+
+	alice.handleOpPatch([]byte(`{"type": "op-patch", "payload": {
+		"patches": [{"op": "remove", "path": "/missing"}],
+		"version": 1,
+		"clientMsgId": "m-3"
+	}}`))
+	var failed ErrorPayload
+	assert.Equal(t, TypeError, readTestMessage(t, alice, &failed))
+	assert.Equal(t, ErrPatchFailed, failed.Code)
+	assert.Equal(t, "m-3", failed.ClientMsgID)
+}
+
+func TestClient_OpPatch_RejectsLongClientMsgID(t *testing.T) {
+	// 测试场景：clientMsgId 过长时拒绝且不应用 Patch
+
+	room := newTestRoom("test-room", []byte(`{"title": "a"}`), new(MockPageService))
+	alice := newAckTestClient(room)
+
+	alice.handleOpPatch([]byte(`{"type": "op-patch", "payload": {
+		"patches": [{"op": "replace", "path": "/title", "value": "b"}],
+		"version": 1,
+		"clientMsgId": "` + strings.Repeat("x", MaxClientMsgIDLength+1) + `"
+	}}`))
+
+	var errPayload ErrorPayload
+	assert.Equal(t, TypeError, readTestMessage(t, alice, &errPayload))
+	assert.Equal(t, ErrInvalidMessage, errPayload.Code)
+	assert.Equal(t, int64(1), room.Version)
+}
+
+func TestClient_OpPatch_Label(t *testing.T) {
+	// 测试场景：label 写入操作日志并随广播下发；含非法字符或使用服务端保留标签的 label 被拒绝且不应用 Patch
+
+	store := &MockOpStore{}
+	writer := NewOpLogWriter(store)
+	go writer.Run()
+
+	room := newTestRoom("test-room", []byte(`{"title": "a"}`), new(MockPageService))
+	room.opLog = writer
+	alice := newAckTestClient(room)
+
+	alice.handleOpPatch([]byte(`{"type": "op-patch", "payload": {
+		"patches": [{"op": "replace", "path": "/title", "value": "b"}],
+		"version": 1,
+		"label": "drag-resize"
+	}}`))
+	var ack AckPayload
+	assert.Equal(t, TypeAck, readTestMessage(t, alice, &ack))
+
+	var broadcast WSMessage
+	require.NoError(t, json.Unmarshal((<-room.broadcast).Message, &broadcast))
+	var payload OpPatchPayload
+	require.NoError(t, json.Unmarshal(broadcast.Payload, &payload))
+	assert.Equal(t, "drag-resize", payload.Label)
+
+	alice.handleOpPatch([]byte(`{"type": "op-patch", "payload": {
+		"patches": [{"op": "replace", "path": "/title", "value": "c"}],
+		"version": 2,
+		"label": "drag resize"
+	}}`))
+	var errPayload ErrorPayload
+	assert.Equal(t, TypeError, readTestMessage(t, alice, &errPayload))
+	assert.Equal(t, ErrInvalidMessage, errPayload.Code)
+	assert.Equal(t, int64(2), room.Version)
+
+	alice.handleOpPatch([]byte(`{"type": "op-patch", "payload": {
+		"patches": [{"op": "replace", "path": "/title", "value": "c"}],
+		"version": 2,
+		"label": "` + LabelAIGenerate + `"
+	}}`))
+	assert.Equal(t, TypeError, readTestMessage(t, alice, &errPayload))
+	assert.Equal(t, ErrInvalidMessage, errPayload.Code)
+	assert.Equal(t, int64(2), room.Version)
+	writer.Close()
+
+	ops := store.Ops()
+	assert.Len(t, ops, 1)
+	assert.Equal(t, "drag-resize", ops[0].Label)
+}
+
+func TestClient_ConnConfig(t *testing.T) {
+	// 测试场景：连接使用 Hub 配置的发送缓冲区和心跳；不回复 Ping 的连接在 PongWait 后被断开
+
+	cfg := ConnConfig{
+		PongWait:       200 * time.Millisecond,
+		PingPeriod:     50 * time.Millisecond,
+		WriteWait:      time.Second,
+		MaxMessageSize: 1024,
+		SendBufferSize: 16,
+	}
+	hub := NewHub(new(MockPageService), WithConnConfig(cfg))
+	assert.Equal(t, 16, cap(NewClient(hub, nil, "test-room", UserInfo{}).send))
+	assert.Equal(t, DefaultConnConfig.SendBufferSize, cap(NewClient(nil, nil, "test-room", UserInfo{}).send))
+	assert.Equal(t, int64(1024), hub.Limits().MaxMessageSize)
+	assert.Equal(t, "50ms", hub.Limits().PingPeriod)
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	room := NewRoom("test-room", []byte(`{"title": "a"}`), mockService, nil)
+	defer room.Stop()
+
+	var upgrader websocket.Upgrader
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := NewClient(hub, conn, room.ID, UserInfo{UserID: "alice"})
+		if err := room.Register(client); err != nil {
+			conn.Close()
+			return
+		}
+		go client.WritePump()
+		go client.ReadPump()
+	}))
+	defer server.Close()
+
+	// 不读取连接，gorilla 客户端就不会回复 Ping
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assert.Eventually(t, func() bool { return room.ClientCount() == 1 }, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return room.ClientCount() == 0 }, 2*time.Second, 20*time.Millisecond)
+}
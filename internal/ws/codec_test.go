@@ -0,0 +1,120 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"lowercode-go-server/internal/msgpack"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 消息编码协商单元测试 ==========
+
+// startCodecTestServer 启动一个把连接注册到 room 的 WebSocket 服务，按子协议协商编码
+func startCodecTestServer(t *testing.T, room *Room) string {
+	t.Helper()
+	upgrader := websocket.Upgrader{Subprotocols: Subprotocols()}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := NewClient(nil, conn, room.ID, UserInfo{UserID: r.URL.Query().Get("user")})
+		client.Codec, _ = CodecFor(conn.Subprotocol())
+		if err := room.Register(client); err != nil {
+			conn.Close()
+			return
+		}
+		go client.WritePump()
+		go client.ReadPump()
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// readFrameOfType 读取连接上的帧直到出现指定类型的消息，返回帧类型和 JSON 形式的消息
+func readFrameOfType(t *testing.T, conn *websocket.Conn, msgType MessageType) (int, WSMessage) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		frameType, frame, err := conn.ReadMessage()
+		require.NoError(t, err)
+
+		data := frame
+		if frameType == websocket.BinaryMessage {
+			data, err = msgpack.ToJSON(frame)
+			require.NoError(t, err)
+		}
+		var msg WSMessage
+		require.NoError(t, json.Unmarshal(data, &msg))
+		if msg.Type == msgType {
+			return frameType, msg
+		}
+	}
+}
+
+func TestCodec_MsgPackNegotiation(t *testing.T) {
+	// 测试场景：协商 lowcode.msgpack 的连接收发二进制帧，未协商的连接仍使用 JSON 文本帧，
+	// 两种连接之间的广播互通
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	room := NewRoom("test-room", []byte(`{"title": "a"}`), mockService, nil)
+	defer room.Stop()
+	url := startCodecTestServer(t, room)
+
+	dialer := websocket.Dialer{Subprotocols: []string{SubprotocolMsgPack}}
+	packed, _, err := dialer.Dial(url+"?user=alice", nil)
+	require.NoError(t, err)
+	defer packed.Close()
+	assert.Equal(t, SubprotocolMsgPack, packed.Subprotocol())
+
+	frameType, sync := readFrameOfType(t, packed, TypeSync)
+	assert.Equal(t, websocket.BinaryMessage, frameType)
+	var payload SyncPayload
+	require.NoError(t, json.Unmarshal(sync.Payload, &payload))
+	assert.JSONEq(t, `{"title": "a"}`, string(payload.Schema))
+
+	plain, _, err := websocket.DefaultDialer.Dial(url+"?user=bob", nil)
+	require.NoError(t, err)
+	defer plain.Close()
+	assert.Empty(t, plain.Subprotocol())
+	frameType, _ = readFrameOfType(t, plain, TypeSync)
+	assert.Equal(t, websocket.TextMessage, frameType)
+
+	// MessagePack 客户端发出的光标以 JSON 转发给普通客户端
+	cursor, err := msgpack.FromJSON([]byte(`{"type": "cursor-move", "senderId": "alice", "payload": {"x": 10, "y": 20}, "ts": 1}`))
+	require.NoError(t, err)
+	require.NoError(t, packed.WriteMessage(websocket.BinaryMessage, cursor))
+
+	frameType, msg := readFrameOfType(t, plain, TypeCursorMove)
+	assert.Equal(t, websocket.TextMessage, frameType)
+	assert.JSONEq(t, `{"x": 10, "y": 20}`, string(msg.Payload))
+
+	// 无法解码的二进制帧收到 INVALID_MESSAGE，连接保持
+	require.NoError(t, packed.WriteMessage(websocket.BinaryMessage, []byte{0xc1}))
+	_, errMsg := readFrameOfType(t, packed, TypeError)
+	var errPayload ErrorPayload
+	require.NoError(t, json.Unmarshal(errMsg.Payload, &errPayload))
+	assert.Equal(t, ErrInvalidMessage, errPayload.Code)
+}
+
+func TestCodecFor(t *testing.T) {
+	// 测试场景：只有编码子协议被识别，其他值（如放在子协议中的 Token）回退为 JSON
+
+	codec, ok := CodecFor(SubprotocolMsgPack)
+	assert.True(t, ok)
+	assert.Equal(t, MsgPackCodec, codec)
+
+	codec, ok = CodecFor("eyJhbGciOi.token")
+	assert.False(t, ok)
+	assert.Equal(t, JSONCodec, codec)
+}
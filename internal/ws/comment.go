@@ -0,0 +1,23 @@
+package ws
+
+// CommentPayload comment-* 消息的 payload 结构，同时用作评论 REST 接口的响应
+type CommentPayload struct {
+	ID          uint   `json:"id"`
+	ComponentID string `json:"componentId"`
+	ParentID    uint   `json:"parentId,omitempty"` // 所属线程的首条评论，0 表示线程首条
+	AuthorID    string `json:"authorId"`
+	Text        string `json:"text"`
+	Resolved    bool   `json:"resolved"`
+	ResolvedBy  string `json:"resolvedBy,omitempty"`
+	CreatedAt   int64  `json:"createdAt"` // 毫秒时间戳
+	UpdatedAt   int64  `json:"updatedAt"` // 毫秒时间戳
+}
+
+// NotifyComment 向房间内所有用户广播评论变化（非关键消息），senderID 为操作者。
+// 评论通过 REST 接口修改，操作者自己的编辑器也需要收到。
+func (r *Room) NotifyComment(msgType MessageType, senderID string, payload CommentPayload) {
+	select {
+	case r.broadcast <- &RoomBroadcast{Message: encodeMessage(msgType, senderID, payload)}:
+	case <-r.stopChan:
+	}
+}
@@ -0,0 +1,30 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== 评论广播单元测试 ==========
+
+func TestRoom_NotifyComment(t *testing.T) {
+	// 测试场景：评论变化广播给房间内所有用户，包括操作者本人
+
+	room := newTestRoom("page-1", []byte(`{}`), new(MockPageService))
+	alice := &Client{UserInfo: UserInfo{UserID: "alice"}, send: make(chan []byte, 4), Room: room}
+	bob := &Client{UserInfo: UserInfo{UserID: "bob"}, send: make(chan []byte, 4), Room: room}
+	room.clients[alice] = true
+	room.clients[bob] = true
+
+	room.NotifyComment(TypeCommentAdded, "alice", CommentPayload{ID: 7, ComponentID: "c1", AuthorID: "alice", Text: "看这里"})
+	room.deliver(<-room.broadcast)
+
+	for _, c := range []*Client{alice, bob} {
+		var comment CommentPayload
+		readMessage(t, c, TypeCommentAdded, &comment)
+		assert.Equal(t, uint(7), comment.ID)
+		assert.Equal(t, "c1", comment.ComponentID)
+		assert.Equal(t, "看这里", comment.Text)
+	}
+}
@@ -0,0 +1,168 @@
+package ws
+
+import (
+	"bufio"
+	"compress/flate"
+	"errors"
+	"expvar"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// 压缩默认配置
+const (
+	DefaultCompressionLevel   = flate.BestSpeed // sync 等大消息以速度优先即可获得大部分压缩收益
+	DefaultCompressionMinSize = 1024            // 光标、ack 等小消息压缩收益低于 CPU 开销
+)
+
+// compressionMetrics 全部房间压缩发送的帧数与字节数，通过 expvar 暴露为 ws_compression
+var compressionMetrics = expvar.NewMap("ws_compression")
+
+// CompressionConfig permessage-deflate 配置
+type CompressionConfig struct {
+	Enabled bool // 为 true 时与声明支持的客户端协商 permessage-deflate
+	Level   int  // flate 压缩级别，1（最快）到 9（压缩率最高）
+	MinSize int  // 编码后小于该字节数的帧不压缩
+}
+
+// CompressionStats 压缩发送的累计计数，RawBytes 与 WireBytes 之比即压缩率
+type CompressionStats struct {
+	Frames    int64 `json:"frames"`    // 压缩发送的帧数
+	RawBytes  int64 `json:"rawBytes"`  // 压缩前的帧大小
+	WireBytes int64 `json:"wireBytes"` // 实际写到连接上的字节数（含帧头）
+}
+
+// compressionStats 房间级压缩计数，零值可用，并发安全
+type compressionStats struct {
+	frames atomic.Int64
+	raw    atomic.Int64
+	wire   atomic.Int64
+}
+
+// record 累计一个压缩发送的帧
+func (s *compressionStats) record(raw, wire int) {
+	s.frames.Add(1)
+	s.raw.Add(int64(raw))
+	s.wire.Add(int64(wire))
+}
+
+// snapshot 返回计数的拷贝，没有压缩过任何帧时返回 nil
+func (s *compressionStats) snapshot() *CompressionStats {
+	frames := s.frames.Load()
+	if frames == 0 {
+		return nil
+	}
+	return &CompressionStats{Frames: frames, RawBytes: s.raw.Load(), WireBytes: s.wire.Load()}
+}
+
+// WireCounter 统计写入底层连接的字节数
+type WireCounter struct {
+	net.Conn
+	written atomic.Int64
+}
+
+// Write 写入底层连接并累计字节数
+func (w *WireCounter) Write(p []byte) (int, error) {
+	n, err := w.Conn.Write(p)
+	w.written.Add(int64(n))
+	return n, err
+}
+
+// Written 返回累计写入的字节数
+func (w *WireCounter) Written() int64 {
+	return w.written.Load()
+}
+
+// CountWireBytes 包装 ResponseWriter，升级时被劫持的底层连接经 WireCounter 写出。
+// gorilla/websocket 不暴露压缩后的帧大小，只能在连接层统计实际写出的字节数。
+func CountWireBytes(w http.ResponseWriter) (http.ResponseWriter, *WireCounter) {
+	counter := &WireCounter{}
+	return &countingHijacker{ResponseWriter: w, counter: counter}, counter
+}
+
+// countingHijacker 劫持连接时用 WireCounter 包装底层连接
+type countingHijacker struct {
+	http.ResponseWriter
+	counter *WireCounter
+}
+
+// Hijack 实现 http.Hijacker
+func (h *countingHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := h.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("ResponseWriter 不支持 Hijack")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	h.counter.Conn = conn
+	return h.counter, rw, nil
+}
+
+// CompressionOffered 判断客户端握手请求是否声明支持 permessage-deflate
+func CompressionOffered(r *http.Request) bool {
+	for _, header := range r.Header.Values("Sec-WebSocket-Extensions") {
+		for _, ext := range strings.Split(header, ",") {
+			name, _, _ := strings.Cut(ext, ";")
+			if strings.EqualFold(strings.TrimSpace(name), "permessage-deflate") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// errCompressionUnsupported 底层传输不支持逐帧压缩
+var errCompressionUnsupported = errors.New("transport does not support compression")
+
+// connCompression 已协商 permessage-deflate 的连接的压缩状态
+type connCompression struct {
+	minSize int
+	wire    *WireCounter
+}
+
+// EnableCompression 为已协商 permessage-deflate 的连接开启压缩，
+// wire 为升级时 CountWireBytes 返回的计数器，用于统计压缩后的字节数
+func (c *Client) EnableCompression(cfg CompressionConfig, wire *WireCounter) error {
+	conn, ok := c.Transport.(compressionTransport)
+	if !ok {
+		return errCompressionUnsupported
+	}
+	if err := conn.SetCompressionLevel(cfg.Level); err != nil {
+		return err
+	}
+	c.compression = &connCompression{minSize: cfg.MinSize, wire: wire}
+	return nil
+}
+
+// writeFrame 写出一帧，已协商压缩的连接只压缩不小于 minSize 的帧并累计压缩计数
+func (c *Client) writeFrame(frameType int, frame []byte) error {
+	cc := c.compression
+	if cc == nil {
+		return c.Transport.WriteMessage(frameType, frame)
+	}
+
+	compress := len(frame) >= cc.minSize
+	c.Transport.(compressionTransport).EnableWriteCompression(compress)
+	before := cc.wire.Written()
+	if err := c.Transport.WriteMessage(frameType, frame); err != nil {
+		return err
+	}
+	if compress {
+		c.recordCompression(len(frame), int(cc.wire.Written()-before))
+	}
+	return nil
+}
+
+// recordCompression 将一个压缩帧计入全局和所在房间的计数
+func (c *Client) recordCompression(raw, wire int) {
+	compressionMetrics.Add("frames", 1)
+	compressionMetrics.Add("raw_bytes", int64(raw))
+	compressionMetrics.Add("wire_bytes", int64(wire))
+	if c.Room != nil {
+		c.Room.compression.record(raw, wire)
+	}
+}
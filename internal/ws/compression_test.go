@@ -0,0 +1,136 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 消息压缩单元测试 ==========
+
+// startCompressionTestServer 启动一个按 cfg 协商 permessage-deflate 的 WebSocket 服务
+func startCompressionTestServer(t *testing.T, room *Room, cfg CompressionConfig) string {
+	t.Helper()
+	upgrader := websocket.Upgrader{EnableCompression: cfg.Enabled}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writer, wire := CountWireBytes(w)
+		conn, err := upgrader.Upgrade(writer, r, nil)
+		if err != nil {
+			return
+		}
+		client := NewClient(nil, conn, room.ID, UserInfo{UserID: r.URL.Query().Get("user")})
+		if cfg.Enabled && CompressionOffered(r) {
+			require.NoError(t, client.EnableCompression(cfg, wire))
+		}
+		if err := room.Register(client); err != nil {
+			conn.Close()
+			return
+		}
+		go client.WritePump()
+		go client.ReadPump()
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestCompression_LargeSyncCompressed(t *testing.T) {
+	// 测试场景：协商压缩的连接收到的大 sync 被压缩，房间计数记录压缩前后的字节数；
+	// 小于 MinSize 的消息（user-join）不压缩、不计入
+
+	schema := `{"title": "` + strings.Repeat("重复的页面内容 ", 2000) + `"}`
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	room := NewRoom("test-room", []byte(schema), mockService, nil)
+	defer room.Stop()
+	url := startCompressionTestServer(t, room, CompressionConfig{
+		Enabled: true,
+		Level:   DefaultCompressionLevel,
+		MinSize: DefaultCompressionMinSize,
+	})
+
+	dialer := websocket.Dialer{EnableCompression: true}
+	conn, _, err := dialer.Dial(url+"?user=alice", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, sync := readFrameOfType(t, conn, TypeSync)
+	var payload SyncPayload
+	require.NoError(t, json.Unmarshal(sync.Payload, &payload))
+	assert.JSONEq(t, schema, string(payload.Schema))
+
+	stats := room.Stats().Compression
+	require.NotNil(t, stats)
+	assert.Equal(t, int64(1), stats.Frames)
+	assert.Greater(t, stats.RawBytes, int64(len(schema)))
+	assert.Less(t, stats.WireBytes*10, stats.RawBytes)
+
+	// 另一个用户加入，alice 收到的 user-join 小于 MinSize，不压缩
+	other, _, err := dialer.Dial(url+"?user=bob", nil)
+	require.NoError(t, err)
+	defer other.Close()
+	readFrameOfType(t, other, TypeSync)
+	readFrameOfType(t, conn, TypeUserJoin)
+
+	// 只有 bob 的 sync 被压缩
+	frames := func() int64 { return room.Stats().Compression.Frames }
+	assert.Eventually(t, func() bool { return frames() == 2 }, time.Second, 10*time.Millisecond)
+	assert.Never(t, func() bool { return frames() > 2 }, 100*time.Millisecond, 10*time.Millisecond)
+}
+
+func TestCompression_NotNegotiated(t *testing.T) {
+	// 测试场景：客户端未声明支持或服务端关闭压缩时按原样发送，不记录压缩计数
+
+	for _, tt := range []struct {
+		name   string
+		server bool
+		client bool
+	}{
+		{name: "客户端未声明", server: true, client: false},
+		{name: "服务端关闭", server: false, client: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockPageService)
+			mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			room := NewRoom("test-room", []byte(`{"title": "`+strings.Repeat("a", 4096)+`"}`), mockService, nil)
+			defer room.Stop()
+			url := startCompressionTestServer(t, room, CompressionConfig{Enabled: tt.server, Level: DefaultCompressionLevel})
+
+			dialer := websocket.Dialer{EnableCompression: tt.client}
+			conn, _, err := dialer.Dial(url+"?user=alice", nil)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			readFrameOfType(t, conn, TypeSync)
+			assert.Nil(t, room.Stats().Compression)
+		})
+	}
+}
+
+func TestCompressionOffered(t *testing.T) {
+	// 测试场景：按扩展名识别 permessage-deflate，忽略参数、大小写和其他扩展
+
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"permessage-deflate; client_max_window_bits", true},
+		{"x-webkit-deflate-frame, Permessage-Deflate", true},
+		{"x-webkit-deflate-frame", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		if tt.header != "" {
+			r.Header.Set("Sec-WebSocket-Extensions", tt.header)
+		}
+		assert.Equal(t, tt.want, CompressionOffered(r), tt.header)
+	}
+}
@@ -0,0 +1,150 @@
+package ws
+
+import "time"
+
+// ConnConfig 单个 WebSocket 连接的心跳与限制。
+// 部署在会提前断开空闲连接的代理之后时，可缩短 PingPeriod 让心跳先于代理超时到达。
+type ConnConfig struct {
+	PongWait       time.Duration // 等待 Pong（或任意消息）的最大时间，超时断开
+	PingPeriod     time.Duration // Ping 发送间隔，必须小于 PongWait
+	WriteWait      time.Duration // 写消息超时时间
+	MaxMessageSize int64         // 单条入站消息的最大字节数，防止恶意攻击
+	SendBufferSize int           // 发送缓冲区可容纳的消息数，写满时非关键消息被丢弃
+}
+
+// DefaultConnConfig 默认连接配置
+var DefaultConnConfig = ConnConfig{
+	PongWait:       60 * time.Second,
+	PingPeriod:     54 * time.Second,
+	WriteWait:      10 * time.Second,
+	MaxMessageSize: 512 * 1024,
+	SendBufferSize: 256,
+}
+
+// WithConnConfig 设置新连接的心跳与限制，只影响之后建立的连接
+func WithConnConfig(cfg ConnConfig) HubOption {
+	return func(h *Hub) {
+		h.connConfig = &cfg
+	}
+}
+
+// WithMaxClientsPerRoom 设置单个房间的连接数上限，n 为 0 时不限制。
+// 一个房间的所有连接共用同一个事件循环，上限防止大量连接拖慢整个房间
+func WithMaxClientsPerRoom(n int) HubOption {
+	return func(h *Hub) {
+		h.maxClients = n
+	}
+}
+
+// HubLimits 协同引擎的运行限制，均为编译期常量或启动时的配置
+type HubLimits struct {
+	// 自适应刷盘的边界，见 FlushPolicy
+	FlushMinInterval  string `json:"flushMinInterval"`
+	FlushMaxInterval  string `json:"flushMaxInterval"`
+	FlushMinThreshold int64  `json:"flushMinThreshold"`
+	FlushMaxThreshold int64  `json:"flushMaxThreshold"`
+
+	// 刷盘协程池，见 FlushWorkerConfig
+	FlushWorkers    int `json:"flushWorkers"`
+	FlushMaxRetries int `json:"flushMaxRetries"`
+
+	SnapshotEveryFlushes int    `json:"snapshotEveryFlushes"` // 1 表示每次刷盘都写全量快照
+	LockTTL              string `json:"lockTtl"`
+	MaxMessageSize       int64  `json:"maxMessageSize"`
+	ChatHistoryLimit     int    `json:"chatHistoryLimit"`
+	ChatMaxLength        int    `json:"chatMaxLength"`
+	OpLogQueueSize       int    `json:"opLogQueueSize"`
+
+	// 连接心跳与发送缓冲区，见 ConnConfig
+	PongWait       string `json:"pongWait"`
+	PingPeriod     string `json:"pingPeriod"`
+	WriteWait      string `json:"writeWait"`
+	SendBufferSize int    `json:"sendBufferSize"`
+
+	MaxClientsPerRoom int `json:"maxClientsPerRoom"` // 0 表示不限制
+	Shards            int `json:"shards"`            // 房间表分片数，见 WithShards
+
+	RateLimit RateLimitConfig `json:"rateLimit"` // 单个连接的入站消息限流
+}
+
+// HubFeatures 通过 HubOption 启用的可选功能
+type HubFeatures struct {
+	OpLog            bool `json:"opLog"`
+	LifecycleEvents  bool `json:"lifecycleEvents"`
+	DeltaPersistence bool `json:"deltaPersistence"`
+	ChatPersistence  bool `json:"chatPersistence"` // 为 true 时各页面可单独开启聊天持久化
+	ConflictBackups  bool `json:"conflictBackups"` // 客户端可上传被拒绝的本地状态
+
+	PageActivity bool `json:"pageActivity"` // 记录用户最近打开、编辑的页面
+	Watchdog     bool `json:"watchdog"`     // 过载时拒绝创建房间并对感知类广播降频
+	ReproJournal bool `json:"reproJournal"` // 可导出房间的复现包
+	References   bool `json:"references"`   // 刷盘后重建页面之间的引用索引
+	Moderation   bool `json:"moderation"`   // 审核聊天和组件属性文本
+	Hibernation  bool `json:"hibernation"`  // 空闲房间休眠到外部存储，再次加入时直接恢复
+
+	LatencyTracking bool `json:"latencyTracking"` // 记录编辑链路各阶段的耗时，ack 中带回服务端耗时
+}
+
+// Limits 返回当前生效的运行限制
+func (h *Hub) Limits() HubLimits {
+	conn := DefaultConnConfig
+	if h.connConfig != nil {
+		conn = *h.connConfig
+	}
+	flush := DefaultFlushPolicy
+	if h.flushPolicy != nil {
+		flush = *h.flushPolicy
+	}
+	limits := HubLimits{
+		FlushMinInterval:  flush.MinInterval.String(),
+		FlushMaxInterval:  flush.MaxInterval.String(),
+		FlushMinThreshold: flush.MinThreshold,
+		FlushMaxThreshold: flush.MaxThreshold,
+
+		FlushWorkers:    h.flusher.cfg.Workers,
+		FlushMaxRetries: h.flusher.cfg.MaxRetries,
+
+		SnapshotEveryFlushes: 1,
+		LockTTL:              LockTTL.String(),
+		MaxMessageSize:       conn.MaxMessageSize,
+		ChatHistoryLimit:     ChatHistoryLimit,
+		ChatMaxLength:        ChatMaxLength,
+		OpLogQueueSize:       opLogQueueSize,
+
+		PongWait:       conn.PongWait.String(),
+		PingPeriod:     conn.PingPeriod.String(),
+		WriteWait:      conn.WriteWait.String(),
+		SendBufferSize: conn.SendBufferSize,
+
+		MaxClientsPerRoom: h.maxClients,
+		Shards:            len(h.shards),
+		RateLimit:         DefaultRateLimit,
+	}
+	if h.rateLimit != nil {
+		limits.RateLimit = *h.rateLimit
+	}
+	if h.deltas != nil {
+		limits.SnapshotEveryFlushes = h.deltas.snapshotEvery
+	}
+	return limits
+}
+
+// Features 返回已启用的可选功能
+func (h *Hub) Features() HubFeatures {
+	return HubFeatures{
+		OpLog:            h.opLog != nil,
+		LifecycleEvents:  h.events != nil,
+		DeltaPersistence: h.deltas != nil,
+		ChatPersistence:  h.chat != nil,
+		ConflictBackups:  h.backups != nil,
+
+		PageActivity: h.activity != nil,
+		Watchdog:     h.watchdog != nil,
+		ReproJournal: h.reproWindow > 0,
+		References:   h.references != nil,
+		Moderation:   h.moderator != nil,
+		Hibernation:  h.hibernation != nil,
+
+		LatencyTracking: h.latencyTracking,
+	}
+}
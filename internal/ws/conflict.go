@@ -0,0 +1,270 @@
+package ws
+
+import (
+	"encoding/json"
+	"strings"
+
+	"lowercode-go-server/domain/entity"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// ConflictResolver 处理基于旧版本的编辑：op-patch 的 version 落后于房间当前版本时，
+// 由页面协同设置选择的策略决定拒绝，还是改写为可以在当前状态上应用的 Patch
+type ConflictResolver interface {
+	// Resolve 返回在当前状态上应用的 Patch，无法解决时返回 *VersionConflictError
+	Resolve(edit StaleEdit) (jsonpatch.Patch, error)
+}
+
+// StaleEdit 一次基于旧版本的编辑
+type StaleEdit struct {
+	Author         UserInfo
+	Patch          jsonpatch.Patch // 客户端 Patch，基于 BaseVersion 的状态生成
+	BaseVersion    int64
+	CurrentVersion int64
+	State          []byte // 房间当前状态，只读
+
+	// Since BaseVersion 之后已应用的编辑，按版本升序；内存窗口覆盖不到 BaseVersion 时 Covered 为 false
+	Since   []AppliedEdit
+	Covered bool
+}
+
+// AppliedEdit 房间内存窗口中一次已应用的编辑
+type AppliedEdit struct {
+	Version  int64
+	AuthorID string // 编辑者，服务端生成的编辑（如回放）为空
+	Patch    jsonpatch.Patch
+}
+
+// conflictError 返回 edit 对应的版本冲突错误
+func (e StaleEdit) conflictError() error {
+	return &VersionConflictError{CurrentVersion: e.CurrentVersion, ExpectedVersion: e.BaseVersion}
+}
+
+// conflictResolvers 各冲突策略的实现
+var conflictResolvers = map[entity.ConflictStrategy]ConflictResolver{
+	entity.ConflictStrict: strictResolver{},
+	entity.ConflictRebase: rebaseResolver{},
+	entity.ConflictLWW:    lwwResolver{},
+	entity.ConflictCRDT:   crdtResolver{},
+}
+
+// resolverFor 返回策略对应的实现，未知策略按 strict 处理
+func resolverFor(strategy entity.ConflictStrategy) ConflictResolver {
+	if resolver, ok := conflictResolvers[strategy]; ok {
+		return resolver
+	}
+	return strictResolver{}
+}
+
+// strictResolver 乐观锁：总是拒绝
+type strictResolver struct{}
+
+func (strictResolver) Resolve(edit StaleEdit) (jsonpatch.Patch, error) {
+	return nil, edit.conflictError()
+}
+
+// rebaseResolver 服务端变基：客户端 Patch 修改的单位与期间其他人修改的单位不重叠时原样应用到当前状态，
+// 否则拒绝。合并单位与分支合并相同：组件的一个字段，或 components 之外的一个顶层字段
+type rebaseResolver struct{}
+
+func (rebaseResolver) Resolve(edit StaleEdit) (jsonpatch.Patch, error) {
+	if !edit.Covered {
+		return nil, edit.conflictError()
+	}
+	others := othersUnits(edit)
+	for _, op := range edit.Patch {
+		for _, unit := range opUnits(op) {
+			if overlapsAny(unit, others) {
+				return nil, edit.conflictError()
+			}
+		}
+	}
+	if _, err := edit.Patch.Apply(edit.State); err != nil {
+		return nil, edit.conflictError()
+	}
+	return edit.Patch, nil
+}
+
+// lwwResolver 组件级后写者胜出：忽略版本直接应用到当前状态，覆盖期间其他人对同一组件的修改；
+// 目标已不存在（如组件已被删除）导致无法应用时拒绝
+type lwwResolver struct{}
+
+func (lwwResolver) Resolve(edit StaleEdit) (jsonpatch.Patch, error) {
+	if _, err := edit.Patch.Apply(edit.State); err != nil {
+		return nil, edit.conflictError()
+	}
+	return edit.Patch, nil
+}
+
+// crdtResolver CRDT 模式：每个合并单位视为一个后写者胜出寄存器，逐个操作合并。
+// 与期间其他人的修改重叠（并发修改）时按用户 ID 决定胜者（较大者胜出），结果与到达顺序无关；
+// 落败或目标已不存在的操作被丢弃，其余操作照常应用，因此不会拒绝编辑
+type crdtResolver struct{}
+
+func (crdtResolver) Resolve(edit StaleEdit) (jsonpatch.Patch, error) {
+	if !edit.Covered {
+		return nil, edit.conflictError()
+	}
+
+	var concurrent []authoredUnit
+	for _, applied := range edit.Since {
+		if applied.AuthorID == edit.Author.UserID {
+			continue
+		}
+		for _, op := range applied.Patch {
+			for _, unit := range opUnits(op) {
+				concurrent = append(concurrent, authoredUnit{unit: unit, authorID: applied.AuthorID})
+			}
+		}
+	}
+
+	state := edit.State
+	resolved := jsonpatch.Patch{}
+	for _, op := range edit.Patch {
+		if losesTo(op, edit.Author.UserID, concurrent) {
+			continue
+		}
+		next, err := jsonpatch.Patch{op}.Apply(state)
+		if err != nil {
+			continue
+		}
+		state = next
+		resolved = append(resolved, op)
+	}
+	return resolved, nil
+}
+
+// authoredUnit 期间其他人修改的一个合并单位
+type authoredUnit struct {
+	unit     []string
+	authorID string
+}
+
+// losesTo op 修改的单位是否与用户 ID 更大的人的并发修改重叠
+func losesTo(op jsonpatch.Operation, authorID string, concurrent []authoredUnit) bool {
+	for _, unit := range opUnits(op) {
+		for _, other := range concurrent {
+			if other.authorID > authorID && unitsOverlap(unit, other.unit) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// othersUnits 返回期间作者以外的人修改的合并单位
+func othersUnits(edit StaleEdit) [][]string {
+	var units [][]string
+	for _, applied := range edit.Since {
+		if applied.AuthorID == edit.Author.UserID {
+			continue
+		}
+		for _, op := range applied.Patch {
+			units = append(units, opUnits(op)...)
+		}
+	}
+	return units
+}
+
+// opUnits 返回操作读写的合并单位（path，move/copy 还包括 from）
+func opUnits(op jsonpatch.Operation) [][]string {
+	var units [][]string
+	for _, get := range []func() (string, error){op.Path, op.From} {
+		if p, err := get(); err == nil {
+			units = append(units, unitOfPath(p))
+		}
+	}
+	return units
+}
+
+// unitOfPath 将 Patch 路径截断为合并单位：/components 下最多保留组件 ID 和字段名，其余顶层字段只保留字段名
+func unitOfPath(path string) []string {
+	if path == "" {
+		return []string{}
+	}
+	tokens := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+
+	depth := 1
+	if tokens[0] == "components" {
+		depth = 3
+	}
+	if len(tokens) > depth {
+		tokens = tokens[:depth]
+	}
+	return tokens
+}
+
+// overlapsAny unit 是否与 units 中任意一个重叠
+func overlapsAny(unit []string, units [][]string) bool {
+	for _, other := range units {
+		if unitsOverlap(unit, other) {
+			return true
+		}
+	}
+	return false
+}
+
+// unitsOverlap 两个单位是否相同或其中一个是另一个的前缀
+func unitsOverlap(a, b []string) bool {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// staleEditLocked 收集基于 baseVersion 的编辑所需的上下文，调用方需持有 stateMu
+func (r *Room) staleEditLocked(author UserInfo, patch jsonpatch.Patch, baseVersion int64) StaleEdit {
+	edit := StaleEdit{
+		Author:         author,
+		Patch:          patch,
+		BaseVersion:    baseVersion,
+		CurrentVersion: r.Version,
+		State:          r.CurrentState,
+		Covered:        r.windowFloorLocked() <= baseVersion+1,
+	}
+	if !edit.Covered {
+		return edit
+	}
+	for _, p := range r.recentPatches {
+		if p.version <= baseVersion {
+			continue
+		}
+		applied, err := jsonpatch.DecodePatch(p.patch)
+		if err != nil {
+			edit.Covered = false
+			return edit
+		}
+		edit.Since = append(edit.Since, AppliedEdit{Version: p.version, AuthorID: p.authorID, Patch: applied})
+	}
+	return edit
+}
+
+// resolveStaleLocked 按页面的冲突策略处理基于旧版本的编辑，返回改写后的 Patch 及其 JSON，
+// 调用方需持有 stateMu
+func (r *Room) resolveStaleLocked(author UserInfo, patch jsonpatch.Patch, baseVersion int64) (jsonpatch.Patch, []byte, error) {
+	if baseVersion > r.Version {
+		return nil, nil, &VersionConflictError{CurrentVersion: r.Version, ExpectedVersion: baseVersion}
+	}
+	edit := r.staleEditLocked(author, patch, baseVersion)
+
+	resolver := r.resolver
+	if resolver == nil {
+		resolver = strictResolver{}
+	}
+	resolved, err := resolver.Resolve(edit)
+	if err != nil {
+		return nil, nil, err
+	}
+	patchBytes, err := json.Marshal(resolved)
+	if err != nil {
+		return nil, nil, edit.conflictError()
+	}
+	return resolved, patchBytes, nil
+}
@@ -0,0 +1,120 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== 冲突处理策略单元测试 ==========
+
+var (
+	conflictAlice = UserInfo{UserID: "alice", UserName: "Alice"}
+	conflictBob   = UserInfo{UserID: "bob", UserName: "Bob"}
+)
+
+// newConflictTestRoom 创建使用 strategy 的房间，并由 bob 在版本 1 上修改组件 a 的 props
+func newConflictTestRoom(t *testing.T, strategy entity.ConflictStrategy) *Room {
+	room := newTestRoom("test-room", []byte(`{"components":{"a":{"props":{"x":1}},"b":{"props":{"y":1}}}}`), new(MockPageService))
+	room.resolver = resolverFor(strategy)
+
+	_, err := room.ApplyEdit(conflictBob, []byte(`[{"op":"replace","path":"/components/a/props/x","value":2}]`), 1)
+	assert.NoError(t, err)
+	return room
+}
+
+// componentProps 返回房间当前状态中组件 id 的 props
+func componentProps(t *testing.T, room *Room, id string) map[string]interface{} {
+	var doc struct {
+		Components map[string]struct {
+			Props map[string]interface{} `json:"props"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.Unmarshal(room.CurrentState, &doc))
+	return doc.Components[id].Props
+}
+
+func TestConflict_Strict(t *testing.T) {
+	// 测试场景：默认策略下基于旧版本的编辑总是被拒绝，即使修改的是其他组件
+
+	room := newConflictTestRoom(t, entity.ConflictStrict)
+
+	_, err := room.ApplyEdit(conflictAlice, []byte(`[{"op":"replace","path":"/components/b/props/y","value":2}]`), 1)
+	var conflict *VersionConflictError
+	assert.ErrorAs(t, err, &conflict)
+	assert.Equal(t, int64(2), conflict.CurrentVersion)
+	assert.Equal(t, int64(2), room.Version)
+}
+
+func TestConflict_Rebase(t *testing.T) {
+	// 测试场景：与期间其他人的修改不重叠时在最新状态上应用并标记 rebased；
+	// 修改同一组件字段时拒绝；内存窗口覆盖不到基础版本时拒绝；作者自己期间的修改不算冲突
+
+	room := newConflictTestRoom(t, entity.ConflictRebase)
+
+	result, err := room.ApplyEdit(conflictAlice, []byte(`[{"op":"replace","path":"/components/b/props/y","value":2}]`), 1)
+	assert.NoError(t, err)
+	assert.True(t, result.Rebased)
+	assert.Equal(t, int64(3), result.Version)
+	assert.Equal(t, 2.0, componentProps(t, room, "a")["x"])
+	assert.Equal(t, 2.0, componentProps(t, room, "b")["y"])
+
+	_, err = room.ApplyEdit(conflictAlice, []byte(`[{"op":"add","path":"/components/a/props/z","value":1}]`), 1)
+	assert.ErrorAs(t, err, new(*VersionConflictError))
+
+	_, err = room.ApplyEdit(conflictBob, []byte(`[{"op":"add","path":"/components/a/props/z","value":1}]`), 1)
+	assert.NoError(t, err, "期间对组件 a 的修改来自 bob 自己")
+
+	room.recentPatches = room.recentPatches[1:]
+	_, err = room.ApplyEdit(conflictAlice, []byte(`[{"op":"add","path":"/title","value":"t"}]`), 1)
+	assert.ErrorAs(t, err, new(*VersionConflictError))
+}
+
+func TestConflict_LastWriterWins(t *testing.T) {
+	// 测试场景：忽略版本直接应用，覆盖期间其他人对同一组件的修改；目标已被删除时拒绝
+
+	room := newConflictTestRoom(t, entity.ConflictLWW)
+
+	result, err := room.ApplyEdit(conflictAlice, []byte(`[{"op":"replace","path":"/components/a/props/x","value":3}]`), 1)
+	assert.NoError(t, err)
+	assert.True(t, result.Rebased)
+	assert.Equal(t, 3.0, componentProps(t, room, "a")["x"])
+
+	_, err = room.ApplyEdit(conflictBob, []byte(`[{"op":"remove","path":"/components/b"}]`), 3)
+	assert.NoError(t, err)
+	_, err = room.ApplyEdit(conflictAlice, []byte(`[{"op":"replace","path":"/components/b/props/y","value":2}]`), 3)
+	assert.ErrorAs(t, err, new(*VersionConflictError))
+}
+
+func TestConflict_CRDT(t *testing.T) {
+	// 测试场景：并发修改同一字段时用户 ID 较大者胜出，落败的操作被丢弃、其余操作照常应用；
+	// 胜出方基于旧版本的修改覆盖落败方
+
+	room := newConflictTestRoom(t, entity.ConflictCRDT)
+
+	result, err := room.ApplyEdit(conflictAlice, []byte(`[
+		{"op":"replace","path":"/components/a/props/x","value":3},
+		{"op":"replace","path":"/components/b/props/y","value":3}
+	]`), 1)
+	assert.NoError(t, err)
+	assert.True(t, result.Rebased)
+	assert.Equal(t, 2.0, componentProps(t, room, "a")["x"], "bob 胜出，alice 对 a 的修改被丢弃")
+	assert.Equal(t, 3.0, componentProps(t, room, "b")["y"])
+	assert.NotContains(t, string(result.Patches), "/components/a/props/x")
+
+	result, err = room.ApplyEdit(conflictBob, []byte(`[{"op":"replace","path":"/components/b/props/y","value":4}]`), 2)
+	assert.NoError(t, err)
+	assert.True(t, result.Rebased)
+	assert.Equal(t, 4.0, componentProps(t, room, "b")["y"], "bob 胜出，覆盖 alice 的并发修改")
+}
+
+func TestCollabSettings_ConflictStrategy(t *testing.T) {
+	// 测试场景：未设置时为 strict；未知策略不合法
+
+	assert.Equal(t, entity.ConflictStrict, entity.DefaultCollabSettings().ConflictStrategy())
+	assert.True(t, entity.CollabSettings{Conflict: entity.ConflictCRDT}.Valid())
+	assert.False(t, entity.CollabSettings{Conflict: "ot"}.Valid())
+}
@@ -0,0 +1,67 @@
+package ws
+
+import (
+	"encoding/json"
+)
+
+// DeltaStore 差量持久化接口，由 repository 层实现
+type DeltaStore interface {
+	// SavePageDelta 追加 oldVersion → newVersion 之间的 Patch 列表，并推进页面版本号（乐观锁）。
+	// patches 为 JSON 数组，第 i 个元素是把版本 oldVersion+i 变为 oldVersion+i+1 的 RFC 6902 Patch。
+	SavePageDelta(pageID string, patches []byte, oldVersion, newVersion int64) error
+}
+
+// deltaPolicy 差量持久化配置
+type deltaPolicy struct {
+	store         DeltaStore
+	snapshotEvery int // 每 N 次刷盘写一次全量快照
+}
+
+// WithDeltaPersistence 启用差量持久化：刷盘时只写入两次刷盘之间的 Patch，
+// 每 snapshotEvery 次刷盘写一次全量快照。snapshotEvery <= 1 时等同于每次全量刷盘。
+func WithDeltaPersistence(store DeltaStore, snapshotEvery int) HubOption {
+	return func(h *Hub) {
+		if store == nil || snapshotEvery <= 1 {
+			return
+		}
+		h.deltas = &deltaPolicy{store: store, snapshotEvery: snapshotEvery}
+	}
+}
+
+// bufferPatchLocked 缓存已应用但尚未刷盘的 Patch，调用方需持有 stateMu
+func (r *Room) bufferPatchLocked(patch []byte) {
+	if r.deltas == nil {
+		return
+	}
+	r.pendingPatches = append(r.pendingPatches, append(json.RawMessage(nil), patch...))
+}
+
+// pendingDeltaLocked 返回 lastPersistedVersion 之后的 Patch 列表，调用方需持有 stateMu。
+// 未启用差量、缓存与版本号对不上（例如房间刚从数据库加载）时返回 nil，由调用方回退为全量快照。
+func (r *Room) pendingDeltaLocked() []byte {
+	if r.deltas == nil || int64(len(r.pendingPatches)) != r.Version-r.lastPersistedVersion {
+		return nil
+	}
+	data, err := json.Marshal(r.pendingPatches)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// nextFlushIsSnapshot 判断本次刷盘是否应写全量快照
+func (r *Room) nextFlushIsSnapshot() bool {
+	return r.deltas == nil || (r.flushCount+1)%r.deltas.snapshotEvery == 0
+}
+
+// trimPendingLocked 刷盘成功后丢弃已落盘的 Patch，调用方需持有 stateMu
+func (r *Room) trimPendingLocked(persisted int64) {
+	if r.deltas == nil {
+		return
+	}
+	if persisted >= int64(len(r.pendingPatches)) {
+		r.pendingPatches = nil
+		return
+	}
+	r.pendingPatches = r.pendingPatches[persisted:]
+}
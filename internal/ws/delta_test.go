@@ -0,0 +1,102 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ========== 差量持久化单元测试 ==========
+// 测试重点：刷盘在差量与全量快照之间切换，Patch 缓存与版本号保持一致
+
+func newDeltaTestRoom(mockService *MockPageService, store *MockDeltaStore, snapshotEvery int) *Room {
+	room := newTestRoom("page-1", []byte(`{"title": "a"}`), mockService)
+	room.lastPersistedVersion = 1
+	room.deltas = &deltaPolicy{store: store, snapshotEvery: snapshotEvery}
+	return room
+}
+
+func applyTitle(t *testing.T, room *Room, title string) {
+	t.Helper()
+	_, version := room.GetSnapshot()
+	patch, _ := json.Marshal([]map[string]string{{"op": "replace", "path": "/title", "value": title}})
+	assert.NoError(t, room.ApplyPatch(patch, version))
+}
+
+func TestRoom_Flush_DeltaThenSnapshot(t *testing.T) {
+	// 测试场景：snapshotEvery=3，前两次刷盘只写差量，第三次写全量快照
+
+	mockService := new(MockPageService)
+	store := new(MockDeltaStore)
+	room := newDeltaTestRoom(mockService, store, 3)
+
+	store.On("SavePageDelta", "page-1", mock.Anything, int64(1), int64(3)).Return(nil).Once()
+	store.On("SavePageDelta", "page-1", mock.Anything, int64(3), int64(4)).Return(nil).Once()
+	mockService.On("SavePageState", "page-1", []byte(`{"title":"d"}`), int64(4), int64(5)).Return(nil).Once()
+
+	applyTitle(t, room, "b")
+	applyTitle(t, room, "c")
+	room.flushToDB("测试")
+
+	var patches []json.RawMessage
+	assert.NoError(t, json.Unmarshal(store.Calls[0].Arguments.Get(1).([]byte), &patches))
+	assert.Len(t, patches, 2, "差量应包含两次刷盘之间的每个 Patch")
+	assert.Empty(t, room.pendingPatches)
+
+	applyTitle(t, room, "c2")
+	room.flushToDB("测试")
+
+	applyTitle(t, room, "d")
+	room.flushToDB("测试")
+
+	store.AssertExpectations(t)
+	mockService.AssertExpectations(t)
+	assert.Equal(t, int64(5), room.lastPersistedVersion)
+	assert.Empty(t, room.pendingPatches)
+}
+
+func TestRoom_Flush_DeltaFailureKeepsPatches(t *testing.T) {
+	// 测试场景：差量写入失败时保留缓存，下次刷盘包含全部未落盘的 Patch
+
+	mockService := new(MockPageService)
+	store := new(MockDeltaStore)
+	room := newDeltaTestRoom(mockService, store, 10)
+
+	store.On("SavePageDelta", "page-1", mock.Anything, int64(1), int64(2)).Return(assert.AnError).Once()
+	store.On("SavePageDelta", "page-1", mock.Anything, int64(1), int64(3)).Return(nil).Once()
+
+	applyTitle(t, room, "b")
+	room.flushToDB("测试")
+	assert.Len(t, room.pendingPatches, 1)
+
+	applyTitle(t, room, "c")
+	room.flushToDB("测试")
+
+	store.AssertExpectations(t)
+	assert.Equal(t, int64(3), room.lastPersistedVersion)
+	assert.Empty(t, room.pendingPatches)
+}
+
+func TestRoom_Flush_ForceSnapshot(t *testing.T) {
+	// 测试场景：销毁前刷盘总是写全量快照；缓存与版本号对不上时也回退为全量快照
+
+	mockService := new(MockPageService)
+	store := new(MockDeltaStore)
+	room := newDeltaTestRoom(mockService, store, 10)
+
+	mockService.On("SavePageState", "page-1", mock.Anything, int64(1), int64(2)).Return(nil).Once()
+	mockService.On("SavePageState", "page-1", mock.Anything, int64(2), int64(4)).Return(nil).Once()
+
+	applyTitle(t, room, "b")
+	room.persist("销毁前", true)
+
+	applyTitle(t, room, "c")
+	applyTitle(t, room, "d")
+	room.pendingPatches = room.pendingPatches[1:]
+	room.flushToDB("测试")
+
+	store.AssertNotCalled(t, "SavePageDelta", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockService.AssertExpectations(t)
+}
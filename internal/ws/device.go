@@ -0,0 +1,76 @@
+package ws
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 设备标识限制
+const (
+	MaxDeviceIDLength   = 64 // deviceId 最大长度
+	MaxDeviceNameLength = 32 // deviceName 最大字符数，超出部分截断
+)
+
+// newClientID 为每个连接生成的 ID，同一用户的多个连接（多设备、多标签页）据此区分
+func newClientID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "c-" + hex.EncodeToString(buf)
+}
+
+// NormalizeDevice 整理连接时客户端声明的设备：deviceId 由字母、数字和 - _ . : 组成且不超过 MaxDeviceIDLength，
+// 否则忽略；deviceName（如 "iPad"、"desktop"）去掉控制字符和首尾空白后截断到 MaxDeviceNameLength 个字符。
+// 没有 deviceId 时 deviceName 也忽略
+func NormalizeDevice(deviceID, deviceName string) (string, string) {
+	if deviceID == "" || len(deviceID) > MaxDeviceIDLength || !validDeviceID(deviceID) {
+		return "", ""
+	}
+
+	name := strings.TrimSpace(strings.Map(func(ch rune) rune {
+		if unicode.IsControl(ch) {
+			return -1
+		}
+		return ch
+	}, deviceName))
+	if utf8.RuneCountInString(name) > MaxDeviceNameLength {
+		name = strings.TrimSpace(string([]rune(name)[:MaxDeviceNameLength]))
+	}
+	return deviceID, name
+}
+
+// validDeviceID deviceId 是否只包含字母、数字和 - _ . :
+func validDeviceID(id string) bool {
+	for _, ch := range id {
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case ch == '-' || ch == '_' || ch == '.' || ch == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// sameDevice a、b 是否为同一连接，或同一用户在同一设备上的连接（如同一浏览器的多个标签页）。
+// 组件锁属于设备：同一设备的其他连接可以续期、释放持有者的锁，持有者断开时锁转给它们
+func sameDevice(a, b *Client) bool {
+	if a == b {
+		return true
+	}
+	return a.UserInfo.DeviceID != "" &&
+		a.UserInfo.DeviceID == b.UserInfo.DeviceID &&
+		a.UserInfo.UserID == b.UserInfo.UserID
+}
+
+// deviceSibling 返回房间内与 client 同一设备、可以持有组件锁的另一个连接，没有时返回 nil，仅在 run() 内调用
+func (r *Room) deviceSibling(client *Client) *Client {
+	for c := range r.clients {
+		if c != client && sameDevice(c, client) && !c.UserInfo.ReadOnly() {
+			return c
+		}
+	}
+	return nil
+}
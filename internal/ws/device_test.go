@@ -0,0 +1,136 @@
+package ws
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 多设备在线状态单元测试 ==========
+// 测试重点：同一用户的多个设备分别显示，组件锁属于设备
+
+// newDeviceClient 创建加入 room 的客户端，deviceID 为空表示未声明设备
+func newDeviceClient(room *Room, userID, deviceID, deviceName string) *Client {
+	c := NewClient(nil, nil, room.ID, UserInfo{UserID: userID, DeviceID: deviceID, DeviceName: deviceName})
+	c.Room = room
+	room.clients[c] = true
+	return c
+}
+
+func TestNormalizeDevice(t *testing.T) {
+	// 测试场景：合法的 deviceId 原样保留，deviceName 去掉控制字符并截断；deviceId 不合法或为空时两者都忽略
+
+	id, name := NormalizeDevice("d-1a2b:tab", "  Alice 的 iPad\n ")
+	assert.Equal(t, "d-1a2b:tab", id)
+	assert.Equal(t, "Alice 的 iPad", name)
+
+	_, name = NormalizeDevice("d-1", strings.Repeat("桌", MaxDeviceNameLength+5))
+	assert.Equal(t, MaxDeviceNameLength, len([]rune(name)))
+
+	for _, deviceID := range []string{"", "d 1", "d/1", strings.Repeat("x", MaxDeviceIDLength+1)} {
+		id, name := NormalizeDevice(deviceID, "iPad")
+		assert.Empty(t, id, deviceID)
+		assert.Empty(t, name, deviceID)
+	}
+}
+
+func TestNewClient_ClientID(t *testing.T) {
+	// 测试场景：每个连接生成不同的 clientId，已指定时保留
+
+	a := NewClient(nil, nil, "page-1", UserInfo{UserID: "alice"})
+	b := NewClient(nil, nil, "page-1", UserInfo{UserID: "alice"})
+	assert.NotEmpty(t, a.UserInfo.ClientID)
+	assert.NotEqual(t, a.UserInfo.ClientID, b.UserInfo.ClientID)
+
+	c := NewClient(nil, nil, "page-1", UserInfo{UserID: "alice", ClientID: "c-fixed"})
+	assert.Equal(t, "c-fixed", c.UserInfo.ClientID)
+}
+
+func TestLockTable_SameDevice(t *testing.T) {
+	// 测试场景：同一设备的其他连接续期时锁转给它，也可以释放；同一用户的其他设备、未声明设备的连接被拒绝
+
+	table := newLockTable(LockTTL)
+	tab1 := &Client{UserInfo: UserInfo{UserID: "alice", DeviceID: "desktop"}}
+	tab2 := &Client{UserInfo: UserInfo{UserID: "alice", DeviceID: "desktop"}}
+	ipad := &Client{UserInfo: UserInfo{UserID: "alice", DeviceID: "ipad"}}
+	bob := &Client{UserInfo: UserInfo{UserID: "bob", DeviceID: "desktop"}}
+	now := time.Now()
+
+	first, ok := table.acquire("c1", tab1, now)
+	require.True(t, ok)
+	acquiredAt := first.acquiredAt
+
+	for _, other := range []*Client{ipad, bob, {UserInfo: UserInfo{UserID: "alice"}}} {
+		lock, ok := table.acquire("c1", other, now)
+		assert.False(t, ok)
+		assert.Same(t, tab1, lock.holder)
+	}
+
+	lock, ok := table.acquire("c1", tab2, now.Add(time.Second))
+	require.True(t, ok)
+	assert.Same(t, tab2, lock.holder)
+	assert.Equal(t, acquiredAt, lock.acquiredAt)
+	assert.Equal(t, now.Add(time.Second+LockTTL), lock.expiresAt)
+
+	// 同一设备抢占不算被抢占，其他设备抢占时返回原持有者
+	assert.Nil(t, table.steal("c1", tab1, now))
+	assert.Same(t, tab1, table.steal("c1", ipad, now))
+	assert.Same(t, ipad, table.steal("c1", tab2, now))
+
+	assert.False(t, table.release("c1", ipad))
+	assert.True(t, table.release("c1", tab1))
+}
+
+func TestRoom_HandOverLocksOnDisconnect(t *testing.T) {
+	// 测试场景：持有者断开时锁转给同一设备的其他连接并广播新持有者；同一设备没有其他连接时释放
+
+	room := newTestRoom("page-1", []byte(`{}`), new(MockPageService))
+	room.selections = newSelectionTable()
+	tab1 := newDeviceClient(room, "alice", "desktop", "desktop")
+	tab2 := newDeviceClient(room, "alice", "desktop", "desktop")
+	ipad := newDeviceClient(room, "alice", "ipad", "iPad")
+
+	room.locks.acquire("c1", tab1, time.Now())
+
+	room.removeClient(tab1)
+	for _, c := range []*Client{tab2, ipad} {
+		var lock LockPayload
+		readMessage(t, c, TypeLockAcquired, &lock)
+		assert.Equal(t, tab2.UserInfo.ClientID, lock.User.ClientID)
+
+		var left UserInfo
+		readMessage(t, c, TypeUserLeave, &left)
+		assert.Equal(t, tab1.UserInfo.ClientID, left.ClientID)
+	}
+	assert.Same(t, tab2, room.locks.locks["c1"].holder)
+
+	room.removeClient(tab2)
+	var released LockPayload
+	readMessage(t, ipad, TypeLockReleased, &released)
+	assert.Equal(t, LockReasonDisconnected, released.Reason)
+	assert.Empty(t, room.locks.locks)
+}
+
+func TestRoom_SyncPresencePerDevice(t *testing.T) {
+	// 测试场景：sync 带回本连接的 clientId，同一用户的其他设备作为单独的在线项下发
+
+	room := newTestRoom("page-1", []byte(`{}`), new(MockPageService))
+	room.selections = newSelectionTable()
+	desktop := newDeviceClient(room, "alice", "desktop", "desktop")
+	ipad := newDeviceClient(room, "alice", "ipad", "iPad")
+
+	data, _ := room.encodeSync(desktop)
+	var msg WSMessage
+	require.NoError(t, json.Unmarshal(data, &msg))
+	var sync SyncPayload
+	require.NoError(t, json.Unmarshal(msg.Payload, &sync))
+
+	assert.Equal(t, desktop.UserInfo.ClientID, sync.ClientID)
+	require.Len(t, sync.Users, 1)
+	assert.Equal(t, ipad.UserInfo.ClientID, sync.Users[0].ClientID)
+	assert.Equal(t, "iPad", sync.Users[0].DeviceName)
+}
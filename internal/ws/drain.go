@@ -0,0 +1,133 @@
+package ws
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// 实例状态，通过 /ready 和 X-Instance-State 响应头告知负载均衡与客户端，见 InstanceState
+const (
+	InstanceReady       = "ready"
+	InstanceOverloaded  = "overloaded"  // 过载保护中：仍然服务，但新流量应优先分给其他实例
+	InstanceMaintenance = "maintenance" // 运维开启了维护模式，实例退出负载均衡
+	InstanceDraining    = "draining"    // 即将停机，实例退出负载均衡
+)
+
+// DefaultReconnectWindow 维护模式通知客户端分散重连的时间窗口
+const DefaultReconnectWindow = 30 * time.Second
+
+// ServerDrainingPayload server-draining 消息的 payload 结构
+type ServerDrainingPayload struct {
+	Reason string `json:"reason"` // draining / maintenance
+
+	// ReconnectWithinMs 客户端应在 [0, ReconnectWithinMs) 内随机选一个时间断开并重连，
+	// 负载均衡会把重连分配到其他实例；各客户端错开重连，避免同时涌向其他实例
+	ReconnectWithinMs int64  `json:"reconnectWithinMs"`
+	Message           string `json:"message"`
+}
+
+// drainState 排空与维护模式，受 mu 保护
+type drainState struct {
+	mu          sync.RWMutex
+	leaving     bool          // 已调用 Drain，即将停机
+	maintenance bool          // 维护模式
+	window      time.Duration // 最近一次通知的重连窗口，新加入的客户端使用同一窗口
+}
+
+// InstanceState 返回实例当前的状态：停机或排空中为 draining，维护模式为 maintenance，
+// 过载保护中为 overloaded，否则为 ready
+func (h *Hub) InstanceState() string {
+	h.drain.mu.RLock()
+	leaving, maintenance := h.drain.leaving, h.drain.maintenance
+	h.drain.mu.RUnlock()
+
+	switch {
+	case leaving || h.draining.Load():
+		return InstanceDraining
+	case maintenance:
+		return InstanceMaintenance
+	case h.watchdog != nil && h.watchdog.Overloaded():
+		return InstanceOverloaded
+	}
+	return InstanceReady
+}
+
+// Drain 停机前的排空阶段：InstanceState 变为 draining，负载均衡随 /ready 失败停止分配新流量；
+// 在线客户端收到 server-draining，在 window 内分散重连到其他实例。房间照常服务，
+// 之后仍需调用 Shutdown 刷盘并断开剩余连接。返回通知的房间数
+func (h *Hub) Drain(window time.Duration) int {
+	h.drain.mu.Lock()
+	h.drain.leaving = true
+	h.drain.window = window
+	h.drain.mu.Unlock()
+
+	rooms := h.notifyDraining(InstanceDraining, window)
+	log.Printf("[Hub] 开始排空，已通知 %d 个房间的客户端在 %s 内重连到其他实例", rooms, window)
+	return rooms
+}
+
+// SetMaintenance 开启或关闭维护模式。开启时实例退出负载均衡（/ready 失败），在线客户端收到 server-draining，
+// 在 window 内分散重连到其他实例；连接不会被强制断开，单实例部署时客户端重连后仍回到本实例。
+// 返回通知的房间数，关闭或重复开启时为 0
+func (h *Hub) SetMaintenance(enabled bool, window time.Duration) int {
+	h.drain.mu.Lock()
+	changed := h.drain.maintenance != enabled
+	h.drain.maintenance = enabled
+	if enabled {
+		h.drain.window = window
+	}
+	h.drain.mu.Unlock()
+
+	if !changed {
+		return 0
+	}
+	if !enabled {
+		log.Printf("[Hub] 已关闭维护模式")
+		return 0
+	}
+	rooms := h.notifyDraining(InstanceMaintenance, window)
+	log.Printf("[Hub] 已开启维护模式，通知 %d 个房间的客户端在 %s 内重连到其他实例", rooms, window)
+	return rooms
+}
+
+// Maintenance 是否处于维护模式
+func (h *Hub) Maintenance() bool {
+	h.drain.mu.RLock()
+	defer h.drain.mu.RUnlock()
+	return h.drain.maintenance
+}
+
+// notifyDraining 向所有房间广播 server-draining，返回房间数
+func (h *Hub) notifyDraining(reason string, window time.Duration) int {
+	data := encodeServerMessage(TypeServerDraining, drainingPayload(reason, window))
+	rooms := h.allRooms()
+	for _, room := range rooms {
+		room.notifyAll(data)
+	}
+	return len(rooms)
+}
+
+// drainingPayload 按原因生成 server-draining 的内容
+func drainingPayload(reason string, window time.Duration) ServerDrainingPayload {
+	message := "服务即将重启，连接将自动切换到其他服务器"
+	if reason == InstanceMaintenance {
+		message = "服务器即将维护，连接将自动切换到其他服务器"
+	}
+	return ServerDrainingPayload{Reason: reason, ReconnectWithinMs: window.Milliseconds(), Message: message}
+}
+
+// sendDrainHint 排空或维护期间新加入的客户端同样收到 server-draining，仅在 run() 内调用
+func (r *Room) sendDrainHint(client *Client) {
+	if r.hub == nil {
+		return
+	}
+	state := r.hub.InstanceState()
+	if state != InstanceDraining && state != InstanceMaintenance {
+		return
+	}
+	r.hub.drain.mu.RLock()
+	window := r.hub.drain.window
+	r.hub.drain.mu.RUnlock()
+	r.sendToClient(client, encodeServerMessage(TypeServerDraining, drainingPayload(state, window)))
+}
@@ -0,0 +1,118 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 排空与维护模式单元测试 ==========
+
+// waitForDraining 跳过其他消息，等待客户端收到下一条 server-draining
+func waitForDraining(t *testing.T, c *Client) (ServerDrainingPayload, bool) {
+	t.Helper()
+	timeout := time.After(200 * time.Millisecond)
+	for {
+		select {
+		case data := <-c.send:
+			var msg WSMessage
+			require.NoError(t, json.Unmarshal(data, &msg))
+			if msg.Type != TypeServerDraining {
+				continue
+			}
+			var payload ServerDrainingPayload
+			require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+			return payload, true
+		case <-timeout:
+			return ServerDrainingPayload{}, false
+		}
+	}
+}
+
+func TestHub_InstanceState(t *testing.T) {
+	// 测试场景：默认 ready；过载时为 overloaded；维护模式优先于过载；排空优先于维护模式
+
+	w := NewWatchdog(WatchdogConfig{MaxGoroutines: 100})
+	hub := NewHub(new(MockPageService), WithWatchdog(w))
+	assert.Equal(t, InstanceReady, hub.InstanceState())
+
+	w.observe(1000, 0, 0, time.Now())
+	assert.Equal(t, InstanceOverloaded, hub.InstanceState())
+
+	hub.SetMaintenance(true, time.Second)
+	assert.True(t, hub.Maintenance())
+	assert.Equal(t, InstanceMaintenance, hub.InstanceState())
+
+	hub.Drain(time.Second)
+	assert.Equal(t, InstanceDraining, hub.InstanceState())
+
+	stopped := NewHub(new(MockPageService))
+	stopped.draining.Store(true)
+	assert.Equal(t, InstanceDraining, stopped.InstanceState(), "Shutdown 期间同样为 draining")
+}
+
+func TestHub_Drain(t *testing.T) {
+	// 测试场景：Drain 通知所有房间的客户端在窗口内重连，房间照常服务；之后加入的客户端同样收到提示
+
+	hub, alice, bob := newNoticeTestHub(t)
+
+	rooms := hub.Drain(20 * time.Second)
+	assert.Equal(t, 2, rooms)
+	for _, c := range []*Client{alice, bob} {
+		payload, ok := waitForDraining(t, c)
+		require.True(t, ok, "%s 未收到排空提示", c.UserInfo.UserID)
+		assert.Equal(t, InstanceDraining, payload.Reason)
+		assert.Equal(t, int64(20000), payload.ReconnectWithinMs)
+		assert.NotEmpty(t, payload.Message)
+	}
+
+	room := hub.GetRoom("page-1")
+	require.NotNil(t, room)
+	carol := &Client{UserInfo: UserInfo{UserID: "carol", UserName: "Carol"}, send: make(chan []byte, 16)}
+	require.NoError(t, room.Register(carol))
+	payload, ok := waitForDraining(t, carol)
+	require.True(t, ok)
+	assert.Equal(t, int64(20000), payload.ReconnectWithinMs)
+}
+
+func TestHub_SetMaintenance(t *testing.T) {
+	// 测试场景：开启维护模式时通知在线客户端，重复开启不再通知；关闭后新加入的客户端不再收到提示
+
+	hub, alice, _ := newNoticeTestHub(t)
+
+	assert.Equal(t, 2, hub.SetMaintenance(true, 30*time.Second))
+	payload, ok := waitForDraining(t, alice)
+	require.True(t, ok)
+	assert.Equal(t, InstanceMaintenance, payload.Reason)
+	assert.Equal(t, int64(30000), payload.ReconnectWithinMs)
+
+	assert.Equal(t, 0, hub.SetMaintenance(true, 30*time.Second))
+	_, ok = waitForDraining(t, alice)
+	assert.False(t, ok, "重复开启不应再次通知")
+
+	assert.Equal(t, 0, hub.SetMaintenance(false, 0))
+	assert.False(t, hub.Maintenance())
+	assert.Equal(t, InstanceReady, hub.InstanceState())
+
+	room := hub.GetRoom("page-2")
+	require.NotNil(t, room)
+	dave := &Client{UserInfo: UserInfo{UserID: "dave", UserName: "Dave"}, send: make(chan []byte, 16)}
+	require.NoError(t, room.Register(dave))
+	_, ok = waitForDraining(t, dave)
+	assert.False(t, ok)
+}
+
+func TestHub_Drain_NoRooms(t *testing.T) {
+	// 测试场景：没有房间时 Drain 返回 0，状态仍变为 draining
+
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", mock.Anything).Return([]byte(`{}`), int64(1), nil).Maybe()
+	hub := NewHub(mockService)
+
+	assert.Equal(t, 0, hub.Drain(time.Second))
+	assert.Equal(t, InstanceDraining, hub.InstanceState())
+}
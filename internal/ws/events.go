@@ -0,0 +1,84 @@
+package ws
+
+import (
+	"encoding/json"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/logging"
+)
+
+// LifecycleTopic 房间生命周期事件在 Outbox 中的主题
+const LifecycleTopic = "room.lifecycle"
+
+// LifecycleEventType 房间生命周期事件类型
+type LifecycleEventType string
+
+const (
+	EventRoomCreated    LifecycleEventType = "room.created"      // 房间从数据库版本加载
+	EventFlushCompleted LifecycleEventType = "room.flushed"      // 刷盘成功
+	EventFlushFailed    LifecycleEventType = "room.flush_failed" // 刷盘失败
+	EventRoomDestroyed  LifecycleEventType = "room.destroyed"    // 房间事件循环退出
+)
+
+// LifecycleEvent 房间生命周期事件。
+// 外部巡检程序据此核对数据库版本与房间声称的持久化进度：
+// 若 room.destroyed 的 PersistedVersion 小于 Version，说明有未落盘的编辑。
+type LifecycleEvent struct {
+	Type             LifecycleEventType `json:"type"`
+	PageID           string             `json:"pageId"`
+	Version          int64              `json:"version"`               // 事件发生时的内存版本
+	PersistedVersion int64              `json:"persistedVersion"`      // 事件发生后已确认落盘的版本
+	FromVersion      int64              `json:"fromVersion,omitempty"` // 刷盘事件的起始版本
+	Reason           string             `json:"reason,omitempty"`
+	Error            string             `json:"error,omitempty"`
+	Timestamp        int64              `json:"timestamp"`
+}
+
+// EventSink 生命周期事件接收方，实现需自行处理失败（不得 panic）
+type EventSink interface {
+	Publish(event LifecycleEvent)
+}
+
+// OutboxStore 事件持久化接口，由 repository 层实现
+type OutboxStore interface {
+	Append(event *entity.OutboxEvent) error
+}
+
+// OutboxSink 将生命周期事件写入 Outbox 表
+type OutboxSink struct {
+	store OutboxStore
+}
+
+// NewOutboxSink 创建 OutboxSink 实例
+func NewOutboxSink(store OutboxStore) *OutboxSink {
+	return &OutboxSink{store: store}
+}
+
+// Publish 同步写入一条事件，失败只记录日志，不影响房间运行
+func (s *OutboxSink) Publish(event LifecycleEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		logging.Errorf("[Outbox] 事件序列化失败: %v", err)
+		return
+	}
+
+	if err := s.store.Append(&entity.OutboxEvent{
+		Topic:     LifecycleTopic,
+		Key:       event.PageID,
+		Payload:   payload,
+		CreatedAt: time.UnixMilli(event.Timestamp),
+	}); err != nil {
+		logging.Errorf("[Outbox] 写入事件 %s (%s) 失败: %v", event.Type, event.PageID, err)
+	}
+}
+
+// emit 发送房间生命周期事件，未配置 EventSink 时忽略
+func (r *Room) emit(event LifecycleEvent) {
+	if r.events == nil {
+		return
+	}
+	event.PageID = r.ID
+	event.Timestamp = time.Now().UnixMilli()
+	r.events.Publish(event)
+}
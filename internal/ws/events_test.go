@@ -0,0 +1,83 @@
+package ws
+
+import (
+	"errors"
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ========== 生命周期事件单元测试 ==========
+// 测试重点：创建、刷盘、销毁各阶段的事件及其版本字段
+
+func TestRoom_LifecycleEvents(t *testing.T) {
+	// 测试场景：创建房间 -> 编辑 -> 停止，依次产生 created / flushed / destroyed
+
+	mockService := new(MockPageService)
+	sink := &MockEventSink{}
+	hub := NewHub(mockService, WithEventSink(sink))
+
+	mockService.On("GetPageState", "page-1").Return([]byte(`{"title": "a"}`), int64(3), nil)
+	mockService.On("SavePageState", "page-1", mock.Anything, int64(3), int64(4)).Return(nil)
+
+	room, err := hub.GetOrCreateRoom("page-1")
+	assert.NoError(t, err)
+
+	patch := []byte(`[{"op": "replace", "path": "/title", "value": "b"}]`)
+	assert.NoError(t, room.ApplyPatch(patch, 3))
+	room.Stop()
+
+	assert.Equal(t, []LifecycleEventType{EventRoomCreated, EventFlushCompleted, EventRoomDestroyed}, sink.Types())
+
+	destroyed := sink.Last()
+	assert.Equal(t, "page-1", destroyed.PageID)
+	assert.Equal(t, int64(4), destroyed.Version)
+	assert.Equal(t, int64(4), destroyed.PersistedVersion)
+}
+
+func TestRoom_LifecycleEvents_FlushFailed(t *testing.T) {
+	// 测试场景：刷盘失败时发送 flush_failed，销毁事件暴露未落盘的版本差
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(errors.New("db down"))
+
+	room := newTestRoom("page-1", []byte(`{"title": "a"}`), mockService)
+	room.lastPersistedVersion = 1
+	sink := &MockEventSink{}
+	room.events = sink
+
+	patch := []byte(`[{"op": "replace", "path": "/title", "value": "b"}]`)
+	assert.NoError(t, room.ApplyPatch(patch, 1))
+	room.flushToDB("测试")
+
+	failed := sink.Last()
+	assert.Equal(t, EventFlushFailed, failed.Type)
+	assert.Equal(t, int64(1), failed.FromVersion)
+	assert.Equal(t, int64(2), failed.Version)
+	assert.Equal(t, int64(1), failed.PersistedVersion)
+	assert.Equal(t, "db down", failed.Error)
+}
+
+type recordingOutboxStore struct {
+	events []*entity.OutboxEvent
+}
+
+func (s *recordingOutboxStore) Append(event *entity.OutboxEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestOutboxSink_Publish(t *testing.T) {
+	store := &recordingOutboxStore{}
+	NewOutboxSink(store).Publish(LifecycleEvent{Type: EventRoomCreated, PageID: "page-1", Version: 3})
+
+	assert.Len(t, store.events, 1)
+	assert.Equal(t, LifecycleTopic, store.events[0].Topic)
+	assert.Equal(t, "page-1", store.events[0].Key)
+	assert.JSONEq(t, `{"type": "room.created", "pageId": "page-1", "version": 3, "persistedVersion": 0, "timestamp": 0}`,
+		string(store.events[0].Payload))
+}
@@ -0,0 +1,109 @@
+package ws
+
+import (
+	"math"
+	"time"
+)
+
+// FlushPolicy 自适应刷盘的边界。
+// 房间每次定时刷盘时按上一周期的编辑速率和刷盘耗时重新计算节奏：
+// 编辑稀疏时按 MinInterval 频繁地写入少量修改，缩短崩溃时可能丢失的时间窗口；
+// 编辑密集时按速率放大阈值、大约每个周期攒满一次，由阈值触发刷盘，定时刷盘放宽到 MaxInterval 只作兜底。
+// 刷盘耗时变长（数据库繁忙）时周期随之拉长，刷盘最多占用 1/flushLatencyFactor 的时间
+type FlushPolicy struct {
+	MinInterval  time.Duration // 编辑稀疏时的定时刷盘间隔
+	MaxInterval  time.Duration // 编辑密集时的定时刷盘间隔，也是刷盘周期的上限
+	MinThreshold int64         // 触发刷盘的未保存版本数下限，也是房间创建时的初始阈值
+	MaxThreshold int64         // 触发刷盘的未保存版本数上限
+}
+
+// DefaultFlushPolicy 默认刷盘边界
+var DefaultFlushPolicy = FlushPolicy{
+	MinInterval:  2 * time.Second,
+	MaxInterval:  30 * time.Second,
+	MinThreshold: 20,
+	MaxThreshold: 500,
+}
+
+// flushLatencyFactor 刷盘周期至少为平均刷盘耗时的倍数
+const flushLatencyFactor = 10
+
+// WithFlushPolicy 设置自适应刷盘的边界，只影响之后创建的房间；页面设置覆盖的间隔或阈值不受影响
+func WithFlushPolicy(policy FlushPolicy) HubOption {
+	return func(h *Hub) {
+		h.flushPolicy = &policy
+	}
+}
+
+// next 按编辑速率（版本/秒）和平均刷盘耗时计算下一周期的定时刷盘间隔和阈值
+func (p FlushPolicy) next(rate float64, latency time.Duration) (time.Duration, int64) {
+	period := min(max(latency*flushLatencyFactor, p.MinInterval), p.MaxInterval)
+
+	expected := int64(math.Ceil(rate * period.Seconds()))
+	if expected < p.MinThreshold {
+		// 编辑稀疏：阈值攒不满，由定时刷盘尽快写入
+		return period, p.MinThreshold
+	}
+	// 编辑密集：大约每个周期攒满一次阈值，定时刷盘只作兜底
+	return p.MaxInterval, min(expected, p.MaxThreshold)
+}
+
+// adaptFlush 按上一周期的编辑速率和刷盘耗时调整刷盘节奏，仅在 run() 内调用。
+// 页面设置覆盖了刷盘间隔时保留覆盖值，覆盖了阈值时 flushThresholdLocked 优先使用覆盖值
+func (r *Room) adaptFlush(now time.Time) {
+	pinned := r.collabSettings().FlushInterval() > 0
+
+	r.stateMu.Lock()
+	if elapsed := now.Sub(r.rateSince).Seconds(); elapsed > 0 {
+		rate := float64(r.Version-r.rateVersion) / elapsed
+		r.patchRate = (r.patchRate + rate) / 2
+	}
+	r.rateVersion, r.rateSince = r.Version, now
+	interval, threshold := r.flushPolicy.next(r.patchRate, r.flushLatency)
+	r.adaptiveThreshold = threshold
+	reset := !pinned && interval != r.flushEvery
+	if reset {
+		r.flushEvery = interval
+	}
+	r.stateMu.Unlock()
+
+	if reset {
+		r.flushTicker.Reset(interval)
+	}
+}
+
+// adaptiveIntervalLocked 按当前的编辑速率和刷盘耗时返回自适应的定时刷盘间隔，调用方需持有 stateMu
+func (r *Room) adaptiveIntervalLocked() time.Duration {
+	if interval, _ := r.flushPolicy.next(r.patchRate, r.flushLatency); interval > 0 {
+		return interval
+	}
+	return DefaultFlushPolicy.MinInterval
+}
+
+// recordFlushLatencyLocked 记录一次成功刷盘的耗时（滑动平均），调用方需持有 stateMu
+func (r *Room) recordFlushLatencyLocked(d time.Duration) {
+	if r.flushLatency == 0 {
+		r.flushLatency = d
+	} else {
+		r.flushLatency = (r.flushLatency + d) / 2
+	}
+	patchMetrics.Add("flushNanos", d.Nanoseconds())
+}
+
+// FlushPace 房间当前的刷盘节奏
+type FlushPace struct {
+	Interval  string  `json:"interval"`  // 定时刷盘间隔
+	Threshold int64   `json:"threshold"` // 触发刷盘的未保存版本数
+	PatchRate float64 `json:"patchRate"` // 编辑速率（版本/秒），滑动平均
+	Latency   string  `json:"latency"`   // 刷盘耗时，滑动平均
+}
+
+// flushPaceLocked 返回房间当前的刷盘节奏，调用方需持有 stateMu
+func (r *Room) flushPaceLocked() FlushPace {
+	return FlushPace{
+		Interval:  r.flushEvery.String(),
+		Threshold: r.flushThresholdLocked(),
+		PatchRate: math.Round(r.patchRate*100) / 100,
+		Latency:   r.flushLatency.String(),
+	}
+}
@@ -0,0 +1,68 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== 自适应刷盘单元测试 ==========
+
+func TestFlushPolicy_Next(t *testing.T) {
+	// 测试场景：编辑稀疏时按最短间隔定时刷盘；编辑密集时阈值随速率放大、不超过上限，定时刷盘放宽到最长间隔；
+	// 刷盘耗时变长时周期拉长，不超过最长间隔
+
+	p := DefaultFlushPolicy
+
+	interval, threshold := p.next(0, 0)
+	assert.Equal(t, p.MinInterval, interval)
+	assert.Equal(t, p.MinThreshold, threshold)
+
+	interval, threshold = p.next(1, time.Second)
+	assert.Equal(t, 10*time.Second, interval)
+	assert.Equal(t, p.MinThreshold, threshold)
+
+	interval, threshold = p.next(40, 0)
+	assert.Equal(t, p.MaxInterval, interval)
+	assert.Equal(t, int64(80), threshold)
+
+	interval, threshold = p.next(1000, time.Hour)
+	assert.Equal(t, p.MaxInterval, interval)
+	assert.Equal(t, p.MaxThreshold, threshold)
+}
+
+func TestRoom_AdaptFlush(t *testing.T) {
+	// 测试场景：编辑密集时放大阈值、放宽定时刷盘；编辑停止后速率衰减，恢复频繁的定时刷盘；
+	// 页面设置覆盖了刷盘间隔时只调整阈值
+
+	room := newTestRoom("test-room", []byte(`{}`), new(MockPageService))
+	room.flushPolicy = DefaultFlushPolicy
+	room.flushEvery = DefaultFlushPolicy.MinInterval
+
+	now := time.Now()
+	room.rateVersion, room.rateSince = room.Version, now.Add(-time.Second)
+	room.Version += 100
+	room.adaptFlush(now)
+	assert.Equal(t, DefaultFlushPolicy.MaxInterval, room.flushEvery)
+	assert.Equal(t, int64(100), room.flushThresholdLocked())
+
+	for i := 1; i <= 3; i++ {
+		room.adaptFlush(now.Add(time.Duration(i) * time.Second))
+	}
+	assert.Equal(t, DefaultFlushPolicy.MinInterval, room.flushEvery)
+	assert.Equal(t, DefaultFlushPolicy.MinThreshold, room.flushThresholdLocked())
+
+	room.settings = &entity.CollabSettings{FlushIntervalSeconds: 60}
+	room.flushEvery = time.Minute
+	room.Version += 1000
+	room.adaptFlush(now.Add(5 * time.Second))
+	assert.Equal(t, time.Minute, room.flushEvery)
+	assert.Equal(t, DefaultFlushPolicy.MaxThreshold, room.flushThresholdLocked())
+
+	pace := room.flushPaceLocked()
+	assert.Equal(t, "1m0s", pace.Interval)
+	assert.Greater(t, pace.PatchRate, 0.0)
+}
@@ -0,0 +1,241 @@
+package ws
+
+import (
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/logging"
+)
+
+// FlushWorkerConfig 所有房间共用的刷盘协程池。
+// 房间触发的刷盘（定时、阈值）进入共享队列，同一房间排队期间的多次触发合并为一次；
+// 写入协程每次从队列取出至多 DequeueSize 个房间逐个写入（每个房间各自一次刷盘，不合并为一个事务），
+// 同时写数据库的房间数不超过 Workers。
+// 刷盘失败（乐观锁冲突、页面不存在除外）按指数退避重试，重试期间的新触发并入这次重试，不会继续冲击数据库
+type FlushWorkerConfig struct {
+	Workers     int           // 写入协程数，即同时写数据库的房间数上限
+	DequeueSize int           // 写入协程每次从队列取出的房间数，只减少争抢队列锁的次数，取出的房间仍逐个刷盘
+	MaxRetries  int           // 刷盘失败后的最大重试次数，之后等待下一次触发
+	RetryBase   time.Duration // 首次重试的等待时间，之后每次翻倍
+	RetryMax    time.Duration // 重试等待时间的上限
+}
+
+// DefaultFlushWorkers 默认刷盘协程池配置
+var DefaultFlushWorkers = FlushWorkerConfig{
+	Workers:     8,
+	DequeueSize: 16,
+	MaxRetries:  5,
+	RetryBase:   500 * time.Millisecond,
+	RetryMax:    30 * time.Second,
+}
+
+// flushMetrics 刷盘协程池计数，通过 expvar 暴露：
+// queued 为进入队列的刷盘，coalesced 为并入已排队刷盘的触发，dequeues 为写入协程从队列取出房间的次数，
+// retried 为失败后安排的重试，gaveUp 为重试用尽或不可重试而放弃的刷盘（状态仍在内存中，等待下一次触发）
+var flushMetrics = expvar.NewMap("ws_flush")
+
+// WithFlushWorkers 设置刷盘协程池，Workers、DequeueSize 和重试等待时间未设置时使用 DefaultFlushWorkers 中的值，
+// MaxRetries 为 0 时不重试
+func WithFlushWorkers(cfg FlushWorkerConfig) HubOption {
+	return func(h *Hub) {
+		h.flusher = newFlushScheduler(cfg)
+	}
+}
+
+// flushJob 一个房间排队中、写入中或等待重试的刷盘
+type flushJob struct {
+	reason  string
+	attempt int  // 已失败的次数
+	running bool // 写入协程正在处理
+	again   bool // 写入期间又有新的触发，完成后重新排队
+}
+
+// flushScheduler 刷盘协程池，写入协程在第一次排队时启动
+type flushScheduler struct {
+	cfg   FlushWorkerConfig
+	slots chan struct{} // 同时写数据库的房间数上限，销毁前的最后一次刷盘同样占用
+
+	mu    sync.Mutex
+	cond  *sync.Cond
+	queue []*Room
+	jobs  map[*Room]*flushJob // 排队中、写入中或等待重试的房间，受 mu 保护
+
+	start sync.Once
+}
+
+func newFlushScheduler(cfg FlushWorkerConfig) *flushScheduler {
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultFlushWorkers.Workers
+	}
+	if cfg.DequeueSize <= 0 {
+		cfg.DequeueSize = DefaultFlushWorkers.DequeueSize
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBase <= 0 {
+		cfg.RetryBase = DefaultFlushWorkers.RetryBase
+	}
+	if cfg.RetryMax < cfg.RetryBase {
+		cfg.RetryMax = max(DefaultFlushWorkers.RetryMax, cfg.RetryBase)
+	}
+	s := &flushScheduler{
+		cfg:   cfg,
+		slots: make(chan struct{}, cfg.Workers),
+		jobs:  make(map[*Room]*flushJob),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// schedule 把房间加入刷盘队列，不阻塞；房间已在队列、写入中或等待重试时合并
+func (s *flushScheduler) schedule(room *Room, reason string) {
+	s.start.Do(func() {
+		for i := 0; i < s.cfg.Workers; i++ {
+			go s.work()
+		}
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[room]; ok {
+		if job.running {
+			job.again = true
+		}
+		flushMetrics.Add("coalesced", 1)
+		return
+	}
+	s.jobs[room] = &flushJob{reason: reason}
+	s.enqueueLocked(room)
+	flushMetrics.Add("queued", 1)
+}
+
+// enqueueLocked 把房间放入队列并唤醒一个写入协程，调用方需持有 mu
+func (s *flushScheduler) enqueueLocked(room *Room) {
+	s.queue = append(s.queue, room)
+	s.cond.Signal()
+}
+
+// work 写入协程：每次从队列取出至多 DequeueSize 个房间，逐个刷盘
+func (s *flushScheduler) work() {
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 {
+			s.cond.Wait()
+		}
+		n := min(len(s.queue), s.cfg.DequeueSize)
+		rooms := make([]*Room, n)
+		copy(rooms, s.queue)
+		s.queue = s.queue[n:]
+		reasons := make([]string, n)
+		for i, room := range rooms {
+			job := s.jobs[room]
+			job.running = true
+			reasons[i] = job.reason
+		}
+		s.mu.Unlock()
+		flushMetrics.Add("dequeues", 1)
+
+		for i, room := range rooms {
+			err := s.persist(room, reasons[i], false)
+			s.finish(room, err)
+		}
+	}
+}
+
+// finish 处理一次刷盘的结果：可重试的失败按退避时间重新排队，写入期间有新触发时立即重新排队；
+// 等待重试期间房间开始停止时放弃重试，未保存的状态由销毁前的最后一次刷盘写入
+func (s *flushScheduler) finish(room *Room, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.jobs[room]
+	job.running = false
+
+	if err != nil && !room.IsStopping() {
+		if retryableFlushError(err) && job.attempt < s.cfg.MaxRetries {
+			delay := s.backoff(job.attempt)
+			job.attempt++
+			job.again = false
+			flushMetrics.Add("retried", 1)
+			logging.Warnf("[Room %s] 刷盘失败，%s 后第 %d 次重试", room.ID, delay, job.attempt)
+			time.AfterFunc(delay, func() {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				if room.IsStopping() {
+					delete(s.jobs, room)
+					return
+				}
+				s.enqueueLocked(room)
+			})
+			return
+		}
+		flushMetrics.Add("gaveUp", 1)
+		logging.Errorf("[Room %s] 刷盘失败且不再重试（已重试 %d 次），等待下一次触发: %v", room.ID, job.attempt, err)
+	}
+
+	if job.again && !room.IsStopping() {
+		job.again = false
+		job.attempt = 0
+		s.enqueueLocked(room)
+		return
+	}
+	delete(s.jobs, room)
+}
+
+// flushNow 同步刷盘，用于房间销毁前的最后一次刷盘：占用写入名额，失败时按退避时间重试，返回最后一次的错误
+func (s *flushScheduler) flushNow(room *Room, reason string, forceSnapshot bool) error {
+	for attempt := 0; ; attempt++ {
+		err := s.persist(room, reason, forceSnapshot)
+		if err == nil || !retryableFlushError(err) || attempt >= s.cfg.MaxRetries {
+			if err != nil && attempt > 0 {
+				logging.Errorf("[Room %s] %s刷盘重试 %d 次后仍失败: %v", room.ID, reason, attempt, err)
+			}
+			return err
+		}
+		flushMetrics.Add("retried", 1)
+		time.Sleep(s.backoff(attempt))
+	}
+}
+
+// persist 占用一个写入名额执行一次刷盘
+func (s *flushScheduler) persist(room *Room, reason string, forceSnapshot bool) error {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+	return room.persist(reason, forceSnapshot)
+}
+
+// backoff 第 attempt 次失败后的等待时间
+func (s *flushScheduler) backoff(attempt int) time.Duration {
+	delay := s.cfg.RetryBase
+	for i := 0; i < attempt && delay < s.cfg.RetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, s.cfg.RetryMax)
+}
+
+// retryableFlushError 刷盘错误是否值得重试：乐观锁冲突（数据库中的版本已被其他写入推进）
+// 和页面已删除重试也不会成功
+func retryableFlushError(err error) bool {
+	return !errors.Is(err, domainErrors.ErrOptimisticLock) && !errors.Is(err, domainErrors.ErrPageNotFound)
+}
+
+// scheduleFlush 把刷盘交给协程池，不阻塞房间的事件循环；未关联 Hub 时直接在新协程中刷盘
+func (r *Room) scheduleFlush(reason string) {
+	if r.hub == nil || r.hub.flusher == nil {
+		go r.flushToDB(reason)
+		return
+	}
+	r.hub.flusher.schedule(r, reason)
+}
+
+// flushFinal 销毁前的最后一次刷盘（全量快照），失败时重试，仅在 run() 退出时调用
+func (r *Room) flushFinal() {
+	if r.hub == nil || r.hub.flusher == nil {
+		r.persist("销毁前", true)
+		return
+	}
+	r.hub.flusher.flushNow(r, "销毁前", true)
+}
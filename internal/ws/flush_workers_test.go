@@ -0,0 +1,206 @@
+package ws
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	domainErrors "lowercode-go-server/domain/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 刷盘协程池单元测试 ==========
+
+// newFlushTestRoom 创建房间并应用一次编辑，使其有未保存的版本
+func newFlushTestRoom(t *testing.T, hub *Hub, pageID string) *Room {
+	t.Helper()
+	room, err := hub.GetOrCreateRoom(pageID)
+	require.NoError(t, err)
+	require.NoError(t, room.ApplyPatch(renamePatch("Home"), room.Version))
+	return room
+}
+
+// persistedVersion 返回房间最近一次刷盘的版本
+func persistedVersion(room *Room) int64 {
+	room.stateMu.RLock()
+	defer room.stateMu.RUnlock()
+	return room.lastPersistedVersion
+}
+
+var fastRetry = FlushWorkerConfig{Workers: 2, MaxRetries: 3, RetryBase: 5 * time.Millisecond, RetryMax: 20 * time.Millisecond}
+
+func TestFlushScheduler_Coalesce(t *testing.T) {
+	// 测试场景：写入期间同一房间的多次触发合并为写入完成后的一次刷盘
+
+	release := make(chan struct{})
+	var calls atomic.Int32
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", "page-1").Return([]byte(hibernateTestState), int64(1), nil)
+	mockService.On("SavePageState", "page-1", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) {
+			if calls.Add(1) == 1 {
+				<-release
+			}
+		}).Return(nil)
+	hub := NewHub(mockService, WithFlushWorkers(FlushWorkerConfig{Workers: 1}))
+
+	room := newFlushTestRoom(t, hub, "page-1")
+	hub.flusher.schedule(room, "测试")
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, room.ApplyPatch(renamePatch("About"), 2))
+	for i := 0; i < 5; i++ {
+		hub.flusher.schedule(room, "测试")
+	}
+	close(release)
+
+	assert.Eventually(t, func() bool { return persistedVersion(room) == 3 }, time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(2), calls.Load(), "写入期间的触发应合并为一次")
+}
+
+func TestFlushScheduler_BoundsWriters(t *testing.T) {
+	// 测试场景：同时写数据库的房间数不超过 Workers，所有房间最终都刷盘
+
+	var active, peak atomic.Int32
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", mock.Anything).Return([]byte(hibernateTestState), int64(1), nil)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) {
+			n := active.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			active.Add(-1)
+		}).Return(nil)
+	hub := NewHub(mockService, WithFlushWorkers(FlushWorkerConfig{Workers: 2, DequeueSize: 2}))
+
+	rooms := make([]*Room, 6)
+	for i := range rooms {
+		rooms[i] = newFlushTestRoom(t, hub, fmt.Sprintf("page-%d", i))
+	}
+	for _, room := range rooms {
+		hub.flusher.schedule(room, "测试")
+	}
+
+	assert.Eventually(t, func() bool {
+		for _, room := range rooms {
+			if persistedVersion(room) != 2 {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+	assert.Equal(t, 2, hub.Limits().FlushWorkers)
+}
+
+func TestFlushScheduler_Retry(t *testing.T) {
+	// 测试场景：刷盘失败后按退避时间重试，数据库恢复后写入成功
+
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", "page-1").Return([]byte(hibernateTestState), int64(1), nil)
+	mockService.On("SavePageState", "page-1", mock.Anything, int64(1), int64(2)).Return(errors.New("db down")).Twice()
+	mockService.On("SavePageState", "page-1", mock.Anything, int64(1), int64(2)).Return(nil).Once()
+	hub := NewHub(mockService, WithFlushWorkers(fastRetry))
+
+	room := newFlushTestRoom(t, hub, "page-1")
+	hub.flusher.schedule(room, "测试")
+
+	assert.Eventually(t, func() bool { return persistedVersion(room) == 2 }, time.Second, 5*time.Millisecond)
+	mockService.AssertNumberOfCalls(t, "SavePageState", 3)
+}
+
+func TestFlushScheduler_NoRetryOnConflict(t *testing.T) {
+	// 测试场景：乐观锁冲突不重试；重试用尽后放弃，下一次触发重新开始
+
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", mock.Anything).Return([]byte(hibernateTestState), int64(1), nil)
+	mockService.On("SavePageState", "page-1", mock.Anything, mock.Anything, mock.Anything).Return(domainErrors.ErrOptimisticLock)
+	mockService.On("SavePageState", "page-2", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("db down"))
+	hub := NewHub(mockService, WithFlushWorkers(fastRetry))
+
+	conflicted := newFlushTestRoom(t, hub, "page-1")
+	failing := newFlushTestRoom(t, hub, "page-2")
+	hub.flusher.schedule(conflicted, "测试")
+	hub.flusher.schedule(failing, "测试")
+
+	idle := func() bool {
+		hub.flusher.mu.Lock()
+		defer hub.flusher.mu.Unlock()
+		return len(hub.flusher.jobs) == 0
+	}
+	require.Eventually(t, idle, time.Second, 5*time.Millisecond)
+	calls := func(pageID string) int {
+		n := 0
+		for _, call := range mockService.Calls {
+			if call.Method == "SavePageState" && call.Arguments.String(0) == pageID {
+				n++
+			}
+		}
+		return n
+	}
+	assert.Equal(t, 1, calls("page-1"))
+	assert.Equal(t, 1+fastRetry.MaxRetries, calls("page-2"))
+	assert.Equal(t, int64(1), persistedVersion(failing), "未写入的状态仍保留在内存中")
+}
+
+func TestFlushScheduler_NoRetryAfterStop(t *testing.T) {
+	// 测试场景：等待重试期间房间停止，销毁前的最后一次刷盘写入后，到期的重试不再排队，房间从协程池中移除
+
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", "page-1").Return([]byte(hibernateTestState), int64(1), nil)
+	mockService.On("SavePageState", "page-1", mock.Anything, int64(1), int64(2)).Return(errors.New("db down")).Once()
+	mockService.On("SavePageState", "page-1", mock.Anything, int64(1), int64(2)).Return(nil).Once()
+	hub := NewHub(mockService, WithFlushWorkers(FlushWorkerConfig{
+		Workers: 1, MaxRetries: 3, RetryBase: 50 * time.Millisecond, RetryMax: 50 * time.Millisecond,
+	}))
+
+	room := newFlushTestRoom(t, hub, "page-1")
+	hub.flusher.schedule(room, "测试")
+	waitingRetry := func() bool {
+		hub.flusher.mu.Lock()
+		defer hub.flusher.mu.Unlock()
+		job, ok := hub.flusher.jobs[room]
+		return ok && job.attempt == 1 && !job.running
+	}
+	require.Eventually(t, waitingRetry, time.Second, time.Millisecond)
+	dequeues := counterValue(flushMetrics, "dequeues")
+
+	room.Stop()
+	assert.Equal(t, int64(2), persistedVersion(room))
+
+	assert.Eventually(t, func() bool {
+		hub.flusher.mu.Lock()
+		defer hub.flusher.mu.Unlock()
+		return len(hub.flusher.jobs) == 0
+	}, time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, dequeues, counterValue(flushMetrics, "dequeues"), "已停止的房间不应重新排队")
+	mockService.AssertExpectations(t)
+}
+
+func TestRoom_FlushFinal_Retries(t *testing.T) {
+	// 测试场景：销毁前的最后一次刷盘失败时同步重试，成功后才销毁
+
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", "page-1").Return([]byte(hibernateTestState), int64(1), nil)
+	mockService.On("SavePageState", "page-1", mock.Anything, int64(1), int64(2)).Return(errors.New("db down")).Once()
+	mockService.On("SavePageState", "page-1", mock.Anything, int64(1), int64(2)).Return(nil).Once()
+	hub := NewHub(mockService, WithFlushWorkers(fastRetry))
+
+	room := newFlushTestRoom(t, hub, "page-1")
+	room.Stop()
+
+	assert.Equal(t, int64(2), persistedVersion(room))
+	mockService.AssertExpectations(t)
+}
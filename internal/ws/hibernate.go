@@ -0,0 +1,110 @@
+package ws
+
+import (
+	"expvar"
+	"time"
+
+	"lowercode-go-server/internal/logging"
+)
+
+// DefaultHibernationTTL 休眠房间状态的默认保留时间
+const DefaultHibernationTTL = 10 * time.Minute
+
+// MaxHibernatedBytes 单个房间休眠状态的上限，超出时不休眠，下次加入从数据库加载
+const MaxHibernatedBytes = 4 << 20
+
+// hibernationMetrics 房间休眠计数，通过 expvar 暴露：
+// hibernated 为写入休眠状态的房间，rehydrated 为从休眠状态恢复的房间，misses 为没有休眠状态、从数据库加载的次数，
+// skipped 为未刷盘成功或超出 MaxHibernatedBytes 而未休眠的房间，errors 为存储读写失败次数
+var hibernationMetrics = expvar.NewMap("ws_hibernation")
+
+// HibernationStore 保存空闲房间的状态（如 Redis），房间再次被加入时直接恢复，无需读取数据库。
+// 状态只在刷盘成功后写入，与数据库中的版本一致
+type HibernationStore interface {
+	// Save 保存页面状态，ttl 后过期；已保存更高版本时不覆盖
+	Save(pageID string, state []byte, version int64, ttl time.Duration) error
+
+	// Take 取出并删除页面的休眠状态，没有时 ok 为 false。
+	// 取出即删除，同一份状态只会恢复一个房间，房间存活期间不会留下过期的副本
+	Take(pageID string) (state []byte, version int64, ok bool, err error)
+
+	// Discard 删除页面的休眠状态，页面不存在时不做任何事
+	Discard(pageID string) error
+}
+
+// hibernation 房间休眠配置
+type hibernation struct {
+	store HibernationStore
+	ttl   time.Duration
+}
+
+// WithHibernation 启用房间休眠：空闲房间销毁（或停机）时，刷盘成功后把状态和版本写入 store，保留 ttl；
+// 下次加入页面时直接从 store 恢复，省去数据库读取和差量回放。ttl <= 0 时使用 DefaultHibernationTTL。
+// 被强制关闭（页面删除、所有者变更等）的房间不休眠，并删除已有的休眠状态
+func WithHibernation(store HibernationStore, ttl time.Duration) HubOption {
+	return func(h *Hub) {
+		if ttl <= 0 {
+			ttl = DefaultHibernationTTL
+		}
+		h.hibernation = &hibernation{store: store, ttl: ttl}
+	}
+}
+
+// loadRoomState 加载房间的初始状态：优先取出休眠状态，没有或读取失败时从数据库加载。
+// rehydrated 表示来自休眠状态，调用方需持有房间所在分片的锁
+func (h *Hub) loadRoomState(roomID string) (state []byte, version int64, rehydrated bool, err error) {
+	if h.hibernation != nil {
+		state, version, ok, err := h.hibernation.store.Take(roomID)
+		switch {
+		case err != nil:
+			hibernationMetrics.Add("errors", 1)
+			logging.Warnf("[Hub] 读取页面 %s 的休眠状态失败，从数据库加载: %v", roomID, err)
+		case ok:
+			hibernationMetrics.Add("rehydrated", 1)
+			return state, version, true, nil
+		default:
+			hibernationMetrics.Add("misses", 1)
+		}
+	}
+	state, version, err = h.pageService.GetPageState(roomID)
+	return state, version, false, err
+}
+
+// discardHibernated 删除页面的休眠状态，用于强制关闭房间的场景（如页面删除），
+// 避免之后从休眠状态恢复出已删除或已过时的页面
+func (h *Hub) discardHibernated(roomID string) {
+	if h.hibernation == nil {
+		return
+	}
+	if err := h.hibernation.store.Discard(roomID); err != nil {
+		hibernationMetrics.Add("errors", 1)
+		logging.Errorf("[Hub] 删除页面 %s 的休眠状态失败，最多 %s 后过期: %v", roomID, h.hibernation.ttl, err)
+	}
+}
+
+// hibernate 销毁前的最后一次刷盘之后写入休眠状态，仅在 run() 退出时调用。
+// 被强制关闭的房间不休眠；未能刷盘时删除已有的休眠状态，下次加入从数据库加载
+func (r *Room) hibernate() {
+	if r.hub == nil || r.hub.hibernation == nil || r.stopReason != "" {
+		return
+	}
+
+	r.stateMu.RLock()
+	persisted := r.Version == r.lastPersistedVersion
+	state := make([]byte, len(r.CurrentState))
+	copy(state, r.CurrentState)
+	version := r.Version
+	r.stateMu.RUnlock()
+
+	if !persisted || len(state) > MaxHibernatedBytes {
+		hibernationMetrics.Add("skipped", 1)
+		r.hub.discardHibernated(r.ID)
+		return
+	}
+	if err := r.hub.hibernation.store.Save(r.ID, state, version, r.hub.hibernation.ttl); err != nil {
+		hibernationMetrics.Add("errors", 1)
+		logging.Warnf("[Room %s] 写入休眠状态失败，下次加入从数据库加载: %v", r.ID, err)
+		return
+	}
+	hibernationMetrics.Add("hibernated", 1)
+}
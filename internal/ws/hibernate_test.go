@@ -0,0 +1,125 @@
+package ws
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 房间休眠单元测试 ==========
+
+const hibernateTestState = `{"rootId": 1, "components": {"1": {"id": 1, "name": "Page"}}}`
+
+// renamePatch 把根组件改名为 name
+func renamePatch(name string) []byte {
+	return []byte(`[{"op": "replace", "path": "/components/1/name", "value": "` + name + `"}]`)
+}
+
+func TestHub_Hibernation_Rehydrate(t *testing.T) {
+	// 测试场景：空闲房间销毁时把刷盘后的状态写入存储；再次加入时直接恢复，不读取数据库，
+	// 恢复后休眠状态被取出；功能开关中可见
+
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", "page-1").Return([]byte(hibernateTestState), int64(1), nil).Once()
+	mockService.On("SavePageState", "page-1", mock.Anything, int64(1), int64(2)).Return(nil).Once()
+	store := NewMockHibernationStore()
+	hub := NewHub(mockService, WithHibernation(store, time.Minute))
+	assert.True(t, hub.Features().Hibernation)
+
+	room, err := hub.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	require.NoError(t, room.ApplyPatch(renamePatch("Home"), 1))
+	hub.handleIdleRoom(room)
+	require.Nil(t, hub.GetRoom("page-1"))
+
+	state, version, ok := store.Entry("page-1")
+	require.True(t, ok)
+	assert.Equal(t, int64(2), version)
+	assert.Contains(t, state, `"name":"Home"`)
+
+	rehydrated, err := hub.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	defer rehydrated.Stop()
+	snapshot, version := rehydrated.GetSnapshot()
+	assert.Equal(t, int64(2), version)
+	assert.Contains(t, string(snapshot), `"name":"Home"`)
+	mockService.AssertNumberOfCalls(t, "GetPageState", 1)
+
+	_, _, ok = store.Entry("page-1")
+	assert.False(t, ok, "恢复后应取出休眠状态")
+
+	// 恢复的房间从休眠版本继续刷盘
+	mockService.On("SavePageState", "page-1", mock.Anything, int64(2), int64(3)).Return(nil).Once()
+	require.NoError(t, rehydrated.ApplyPatch(renamePatch("About"), 2))
+	rehydrated.Stop()
+	mockService.AssertExpectations(t)
+}
+
+func TestHub_Hibernation_ForcedClose(t *testing.T) {
+	// 测试场景：被强制关闭（页面删除）的房间不休眠；房间不在内存中时也删除已有的休眠状态
+
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", mock.Anything).Return([]byte(hibernateTestState), int64(1), nil)
+	store := NewMockHibernationStore()
+	hub := NewHub(mockService, WithHibernation(store, time.Minute))
+
+	room, err := hub.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	hub.CloseRoom("page-1")
+	<-room.doneChan
+	_, _, ok := store.Entry("page-1")
+	assert.False(t, ok)
+
+	require.NoError(t, store.Save("page-2", []byte(hibernateTestState), 5, time.Minute))
+	hub.CloseRoom("page-2")
+	_, _, ok = store.Entry("page-2")
+	assert.False(t, ok)
+
+	require.NoError(t, store.Save("page-3", []byte(hibernateTestState), 5, time.Minute))
+	hub.CloseRoomWithReason("page-3", ErrRoomMoved, "页面已转由其他节点承载")
+	_, _, ok = store.Entry("page-3")
+	assert.True(t, ok, "页面转由其他节点承载时保留休眠状态")
+}
+
+func TestHub_Hibernation_FlushFailed(t *testing.T) {
+	// 测试场景：销毁前刷盘失败时不休眠，并删除已有的休眠状态，下次加入从数据库加载
+
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", "page-1").Return([]byte(hibernateTestState), int64(1), nil)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("db down"))
+	store := NewMockHibernationStore()
+	hub := NewHub(mockService, WithHibernation(store, time.Minute))
+
+	room, err := hub.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	require.NoError(t, room.ApplyPatch(renamePatch("Home"), 1))
+	require.NoError(t, store.Save("page-1", []byte(hibernateTestState), 1, time.Minute))
+	hub.handleIdleRoom(room)
+
+	_, _, ok := store.Entry("page-1")
+	assert.False(t, ok)
+}
+
+func TestHub_Hibernation_StoreUnavailable(t *testing.T) {
+	// 测试场景：存储不可用时从数据库加载房间，销毁时写入失败不影响刷盘
+
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", "page-1").Return([]byte(hibernateTestState), int64(1), nil)
+	mockService.On("SavePageState", "page-1", mock.Anything, int64(1), int64(2)).Return(nil).Once()
+	store := NewMockHibernationStore()
+	store.SetFailing(true)
+	hub := NewHub(mockService, WithHibernation(store, time.Minute))
+
+	room, err := hub.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), room.Version)
+	require.NoError(t, room.ApplyPatch(renamePatch("Home"), 1))
+	hub.handleIdleRoom(room)
+
+	mockService.AssertExpectations(t)
+	assert.Nil(t, hub.GetRoom("page-1"))
+}
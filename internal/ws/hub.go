@@ -6,17 +6,81 @@ import (
 	"errors"
 	"log"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/logging"
 )
 
 // Hub 负责管理所有协同编辑房间的生命周期。
 // 作为中央协调者，Hub 只处理房间的创建和销毁，不参与业务消息处理。
+// 房间按 ID 哈希分布到多个分片（见 shard.go），每个分片有独立的锁和空闲房间通道
 type Hub struct {
-	rooms       map[string]*Room
-	mu          sync.RWMutex
-	idleRoom    chan *Room // 空闲房间信号通道，用于接收销毁请求
+	shards      []*hubShard
 	pageService PageService
+	opLog       *OpLogWriter        // 可选，操作日志写入器
+	events      EventSink           // 可选，生命周期事件接收方
+	deltas      *deltaPolicy        // 可选，差量持久化配置
+	chat        ChatStore           // 可选，聊天持久化
+	settings    SettingsStore       // 可选，页面级协同设置
+	backups     ConflictBackupStore // 可选，冲突备份
+
+	archive  *ArchiveWriter  // 可选，房间销毁时归档到对象存储
+	activity *ActivityWriter // 可选，记录用户最近打开、编辑的页面
+
+	references *ReferenceIndexer // 可选，刷盘后重建页面之间的引用索引
+
+	catchUpOps OpReader // 可选，重连追赶时读取内存窗口之外的操作日志
+
+	connConfig *ConnConfig // 可选，连接心跳与限制，为 nil 时使用 DefaultConnConfig
+	maxClients int         // 单个房间的连接数上限，为 0 时不限制
+
+	rateLimit *RateLimitConfig // 可选，入站消息限流，为 nil 时使用 DefaultRateLimit
+
+	flushPolicy *FlushPolicy // 可选，自适应刷盘的边界，为 nil 时使用 DefaultFlushPolicy
+
+	watchdog *Watchdog // 可选，过载时拒绝创建房间并对感知类广播降频
+
+	reproWindow int // 复现日志保留的最少操作数，为 0 时不记录，见 repro.go
+
+	draining atomic.Bool // 优雅停机中，不再创建房间，见 Shutdown
+	drain    drainState  // 停机前的排空与维护模式，见 Drain、SetMaintenance
+
+	noticesMu sync.Mutex
+	notices   []SystemNoticePayload // 尚未过期的系统公告，新加入房间的用户也会收到，受 noticesMu 保护
+
+	bridge RoomBridge // 可选，跨节点转发房间消息，见 bridge.go
+	nodeID string     // 本节点在桥接消息中的 ID
+
+	ownership *ownership // 可选，同一页面只由一个节点承载房间，见 ownership.go
+
+	ai *aiStreaming // 可选，连接内的流式 AI 生成，见 ai.go
+
+	moderator ContentModerator // 可选，审核聊天和组件属性文本，见 moderation.go
+
+	hibernation *hibernation // 可选，空闲房间的状态保存到外部存储，再次加入时直接恢复，见 hibernate.go
+
+	flusher *flushScheduler // 所有房间共用的刷盘协程池，见 flush_workers.go
+
+	latencyTracking bool // 记录编辑链路各阶段的耗时并在 ack 中带回，见 latency.go
+}
+
+// HubOption Hub 可选配置
+type HubOption func(*Hub)
+
+// WithOpLog 启用操作日志，房间内每个已应用的 Patch 都会异步写入
+func WithOpLog(w *OpLogWriter) HubOption {
+	return func(h *Hub) {
+		h.opLog = w
+	}
+}
+
+// WithEventSink 启用房间生命周期事件（创建、刷盘、销毁）
+func WithEventSink(sink EventSink) HubOption {
+	return func(h *Hub) {
+		h.events = sink
+	}
 }
 
 // PageService 定义数据库操作接口。
@@ -35,23 +99,42 @@ type PageService interface {
 }
 
 // NewHub 创建并返回 Hub 实例。
-func NewHub(pageService PageService) *Hub {
-	return &Hub{
-		rooms:       make(map[string]*Room),
-		idleRoom:    make(chan *Room, 16),
-		pageService: pageService,
+func NewHub(pageService PageService, opts ...HubOption) *Hub {
+	h := &Hub{pageService: pageService}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.shards == nil {
+		WithShards(DefaultHubShards)(h)
 	}
+	if h.flusher == nil {
+		WithFlushWorkers(DefaultFlushWorkers)(h)
+	}
+	return h
 }
 
 // Run 启动 Hub 事件循环。
 // 该方法应在独立 goroutine 中调用，会阻塞直到 Hub 停止。
 func (h *Hub) Run() {
 	log.Println("[Hub] 已启动")
+	if h.ownership != nil {
+		log.Printf("[Hub] 房间租约已启用：本节点 %s，有效期 %s", h.ownership.self, h.ownership.ttl)
+		go h.renewOwnership()
+	}
 
-	for room := range h.idleRoom {
-		// 在独立 goroutine 中处理空闲房间，避免阻塞事件循环
-		go h.handleIdleRoom(room)
+	// 每个分片一个协程处理空闲房间，房间很多时销毁请求不会在同一个通道上排队
+	var wg sync.WaitGroup
+	for _, shard := range h.shards {
+		wg.Add(1)
+		go func(shard *hubShard) {
+			defer wg.Done()
+			for room := range shard.idle {
+				// 在独立 goroutine 中处理空闲房间，避免阻塞事件循环
+				go h.handleIdleRoom(room)
+			}
+		}(shard)
 	}
+	wg.Wait()
 }
 
 // handleIdleRoom 处理空闲房间的销毁请求。
@@ -67,11 +150,7 @@ func (h *Hub) handleIdleRoom(room *Room) {
 	room.Stop()
 
 	// 安全删除：检查指针同一性，防止误删新创建的同名房间
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	if currentRoom, ok := h.rooms[room.ID]; ok && currentRoom == room {
-		delete(h.rooms, room.ID)
+	if h.removeRoom(room) {
 		log.Printf("[Hub] 房间 %s 已销毁", room.ID)
 	} else {
 		log.Printf("[Hub] 房间 %s 已被替换或移除，跳过删除", room.ID)
@@ -83,16 +162,40 @@ func (h *Hub) handleIdleRoom(room *Room) {
 //
 // 即使房间正在停止，其数据仍然有效（受 stateMu 保护），故仍返回。
 func (h *Hub) GetRoom(roomID string) *Room {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	shard := h.shardFor(roomID)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
 
-	room, exists := h.rooms[roomID]
+	room, exists := shard.rooms[roomID]
 	if exists {
 		return room
 	}
 	return nil
 }
 
+// RoomState 房间版本状态，供一致性巡检使用
+type RoomState struct {
+	PageID           string `json:"pageId"`
+	Version          int64  `json:"version"`
+	PersistedVersion int64  `json:"persistedVersion"`
+}
+
+// RoomStates 返回当前所有房间的版本状态快照
+func (h *Hub) RoomStates() []RoomState {
+	rooms := h.allRooms()
+	states := make([]RoomState, 0, len(rooms))
+	for _, room := range rooms {
+		room.stateMu.RLock()
+		states = append(states, RoomState{
+			PageID:           room.ID,
+			Version:          room.Version,
+			PersistedVersion: room.lastPersistedVersion,
+		})
+		room.stateMu.RUnlock()
+	}
+	return states
+}
+
 // GetOrCreateRoom 获取或创建房间。
 // 只有数据库中存在的页面才会创建对应房间（Pre-creation 模式）。
 //
@@ -100,11 +203,21 @@ func (h *Hub) GetRoom(roomID string) *Room {
 //   - 成功时返回 Room 指针
 //   - 页面不存在时返回 ErrPageNotFound
 //   - 房间正在关闭时返回 ErrRoomClosing
+//   - 启用房间租约且页面由其他节点承载时返回 *RoomOwnedError（errors.Is ErrRoomOwnedElsewhere）
+//   - 服务优雅停机中返回 ErrServerShuttingDown
+//   - 服务过载时返回 ErrServerOverloaded，已有房间不受影响
 func (h *Hub) GetOrCreateRoom(roomID string) (*Room, error) {
+	shard := h.shardFor(roomID)
+
 	// 快速路径：读锁
-	h.mu.RLock()
-	room, exists := h.rooms[roomID]
-	h.mu.RUnlock()
+	shard.mu.RLock()
+	room, exists := shard.rooms[roomID]
+	draining := h.checkDraining()
+	shard.mu.RUnlock()
+
+	if draining != nil {
+		return nil, draining
+	}
 
 	if exists {
 		if room.IsStopping() {
@@ -114,12 +227,15 @@ func (h *Hub) GetOrCreateRoom(roomID string) (*Room, error) {
 		return room, nil
 	}
 
-	// 慢速路径：写锁创建
-	h.mu.Lock()
-	defer h.mu.Unlock()
+	// 慢速路径：分片写锁创建，只阻塞同一分片内的房间
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
 	// 获取写锁后再次检查
-	room, exists = h.rooms[roomID]
+	if err := h.checkDraining(); err != nil {
+		return nil, err
+	}
+	room, exists = shard.rooms[roomID]
 	if exists {
 		if room.IsStopping() {
 			log.Printf("[Hub] 房间 %s 正在关闭，请客户端重试", roomID)
@@ -128,49 +244,105 @@ func (h *Hub) GetOrCreateRoom(roomID string) (*Room, error) {
 		return room, nil
 	}
 
-	// 从数据库加载状态
-	state, version, err := h.pageService.GetPageState(roomID)
+	// 过载时不再创建房间，加载页面和新的事件循环都会进一步占用内存
+	if h.watchdog.Overloaded() {
+		watchdogMetrics.Add("roomsRejected", 1)
+		log.Printf("[Hub] 服务过载，拒绝创建房间 %s", roomID)
+		return nil, domainErrors.ErrServerOverloaded
+	}
+
+	// 多节点部署时先取得页面的租约，页面已由其他节点承载时不在本节点创建房间
+	if err := h.acquireOwnership(roomID); err != nil {
+		return nil, err
+	}
+
+	// 加载状态：优先从休眠状态恢复，否则读取数据库
+	state, version, rehydrated, err := h.loadRoomState(roomID)
 	if err != nil {
+		h.releaseOwnership(roomID)
 		if errors.Is(err, domainErrors.ErrPageNotFound) {
 			log.Printf("[Hub] 页面 %s 不存在，拒绝创建房间", roomID)
 			return nil, domainErrors.ErrPageNotFound
 		}
-		log.Printf("[Hub] 加载页面 %s 失败: %v", roomID, err)
+		logging.Errorf("[Hub] 加载页面 %s 失败: %v", roomID, err)
 		return nil, err
 	}
 
+	// 曾被应用但未能刷盘的版本留下的操作日志不属于页面历史，房间将以相同版本号重新产生
+	if h.opLog != nil {
+		h.opLog.TruncateAfter(roomID, version)
+	}
+
 	// 创建并注册房间
 	room = NewRoom(roomID, state, h.pageService, h)
 	room.Version = version
 	room.lastPersistedVersion = version
-	h.rooms[roomID] = room
+	room.loadedVersion = version
+	room.leaseRenewed.Store(time.Now().UnixNano())
+	room.joinBridge()
+	if h.reproWindow > 0 {
+		room.repro = newReproJournal(h.reproWindow, state, version)
+	}
+	shard.rooms[roomID] = room
 
-	log.Printf("[Hub] 创建房间 %s，版本: %d", roomID, version)
+	room.emit(LifecycleEvent{
+		Type:             EventRoomCreated,
+		Version:          version,
+		PersistedVersion: version,
+	})
+
+	if rehydrated {
+		log.Printf("[Hub] 创建房间 %s（从休眠状态恢复），版本: %d", roomID, version)
+	} else {
+		log.Printf("[Hub] 创建房间 %s，版本: %d", roomID, version)
+	}
 	return room, nil
 }
 
 // NotifyIdle 由 Room 调用，通知 Hub 该房间已空闲。
 func (h *Hub) NotifyIdle(room *Room) {
-	h.idleRoom <- room
+	h.shardFor(room.ID).idle <- room
+}
+
+// ReleaseIfIdle 房间内没有客户端时通知 Hub 销毁，
+// 用于 HTTP 接口为修改页面而通过 GetOrCreateRoom 临时创建的房间
+func (h *Hub) ReleaseIfIdle(room *Room) {
+	if room.ClientCount() == 0 && !room.IsStopping() {
+		h.NotifyIdle(room)
+	}
 }
 
 // CloseRoom 强制关闭房间，用于页面删除场景。
 // 执行"先关房间后删数据"的安全删除流程。
 func (h *Hub) CloseRoom(roomID string) {
-	h.mu.Lock()
-	room, exists := h.rooms[roomID]
+	h.CloseRoomWithReason(roomID, ErrPageDeleted, "页面已被删除")
+}
+
+// CloseRoomWithReason 强制关闭房间，先向所有客户端发送 code 错误并刷盘。
+// 客户端是否重连由 code 决定（如 PAGE_DELETED 不应重连）
+func (h *Hub) CloseRoomWithReason(roomID string, code ErrorCode, message string) {
+	shard := h.shardFor(roomID)
+	shard.mu.Lock()
+	room, exists := shard.rooms[roomID]
 	if !exists {
-		h.mu.Unlock()
+		shard.mu.Unlock()
+		// 页面已转由其他节点承载时，休眠状态属于该节点，不删除
+		if code != ErrRoomMoved {
+			h.discardHibernated(roomID)
+		}
 		log.Printf("[Hub] 房间 %s 不存在于内存中，无需关闭", roomID)
 		return
 	}
 
 	// 先从 map 移除，防止新客户端加入
-	delete(h.rooms, roomID)
-	h.mu.Unlock()
+	delete(shard.rooms, roomID)
+	shard.mu.Unlock()
 
-	// 停止房间并刷盘（阻塞调用）
-	room.StopWithReason(ErrPageDeleted, "页面已被删除")
+	// 停止房间并刷盘（阻塞调用），被强制关闭的房间不休眠
+	room.StopWithReason(code, message)
+	if code != ErrRoomMoved {
+		h.discardHibernated(roomID)
+	}
 
-	log.Printf("[Hub] 强制关闭房间 %s（页面被删除）", roomID)
+	log.Printf("[Hub] 强制关闭房间 %s（%s）", roomID, message)
 }
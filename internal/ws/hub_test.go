@@ -1,8 +1,10 @@
 package ws
 
 import (
+	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	domainErrors "lowercode-go-server/domain/errors"
 
@@ -144,3 +146,70 @@ func TestHub_GetRoom_ExistingRoom(t *testing.T) {
 	assert.NotNil(t, gotRoom)
 	assert.Same(t, createdRoom, gotRoom)
 }
+
+func TestHub_LimitsAndFeatures(t *testing.T) {
+	// 测试场景：Limits / Features 反映通过 HubOption 启用的配置
+
+	plain := NewHub(new(MockPageService))
+	assert.Equal(t, HubFeatures{}, plain.Features())
+	assert.Equal(t, 1, plain.Limits().SnapshotEveryFlushes)
+	assert.Equal(t, DefaultFlushPolicy.MinThreshold, plain.Limits().FlushMinThreshold)
+
+	hub := NewHub(new(MockPageService),
+		WithEventSink(&MockEventSink{}),
+		WithDeltaPersistence(new(MockDeltaStore), 10),
+		WithChatStore(&MockChatStore{}),
+	)
+	assert.Equal(t, HubFeatures{LifecycleEvents: true, DeltaPersistence: true, ChatPersistence: true}, hub.Features())
+	assert.Equal(t, 10, hub.Limits().SnapshotEveryFlushes)
+}
+
+func TestHub_Shards(t *testing.T) {
+	// 测试场景：房间按 ID 分布到多个分片，同一 ID 总是落在同一分片；
+	// GetRoom、RoomStates 跨分片可见，Limits 返回分片数
+
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", mock.Anything).Return([]byte(`{"rootId": 1, "components": {}}`), int64(1), nil)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	hub := NewHub(mockService, WithShards(8))
+	assert.Equal(t, 8, hub.Limits().Shards)
+	assert.Equal(t, DefaultHubShards, NewHub(mockService).Limits().Shards)
+
+	used := make(map[*hubShard]bool)
+	for i := 0; i < 64; i++ {
+		roomID := fmt.Sprintf("page-%d", i)
+		room, err := hub.GetOrCreateRoom(roomID)
+		assert.NoError(t, err)
+		assert.Same(t, hub.shardFor(roomID), hub.shardFor(roomID))
+		assert.Same(t, room, hub.GetRoom(roomID))
+		used[hub.shardFor(roomID)] = true
+	}
+	assert.Greater(t, len(used), 1)
+	assert.Len(t, hub.RoomStates(), 64)
+}
+
+func TestHub_Shards_IdleRoom(t *testing.T) {
+	// 测试场景：空闲房间经所在分片的通道销毁，不影响其他分片的房间
+
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", mock.Anything).Return([]byte(`{"rootId": 1, "components": {}}`), int64(1), nil)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	hub := NewHub(mockService, WithShards(4))
+	go hub.Run()
+
+	idle, err := hub.GetOrCreateRoom("idle-room")
+	assert.NoError(t, err)
+	var other *Room
+	for i := 0; other == nil; i++ {
+		roomID := fmt.Sprintf("other-%d", i)
+		if hub.shardFor(roomID) != hub.shardFor(idle.ID) {
+			other, err = hub.GetOrCreateRoom(roomID)
+			assert.NoError(t, err)
+		}
+	}
+
+	hub.NotifyIdle(idle)
+	assert.Eventually(t, func() bool { return hub.GetRoom(idle.ID) == nil }, time.Second, 10*time.Millisecond)
+	assert.Same(t, other, hub.GetRoom(other.ID))
+	assert.Len(t, hub.RoomStates(), 1)
+}
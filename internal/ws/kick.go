@@ -0,0 +1,67 @@
+package ws
+
+import "log"
+
+// kickOp 移出连接请求，match 选出要关闭的连接，reply 返回被关闭的连接数
+type kickOp struct {
+	match  func(UserInfo) bool
+	code   ErrorCode
+	reason string
+	target string // 日志中被移出的对象
+	reply  chan int
+}
+
+// handleKickOp 关闭 match 选中的全部连接，仅在 run() 内调用。
+// 被移出的客户端先收到 op.code 错误，随后发送通道关闭、连接断开；
+// 其持有的组件锁立即释放，其他用户收到 user-leave。
+func (r *Room) handleKickOp(op *kickOp) {
+	data := encodeServerMessage(TypeError, ErrorPayload{Code: op.code, Message: op.reason})
+
+	kicked := 0
+	for client := range r.clients {
+		if !op.match(client.UserInfo) {
+			continue
+		}
+		// 缓冲区满时放弃通知，连接仍会被关闭
+		select {
+		case client.send <- data:
+		default:
+		}
+		r.removeClient(client)
+		kicked++
+	}
+
+	if kicked > 0 {
+		log.Printf("[Room %s] %s被移出，关闭 %d 个连接，剩余人数: %d",
+			r.ID, op.target, kicked, len(r.clients))
+		r.notifyIdleIfEmpty()
+	}
+	op.reply <- kicked
+}
+
+// Kick 将 userID 的全部连接移出房间，返回被关闭的连接数；房间已停止时返回 0。
+// 被移出的用户仍可重新连接，权限检查由调用方负责。
+func (r *Room) Kick(userID, reason string) int {
+	return r.kick(&kickOp{
+		match:  func(u UserInfo) bool { return u.UserID == userID },
+		code:   ErrKicked,
+		reason: reason,
+		target: "用户 [" + userID + "] ",
+	})
+}
+
+// kick 提交移出请求并等待结果，房间已停止时返回 0
+func (r *Room) kick(op *kickOp) int {
+	op.reply = make(chan int, 1)
+	select {
+	case r.kickOps <- op:
+	case <-r.stopChan:
+		return 0
+	}
+	select {
+	case kicked := <-op.reply:
+		return kicked
+	case <-r.stopChan:
+		return 0
+	}
+}
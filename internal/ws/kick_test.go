@@ -0,0 +1,62 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ========== 移出用户单元测试 ==========
+
+func TestRoom_Kick(t *testing.T) {
+	// 测试场景：Alice 在两个标签页中连接并持有组件锁，被移出后
+	// 两个连接都先收到 KICKED 错误再被关闭；Bob 收到锁释放和 user-leave，仍留在房间
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	room := NewRoom("test-room", []byte(`{}`), mockService, nil)
+	defer room.Stop()
+
+	aliceInfo := UserInfo{UserID: "alice", UserName: "Alice"}
+	aliceTab1 := &Client{UserInfo: aliceInfo, send: make(chan []byte, 16)}
+	aliceTab2 := &Client{UserInfo: aliceInfo, send: make(chan []byte, 16)}
+	bob := &Client{UserInfo: UserInfo{UserID: "bob", UserName: "Bob"}, send: make(chan []byte, 16)}
+
+	for _, c := range []*Client{aliceTab1, aliceTab2, bob} {
+		assert.NoError(t, room.Register(c))
+	}
+	room.AcquireLock(aliceTab1, "c1")
+
+	assert.Equal(t, 2, room.Kick("alice", "bye"))
+	assert.Equal(t, []UserInfo{bob.UserInfo}, room.Users())
+
+	for _, c := range []*Client{aliceTab1, aliceTab2} {
+		var last WSMessage
+		for data := range c.send {
+			assert.NoError(t, json.Unmarshal(data, &last))
+		}
+		assert.Equal(t, TypeError, last.Type)
+		var payload ErrorPayload
+		assert.NoError(t, json.Unmarshal(last.Payload, &payload))
+		assert.Equal(t, ErrorPayload{Code: ErrKicked, Message: "bye"}, payload)
+	}
+
+	received := map[MessageType]int{}
+	timeout := time.After(time.Second)
+	for received[TypeUserLeave] < 2 {
+		select {
+		case data := <-bob.send:
+			var msg WSMessage
+			assert.NoError(t, json.Unmarshal(data, &msg))
+			received[msg.Type]++
+		case <-timeout:
+			t.Fatalf("bob 未收到 user-leave: %v", received)
+		}
+	}
+	assert.Equal(t, 1, received[TypeLockReleased])
+
+	assert.Equal(t, 0, room.Kick("alice", "bye"))
+}
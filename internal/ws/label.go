@@ -0,0 +1,32 @@
+package ws
+
+// MaxPatchLabelLength op-patch 的 label 最大长度
+const MaxPatchLabelLength = 32
+
+// 服务端生成的编辑使用的标签，客户端不能使用
+const (
+	LabelBulkOps    = "bulk-ops"    // HTTP 批量操作
+	LabelMerge      = "merge"       // 合并分支或合并请求
+	LabelSecret     = "secret-prop" // 设置或清除密钥属性
+	LabelAIGenerate = "ai-generate" // 服务端调用大语言模型生成的修改
+)
+
+// validPatchLabel label 是否为空，或由字母、数字和 - _ . : 组成、不超过 MaxPatchLabelLength 且不是服务端保留的标签
+func validPatchLabel(label string) bool {
+	if len(label) > MaxPatchLabelLength {
+		return false
+	}
+	switch label {
+	case LabelBulkOps, LabelMerge, LabelSecret, LabelAIGenerate:
+		return false
+	}
+	for _, ch := range label {
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case ch == '-' || ch == '_' || ch == '.' || ch == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
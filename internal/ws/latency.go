@@ -0,0 +1,196 @@
+package ws
+
+import (
+	"expvar"
+	"math"
+	"sync"
+	"time"
+)
+
+// 编辑链路的各个阶段，用于区分"编辑卡顿"是服务端排队、刷盘停滞还是网络造成的
+const (
+	LatencyQueue     = "queue"     // 收到 op-patch 帧到开始应用：解码、限流、内容审核
+	LatencyApply     = "apply"     // 应用 Patch，含等待房间状态锁
+	LatencyBroadcast = "broadcast" // 应用完成到放入其他人的发送缓冲区：房间事件循环的积压
+	LatencyDeliver   = "deliver"   // 应用完成到写入其他人的连接：发送缓冲区积压和网络写入
+	LatencyPersist   = "persist"   // 第一个未落盘的编辑到刷盘成功：刷盘排队、重试和数据库耗时
+)
+
+// LatencyBuckets 延迟直方图的桶上界（毫秒）。
+// LatencyStats.Buckets 与之一一对应，末尾多一项记录超过最大上界的次数。
+var LatencyBuckets = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 10000}
+
+// latencyMetrics 全部房间按阶段累计的延迟直方图，通过 expvar 暴露为 ws_latency
+var latencyMetrics = &latencyStats{}
+
+func init() {
+	expvar.Publish("ws_latency", expvar.Func(func() interface{} {
+		return latencyMetrics.snapshot()
+	}))
+}
+
+// WithLatencyTracking 记录编辑链路各阶段的耗时（见 LatencyQueue 等），并在 ack 中带回服务端耗时
+func WithLatencyTracking() HubOption {
+	return func(h *Hub) {
+		h.latencyTracking = true
+	}
+}
+
+// AckTiming op-patch 在服务端的耗时（毫秒），开启延迟追踪时随 ack 返回。
+// 客户端从发送到收到 ack 的总耗时减去两者即为网络往返时间
+type AckTiming struct {
+	QueueMs float64 `json:"queueMs"` // 收到消息到开始应用
+	ApplyMs float64 `json:"applyMs"` // 应用 Patch
+}
+
+// LatencyStats 单一阶段的累计延迟
+type LatencyStats struct {
+	Count   int64   `json:"count"`
+	TotalMs float64 `json:"totalMs"`
+	MaxMs   float64 `json:"maxMs"`
+	Buckets []int64 `json:"buckets"` // 延迟直方图，桶上界见 LatencyBuckets
+}
+
+// latencyStats 按阶段累计的延迟直方图，零值可用，并发安全
+type latencyStats struct {
+	mu     sync.Mutex
+	stages map[string]*LatencyStats
+}
+
+// observe 累计一次耗时，负值（跨节点时钟偏差）计为 0
+func (s *latencyStats) observe(stage string, d time.Duration) {
+	ms := max(float64(d)/float64(time.Millisecond), 0)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stages == nil {
+		s.stages = make(map[string]*LatencyStats)
+	}
+	st := s.stages[stage]
+	if st == nil {
+		st = &LatencyStats{Buckets: make([]int64, len(LatencyBuckets)+1)}
+		s.stages[stage] = st
+	}
+
+	st.Count++
+	st.TotalMs += ms
+	st.MaxMs = max(st.MaxMs, ms)
+	st.Buckets[latencyBucket(ms)]++
+}
+
+// snapshot 返回计数的拷贝：阶段 → 累计延迟
+func (s *latencyStats) snapshot() map[string]LatencyStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]LatencyStats, len(s.stages))
+	for stage, st := range s.stages {
+		copied := *st
+		copied.Buckets = append([]int64(nil), st.Buckets...)
+		out[stage] = copied
+	}
+	return out
+}
+
+// latencyBucket 返回 ms 所在直方图桶的下标
+func latencyBucket(ms float64) int {
+	for i, bound := range LatencyBuckets {
+		if ms <= bound {
+			return i
+		}
+	}
+	return len(LatencyBuckets)
+}
+
+// roundMs 把耗时换算为毫秒，保留两位小数
+func roundMs(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)/10) / 100
+}
+
+// latencyTracking 是否开启了延迟追踪
+func (c *Client) latencyTracking() bool {
+	return c.Hub != nil && c.Hub.latencyTracking
+}
+
+// latencyTracking 是否开启了延迟追踪
+func (r *Room) latencyTracking() bool {
+	return r.hub != nil && r.hub.latencyTracking
+}
+
+// observeApplied 记录一次 op-patch 的排队和应用耗时，返回随 ack 带回的耗时；未开启时返回 nil
+func (c *Client) observeApplied(receivedAt, applyStarted, applied time.Time) *AckTiming {
+	if !c.latencyTracking() || receivedAt.IsZero() {
+		return nil
+	}
+	latencyMetrics.observe(LatencyQueue, applyStarted.Sub(receivedAt))
+	latencyMetrics.observe(LatencyApply, applied.Sub(applyStarted))
+	return &AckTiming{
+		QueueMs: roundMs(applyStarted.Sub(receivedAt)),
+		ApplyMs: roundMs(applied.Sub(applyStarted)),
+	}
+}
+
+// trackApplied 在广播放入 c 的发送缓冲区前记下应用完成时间，WritePump 写入后据此记录送达耗时；
+// appliedAt 为零值或未开启追踪时不记录。时间随发送缓冲区中的消息单独保存，不从消息内容解析
+func (c *Client) trackApplied(message []byte, appliedAt time.Time) {
+	if appliedAt.IsZero() || len(message) == 0 || !c.latencyTracking() {
+		return
+	}
+	c.appliedMu.Lock()
+	defer c.appliedMu.Unlock()
+	if c.appliedAt == nil {
+		c.appliedAt = make(map[*byte]time.Time)
+	}
+	c.appliedAt[&message[0]] = appliedAt
+}
+
+// takeApplied 取出并移除 trackApplied 记下的时间，没有时返回 false
+func (c *Client) takeApplied(message []byte) (time.Time, bool) {
+	if len(message) == 0 {
+		return time.Time{}, false
+	}
+	c.appliedMu.Lock()
+	defer c.appliedMu.Unlock()
+	appliedAt, ok := c.appliedAt[&message[0]]
+	if ok {
+		delete(c.appliedAt, &message[0])
+	}
+	return appliedAt, ok
+}
+
+// observeDelivered 写入连接后记录广播从应用完成到送达的耗时，仅对 trackApplied 记下时间的消息记录
+func (c *Client) observeDelivered(message []byte) {
+	if appliedAt, ok := c.takeApplied(message); ok {
+		latencyMetrics.observe(LatencyDeliver, time.Since(appliedAt))
+	}
+}
+
+// observeBroadcast 广播放入发送缓冲区后记录从应用完成起的耗时，仅对带 AppliedAt 的广播记录
+func (r *Room) observeBroadcast(msg *RoomBroadcast) {
+	if msg.AppliedAt.IsZero() {
+		return
+	}
+	latencyMetrics.observe(LatencyBroadcast, time.Since(msg.AppliedAt))
+}
+
+// noteDirtyLocked 记录第一个未落盘编辑的时间，调用方需持有 stateMu
+func (r *Room) noteDirtyLocked() {
+	if r.dirtySince.IsZero() && r.latencyTracking() {
+		r.dirtySince = time.Now()
+	}
+}
+
+// observePersistedLocked 刷盘成功后记录未落盘编辑等待的时间，调用方需持有 stateMu。
+// started 为本次刷盘开始写入的时间，此前读取状态之后应用的编辑留到下次刷盘，从该时间起计
+func (r *Room) observePersistedLocked(started time.Time) {
+	if r.dirtySince.IsZero() {
+		return
+	}
+	latencyMetrics.observe(LatencyPersist, time.Since(r.dirtySince))
+	if r.Version == r.lastPersistedVersion {
+		r.dirtySince = time.Time{}
+	} else {
+		r.dirtySince = started
+	}
+}
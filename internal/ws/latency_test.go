@@ -0,0 +1,127 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 延迟追踪单元测试 ==========
+
+func TestLatencyStats_Observe(t *testing.T) {
+	// 测试场景：按阶段累计次数、总耗时和最大值，耗时落入对应直方图桶，负值计为 0
+
+	var s latencyStats
+	s.observe(LatencyApply, 500*time.Microsecond)
+	s.observe(LatencyApply, 30*time.Millisecond)
+	s.observe(LatencyApply, -time.Millisecond)
+	s.observe(LatencyPersist, time.Minute)
+
+	snapshot := s.snapshot()
+
+	apply := snapshot[LatencyApply]
+	assert.Equal(t, int64(3), apply.Count)
+	assert.InDelta(t, 30.5, apply.TotalMs, 0.001)
+	assert.InDelta(t, 30, apply.MaxMs, 0.001)
+	assert.Equal(t, []int64{2, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0}, apply.Buckets)
+	assert.Equal(t, int64(1), snapshot[LatencyPersist].Buckets[len(LatencyBuckets)])
+
+	// 快照是拷贝，之后的计数不影响已取出的结果
+	s.observe(LatencyApply, time.Millisecond)
+	assert.Equal(t, int64(3), snapshot[LatencyApply].Count)
+}
+
+func TestClient_OpPatch_AckTiming(t *testing.T) {
+	// 测试场景：开启延迟追踪时 ack 带回排队和应用耗时，广播带上应用完成时间；未开启时两者都没有
+
+	room := newTestRoom("test-room", []byte(`{"title": "a"}`), new(MockPageService))
+	alice := newAckTestClient(room)
+	patch := []byte(`{"type": "op-patch", "payload": {
+		"patches": [{"op": "replace", "path": "/title", "value": "b"}],
+		"version": 1
+	}}`)
+
+	alice.receivedAt = time.Now()
+	alice.handleOpPatch(patch)
+	var ack AckPayload
+	assert.Equal(t, TypeAck, readTestMessage(t, alice, &ack))
+	assert.Nil(t, ack.Timing)
+	assert.True(t, (<-room.broadcast).AppliedAt.IsZero())
+
+	hub := &Hub{latencyTracking: true}
+	room.hub, alice.Hub = hub, hub
+	alice.receivedAt = time.Now().Add(-20 * time.Millisecond)
+	alice.handleOpPatch([]byte(`{"type": "op-patch", "payload": {
+		"patches": [{"op": "replace", "path": "/title", "value": "c"}],
+		"version": 2
+	}}`))
+	assert.Equal(t, TypeAck, readTestMessage(t, alice, &ack))
+	require.NotNil(t, ack.Timing)
+	assert.GreaterOrEqual(t, ack.Timing.QueueMs, 20.0)
+	assert.GreaterOrEqual(t, ack.Timing.ApplyMs, 0.0)
+	assert.False(t, (<-room.broadcast).AppliedAt.IsZero())
+}
+
+func TestClient_ObserveDelivered(t *testing.T) {
+	// 测试场景：带应用完成时间的广播写入后记录送达耗时；被丢弃的、不带时间的消息和未开启追踪时不记录
+
+	before := latencyMetrics.snapshot()[LatencyDeliver].Count
+
+	room := newTestRoom("test-room", []byte(`{}`), new(MockPageService))
+	room.hub = &Hub{latencyTracking: true}
+	alice := &Client{Hub: room.hub, UserInfo: UserInfo{UserID: "alice"}, send: make(chan []byte, 8), Room: room}
+	bob := &Client{Hub: room.hub, UserInfo: UserInfo{UserID: "bob"}, send: make(chan []byte, 1), Room: room}
+	room.clients[alice] = true
+	room.clients[bob] = true
+	bob.send <- []byte(`{"type": "cursor-move"}`)
+
+	patch := []byte(`{"type": "op-patch"}`)
+	room.deliver(&RoomBroadcast{Message: patch, AppliedAt: time.Now().Add(-10 * time.Millisecond)})
+	room.deliver(&RoomBroadcast{Message: []byte(`{"type": "cursor-move"}`)})
+
+	alice.observeDelivered(<-alice.send)
+	alice.observeDelivered(<-alice.send)
+	bob.observeDelivered(<-bob.send)
+	assert.Empty(t, bob.appliedAt, "被丢弃的消息不应留下记录")
+
+	after := latencyMetrics.snapshot()[LatencyDeliver]
+	assert.Equal(t, before+1, after.Count)
+	assert.GreaterOrEqual(t, after.MaxMs, 10.0)
+
+	// 未开启追踪时不记录
+	carol := &Client{}
+	carol.trackApplied(patch, time.Now())
+	carol.observeDelivered(patch)
+	assert.Equal(t, before+1, latencyMetrics.snapshot()[LatencyDeliver].Count)
+}
+
+func TestRoom_PersistLatency(t *testing.T) {
+	// 测试场景：第一个未落盘的编辑开始计时，刷盘成功后记录并清零；未开启追踪时不计时
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", "test-room", mock.Anything, int64(1), mock.Anything).Return(nil)
+	room := newTestRoom("test-room", []byte(`{"title": "a"}`), mockService)
+	room.lastPersistedVersion = 1
+
+	_, err := room.ApplyEdit(UserInfo{UserID: "alice"}, []byte(`[{"op": "replace", "path": "/title", "value": "b"}]`), 1)
+	require.NoError(t, err)
+	assert.True(t, room.dirtySince.IsZero())
+
+	room.hub = &Hub{latencyTracking: true}
+	_, err = room.ApplyEdit(UserInfo{UserID: "alice"}, []byte(`[{"op": "replace", "path": "/title", "value": "c"}]`), 2)
+	require.NoError(t, err)
+	dirtySince := room.dirtySince
+	require.False(t, dirtySince.IsZero())
+
+	_, err = room.ApplyEdit(UserInfo{UserID: "alice"}, []byte(`[{"op": "replace", "path": "/title", "value": "d"}]`), 3)
+	require.NoError(t, err)
+	assert.Equal(t, dirtySince, room.dirtySince)
+
+	before := latencyMetrics.snapshot()[LatencyPersist].Count
+	require.NoError(t, room.persist("测试", true))
+	assert.Equal(t, before+1, latencyMetrics.snapshot()[LatencyPersist].Count)
+	assert.True(t, room.dirtySince.IsZero())
+}
@@ -0,0 +1,282 @@
+package ws
+
+import (
+	"log"
+	"sort"
+	"time"
+)
+
+// 组件锁配置
+const (
+	LockTTL           = 30 * time.Second // 锁在未续期时的存活时间，防止废弃的锁永久阻塞页面
+	lockSweepInterval = 5 * time.Second  // 过期锁扫描间隔
+)
+
+// 锁释放原因
+const (
+	LockReasonReleased     = "released"     // 持有者主动释放
+	LockReasonExpired      = "expired"      // 超过 TTL 未续期
+	LockReasonDisconnected = "disconnected" // 持有者断开连接
+	LockReasonStolen       = "stolen"       // 被其他用户抢占
+	LockReasonPresentation = "presentation" // 进入演示模式，非演示者的锁被收回
+	LockReasonSession      = "session"      // 协作时段结束，所有者以外用户的锁被收回
+)
+
+// componentLock 单个组件锁
+type componentLock struct {
+	componentID string
+	holder      *Client
+	acquiredAt  time.Time
+	expiresAt   time.Time
+}
+
+// lockTable 房间内的组件锁表。
+// 与 clients map 一样只在 Room.run() 内访问，无需加锁。
+type lockTable struct {
+	ttl   time.Duration
+	locks map[string]*componentLock
+}
+
+func newLockTable(ttl time.Duration) *lockTable {
+	return &lockTable{
+		ttl:   ttl,
+		locks: make(map[string]*componentLock),
+	}
+}
+
+// acquire 获取或续期锁。
+// 锁空闲、已过期或已被 c 所在设备持有时成功，同一设备的其他连接续期时锁转给 c；否则返回当前持有者和 false。
+func (t *lockTable) acquire(componentID string, c *Client, now time.Time) (*componentLock, bool) {
+	lock, ok := t.locks[componentID]
+	if ok && !sameDevice(lock.holder, c) && now.Before(lock.expiresAt) {
+		return lock, false
+	}
+
+	switch {
+	case !ok || !sameDevice(lock.holder, c):
+		lock = &componentLock{componentID: componentID, holder: c, acquiredAt: now}
+		t.locks[componentID] = lock
+	case lock.holder != c:
+		lock.holder = c
+	}
+	lock.expiresAt = now.Add(t.ttl)
+	return lock, true
+}
+
+// release 释放 c 所在设备持有的锁，锁不属于该设备时返回 false
+func (t *lockTable) release(componentID string, c *Client) bool {
+	lock, ok := t.locks[componentID]
+	if !ok || !sameDevice(lock.holder, c) {
+		return false
+	}
+	delete(t.locks, componentID)
+	return true
+}
+
+// steal 无条件将锁转移给 c，返回原持有者（无人持有、已过期或与 c 为同一设备时为 nil）
+func (t *lockTable) steal(componentID string, c *Client, now time.Time) *Client {
+	var previous *Client
+	if lock, ok := t.locks[componentID]; ok && !sameDevice(lock.holder, c) && now.Before(lock.expiresAt) {
+		previous = lock.holder
+	}
+
+	t.locks[componentID] = &componentLock{
+		componentID: componentID,
+		holder:      c,
+		acquiredAt:  now,
+		expiresAt:   now.Add(t.ttl),
+	}
+	return previous
+}
+
+// releaseHeldBy 释放 c 持有的全部锁，返回被释放的组件 ID
+func (t *lockTable) releaseHeldBy(c *Client) []string {
+	var released []string
+	for id, lock := range t.locks {
+		if lock.holder == c {
+			delete(t.locks, id)
+			released = append(released, id)
+		}
+	}
+	return released
+}
+
+// transfer 把 from 持有的全部锁转给 to，有效期不变，返回被转移的组件 ID
+func (t *lockTable) transfer(from, to *Client) []string {
+	var moved []string
+	for id, lock := range t.locks {
+		if lock.holder == from {
+			lock.holder = to
+			moved = append(moved, id)
+		}
+	}
+	sort.Strings(moved)
+	return moved
+}
+
+// snapshot 返回当前未过期的锁，按组件 ID 排序，随 sync 下发给新加入的用户
+func (t *lockTable) snapshot(now time.Time) []LockPayload {
+	var locks []LockPayload
+	for id, lock := range t.locks {
+		if now.Before(lock.expiresAt) {
+			locks = append(locks, LockPayload{ComponentID: id, User: lock.holder.UserInfo})
+		}
+	}
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].ComponentID < locks[j].ComponentID
+	})
+	return locks
+}
+
+// expire 移除所有已过期的锁并返回
+func (t *lockTable) expire(now time.Time) []*componentLock {
+	var expired []*componentLock
+	for id, lock := range t.locks {
+		if !now.Before(lock.expiresAt) {
+			delete(t.locks, id)
+			expired = append(expired, lock)
+		}
+	}
+	return expired
+}
+
+// --- Room 侧的锁操作（均在 run() 内执行） ---
+
+// lockOpKind 锁操作类型
+type lockOpKind int
+
+const (
+	lockOpSteal   lockOpKind = iota
+	lockOpAcquire            // 获取或续期
+	lockOpRelease            // 主动释放
+)
+
+// lockOp 客户端发往 Room 的锁操作请求
+type lockOp struct {
+	kind        lockOpKind
+	componentID string
+	client      *Client
+}
+
+// handleLockOp 处理锁操作
+func (r *Room) handleLockOp(op *lockOp) {
+	if _, ok := r.clients[op.client]; !ok {
+		return
+	}
+
+	switch op.kind {
+	case lockOpAcquire:
+		now := time.Now()
+		existing, held := r.locks.locks[op.componentID]
+		renewal := held && existing.holder == op.client && now.Before(existing.expiresAt)
+
+		lock, ok := r.locks.acquire(op.componentID, op.client, now)
+		if !ok {
+			// 仅通知请求者，前端可提示"XX 正在编辑"或选择抢占
+			r.sendToClient(op.client, encodeServerMessage(TypeLockDenied, LockPayload{
+				ComponentID: op.componentID,
+				User:        lock.holder.UserInfo,
+			}))
+			return
+		}
+
+		data := encodeServerMessage(TypeLockAcquired, LockPayload{
+			ComponentID: op.componentID,
+			User:        op.client.UserInfo,
+		})
+		if renewal {
+			// 续期只确认给持有者，避免每次续期都广播
+			r.sendToClient(op.client, data)
+			return
+		}
+		r.deliver(&RoomBroadcast{Message: data})
+
+	case lockOpRelease:
+		if !r.locks.release(op.componentID, op.client) {
+			return
+		}
+		r.deliver(&RoomBroadcast{Message: encodeServerMessage(TypeLockReleased, LockPayload{
+			ComponentID: op.componentID,
+			User:        op.client.UserInfo,
+			Reason:      LockReasonReleased,
+		})})
+
+	case lockOpSteal:
+		previous := r.locks.steal(op.componentID, op.client, time.Now())
+		if previous != nil {
+			// 单独通知原持有者，便于前端提示"你的锁已被 XX 抢占"
+			r.sendToClient(previous, encodeServerMessage(TypeLockStolen, LockPayload{
+				ComponentID: op.componentID,
+				User:        op.client.UserInfo,
+				Reason:      LockReasonStolen,
+			}))
+			log.Printf("[Room %s] 用户 [%s] 抢占了 [%s] 持有的组件锁 %s",
+				r.ID, op.client.UserInfo.UserName, previous.UserInfo.UserName, op.componentID)
+		}
+		r.deliver(&RoomBroadcast{Message: encodeServerMessage(TypeLockAcquired, LockPayload{
+			ComponentID: op.componentID,
+			User:        op.client.UserInfo,
+		})})
+	}
+}
+
+// releaseLocksOf 释放客户端持有的全部锁并广播，reason 为断开连接或进入演示模式
+func (r *Room) releaseLocksOf(client *Client, reason string) {
+	for _, id := range r.locks.releaseHeldBy(client) {
+		r.deliver(&RoomBroadcast{Message: encodeServerMessage(TypeLockReleased, LockPayload{
+			ComponentID: id,
+			User:        client.UserInfo,
+			Reason:      reason,
+		})})
+	}
+}
+
+// handOverLocks 断开的连接持有的锁转给同一设备上仍在房间内的其他连接（如切换标签页），
+// 广播新的持有者；没有这样的连接时不处理，由 releaseLocksOf 释放，仅在 run() 内调用
+func (r *Room) handOverLocks(client *Client) {
+	sibling := r.deviceSibling(client)
+	if sibling == nil {
+		return
+	}
+	for _, id := range r.locks.transfer(client, sibling) {
+		r.deliver(&RoomBroadcast{Message: encodeServerMessage(TypeLockAcquired, LockPayload{
+			ComponentID: id,
+			User:        sibling.UserInfo,
+		})})
+	}
+}
+
+// expireLocks 清理超时未续期的锁并广播
+func (r *Room) expireLocks() {
+	for _, lock := range r.locks.expire(time.Now()) {
+		r.deliver(&RoomBroadcast{Message: encodeServerMessage(TypeLockReleased, LockPayload{
+			ComponentID: lock.componentID,
+			User:        lock.holder.UserInfo,
+			Reason:      LockReasonExpired,
+		})})
+	}
+}
+
+// AcquireLock 请求获取或续期组件锁，锁被他人持有时请求者收到 lock-denied
+func (r *Room) AcquireLock(client *Client, componentID string) {
+	select {
+	case r.lockOps <- &lockOp{kind: lockOpAcquire, componentID: componentID, client: client}:
+	case <-r.stopChan:
+	}
+}
+
+// ReleaseLock 释放客户端持有的组件锁，未持有时忽略
+func (r *Room) ReleaseLock(client *Client, componentID string) {
+	select {
+	case r.lockOps <- &lockOp{kind: lockOpRelease, componentID: componentID, client: client}:
+	case <-r.stopChan:
+	}
+}
+
+// StealLock 请求抢占组件锁，原持有者会收到 lock-stolen 通知
+func (r *Room) StealLock(client *Client, componentID string) {
+	select {
+	case r.lockOps <- &lockOp{kind: lockOpSteal, componentID: componentID, client: client}:
+	case <-r.stopChan:
+	}
+}
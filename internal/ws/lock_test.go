@@ -0,0 +1,152 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== 组件锁单元测试 ==========
+// 测试重点：TTL 过期、抢占、断线释放
+
+func TestLockTable_AcquireConflict(t *testing.T) {
+	// 测试场景：锁被他人持有时获取失败，持有者可续期
+
+	table := newLockTable(LockTTL)
+	alice, bob := &Client{}, &Client{}
+	now := time.Now()
+
+	_, ok := table.acquire("c1", alice, now)
+	assert.True(t, ok)
+
+	lock, ok := table.acquire("c1", bob, now)
+	assert.False(t, ok)
+	assert.Same(t, alice, lock.holder)
+
+	// 持有者续期，过期时间顺延
+	lock, ok = table.acquire("c1", alice, now.Add(10*time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(10*time.Second+LockTTL), lock.expiresAt)
+}
+
+func TestLockTable_Expire(t *testing.T) {
+	// 测试场景：超过 TTL 未续期的锁被清理，他人可重新获取
+
+	table := newLockTable(LockTTL)
+	alice, bob := &Client{}, &Client{}
+	now := time.Now()
+
+	table.acquire("c1", alice, now)
+
+	assert.Empty(t, table.expire(now.Add(LockTTL-time.Second)))
+
+	expired := table.expire(now.Add(LockTTL))
+	assert.Len(t, expired, 1)
+	assert.Same(t, alice, expired[0].holder)
+
+	_, ok := table.acquire("c1", bob, now.Add(LockTTL))
+	assert.True(t, ok)
+}
+
+func TestLockTable_Steal(t *testing.T) {
+	// 测试场景：抢占返回原持有者，锁归属转移
+
+	table := newLockTable(LockTTL)
+	alice, bob := &Client{}, &Client{}
+	now := time.Now()
+
+	// 无人持有时抢占，没有原持有者
+	assert.Nil(t, table.steal("c1", alice, now))
+
+	previous := table.steal("c1", bob, now)
+	assert.Same(t, alice, previous)
+
+	_, ok := table.acquire("c1", alice, now)
+	assert.False(t, ok)
+	assert.False(t, table.release("c1", alice))
+	assert.True(t, table.release("c1", bob))
+}
+
+func TestLockTable_ReleaseHeldBy(t *testing.T) {
+	// 测试场景：断线时释放该客户端持有的全部锁
+
+	table := newLockTable(LockTTL)
+	alice, bob := &Client{}, &Client{}
+	now := time.Now()
+
+	table.acquire("c1", alice, now)
+	table.acquire("c2", alice, now)
+	table.acquire("c3", bob, now)
+
+	released := table.releaseHeldBy(alice)
+	assert.ElementsMatch(t, []string{"c1", "c2"}, released)
+	assert.Len(t, table.locks, 1)
+}
+
+func TestRoom_LockComponent(t *testing.T) {
+	// 测试场景：获取成功广播给所有人，他人获取被拒绝，续期只确认给持有者，释放后广播
+
+	room := newTestRoom("page-1", []byte(`{}`), new(MockPageService))
+	alice := &Client{UserInfo: UserInfo{UserID: "alice"}, send: make(chan []byte, 8), Room: room}
+	bob := &Client{UserInfo: UserInfo{UserID: "bob"}, send: make(chan []byte, 8), Room: room}
+	room.clients[alice] = true
+	room.clients[bob] = true
+
+	room.handleLockOp(&lockOp{kind: lockOpAcquire, componentID: "c1", client: alice})
+	for _, c := range []*Client{alice, bob} {
+		var lock LockPayload
+		readMessage(t, c, TypeLockAcquired, &lock)
+		assert.Equal(t, "alice", lock.User.UserID)
+	}
+
+	room.handleLockOp(&lockOp{kind: lockOpAcquire, componentID: "c1", client: bob})
+	var denied LockPayload
+	readMessage(t, bob, TypeLockDenied, &denied)
+	assert.Equal(t, "alice", denied.User.UserID)
+	assert.Empty(t, alice.send)
+
+	room.handleLockOp(&lockOp{kind: lockOpAcquire, componentID: "c1", client: alice})
+	var renewed LockPayload
+	readMessage(t, alice, TypeLockAcquired, &renewed)
+	assert.Empty(t, bob.send, "续期不应广播")
+
+	assert.Equal(t, []LockPayload{{ComponentID: "c1", User: alice.UserInfo}}, room.locks.snapshot(time.Now()))
+
+	// 非持有者释放被忽略
+	room.handleLockOp(&lockOp{kind: lockOpRelease, componentID: "c1", client: bob})
+	assert.Empty(t, bob.send)
+
+	room.handleLockOp(&lockOp{kind: lockOpRelease, componentID: "c1", client: alice})
+	for _, c := range []*Client{alice, bob} {
+		var released LockPayload
+		readMessage(t, c, TypeLockReleased, &released)
+		assert.Equal(t, LockReasonReleased, released.Reason)
+	}
+	assert.Empty(t, room.locks.snapshot(time.Now()))
+}
+
+func TestRoom_CriticalBroadcastKickReleasesLocks(t *testing.T) {
+	// 测试场景：关键消息因发送缓冲区写满阻塞时，被踢出的用户与主动断开一样释放锁并通知其他人离开
+
+	room := newTestRoom("page-1", []byte(`{}`), new(MockPageService))
+	alice := &Client{UserInfo: UserInfo{UserID: "alice"}, send: make(chan []byte, 8), Room: room}
+	bob := &Client{UserInfo: UserInfo{UserID: "bob"}, send: make(chan []byte, 1), Room: room}
+	room.clients[alice] = true
+	room.clients[bob] = true
+	room.locks.acquire("c1", bob, time.Now())
+	bob.send <- []byte(`{"type": "cursor-move"}`)
+
+	room.deliver(&RoomBroadcast{Message: []byte(`{"type": "op-patch"}`), IsCritical: true})
+	assert.NotContains(t, room.clients, bob)
+	assert.Equal(t, `{"type": "op-patch"}`, string(<-alice.send))
+
+	var released LockPayload
+	readMessage(t, alice, TypeLockReleased, &released)
+	assert.Equal(t, LockReasonDisconnected, released.Reason)
+	assert.Empty(t, room.locks.snapshot(time.Now()))
+
+	var left UserInfo
+	readMessage(t, alice, TypeUserLeave, &left)
+	assert.Equal(t, "bob", left.UserID)
+}
@@ -1,6 +1,14 @@
 package ws
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/msgpack"
+
+	"github.com/gorilla/websocket"
+)
 
 // MessageType 定义 WebSocket 消息类型
 type MessageType string
@@ -14,10 +22,133 @@ const (
 	TypeUserJoin  MessageType = "user-join"  // 用户加入房间
 	TypeUserLeave MessageType = "user-leave" // 用户离开房间
 	TypeSync      MessageType = "sync"       // 全量同步
-	TypeAck       MessageType = "ack"        // 确认消息
+	TypeAck       MessageType = "ack"        // op-patch 已应用的确认（仅发给发送者）
 	TypeError     MessageType = "error"      // 错误消息
+
+	// 服务优雅停机时，房间刷盘前发给所有客户端，随后连接以 1012 (Service Restart) 关闭
+	TypeServerRestarting MessageType = "server-restarting"
+
+	// 实例即将停机或进入维护模式时发给所有客户端，客户端应在给定窗口内分散重连，由负载均衡分配到其他实例
+	TypeServerDraining MessageType = "server-draining"
+
+	// 客户端检测到状态偏离（如连续版本冲突）时请求重新发送 sync，无需断开重连
+	TypeRequestSync MessageType = "request-sync" // 请求全量重新同步（客户端 → 服务端）
+
+	// 重连时携带 sinceVersion 的客户端收到 catch-up 代替 sync，只包含缺失版本的 Patch
+	TypeCatchUp MessageType = "catch-up" // 增量追赶（仅发给重连的客户端）
+
+	// op-validate 与 op-patch 格式相同，服务端只校验并在副本上试应用，不提交也不广播
+	TypeOpValidate     MessageType = "op-validate"     // 试应用 Patch（客户端 → 服务端）
+	TypeValidateResult MessageType = "validate-result" // 试应用结果（仅发给请求者）
+
+	// 组件锁消息类型
+	TypeLockComponent   MessageType = "lock-component"   // 获取或续期组件锁（客户端 → 服务端）
+	TypeUnlockComponent MessageType = "unlock-component" // 释放组件锁（客户端 → 服务端）
+	TypeLockSteal       MessageType = "lock-steal"       // 抢占组件锁（客户端 → 服务端）
+	TypeLockAcquired    MessageType = "lock-acquired"    // 组件锁被获取（广播；续期时仅发给持有者）
+	TypeLockReleased    MessageType = "lock-released"    // 组件锁被释放（广播）
+	TypeLockStolen      MessageType = "lock-stolen"      // 你的组件锁被抢占（仅通知原持有者）
+	TypeLockDenied      MessageType = "lock-denied"      // 组件锁被他人持有，获取失败（仅通知请求者）
+
+	// 选中状态消息类型
+	TypeSelect            MessageType = "select"             // 选中单个组件（客户端 → 服务端），等价于只含一个 ID 的 selection-change
+	TypeSelectionChange   MessageType = "selection-change"   // 选中的组件列表变化（客户端 → 服务端 → 其他人，非关键消息）
+	TypeSelectionConflict MessageType = "selection-conflict" // 多人选中同一组件的提示（仅发给相关用户）
+
+	// 聊天消息类型
+	TypeChat        MessageType = "chat"         // 房间内聊天（客户端 → 服务端 → 所有人）
+	TypeChatHistory MessageType = "chat-history" // 聊天记录及持久化设置（加入房间或设置变更时下发）
+
+	// 页面级协同设置变更（仅服务端下发，加入时的设置在 sync 中）
+	TypeCollabSettings MessageType = "collab-settings"
+
+	// 演示模式：页面所有者开启或结束（客户端 → 服务端），状态变化广播给所有人
+	TypePresentation MessageType = "presentation"
+
+	// 协作时段开始、结束或被修改（仅服务端下发），加入时的状态在 sync 中
+	TypeSession MessageType = "session"
+
+	// 冲突备份：连续冲突或被强制重新同步后，客户端上传被拒绝的本地状态
+	TypeConflictBackup      MessageType = "conflict-backup"       // 上传冲突备份（客户端 → 服务端）
+	TypeConflictBackupSaved MessageType = "conflict-backup-saved" // 冲突备份已保存（仅发给上传者）
+
+	// 运维发布的系统公告（仅服务端下发）
+	TypeSystemNotice MessageType = "system-notice"
+
+	// 评论消息类型（通过 REST 接口修改后由服务端广播给所有人）
+	TypeCommentAdded    MessageType = "comment-added"    // 新评论或回复
+	TypeCommentUpdated  MessageType = "comment-updated"  // 评论内容被修改
+	TypeCommentResolved MessageType = "comment-resolved" // 线程被解决或重新打开
+	TypeCommentDeleted  MessageType = "comment-deleted"  // 评论（及其回复）被删除
+
+	// 视口跟随消息类型
+	TypeViewportUpdate MessageType = "viewport-update" // 画布滚动与缩放（客户端 → 服务端 → 其他人，非关键消息）
+
+	// 文本协同消息类型（需协商 text-ot 能力）
+	TypeTextOp  MessageType = "text-op"  // 文本属性的 OT 操作
+	TypeTextAck MessageType = "text-ack" // 文本操作已应用的确认（仅发给发送者）
+
+	// 流式 AI 生成，见 ai.go
+	TypeAIGenerate MessageType = "ai-generate" // 发起 AI 生成（客户端 → 服务端）
+	TypeAICancel   MessageType = "ai-cancel"   // 取消进行中的 AI 生成（客户端 → 服务端）
+	TypeAIChunk    MessageType = "ai-chunk"    // 模型输出的增量文本（仅发给请求者）
+	TypeAIDone     MessageType = "ai-done"     // AI 生成完成或已取消（仅发给请求者）
 )
 
+// SubprotocolMsgPack 使用 MessagePack 编码 WSMessage 的 WebSocket 子协议
+const SubprotocolMsgPack = "lowcode.msgpack"
+
+// Codec WebSocket 帧编解码。房间内部始终以 JSON 表示消息，Codec 只在连接边界转换，
+// 因此广播时同一条消息可以按各连接协商的编码分别发送。
+type Codec interface {
+	// Subprotocol 协商用的子协议名，默认的 JSON 编码为空
+	Subprotocol() string
+	// Encode 把 JSON 消息编码为帧，返回 websocket.TextMessage 或 BinaryMessage
+	Encode(msg []byte) (frameType int, frame []byte, err error)
+	// Decode 把收到的二进制帧解码为 JSON 消息；文本帧总是按 JSON 处理，不经过 Decode
+	Decode(frame []byte) ([]byte, error)
+}
+
+// JSONCodec 默认编码，消息原样以文本帧发送
+var JSONCodec Codec = jsonCodec{}
+
+// MsgPackCodec MessagePack 编码，消息以二进制帧发送，字段名与 JSON 相同
+var MsgPackCodec Codec = msgPackCodec{}
+
+// Subprotocols 返回服务端支持的编码子协议，用于 websocket.Upgrader
+func Subprotocols() []string {
+	return []string{SubprotocolMsgPack}
+}
+
+// CodecFor 返回子协议对应的编码，ok 为 false 表示不是编码子协议（此时返回 JSONCodec）
+func CodecFor(subprotocol string) (codec Codec, ok bool) {
+	if subprotocol == SubprotocolMsgPack {
+		return MsgPackCodec, true
+	}
+	return JSONCodec, false
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Subprotocol() string { return "" }
+
+func (jsonCodec) Encode(msg []byte) (int, []byte, error) {
+	return websocket.TextMessage, msg, nil
+}
+
+func (jsonCodec) Decode(frame []byte) ([]byte, error) { return frame, nil }
+
+type msgPackCodec struct{}
+
+func (msgPackCodec) Subprotocol() string { return SubprotocolMsgPack }
+
+func (msgPackCodec) Encode(msg []byte) (int, []byte, error) {
+	frame, err := msgpack.FromJSON(msg)
+	return websocket.BinaryMessage, frame, err
+}
+
+func (msgPackCodec) Decode(frame []byte) ([]byte, error) { return msgpack.ToJSON(frame) }
+
 // WSMessage 统一的 WebSocket 消息结构
 type WSMessage struct {
 	Type      MessageType     `json:"type"`     // 消息类型
@@ -30,14 +161,155 @@ type WSMessage struct {
 type SyncPayload struct {
 	Schema  json.RawMessage `json:"schema"`
 	Version int64           `json:"version"`
-	Users   []UserInfo      `json:"users"`
+	Users   []UserInfo      `json:"users"` // 其他连接，同一用户的多个设备、标签页各占一项，按 clientId 区分
+
+	// ClientID 本连接的 ID，客户端据此在在线列表和锁的持有者中识别自己
+	ClientID string `json:"clientId,omitempty"`
+
+	// Capabilities 本连接协商成功的可选能力，如 "text-ot"
+	Capabilities []string `json:"capabilities,omitempty"`
+
+	// TextRevisions 协商了 text-ot 时各文本属性的当前修订号（JSON Pointer → 修订号），未出现的属性修订号为 0
+	TextRevisions map[string]int `json:"textRevisions,omitempty"`
+
+	// Selections 房间内其他用户当前选中的组件，供新加入者立即显示选中高亮
+	Selections []UserSelection `json:"selections,omitempty"`
+
+	// Locks 当前被持有的组件锁
+	Locks []LockPayload `json:"locks,omitempty"`
+
+	// Settings 页面级协同设置，前端据此隐藏被关闭的光标、选中、聊天功能
+	Settings entity.CollabSettings `json:"settings"`
+
+	// Presentation 当前的演示模式状态，未处于演示模式时省略
+	Presentation *PresentationPayload `json:"presentation,omitempty"`
+
+	// Session 页面的协作时段及当前是否开放，未设置时段时省略
+	Session *SessionPayload `json:"session,omitempty"`
+}
+
+// CatchUpPayload catch-up 消息的 payload 结构。
+// Patches 第 i 个元素是把版本 sinceVersion+i 变为 sinceVersion+i+1 的 RFC 6902 Patch，
+// 依次应用后客户端状态与 version 一致；其余字段与 sync 相同。
+type CatchUpPayload struct {
+	SinceVersion int64             `json:"sinceVersion"`
+	Version      int64             `json:"version"`
+	Patches      []json.RawMessage `json:"patches"`
+	Users        []UserInfo        `json:"users"`
+	ClientID     string            `json:"clientId,omitempty"`
+
+	Capabilities  []string        `json:"capabilities,omitempty"`
+	TextRevisions map[string]int  `json:"textRevisions,omitempty"`
+	Selections    []UserSelection `json:"selections,omitempty"`
+	Locks         []LockPayload   `json:"locks,omitempty"`
+
+	Presentation *PresentationPayload `json:"presentation,omitempty"`
+	Session      *SessionPayload      `json:"session,omitempty"`
+}
+
+// ServerRestartingPayload server-restarting 消息的 payload 结构
+type ServerRestartingPayload struct {
+	Version int64  `json:"version"` // 通知时的页面版本，该版本及之前的修改会在断开前刷盘
+	Message string `json:"message"`
+}
+
+// MaxClientMsgIDLength 客户端消息 ID 的最大长度
+const MaxClientMsgIDLength = 64
+
+// OpPatchPayload op-patch 消息的 payload 结构
+type OpPatchPayload struct {
+	Patches json.RawMessage `json:"patches"`
+	Version int64           `json:"version"` // 基准版本号，应用后页面版本为 version+1
+
+	// ClientMsgID 客户端生成的消息 ID，服务端在 ack 或 error 中原样带回，不广播给其他人
+	ClientMsgID string `json:"clientMsgId,omitempty"`
+
+	// Label 产生该编辑的编辑器功能（如 "drag-resize"、"undo"、"AI-assist"），可选；
+	// 写入操作日志，并随广播转发给其他人
+	Label string `json:"label,omitempty"`
+}
+
+// AckPayload op-patch 已应用的确认（仅发给发送者）
+type AckPayload struct {
+	ClientMsgID string `json:"clientMsgId,omitempty"` // 对应 op-patch 的客户端消息 ID
+	Version     int64  `json:"version"`               // 应用后的页面版本号
+
+	// Patches 服务端追加的编辑归属操作，发送者应用后与服务端状态保持一致；无追加时省略
+	Patches json.RawMessage `json:"patches,omitempty"`
+
+	// Rebased op-patch 基于旧版本，已按页面的冲突策略改写后应用到最新状态，
+	// 发送者的本地状态可能与服务端不同，服务端随后会发送 sync
+	Rebased bool `json:"rebased,omitempty"`
+
+	// Timing 服务端的排队和应用耗时，仅开启延迟追踪时返回，见 latency.go
+	Timing *AckTiming `json:"timing,omitempty"`
+}
+
+// ValidateResultPayload op-validate 的试应用结果（仅发给请求者）
+type ValidateResultPayload struct {
+	ClientMsgID string `json:"clientMsgId,omitempty"` // 对应 op-validate 的客户端消息 ID
+	Valid       bool   `json:"valid"`
+
+	// Version 成功时为提交后将得到的版本号，失败时为当前版本号
+	Version int64 `json:"version"`
+
+	// Patches 提交时实际会应用的 Patch（含服务端追加的编辑归属），仅成功时返回
+	Patches json.RawMessage `json:"patches,omitempty"`
+
+	// Code、Message 失败原因，与 op-patch 失败时的 error 消息相同
+	Code    ErrorCode `json:"code,omitempty"`
+	Message string    `json:"message,omitempty"`
 }
 
 // UserInfo 用户基础信息
 type UserInfo struct {
-	UserID   string `json:"userId"`
-	UserName string `json:"userName"`
-	Color    string `json:"color,omitempty"`
+	UserID    string `json:"userId"`
+	UserName  string `json:"userName"`
+	AvatarURL string `json:"avatarUrl,omitempty"` // 头像地址，用户没有头像时省略
+	Color     string `json:"color,omitempty"`
+	Guest     bool   `json:"guest,omitempty"` // 免登录访客，身份由服务端临时分配
+	Role      string `json:"role,omitempty"`  // 页面角色 owner/editor/viewer，未校验页面权限（如访客）时为空
+
+	// Spectator 只读观看连接：viewer 或连接时声明 readonly=true，随在线状态广播给其他人
+	Spectator bool `json:"spectator,omitempty"`
+
+	// ClientID 服务端为每个连接生成的 ID；DeviceID、DeviceName 为客户端连接时声明的稳定设备标识和名称（如 "iPad"），
+	// 同一用户的多个设备在在线列表中分别显示，组件锁属于设备，见 device.go
+	ClientID   string `json:"clientId,omitempty"`
+	DeviceID   string `json:"deviceId,omitempty"`
+	DeviceName string `json:"deviceName,omitempty"`
+
+	// SessionID 连接所用 Token 的登录会话 ID（Clerk sid），会话被吊销时据此断开连接；不下发给客户端
+	SessionID string `json:"-"`
+}
+
+// ReadOnly 是否为只读连接（viewer 或观看者），不能修改页面和组件锁
+func (u UserInfo) ReadOnly() bool {
+	return u.Role == entity.RoleViewer || u.Spectator
+}
+
+// LockPayload 组件锁消息的 payload 结构
+type LockPayload struct {
+	ComponentID string   `json:"componentId"`
+	User        UserInfo `json:"user,omitempty"`   // 锁的（新）持有者，释放时为原持有者
+	Reason      string   `json:"reason,omitempty"` // 释放原因，见 LockReason* 常量
+}
+
+// encodeServerMessage 构造并序列化一条服务端下发的消息
+func encodeServerMessage(msgType MessageType, payload interface{}) []byte {
+	return encodeMessage(msgType, "server", payload)
+}
+
+// encodeMessage 构造并序列化一条消息，senderID 由服务端指定
+func encodeMessage(msgType MessageType, senderID string, payload interface{}) []byte {
+	payloadBytes, _ := json.Marshal(payload)
+	data, _ := json.Marshal(WSMessage{
+		Type:      msgType,
+		SenderID:  senderID,
+		Payload:   payloadBytes,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	return data
 }
 
 // --- 错误码定义 ---
@@ -50,15 +322,35 @@ const (
 	ErrPatchInvalid    ErrorCode = "PATCH_INVALID"    // Patch 格式错误
 	ErrPatchFailed     ErrorCode = "PATCH_FAILED"     // Patch 应用失败
 	ErrRoomNotFound    ErrorCode = "ROOM_NOT_FOUND"   // 房间不存在
-	ErrUnauthorized    ErrorCode = "UNAUTHORIZED"     // 未授权
+	ErrUnauthorized    ErrorCode = "UNAUTHORIZED"     // 未授权，只读协作者或演示模式下的非演示者提交编辑时也返回此错误
 	ErrInternalError   ErrorCode = "INTERNAL_ERROR"   // 服务器内部错误
 	ErrPageDeleted     ErrorCode = "PAGE_DELETED"     // 页面已被删除
+	ErrInvalidMessage  ErrorCode = "INVALID_MESSAGE"  // 消息格式错误
+	ErrTextRevision    ErrorCode = "TEXT_REVISION"    // 文本操作的基准修订号过旧，需重新同步
+	ErrCapability      ErrorCode = "CAPABILITY"       // 未协商对应能力
+	ErrKicked          ErrorCode = "KICKED"           // 被页面创建者移出房间，连接随后关闭
+	ErrRoomFull        ErrorCode = "ROOM_FULL"        // 房间连接数已达上限，连接随后关闭
+	ErrRateLimited     ErrorCode = "RATE_LIMITED"     // 消息发送过于频繁，被丢弃；持续超限时连接随后关闭
+	ErrFeatureDisabled ErrorCode = "FEATURE_DISABLED" // 页面已关闭该协同功能（如聊天）
+	ErrSessionClosed   ErrorCode = "SESSION_CLOSED"   // 不在页面的协作时段内：加入被拒绝（连接随后关闭）或编辑被拒绝
+	ErrSessionRevoked  ErrorCode = "SESSION_REVOKED"  // 登录会话已失效（退出登录、被吊销或账号被删除），连接随后关闭
+	ErrOwnerChanged    ErrorCode = "OWNER_CHANGED"    // 页面所有者已变更（原所有者的账号被删除），连接随后关闭，重连后按新的角色加入
+	ErrRoomMoved       ErrorCode = "ROOM_MOVED"       // 房间租约已转到其他节点，连接随后关闭，重连后转发到新的节点
+	ErrAIFailed        ErrorCode = "AI_FAILED"        // 模型服务调用失败或生成的修改无效，clientMsgId 为 ai-generate 的请求 ID
+	ErrContentRejected ErrorCode = "CONTENT_REJECTED" // 聊天或属性文本未通过内容审核，未应用也未广播
+	ErrUpgradeRequired ErrorCode = "UPGRADE_REQUIRED" // 本月 AI 生成次数已达套餐额度，需要升级套餐
 )
 
 // ErrorPayload 错误消息的 payload 结构
 type ErrorPayload struct {
 	Code    ErrorCode `json:"code"`    // 错误码
 	Message string    `json:"message"` // 错误描述
+
+	// ClientMsgID 出错的 op-patch 携带的客户端消息 ID，前端据此回滚对应的乐观更新
+	ClientMsgID string `json:"clientMsgId,omitempty"`
+
+	// CurrentRevision TEXT_REVISION 错误时该文本属性的当前修订号
+	CurrentRevision *int `json:"currentRevision,omitempty"`
 }
 
 // --- 自定义错误类型 ---
@@ -73,6 +365,16 @@ func (e *VersionConflictError) Error() string {
 	return "version conflict"
 }
 
+// TextRevisionError 文本操作基准修订号无效（过旧或超前）
+type TextRevisionError struct {
+	CurrentRevision int
+	BaseRevision    int
+}
+
+func (e *TextRevisionError) Error() string {
+	return "text revision out of range"
+}
+
 // PatchError Patch 处理错误
 type PatchError struct {
 	Reason string
@@ -0,0 +1,151 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"sync"
+)
+
+// 消息方向
+const (
+	DirectionIn  = "in"  // 客户端 → 服务端
+	DirectionOut = "out" // 服务端 → 客户端
+)
+
+// typeUnknown 无法识别或服务端不处理的入站消息类型统一计入此类，避免客户端构造任意类型撑大计数表
+const typeUnknown MessageType = "unknown"
+
+// MessageSizeBuckets 消息大小直方图的桶上界（字节）。
+// TypeStats.Sizes 与之一一对应，末尾多一项记录超过最大上界的消息数。
+var MessageSizeBuckets = []int{256, 1024, 4096, 16384, 65536, 262144}
+
+// inboundTypes ReadPump 会处理的入站消息类型
+var inboundTypes = map[MessageType]bool{
+	TypeOpPatch:         true,
+	TypeCursorMove:      true,
+	TypeLockComponent:   true,
+	TypeUnlockComponent: true,
+	TypeLockSteal:       true,
+	TypeTextOp:          true,
+	TypeSelect:          true,
+	TypeSelectionChange: true,
+	TypeChat:            true,
+	TypeViewportUpdate:  true,
+	TypeRequestSync:     true,
+	TypeOpValidate:      true,
+	TypePresentation:    true,
+	TypeConflictBackup:  true,
+}
+
+// messageMetrics 全部房间按方向、类型累计的消息计数，通过 expvar 暴露为 ws_messages
+var messageMetrics = &messageStats{}
+
+func init() {
+	expvar.Publish("ws_messages", expvar.Func(func() interface{} {
+		return messageMetrics.snapshot()
+	}))
+}
+
+// TypeStats 单一方向、单一类型消息的累计计数
+type TypeStats struct {
+	Count int64   `json:"count"`
+	Bytes int64   `json:"bytes"`
+	Sizes []int64 `json:"sizes"` // 大小直方图，桶上界见 MessageSizeBuckets
+}
+
+// messageStats 按方向和类型累计的消息计数，零值可用，并发安全
+type messageStats struct {
+	mu    sync.Mutex
+	stats map[string]map[MessageType]*TypeStats
+}
+
+// record 累计一条消息
+func (s *messageStats) record(direction string, msgType MessageType, size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stats == nil {
+		s.stats = make(map[string]map[MessageType]*TypeStats)
+	}
+	byType := s.stats[direction]
+	if byType == nil {
+		byType = make(map[MessageType]*TypeStats)
+		s.stats[direction] = byType
+	}
+	ts := byType[msgType]
+	if ts == nil {
+		ts = &TypeStats{Sizes: make([]int64, len(MessageSizeBuckets)+1)}
+		byType[msgType] = ts
+	}
+
+	ts.Count++
+	ts.Bytes += int64(size)
+	ts.Sizes[sizeBucket(size)]++
+}
+
+// snapshot 返回计数的拷贝：方向 → 类型 → 计数
+func (s *messageStats) snapshot() map[string]map[MessageType]TypeStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]map[MessageType]TypeStats, len(s.stats))
+	for direction, byType := range s.stats {
+		copied := make(map[MessageType]TypeStats, len(byType))
+		for msgType, ts := range byType {
+			copied[msgType] = TypeStats{
+				Count: ts.Count,
+				Bytes: ts.Bytes,
+				Sizes: append([]int64(nil), ts.Sizes...),
+			}
+		}
+		out[direction] = copied
+	}
+	return out
+}
+
+// sizeBucket 返回 size 所在直方图桶的下标
+func sizeBucket(size int) int {
+	for i, bound := range MessageSizeBuckets {
+		if size <= bound {
+			return i
+		}
+	}
+	return len(MessageSizeBuckets)
+}
+
+// recordMessage 将一条消息计入全局和所在房间的计数
+func (c *Client) recordMessage(direction string, msgType MessageType, size int) {
+	messageMetrics.record(direction, msgType, size)
+	if c.Room != nil {
+		c.Room.messages.record(direction, msgType, size)
+	}
+}
+
+// inboundType 返回入站消息的计数类型，不处理的类型计为 unknown
+func inboundType(msgType MessageType) MessageType {
+	if inboundTypes[msgType] {
+		return msgType
+	}
+	return typeUnknown
+}
+
+// outboundType 取出出站消息的类型。
+// 服务端构造的消息 type 总在首位，直接截取；转发的客户端原始消息字段顺序不定，回退到完整解析。
+func outboundType(data []byte) MessageType {
+	const prefix = `{"type":"`
+	if bytes.HasPrefix(data, []byte(prefix)) {
+		rest := data[len(prefix):]
+		if end := bytes.IndexByte(rest, '"'); end >= 0 {
+			return MessageType(rest[:end])
+		}
+	}
+
+	var msg struct {
+		Type MessageType `json:"type"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Type == "" {
+		return typeUnknown
+	}
+	return msg.Type
+}
@@ -0,0 +1,57 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== 消息计数单元测试 ==========
+
+func TestMessageStats_Record(t *testing.T) {
+	// 测试场景：按方向和类型分别累计条数、字节数，大小落入对应直方图桶
+
+	var s messageStats
+	s.record(DirectionIn, TypeCursorMove, 100)
+	s.record(DirectionIn, TypeCursorMove, 256)
+	s.record(DirectionIn, TypeCursorMove, 257)
+	s.record(DirectionOut, TypeSync, 1<<20)
+
+	snapshot := s.snapshot()
+
+	cursor := snapshot[DirectionIn][TypeCursorMove]
+	assert.Equal(t, int64(3), cursor.Count)
+	assert.Equal(t, int64(613), cursor.Bytes)
+	assert.Equal(t, []int64{2, 1, 0, 0, 0, 0, 0}, cursor.Sizes)
+
+	syncStats := snapshot[DirectionOut][TypeSync]
+	assert.Equal(t, int64(1), syncStats.Count)
+	assert.Equal(t, int64(1), syncStats.Sizes[len(MessageSizeBuckets)])
+
+	// 快照是拷贝，之后的计数不影响已取出的结果
+	s.record(DirectionIn, TypeCursorMove, 10)
+	assert.Equal(t, int64(3), snapshot[DirectionIn][TypeCursorMove].Count)
+}
+
+func TestClient_RecordMessage_PerRoom(t *testing.T) {
+	// 测试场景：消息同时计入所在房间；未知的入站类型计为 unknown
+
+	room := newTestRoom("test-room", []byte(`{}`), new(MockPageService))
+	c := &Client{Room: room}
+
+	c.recordMessage(DirectionIn, inboundType(TypeViewportUpdate), 80)
+	c.recordMessage(DirectionIn, inboundType("made-up-type"), 20)
+
+	snapshot := room.messages.snapshot()
+	assert.Equal(t, int64(1), snapshot[DirectionIn][TypeViewportUpdate].Count)
+	assert.Equal(t, int64(20), snapshot[DirectionIn][typeUnknown].Bytes)
+	assert.NotContains(t, snapshot[DirectionIn], MessageType("made-up-type"))
+}
+
+func TestOutboundType(t *testing.T) {
+	// 测试场景：服务端构造的消息走前缀截取，转发的客户端消息回退到完整解析
+
+	assert.Equal(t, TypeLockAcquired, outboundType(encodeServerMessage(TypeLockAcquired, LockPayload{ComponentID: "c1"})))
+	assert.Equal(t, TypeOpPatch, outboundType([]byte(`{"payload":{"patches":[]},"type":"op-patch"}`)))
+	assert.Equal(t, typeUnknown, outboundType([]byte(`not json`)))
+}
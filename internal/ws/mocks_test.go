@@ -1,6 +1,15 @@
 package ws
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+
 	"github.com/stretchr/testify/mock"
 )
 
@@ -29,3 +38,650 @@ func (m *MockPageService) SavePageState(pageID string, state []byte, oldVersion,
 	args := m.Called(pageID, state, oldVersion, newVersion)
 	return args.Error(0)
 }
+
+// ========== MockOpStore ==========
+// 实现 OpStore 接口，记录每次批量写入的内容
+
+type MockOpStore struct {
+	mu      sync.Mutex
+	batches [][]*entity.PageOp
+	fails   int // 之后的前 fails 次 CreateBatch 返回错误
+}
+
+func (m *MockOpStore) CreateBatch(ops []*entity.PageOp) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fails > 0 {
+		m.fails--
+		return errors.New("connection reset")
+	}
+	m.batches = append(m.batches, append([]*entity.PageOp(nil), ops...))
+	return nil
+}
+
+// DeleteAfter 删除页面版本大于 version 的操作日志
+func (m *MockOpStore) DeleteAfter(pageID string, version int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var deleted int64
+	for i, batch := range m.batches {
+		kept := batch[:0:0]
+		for _, op := range batch {
+			if op.PageID == pageID && op.Version > version {
+				deleted++
+				continue
+			}
+			kept = append(kept, op)
+		}
+		m.batches[i] = kept
+	}
+	return deleted, nil
+}
+
+// Ops 返回已写入的全部操作日志（按写入顺序）
+func (m *MockOpStore) Ops() []*entity.PageOp {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var all []*entity.PageOp
+	for _, batch := range m.batches {
+		all = append(all, batch...)
+	}
+	return all
+}
+
+// ListSince 按版本升序返回 sinceVersion 之后的操作日志，实现 OpReader 接口
+func (m *MockOpStore) ListSince(pageID string, sinceVersion int64, limit int) ([]*entity.PageOp, error) {
+	var ops []*entity.PageOp
+	for _, op := range m.Ops() {
+		if op.PageID == pageID && op.Version > sinceVersion && len(ops) < limit {
+			ops = append(ops, op)
+		}
+	}
+	return ops, nil
+}
+
+// ========== MockObjectStore ==========
+// 实现 ObjectStore 接口，记录上传的对象
+
+type MockObjectStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (m *MockObjectStore) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.objects == nil {
+		m.objects = make(map[string][]byte)
+	}
+	m.objects[key] = append([]byte(nil), body...)
+	return nil
+}
+
+// Objects 返回已上传的对象
+func (m *MockObjectStore) Objects() map[string][]byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	objects := make(map[string][]byte, len(m.objects))
+	for k, v := range m.objects {
+		objects[k] = v
+	}
+	return objects
+}
+
+// ========== MockActivityStore ==========
+// 实现 ActivityStore 接口，记录每次批量写入的活动
+
+type MockActivityStore struct {
+	mu         sync.Mutex
+	activities []*entity.PageActivity
+}
+
+func (m *MockActivityStore) Record(activities []*entity.PageActivity) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.activities = append(m.activities, activities...)
+	return nil
+}
+
+// Activities 返回已写入的全部活动，按用户 ID 索引（测试中每个用户只出现一次）
+func (m *MockActivityStore) Activities() map[string]*entity.PageActivity {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	byUser := make(map[string]*entity.PageActivity, len(m.activities))
+	for _, a := range m.activities {
+		byUser[a.UserID] = a
+	}
+	return byUser
+}
+
+// ========== MockReferenceStore ==========
+// 实现 ReferenceStore 接口，记录每个页面最后写入的引用及写入次数，pages 之外的页面视为不存在
+
+type MockReferenceStore struct {
+	mu     sync.Mutex
+	pages  map[string]bool
+	refs   map[string][]*entity.PageReference
+	writes int
+}
+
+func (m *MockReferenceStore) ReplaceForPage(sourcePageID string, refs []*entity.PageReference) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.refs == nil {
+		m.refs = make(map[string][]*entity.PageReference)
+	}
+	m.refs[sourcePageID] = refs
+	m.writes++
+	return nil
+}
+
+func (m *MockReferenceStore) MissingPages(pageIDs []string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var missing []string
+	for _, id := range pageIDs {
+		if !m.pages[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+// Refs 返回页面最后写入的引用
+func (m *MockReferenceStore) Refs(pageID string) []*entity.PageReference {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.refs[pageID]
+}
+
+// Writes 返回写入次数
+func (m *MockReferenceStore) Writes() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.writes
+}
+
+// ========== MockEventSink ==========
+// 实现 EventSink 接口，记录收到的生命周期事件
+
+type MockEventSink struct {
+	mu     sync.Mutex
+	events []LifecycleEvent
+}
+
+func (m *MockEventSink) Publish(event LifecycleEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, event)
+}
+
+// Types 返回已收到事件的类型（按发送顺序）
+func (m *MockEventSink) Types() []LifecycleEventType {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	types := make([]LifecycleEventType, 0, len(m.events))
+	for _, event := range m.events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+// Last 返回最后一条事件
+func (m *MockEventSink) Last() LifecycleEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.events[len(m.events)-1]
+}
+
+// ========== MockDeltaStore ==========
+// 实现 DeltaStore 接口，用于差量刷盘测试
+
+type MockDeltaStore struct {
+	mock.Mock
+}
+
+func (m *MockDeltaStore) SavePageDelta(pageID string, patches []byte, oldVersion, newVersion int64) error {
+	args := m.Called(pageID, patches, oldVersion, newVersion)
+	return args.Error(0)
+}
+
+// ========== MockChatStore ==========
+// 实现 ChatStore 接口，Save 在独立 goroutine 中调用，通过 saved 通道通知测试
+
+type MockChatStore struct {
+	persisted bool
+	history   []*entity.ChatMessage
+	saved     chan *entity.ChatMessage
+}
+
+func (m *MockChatStore) Save(msg *entity.ChatMessage) error {
+	m.saved <- msg
+	return nil
+}
+
+func (m *MockChatStore) ListRecent(pageID string, limit int) ([]*entity.ChatMessage, error) {
+	return m.history, nil
+}
+
+func (m *MockChatStore) ChatPersisted(pageID string) (bool, error) {
+	return m.persisted, nil
+}
+
+// ========== MockConflictBackupStore ==========
+// 实现 ConflictBackupStore 接口，保存时按顺序分配 ID；err 非空时保存失败
+
+type MockConflictBackupStore struct {
+	saved []*entity.ConflictBackup
+	err   error
+}
+
+func (m *MockConflictBackupStore) Save(backup *entity.ConflictBackup) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.saved = append(m.saved, backup)
+	backup.ID = uint(len(m.saved))
+	return nil
+}
+
+// ========== MockTransport ==========
+// 内存中的 Transport 实现，用于不经过真实连接测试 ReadPump / WritePump
+
+// mockFrame 一帧消息
+type mockFrame struct {
+	frameType int
+	data      []byte
+}
+
+var (
+	errMockClosed   = errors.New("mock transport closed")
+	errMockTimeout  = errors.New("mock transport read timeout")
+	errMockTooLarge = errors.New("mock transport read limit exceeded")
+)
+
+type MockTransport struct {
+	inbound  chan mockFrame // 测试写入，ReadMessage 读出
+	outbound chan mockFrame // WriteMessage 写入，测试读出
+	closed   chan struct{}
+	once     sync.Once
+
+	mu           sync.Mutex
+	readDeadline time.Time
+	readLimit    int64
+	writeErr     error
+	pongHandler  func(string) error
+}
+
+func NewMockTransport() *MockTransport {
+	return &MockTransport{
+		inbound:  make(chan mockFrame, 16),
+		outbound: make(chan mockFrame, 16),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (m *MockTransport) ReadMessage() (int, []byte, error) {
+	for {
+		m.mu.Lock()
+		deadline, limit := m.readDeadline, m.readLimit
+		m.mu.Unlock()
+
+		var timeout <-chan time.Time
+		if !deadline.IsZero() {
+			timeout = time.After(time.Until(deadline))
+		}
+
+		select {
+		case frame := <-m.inbound:
+			if limit > 0 && int64(len(frame.data)) > limit {
+				return 0, nil, errMockTooLarge
+			}
+			return frame.frameType, frame.data, nil
+		case <-m.closed:
+			return 0, nil, errMockClosed
+		case <-timeout:
+			// 等待期间读超时可能已被 Pong 延长，重新检查
+			m.mu.Lock()
+			expired := !m.readDeadline.After(time.Now())
+			m.mu.Unlock()
+			if expired {
+				return 0, nil, errMockTimeout
+			}
+		}
+	}
+}
+
+func (m *MockTransport) WriteMessage(frameType int, data []byte) error {
+	m.mu.Lock()
+	err := m.writeErr
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-m.closed:
+		return errMockClosed
+	default:
+	}
+	m.outbound <- mockFrame{frameType: frameType, data: data}
+	return nil
+}
+
+func (m *MockTransport) Close() error {
+	m.once.Do(func() { close(m.closed) })
+	return nil
+}
+
+func (m *MockTransport) SetReadDeadline(t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readDeadline = t
+	return nil
+}
+
+func (m *MockTransport) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+func (m *MockTransport) SetReadLimit(limit int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readLimit = limit
+}
+
+func (m *MockTransport) SetPongHandler(h func(string) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pongHandler = h
+}
+
+// Receive 模拟客户端发来一帧
+func (m *MockTransport) Receive(frameType int, data []byte) {
+	m.inbound <- mockFrame{frameType: frameType, data: data}
+}
+
+// Pong 模拟收到心跳回应
+func (m *MockTransport) Pong() {
+	m.mu.Lock()
+	h := m.pongHandler
+	m.mu.Unlock()
+	if h != nil {
+		h("")
+	}
+}
+
+// FailWrites 之后的写操作都返回 err
+func (m *MockTransport) FailWrites(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.writeErr = err
+}
+
+// IsClosed 判断连接是否已被关闭
+func (m *MockTransport) IsClosed() bool {
+	select {
+	case <-m.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// ========== MockSettingsStore ==========
+// 实现 SettingsStore 接口，返回固定设置
+
+type MockSettingsStore struct {
+	settings entity.CollabSettings
+}
+
+func (m *MockSettingsStore) CollabSettings(pageID string) (entity.CollabSettings, error) {
+	return m.settings, nil
+}
+
+// ========== MockRoomBridge ==========
+// 实现 RoomBridge 接口，在同一进程的多个 Hub 之间按发布顺序转发消息；
+// SetPartitioned(true) 期间发布的消息被丢弃，模拟节点之间的网络分区
+
+type MockRoomBridge struct {
+	mu          sync.Mutex
+	handlers    map[string]map[int]func([]byte)
+	nextID      int
+	partitioned bool
+	queue       chan mockBridgeMessage
+}
+
+type mockBridgeMessage struct {
+	pageID string
+	data   []byte
+}
+
+func NewMockRoomBridge() *MockRoomBridge {
+	b := &MockRoomBridge{handlers: make(map[string]map[int]func([]byte)), queue: make(chan mockBridgeMessage, 1024)}
+	go func() {
+		for msg := range b.queue {
+			b.mu.Lock()
+			handlers := make([]func([]byte), 0, len(b.handlers[msg.pageID]))
+			for _, h := range b.handlers[msg.pageID] {
+				handlers = append(handlers, h)
+			}
+			b.mu.Unlock()
+			for _, h := range handlers {
+				h(msg.data)
+			}
+		}
+	}()
+	return b
+}
+
+func (b *MockRoomBridge) Publish(pageID string, data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.partitioned {
+		b.queue <- mockBridgeMessage{pageID: pageID, data: data}
+	}
+}
+
+func (b *MockRoomBridge) Subscribe(pageID string, handler func([]byte)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.handlers[pageID] == nil {
+		b.handlers[pageID] = make(map[int]func([]byte))
+	}
+	id := b.nextID
+	b.nextID++
+	b.handlers[pageID][id] = handler
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers[pageID], id)
+	}
+}
+
+// SetPartitioned 开始或结束模拟的网络分区
+func (b *MockRoomBridge) SetPartitioned(partitioned bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.partitioned = partitioned
+}
+
+// ========== MockRoomLock ==========
+// 实现 RoomLock 接口的内存租约锁，不处理过期；Steal 模拟租约过期后被其他节点取得，SetFailing 模拟锁服务不可用
+
+type MockRoomLock struct {
+	mu      sync.Mutex
+	holders map[string]string
+	failing bool
+}
+
+func NewMockRoomLock() *MockRoomLock {
+	return &MockRoomLock{holders: make(map[string]string)}
+}
+
+func (l *MockRoomLock) Acquire(pageID, owner string, ttl time.Duration) (string, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.failing {
+		return "", false, errors.New("lock unavailable")
+	}
+	if holder, ok := l.holders[pageID]; ok && holder != owner {
+		return holder, false, nil
+	}
+	l.holders[pageID] = owner
+	return owner, true, nil
+}
+
+func (l *MockRoomLock) Renew(pageID, owner string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.failing {
+		return false, errors.New("lock unavailable")
+	}
+	return l.holders[pageID] == owner, nil
+}
+
+func (l *MockRoomLock) Release(pageID, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holders[pageID] == owner {
+		delete(l.holders, pageID)
+	}
+	return nil
+}
+
+func (l *MockRoomLock) Owner(pageID string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.failing {
+		return "", errors.New("lock unavailable")
+	}
+	return l.holders[pageID], nil
+}
+
+// Steal 把页面的租约交给 owner
+func (l *MockRoomLock) Steal(pageID, owner string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.holders[pageID] = owner
+}
+
+// SetFailing 开始或结束模拟的锁服务故障
+func (l *MockRoomLock) SetFailing(failing bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.failing = failing
+}
+
+// ========== MockAIStreamer ==========
+// 实现 AIStreamer 接口：依次输出 chunks，wait 为 true 时随后等待取消，否则返回 result 和 err
+
+type MockAIStreamer struct {
+	chunks []string
+	result json.RawMessage
+	err    error
+	wait   bool
+}
+
+func (m *MockAIStreamer) StreamAI(ctx context.Context, pageID, userID, prompt, mode string, onChunk func(delta string)) (json.RawMessage, error) {
+	for _, chunk := range m.chunks {
+		onChunk(chunk)
+	}
+	if m.wait {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return m.result, m.err
+}
+
+// ========== MockContentModerator ==========
+// 实现 ContentModerator 接口：记录收到的文本，任一文本包含 reject 时拒绝
+
+type MockContentModerator struct {
+	mu     sync.Mutex
+	reject string
+	texts  []ModeratedText
+}
+
+func (m *MockContentModerator) Moderate(ctx context.Context, pageID string, author UserInfo, texts []ModeratedText) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.texts = append(m.texts, texts...)
+	for _, text := range texts {
+		if m.reject != "" && strings.Contains(text.Text, m.reject) {
+			return errors.New("content rejected")
+		}
+	}
+	return nil
+}
+
+// Texts 返回目前收到的全部文本
+func (m *MockContentModerator) Texts() []ModeratedText {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]ModeratedText(nil), m.texts...)
+}
+
+// ========== MockHibernationStore ==========
+// 实现 HibernationStore 接口的内存存储，不处理过期；SetFailing 模拟存储不可用
+
+type hibernatedEntry struct {
+	state   []byte
+	version int64
+}
+
+type MockHibernationStore struct {
+	mu      sync.Mutex
+	entries map[string]hibernatedEntry
+	failing bool
+}
+
+func NewMockHibernationStore() *MockHibernationStore {
+	return &MockHibernationStore{entries: make(map[string]hibernatedEntry)}
+}
+
+func (m *MockHibernationStore) Save(pageID string, state []byte, version int64, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failing {
+		return errors.New("store unavailable")
+	}
+	if current, ok := m.entries[pageID]; ok && current.version > version {
+		return nil
+	}
+	m.entries[pageID] = hibernatedEntry{state: state, version: version}
+	return nil
+}
+
+func (m *MockHibernationStore) Take(pageID string) ([]byte, int64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failing {
+		return nil, 0, false, errors.New("store unavailable")
+	}
+	entry, ok := m.entries[pageID]
+	delete(m.entries, pageID)
+	return entry.state, entry.version, ok, nil
+}
+
+func (m *MockHibernationStore) Discard(pageID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, pageID)
+	return nil
+}
+
+// Entry 返回页面的休眠状态
+func (m *MockHibernationStore) Entry(pageID string) (string, int64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[pageID]
+	return string(entry.state), entry.version, ok
+}
+
+// SetFailing 设置存储是否不可用
+func (m *MockHibernationStore) SetFailing(failing bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failing = failing
+}
@@ -0,0 +1,235 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"lowercode-go-server/internal/jsondiff"
+	"lowercode-go-server/internal/ot"
+)
+
+// 待审核文本的来源
+const (
+	ModerationSourceChat = "chat" // 聊天消息
+	ModerationSourceProp = "prop" // 组件属性（op-patch、text-op）
+)
+
+// contentRejectedMessage 内容被拒绝时发给发送者的说明，不透露命中的词
+const contentRejectedMessage = "内容未通过审核，请修改后重试"
+
+// ModeratedText 一段待审核的文本
+type ModeratedText struct {
+	Source   string // chat / prop
+	Path     string // 属性的 JSON Pointer，聊天为空
+	Text     string
+	Previous string // 属性修改前的值，供审核方只处理新引入的内容；聊天和新增的属性为空
+}
+
+// ContentModerator 审核用户提交的聊天消息和组件属性文本，由 usecase.ModerationUseCase 实现。
+// 返回错误时拒绝本次提交；命中后只记录或通知、不拒绝时返回 nil。
+// 在发送者的 ReadPump 中同步调用，只阻塞该连接，不阻塞房间事件循环
+type ContentModerator interface {
+	Moderate(ctx context.Context, pageID string, author UserInfo, texts []ModeratedText) error
+}
+
+// WithModeration 启用内容审核：聊天消息和 op-patch、text-op 写入组件属性的文本在应用前审核，
+// 被拒绝时发送者收到 CONTENT_REJECTED，其他人收不到。服务端生成的编辑（AI、HTTP 接口）不经过审核
+func WithModeration(m ContentModerator) HubOption {
+	return func(h *Hub) {
+		h.moderator = m
+	}
+}
+
+// contentModerator 返回内容审核方，未启用时为 nil
+func (h *Hub) contentModerator() ContentModerator {
+	if h == nil {
+		return nil
+	}
+	return h.moderator
+}
+
+// moderateChat 审核聊天消息，被拒绝时返回错误
+func (c *Client) moderateChat(text string) error {
+	m := c.Hub.contentModerator()
+	if m == nil {
+		return nil
+	}
+	return m.Moderate(context.Background(), c.RoomID, c.UserInfo,
+		[]ModeratedText{{Source: ModerationSourceChat, Text: text}})
+}
+
+// moderatePatch 审核 Patch 写入组件属性的文本，被拒绝时返回错误
+func (c *Client) moderatePatch(patchBytes []byte) error {
+	m := c.Hub.contentModerator()
+	if m == nil {
+		return nil
+	}
+	texts := c.Room.patchPropTexts(patchBytes)
+	if len(texts) == 0 {
+		return nil
+	}
+	return m.Moderate(context.Background(), c.RoomID, c.UserInfo, texts)
+}
+
+// moderateTextOp 审核文本操作应用后的属性文本，被拒绝时返回错误
+func (c *Client) moderateTextOp(componentID, prop string, op ot.TextOperation) error {
+	m := c.Hub.contentModerator()
+	if m == nil {
+		return nil
+	}
+	text := c.Room.textOpPropText(componentID, prop, op)
+	if text.Text == "" {
+		return nil
+	}
+	return m.Moderate(context.Background(), c.RoomID, c.UserInfo, []ModeratedText{text})
+}
+
+// patchPropTexts 提取 Patch 中 add / replace 写入组件属性（/components/{id}/props/...）的非空字符串，
+// 包括新增组件中的属性；Previous 取当前状态中同一位置的字符串。Patch 格式错误时返回 nil，由 ApplyEdit 报告
+func (r *Room) patchPropTexts(patchBytes []byte) []ModeratedText {
+	var ops []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(patchBytes, &ops); err != nil {
+		return nil
+	}
+
+	var texts []ModeratedText
+	for _, op := range ops {
+		if (op.Op != "add" && op.Op != "replace") || len(op.Value) == 0 {
+			continue
+		}
+		tokens, ok := splitPointer(op.Path)
+		if !ok || !mayContainProps(tokens) {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			continue
+		}
+		collectPropTexts(tokens, value, &texts)
+	}
+	if len(texts) == 0 {
+		return nil
+	}
+
+	var state interface{}
+	r.stateMu.RLock()
+	err := json.Unmarshal(r.CurrentState, &state)
+	r.stateMu.RUnlock()
+	if err != nil {
+		return texts
+	}
+	for i := range texts {
+		if tokens, ok := splitPointer(texts[i].Path); ok {
+			if previous, ok := lookupTokens(state, tokens).(string); ok {
+				texts[i].Previous = previous
+			}
+		}
+	}
+	return texts
+}
+
+// textOpPropText 预估文本操作应用后的属性文本：能在当前文本上直接应用时（没有并发操作）审核完整文本，
+// 否则只审核操作插入的文本
+func (r *Room) textOpPropText(componentID, prop string, op ot.TextOperation) ModeratedText {
+	text := ModeratedText{Source: ModerationSourceProp, Path: textPropPath(componentID, prop)}
+
+	r.stateMu.RLock()
+	current, err := readTextProp(r.CurrentState, componentID, prop)
+	r.stateMu.RUnlock()
+	if err == nil {
+		if applied, err := op.Apply(current); err == nil {
+			text.Text, text.Previous = applied, current
+			return text
+		}
+	}
+
+	var inserted strings.Builder
+	for _, o := range op {
+		inserted.WriteString(o.Insert)
+	}
+	text.Text = inserted.String()
+	return text
+}
+
+// collectPropTexts 收集 value 中位于组件属性下的非空字符串，对象按 key 排序以保证顺序稳定
+func collectPropTexts(tokens []string, value interface{}, texts *[]ModeratedText) {
+	switch v := value.(type) {
+	case string:
+		if v != "" && isPropPath(tokens) {
+			*texts = append(*texts, ModeratedText{Source: ModerationSourceProp, Path: jsondiff.Pointer(tokens...), Text: v})
+		}
+	case map[string]interface{}:
+		if !mayContainProps(tokens) {
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			collectPropTexts(append(tokens[:len(tokens):len(tokens)], key), v[key], texts)
+		}
+	case []interface{}:
+		if !mayContainProps(tokens) {
+			return
+		}
+		for i, item := range v {
+			collectPropTexts(append(tokens[:len(tokens):len(tokens)], strconv.Itoa(i)), item, texts)
+		}
+	}
+}
+
+// isPropPath 路径是否位于某个组件的 props 之下
+func isPropPath(tokens []string) bool {
+	return len(tokens) >= 4 && tokens[0] == "components" && tokens[2] == "props"
+}
+
+// mayContainProps 路径处的值是否可能包含组件属性：整个文档、components、单个组件或 props 之下
+func mayContainProps(tokens []string) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	return tokens[0] == "components" && (len(tokens) < 3 || tokens[2] == "props")
+}
+
+// splitPointer 把 JSON Pointer 拆分为反转义后的 token，"" 表示整个文档
+func splitPointer(path string) ([]string, bool) {
+	if path == "" {
+		return []string{}, true
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, false
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, true
+}
+
+// lookupTokens 读取 doc 中 tokens 处的值，不存在时返回 nil
+func lookupTokens(doc interface{}, tokens []string) interface{} {
+	for _, token := range tokens {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			doc = v[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			doc = v[i]
+		default:
+			return nil
+		}
+	}
+	return doc
+}
@@ -0,0 +1,108 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 内容审核单元测试 ==========
+
+// newModerationTestRoom 创建启用内容审核的房间，组件 1 的 text 属性为 "hello"，alice 支持 text-ot、bob 为旁观者
+func newModerationTestRoom(t *testing.T, moderator ContentModerator) (*Room, *Client, *Client) {
+	service := new(MockPageService)
+	service.On("GetPageState", "page-1").Return([]byte(`{"rootId":1,"components":{"1":{"id":1,"props":{"text":"hello"}}}}`), int64(1), nil)
+	service.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	hub := NewHub(service, WithModeration(moderator))
+	room, err := hub.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	t.Cleanup(room.Stop)
+
+	alice := &Client{Hub: hub, RoomID: "page-1", send: make(chan []byte, 64),
+		Capabilities: map[string]bool{CapabilityTextOT: true},
+		UserInfo:     UserInfo{UserID: "alice", UserName: "Alice", Role: entity.RoleEditor}}
+	bob := &Client{Hub: hub, RoomID: "page-1", send: make(chan []byte, 64),
+		UserInfo: UserInfo{UserID: "bob", UserName: "Bob", Role: entity.RoleEditor}}
+	for _, c := range []*Client{alice, bob} {
+		require.NoError(t, room.Register(c))
+		nextTestMessageOfType(t, c, TypeSync)
+	}
+	return room, alice, bob
+}
+
+// sendModerationMessage 模拟客户端发送消息
+func sendModerationMessage(c *Client, msgType MessageType, payload string) {
+	data, _ := json.Marshal(WSMessage{Type: msgType, Payload: json.RawMessage(payload)})
+	c.handleMessage(websocket.TextMessage, data)
+}
+
+func TestClient_ModerationRejects(t *testing.T) {
+	// 测试场景：聊天、op-patch 和 text-op 被拒绝时发送者收到 CONTENT_REJECTED（op-patch 带回 clientMsgId），
+	// 页面版本不变，其他人收不到；通过审核的聊天正常广播
+
+	moderator := &MockContentModerator{reject: "spam"}
+	room, alice, bob := newModerationTestRoom(t, moderator)
+
+	sendModerationMessage(alice, TypeChat, `{"text":"buy spam"}`)
+	assert.Equal(t, ErrContentRejected, nextAIError(t, alice).Code)
+
+	sendModerationMessage(alice, TypeOpPatch,
+		`{"version":1,"clientMsgId":"m-1","patches":[{"op":"replace","path":"/components/1/props/text","value":"spam"}]}`)
+	rejected := nextAIError(t, alice)
+	assert.Equal(t, ErrContentRejected, rejected.Code)
+	assert.Equal(t, "m-1", rejected.ClientMsgID)
+
+	sendModerationMessage(alice, TypeTextOp, `{"componentId":"1","prop":"text","revision":0,"ops":[5," spam"]}`)
+	assert.Equal(t, ErrContentRejected, nextAIError(t, alice).Code)
+
+	_, version := room.GetSnapshot()
+	assert.Equal(t, int64(1), version)
+
+	sendModerationMessage(alice, TypeChat, `{"text":"hi"}`)
+	msg := nextTestMessageOfType(t, bob, TypeChat)
+	var chat ChatPayload
+	require.NoError(t, json.Unmarshal(msg.Payload, &chat))
+	assert.Equal(t, "hi", chat.Text, "被拒绝的聊天不会广播")
+}
+
+func TestClient_ModerationTexts(t *testing.T) {
+	// 测试场景：只审核写入组件属性的非空字符串（含新增组件的属性），Previous 为修改前的值；
+	// text-op 审核应用后的完整文本；不涉及属性的 Patch 不调用审核
+
+	moderator := &MockContentModerator{}
+	_, alice, _ := newModerationTestRoom(t, moderator)
+
+	sendModerationMessage(alice, TypeOpPatch, `{"version":1,"patches":[
+		{"op":"replace","path":"/components/1/props/text","value":"world"},
+		{"op":"add","path":"/components/2","value":{"id":2,"name":"Button","props":{"label":"OK","size":3,"items":["a",""]}}},
+		{"op":"add","path":"/components/1/name","value":"Text"}]}`)
+	nextTestMessageOfType(t, alice, TypeAck)
+
+	sendModerationMessage(alice, TypeOpPatch, `{"version":2,"patches":[{"op":"replace","path":"/rootId","value":1}]}`)
+	nextTestMessageOfType(t, alice, TypeAck)
+
+	sendModerationMessage(alice, TypeTextOp, `{"componentId":"1","prop":"text","revision":0,"ops":[5,"!"]}`)
+	nextTestMessageOfType(t, alice, TypeTextAck)
+
+	assert.Equal(t, []ModeratedText{
+		{Source: ModerationSourceProp, Path: "/components/1/props/text", Text: "world", Previous: "hello"},
+		{Source: ModerationSourceProp, Path: "/components/2/props/items/0", Text: "a"},
+		{Source: ModerationSourceProp, Path: "/components/2/props/label", Text: "OK"},
+		{Source: ModerationSourceProp, Path: "/components/1/props/text", Text: "world!", Previous: "world"},
+	}, moderator.Texts())
+}
+
+func TestRoom_TextOpPropTextConcurrent(t *testing.T) {
+	// 测试场景：文本操作无法直接应用到当前文本（存在并发操作）时只审核插入的文本
+
+	room := newTestRoom("test-room", []byte(`{"components":{"1":{"props":{"text":"hello"}}}}`), new(MockPageService))
+	text := room.textOpPropText("1", "text", textOp(t, `[2,"ab",1,"c"]`))
+	assert.Equal(t, "abc", text.Text)
+	assert.Empty(t, text.Previous)
+}
@@ -0,0 +1,110 @@
+package ws
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"time"
+)
+
+// 系统公告级别
+const (
+	NoticeInfo     = "info"     // 一般通知
+	NoticeWarning  = "warning"  // 即将维护等需要留意的通知
+	NoticeCritical = "critical" // 即将中断服务，需要立即保存或退出
+)
+
+// NoticeMaxLength 系统公告内容的最大字符数
+const NoticeMaxLength = 500
+
+// SystemNoticePayload system-notice 消息的 payload 结构
+type SystemNoticePayload struct {
+	ID        string `json:"id"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+	PageID    string `json:"pageId,omitempty"`    // 只发往该页面时非空
+	CreatedAt int64  `json:"createdAt"`           // 毫秒时间戳
+	ExpiresAt int64  `json:"expiresAt,omitempty"` // 毫秒时间戳，到期后前端应隐藏；为 0 时只发给当前在线用户
+}
+
+// ValidNoticeSeverity 判断公告级别是否合法
+func ValidNoticeSeverity(severity string) bool {
+	switch severity {
+	case NoticeInfo, NoticeWarning, NoticeCritical:
+		return true
+	}
+	return false
+}
+
+// Announce 向所有房间（notice.PageID 非空时只向该页面的房间）广播系统公告，返回送达的房间数。
+// 带有效期的公告在到期前保留在 Hub 中，之后加入房间的用户也会收到
+func (h *Hub) Announce(notice SystemNoticePayload) (SystemNoticePayload, int) {
+	id := make([]byte, 8)
+	rand.Read(id)
+	notice.ID = hex.EncodeToString(id)
+	notice.CreatedAt = time.Now().UnixMilli()
+
+	h.noticesMu.Lock()
+	if notice.ExpiresAt > 0 {
+		h.notices = append(h.pruneNoticesLocked(time.Now()), notice)
+	}
+	h.noticesMu.Unlock()
+
+	var rooms []*Room
+	if notice.PageID == "" {
+		rooms = h.allRooms()
+	} else if room := h.GetRoom(notice.PageID); room != nil {
+		rooms = []*Room{room}
+	}
+
+	data := encodeServerMessage(TypeSystemNotice, notice)
+	for _, room := range rooms {
+		room.notifyAll(data)
+	}
+	log.Printf("[Hub] 系统公告 [%s] 已发送到 %d 个房间: %s", notice.Severity, len(rooms), notice.Message)
+	return notice, len(rooms)
+}
+
+// activeNotices 返回发往 pageID 且尚未过期的公告
+func (h *Hub) activeNotices(pageID string) []SystemNoticePayload {
+	h.noticesMu.Lock()
+	defer h.noticesMu.Unlock()
+
+	h.notices = h.pruneNoticesLocked(time.Now())
+	var notices []SystemNoticePayload
+	for _, notice := range h.notices {
+		if notice.PageID == "" || notice.PageID == pageID {
+			notices = append(notices, notice)
+		}
+	}
+	return notices
+}
+
+// pruneNoticesLocked 去掉已过期的公告，调用方需持有 noticesMu
+func (h *Hub) pruneNoticesLocked(now time.Time) []SystemNoticePayload {
+	active := h.notices[:0]
+	for _, notice := range h.notices {
+		if notice.ExpiresAt > now.UnixMilli() {
+			active = append(active, notice)
+		}
+	}
+	return active
+}
+
+// notifyAll 向房间内所有用户广播服务端消息（非关键消息）
+func (r *Room) notifyAll(data []byte) {
+	select {
+	case r.broadcast <- &RoomBroadcast{Message: data}:
+	case <-r.stopChan:
+	}
+}
+
+// sendNotices 向新加入的客户端补发尚未过期的系统公告，仅在 run() 内调用
+func (r *Room) sendNotices(client *Client) {
+	if r.hub == nil {
+		return
+	}
+	for _, notice := range r.hub.activeNotices(r.ID) {
+		r.sendToClient(client, encodeServerMessage(TypeSystemNotice, notice))
+	}
+}
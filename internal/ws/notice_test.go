@@ -0,0 +1,112 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 系统公告单元测试 ==========
+
+// waitForNotice 跳过其他消息，等待客户端收到下一条 system-notice
+func waitForNotice(t *testing.T, c *Client) (SystemNoticePayload, bool) {
+	t.Helper()
+	timeout := time.After(200 * time.Millisecond)
+	for {
+		select {
+		case data := <-c.send:
+			var msg WSMessage
+			require.NoError(t, json.Unmarshal(data, &msg))
+			if msg.Type != TypeSystemNotice {
+				continue
+			}
+			var notice SystemNoticePayload
+			require.NoError(t, json.Unmarshal(msg.Payload, &notice))
+			return notice, true
+		case <-timeout:
+			return SystemNoticePayload{}, false
+		}
+	}
+}
+
+func newNoticeTestHub(t *testing.T) (*Hub, *Client, *Client) {
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", mock.Anything).Return([]byte(`{}`), int64(1), nil)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	hub := NewHub(mockService)
+
+	alice := &Client{UserInfo: UserInfo{UserID: "alice", UserName: "Alice"}, send: make(chan []byte, 16)}
+	bob := &Client{UserInfo: UserInfo{UserID: "bob", UserName: "Bob"}, send: make(chan []byte, 16)}
+	for pageID, c := range map[string]*Client{"page-1": alice, "page-2": bob} {
+		room, err := hub.GetOrCreateRoom(pageID)
+		require.NoError(t, err)
+		t.Cleanup(room.Stop)
+		require.NoError(t, room.Register(c))
+	}
+	return hub, alice, bob
+}
+
+func TestHub_Announce_AllRooms(t *testing.T) {
+	// 测试场景：未指定页面的公告发往所有房间，返回送达的房间数并分配 ID
+
+	hub, alice, bob := newNoticeTestHub(t)
+
+	sent, rooms := hub.Announce(SystemNoticePayload{Severity: NoticeWarning, Message: "今晚 23:00 停机维护"})
+	assert.Equal(t, 2, rooms)
+	assert.NotEmpty(t, sent.ID)
+	assert.NotZero(t, sent.CreatedAt)
+
+	for _, c := range []*Client{alice, bob} {
+		notice, ok := waitForNotice(t, c)
+		require.True(t, ok, "%s 未收到公告", c.UserInfo.UserID)
+		assert.Equal(t, sent, notice)
+	}
+}
+
+func TestHub_Announce_SinglePage(t *testing.T) {
+	// 测试场景：指定页面的公告只发往该页面的房间；页面没有活跃房间时送达 0 个
+
+	hub, alice, bob := newNoticeTestHub(t)
+
+	_, rooms := hub.Announce(SystemNoticePayload{Severity: NoticeInfo, Message: "hi", PageID: "page-1"})
+	assert.Equal(t, 1, rooms)
+
+	_, ok := waitForNotice(t, alice)
+	assert.True(t, ok)
+	_, ok = waitForNotice(t, bob)
+	assert.False(t, ok, "其他页面不应收到公告")
+
+	_, rooms = hub.Announce(SystemNoticePayload{Severity: NoticeInfo, Message: "hi", PageID: "page-3"})
+	assert.Equal(t, 0, rooms)
+}
+
+func TestHub_Announce_ReplayedToLateJoiners(t *testing.T) {
+	// 测试场景：带有效期的公告在到期前补发给之后加入的用户；
+	// 不带有效期或已过期的公告不补发
+
+	hub, _, _ := newNoticeTestHub(t)
+
+	hub.Announce(SystemNoticePayload{Severity: NoticeInfo, Message: "仅在线用户"})
+	hub.Announce(SystemNoticePayload{Severity: NoticeInfo, Message: "已过期", ExpiresAt: time.Now().Add(-time.Minute).UnixMilli()})
+	active, _ := hub.Announce(SystemNoticePayload{
+		Severity:  NoticeCritical,
+		Message:   "5 分钟后停机",
+		ExpiresAt: time.Now().Add(5 * time.Minute).UnixMilli(),
+	})
+
+	room, err := hub.GetOrCreateRoom("page-3")
+	require.NoError(t, err)
+	defer room.Stop()
+	carol := &Client{UserInfo: UserInfo{UserID: "carol", UserName: "Carol"}, send: make(chan []byte, 16)}
+	require.NoError(t, room.Register(carol))
+
+	notice, ok := waitForNotice(t, carol)
+	require.True(t, ok)
+	assert.Equal(t, active, notice)
+	_, ok = waitForNotice(t, carol)
+	assert.False(t, ok, "只应补发一条未过期的公告")
+}
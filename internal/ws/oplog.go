@@ -0,0 +1,184 @@
+package ws
+
+import (
+	"expvar"
+	"sync"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/logging"
+)
+
+// 操作日志写入配置
+const (
+	opLogQueueSize     = 4096                   // 待写入队列容量
+	opLogBatchSize     = 200                    // 单批最大写入条数
+	opLogFlushInterval = 500 * time.Millisecond // 未攒满一批时的最长等待时间
+	opLogMaxAttempts   = 3                      // 单批最多写入次数，之后放弃并计入 failed
+	opLogRetryDelay    = 200 * time.Millisecond // 重试间隔，按已尝试次数递增
+)
+
+// opLogMetrics 操作日志写入计数，通过 expvar 暴露：
+// dropped 为队列已满或写入器已关闭时丢弃的条数，retries 为批量写入的重试次数，failed 为重试后仍未写入的条数，
+// truncated 为房间加载时删除的未刷盘版本条数
+var opLogMetrics = expvar.NewMap("ws_op_log")
+
+// OpStore 操作日志持久化接口，由 repository 层实现
+type OpStore interface {
+	// CreateBatch 批量写入，已存在的 (pageID, version) 被覆盖
+	CreateBatch(ops []*entity.PageOp) error
+	// DeleteAfter 删除页面版本大于 version 的操作日志
+	DeleteAfter(pageID string, version int64) (int64, error)
+}
+
+// OpLogWriter 异步批量写入操作日志。
+// Room 在 ApplyPatch 的锁内调用 Append，因此 Append 必须非阻塞。
+type OpLogWriter struct {
+	store OpStore
+	queue chan *entity.PageOp
+
+	mu     sync.RWMutex // 保护 closed，保证 Close 之后不再入队
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewOpLogWriter 创建 OpLogWriter，需另起 goroutine 调用 Run
+func NewOpLogWriter(store OpStore) *OpLogWriter {
+	return &OpLogWriter{
+		store: store,
+		queue: make(chan *entity.PageOp, opLogQueueSize),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// Append 追加一条操作日志（非阻塞）。
+// 队列已满或已关闭时丢弃并计入 dropped，依赖操作日志的功能需自行处理版本缺口。
+func (w *OpLogWriter) Append(op *entity.PageOp) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		opLogMetrics.Add("dropped", 1)
+		logging.Warnf("[OpLog] 写入器已关闭，丢弃操作日志 %s@%d", op.PageID, op.Version)
+		return
+	}
+
+	select {
+	case w.queue <- op:
+	default:
+		opLogMetrics.Add("dropped", 1)
+		logging.Warnf("[OpLog] 队列已满，丢弃操作日志 %s@%d", op.PageID, op.Version)
+	}
+}
+
+// TruncateAfter 删除页面版本大于 version 的操作日志，在房间加载页面后同步调用。
+// 这些版本曾被应用但未能刷盘（崩溃、乐观锁冲突、桥接分叉落败），不属于页面的实际历史；
+// 房间会以相同的版本号产生新的操作并覆盖写入，删除它们避免追赶和编辑记录在覆盖之前读到
+func (w *OpLogWriter) TruncateAfter(pageID string, version int64) {
+	n, err := w.store.DeleteAfter(pageID, version)
+	if err != nil {
+		logging.Errorf("[OpLog] 删除页面 %s 版本 %d 之后的操作日志失败: %v", pageID, version, err)
+		return
+	}
+	if n > 0 {
+		opLogMetrics.Add("truncated", n)
+		logging.Warnf("[OpLog] 删除页面 %s 版本 %d 之后未刷盘的 %d 条操作日志", pageID, version, n)
+	}
+}
+
+// writeBatch 写入一批操作日志，失败时按递增间隔重试，最多 opLogMaxAttempts 次
+func (w *OpLogWriter) writeBatch(batch []*entity.PageOp) {
+	var err error
+	for attempt := 1; attempt <= opLogMaxAttempts; attempt++ {
+		if err = w.store.CreateBatch(batch); err == nil {
+			return
+		}
+		if attempt < opLogMaxAttempts {
+			opLogMetrics.Add("retries", 1)
+			logging.Warnf("[OpLog] 批量写入 %d 条操作日志失败，第 %d 次重试: %v", len(batch), attempt, err)
+			time.Sleep(time.Duration(attempt) * opLogRetryDelay)
+		}
+	}
+	opLogMetrics.Add("failed", int64(len(batch)))
+	logging.Errorf("[OpLog] 批量写入 %d 条操作日志失败，已放弃: %v", len(batch), err)
+}
+
+// Run 批量写入循环，阻塞直到 Close 被调用且队列清空
+func (w *OpLogWriter) Run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(opLogFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*entity.PageOp, 0, opLogBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		w.writeBatch(batch)
+		batch = make([]*entity.PageOp, 0, opLogBatchSize)
+	}
+
+	for {
+		select {
+		case op := <-w.queue:
+			batch = append(batch, op)
+			if len(batch) >= opLogBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-w.stop:
+			// Close 之后不会再有新日志入队，取空队列后退出
+			for {
+				select {
+				case op := <-w.queue:
+					batch = append(batch, op)
+					if len(batch) >= opLogBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close 停止接收新日志，等待队列中剩余日志写入完成。
+// 应在所有 Room 停止之后调用，之后的 Append 会被丢弃。
+func (w *OpLogWriter) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.stop)
+	}
+	w.mu.Unlock()
+
+	<-w.done
+}
+
+// recordOpLocked 将已应用的 Patch 及其标签写入操作日志和复现日志，调用方需持有 stateMu 且已推进 Version
+func (r *Room) recordOpLocked(patch []byte, author UserInfo, label string) {
+	if r.opLog == nil && r.repro == nil {
+		return
+	}
+	op := &entity.PageOp{
+		PageID:    r.ID,
+		Version:   r.Version,
+		Patch:     append([]byte(nil), patch...),
+		AuthorID:  author.UserID,
+		Guest:     author.Guest,
+		Label:     label,
+		CreatedAt: time.Now(),
+	}
+	if r.opLog != nil {
+		r.opLog.Append(op)
+	}
+	if r.repro != nil {
+		r.repro.record(op, r.CurrentState)
+	}
+}
@@ -0,0 +1,137 @@
+package ws
+
+import (
+	"expvar"
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 操作日志单元测试 ==========
+// 测试重点：Patch 应用后写入操作日志，Close 时剩余日志全部落盘
+
+func TestOpLogWriter_CloseFlushesPending(t *testing.T) {
+	// 测试场景：未攒满一批就关闭，队列中的日志仍按顺序写入
+
+	store := &MockOpStore{}
+	writer := NewOpLogWriter(store)
+	go writer.Run()
+
+	for v := int64(2); v <= 4; v++ {
+		writer.Append(&entity.PageOp{PageID: "page-1", Version: v})
+	}
+	writer.Close()
+
+	ops := store.Ops()
+	assert.Len(t, ops, 3)
+	for i, op := range ops {
+		assert.Equal(t, int64(i+2), op.Version)
+	}
+
+	// 关闭后的 Append 被丢弃，不会 panic
+	writer.Append(&entity.PageOp{PageID: "page-1", Version: 5})
+	assert.Len(t, store.Ops(), 3)
+}
+
+func TestRoom_ApplyPatch_RecordsOp(t *testing.T) {
+	// 测试场景：每次成功的 Patch 记录一条带新版本号的操作日志，失败的 Patch 不记录
+
+	store := &MockOpStore{}
+	writer := NewOpLogWriter(store)
+	go writer.Run()
+
+	room := newTestRoom("test-room", []byte(`{"title": "a"}`), new(MockPageService))
+	room.opLog = writer
+
+	patch := []byte(`[{"op": "replace", "path": "/title", "value": "b"}]`)
+	assert.NoError(t, room.ApplyPatch(patch, 1))
+	assert.Error(t, room.ApplyPatch(patch, 1)) // 版本冲突
+	writer.Close()
+
+	ops := store.Ops()
+	assert.Len(t, ops, 1)
+	assert.Equal(t, "test-room", ops[0].PageID)
+	assert.Equal(t, int64(2), ops[0].Version)
+	assert.JSONEq(t, string(patch), string(ops[0].Patch))
+}
+
+func TestRoom_ApplyPatchAs_GuestWatermark(t *testing.T) {
+	// 测试场景：访客提交的 Patch 在操作日志中带有访客水印
+
+	store := &MockOpStore{}
+	writer := NewOpLogWriter(store)
+	go writer.Run()
+
+	room := newTestRoom("test-room", []byte(`{"title": "a"}`), new(MockPageService))
+	room.opLog = writer
+
+	guest := UserInfo{UserID: "guest-abcd", UserName: "访客 ABCD", Guest: true}
+	patch := []byte(`[{"op": "replace", "path": "/title", "value": "b"}]`)
+	assert.NoError(t, room.ApplyPatchAs(guest, patch, 1))
+	writer.Close()
+
+	ops := store.Ops()
+	assert.Len(t, ops, 1)
+	assert.Equal(t, "guest-abcd", ops[0].AuthorID)
+	assert.True(t, ops[0].Guest)
+}
+
+func TestOpLogWriter_RetryFailedBatch(t *testing.T) {
+	// 测试场景：批量写入失败时重试，重试成功不丢日志；关闭后的 Append 计入 dropped
+
+	before := counterValue(opLogMetrics, "retries")
+	store := &MockOpStore{fails: 2}
+	writer := NewOpLogWriter(store)
+	go writer.Run()
+
+	writer.Append(&entity.PageOp{PageID: "page-1", Version: 2})
+	writer.Close()
+
+	require.Len(t, store.Ops(), 1)
+	assert.Equal(t, before+2, counterValue(opLogMetrics, "retries"))
+
+	dropped := counterValue(opLogMetrics, "dropped")
+	writer.Append(&entity.PageOp{PageID: "page-1", Version: 3})
+	assert.Equal(t, dropped+1, counterValue(opLogMetrics, "dropped"))
+}
+
+func TestHub_TruncatesUnpersistedOps(t *testing.T) {
+	// 测试场景：房间加载页面时删除版本大于已刷盘版本的操作日志，其他页面不受影响
+
+	store := &MockOpStore{}
+	writer := NewOpLogWriter(store)
+	go writer.Run()
+	defer writer.Close()
+	require.NoError(t, store.CreateBatch([]*entity.PageOp{
+		{PageID: "room-1", Version: 2},
+		{PageID: "room-1", Version: 3},
+		{PageID: "room-1", Version: 4},
+		{PageID: "room-2", Version: 5},
+	}))
+
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", "room-1").Return([]byte(`{}`), int64(2), nil)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	hub := NewHub(mockService, WithOpLog(writer))
+
+	_, err := hub.GetOrCreateRoom("room-1")
+	require.NoError(t, err)
+
+	var versions []int64
+	for _, op := range store.Ops() {
+		versions = append(versions, op.Version)
+	}
+	assert.Equal(t, []int64{2, 5}, versions)
+}
+
+// counterValue 读取 expvar 计数，尚未计数时为 0
+func counterValue(m *expvar.Map, key string) int64 {
+	if v, ok := m.Get(key).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
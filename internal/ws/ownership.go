@@ -0,0 +1,157 @@
+package ws
+
+import (
+	"expvar"
+	"fmt"
+	"time"
+
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/logging"
+)
+
+// DefaultOwnershipTTL 房间租约的默认有效期，每 1/3 有效期续约一次
+const DefaultOwnershipTTL = 15 * time.Second
+
+// ownershipMetrics 房间租约计数，通过 expvar 暴露：
+// acquired 为获取的租约，rejected 为页面已由其他节点承载而拒绝创建房间的次数，
+// lost 为续约失败或租约被其他节点取得后关闭的房间，errors 为锁服务调用失败次数
+var ownershipMetrics = expvar.NewMap("ws_ownership")
+
+// RoomLock 分布式租约锁，保证同一页面同时只有一个节点承载权威房间（如 Redis SET NX PX、etcd lease）。
+// owner 为节点的对外地址，其他节点据此把请求转发给承载房间的节点
+type RoomLock interface {
+	// Acquire 尝试以 owner 身份获取页面的租约；已由 owner 持有时视为成功并续期。
+	// 被其他节点持有时返回持有者和 false
+	Acquire(pageID, owner string, ttl time.Duration) (holder string, ok bool, err error)
+
+	// Renew 续期 owner 持有的租约，租约已过期或被其他节点取得时返回 false
+	Renew(pageID, owner string, ttl time.Duration) (bool, error)
+
+	// Release 释放 owner 持有的租约，租约已不属于 owner 时不做任何事
+	Release(pageID, owner string) error
+
+	// Owner 返回页面租约的持有者，没有节点持有时返回空字符串
+	Owner(pageID string) (string, error)
+}
+
+// ownership 房间租约配置
+type ownership struct {
+	lock RoomLock
+	self string // 本节点的对外地址，作为租约持有者
+	ttl  time.Duration
+}
+
+// WithRoomOwnership 启用房间租约：GetOrCreateRoom 创建房间前以 self 身份获取页面租约，
+// 页面已由其他节点承载时返回 RoomOwnedError，调用方应把请求转发给该节点；房间销毁（刷盘之后）时释放租约。
+// ttl <= 0 时使用 DefaultOwnershipTTL；节点失联时其他节点最多等待 ttl 即可接管页面
+func WithRoomOwnership(lock RoomLock, self string, ttl time.Duration) HubOption {
+	return func(h *Hub) {
+		if ttl <= 0 {
+			ttl = DefaultOwnershipTTL
+		}
+		h.ownership = &ownership{lock: lock, self: self, ttl: ttl}
+	}
+}
+
+// RoomOwnedError 页面的房间由其他节点承载
+type RoomOwnedError struct {
+	PageID string
+	Owner  string // 承载房间的节点地址
+}
+
+func (e *RoomOwnedError) Error() string {
+	return fmt.Sprintf("页面 %s 的房间由节点 %s 承载", e.PageID, e.Owner)
+}
+
+// Unwrap 使 errors.Is(err, ErrRoomOwnedElsewhere) 成立
+func (e *RoomOwnedError) Unwrap() error {
+	return domainErrors.ErrRoomOwnedElsewhere
+}
+
+// acquireOwnership 创建房间前获取租约，调用方需持有房间所在分片的锁。
+// 锁服务不可用时拒绝创建：无法确认其他节点是否已承载该页面
+func (h *Hub) acquireOwnership(roomID string) error {
+	if h.ownership == nil {
+		return nil
+	}
+	holder, ok, err := h.ownership.lock.Acquire(roomID, h.ownership.self, h.ownership.ttl)
+	if err != nil {
+		ownershipMetrics.Add("errors", 1)
+		logging.Errorf("[Hub] 获取页面 %s 的房间租约失败: %v", roomID, err)
+		return fmt.Errorf("%w: %v", domainErrors.ErrRoomLockUnavailable, err)
+	}
+	if !ok {
+		ownershipMetrics.Add("rejected", 1)
+		return &RoomOwnedError{PageID: roomID, Owner: holder}
+	}
+	ownershipMetrics.Add("acquired", 1)
+	return nil
+}
+
+// releaseOwnership 房间销毁时释放租约，由 Room 在最后一次刷盘之后调用
+func (h *Hub) releaseOwnership(roomID string) {
+	if h.ownership == nil {
+		return
+	}
+	if err := h.ownership.lock.Release(roomID, h.ownership.self); err != nil {
+		ownershipMetrics.Add("errors", 1)
+		logging.Warnf("[Hub] 释放页面 %s 的房间租约失败，将在 %s 后过期: %v", roomID, h.ownership.ttl, err)
+	}
+}
+
+// RoomOwner 返回承载页面房间的其他节点的地址。
+// 未启用租约、房间在本节点或没有节点承载时返回空字符串，此时应在本节点处理请求
+func (h *Hub) RoomOwner(pageID string) (string, error) {
+	if h.ownership == nil || h.GetRoom(pageID) != nil {
+		return "", nil
+	}
+	owner, err := h.ownership.lock.Owner(pageID)
+	if err != nil {
+		ownershipMetrics.Add("errors", 1)
+		return "", err
+	}
+	if owner == h.ownership.self {
+		return "", nil
+	}
+	return owner, nil
+}
+
+// renewOwnership 定期为本节点的所有房间续约，Hub 停机后退出。
+// 租约被其他节点取得，或连续续约失败直到租约可能已过期时，关闭房间（先刷盘），客户端重连后转发到新的节点；
+// 关闭时的刷盘由页面版本的乐观锁防止覆盖新节点已写入的数据
+func (h *Hub) renewOwnership() {
+	ticker := time.NewTicker(h.ownership.ttl / 3)
+	defer ticker.Stop()
+	for range ticker.C {
+		if h.draining.Load() {
+			return
+		}
+		for _, room := range h.allRooms() {
+			if !room.IsStopping() {
+				h.renewRoom(room)
+			}
+		}
+	}
+}
+
+// renewRoom 为单个房间续约
+func (h *Hub) renewRoom(room *Room) {
+	now := time.Now()
+	ok, err := h.ownership.lock.Renew(room.ID, h.ownership.self, h.ownership.ttl)
+	switch {
+	case err == nil && ok:
+		room.leaseRenewed.Store(now.UnixNano())
+		return
+	case err != nil:
+		ownershipMetrics.Add("errors", 1)
+		if now.Sub(time.Unix(0, room.leaseRenewed.Load())) < h.ownership.ttl {
+			logging.Warnf("[Hub] 页面 %s 的房间租约续约失败，稍后重试: %v", room.ID, err)
+			return
+		}
+		logging.Errorf("[Hub] 页面 %s 的房间租约可能已过期，关闭房间: %v", room.ID, err)
+	default:
+		logging.Warnf("[Hub] 页面 %s 的房间租约已被其他节点取得，关闭房间", room.ID)
+	}
+	ownershipMetrics.Add("lost", 1)
+	go h.CloseRoomWithReason(room.ID, ErrRoomMoved, "页面已转由其他节点承载，请重新连接")
+}
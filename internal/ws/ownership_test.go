@@ -0,0 +1,123 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	domainErrors "lowercode-go-server/domain/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 房间租约单元测试 ==========
+
+// newOwnershipTestHub 创建以 self 身份参与租约的 Hub，数据库中的页面 page-1 为版本 1
+func newOwnershipTestHub(lock RoomLock, self string) *Hub {
+	service := new(MockPageService)
+	service.On("GetPageState", "page-1").Return([]byte(`{}`), int64(1), nil)
+	service.On("GetPageState", "missing").Return(nil, int64(0), domainErrors.ErrPageNotFound)
+	service.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	return NewHub(service, WithRoomOwnership(lock, self, time.Minute))
+}
+
+func TestHub_Ownership(t *testing.T) {
+	// 测试场景：一个节点创建房间后取得租约，其他节点不能创建并得到承载节点的地址；
+	// 房间销毁（刷盘后）释放租约，其他节点随后可以创建；页面不存在时不占用租约
+
+	lock := NewMockRoomLock()
+	hubA := newOwnershipTestHub(lock, "http://node-a")
+	hubB := newOwnershipTestHub(lock, "http://node-b")
+
+	room, err := hubA.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+
+	_, err = hubB.GetOrCreateRoom("page-1")
+	var owned *RoomOwnedError
+	require.ErrorAs(t, err, &owned)
+	assert.ErrorIs(t, err, domainErrors.ErrRoomOwnedElsewhere)
+	assert.Equal(t, "http://node-a", owned.Owner)
+
+	owner, err := hubB.RoomOwner("page-1")
+	require.NoError(t, err)
+	assert.Equal(t, "http://node-a", owner)
+	owner, err = hubA.RoomOwner("page-1")
+	require.NoError(t, err)
+	assert.Empty(t, owner, "房间在本节点时不转发")
+
+	hubA.CloseRoom("page-1")
+	<-room.doneChan
+	roomB, err := hubB.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	defer roomB.Stop()
+
+	_, err = hubA.GetOrCreateRoom("missing")
+	assert.ErrorIs(t, err, domainErrors.ErrPageNotFound)
+	owner, err = lock.Owner("missing")
+	require.NoError(t, err)
+	assert.Empty(t, owner)
+}
+
+func TestHub_OwnershipLockUnavailable(t *testing.T) {
+	// 测试场景：锁服务不可用时拒绝创建房间，无法确认其他节点是否已承载该页面
+
+	lock := NewMockRoomLock()
+	lock.SetFailing(true)
+	hub := newOwnershipTestHub(lock, "http://node-a")
+
+	_, err := hub.GetOrCreateRoom("page-1")
+	assert.ErrorIs(t, err, domainErrors.ErrRoomLockUnavailable)
+	assert.Nil(t, hub.GetRoom("page-1"))
+}
+
+func TestHub_OwnershipLost(t *testing.T) {
+	// 测试场景：续约暂时失败但租约未到期时保留房间；租约被其他节点取得时刷盘关闭房间，客户端收到 ROOM_MOVED
+
+	lock := NewMockRoomLock()
+	hub := newOwnershipTestHub(lock, "http://node-a")
+	room, err := hub.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	alice := &Client{UserInfo: UserInfo{UserID: "alice"}, RoomID: "page-1", send: make(chan []byte, 16)}
+	require.NoError(t, room.Register(alice))
+
+	lock.SetFailing(true)
+	hub.renewRoom(room)
+	assert.Same(t, room, hub.GetRoom("page-1"))
+
+	lock.SetFailing(false)
+	lock.Steal("page-1", "http://node-b")
+	hub.renewRoom(room)
+
+	msg := nextTestMessageOfType(t, alice, TypeError)
+	var payload ErrorPayload
+	require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, ErrRoomMoved, payload.Code)
+	<-room.doneChan
+	assert.Nil(t, hub.GetRoom("page-1"))
+
+	owner, err := lock.Owner("page-1")
+	require.NoError(t, err)
+	assert.Equal(t, "http://node-b", owner, "关闭房间时不能释放其他节点的租约")
+}
+
+func TestHub_OwnershipExpired(t *testing.T) {
+	// 测试场景：续约持续失败直到租约可能已过期时关闭房间
+
+	lock := NewMockRoomLock()
+	hub := newOwnershipTestHub(lock, "http://node-a")
+	room, err := hub.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+
+	lock.SetFailing(true)
+	room.leaseRenewed.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	hub.renewRoom(room)
+
+	select {
+	case <-room.doneChan:
+	case <-time.After(time.Second):
+		t.Fatal(errors.New("租约过期后房间未关闭"))
+	}
+}
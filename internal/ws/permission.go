@@ -0,0 +1,46 @@
+package ws
+
+import (
+	"encoding/json"
+
+	"lowercode-go-server/domain/entity"
+)
+
+// isEditMessage 判断消息是否会修改页面或组件锁，只读协作者和演示模式下的非演示者不能发送
+func isEditMessage(msgType MessageType) bool {
+	switch msgType {
+	case TypeOpPatch, TypeTextOp, TypeLockComponent, TypeUnlockComponent, TypeLockSteal:
+		return true
+	}
+	return false
+}
+
+// editDenied 返回客户端当前不能编辑的错误码和原因，可以编辑时返回空字符串
+func (c *Client) editDenied() (ErrorCode, string) {
+	if c.UserInfo.Role == entity.RoleViewer {
+		return ErrUnauthorized, "只读协作者不能编辑此页面"
+	}
+	if c.UserInfo.Spectator {
+		return ErrUnauthorized, "观看模式下不能编辑此页面"
+	}
+	if c.Room != nil && c.UserInfo.Role != entity.RoleOwner && !c.Room.SessionOpen() {
+		return ErrSessionClosed, "不在页面的协作时段内，页面只读"
+	}
+	if c.Room != nil && !c.Room.MayEdit(c.UserInfo.UserID) {
+		return ErrUnauthorized, "演示模式下只有演示者可以编辑"
+	}
+	return "", ""
+}
+
+// rejectReadOnly 告知客户端编辑被拒绝，op-patch 带回 clientMsgId 供前端回滚乐观更新
+func (c *Client) rejectReadOnly(msg WSMessage, code ErrorCode, reason string) {
+	clientMsgID := ""
+	if msg.Type == TypeOpPatch {
+		var payload OpPatchPayload
+		json.Unmarshal(msg.Payload, &payload)
+		if len(payload.ClientMsgID) <= MaxClientMsgIDLength {
+			clientMsgID = payload.ClientMsgID
+		}
+	}
+	c.sendOpError(clientMsgID, code, reason)
+}
@@ -0,0 +1,74 @@
+package ws
+
+import (
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== 只读协作者单元测试 ==========
+
+func TestClient_ReadOnlyRejectsEdits(t *testing.T) {
+	// 测试场景：只读协作者的编辑和锁消息被拒绝并收到 UNAUTHORIZED，op-patch 带回 clientMsgId，
+	// 房间状态不变；光标等非编辑消息正常处理
+
+	room := newTestRoom("test-room", []byte(`{"title": "a"}`), new(MockPageService))
+	viewer := newAckTestClient(room)
+	viewer.UserInfo.Role = entity.RoleViewer
+
+	viewer.handleMessage(websocket.TextMessage, []byte(`{"type": "op-patch", "payload": {
+		"patches": [{"op": "replace", "path": "/title", "value": "b"}],
+		"version": 1, "clientMsgId": "m1"}}`))
+
+	var errPayload ErrorPayload
+	assert.Equal(t, TypeError, readTestMessage(t, viewer, &errPayload))
+	assert.Equal(t, ErrUnauthorized, errPayload.Code)
+	assert.Equal(t, "m1", errPayload.ClientMsgID)
+
+	for _, msgType := range []MessageType{TypeTextOp, TypeLockComponent, TypeUnlockComponent, TypeLockSteal} {
+		viewer.handleMessage(websocket.TextMessage, []byte(`{"type": "`+string(msgType)+`", "payload": {"componentId": "1"}}`))
+		errPayload = ErrorPayload{}
+		assert.Equal(t, TypeError, readTestMessage(t, viewer, &errPayload), msgType)
+		assert.Equal(t, ErrUnauthorized, errPayload.Code, msgType)
+		assert.Empty(t, errPayload.ClientMsgID, msgType)
+	}
+
+	snapshot, version := room.GetSnapshot()
+	assert.JSONEq(t, `{"title": "a"}`, string(snapshot))
+	assert.Equal(t, int64(1), version)
+
+	viewer.handleMessage(websocket.TextMessage, []byte(`{"type": "cursor-move", "payload": {"x": 1, "y": 2}}`))
+	assert.Empty(t, viewer.send)
+}
+
+func TestClient_SpectatorRejectsEdits(t *testing.T) {
+	// 测试场景：以 readonly 加入的观看者即使是 editor 也不能编辑，op-patch 不会被应用；
+	// 加入时其他人收到的 user-join 中标记为 spectator
+
+	room, alice, bob := newChatTestRoom(&MockChatStore{})
+	bob.UserInfo.Role = entity.RoleEditor
+	bob.UserInfo.Spectator = true
+	assert.True(t, bob.UserInfo.ReadOnly())
+
+	room.announcePresence(TypeUserJoin, bob)
+	var joined UserInfo
+	readMessage(t, alice, TypeUserJoin, &joined)
+	assert.True(t, joined.Spectator)
+
+	bob.handleMessage(websocket.TextMessage, []byte(`{"type": "op-patch", "payload": {
+		"patches": [{"op": "add", "path": "/title", "value": "b"}],
+		"version": 1, "clientMsgId": "m1"}}`))
+
+	var errPayload ErrorPayload
+	readMessage(t, bob, TypeError, &errPayload)
+	assert.Equal(t, ErrUnauthorized, errPayload.Code)
+	assert.Equal(t, "m1", errPayload.ClientMsgID)
+
+	snapshot, version := room.GetSnapshot()
+	assert.JSONEq(t, `{}`, string(snapshot))
+	assert.Equal(t, int64(1), version)
+	assert.Empty(t, alice.send)
+}
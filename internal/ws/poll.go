@@ -0,0 +1,62 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// LongPollTimeout 长轮询没有新版本时的最长等待时间，短于常见代理 30s 的空闲超时
+const LongPollTimeout = 25 * time.Second
+
+// PollResult 长轮询结果：SinceVersion 之后直到 Version 的连续 Patch。
+// Resync 为 true 时无法提供连续的 Patch（版本过旧或超出追赶上限），调用方需重新拉取全量页面
+type PollResult struct {
+	SinceVersion int64             `json:"sinceVersion"`
+	Version      int64             `json:"version"`
+	Patches      []json.RawMessage `json:"patches,omitempty"`
+	Resync       bool              `json:"resync,omitempty"`
+}
+
+// notifyVersionLocked 唤醒等待新版本的长轮询，调用方需持有 stateMu 写锁且已推进 Version
+func (r *Room) notifyVersionLocked() {
+	if r.versionChanged != nil {
+		close(r.versionChanged)
+		r.versionChanged = nil
+	}
+}
+
+// Poll 等待房间版本超过 since，返回其后的连续 Patch，供无法使用 WebSocket 的客户端降级协同。
+// 版本已超过 since 时立即返回；ctx 结束或房间停止前没有新版本时返回 nil，调用方应重新发起轮询。
+// 与重连追赶一样，内存窗口之外的版本从操作日志读取。
+func (r *Room) Poll(ctx context.Context, since int64) *PollResult {
+	ops := r.prefetchOps(since, "长轮询")
+
+	for {
+		r.stateMu.Lock()
+		version := r.Version
+		if since != version {
+			patches := r.patchesSinceLocked(since, ops)
+			r.stateMu.Unlock()
+			return &PollResult{
+				SinceVersion: since,
+				Version:      version,
+				Patches:      patches,
+				Resync:       patches == nil,
+			}
+		}
+		if r.versionChanged == nil {
+			r.versionChanged = make(chan struct{})
+		}
+		changed := r.versionChanged
+		r.stateMu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil
+		case <-r.stopChan:
+			return nil
+		}
+	}
+}
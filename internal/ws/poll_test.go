@@ -0,0 +1,95 @@
+package ws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 长轮询单元测试 ==========
+
+func TestRoom_Poll_WaitsForNewVersion(t *testing.T) {
+	// 测试场景：sinceVersion 为当前版本时阻塞，提交编辑后返回新版本及其 Patch
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	room := NewRoom("test-room", []byte(`{"title": "a"}`), mockService, nil)
+	defer room.Stop()
+
+	results := make(chan *PollResult, 1)
+	go func() { results <- room.Poll(context.Background(), 1) }()
+
+	select {
+	case <-results:
+		t.Fatal("没有新版本时不应返回")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_, err := room.SubmitEdit(UserInfo{UserID: "alice"}, []byte(`[{"op": "replace", "path": "/title", "value": "b"}]`), 1)
+	require.NoError(t, err)
+
+	select {
+	case result := <-results:
+		require.NotNil(t, result)
+		assert.Equal(t, int64(1), result.SinceVersion)
+		assert.Equal(t, int64(2), result.Version)
+		assert.Len(t, result.Patches, 1)
+		assert.False(t, result.Resync)
+	case <-time.After(time.Second):
+		t.Fatal("提交编辑后长轮询未返回")
+	}
+}
+
+func TestRoom_Poll_Immediate(t *testing.T) {
+	// 测试场景：版本已超过 sinceVersion 时立即返回连续的 Patch；
+	// 超出内存窗口或 sinceVersion 大于当前版本时要求重新同步
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	room := newTestRoom("test-room", []byte(`{"count": 0}`), mockService)
+	for v := int64(1); v <= CatchUpWindow+2; v++ {
+		require.NoError(t, room.ApplyPatch([]byte(`[{"op": "replace", "path": "/count", "value": 1}]`), v))
+	}
+	version := room.Version
+
+	result := room.Poll(context.Background(), version-2)
+	require.NotNil(t, result)
+	assert.Equal(t, version, result.Version)
+	assert.Len(t, result.Patches, 2)
+	assert.False(t, result.Resync)
+
+	for _, since := range []int64{1, version + 1} {
+		result := room.Poll(context.Background(), since)
+		require.NotNil(t, result)
+		assert.True(t, result.Resync, "since=%d", since)
+		assert.Nil(t, result.Patches)
+	}
+}
+
+func TestRoom_Poll_Timeout(t *testing.T) {
+	// 测试场景：ctx 超时或房间停止前没有新版本时返回 nil
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	room := NewRoom("test-room", []byte(`{}`), mockService, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Nil(t, room.Poll(ctx, 1))
+
+	results := make(chan *PollResult, 1)
+	go func() { results <- room.Poll(context.Background(), 1) }()
+	time.Sleep(20 * time.Millisecond)
+	room.Stop()
+
+	select {
+	case result := <-results:
+		assert.Nil(t, result)
+	case <-time.After(time.Second):
+		t.Fatal("房间停止后长轮询未返回")
+	}
+}
@@ -0,0 +1,165 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+
+	"lowercode-go-server/domain/entity"
+)
+
+// PresentationPayload presentation 消息的 payload 结构。
+// 客户端发送时填写 active 和可选的 presenterId（缺省为自己）；
+// 服务端广播时带上演示者信息，跟随者据此只应用演示者的 viewport-update。
+type PresentationPayload struct {
+	Active      bool      `json:"active"`
+	PresenterID string    `json:"presenterId,omitempty"`
+	Presenter   *UserInfo `json:"presenter,omitempty"`
+}
+
+// presentationOp 发往 Room 的演示模式切换请求
+type presentationOp struct {
+	client      *Client
+	active      bool
+	presenterID string
+}
+
+// handlePresentation 处理演示模式切换请求，权限和演示者是否在线由 Room 检查
+func (c *Client) handlePresentation(payload json.RawMessage) {
+	if c.Room == nil {
+		c.sendError(ErrRoomNotFound, c.RoomID)
+		return
+	}
+
+	var presentation PresentationPayload
+	if err := json.Unmarshal(payload, &presentation); err != nil {
+		c.sendError(ErrInvalidMessage, "presentation 格式错误")
+		return
+	}
+
+	c.Room.SetPresentation(c, presentation.Active, presentation.PresenterID)
+}
+
+// SetPresentation 请求开启或结束演示模式，只有页面所有者可以切换
+func (r *Room) SetPresentation(client *Client, active bool, presenterID string) {
+	select {
+	case r.presentOps <- &presentationOp{client: client, active: active, presenterID: presenterID}:
+	case <-r.stopChan:
+	}
+}
+
+// handlePresentationOp 切换演示模式并广播给所有人，仅在 run() 内调用。
+// 开启时收回非演示者持有的组件锁，之后只有演示者可以编辑；重复开启可直接更换演示者
+func (r *Room) handlePresentationOp(op *presentationOp) {
+	if _, ok := r.clients[op.client]; !ok {
+		return
+	}
+	if op.client.UserInfo.Role != entity.RoleOwner {
+		r.sendToClient(op.client, encodeServerMessage(TypeError, ErrorPayload{
+			Code:    ErrUnauthorized,
+			Message: "只有页面所有者可以切换演示模式",
+		}))
+		return
+	}
+
+	if !op.active {
+		r.endPresentation()
+		return
+	}
+
+	presenterID := op.presenterID
+	if presenterID == "" {
+		presenterID = op.client.UserInfo.UserID
+	}
+	presenter := r.findEditor(presenterID)
+	if presenter == nil {
+		r.sendToClient(op.client, encodeServerMessage(TypeError, ErrorPayload{
+			Code:    ErrInvalidMessage,
+			Message: "演示者不在房间内或没有编辑权限",
+		}))
+		return
+	}
+
+	info := presenter.UserInfo
+	r.setPresenter(&info)
+	for client := range r.clients {
+		if client.UserInfo.UserID != presenterID {
+			r.releaseLocksOf(client, LockReasonPresentation)
+		}
+	}
+	r.broadcastPresentation()
+	log.Printf("[Room %s] 演示模式已开启，演示者: [%s]", r.ID, info.UserName)
+}
+
+// findEditor 返回房间内 userID 对应的一个可编辑连接，仅在 run() 内调用
+func (r *Room) findEditor(userID string) *Client {
+	for client := range r.clients {
+		if client.UserInfo.UserID == userID && !client.UserInfo.ReadOnly() {
+			return client
+		}
+	}
+	return nil
+}
+
+// endPresentation 结束演示模式并广播，未处于演示模式时忽略，仅在 run() 内调用
+func (r *Room) endPresentation() {
+	if r.presentationState() == nil {
+		return
+	}
+	r.setPresenter(nil)
+	r.broadcastPresentation()
+	log.Printf("[Room %s] 演示模式已结束", r.ID)
+}
+
+// endPresentationIfPresenterLeft 演示者的最后一个连接离开时结束演示模式，避免房间无人可编辑，仅在 run() 内调用
+func (r *Room) endPresentationIfPresenterLeft(client *Client) {
+	state := r.presentationState()
+	if state == nil || state.Presenter.UserID != client.UserInfo.UserID {
+		return
+	}
+	for c := range r.clients {
+		if c.UserInfo.UserID == client.UserInfo.UserID {
+			return
+		}
+	}
+	r.endPresentation()
+}
+
+// broadcastPresentation 把当前演示模式状态发给房间内所有人，仅在 run() 内调用
+func (r *Room) broadcastPresentation() {
+	payload := PresentationPayload{}
+	if state := r.presentationState(); state != nil {
+		payload = *state
+	}
+	data := encodeServerMessage(TypePresentation, payload)
+	for client := range r.clients {
+		r.sendToClient(client, data)
+	}
+}
+
+// setPresenter 设置演示者，nil 表示结束演示模式，仅在 run() 内调用
+func (r *Room) setPresenter(presenter *UserInfo) {
+	r.stateMu.Lock()
+	r.presenter = presenter
+	r.stateMu.Unlock()
+}
+
+// presentationState 返回当前演示模式状态，未处于演示模式时返回 nil
+func (r *Room) presentationState() *PresentationPayload {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	if r.presenter == nil {
+		return nil
+	}
+	return &PresentationPayload{Active: true, PresenterID: r.presenter.UserID, Presenter: r.presenter}
+}
+
+// MayEdit 判断用户当前能否编辑：未处于演示模式时总是可以，否则只有演示者可以。
+// 不经 WebSocket 提交修改的入口（REST 批量操作、AI 生成、合并等）同样需要检查。
+// 可在任意 goroutine 中调用
+func (r *Room) MayEdit(userID string) bool {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	return r.presenter == nil || r.presenter.UserID == userID
+}
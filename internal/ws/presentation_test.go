@@ -0,0 +1,116 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== 演示模式单元测试 ==========
+
+func TestRoom_Presentation_Start(t *testing.T) {
+	// 测试场景：所有者指定演示者后所有人收到 presentation，非演示者持有的锁被收回，
+	// 非演示者的编辑被拒绝并收到 UNAUTHORIZED，演示者可以正常编辑；sync 中带有演示状态
+
+	room, alice, bob := newSettingsTestRoom(nil)
+	alice.UserInfo.Role = entity.RoleOwner
+	bob.UserInfo.Role = entity.RoleEditor
+	room.locks.acquire("1", alice, time.Now())
+
+	room.handlePresentationOp(&presentationOp{client: alice, active: true, presenterID: "bob"})
+
+	var released LockPayload
+	readMessage(t, bob, TypeLockReleased, &released)
+	assert.Equal(t, LockReasonPresentation, released.Reason)
+	for _, c := range []*Client{alice, bob} {
+		var presentation PresentationPayload
+		if c == alice {
+			readMessage(t, alice, TypeLockReleased, &released)
+		}
+		readMessage(t, c, TypePresentation, &presentation)
+		assert.True(t, presentation.Active)
+		assert.Equal(t, "bob", presentation.Presenter.UserID)
+	}
+	assert.Empty(t, room.locks.snapshot(time.Now()))
+
+	alice.handleMessage(websocket.TextMessage, []byte(`{"type": "op-patch", "payload": {
+		"patches": [{"op": "replace", "path": "/title", "value": "b"}],
+		"version": 1, "clientMsgId": "m1"}}`))
+	var errPayload ErrorPayload
+	readMessage(t, alice, TypeError, &errPayload)
+	assert.Equal(t, ErrUnauthorized, errPayload.Code)
+	assert.Equal(t, "m1", errPayload.ClientMsgID)
+	assert.True(t, room.MayEdit("bob"))
+	assert.False(t, room.MayEdit("alice"))
+
+	data, _ := room.encodeSync(alice)
+	var msg WSMessage
+	assert.NoError(t, json.Unmarshal(data, &msg))
+	var sync SyncPayload
+	assert.NoError(t, json.Unmarshal(msg.Payload, &sync))
+	if assert.NotNil(t, sync.Presentation) {
+		assert.Equal(t, "bob", sync.Presentation.PresenterID)
+	}
+}
+
+func TestRoom_Presentation_Rejected(t *testing.T) {
+	// 测试场景：非所有者切换演示模式收到 UNAUTHORIZED；演示者不在线或为只读协作者时收到 INVALID_MESSAGE，
+	// 房间保持非演示模式
+
+	room, alice, bob := newSettingsTestRoom(nil)
+	alice.UserInfo.Role = entity.RoleOwner
+	bob.UserInfo.Role = entity.RoleViewer
+
+	room.handlePresentationOp(&presentationOp{client: bob, active: true})
+	var errPayload ErrorPayload
+	readMessage(t, bob, TypeError, &errPayload)
+	assert.Equal(t, ErrUnauthorized, errPayload.Code)
+
+	for _, presenterID := range []string{"bob", "carol"} {
+		room.handlePresentationOp(&presentationOp{client: alice, active: true, presenterID: presenterID})
+		readMessage(t, alice, TypeError, &errPayload)
+		assert.Equal(t, ErrInvalidMessage, errPayload.Code, presenterID)
+	}
+
+	assert.Nil(t, room.presentationState())
+	assert.Empty(t, bob.send)
+}
+
+func TestRoom_Presentation_End(t *testing.T) {
+	// 测试场景：所有者结束演示后所有人可以编辑；演示者离开房间时自动结束演示模式
+
+	room, alice, bob := newSettingsTestRoom(nil)
+	alice.UserInfo.Role = entity.RoleOwner
+
+	// 缺省 presenterId 时所有者自己成为演示者
+	room.handlePresentationOp(&presentationOp{client: alice, active: true})
+	var presentation PresentationPayload
+	readMessage(t, alice, TypePresentation, &presentation)
+	readMessage(t, bob, TypePresentation, &presentation)
+	assert.Equal(t, "alice", presentation.PresenterID)
+	assert.False(t, room.MayEdit("bob"))
+
+	room.handlePresentationOp(&presentationOp{client: alice, active: false})
+	readMessage(t, alice, TypePresentation, &presentation)
+	presentation = PresentationPayload{}
+	readMessage(t, bob, TypePresentation, &presentation)
+	assert.False(t, presentation.Active)
+	assert.Nil(t, presentation.Presenter)
+	assert.True(t, room.MayEdit("bob"))
+
+	room.handlePresentationOp(&presentationOp{client: alice, active: true, presenterID: "bob"})
+	readMessage(t, alice, TypePresentation, &presentation)
+	assert.Equal(t, "bob", presentation.PresenterID)
+
+	room.removeClient(bob)
+	var leave UserInfo
+	readMessage(t, alice, TypeUserLeave, &leave)
+	readMessage(t, alice, TypePresentation, &presentation)
+	assert.False(t, presentation.Active)
+	assert.True(t, room.MayEdit("alice"))
+}
@@ -0,0 +1,155 @@
+package ws
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== ReadPump / WritePump 单元测试 ==========
+// 测试重点：不经过真实连接，用 MockTransport 覆盖读写协程的异常路径
+
+// newPumpTestClient 创建使用 MockTransport 的客户端并加入未启动事件循环的房间
+func newPumpTestClient(t *testing.T, cfg ConnConfig) (*Client, *MockTransport, *Room) {
+	t.Helper()
+	room := newTestRoom("test-room", []byte(`{"title": "a"}`), new(MockPageService))
+	transport := NewMockTransport()
+	hub := NewHub(new(MockPageService), WithConnConfig(cfg))
+	client := NewClient(hub, transport, room.ID, UserInfo{UserID: "alice", UserName: "Alice"})
+	client.Room = room
+	return client, transport, room
+}
+
+// pumpTestConfig 测试用的短心跳配置
+var pumpTestConfig = ConnConfig{
+	PongWait:       100 * time.Millisecond,
+	PingPeriod:     30 * time.Millisecond,
+	WriteWait:      time.Second,
+	MaxMessageSize: 1024,
+	SendBufferSize: 4,
+}
+
+// expectUnregister 等待 ReadPump 退出时注销客户端
+func expectUnregister(t *testing.T, room *Room, client *Client) {
+	t.Helper()
+	select {
+	case got := <-room.unregister:
+		assert.Same(t, client, got)
+	case <-time.After(time.Second):
+		t.Fatal("ReadPump 未注销客户端")
+	}
+}
+
+func TestReadPump_MalformedMessage(t *testing.T) {
+	// 测试场景：无法解码的二进制帧收到 INVALID_MESSAGE，非法 JSON 被忽略，连接保持并继续处理后续消息
+
+	client, transport, room := newPumpTestClient(t, pumpTestConfig)
+	client.Codec = MsgPackCodec
+	go client.ReadPump()
+
+	transport.Receive(websocket.BinaryMessage, []byte{0xc1})
+	var decodeErr ErrorPayload
+	assert.Equal(t, TypeError, readTestMessage(t, client, &decodeErr))
+	assert.Equal(t, ErrInvalidMessage, decodeErr.Code)
+
+	transport.Receive(websocket.TextMessage, []byte(`{not json`))
+	transport.Receive(websocket.TextMessage, []byte(`{"type": "lock-component", "payload": {}}`))
+	var lockErr ErrorPayload
+	assert.Equal(t, TypeError, readTestMessage(t, client, &lockErr))
+	assert.Equal(t, "componentId 不能为空", lockErr.Message)
+	assert.False(t, transport.IsClosed())
+
+	transport.Close()
+	expectUnregister(t, room, client)
+}
+
+func TestReadPump_OversizedMessage(t *testing.T) {
+	// 测试场景：超过 MaxMessageSize 的消息导致读失败，客户端被注销且连接关闭
+
+	client, transport, room := newPumpTestClient(t, pumpTestConfig)
+	go client.ReadPump()
+
+	transport.Receive(websocket.TextMessage, []byte(`{"type": "chat", "payload": {"text": "`+strings.Repeat("x", 2048)+`"}}`))
+
+	expectUnregister(t, room, client)
+	assert.Eventually(t, transport.IsClosed, time.Second, 10*time.Millisecond)
+	assert.Empty(t, client.send)
+}
+
+func TestReadPump_HeartbeatExpiry(t *testing.T) {
+	// 测试场景：持续收到 Pong 时连接保持；停止回应后超过 PongWait 被注销并关闭
+
+	client, transport, room := newPumpTestClient(t, pumpTestConfig)
+	go client.ReadPump()
+
+	for i := 0; i < 9; i++ {
+		time.Sleep(pumpTestConfig.PongWait / 3)
+		transport.Pong()
+	}
+	assert.False(t, transport.IsClosed())
+
+	expectUnregister(t, room, client)
+	assert.Eventually(t, transport.IsClosed, time.Second, 10*time.Millisecond)
+}
+
+func TestWritePump_Heartbeat(t *testing.T) {
+	// 测试场景：WritePump 按 PingPeriod 发送 Ping，消息按原样写出
+
+	client, transport, _ := newPumpTestClient(t, pumpTestConfig)
+	go client.WritePump()
+	defer close(client.send)
+
+	client.send <- []byte(`{"type": "cursor-move"}`)
+	frame := <-transport.outbound
+	assert.Equal(t, websocket.TextMessage, frame.frameType)
+	assert.JSONEq(t, `{"type": "cursor-move"}`, string(frame.data))
+
+	select {
+	case frame := <-transport.outbound:
+		assert.Equal(t, websocket.PingMessage, frame.frameType)
+	case <-time.After(time.Second):
+		t.Fatal("未发送 Ping")
+	}
+}
+
+func TestWritePump_SendBufferOverflow(t *testing.T) {
+	// 测试场景：发送缓冲区写满时非关键消息被丢弃；关键消息阻塞时客户端被移出房间，
+	// WritePump 写完已缓冲的消息后发送关闭帧并关闭连接
+
+	client, transport, room := newPumpTestClient(t, pumpTestConfig)
+	room.clients[client] = true
+
+	for i := 0; i < pumpTestConfig.SendBufferSize+2; i++ {
+		room.deliver(&RoomBroadcast{Message: []byte(`{"type": "cursor-move"}`)})
+	}
+	assert.Len(t, client.send, pumpTestConfig.SendBufferSize)
+	assert.True(t, room.clients[client])
+
+	room.deliver(&RoomBroadcast{Message: []byte(`{"type": "op-patch"}`), IsCritical: true})
+	assert.NotContains(t, room.clients, client)
+
+	go client.WritePump()
+	for i := 0; i < pumpTestConfig.SendBufferSize; i++ {
+		assert.Equal(t, websocket.TextMessage, (<-transport.outbound).frameType)
+	}
+	assert.Equal(t, websocket.CloseMessage, (<-transport.outbound).frameType)
+	assert.Eventually(t, transport.IsClosed, time.Second, 10*time.Millisecond)
+}
+
+func TestWritePump_WriteError(t *testing.T) {
+	// 测试场景：写失败时 WritePump 关闭连接，ReadPump 随之读失败并注销客户端
+
+	client, transport, room := newPumpTestClient(t, pumpTestConfig)
+	transport.FailWrites(errors.New("broken pipe"))
+	go client.ReadPump()
+	go client.WritePump()
+
+	client.send <- []byte(`{"type": "cursor-move"}`)
+
+	expectUnregister(t, room, client)
+	assert.True(t, transport.IsClosed())
+}
@@ -0,0 +1,134 @@
+package ws
+
+import (
+	"encoding/json"
+	"expvar"
+	"time"
+
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/ratelimit"
+)
+
+// violationRecoveryRate 被限流消息的容忍量每秒恢复的条数
+const violationRecoveryRate = 1
+
+// rateLimitMetrics 被限流丢弃的消息数（按类型）与因持续超限被断开的连接数，通过 expvar 暴露为 ws_rate_limit
+var rateLimitMetrics = expvar.NewMap("ws_rate_limit")
+
+// RateLimitConfig 单个连接的入站消息限流，编辑与光标分别计算预算，速率为 0 时不限制对应类型
+type RateLimitConfig struct {
+	EditRate    float64 `json:"editRate"` // op-patch、text-op 每秒允许的条数
+	EditBurst   int     `json:"editBurst"`
+	CursorRate  float64 `json:"cursorRate"` // cursor-move 每秒允许的条数
+	CursorBurst int     `json:"cursorBurst"`
+
+	// MaxViolations 被限流消息的容忍量，每秒恢复 1 条，耗尽后断开连接；为 0 时只限流不断开
+	MaxViolations int `json:"maxViolations"`
+}
+
+// DefaultRateLimit 默认限流配置，正常编辑和 60fps 以内节流后的光标不会触发
+var DefaultRateLimit = RateLimitConfig{
+	EditRate:      20,
+	EditBurst:     40,
+	CursorRate:    30,
+	CursorBurst:   60,
+	MaxViolations: 50,
+}
+
+// WithRateLimit 设置新连接的入站消息限流，只影响之后建立的连接
+func WithRateLimit(cfg RateLimitConfig) HubOption {
+	return func(h *Hub) {
+		h.rateLimit = &cfg
+	}
+}
+
+// clientLimiter 单个连接的令牌桶，只在 ReadPump 中访问；各 Bucket 为 nil 时不限制
+type clientLimiter struct {
+	edit       *ratelimit.Bucket
+	cursor     *ratelimit.Bucket
+	violations *ratelimit.Bucket
+}
+
+// newClientLimiter 按配置创建令牌桶
+func newClientLimiter(cfg RateLimitConfig) *clientLimiter {
+	l := &clientLimiter{}
+	if cfg.EditRate > 0 {
+		l.edit = ratelimit.NewBucket(cfg.EditRate, cfg.EditBurst)
+	}
+	if cfg.CursorRate > 0 {
+		l.cursor = ratelimit.NewBucket(cfg.CursorRate, cfg.CursorBurst)
+	}
+	if cfg.MaxViolations > 0 {
+		l.violations = ratelimit.NewBucket(violationRecoveryRate, cfg.MaxViolations)
+	}
+	return l
+}
+
+// allow 判断消息是否在预算内；超出预算时 disconnect 表示容忍量已耗尽，应断开连接
+func (l *clientLimiter) allow(msgType MessageType, now time.Time) (allowed, disconnect bool) {
+	if l == nil {
+		return true, false
+	}
+
+	var bucket *ratelimit.Bucket
+	switch msgType {
+	case TypeOpPatch, TypeTextOp:
+		bucket = l.edit
+	case TypeCursorMove:
+		bucket = l.cursor
+	}
+	if bucket == nil {
+		return true, false
+	}
+	if ok, _ := bucket.Take(now); ok {
+		return true, false
+	}
+
+	rateLimitMetrics.Add("dropped_"+string(msgType), 1)
+	if l.violations == nil {
+		return false, false
+	}
+	ok, _ := l.violations.Take(now)
+	return false, !ok
+}
+
+// rejectRateLimited 告知客户端编辑因限流被丢弃，带回 clientMsgId 供前端回滚乐观更新；光标直接丢弃
+func (c *Client) rejectRateLimited(msg WSMessage) {
+	switch msg.Type {
+	case TypeOpPatch:
+		var payload OpPatchPayload
+		json.Unmarshal(msg.Payload, &payload)
+		if len(payload.ClientMsgID) > MaxClientMsgIDLength {
+			payload.ClientMsgID = ""
+		}
+		c.sendOpError(payload.ClientMsgID, ErrRateLimited, "编辑过于频繁，该修改已被丢弃")
+	case TypeTextOp:
+		c.sendError(ErrRateLimited, "编辑过于频繁，该修改已被丢弃")
+	}
+}
+
+// disconnectRateLimited 持续超限时通知客户端并断开连接，在 ReadPump 中调用，返回后 ReadPump 退出。
+// 先注销，房间关闭发送通道后 WritePump 发出错误和关闭帧并关闭连接；
+// 在此之前继续读取并丢弃消息，避免 ReadPump 提前关闭连接导致错误未送达。发送缓冲区已满时放弃通知
+func (c *Client) disconnectRateLimited() {
+	select {
+	case c.send <- encodeServerMessage(TypeError, ErrorPayload{
+		Code:    ErrRateLimited,
+		Message: "消息发送过于频繁，连接已断开",
+	}):
+	default:
+	}
+	rateLimitMetrics.Add("disconnected", 1)
+	logging.Warnf("[Client] 用户 [%s] 持续超出消息速率限制，断开连接", c.UserInfo.UserName)
+
+	if c.Room == nil {
+		return
+	}
+	c.Room.Unregister(c)
+	c.Transport.SetReadDeadline(time.Now().Add(c.connConfig().WriteWait))
+	for {
+		if _, _, err := c.Transport.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 入站消息限流单元测试 ==========
+
+func TestClientLimiter_Allow(t *testing.T) {
+	// 测试场景：编辑与光标分别计算预算，其他消息不限流；
+	// 超限消息耗尽容忍量后要求断开，预算随时间恢复
+
+	l := newClientLimiter(RateLimitConfig{EditRate: 1, EditBurst: 2, CursorRate: 1, CursorBurst: 3, MaxViolations: 2})
+	now := time.Now()
+	allow := func(msgType MessageType) (bool, bool) { return l.allow(msgType, now) }
+
+	for i := 0; i < 2; i++ {
+		ok, _ := allow(TypeOpPatch)
+		assert.True(t, ok)
+	}
+	ok, disconnect := allow(TypeTextOp)
+	assert.False(t, ok)
+	assert.False(t, disconnect)
+
+	// 编辑预算耗尽不影响光标，锁等其他消息不限流
+	for i := 0; i < 3; i++ {
+		ok, _ := allow(TypeCursorMove)
+		assert.True(t, ok)
+	}
+	for i := 0; i < 10; i++ {
+		ok, _ := allow(TypeLockComponent)
+		assert.True(t, ok)
+	}
+
+	ok, disconnect = allow(TypeCursorMove)
+	assert.False(t, ok)
+	assert.False(t, disconnect)
+	ok, disconnect = allow(TypeOpPatch)
+	assert.False(t, ok)
+	assert.True(t, disconnect)
+
+	now = now.Add(time.Second)
+	ok, _ = allow(TypeOpPatch)
+	assert.True(t, ok)
+
+	// 速率为 0 时不限制，未创建 limiter 时不限制
+	unlimited := newClientLimiter(RateLimitConfig{})
+	var none *clientLimiter
+	for i := 0; i < 100; i++ {
+		ok, _ := unlimited.allow(TypeOpPatch, now)
+		assert.True(t, ok)
+		ok, _ = none.allow(TypeCursorMove, now)
+		assert.True(t, ok)
+	}
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	// 测试场景：超出编辑预算的 op-patch 被丢弃并收到带 clientMsgId 的 RATE_LIMITED，
+	// 持续超限后收到 RATE_LIMITED 并被断开，其他用户收到 user-leave
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	room := NewRoom("test-room", []byte(`{"count": 0}`), mockService, nil)
+	defer room.Stop()
+	hub := NewHub(mockService, WithRateLimit(RateLimitConfig{EditRate: 0.001, EditBurst: 1, MaxViolations: 3}))
+	assert.Equal(t, 3, hub.Limits().RateLimit.MaxViolations)
+
+	var upgrader websocket.Upgrader
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := NewClient(hub, conn, room.ID, UserInfo{UserID: r.URL.Query().Get("user")})
+		require.NoError(t, room.Register(client))
+		go client.WritePump()
+		go client.ReadPump()
+	}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	abuser, _, err := websocket.DefaultDialer.Dial(url+"?user=mallory", nil)
+	require.NoError(t, err)
+	defer abuser.Close()
+	readFrameOfType(t, abuser, TypeSync)
+	other, _, err := websocket.DefaultDialer.Dial(url+"?user=bob", nil)
+	require.NoError(t, err)
+	defer other.Close()
+	readFrameOfType(t, other, TypeSync)
+
+	sendPatch := func(version int, msgID string) {
+		require.NoError(t, abuser.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(
+			`{"type": "op-patch", "payload": {"patches": [{"op": "replace", "path": "/count", "value": %d}], "version": %d, "clientMsgId": %q}}`,
+			version, version, msgID))))
+	}
+
+	sendPatch(1, "m-1")
+	_, ack := readFrameOfType(t, abuser, TypeAck)
+	assert.Contains(t, string(ack.Payload), `"m-1"`)
+
+	for i := 2; i <= 4; i++ {
+		msgID := fmt.Sprintf("m-%d", i)
+		sendPatch(2, msgID)
+		_, msg := readFrameOfType(t, abuser, TypeError)
+		var payload ErrorPayload
+		require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+		assert.Equal(t, ErrorPayload{Code: ErrRateLimited, Message: payload.Message, ClientMsgID: msgID}, payload)
+	}
+	assert.Equal(t, int64(2), room.Version)
+
+	// 容忍量耗尽，收到不带 clientMsgId 的 RATE_LIMITED 后连接关闭
+	sendPatch(2, "m-5")
+	_, msg := readFrameOfType(t, abuser, TypeError)
+	var payload ErrorPayload
+	require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, ErrRateLimited, payload.Code)
+	assert.Empty(t, payload.ClientMsgID)
+
+	abuser.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err = abuser.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNoStatusReceived), "%v", err)
+
+	_, leave := readFrameOfType(t, other, TypeUserLeave)
+	assert.Contains(t, string(leave.Payload), "mallory")
+	assert.Equal(t, int64(2), room.Version)
+}
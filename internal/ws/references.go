@@ -0,0 +1,180 @@
+package ws
+
+import (
+	"sync"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/pageref"
+)
+
+// referenceQueueSize 待索引队列容量
+const referenceQueueSize = 256
+
+// ReferenceStore 页面引用索引持久化接口，由 repository 层实现
+type ReferenceStore interface {
+	ReplaceForPage(sourcePageID string, refs []*entity.PageReference) error
+	MissingPages(pageIDs []string) ([]string, error)
+}
+
+// WithReferenceIndex 启用页面引用索引：房间刷盘成功后异步重建页面的出站引用，并检查失效的引用
+func WithReferenceIndex(ix *ReferenceIndexer) HubOption {
+	return func(h *Hub) {
+		h.references = ix
+	}
+}
+
+// referenceJob 待索引的页面状态
+type referenceJob struct {
+	pageID string
+	state  []byte
+}
+
+// ReferenceIndexer 异步重建页面引用索引。
+// Room 在刷盘路径中调用 Index，因此必须非阻塞；引用没有变化时不写数据库
+type ReferenceIndexer struct {
+	store ReferenceStore
+	queue chan referenceJob
+
+	mu     sync.RWMutex // 保护 closed，保证 Close 之后不再入队
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+
+	indexed map[string]string // 页面 -> 上次写入的引用签名，只在 Run 中访问
+}
+
+// NewReferenceIndexer 创建 ReferenceIndexer，需另起 goroutine 调用 Run
+func NewReferenceIndexer(store ReferenceStore) *ReferenceIndexer {
+	return &ReferenceIndexer{
+		store:   store,
+		queue:   make(chan referenceJob, referenceQueueSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		indexed: make(map[string]string),
+	}
+}
+
+// Index 提交页面已落盘的状态（非阻塞），队列已满或已关闭时丢弃，下次刷盘时重新索引
+func (ix *ReferenceIndexer) Index(pageID string, state []byte) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	if ix.closed {
+		return
+	}
+
+	select {
+	case ix.queue <- referenceJob{pageID: pageID, state: state}:
+	default:
+		logging.Warnf("[References] 队列已满，跳过页面 %s 的引用索引", pageID)
+	}
+}
+
+// Run 索引循环，阻塞直到 Close 被调用且队列清空
+func (ix *ReferenceIndexer) Run() {
+	defer close(ix.done)
+
+	for {
+		select {
+		case job := <-ix.queue:
+			ix.index(job)
+		case <-ix.stop:
+			// Close 之后不会再有新任务入队，取空队列后退出
+			for {
+				select {
+				case job := <-ix.queue:
+					ix.index(job)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close 停止接收新任务，等待剩余任务完成。应在所有 Room 停止之后调用。
+func (ix *ReferenceIndexer) Close() {
+	ix.mu.Lock()
+	if !ix.closed {
+		ix.closed = true
+		close(ix.stop)
+	}
+	ix.mu.Unlock()
+
+	<-ix.done
+}
+
+// index 提取页面的出站引用，有变化时写入索引，并对指向不存在页面的引用告警
+func (ix *ReferenceIndexer) index(job referenceJob) {
+	refs, err := pageref.Find(job.state)
+	if err != nil {
+		logging.Errorf("[References] 解析页面 %s 的引用失败: %v", job.pageID, err)
+		return
+	}
+
+	signature := referenceSignature(refs)
+	if prev, ok := ix.indexed[job.pageID]; ok && prev == signature {
+		return
+	}
+
+	rows := make([]*entity.PageReference, len(refs))
+	for i, ref := range refs {
+		rows[i] = &entity.PageReference{
+			SourcePageID: job.pageID,
+			TargetPageID: ref.PageID,
+			Path:         ref.Path,
+			ComponentID:  ref.ComponentID,
+			Kind:         ref.Kind,
+		}
+	}
+	if err := ix.store.ReplaceForPage(job.pageID, rows); err != nil {
+		logging.Errorf("[References] 写入页面 %s 的 %d 个引用失败: %v", job.pageID, len(rows), err)
+		return
+	}
+	ix.indexed[job.pageID] = signature
+
+	missing, err := ix.store.MissingPages(pageref.Targets(refs))
+	if err != nil {
+		logging.Errorf("[References] 检查页面 %s 的引用目标失败: %v", job.pageID, err)
+		return
+	}
+	if len(missing) > 0 {
+		logging.Warnf("[References] 页面 %s 引用了不存在的页面 %v，发布前需要修复", job.pageID, missing)
+	}
+}
+
+// referenceSignature 引用列表的签名，用于跳过没有变化的索引写入
+func referenceSignature(refs []pageref.Ref) string {
+	var sig []byte
+	for _, ref := range refs {
+		sig = append(sig, ref.Path...)
+		sig = append(sig, 0)
+		sig = append(sig, ref.PageID...)
+		sig = append(sig, 0)
+		sig = append(sig, ref.Kind...)
+		sig = append(sig, 0)
+	}
+	return string(sig)
+}
+
+// indexReferences 刷盘成功后把页面状态交给引用索引。
+// 状态中没有引用、且上次索引时也没有时跳过，避免每次刷盘都复制全量状态
+func (r *Room) indexReferences() {
+	if r.references == nil {
+		return
+	}
+
+	r.stateMu.Lock()
+	hasRefs := pageref.Contains(r.CurrentState)
+	var state []byte
+	if hasRefs || r.hadReferences {
+		state = append([]byte(nil), r.CurrentState...)
+	}
+	r.hadReferences = hasRefs
+	r.stateMu.Unlock()
+
+	if state != nil {
+		r.references.Index(r.ID, state)
+	}
+}
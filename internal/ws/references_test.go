@@ -0,0 +1,93 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 页面引用索引单元测试 ==========
+// 测试重点：刷盘后重建出站引用，引用没有变化时不重复写入，引用全部删除后清空索引
+
+func TestReferenceIndexer_Index(t *testing.T) {
+	// 测试场景：同一状态索引两次只写入一次；引用被删除后写入空列表
+
+	store := &MockReferenceStore{pages: map[string]bool{"page-2": true}}
+	ix := NewReferenceIndexer(store)
+
+	state := []byte(`{"components": {"3": {"props": {"link": {"$page": "page-2"}, "embed": {"$page": "gone"}}}}}`)
+	ix.index(referenceJob{pageID: "page-1", state: state})
+	ix.index(referenceJob{pageID: "page-1", state: state})
+	assert.Equal(t, 1, store.Writes())
+
+	refs := store.Refs("page-1")
+	require.Len(t, refs, 2)
+	assert.Equal(t, "gone", refs[0].TargetPageID)
+	assert.Equal(t, "/components/3/props/embed", refs[0].Path)
+	assert.Equal(t, int64(3), refs[0].ComponentID)
+	assert.Equal(t, "page-2", refs[1].TargetPageID)
+
+	ix.index(referenceJob{pageID: "page-1", state: []byte(`{"components": {}}`)})
+	assert.Equal(t, 2, store.Writes())
+	assert.Empty(t, store.Refs("page-1"))
+}
+
+func TestReferenceIndexer_CloseDrainsQueue(t *testing.T) {
+	// 测试场景：Close 前提交的任务全部完成，Close 之后提交的任务被丢弃
+
+	store := &MockReferenceStore{}
+	ix := NewReferenceIndexer(store)
+	ix.Index("page-1", []byte(`{"a": {"$page": "x"}}`))
+	ix.Index("page-2", []byte(`{"b": {"$page": "y"}}`))
+
+	go ix.Run()
+	ix.Close()
+	ix.Index("page-3", []byte(`{"c": {"$page": "z"}}`))
+
+	assert.Equal(t, 2, store.Writes())
+	assert.Len(t, store.Refs("page-1"), 1)
+	assert.Len(t, store.Refs("page-2"), 1)
+	assert.Nil(t, store.Refs("page-3"))
+}
+
+func TestRoom_FlushIndexesReferences(t *testing.T) {
+	// 测试场景：新房间首次刷盘时即使没有引用也要索引（清理旧记录）；
+	// 之后没有引用的刷盘不再提交，加入引用后重新提交
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", "page-1", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	store := &MockReferenceStore{pages: map[string]bool{"page-2": true}}
+	ix := NewReferenceIndexer(store)
+	hub := NewHub(mockService, WithReferenceIndex(ix))
+	room := NewRoom("page-1", []byte(`{"title": "a"}`), mockService, hub)
+	room.lastPersistedVersion = 1
+	alice := &Client{UserInfo: UserInfo{UserID: "alice"}, send: make(chan []byte, 16)}
+	require.NoError(t, room.Register(alice))
+
+	_, err := room.ApplyEdit(alice.UserInfo, []byte(`[{"op": "replace", "path": "/title", "value": "b"}]`), 1)
+	require.NoError(t, err)
+	room.flushToDB("测试")
+	assert.Len(t, ix.queue, 1)
+
+	_, err = room.ApplyEdit(alice.UserInfo, []byte(`[{"op": "replace", "path": "/title", "value": "c"}]`), 2)
+	require.NoError(t, err)
+	room.flushToDB("测试")
+	assert.Len(t, ix.queue, 1)
+
+	_, err = room.ApplyEdit(alice.UserInfo, []byte(`[{"op": "add", "path": "/link", "value": {"$page": "page-2"}}]`), 3)
+	require.NoError(t, err)
+	room.flushToDB("测试")
+	assert.Len(t, ix.queue, 2)
+
+	room.Stop()
+	go ix.Run()
+	ix.Close()
+
+	refs := store.Refs("page-1")
+	require.Len(t, refs, 1)
+	assert.Equal(t, "page-2", refs[0].TargetPageID)
+	assert.Equal(t, "/link", refs[0].Path)
+}
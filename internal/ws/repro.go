@@ -0,0 +1,198 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// ReproBundleFormat 复现包的格式版本，回放时拒绝不认识的版本
+const ReproBundleFormat = 1
+
+// ReproBundle 可移植的复现包：某个版本的全量快照，以及之后按顺序应用的操作。
+// 在新房间中依次回放 Ops 应得到与 State 一致的状态，用于确定性地复现用户反馈的页面数据损坏
+type ReproBundle struct {
+	Format      int             `json:"format"`
+	PageID      string          `json:"pageId"`
+	BaseVersion int64           `json:"baseVersion"` // Snapshot 对应的版本
+	Snapshot    json.RawMessage `json:"snapshot"`
+	Ops         []ArchivedOp    `json:"ops"`     // (BaseVersion, Version] 内的操作，按版本升序
+	Version     int64           `json:"version"` // 导出时的内存版本
+	State       json.RawMessage `json:"state"`   // 导出时的内存状态
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+// WithReproJournal 启用复现日志：房间在内存中保留最近 window ~ 2*window 个操作及其之前的快照，
+// 可通过 Hub.ReproBundle 导出为复现包。每个房间额外占用两份页面快照的内存
+func WithReproJournal(window int) HubOption {
+	return func(h *Hub) {
+		h.reproWindow = window
+	}
+}
+
+// reproJournal 房间内存中的复现日志，受 Room.stateMu 保护。
+// 操作数达到 2*window 时丢弃较早的一半，并以第 window 个操作之后的快照作为新的起点，
+// 因此总能导出至少最近 window 个操作（房间创建后不足 window 个时为全部操作）
+type reproJournal struct {
+	window      int
+	baseVersion int64
+	base        []byte
+	ops         []*entity.PageOp
+	mid         []byte // ops 中第 window 个操作应用后的状态，成为下一个 base
+}
+
+// newReproJournal 以房间加载时的状态作为起点创建复现日志
+func newReproJournal(window int, state []byte, version int64) *reproJournal {
+	return &reproJournal{
+		window:      window,
+		baseVersion: version,
+		base:        append([]byte(nil), state...),
+	}
+}
+
+// record 追加一条已应用的操作，state 为应用后的状态
+func (j *reproJournal) record(op *entity.PageOp, state []byte) {
+	j.ops = append(j.ops, op)
+
+	switch len(j.ops) {
+	case j.window:
+		j.mid = append([]byte(nil), state...)
+	case 2 * j.window:
+		j.base, j.baseVersion = j.mid, j.ops[j.window-1].Version
+		j.ops = append([]*entity.PageOp(nil), j.ops[j.window:]...)
+		j.mid = append([]byte(nil), state...)
+	}
+}
+
+// ReproBundle 导出房间的复现包。
+// 房间不在本实例内存中时返回 ErrRoomNotFound，未启用复现日志时返回 ErrReproDisabled
+func (h *Hub) ReproBundle(pageID string) (*ReproBundle, error) {
+	if h.reproWindow <= 0 {
+		return nil, domainErrors.ErrReproDisabled
+	}
+	room := h.GetRoom(pageID)
+	if room == nil {
+		return nil, domainErrors.ErrRoomNotFound
+	}
+	return room.ReproBundle()
+}
+
+// ReproBundle 导出房间当前的复现包，未启用复现日志时返回 ErrReproDisabled
+func (r *Room) ReproBundle() (*ReproBundle, error) {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	if r.repro == nil {
+		return nil, domainErrors.ErrReproDisabled
+	}
+
+	ops := make([]ArchivedOp, len(r.repro.ops))
+	for i, op := range r.repro.ops {
+		ops[i] = ArchivedOp{
+			Version:   op.Version,
+			Patch:     append(json.RawMessage(nil), op.Patch...),
+			AuthorID:  op.AuthorID,
+			Guest:     op.Guest,
+			Label:     op.Label,
+			CreatedAt: op.CreatedAt,
+		}
+	}
+	return &ReproBundle{
+		Format:      ReproBundleFormat,
+		PageID:      r.ID,
+		BaseVersion: r.repro.baseVersion,
+		Snapshot:    append(json.RawMessage(nil), r.repro.base...),
+		Ops:         ops,
+		Version:     r.Version,
+		State:       append(json.RawMessage(nil), r.CurrentState...),
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// ReplayResult 复现包的回放结果
+type ReplayResult struct {
+	Applied       int             // 成功应用的操作数
+	Version       int64           // 回放结束时的版本
+	State         json.RawMessage // 回放结束时的状态
+	FailedVersion int64           // 应用失败的操作版本，全部成功时为 0
+	Err           error           // 应用失败的原因
+	Match         bool            // 回放全部操作后与导出时的状态一致
+}
+
+// Replay 在一个新房间中从快照开始依次回放复现包中的操作，直到 until 版本（为 0 时回放全部）。
+// 操作按记录时的原样应用（已包含服务端追加的编辑归属），不再做版本和 editedBy 校验。
+// 复现包本身不完整（格式不认识、快照无效或版本不连续）时返回错误
+func Replay(bundle *ReproBundle, until int64) (*ReplayResult, error) {
+	if bundle.Format != ReproBundleFormat {
+		return nil, fmt.Errorf("不支持的复现包格式 %d", bundle.Format)
+	}
+	if !json.Valid(bundle.Snapshot) {
+		return nil, fmt.Errorf("复现包快照不是有效的 JSON")
+	}
+	for i, op := range bundle.Ops {
+		if op.Version != bundle.BaseVersion+int64(i)+1 {
+			return nil, fmt.Errorf("复现包操作版本不连续: 第 %d 个操作的版本为 %d，应为 %d",
+				i+1, op.Version, bundle.BaseVersion+int64(i)+1)
+		}
+	}
+
+	room := NewRoom(bundle.PageID, append([]byte(nil), bundle.Snapshot...), replayPageService{}, nil)
+	room.Version = bundle.BaseVersion
+	room.lastPersistedVersion = bundle.BaseVersion
+	room.loadedVersion = bundle.BaseVersion
+	defer room.Stop()
+
+	result := &ReplayResult{}
+	for _, op := range bundle.Ops {
+		if until > 0 && op.Version > until {
+			break
+		}
+		if err := room.replayOp(op.Patch); err != nil {
+			result.FailedVersion, result.Err = op.Version, err
+			break
+		}
+		result.Applied++
+	}
+
+	result.State, result.Version = room.GetSnapshot()
+	result.Match = result.Err == nil && result.Version == bundle.Version &&
+		jsonpatch.Equal(result.State, bundle.State)
+	return result, nil
+}
+
+// replayOp 原样应用一条记录的 Patch 并推进版本
+func (r *Room) replayOp(patchBytes []byte) error {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	patch, err := jsonpatch.DecodePatch(patchBytes)
+	if err != nil {
+		return &PatchError{Reason: fmt.Sprintf("patch 解析失败: %v", err)}
+	}
+	modified, err := patch.Apply(r.CurrentState)
+	if err != nil {
+		return &PatchError{Reason: fmt.Sprintf("patch 应用失败: %v", err)}
+	}
+
+	r.CurrentState = modified
+	r.Version++
+	r.rememberPatchLocked(patchBytes, "")
+	r.invalidateTextDocs(patch)
+	return nil
+}
+
+// replayPageService 回放用的房间不读写数据库
+type replayPageService struct{}
+
+func (replayPageService) GetPageState(string) ([]byte, int64, error) {
+	return nil, 0, domainErrors.ErrPageNotFound
+}
+
+func (replayPageService) PageExists(string) (bool, error) { return false, nil }
+
+func (replayPageService) SavePageState(string, []byte, int64, int64) error { return nil }
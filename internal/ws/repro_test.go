@@ -0,0 +1,139 @@
+package ws
+
+import (
+	"fmt"
+	"testing"
+
+	domainErrors "lowercode-go-server/domain/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 复现包单元测试 ==========
+
+// newReproTestRoom 通过启用了复现日志的 Hub 加载版本 10 的页面
+func newReproTestRoom(t *testing.T, window int) (*Hub, *Room) {
+	t.Helper()
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", "page-1").Return([]byte(attributionState), int64(10), nil).Once()
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	hub := NewHub(mockService, WithReproJournal(window))
+	room, err := hub.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	t.Cleanup(room.Stop)
+	return hub, room
+}
+
+// editDesc 以 alice 的身份修改组件 2 的描述，服务端会追加编辑归属
+func editDesc(t *testing.T, room *Room, n int) {
+	t.Helper()
+	_, version := room.GetSnapshot()
+	patch := fmt.Sprintf(`[{"op": "replace", "path": "/components/2/desc", "value": "v%d"}]`, n)
+	_, err := room.ApplyEdit(UserInfo{UserID: "alice", UserName: "Alice"}, []byte(patch), version)
+	require.NoError(t, err)
+}
+
+func TestHub_ReproBundle(t *testing.T) {
+	// 测试场景：复现包包含加载时的快照和之后的全部操作，回放后与导出时的状态一致
+
+	hub, room := newReproTestRoom(t, 3)
+	editDesc(t, room, 1)
+	editDesc(t, room, 2)
+
+	bundle, err := hub.ReproBundle("page-1")
+	require.NoError(t, err)
+	assert.Equal(t, ReproBundleFormat, bundle.Format)
+	assert.Equal(t, int64(10), bundle.BaseVersion)
+	assert.JSONEq(t, attributionState, string(bundle.Snapshot))
+	require.Len(t, bundle.Ops, 2)
+	assert.Equal(t, int64(11), bundle.Ops[0].Version)
+	assert.Equal(t, "alice", bundle.Ops[0].AuthorID)
+	assert.Equal(t, int64(12), bundle.Version)
+
+	result, err := Replay(bundle, 0)
+	require.NoError(t, err)
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 2, result.Applied)
+	assert.Equal(t, int64(12), result.Version)
+	assert.True(t, result.Match)
+	assert.True(t, hub.Features().ReproJournal)
+}
+
+func TestHub_ReproBundle_Rebase(t *testing.T) {
+	// 测试场景：操作数达到 2*window 时丢弃较早的一半并以中间快照为起点，始终至少保留 window 个操作
+
+	hub, room := newReproTestRoom(t, 3)
+	for i := 1; i <= 7; i++ {
+		editDesc(t, room, i)
+	}
+
+	bundle, err := hub.ReproBundle("page-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(13), bundle.BaseVersion)
+	require.Len(t, bundle.Ops, 4)
+	assert.Equal(t, int64(14), bundle.Ops[0].Version)
+	assert.Equal(t, int64(17), bundle.Version)
+
+	result, err := Replay(bundle, 0)
+	require.NoError(t, err)
+	assert.True(t, result.Match)
+}
+
+func TestHub_ReproBundle_Unavailable(t *testing.T) {
+	// 测试场景：房间不在内存中返回 ErrRoomNotFound，未启用复现日志返回 ErrReproDisabled
+
+	hub, _ := newReproTestRoom(t, 3)
+	_, err := hub.ReproBundle("page-2")
+	assert.ErrorIs(t, err, domainErrors.ErrRoomNotFound)
+
+	_, err = NewHub(new(MockPageService)).ReproBundle("page-1")
+	assert.ErrorIs(t, err, domainErrors.ErrReproDisabled)
+}
+
+func TestReplay(t *testing.T) {
+	// 测试场景：回放到指定版本为止；状态不一致、操作无法应用时报告结果；版本不连续或格式未知时拒绝回放
+
+	hub, room := newReproTestRoom(t, 10)
+	for i := 1; i <= 3; i++ {
+		editDesc(t, room, i)
+	}
+	bundle, err := hub.ReproBundle("page-1")
+	require.NoError(t, err)
+
+	result, err := Replay(bundle, 12)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Applied)
+	assert.Equal(t, int64(12), result.Version)
+	assert.Contains(t, string(result.State), `"desc":"v2"`)
+	assert.False(t, result.Match)
+
+	tampered := *bundle
+	tampered.State = []byte(`{}`)
+	result, err = Replay(&tampered, 0)
+	require.NoError(t, err)
+	assert.NoError(t, result.Err)
+	assert.False(t, result.Match)
+
+	broken := *bundle
+	broken.Ops = append([]ArchivedOp(nil), bundle.Ops...)
+	broken.Ops[1].Patch = []byte(`[{"op": "remove", "path": "/missing"}]`)
+	result, err = Replay(&broken, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Applied)
+	assert.Equal(t, int64(12), result.FailedVersion)
+	assert.Error(t, result.Err)
+	assert.False(t, result.Match)
+
+	gap := *bundle
+	gap.Ops = []ArchivedOp{bundle.Ops[0], bundle.Ops[2]}
+	_, err = Replay(&gap, 0)
+	assert.Error(t, err)
+
+	unknown := *bundle
+	unknown.Format = 99
+	_, err = Replay(&unknown, 0)
+	assert.Error(t, err)
+}
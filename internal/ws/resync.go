@@ -0,0 +1,55 @@
+package ws
+
+import (
+	"time"
+
+	"lowercode-go-server/internal/logging"
+)
+
+// ResyncCooldown 同一连接两次 request-sync 的最小间隔，间隔内的重复请求被合并
+const ResyncCooldown = time.Second
+
+// handleRequestSync 处理客户端的重新同步请求
+func (c *Client) handleRequestSync() {
+	if c.Room == nil {
+		c.sendError(ErrRoomNotFound, c.RoomID)
+		return
+	}
+
+	// 冷却期内已有一条 sync 在途，客户端收到后即可恢复
+	now := time.Now()
+	if now.Sub(c.lastSyncRequest) < ResyncCooldown {
+		return
+	}
+	c.lastSyncRequest = now
+
+	// 客户端自行判定本地状态已偏离，sync 会覆盖未确认的修改
+	c.allowConflictBackup()
+	c.Room.RequestSync(c)
+}
+
+// RequestSync 请求房间向 client 重新发送全量同步，房间已停止时忽略
+func (r *Room) RequestSync(client *Client) {
+	select {
+	case r.syncReqs <- client:
+	case <-r.stopChan:
+	}
+}
+
+// resyncClient 向已在房间内的 client 重新发送 sync，仅在 run() 内调用。
+// 发送缓冲区已满说明客户端严重滞后，直接断开，由客户端重连后获取完整状态。
+func (r *Room) resyncClient(client *Client) {
+	if !r.clients[client] {
+		return
+	}
+
+	data, version := r.encodeSync(client)
+	select {
+	case client.send <- data:
+		logging.Debugf("[Room %s] 用户 [%s] 请求重新同步，版本: %d", r.ID, client.UserInfo.UserName, version)
+	default:
+		logging.Warnf("[Room %s] 用户 [%s] 缓冲区已满，无法重新同步，断开连接", r.ID, client.UserInfo.UserName)
+		r.removeClient(client)
+		r.notifyIdleIfEmpty()
+	}
+}
@@ -0,0 +1,87 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 客户端请求重新同步单元测试 ==========
+
+// nextTestMessageOfType 读取 client 收到的消息，直到出现指定类型
+func nextTestMessageOfType(t *testing.T, client *Client, msgType MessageType) WSMessage {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case data := <-client.send:
+			var msg WSMessage
+			require.NoError(t, json.Unmarshal(data, &msg))
+			if msg.Type == msgType {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("未收到 %s 消息", msgType)
+		}
+	}
+}
+
+func TestClient_RequestSync(t *testing.T) {
+	// 测试场景：客户端请求后只有它自己收到包含最新版本的 sync；
+	// 冷却期内的重复请求被合并
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	room := NewRoom("test-room", []byte(`{"title": "a"}`), mockService, nil)
+	defer room.Stop()
+
+	alice := &Client{UserInfo: UserInfo{UserID: "alice"}, RoomID: "test-room", send: make(chan []byte, 16)}
+	bob := &Client{UserInfo: UserInfo{UserID: "bob"}, RoomID: "test-room", send: make(chan []byte, 16)}
+	require.NoError(t, room.Register(alice))
+	require.NoError(t, room.Register(bob))
+	nextTestMessageOfType(t, alice, TypeSync)
+	nextTestMessageOfType(t, bob, TypeSync)
+
+	require.NoError(t, room.ApplyPatch([]byte(`[{"op": "replace", "path": "/title", "value": "b"}]`), 1))
+
+	alice.handleRequestSync()
+	alice.handleRequestSync() // 冷却期内，被合并
+
+	msg := nextTestMessageOfType(t, alice, TypeSync)
+	var payload SyncPayload
+	require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, int64(2), payload.Version)
+	assert.JSONEq(t, `{"title": "b"}`, string(payload.Schema))
+	assert.Equal(t, []UserInfo{bob.UserInfo}, payload.Users)
+
+	// 合并的请求不会再产生 sync，bob 也不会收到
+	room.Users() // 等待 run() 处理完之前的请求
+	for _, c := range []*Client{alice, bob} {
+		for len(c.send) > 0 {
+			var other WSMessage
+			require.NoError(t, json.Unmarshal(<-c.send, &other))
+			assert.NotEqual(t, TypeSync, other.Type)
+		}
+	}
+}
+
+func TestRoom_ResyncClient_DisconnectsWhenBufferFull(t *testing.T) {
+	// 测试场景：发送缓冲区已满时无法重新同步，断开该客户端
+
+	room := newTestRoom("test-room", []byte(`{}`), new(MockPageService))
+	room.selections = newSelectionTable()
+	alice := &Client{UserInfo: UserInfo{UserID: "alice"}, send: make(chan []byte, 1)}
+	room.clients[alice] = true
+	alice.send <- []byte(`{}`)
+
+	room.resyncClient(alice)
+
+	assert.NotContains(t, room.clients, alice)
+	<-alice.send
+	_, open := <-alice.send
+	assert.False(t, open)
+}
@@ -0,0 +1,49 @@
+package ws
+
+import "log"
+
+// 登录会话失效时下发给客户端的提示
+const sessionRevokedReason = "登录状态已失效，请重新登录"
+
+// DisconnectSession 断开所有房间中使用登录会话 sessionID 的连接，返回被关闭的连接数。
+// 连接收到 SESSION_REVOKED 错误后关闭，同一用户其他会话的连接不受影响
+func (h *Hub) DisconnectSession(sessionID string) int {
+	if sessionID == "" {
+		return 0
+	}
+	return h.disconnect(&kickOp{
+		match:  func(u UserInfo) bool { return u.SessionID == sessionID },
+		code:   ErrSessionRevoked,
+		reason: sessionRevokedReason,
+		target: "会话 [" + sessionID + "] ",
+	})
+}
+
+// DisconnectUser 断开所有房间中 userID 以登录身份建立的连接（如账号被删除），返回被关闭的连接数
+func (h *Hub) DisconnectUser(userID string) int {
+	if userID == "" {
+		return 0
+	}
+	return h.disconnect(&kickOp{
+		match:  func(u UserInfo) bool { return u.UserID == userID && !u.Guest },
+		code:   ErrSessionRevoked,
+		reason: sessionRevokedReason,
+		target: "用户 [" + userID + "] ",
+	})
+}
+
+// disconnect 在每个房间中执行同一个移出请求
+func (h *Hub) disconnect(op *kickOp) int {
+	rooms := h.allRooms()
+
+	total := 0
+	for _, room := range rooms {
+		// 每个房间需要独立的 reply 通道
+		roomOp := *op
+		total += room.kick(&roomOp)
+	}
+	if total > 0 {
+		log.Printf("[Hub] %s登录状态失效，断开 %d 个连接", op.target, total)
+	}
+	return total
+}
@@ -0,0 +1,83 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 登录会话吊销单元测试 ==========
+
+// newRevokeTestHub 创建包含 page-1、page-2 两个房间的 Hub
+func newRevokeTestHub(t *testing.T) (*Hub, *Room, *Room) {
+	t.Helper()
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", mock.Anything).Return([]byte(`{}`), int64(1), nil)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	hub := NewHub(mockService)
+	room1, err := hub.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	t.Cleanup(room1.Stop)
+	room2, err := hub.GetOrCreateRoom("page-2")
+	require.NoError(t, err)
+	t.Cleanup(room2.Stop)
+	return hub, room1, room2
+}
+
+// lastError 读取已关闭的发送通道中的最后一条消息，应为错误消息
+func lastError(t *testing.T, c *Client) ErrorPayload {
+	t.Helper()
+	var last WSMessage
+	for data := range c.send {
+		require.NoError(t, json.Unmarshal(data, &last))
+	}
+	require.Equal(t, TypeError, last.Type)
+	var payload ErrorPayload
+	require.NoError(t, json.Unmarshal(last.Payload, &payload))
+	return payload
+}
+
+func TestHub_DisconnectSession(t *testing.T) {
+	// 测试场景：断开所有房间中使用该会话的连接，连接先收到 SESSION_REVOKED；同一用户其他会话的连接不受影响
+
+	hub, room1, room2 := newRevokeTestHub(t)
+	revokedTab1 := &Client{UserInfo: UserInfo{UserID: "alice", SessionID: "sess-1"}, send: make(chan []byte, 16)}
+	revokedTab2 := &Client{UserInfo: UserInfo{UserID: "alice", SessionID: "sess-1"}, send: make(chan []byte, 16)}
+	otherDevice := &Client{UserInfo: UserInfo{UserID: "alice", SessionID: "sess-2"}, send: make(chan []byte, 16)}
+	require.NoError(t, room1.Register(revokedTab1))
+	require.NoError(t, room1.Register(otherDevice))
+	require.NoError(t, room2.Register(revokedTab2))
+
+	assert.Equal(t, 2, hub.DisconnectSession("sess-1"))
+	assert.Equal(t, ErrSessionRevoked, lastError(t, revokedTab1).Code)
+	assert.Equal(t, ErrSessionRevoked, lastError(t, revokedTab2).Code)
+	assert.Equal(t, []UserInfo{otherDevice.UserInfo}, room1.Users())
+	assert.Empty(t, room2.Users())
+
+	assert.Equal(t, 0, hub.DisconnectSession(""))
+	assert.Equal(t, 0, hub.DisconnectSession("sess-1"))
+}
+
+func TestHub_DisconnectUser(t *testing.T) {
+	// 测试场景：断开用户以登录身份建立的全部连接，同 ID 的访客和其他用户不受影响
+
+	hub, room1, room2 := newRevokeTestHub(t)
+	alice := &Client{UserInfo: UserInfo{UserID: "alice", SessionID: "sess-1"}, send: make(chan []byte, 16)}
+	aliceElsewhere := &Client{UserInfo: UserInfo{UserID: "alice", SessionID: "sess-2"}, send: make(chan []byte, 16)}
+	guest := &Client{UserInfo: UserInfo{UserID: "alice", Guest: true}, send: make(chan []byte, 16)}
+	bob := &Client{UserInfo: UserInfo{UserID: "bob", SessionID: "sess-3"}, send: make(chan []byte, 16)}
+	require.NoError(t, room1.Register(alice))
+	require.NoError(t, room1.Register(bob))
+	require.NoError(t, room2.Register(aliceElsewhere))
+	require.NoError(t, room2.Register(guest))
+
+	assert.Equal(t, 2, hub.DisconnectUser("alice"))
+	assert.Equal(t, ErrSessionRevoked, lastError(t, alice).Code)
+	assert.Equal(t, ErrSessionRevoked, lastError(t, aliceElsewhere).Code)
+	assert.Equal(t, []UserInfo{bob.UserInfo}, room1.Users())
+	assert.Equal(t, []UserInfo{guest.UserInfo}, room2.Users())
+}
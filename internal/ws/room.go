@@ -4,9 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/secretprop"
+
 	jsonpatch "github.com/evanphx/json-patch/v5"
 )
 
@@ -21,24 +27,151 @@ type Room struct {
 	clients map[*Client]bool
 
 	// 事件通道
-	broadcast  chan *RoomBroadcast // 广播消息
-	register   chan *Client        // 加入请求
-	unregister chan *Client        // 退出请求
-	stopChan   chan struct{}       // 停止信号
-	doneChan   chan struct{}       // run() 完全退出信号
+	broadcast   chan *RoomBroadcast        // 广播消息
+	register    chan *registerOp           // 加入请求
+	unregister  chan *Client               // 退出请求
+	lockOps     chan *lockOp               // 组件锁操作
+	selectOps   chan *selectOp             // 选中组件变化
+	chatOps     chan *chatOp               // 聊天消息与设置变更
+	settingsOps chan entity.CollabSettings // 页面级协同设置变更
+	presentOps  chan *presentationOp       // 演示模式切换
+	stopChan    chan struct{}              // 停止信号
+	doneChan    chan struct{}              // run() 完全退出信号
+
+	// 在线用户查询，供 HTTP 接口在 run() 之外读取 clients
+	usersReqs chan chan []UserInfo
+
+	// 移出用户请求，由页面创建者通过 HTTP 接口发起
+	kickOps chan *kickOp
+
+	// 客户端主动请求的全量重新同步
+	syncReqs chan *Client
 
 	// 状态标志
 	stopping    bool         // 是否正在停止
+	draining    bool         // 是否因服务重启停止，与 stopping 一同在关闭 stopChan 前写入
 	clientCount int          // 客户端计数，供 Hub 双重检查使用
 	countMu     sync.RWMutex // 保护 clientCount 和 stopping
 
-	// 状态锁，仅用于保护 CurrentState 和 Version 的并发读写
+	// 房间容量，连接数达到上限后拒绝新的加入请求，为 0 时不限制
+	maxClients int
+
+	// 状态锁，仅用于保护 CurrentState、Version 和 textDocs 的并发读写
 	stateMu sync.RWMutex
 
-	// 刷盘相关
+	// sealed 事件循环退出、最终刷盘之前置为 true，之后的编辑被拒绝，避免刷盘后应用的修改丢失；受 stateMu 保护
+	sealed bool
+
+	// 文本属性的 OT 状态，key 为属性的 JSON Pointer
+	textDocs map[string]*textDoc
+
+	// 组件锁，只在 run() 内访问
+	locks      *lockTable
+	lockTicker *time.Ticker
+
+	// 各客户端选中的组件，只在 run() 内访问
+	selections *selectionTable
+
+	// 聊天，run() 启动前由 loadChat 初始化，之后只在 run() 内访问
+	chatStore     ChatStore // 可选，为 nil 时聊天只保存在内存中
+	chatPersisted bool
+	chatHistory   []ChatPayload
+
+	// 冲突备份存储，为 nil 时不接受上传；创建后只读，由客户端 goroutine 直接写入，见 backup.go
+	backups ConflictBackupStore
+
+	// 页面级协同设置，run() 启动前由 loadSettings 初始化，之后只在 run() 内访问，为 nil 时使用默认设置；
+	// flushThreshold 为覆盖的刷盘阈值（0 表示使用自适应阈值），resolver 为设置选择的冲突策略（nil 时为 strict），
+	// 二者受 stateMu 保护
+	settingsStore  SettingsStore // 可选，为 nil 时使用默认设置
+	settings       *entity.CollabSettings
+	flushThreshold int64
+	resolver       ConflictResolver
+
+	// 自适应刷盘节奏，由 adaptFlush 在每次定时刷盘时更新，受 stateMu 保护：
+	// flushEvery 为当前的定时刷盘间隔，adaptiveThreshold 为未被页面设置覆盖时的刷盘阈值，
+	// patchRate 为编辑速率的滑动平均（从 rateSince 时的 rateVersion 起算），flushLatency 为刷盘耗时的滑动平均
+	flushEvery        time.Duration
+	adaptiveThreshold int64
+	patchRate         float64
+	rateVersion       int64
+	rateSince         time.Time
+	flushLatency      time.Duration
+
+	// 演示模式的演示者，为 nil 时未处于演示模式；只在 run() 内写入，
+	// 客户端 goroutine 检查编辑权限时读取，受 stateMu 保护
+	presenter *UserInfo
+
+	// 协作时段，sessionTimer 在下一个开始或结束时间触发，只在 run() 内或 run() 启动前重设；
+	// sessionClosed 为 true 时所有者以外的用户只读，受 stateMu 保护，见 session.go
+	sessionTimer  *time.Timer
+	sessionClosed bool
+
+	// dirtySince 开启延迟追踪时第一个未落盘编辑的时间，受 stateMu 保护，见 latency.go
+	dirtySince time.Time
+
+	// 刷盘相关，persistMu 串行化同一房间的刷盘，定时、阈值触发和销毁前的刷盘不会同时写入
+	persistMu            sync.Mutex
 	lastPersistedVersion int64
 	flushTicker          *time.Ticker
+	flushPolicy          FlushPolicy // 自适应刷盘的边界，见 flush.go
 	pageService          PageService
+	opLog                *OpLogWriter // 可选，为 nil 时不记录操作日志
+	events               EventSink    // 可选，为 nil 时不发送生命周期事件
+	stopReason           ErrorCode    // 停止原因，StopWithReason 设置，在关闭 stopChan 前写入
+
+	// 差量持久化，deltas 为 nil 时每次刷盘都写全量快照
+	deltas         *deltaPolicy
+	pendingPatches []json.RawMessage // lastPersistedVersion 之后已应用的 Patch，受 stateMu 保护
+	flushCount     int               // 成功刷盘次数，受 stateMu 保护
+
+	// 运行计数与高频日志采样，可在任意 goroutine 中访问
+	stats    roomStats
+	patchLog logSampler // Patch 应用成功
+	failLog  logSampler // Patch 冲突或失败
+	flushLog logSampler // 刷盘完成
+
+	// 按方向和类型统计的消息计数，由客户端读写 goroutine 累加
+	messages messageStats
+
+	// 压缩发送的帧数与压缩前后字节数，由客户端写 goroutine 累加
+	compression compressionStats
+
+	// 页面活动，pendingEditors 为尚未刷盘的编辑作者，受 stateMu 保护
+	activity       *ActivityWriter // 可选，为 nil 时不记录
+	pendingEditors map[string]pendingEdit
+
+	// 页面引用索引，hadReferences 为上次索引时状态中是否有引用，受 stateMu 保护；
+	// 初始为 true，房间加载后第一次刷盘总会重建索引
+	references    *ReferenceIndexer // 可选，为 nil 时不索引
+	hadReferences bool
+
+	// 房间销毁时归档，loadedVersion 为房间从数据库加载时的版本
+	archive       *ArchiveWriter // 可选，为 nil 时不归档
+	loadedVersion int64
+
+	// 重连追赶，recentPatches 为最近应用的 Patch（受 stateMu 保护），更早的版本从 catchUpOps 读取
+	catchUpOps    OpReader // 可选，为 nil 时只能从内存窗口追赶
+	recentPatches []versionedPatch
+
+	// 最近操作及其之前的快照，用于导出复现包，受 stateMu 保护
+	repro *reproJournal // 可选，为 nil 时不记录
+
+	// 长轮询等待的版本变化信号，版本推进时关闭并置空，受 stateMu 保护
+	versionChanged chan struct{}
+
+	// 跨节点桥接，bridge 为 nil 时房间只在本节点内广播，见 bridge.go：
+	// remote 为其他节点的消息，在 run() 内处理；chain 为当前版本的哈希链，受 stateMu 保护；
+	// bridgeRequestedAt 为上次请求完整状态的时间，只在 run() 内访问
+	bridge            RoomBridge
+	nodeID            string
+	remote            chan *bridgeMessage
+	unsubscribe       func()
+	chain             string
+	bridgeRequestedAt time.Time
+
+	// leaseRenewed 最近一次获取或续约房间租约的时间（UnixNano），见 ownership.go
+	leaseRenewed atomic.Int64
 
 	// Hub 反向引用
 	hub *Hub
@@ -49,13 +182,20 @@ type RoomBroadcast struct {
 	Message    []byte
 	Sender     *Client
 	IsCritical bool
-}
 
-// 刷盘配置常量
-const (
-	FlushInterval  = 30 * time.Second // 定时刷盘间隔
-	FlushThreshold = 50               // 版本差异阈值触发刷盘
-)
+	// Cursor 为 true 表示光标、视口等感知类消息，页面关闭光标广播时丢弃
+	Cursor bool
+
+	// Capability 非空时，只有协商了该能力的客户端收到 Message，其余客户端收到 Fallback
+	Capability string
+	Fallback   []byte
+
+	// Target 非空时只发给该客户端（仍在房间内时），用于事件循环之外的单播，见 Room.sendAsync
+	Target *Client
+
+	// AppliedAt 开启延迟追踪时 op-patch 的应用完成时间，放入发送缓冲区后记录广播耗时，见 latency.go
+	AppliedAt time.Time
+}
 
 // NewRoom 创建房间并启动事件循环
 func NewRoom(id string, initialState []byte, pageService PageService, hub *Hub) *Room {
@@ -65,14 +205,60 @@ func NewRoom(id string, initialState []byte, pageService PageService, hub *Hub)
 		Version:      1,
 		clients:      make(map[*Client]bool),
 		broadcast:    make(chan *RoomBroadcast, 256),
-		register:     make(chan *Client),
+		register:     make(chan *registerOp),
 		unregister:   make(chan *Client),
+		lockOps:      make(chan *lockOp, 16),
+		selectOps:    make(chan *selectOp, 16),
+		chatOps:      make(chan *chatOp, 16),
+		settingsOps:  make(chan entity.CollabSettings, 1),
+		presentOps:   make(chan *presentationOp, 16),
+		usersReqs:    make(chan chan []UserInfo),
+		kickOps:      make(chan *kickOp),
+		syncReqs:     make(chan *Client, 16),
 		stopChan:     make(chan struct{}),
 		doneChan:     make(chan struct{}),
-		flushTicker:  time.NewTicker(FlushInterval),
+		textDocs:     make(map[string]*textDoc),
+		locks:        newLockTable(LockTTL),
+		selections:   newSelectionTable(),
+		lockTicker:   time.NewTicker(lockSweepInterval),
+		flushTicker:  time.NewTicker(DefaultFlushPolicy.MinInterval),
+		flushPolicy:  DefaultFlushPolicy,
+		sessionTimer: time.NewTimer(time.Hour),
 		pageService:  pageService,
 		hub:          hub,
+
+		hadReferences: true,
+	}
+
+	if hub != nil {
+		r.opLog = hub.opLog
+		r.events = hub.events
+		r.deltas = hub.deltas
+		r.chatStore = hub.chat
+		r.settingsStore = hub.settings
+		r.backups = hub.backups
+		r.archive = hub.archive
+		r.activity = hub.activity
+		r.references = hub.references
+		r.catchUpOps = hub.catchUpOps
+		r.maxClients = hub.maxClients
+		if hub.bridge != nil {
+			r.bridge, r.nodeID = hub.bridge, hub.nodeID
+			r.remote = make(chan *bridgeMessage, bridgeRemoteBuffer)
+		}
+		if hub.flushPolicy != nil {
+			r.flushPolicy = *hub.flushPolicy
+		}
 	}
+	// 房间刚创建时按编辑稀疏处理，第一次定时刷盘后按实际速率调整
+	r.flushEvery = r.flushPolicy.MinInterval
+	r.adaptiveThreshold = r.flushPolicy.MinThreshold
+	r.rateSince = time.Now()
+	r.flushTicker.Reset(r.flushEvery)
+	// 协作时段定时器由 applySessionWindow 按需启动
+	r.sessionTimer.Stop()
+	r.loadChat()
+	r.loadSettings()
 
 	go r.run()
 
@@ -83,63 +269,134 @@ func NewRoom(id string, initialState []byte, pageService PageService, hub *Hub)
 // run 是房间的主事件循环，所有操作在此串行处理。
 func (r *Room) run() {
 	defer func() {
+		r.leaveBridge()
 		r.flushTicker.Stop()
-		r.flushToDB("销毁前")
+		r.lockTicker.Stop()
+		r.sessionTimer.Stop()
+		if r.draining {
+			r.announceRestart()
+		}
+
+		r.stateMu.Lock()
+		r.sealed = true
+		r.stateMu.Unlock()
+
+		// 销毁前总是写全量快照，房间关闭后读取页面无需回放差量
+		r.flushFinal()
+		r.hibernate()
+		// 刷盘（和休眠）之后才释放租约，接管的节点加载到最新状态
+		if r.hub != nil {
+			r.hub.releaseOwnership(r.ID)
+		}
+		if r.draining {
+			r.closeClientsForRestart()
+		}
+
+		r.stateMu.RLock()
+		r.emit(LifecycleEvent{
+			Type:             EventRoomDestroyed,
+			Version:          r.Version,
+			PersistedVersion: r.lastPersistedVersion,
+			Reason:           string(r.stopReason),
+		})
+		r.stateMu.RUnlock()
+
+		r.enqueueArchive()
+
 		close(r.doneChan)
-		log.Printf("[Room %s] 事件循环已停止", r.ID)
+		log.Printf("[Room %s] 事件循环已停止（%s）", r.ID, r.stats.summary())
 	}()
 
 	for {
 		select {
 		// 处理客户端注册
-		case client := <-r.register:
+		case op := <-r.register:
+			client := op.client
+			if r.maxClients > 0 && len(r.clients) >= r.maxClients {
+				r.rejectFull(client)
+				op.reply <- ErrRoomCapacity
+				continue
+			}
+			if err := r.admitSession(client); err != nil {
+				op.reply <- err
+				r.notifyIdleIfEmpty()
+				continue
+			}
+			op.reply <- nil
 			r.clients[client] = true
 			client.Room = r
 			r.updateClientCount(1)
-			r.sendSyncToClient(client)
+			r.sendInitialState(client)
+			r.sendChatHistory(client)
+			r.sendNotices(client)
+			r.sendDrainHint(client)
+			r.announcePresence(TypeUserJoin, client)
+			r.recordOpened(client)
 			log.Printf("[Room %s] 用户 [%s] 加入，当前人数: %d",
 				r.ID, client.UserInfo.UserName, len(r.clients))
 
 		// 处理客户端注销
 		case client := <-r.unregister:
 			if _, ok := r.clients[client]; ok {
-				delete(r.clients, client)
-				close(client.send)
-				r.updateClientCount(-1)
+				r.removeClient(client)
 				log.Printf("[Room %s] 用户 [%s] 离开，剩余人数: %d",
 					r.ID, client.UserInfo.UserName, len(r.clients))
-
-				// 房间空闲时通知 Hub
-				if len(r.clients) == 0 && r.hub != nil {
-					r.hub.NotifyIdle(r)
-				}
+				r.notifyIdleIfEmpty()
 			}
 
 		// 处理广播消息
 		case msg := <-r.broadcast:
-			for client := range r.clients {
-				if msg.Sender != nil && client == msg.Sender {
-					continue
-				}
-
-				select {
-				case client.send <- msg.Message:
-					// 发送成功
-				default:
-					// 缓冲区满时的处理策略
-					if msg.IsCritical {
-						log.Printf("[Room %s] 关键消息阻塞，踢出用户 [%s]",
-							r.ID, client.UserInfo.UserName)
-						delete(r.clients, client)
-						close(client.send)
-					}
-					// 非关键消息直接丢弃
-				}
-			}
+			r.deliver(msg)
+			r.relayCursor(msg)
+
+		// 处理其他节点的消息
+		case msg := <-r.remote:
+			r.handleBridgeMessage(msg)
+
+		// 处理组件锁操作
+		case op := <-r.lockOps:
+			r.handleLockOp(op)
+
+		// 处理选中组件变化
+		case op := <-r.selectOps:
+			r.handleSelectOp(op)
+
+		// 处理聊天消息
+		case op := <-r.chatOps:
+			r.handleChatOp(op)
+
+		// 处理协同设置变更
+		case settings := <-r.settingsOps:
+			r.handleSettingsOp(settings)
+
+		// 处理演示模式切换
+		case op := <-r.presentOps:
+			r.handlePresentationOp(op)
+
+		// 查询在线用户
+		case reply := <-r.usersReqs:
+			reply <- r.onlineUsers()
+
+		// 移出用户
+		case op := <-r.kickOps:
+			r.handleKickOp(op)
+
+		// 客户端请求重新同步
+		case client := <-r.syncReqs:
+			r.resyncClient(client)
+
+		// 定时清理过期的组件锁
+		case <-r.lockTicker.C:
+			r.expireLocks()
 
 		// 定时刷盘
-		case <-r.flushTicker.C:
-			r.flushToDB("定时")
+		case now := <-r.flushTicker.C:
+			r.scheduleFlush("定时")
+			r.adaptFlush(now)
+
+		// 协作时段开始或结束
+		case <-r.sessionTimer.C:
+			r.handleSessionBoundary()
 
 		// 停止信号
 		case <-r.stopChan:
@@ -148,22 +405,130 @@ func (r *Room) run() {
 	}
 }
 
+// removeClient 将客户端移出房间：关闭发送通道，组件锁转给同一设备的其他连接或释放，清除选中状态，
+// 并通知其他用户其离开；演示者的最后一个连接离开时结束演示模式，仅在 run() 内调用
+func (r *Room) removeClient(client *Client) {
+	delete(r.clients, client)
+	close(client.send)
+	r.updateClientCount(-1)
+	r.handOverLocks(client)
+	r.releaseLocksOf(client, LockReasonDisconnected)
+	r.clearSelectionOf(client)
+	r.announcePresence(TypeUserLeave, client)
+	r.endPresentationIfPresenterLeft(client)
+}
+
+// notifyIdleIfEmpty 房间空闲时通知 Hub，仅在 run() 内调用
+func (r *Room) notifyIdleIfEmpty() {
+	if len(r.clients) == 0 && r.hub != nil {
+		r.hub.NotifyIdle(r)
+	}
+}
+
+// deliver 将广播消息投递给房间内除发送者外的所有客户端，仅在 run() 内调用
+func (r *Room) deliver(msg *RoomBroadcast) {
+	if msg.Cursor && !r.collabSettings().Cursors {
+		return
+	}
+	if msg.Target != nil {
+		if r.clients[msg.Target] {
+			r.sendToClient(msg.Target, msg.Message)
+		}
+		return
+	}
+	var blocked []*Client
+	for client := range r.clients {
+		if msg.Sender != nil && client == msg.Sender {
+			continue
+		}
+
+		data := msg.Message
+		if msg.Capability != "" && !client.HasCapability(msg.Capability) {
+			data = msg.Fallback
+		}
+
+		client.trackApplied(data, msg.AppliedAt)
+		select {
+		case client.send <- data:
+			// 发送成功
+		default:
+			// 缓冲区满时的处理策略：关键消息阻塞时踢出，非关键消息直接丢弃
+			client.takeApplied(data)
+			if msg.IsCritical {
+				blocked = append(blocked, client)
+			}
+		}
+	}
+	r.observeBroadcast(msg)
+
+	// 投递完成后再移出，释放锁、清除选中并通知其他人离开，与主动断开一致
+	for _, client := range blocked {
+		logging.Warnf("[Room %s] 关键消息阻塞，踢出用户 [%s]",
+			r.ID, client.UserInfo.UserName)
+		r.removeClient(client)
+	}
+	if len(blocked) > 0 {
+		r.notifyIdleIfEmpty()
+	}
+}
+
+// announcePresence 通知房间内其他用户 client 加入或离开，仅在 run() 内调用。
+// senderId 与 payload 均为该用户，客户端据此增量维护在线列表，无需全量同步。
+func (r *Room) announcePresence(msgType MessageType, client *Client) {
+	r.deliver(&RoomBroadcast{
+		Message: encodeMessage(msgType, client.UserInfo.UserID, client.UserInfo),
+		Sender:  client,
+	})
+}
+
+// sendToClient 向单个客户端发送非关键消息，缓冲区满时丢弃，仅在 run() 内调用
+func (r *Room) sendToClient(client *Client, data []byte) {
+	select {
+	case client.send <- data:
+	default:
+		logging.Warnf("[Room %s] 用户 [%s] 缓冲区已满，丢弃消息", r.ID, client.UserInfo.UserName)
+	}
+}
+
 // sendSyncToClient 向新加入的客户端发送全量同步消息
 func (r *Room) sendSyncToClient(client *Client) {
-	snapshot, version := r.GetSnapshot()
+	data, version := r.encodeSync(client)
+	client.send <- data
 
-	// 收集房间内其他用户信息
-	users := make([]UserInfo, 0, len(r.clients))
-	for c := range r.clients {
-		if c != client {
-			users = append(users, c.UserInfo)
+	logging.Debugf("[Room %s] 已发送 Sync 给 [%s], 版本: %d",
+		r.ID, client.UserInfo.UserName, version)
+}
+
+// encodeSync 构造发给 client 的全量同步消息，返回消息和其中的版本号，仅在 run() 内调用。
+// 非所有者收到的 Schema 中密钥属性只保留掩码
+func (r *Room) encodeSync(client *Client) ([]byte, int64) {
+	r.stateMu.RLock()
+	snapshot := append([]byte(nil), r.CurrentState...)
+	version := r.Version
+	revisions := r.textRevisionsLocked(client)
+	r.stateMu.RUnlock()
+
+	if client.UserInfo.Role != entity.RoleOwner {
+		// 脱敏失败时 Schema 中也只有密文，仍然发送以免客户端无法同步
+		if masked, err := secretprop.Mask(snapshot); err == nil {
+			snapshot = masked
+		} else {
+			logging.Errorf("[Room %s] 密钥属性脱敏失败: %v", r.ID, err)
 		}
 	}
 
 	syncPayload := SyncPayload{
-		Schema:  snapshot,
-		Version: version,
-		Users:   users,
+		Schema:        snapshot,
+		Version:       version,
+		ClientID:      client.UserInfo.ClientID,
+		Users:         r.peersOf(client),
+		Capabilities:  client.CapabilityList(),
+		TextRevisions: revisions,
+		Selections:    r.selections.snapshot(client),
+		Locks:         r.locks.snapshot(time.Now()),
+		Settings:      r.collabSettings(),
+		Presentation:  r.presentationState(),
+		Session:       r.sessionState(),
 	}
 
 	payload, _ := json.Marshal(syncPayload)
@@ -175,10 +540,18 @@ func (r *Room) sendSyncToClient(client *Client) {
 	}
 
 	data, _ := json.Marshal(msg)
-	client.send <- data
+	return data, version
+}
 
-	log.Printf("[Room %s] 已发送 Sync 给 [%s], 版本: %d",
-		r.ID, client.UserInfo.UserName, version)
+// peersOf 返回房间内除 client 之外的用户信息，仅在 run() 内调用
+func (r *Room) peersOf(client *Client) []UserInfo {
+	users := make([]UserInfo, 0, len(r.clients))
+	for c := range r.clients {
+		if c != client {
+			users = append(users, c.UserInfo)
+		}
+	}
+	return users
 }
 
 // --- 对外接口 ---
@@ -186,15 +559,44 @@ func (r *Room) sendSyncToClient(client *Client) {
 // ErrRoomClosed 房间已关闭错误
 var ErrRoomClosed = fmt.Errorf("room is closing")
 
+// ErrRoomCapacity 房间连接数已达上限，客户端已收到 ROOM_FULL 错误
+var ErrRoomCapacity = fmt.Errorf("room is full")
+
+// registerOp 加入请求，reply 返回是否加入成功
+type registerOp struct {
+	client *Client
+	reply  chan error
+}
+
 // Register 将客户端注册到房间。
-// 采用非阻塞方式，防止向已关闭的房间注册。
+// 房间已关闭时返回 ErrRoomClosed，防止向已关闭的房间注册；
+// 房间已满时返回 ErrRoomCapacity，此时客户端的发送通道中已放入 ROOM_FULL 错误并被关闭，
+// 调用方启动 WritePump 即可将错误送达并关闭连接；协作时段外所有者以外的用户加入时返回
+// domainErrors.ErrSessionClosed，发送通道中为 SESSION_CLOSED 错误，处理方式相同。
+// 客户端携带 SinceVersion 时，先在调用方 goroutine 中预取追赶所需的操作日志。
 func (r *Room) Register(client *Client) error {
+	r.prefetchCatchUp(client)
+
+	op := &registerOp{client: client, reply: make(chan error, 1)}
 	select {
-	case r.register <- client:
-		return nil
+	case r.register <- op:
 	case <-r.stopChan:
 		return ErrRoomClosed
 	}
+	// run() 收到请求后总会回复
+	return <-op.reply
+}
+
+// rejectFull 拒绝房间已满时的加入请求，仅在 run() 内调用。
+// 客户端尚未加入房间，不会收到 sync，也不会触发 user-join。
+func (r *Room) rejectFull(client *Client) {
+	client.send <- encodeServerMessage(TypeError, ErrorPayload{
+		Code:    ErrRoomFull,
+		Message: fmt.Sprintf("房间人数已满（上限 %d），请稍后重试", r.maxClients),
+	})
+	close(client.send)
+	log.Printf("[Room %s] 房间已满（%d），拒绝用户 [%s] 加入",
+		r.ID, r.maxClients, client.UserInfo.UserName)
 }
 
 // Unregister 将客户端从房间注销（非阻塞）
@@ -241,6 +643,7 @@ func (r *Room) StopWithReason(reason ErrorCode, message string) {
 		return
 	}
 	r.stopping = true
+	r.stopReason = reason
 	r.countMu.Unlock()
 
 	// 广播错误消息给所有客户端
@@ -281,6 +684,43 @@ func (r *Room) ClientCount() int {
 	return r.clientCount
 }
 
+// Users 返回房间内的在线用户，同一用户的多个连接只计一次，按用户 ID 排序。
+// 查询由 run() 串行处理，房间已停止时返回 nil。
+func (r *Room) Users() []UserInfo {
+	reply := make(chan []UserInfo, 1)
+	select {
+	case r.usersReqs <- reply:
+	case <-r.stopChan:
+		return nil
+	}
+	select {
+	case users := <-reply:
+		return users
+	case <-r.stopChan:
+		return nil
+	}
+}
+
+// onlineUsers 收集在线用户并按用户 ID 去重、排序，仅在 run() 内调用。
+// 同一用户的多个连接合并为一项，去掉只属于某个连接的 clientId 和设备信息
+func (r *Room) onlineUsers() []UserInfo {
+	seen := make(map[string]bool, len(r.clients))
+	users := make([]UserInfo, 0, len(r.clients))
+	for c := range r.clients {
+		if seen[c.UserInfo.UserID] {
+			continue
+		}
+		seen[c.UserInfo.UserID] = true
+		user := c.UserInfo
+		user.ClientID, user.DeviceID, user.DeviceName = "", "", ""
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].UserID < users[j].UserID
+	})
+	return users
+}
+
 // IsStopping 返回房间是否正在停止
 func (r *Room) IsStopping() bool {
 	r.countMu.RLock()
@@ -300,35 +740,145 @@ func (r *Room) updateClientCount(delta int) {
 // ApplyPatch 应用 JSON Patch 到当前状态。
 // 包含版本检查，确保乐观锁机制生效。
 func (r *Room) ApplyPatch(patchBytes []byte, expectedVersion int64) error {
+	return r.ApplyPatchAs(UserInfo{}, patchBytes, expectedVersion)
+}
+
+// ApplyPatchAs 以 author 的身份应用 Patch，author 会写入操作日志用于审计
+func (r *Room) ApplyPatchAs(author UserInfo, patchBytes []byte, expectedVersion int64) error {
+	_, err := r.ApplyEdit(author, patchBytes, expectedVersion)
+	return err
+}
+
+// ApplyEdit 以 author 的身份应用 Patch，并在同一版本内把被修改组件的最后编辑者写入 editedBy。
+// 追加的归属操作与客户端 Patch 一起写入操作日志和差量，保证回放后归属一致。
+// 客户端不能直接修改 editedBy。
+func (r *Room) ApplyEdit(author UserInfo, patchBytes []byte, expectedVersion int64) (*PatchResult, error) {
+	return r.ApplyLabeledEdit(author, "", patchBytes, expectedVersion)
+}
+
+// ApplyLabeledEdit 与 ApplyEdit 相同，label 标明产生该编辑的编辑器功能（如 "drag-resize"、"undo"），
+// 随操作日志保存，用于历史记录展示和功能使用统计，可为空
+func (r *Room) ApplyLabeledEdit(author UserInfo, label string, patchBytes []byte, expectedVersion int64) (result *PatchResult, err error) {
+	defer func() { r.stats.recordPatch(err) }()
+
 	r.stateMu.Lock()
 	defer r.stateMu.Unlock()
 
-	if r.Version != expectedVersion {
-		return &VersionConflictError{
-			CurrentVersion:  r.Version,
-			ExpectedVersion: expectedVersion,
-		}
+	edit, err := r.prepareEditLocked(author, patchBytes, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	r.CurrentState = edit.state
+	r.Version++
+	r.noteDirtyLocked()
+	r.recordOpLocked(edit.result.Patches, author, label)
+	r.rememberPatchLocked(edit.result.Patches, author.UserID)
+	r.relayPatchLocked(edit.result.Patches, author, label)
+	r.noteEditorLocked(author)
+	r.bufferPatchLocked(edit.result.Patches)
+	r.invalidateTextDocs(edit.patch)
+	r.maybeFlushLocked()
+	if edit.result.Rebased {
+		patchMetrics.Add("rebased", 1)
+	}
+
+	return edit.result, nil
+}
+
+// ValidateEdit 对当前状态执行与 ApplyEdit 相同的检查和应用，但不提交（dry-run）。
+// 返回的 PatchResult 为提交后将得到的版本号和 Patch，不计入房间的 Patch 统计。
+func (r *Room) ValidateEdit(author UserInfo, patchBytes []byte, expectedVersion int64) (*PatchResult, error) {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	edit, err := r.prepareEditLocked(author, patchBytes, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	return edit.result, nil
+}
+
+// preparedEdit 已通过校验、尚未提交的编辑
+type preparedEdit struct {
+	patch  jsonpatch.Patch
+	state  []byte       // 应用 Patch 和编辑归属后的新状态
+	result *PatchResult // 提交后的版本号与实际应用的 Patch
+}
+
+// prepareEditLocked 校验版本并在当前状态的副本上应用 Patch，调用方需持有 stateMu（读锁即可）
+func (r *Room) prepareEditLocked(author UserInfo, patchBytes []byte, expectedVersion int64) (*preparedEdit, error) {
+	if r.sealed {
+		return nil, ErrRoomClosed
 	}
 
 	patch, err := jsonpatch.DecodePatch(patchBytes)
 	if err != nil {
-		return &PatchError{Reason: fmt.Sprintf("patch 解析失败: %v", err)}
+		return nil, &PatchError{Reason: fmt.Sprintf("patch 解析失败: %v", err)}
+	}
+	if touchesEditedBy(patch) {
+		return nil, &PatchError{Reason: "editedBy 由服务端维护，不能直接修改"}
+	}
+
+	// 基于旧版本的编辑交给页面的冲突策略处理，默认（strict）直接拒绝
+	rebased := r.Version != expectedVersion
+	if rebased {
+		if patch, patchBytes, err = r.resolveStaleLocked(author, patch, expectedVersion); err != nil {
+			return nil, err
+		}
 	}
 
 	modified, err := patch.Apply(r.CurrentState)
 	if err != nil {
-		return &PatchError{Reason: fmt.Sprintf("patch 应用失败: %v", err)}
+		return nil, &PatchError{Reason: fmt.Sprintf("patch 应用失败: %v", err)}
 	}
 
-	r.CurrentState = modified
-	r.Version++
+	modified, attribution := r.attributeLocked(modified, patch, author, r.Version+1)
+	return &preparedEdit{
+		patch: patch,
+		state: modified,
+		result: &PatchResult{
+			Version:     r.Version + 1,
+			Patches:     concatPatches(patchBytes, attribution),
+			Attribution: attribution,
+			Rebased:     rebased,
+		},
+	}, nil
+}
+
+// SubmitEdit 以 author 的身份应用服务端生成的 Patch（如 HTTP 批量操作），
+// 成功后作为 op-patch 广播给房间内所有用户，包括 author 自己的编辑器
+func (r *Room) SubmitEdit(author UserInfo, patchBytes []byte, expectedVersion int64) (*PatchResult, error) {
+	return r.SubmitLabeledEdit(author, "", patchBytes, expectedVersion)
+}
 
-	// 达到阈值时触发刷盘
-	if r.Version-r.lastPersistedVersion >= FlushThreshold {
-		go r.flushToDB("阈值触发")
+// SubmitLabeledEdit 与 SubmitEdit 相同，label 随操作日志保存并随 op-patch 广播，可为空
+func (r *Room) SubmitLabeledEdit(author UserInfo, label string, patchBytes []byte, expectedVersion int64) (*PatchResult, error) {
+	result, err := r.ApplyLabeledEdit(author, label, patchBytes, expectedVersion)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	msg := &RoomBroadcast{
+		Message: encodeMessage(TypeOpPatch, author.UserID, OpPatchPayload{
+			Patches: result.Patches,
+			Version: result.Version - 1,
+			Label:   label,
+		}),
+		IsCritical: true,
+	}
+	select {
+	case r.broadcast <- msg:
+	case <-r.stopChan:
+	}
+	return result, nil
+}
+
+// maybeFlushLocked 未刷盘版本数达到阈值时触发异步刷盘，调用方需持有 stateMu
+func (r *Room) maybeFlushLocked() {
+	if r.Version-r.lastPersistedVersion >= r.flushThresholdLocked() {
+		r.scheduleFlush("阈值触发")
+	}
 }
 
 // GetSnapshot 获取当前状态快照，返回拷贝以保证并发安全
@@ -342,29 +892,86 @@ func (r *Room) GetSnapshot() ([]byte, int64) {
 	return snapshot, r.Version
 }
 
-// flushToDB 将当前状态持久化到数据库
+// flushToDB 将当前状态持久化到数据库。
+// 启用差量持久化时，只有每 N 次刷盘写入全量快照，其余刷盘只追加 Patch。
 func (r *Room) flushToDB(reason string) {
+	r.persist(reason, false)
+}
+
+// persist 执行一次刷盘，forceSnapshot 为 true 时总是写入全量快照；没有未保存的版本时返回 nil
+func (r *Room) persist(reason string, forceSnapshot bool) error {
+	r.persistMu.Lock()
+	defer r.persistMu.Unlock()
+
 	r.stateMu.RLock()
 	if r.Version == r.lastPersistedVersion {
 		r.stateMu.RUnlock()
-		return
+		return nil
 	}
 
-	snapshot := make([]byte, len(r.CurrentState))
-	copy(snapshot, r.CurrentState)
+	var snapshot, delta []byte
+	if !forceSnapshot && !r.nextFlushIsSnapshot() {
+		delta = r.pendingDeltaLocked()
+	}
+	if delta == nil {
+		snapshot = make([]byte, len(r.CurrentState))
+		copy(snapshot, r.CurrentState)
+	}
 	currentVersion := r.Version
 	lastVersion := r.lastPersistedVersion
 	r.stateMu.RUnlock()
 
-	if err := r.pageService.SavePageState(r.ID, snapshot, lastVersion, currentVersion); err != nil {
-		log.Printf("[Room %s] %s刷盘失败: %v", r.ID, reason, err)
-		return
+	var err error
+	started := time.Now()
+	if delta != nil {
+		err = r.deltas.store.SavePageDelta(r.ID, delta, lastVersion, currentVersion)
+	} else {
+		err = r.pageService.SavePageState(r.ID, snapshot, lastVersion, currentVersion)
+	}
+	latency := time.Since(started)
+	if err != nil {
+		logging.Errorf("[Room %s] %s刷盘失败: %v", r.ID, reason, err)
+		r.emit(LifecycleEvent{
+			Type:             EventFlushFailed,
+			Version:          currentVersion,
+			PersistedVersion: lastVersion,
+			FromVersion:      lastVersion,
+			Reason:           reason,
+			Error:            err.Error(),
+		})
+		return err
 	}
 
 	r.stateMu.Lock()
-	if currentVersion > r.lastPersistedVersion {
+	advanced := currentVersion > r.lastPersistedVersion
+	var editors map[string]time.Time
+	if advanced {
+		editors = r.takePersistedEditorsLocked(currentVersion)
+		r.trimPendingLocked(currentVersion - r.lastPersistedVersion)
 		r.lastPersistedVersion = currentVersion
-		log.Printf("[Room %s] %s刷盘完成, 版本: %d -> %d", r.ID, reason, lastVersion, currentVersion)
+		r.flushCount++
+		r.stats.recordFlush()
+		r.recordFlushLatencyLocked(latency)
+		r.observePersistedLocked(started)
+		mode := "全量"
+		if delta != nil {
+			mode = "差量"
+		}
+		r.logSampled(&r.flushLog, "[Room %s] %s刷盘完成(%s), 版本: %d -> %d", r.ID, reason, mode, lastVersion, currentVersion)
 	}
 	r.stateMu.Unlock()
+
+	if advanced {
+		r.relayPersisted(currentVersion)
+		r.recordEdited(editors)
+		r.indexReferences()
+		r.emit(LifecycleEvent{
+			Type:             EventFlushCompleted,
+			Version:          currentVersion,
+			PersistedVersion: currentVersion,
+			FromVersion:      lastVersion,
+			Reason:           reason,
+		})
+	}
+	return nil
 }
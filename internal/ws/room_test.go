@@ -1,6 +1,7 @@
 package ws
 
 import (
+	"encoding/json"
 	"sync"
 	"testing"
 	"time"
@@ -14,18 +15,23 @@ import (
 
 // 创建测试用的 Room（不启动事件循环）
 func newTestRoom(id string, initialState []byte, mockService *MockPageService) *Room {
-	return &Room{
+	room := &Room{
 		ID:           id,
 		CurrentState: initialState,
 		Version:      1,
 		clients:      make(map[*Client]bool),
 		broadcast:    make(chan *RoomBroadcast, 256),
-		register:     make(chan *Client),
+		register:     make(chan *registerOp),
 		unregister:   make(chan *Client),
 		stopChan:     make(chan struct{}),
-		flushTicker:  time.NewTicker(FlushInterval),
+		flushTicker:  time.NewTicker(DefaultFlushPolicy.MaxInterval),
+		sessionTimer: time.NewTimer(time.Hour),
 		pageService:  mockService,
+		locks:        newLockTable(LockTTL),
+		selections:   newSelectionTable(),
 	}
+	room.sessionTimer.Stop()
+	return room
 }
 
 func TestRoom_ApplyPatch_Success(t *testing.T) {
@@ -144,7 +150,7 @@ func TestRoom_ApplyPatch_InvalidPath(t *testing.T) {
 
 func TestRoom_ApplyPatch_ThresholdFlush(t *testing.T) {
 	// 测试场景：阈值刷盘
-	// 连续调用 ApplyPatch 达到 FlushThreshold 次，验证 SavePageState 被异步触发
+	// 连续调用 ApplyPatch 达到初始阈值 DefaultFlushPolicy.MinThreshold 次，验证 SavePageState 被异步触发
 
 	mockService := new(MockPageService)
 	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
@@ -153,8 +159,8 @@ func TestRoom_ApplyPatch_ThresholdFlush(t *testing.T) {
 	room := newTestRoom("test-room", initialState, mockService)
 	room.lastPersistedVersion = 1
 
-	// 连续应用 FlushThreshold 次 Patch
-	for i := 0; i < FlushThreshold; i++ {
+	// 连续应用 MinThreshold 次 Patch
+	for i := 0; i < int(DefaultFlushPolicy.MinThreshold); i++ {
 		// 每次都使用 add 操作增加一个字段
 		patchBytes := []byte(`[{"op": "add", "path": "/test` + string(rune('a'+i)) + `", "value": ` + string(rune('0'+i%10)) + `}]`)
 
@@ -270,3 +276,143 @@ func TestRoom_ClientCount(t *testing.T) {
 	room.countMu.Unlock()
 	assert.True(t, room.IsStopping())
 }
+
+func TestRoom_Presence_JoinLeave(t *testing.T) {
+	// 测试场景：新用户加入时其他人收到 user-join，离开时收到 user-leave，
+	// 加入者本人只收到 sync，不会收到自己的 user-join
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	room := NewRoom("test-room", []byte(`{}`), mockService, nil)
+	defer room.Stop()
+
+	alice := &Client{UserInfo: UserInfo{UserID: "alice", UserName: "Alice"}, send: make(chan []byte, 8)}
+	bob := &Client{UserInfo: UserInfo{UserID: "bob", UserName: "Bob", Guest: true}, send: make(chan []byte, 8)}
+
+	assert.NoError(t, room.Register(alice))
+	assert.NoError(t, room.Register(bob))
+	room.Unregister(bob)
+
+	next := func(c *Client) WSMessage {
+		select {
+		case data := <-c.send:
+			var msg WSMessage
+			assert.NoError(t, json.Unmarshal(data, &msg))
+			return msg
+		case <-time.After(time.Second):
+			t.Fatalf("%s 未收到消息", c.UserInfo.UserID)
+			return WSMessage{}
+		}
+	}
+	skipUntil := func(c *Client, msgType MessageType) WSMessage {
+		for {
+			if msg := next(c); msg.Type == msgType {
+				return msg
+			}
+		}
+	}
+
+	join := skipUntil(alice, TypeUserJoin)
+	assert.Equal(t, "bob", join.SenderID)
+	var joined UserInfo
+	assert.NoError(t, json.Unmarshal(join.Payload, &joined))
+	assert.Equal(t, bob.UserInfo, joined)
+
+	leave := next(alice)
+	assert.Equal(t, TypeUserLeave, leave.Type)
+	assert.Equal(t, "bob", leave.SenderID)
+
+	// bob 的 send 在离开时被关闭，其中不应有自己的 user-join
+	for data := range bob.send {
+		var msg WSMessage
+		assert.NoError(t, json.Unmarshal(data, &msg))
+		assert.NotEqual(t, TypeUserJoin, msg.Type)
+	}
+}
+
+func TestRoom_Users(t *testing.T) {
+	// 测试场景：同一用户的多个连接只计一次，结果按用户 ID 排序；房间停止后返回 nil
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	room := NewRoom("test-room", []byte(`{}`), mockService, nil)
+
+	bob := UserInfo{UserID: "bob", UserName: "Bob"}
+	alice := UserInfo{UserID: "alice", UserName: "Alice"}
+	for _, info := range []UserInfo{bob, alice, bob} {
+		assert.NoError(t, room.Register(&Client{UserInfo: info, send: make(chan []byte, 16)}))
+	}
+
+	assert.Equal(t, []UserInfo{alice, bob}, room.Users())
+
+	room.Stop()
+	assert.Nil(t, room.Users())
+}
+
+func TestRoom_SubmitEdit_BroadcastsToAll(t *testing.T) {
+	// 测试场景：服务端提交的编辑作为 op-patch 广播给所有人，包括作者自己的连接
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	room := NewRoom("test-room", []byte(`{"title": "a"}`), mockService, nil)
+	defer room.Stop()
+
+	alice := &Client{UserInfo: UserInfo{UserID: "alice"}, RoomID: "test-room", send: make(chan []byte, 16)}
+	bob := &Client{UserInfo: UserInfo{UserID: "bob"}, RoomID: "test-room", send: make(chan []byte, 16)}
+	assert.NoError(t, room.Register(alice))
+	assert.NoError(t, room.Register(bob))
+
+	result, err := room.SubmitEdit(alice.UserInfo, []byte(`[{"op": "replace", "path": "/title", "value": "b"}]`), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), result.Version)
+
+	for _, c := range []*Client{alice, bob} {
+		msg := nextTestMessageOfType(t, c, TypeOpPatch)
+		assert.Equal(t, "alice", msg.SenderID)
+		var payload OpPatchPayload
+		assert.NoError(t, json.Unmarshal(msg.Payload, &payload))
+		assert.Equal(t, int64(1), payload.Version)
+	}
+
+	_, err = room.SubmitEdit(alice.UserInfo, []byte(`[{"op": "replace", "path": "/title", "value": "c"}]`), 1)
+	var conflict *VersionConflictError
+	assert.ErrorAs(t, err, &conflict)
+}
+
+func TestRoom_Register_RoomFull(t *testing.T) {
+	// 测试场景：连接数达到上限后新的加入请求被拒绝，被拒绝的客户端只收到 ROOM_FULL 错误，
+	// 发送通道随后关闭；有人离开后可以再次加入
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	hub := NewHub(mockService, WithMaxClientsPerRoom(2))
+	room := NewRoom("test-room", []byte(`{}`), mockService, hub)
+	defer room.Stop()
+	assert.Equal(t, 2, hub.Limits().MaxClientsPerRoom)
+
+	alice := &Client{UserInfo: UserInfo{UserID: "alice"}, send: make(chan []byte, 16)}
+	bob := &Client{UserInfo: UserInfo{UserID: "bob"}, send: make(chan []byte, 16)}
+	carol := &Client{UserInfo: UserInfo{UserID: "carol"}, send: make(chan []byte, 16)}
+	assert.NoError(t, room.Register(alice))
+	assert.NoError(t, room.Register(bob))
+
+	assert.ErrorIs(t, room.Register(carol), ErrRoomCapacity)
+	var messages []WSMessage
+	for data := range carol.send {
+		var msg WSMessage
+		assert.NoError(t, json.Unmarshal(data, &msg))
+		messages = append(messages, msg)
+	}
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, TypeError, messages[0].Type)
+		var payload ErrorPayload
+		assert.NoError(t, json.Unmarshal(messages[0].Payload, &payload))
+		assert.Equal(t, ErrRoomFull, payload.Code)
+	}
+	assert.Len(t, room.Users(), 2)
+
+	room.Unregister(bob)
+	dave := &Client{UserInfo: UserInfo{UserID: "dave"}, send: make(chan []byte, 16)}
+	assert.NoError(t, room.Register(dave))
+	assert.Len(t, room.Users(), 2)
+}
@@ -0,0 +1,219 @@
+package ws
+
+import (
+	"sort"
+
+	"lowercode-go-server/internal/logging"
+)
+
+// MaxSelectedComponents 单个用户同时选中的组件上限，超出部分被忽略
+const MaxSelectedComponents = 200
+
+// SelectionPayload select / selection-conflict 消息的 payload 结构
+type SelectionPayload struct {
+	ComponentID string     `json:"componentId"`     // 为空表示取消选中
+	Users       []UserInfo `json:"users,omitempty"` // 当前选中该组件的全部用户，仅 selection-conflict 携带
+}
+
+// SelectionChangePayload selection-change 消息的 payload 结构，空列表表示取消全部选中
+type SelectionChangePayload struct {
+	ComponentIDs []string `json:"componentIds"`
+
+	// ClientID 选中者的连接 ID，仅服务端广播时携带，同一用户的多个设备据此分别显示高亮
+	ClientID string `json:"clientId,omitempty"`
+}
+
+// UserSelection 某个用户当前选中的组件，随 sync 下发给新加入的用户
+type UserSelection struct {
+	UserID       string   `json:"userId"`
+	ClientID     string   `json:"clientId,omitempty"`
+	ComponentIDs []string `json:"componentIds"`
+}
+
+// selectionTable 记录每个客户端当前选中的组件（已去重、排序）。
+// 与 clients map 一样只在 Room.run() 内访问，无需加锁。
+type selectionTable struct {
+	byClient map[*Client][]string
+}
+
+func newSelectionTable() *selectionTable {
+	return &selectionTable{byClient: make(map[*Client][]string)}
+}
+
+// set 更新 c 的选中组件（空列表表示取消选中），返回之前选中的组件
+func (t *selectionTable) set(c *Client, componentIDs []string) []string {
+	previous := t.byClient[c]
+	if len(componentIDs) == 0 {
+		delete(t.byClient, c)
+	} else {
+		t.byClient[c] = componentIDs
+	}
+	return previous
+}
+
+// selectors 返回选中 componentID 的客户端，按用户 ID 排序保证消息稳定
+func (t *selectionTable) selectors(componentID string) []*Client {
+	var clients []*Client
+	for c, ids := range t.byClient {
+		if containsID(ids, componentID) {
+			clients = append(clients, c)
+		}
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		if clients[i].UserInfo.UserID != clients[j].UserInfo.UserID {
+			return clients[i].UserInfo.UserID < clients[j].UserInfo.UserID
+		}
+		return clients[i].UserInfo.ClientID < clients[j].UserInfo.ClientID
+	})
+	return clients
+}
+
+// snapshot 返回除 exclude 外所有客户端的选中状态，按用户 ID 排序
+func (t *selectionTable) snapshot(exclude *Client) []UserSelection {
+	var selections []UserSelection
+	for c, ids := range t.byClient {
+		if c == exclude {
+			continue
+		}
+		selections = append(selections, UserSelection{UserID: c.UserInfo.UserID, ClientID: c.UserInfo.ClientID, ComponentIDs: ids})
+	}
+	sort.Slice(selections, func(i, j int) bool {
+		if selections[i].UserID != selections[j].UserID {
+			return selections[i].UserID < selections[j].UserID
+		}
+		return selections[i].ClientID < selections[j].ClientID
+	})
+	return selections
+}
+
+// normalizeSelection 去重、排序并截断到 MaxSelectedComponents，忽略空 ID
+func normalizeSelection(componentIDs []string) []string {
+	seen := make(map[string]bool, len(componentIDs))
+	ids := make([]string, 0, len(componentIDs))
+	for _, id := range componentIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		if len(ids) == MaxSelectedComponents {
+			break
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// containsID 在已排序的 ids 中查找 id
+func containsID(ids []string, id string) bool {
+	i := sort.SearchStrings(ids, id)
+	return i < len(ids) && ids[i] == id
+}
+
+// diffIDs 返回在 a 中但不在 b 中的 ID，a、b 均已排序
+func diffIDs(a, b []string) []string {
+	var diff []string
+	for _, id := range a {
+		if !containsID(b, id) {
+			diff = append(diff, id)
+		}
+	}
+	return diff
+}
+
+// selectOp 客户端发往 Room 的选中变化
+type selectOp struct {
+	client       *Client
+	componentIDs []string
+}
+
+// handleSelectOp 更新选中状态，仅在 run() 内调用
+func (r *Room) handleSelectOp(op *selectOp) {
+	if _, ok := r.clients[op.client]; !ok || !r.collabSettings().Selections {
+		return
+	}
+	r.updateSelection(op.client, normalizeSelection(op.componentIDs))
+}
+
+// updateSelection 将 client 的选中组件改为 componentIDs：
+// 向其他用户广播 selection-change（非关键消息），并在冲突出现或解除时通知相关组件的选中者
+func (r *Room) updateSelection(client *Client, componentIDs []string) {
+	previous := r.selections.byClient[client]
+	removed := diffIDs(previous, componentIDs)
+	added := diffIDs(componentIDs, previous)
+	if len(removed) == 0 && len(added) == 0 {
+		return
+	}
+
+	// 记录变化前的人数，用于判断冲突是否解除
+	prevCounts := make(map[string]int, len(removed))
+	for _, id := range removed {
+		prevCounts[id] = len(r.selections.selectors(id))
+	}
+
+	r.selections.set(client, componentIDs)
+
+	r.deliver(&RoomBroadcast{
+		Message: encodeMessage(TypeSelectionChange, client.UserInfo.UserID, SelectionChangePayload{
+			ComponentIDs: componentIDs,
+			ClientID:     client.UserInfo.ClientID,
+		}),
+		Sender: client,
+	})
+
+	for _, id := range removed {
+		if prevCounts[id] >= 2 {
+			// 冲突解除或人数变化；离开者也要收到，以便清除提示
+			r.notifySelection(id, client)
+		}
+	}
+	for _, id := range added {
+		if len(r.selections.selectors(id)) >= 2 {
+			r.notifySelection(id, nil)
+		}
+	}
+}
+
+// notifySelection 向选中 componentID 的用户（及 extra）发送 selection-conflict。
+// users 少于 2 人表示冲突已解除。
+func (r *Room) notifySelection(componentID string, extra *Client) {
+	selectors := r.selections.selectors(componentID)
+
+	users := make([]UserInfo, 0, len(selectors))
+	for _, c := range selectors {
+		users = append(users, c.UserInfo)
+	}
+	data := encodeServerMessage(TypeSelectionConflict, SelectionPayload{
+		ComponentID: componentID,
+		Users:       users,
+	})
+
+	for _, c := range selectors {
+		r.sendToClient(c, data)
+	}
+	if extra != nil {
+		r.sendToClient(extra, data)
+	}
+
+	if len(users) >= 2 {
+		logging.Debugf("[Room %s] %d 位用户同时选中组件 %s", r.ID, len(users), componentID)
+	}
+}
+
+// clearSelectionOf 客户端断开时清除其选中状态，仅在 run() 内调用。
+// 其他用户通过 user-leave 清除该用户的高亮，这里只需处理冲突提示。
+func (r *Room) clearSelectionOf(client *Client) {
+	for _, id := range r.selections.set(client, nil) {
+		if len(r.selections.selectors(id)) >= 1 {
+			r.notifySelection(id, nil)
+		}
+	}
+}
+
+// Select 更新客户端当前选中的组件，componentIDs 为空表示取消选中
+func (r *Room) Select(client *Client, componentIDs []string) {
+	select {
+	case r.selectOps <- &selectOp{client: client, componentIDs: componentIDs}:
+	case <-r.stopChan:
+	}
+}
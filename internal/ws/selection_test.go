@@ -0,0 +1,148 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== 选中同步与冲突提示单元测试 ==========
+// 测试重点：选中变化转发给其他用户，多人选中同一组件时通知相关用户，冲突解除时通知清除
+
+func newSelectionTestClient(room *Room, userID string) *Client {
+	c := &Client{
+		UserInfo: UserInfo{UserID: userID, UserName: userID},
+		send:     make(chan []byte, 8),
+		Room:     room,
+	}
+	room.clients[c] = true
+	return c
+}
+
+// drainSelection 取出客户端收到的全部 selection-conflict 消息，跳过 selection-change
+func drainSelection(t *testing.T, c *Client) []SelectionPayload {
+	t.Helper()
+	var payloads []SelectionPayload
+	for {
+		select {
+		case data := <-c.send:
+			var msg WSMessage
+			assert.NoError(t, json.Unmarshal(data, &msg))
+			if msg.Type == TypeSelectionChange {
+				continue
+			}
+			assert.Equal(t, TypeSelectionConflict, msg.Type)
+			var payload SelectionPayload
+			assert.NoError(t, json.Unmarshal(msg.Payload, &payload))
+			payloads = append(payloads, payload)
+		default:
+			return payloads
+		}
+	}
+}
+
+func TestRoom_SelectionConflict(t *testing.T) {
+	// 测试场景：Alice 先选中 c1，Bob 随后也选中 c1，双方都收到包含两人的提示；
+	// Carol 选中其他组件不受影响
+
+	room := newTestRoom("test-room", []byte(`{}`), new(MockPageService))
+	room.selections = newSelectionTable()
+	alice := newSelectionTestClient(room, "alice")
+	bob := newSelectionTestClient(room, "bob")
+	carol := newSelectionTestClient(room, "carol")
+
+	room.handleSelectOp(&selectOp{client: alice, componentIDs: []string{"c1"}})
+	room.handleSelectOp(&selectOp{client: carol, componentIDs: []string{"c2"}})
+	assert.Empty(t, drainSelection(t, alice))
+
+	room.handleSelectOp(&selectOp{client: bob, componentIDs: []string{"c1"}})
+
+	for _, c := range []*Client{alice, bob} {
+		payloads := drainSelection(t, c)
+		assert.Len(t, payloads, 1)
+		assert.Equal(t, "c1", payloads[0].ComponentID)
+		assert.Equal(t, []UserInfo{alice.UserInfo, bob.UserInfo}, payloads[0].Users)
+	}
+	assert.Empty(t, drainSelection(t, carol))
+}
+
+func TestRoom_SelectionConflict_Resolved(t *testing.T) {
+	// 测试场景：Bob 取消选中或断开后，冲突解除，双方收到只含剩余用户的提示
+
+	room := newTestRoom("test-room", []byte(`{}`), new(MockPageService))
+	room.selections = newSelectionTable()
+	alice := newSelectionTestClient(room, "alice")
+	bob := newSelectionTestClient(room, "bob")
+
+	room.handleSelectOp(&selectOp{client: alice, componentIDs: []string{"c1"}})
+	room.handleSelectOp(&selectOp{client: bob, componentIDs: []string{"c1"}})
+	drainSelection(t, alice)
+	drainSelection(t, bob)
+
+	room.handleSelectOp(&selectOp{client: bob, componentIDs: nil})
+
+	for _, c := range []*Client{alice, bob} {
+		payloads := drainSelection(t, c)
+		assert.Len(t, payloads, 1)
+		assert.Equal(t, []UserInfo{alice.UserInfo}, payloads[0].Users)
+	}
+
+	// 再次冲突后 Bob 断开
+	room.handleSelectOp(&selectOp{client: bob, componentIDs: []string{"c1"}})
+	drainSelection(t, alice)
+	room.clearSelectionOf(bob)
+
+	payloads := drainSelection(t, alice)
+	assert.Len(t, payloads, 1)
+	assert.Len(t, payloads[0].Users, 1)
+}
+
+func TestRoom_SelectionChange_FanOut(t *testing.T) {
+	// 测试场景：Alice 多选组件后，其他用户收到 selection-change（去重排序），Alice 自己不收到；
+	// 新加入的用户在 sync 中看到 Alice 的选中状态
+
+	room := newTestRoom("test-room", []byte(`{}`), new(MockPageService))
+	room.selections = newSelectionTable()
+	alice := newSelectionTestClient(room, "alice")
+	bob := newSelectionTestClient(room, "bob")
+
+	room.handleSelectOp(&selectOp{client: alice, componentIDs: []string{"c2", "c1", "c2", ""}})
+
+	select {
+	case data := <-bob.send:
+		var msg WSMessage
+		assert.NoError(t, json.Unmarshal(data, &msg))
+		assert.Equal(t, TypeSelectionChange, msg.Type)
+		assert.Equal(t, "alice", msg.SenderID)
+		var payload SelectionChangePayload
+		assert.NoError(t, json.Unmarshal(msg.Payload, &payload))
+		assert.Equal(t, []string{"c1", "c2"}, payload.ComponentIDs)
+	default:
+		t.Fatal("bob 未收到 selection-change")
+	}
+	assert.Empty(t, alice.send)
+
+	// 选中内容不变时不重复广播
+	room.handleSelectOp(&selectOp{client: alice, componentIDs: []string{"c1", "c2"}})
+	assert.Empty(t, bob.send)
+
+	carol := newSelectionTestClient(room, "carol")
+	room.sendSyncToClient(carol)
+	var msg WSMessage
+	assert.NoError(t, json.Unmarshal(<-carol.send, &msg))
+	var sync SyncPayload
+	assert.NoError(t, json.Unmarshal(msg.Payload, &sync))
+	assert.Equal(t, []UserSelection{{UserID: "alice", ComponentIDs: []string{"c1", "c2"}}}, sync.Selections)
+}
+
+func TestNormalizeSelection_Limit(t *testing.T) {
+	// 测试场景：超过上限的选中组件被截断
+
+	ids := make([]string, 0, MaxSelectedComponents+10)
+	for i := 0; i < MaxSelectedComponents+10; i++ {
+		ids = append(ids, fmt.Sprintf("c%03d", i))
+	}
+	assert.Len(t, normalizeSelection(ids), MaxSelectedComponents)
+}
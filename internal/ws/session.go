@@ -0,0 +1,110 @@
+package ws
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+)
+
+// SessionPayload session 消息的 payload 结构，也随 sync / catch-up 下发。
+// Open 为 false 时所有者以外的用户只读，新的加入请求被拒绝
+type SessionPayload struct {
+	Open     bool      `json:"open"`
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+}
+
+// applySessionWindow 按当前协同设置中的协作时段更新房间模式，并把定时器设到下一个开始或结束时间。
+// 返回模式是否发生变化，仅在 run() 内或 run() 启动前调用
+func (r *Room) applySessionWindow(now time.Time) bool {
+	window := r.collabSettings().Session
+	closed := !window.Open(now)
+
+	r.stateMu.Lock()
+	changed := r.sessionClosed != closed
+	r.sessionClosed = closed
+	r.stateMu.Unlock()
+
+	r.sessionTimer.Stop()
+	if next := window.NextBoundary(now); !next.IsZero() {
+		r.sessionTimer.Reset(next.Sub(now))
+	}
+	return changed
+}
+
+// handleSessionBoundary 到达协作时段的开始或结束时间，仅在 run() 内调用
+func (r *Room) handleSessionBoundary() {
+	if r.applySessionWindow(time.Now()) {
+		r.sessionChanged()
+	}
+}
+
+// sessionChanged 协作时段开始或结束后通知所有人；结束时收回所有者以外用户的组件锁，仅在 run() 内调用
+func (r *Room) sessionChanged() {
+	state := r.sessionState()
+	if state == nil {
+		// 时段被取消，房间恢复可编辑
+		state = &SessionPayload{Open: true}
+	}
+
+	if !state.Open {
+		for client := range r.clients {
+			if client.UserInfo.Role != entity.RoleOwner {
+				r.releaseLocksOf(client, LockReasonSession)
+			}
+		}
+	}
+
+	data := encodeServerMessage(TypeSession, state)
+	for client := range r.clients {
+		r.sendToClient(client, data)
+	}
+	if state.Open {
+		log.Printf("[Room %s] 协作时段开始，恢复编辑", r.ID)
+	} else {
+		log.Printf("[Room %s] 不在协作时段内，房间对所有者以外的用户只读", r.ID)
+	}
+}
+
+// sessionState 返回协作时段及当前是否开放，未设置时段时返回 nil，仅在 run() 内调用
+func (r *Room) sessionState() *SessionPayload {
+	window := r.collabSettings().Session
+	if window == nil {
+		return nil
+	}
+	return &SessionPayload{Open: r.SessionOpen(), StartsAt: window.StartsAt, EndsAt: window.EndsAt}
+}
+
+// SessionOpen 判断当前是否在页面的协作时段内，未设置时段时总是 true。
+// 时段外所有者以外的用户只读，可在任意 goroutine 中调用
+func (r *Room) SessionOpen() bool {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+
+	return !r.sessionClosed
+}
+
+// rejectSessionClosed 拒绝协作时段外所有者以外用户的加入请求，仅在 run() 内调用。
+// 客户端尚未加入房间，不会收到 sync，也不会触发 user-join
+func (r *Room) rejectSessionClosed(client *Client) {
+	message := "当前不在页面的协作时段内"
+	if window := r.collabSettings().Session; window != nil {
+		message = fmt.Sprintf("当前不在页面的协作时段内（%s ~ %s）",
+			window.StartsAt.Format(time.RFC3339), window.EndsAt.Format(time.RFC3339))
+	}
+	client.send <- encodeServerMessage(TypeError, ErrorPayload{Code: ErrSessionClosed, Message: message})
+	close(client.send)
+	log.Printf("[Room %s] 不在协作时段内，拒绝用户 [%s] 加入", r.ID, client.UserInfo.UserName)
+}
+
+// admitSession 检查 client 能否在当前时段加入，不能加入时已拒绝并通知客户端，仅在 run() 内调用
+func (r *Room) admitSession(client *Client) error {
+	if client.UserInfo.Role == entity.RoleOwner || r.SessionOpen() {
+		return nil
+	}
+	r.rejectSessionClosed(client)
+	return domainErrors.ErrSessionClosed
+}
@@ -0,0 +1,169 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 协作时段单元测试 ==========
+
+// newSessionTestRoom 创建按 window 加载协作时段的运行中房间
+func newSessionTestRoom(t *testing.T, window *entity.SessionWindow) *Room {
+	t.Helper()
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	hub := NewHub(mockService, WithSettingsStore(&MockSettingsStore{
+		settings: entity.CollabSettings{Cursors: true, Selections: true, Chat: true, Session: window},
+	}))
+	room := NewRoom("page-1", []byte(`{}`), mockService, hub)
+	t.Cleanup(room.Stop)
+	return room
+}
+
+// drainUntil 读取客户端收到的消息直到出现 msgType，超时则失败
+func drainUntil(t *testing.T, c *Client, msgType MessageType, payload interface{}) {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case data := <-c.send:
+			var msg WSMessage
+			require.NoError(t, json.Unmarshal(data, &msg))
+			if msg.Type == msgType {
+				require.NoError(t, json.Unmarshal(msg.Payload, payload))
+				return
+			}
+		case <-timeout:
+			t.Fatalf("%s 未收到 %s 消息", c.UserInfo.UserID, msgType)
+		}
+	}
+}
+
+func TestSessionWindow(t *testing.T) {
+	// 测试场景：时段前后关闭、时段内开放，下一个边界依次为开始和结束时间；未设置时总是开放
+
+	start := time.Date(2026, 5, 1, 14, 0, 0, 0, time.UTC)
+	window := &entity.SessionWindow{StartsAt: start, EndsAt: start.Add(2 * time.Hour)}
+
+	assert.False(t, window.Open(start.Add(-time.Minute)))
+	assert.True(t, window.Open(start))
+	assert.False(t, window.Open(window.EndsAt))
+
+	assert.Equal(t, start, window.NextBoundary(start.Add(-time.Minute)))
+	assert.Equal(t, window.EndsAt, window.NextBoundary(start))
+	assert.True(t, window.NextBoundary(window.EndsAt).IsZero())
+
+	var none *entity.SessionWindow
+	assert.True(t, none.Open(start))
+	assert.True(t, none.NextBoundary(start).IsZero())
+
+	assert.True(t, window.Valid())
+	assert.False(t, entity.SessionWindow{StartsAt: start, EndsAt: start}.Valid())
+	assert.False(t, entity.SessionWindow{StartsAt: start, EndsAt: start.Add(entity.MaxSessionDuration + time.Second)}.Valid())
+}
+
+func TestRoom_Session_RejectJoin(t *testing.T) {
+	// 测试场景：时段外所有者以外的用户加入被拒绝，只收到 SESSION_CLOSED 错误；
+	// 所有者可以加入，sync 中带有时段状态，编辑不受限
+
+	start := time.Now().Add(time.Hour)
+	room := newSessionTestRoom(t, &entity.SessionWindow{StartsAt: start, EndsAt: start.Add(time.Hour)})
+	assert.False(t, room.SessionOpen())
+
+	editor := &Client{UserInfo: UserInfo{UserID: "bob", Role: entity.RoleEditor}, send: make(chan []byte, 16)}
+	assert.ErrorIs(t, room.Register(editor), domainErrors.ErrSessionClosed)
+	var messages []WSMessage
+	for data := range editor.send {
+		var msg WSMessage
+		require.NoError(t, json.Unmarshal(data, &msg))
+		messages = append(messages, msg)
+	}
+	if assert.Len(t, messages, 1) {
+		var payload ErrorPayload
+		require.NoError(t, json.Unmarshal(messages[0].Payload, &payload))
+		assert.Equal(t, ErrSessionClosed, payload.Code)
+	}
+
+	owner := &Client{UserInfo: UserInfo{UserID: "alice", Role: entity.RoleOwner}, send: make(chan []byte, 16)}
+	require.NoError(t, room.Register(owner))
+	var sync SyncPayload
+	drainUntil(t, owner, TypeSync, &sync)
+	require.NotNil(t, sync.Session)
+	assert.False(t, sync.Session.Open)
+	assert.True(t, sync.Session.StartsAt.Equal(start))
+
+	code, reason := owner.editDenied()
+	assert.Empty(t, reason)
+	assert.Empty(t, code)
+	assert.Len(t, room.Users(), 1)
+}
+
+func TestRoom_Session_Ends(t *testing.T) {
+	// 测试场景：时段结束时所有人收到 session（open=false），所有者以外用户的锁被收回且编辑被拒绝
+
+	start := time.Now().Add(-time.Hour)
+	room := newSessionTestRoom(t, &entity.SessionWindow{StartsAt: start, EndsAt: time.Now().Add(200 * time.Millisecond)})
+	assert.True(t, room.SessionOpen())
+
+	owner := &Client{UserInfo: UserInfo{UserID: "alice", Role: entity.RoleOwner}, send: make(chan []byte, 64)}
+	editor := &Client{UserInfo: UserInfo{UserID: "bob", Role: entity.RoleEditor}, send: make(chan []byte, 64)}
+	require.NoError(t, room.Register(owner))
+	require.NoError(t, room.Register(editor))
+	room.lockOps <- &lockOp{kind: lockOpAcquire, componentID: "1", client: editor}
+
+	var released LockPayload
+	drainUntil(t, owner, TypeLockReleased, &released)
+	assert.Equal(t, LockReasonSession, released.Reason)
+
+	var state SessionPayload
+	drainUntil(t, editor, TypeSession, &state)
+	assert.False(t, state.Open)
+	assert.False(t, room.SessionOpen())
+
+	code, reason := editor.editDenied()
+	assert.Equal(t, ErrSessionClosed, code)
+	assert.NotEmpty(t, reason)
+	code, _ = owner.editDenied()
+	assert.Empty(t, code)
+}
+
+func TestRoom_Session_SettingsUpdate(t *testing.T) {
+	// 测试场景：运行中设置已开始的时段无变化；改为尚未开始的时段后房间只读，取消后恢复
+
+	room, alice, bob := newSettingsTestRoom(nil)
+	bob.UserInfo.Role = entity.RoleEditor
+
+	now := time.Now()
+	settings := entity.DefaultCollabSettings()
+	settings.Session = &entity.SessionWindow{StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)}
+	room.handleSettingsOp(settings)
+	var updated entity.CollabSettings
+	readMessage(t, bob, TypeCollabSettings, &updated)
+	readMessage(t, alice, TypeCollabSettings, &updated)
+	assert.Empty(t, bob.send)
+
+	settings.Session = &entity.SessionWindow{StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)}
+	room.handleSettingsOp(settings)
+	readMessage(t, bob, TypeCollabSettings, &updated)
+	var state SessionPayload
+	readMessage(t, bob, TypeSession, &state)
+	assert.False(t, state.Open)
+	assert.False(t, room.SessionOpen())
+	readMessage(t, alice, TypeCollabSettings, &updated)
+	readMessage(t, alice, TypeSession, &state)
+
+	settings.Session = nil
+	room.handleSettingsOp(settings)
+	readMessage(t, bob, TypeCollabSettings, &updated)
+	readMessage(t, bob, TypeSession, &state)
+	assert.True(t, state.Open)
+	assert.True(t, room.SessionOpen())
+}
@@ -0,0 +1,122 @@
+package ws
+
+import (
+	"log"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/logging"
+)
+
+// SettingsStore 页面级协同设置的读取接口，由 repository 层实现
+type SettingsStore interface {
+	// CollabSettings 返回页面的协同设置，页面不存在或未设置时返回默认设置
+	CollabSettings(pageID string) (entity.CollabSettings, error)
+}
+
+// WithSettingsStore 房间创建时读取页面级协同设置。
+// 未配置时所有房间使用默认设置：协同功能全部开启，刷盘节奏使用服务默认值。
+func WithSettingsStore(store SettingsStore) HubOption {
+	return func(h *Hub) {
+		h.settings = store
+	}
+}
+
+// loadSettings 读取页面的协同设置，在 run() 启动前调用，读取失败时使用默认设置
+func (r *Room) loadSettings() {
+	if r.settingsStore == nil {
+		return
+	}
+
+	settings, err := r.settingsStore.CollabSettings(r.ID)
+	if err != nil {
+		logging.Warnf("[Room %s] 读取协同设置失败，使用默认设置: %v", r.ID, err)
+		return
+	}
+	r.setSettings(settings)
+}
+
+// collabSettings 返回当前生效的协同设置，仅在 run() 内或 run() 启动前调用
+func (r *Room) collabSettings() entity.CollabSettings {
+	if r.settings == nil {
+		return entity.DefaultCollabSettings()
+	}
+	return *r.settings
+}
+
+// setSettings 保存设置并按其调整刷盘节奏和协作时段，返回房间是否因此进入或离开协作时段，
+// 仅在 run() 内或 run() 启动前调用
+func (r *Room) setSettings(settings entity.CollabSettings) bool {
+	r.settings = &settings
+
+	interval := settings.FlushInterval()
+
+	r.stateMu.Lock()
+	r.flushThreshold = int64(settings.FlushThreshold)
+	r.resolver = resolverFor(settings.ConflictStrategy())
+	if interval <= 0 {
+		// 未覆盖时恢复自适应的间隔
+		interval = r.adaptiveIntervalLocked()
+	}
+	r.flushEvery = interval
+	r.stateMu.Unlock()
+
+	r.flushTicker.Reset(interval)
+
+	return r.applySessionWindow(time.Now())
+}
+
+// handleSettingsOp 应用新的协同设置并广播给所有人，仅在 run() 内调用。
+// 关闭选中同步时先清除所有人的选中状态，其他人的选中高亮随之消失；
+// 修改协作时段使房间进入或离开时段时另外广播 session
+func (r *Room) handleSettingsOp(settings entity.CollabSettings) {
+	if !settings.Selections && r.collabSettings().Selections {
+		for client := range r.clients {
+			r.updateSelection(client, nil)
+		}
+	}
+	changed := r.setSettings(settings)
+
+	data := encodeServerMessage(TypeCollabSettings, settings)
+	for client := range r.clients {
+		r.sendToClient(client, data)
+	}
+	if changed {
+		r.sessionChanged()
+	}
+	log.Printf("[Room %s] 协同设置已更新: 光标=%v 选中=%v 聊天=%v 刷盘间隔=%ds 刷盘阈值=%d 冲突策略=%s",
+		r.ID, settings.Cursors, settings.Selections, settings.Chat,
+		settings.FlushIntervalSeconds, settings.FlushThreshold, settings.ConflictStrategy())
+}
+
+// flushThresholdLocked 返回触发刷盘的未保存版本数，页面设置覆盖的阈值优先，调用方需持有 stateMu
+func (r *Room) flushThresholdLocked() int64 {
+	if r.flushThreshold > 0 {
+		return r.flushThreshold
+	}
+	if r.adaptiveThreshold > 0 {
+		return r.adaptiveThreshold
+	}
+	return DefaultFlushPolicy.MinThreshold
+}
+
+// SetCollabSettings 更新房间的协同设置，房间内所有人会收到 collab-settings 消息。
+// 新的刷盘间隔从下一个周期开始生效
+func (r *Room) SetCollabSettings(settings entity.CollabSettings) {
+	select {
+	case r.settingsOps <- settings:
+	case <-r.stopChan:
+	}
+}
+
+// broadcastCursor 广播光标、视口等感知类消息，页面关闭光标广播时在 Room 内丢弃；
+// 服务过载时按 WatchdogConfig.CursorInterval 降频，只在 sender 的 ReadPump 中调用
+func (r *Room) broadcastCursor(message []byte, sender *Client) {
+	if r.hub != nil && r.hub.watchdog.throttleCursor(sender, time.Now()) {
+		return
+	}
+	select {
+	case r.broadcast <- &RoomBroadcast{Message: message, Sender: sender, Cursor: true}:
+	case <-r.stopChan:
+	}
+}
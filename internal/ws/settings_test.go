@@ -0,0 +1,107 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== 页面级协同设置单元测试 ==========
+
+// newSettingsTestRoom 创建含两个客户端的房间，settings 非空时按其加载页面设置
+func newSettingsTestRoom(settings *entity.CollabSettings) (*Room, *Client, *Client) {
+	room, alice, bob := newChatTestRoom(&MockChatStore{})
+	room.selections = newSelectionTable()
+	if settings != nil {
+		room.settingsStore = &MockSettingsStore{settings: *settings}
+		room.loadSettings()
+	}
+	return room, alice, bob
+}
+
+func TestRoom_CollabSettings_Defaults(t *testing.T) {
+	// 测试场景：未配置设置时所有功能开启，刷盘阈值使用 DefaultFlushPolicy.MinThreshold；sync 中带有当前设置
+
+	room, alice, bob := newSettingsTestRoom(nil)
+	assert.Equal(t, entity.DefaultCollabSettings(), room.collabSettings())
+	assert.Equal(t, DefaultFlushPolicy.MinThreshold, room.flushThresholdLocked())
+
+	room.deliver(&RoomBroadcast{Message: []byte(`cursor`), Sender: alice, Cursor: true})
+	assert.Equal(t, []byte(`cursor`), <-bob.send)
+
+	data, _ := room.encodeSync(alice)
+	var msg WSMessage
+	assert.NoError(t, json.Unmarshal(data, &msg))
+	var sync SyncPayload
+	assert.NoError(t, json.Unmarshal(msg.Payload, &sync))
+	assert.True(t, sync.Settings.Cursors)
+	assert.True(t, sync.Settings.Chat)
+}
+
+func TestRoom_CollabSettings_Disabled(t *testing.T) {
+	// 测试场景：关闭光标、选中、聊天后，光标和视口广播被丢弃，选中不再同步，
+	// 聊天收到 FEATURE_DISABLED；编辑等其他广播不受影响；刷盘阈值使用覆盖值
+
+	room, alice, bob := newSettingsTestRoom(&entity.CollabSettings{FlushThreshold: 5})
+	assert.Equal(t, int64(5), room.flushThresholdLocked())
+
+	room.deliver(&RoomBroadcast{Message: []byte(`cursor`), Sender: alice, Cursor: true})
+	room.deliver(&RoomBroadcast{Message: []byte(`patch`), Sender: alice, IsCritical: true})
+	assert.Equal(t, []byte(`patch`), <-bob.send)
+	assert.Empty(t, bob.send)
+
+	room.handleSelectOp(&selectOp{client: alice, componentIDs: []string{"1"}})
+	assert.Empty(t, bob.send)
+	assert.Empty(t, room.selections.byClient[alice])
+
+	room.handleChatOp(&chatOp{client: alice, text: "hello"})
+	var errPayload ErrorPayload
+	readMessage(t, alice, TypeError, &errPayload)
+	assert.Equal(t, ErrFeatureDisabled, errPayload.Code)
+	assert.Empty(t, bob.send)
+	assert.Empty(t, room.chatHistory)
+}
+
+func TestRoom_CollabSettings_Update(t *testing.T) {
+	// 测试场景：运行中修改设置时所有人收到 collab-settings；关闭选中同步会清除已有的选中状态
+
+	room, alice, bob := newSettingsTestRoom(nil)
+	room.handleSelectOp(&selectOp{client: alice, componentIDs: []string{"1"}})
+	var selection SelectionChangePayload
+	readMessage(t, bob, TypeSelectionChange, &selection)
+
+	updated := entity.CollabSettings{Cursors: true, Chat: true, FlushIntervalSeconds: 60, FlushThreshold: 10}
+	room.handleSettingsOp(updated)
+
+	// 选中被清除，其他人收到空的 selection-change
+	readMessage(t, bob, TypeSelectionChange, &selection)
+	assert.Empty(t, selection.ComponentIDs)
+	assert.Empty(t, room.selections.byClient[alice])
+
+	for _, c := range []*Client{alice, bob} {
+		var settings entity.CollabSettings
+		readMessage(t, c, TypeCollabSettings, &settings)
+		assert.Equal(t, updated, settings)
+	}
+	assert.Equal(t, int64(10), room.flushThresholdLocked())
+}
+
+func TestCollabSettings_Parse(t *testing.T) {
+	// 测试场景：缺省字段取默认值，无法解析时返回默认设置；刷盘覆盖值超出范围时无效
+
+	assert.Equal(t, entity.DefaultCollabSettings(), entity.ParseCollabSettings(nil))
+	assert.Equal(t, entity.DefaultCollabSettings(), entity.ParseCollabSettings([]byte(`not json`)))
+
+	settings := entity.ParseCollabSettings([]byte(`{"cursors": false, "flushThreshold": 20}`))
+	assert.False(t, settings.Cursors)
+	assert.True(t, settings.Chat)
+	assert.Equal(t, 20, settings.FlushThreshold)
+	assert.True(t, settings.Valid())
+
+	assert.False(t, entity.CollabSettings{FlushIntervalSeconds: 1}.Valid())
+	assert.False(t, entity.CollabSettings{FlushThreshold: entity.MaxFlushThreshold + 1}.Valid())
+	assert.True(t, entity.CollabSettings{FlushIntervalSeconds: 300}.Valid())
+}
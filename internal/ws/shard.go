@@ -0,0 +1,75 @@
+package ws
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// DefaultHubShards Hub 默认的分片数
+const DefaultHubShards = 32
+
+// idleQueueSize 每个分片空闲房间通道的缓冲大小
+const idleQueueSize = 16
+
+// hubShard Hub 的一个分片：按房间 ID 哈希分配，各分片的房间表、锁和空闲房间通道互相独立，
+// 房间数很多时创建、查找和销毁房间不再争用同一把全局锁
+type hubShard struct {
+	mu    sync.RWMutex
+	rooms map[string]*Room
+	idle  chan *Room // 空闲房间信号通道，由 Run 为每个分片启动的协程处理
+}
+
+func newHubShard() *hubShard {
+	return &hubShard{
+		rooms: make(map[string]*Room),
+		idle:  make(chan *Room, idleQueueSize),
+	}
+}
+
+// WithShards 设置 Hub 的分片数，n <= 0 时使用 DefaultHubShards
+func WithShards(n int) HubOption {
+	return func(h *Hub) {
+		if n <= 0 {
+			n = DefaultHubShards
+		}
+		h.shards = make([]*hubShard, n)
+		for i := range h.shards {
+			h.shards[i] = newHubShard()
+		}
+	}
+}
+
+// shardFor 返回房间所在的分片
+func (h *Hub) shardFor(roomID string) *hubShard {
+	if len(h.shards) == 1 {
+		return h.shards[0]
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(roomID))
+	return h.shards[hash.Sum32()%uint32(len(h.shards))]
+}
+
+// allRooms 返回所有分片中房间的快照，逐个分片加读锁，不会同时持有多把锁
+func (h *Hub) allRooms() []*Room {
+	var rooms []*Room
+	for _, shard := range h.shards {
+		shard.mu.RLock()
+		for _, room := range shard.rooms {
+			rooms = append(rooms, room)
+		}
+		shard.mu.RUnlock()
+	}
+	return rooms
+}
+
+// removeRoom 从所在分片中移除房间；检查指针同一性，防止误删新创建的同名房间。返回是否移除
+func (h *Hub) removeRoom(room *Room) bool {
+	shard := h.shardFor(room.ID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if current, ok := shard.rooms[room.ID]; ok && current == room {
+		delete(shard.rooms, room.ID)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package ws
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	domainErrors "lowercode-go-server/domain/errors"
+
+	"github.com/gorilla/websocket"
+)
+
+// restartCloseReason 服务重启时关闭帧携带的原因
+const restartCloseReason = "server restarting"
+
+// Shutdown 优雅停机：不再创建房间，通知所有房间的客户端服务即将重启，
+// 并行刷盘并停止全部房间，最后以 1012 (Service Restart) 关闭帧断开连接。
+// 应在 HTTP 服务 Shutdown 之前调用，被劫持的 WebSocket 连接不受其管理。
+// ctx 到期时不再等待，返回 ctx.Err()，未完成的房间仍在后台继续刷盘。
+func (h *Hub) Shutdown(ctx context.Context) error {
+	// 先标记停机再逐个分片取快照：创建房间时在分片写锁内检查标记，
+	// 标记之前创建的房间一定已在分片中，会出现在快照里
+	h.draining.Store(true)
+	rooms := h.allRooms()
+
+	log.Printf("[Hub] 开始优雅停机，待关闭房间数: %d", len(rooms))
+
+	var wg sync.WaitGroup
+	for _, room := range rooms {
+		wg.Add(1)
+		go func(room *Room) {
+			defer wg.Done()
+			room.drain()
+			h.removeRoom(room)
+		}(room)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Println("[Hub] 全部房间已刷盘关闭")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// checkDraining 停机期间拒绝创建房间，调用方需持有房间所在分片的锁
+func (h *Hub) checkDraining() error {
+	if h.draining.Load() {
+		return domainErrors.ErrServerShuttingDown
+	}
+	return nil
+}
+
+// drain 因服务重启停止房间，阻塞等待刷盘完成。
+// 与 Stop 相同地停止事件循环，run() 退出时额外通知并断开仍在房间内的客户端
+func (r *Room) drain() {
+	r.countMu.Lock()
+	if r.stopping {
+		r.countMu.Unlock()
+		<-r.doneChan
+		return
+	}
+	r.stopping = true
+	r.draining = true
+	r.countMu.Unlock()
+
+	close(r.stopChan)
+	<-r.doneChan
+}
+
+// announceRestart 通知房间内的客户端服务即将重启，仅在 run() 退出时调用。
+// 缓冲区满时放弃通知，客户端仍会收到关闭帧
+func (r *Room) announceRestart() {
+	r.stateMu.RLock()
+	version := r.Version
+	r.stateMu.RUnlock()
+
+	data := encodeServerMessage(TypeServerRestarting, ServerRestartingPayload{
+		Version: version,
+		Message: "服务正在重启，请稍后重新连接",
+	})
+	for client := range r.clients {
+		r.sendToClient(client, data)
+	}
+}
+
+// closeClientsForRestart 关闭全部客户端的发送通道，WritePump 随后发送 1012 关闭帧并断开连接，
+// 仅在 run() 退出时、刷盘之后调用
+func (r *Room) closeClientsForRestart() {
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseServiceRestart, restartCloseReason)
+	for client := range r.clients {
+		client.closeMessage = closeMessage
+		close(client.send)
+		delete(r.clients, client)
+		r.updateClientCount(-1)
+	}
+}
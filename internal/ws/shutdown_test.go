@@ -0,0 +1,94 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	domainErrors "lowercode-go-server/domain/errors"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 优雅停机单元测试 ==========
+
+func TestHub_Shutdown(t *testing.T) {
+	// 测试场景：停机时客户端先收到 server-restarting，房间刷盘后连接以 1012 关闭；
+	// 之后不再创建房间，已停止房间拒绝编辑
+
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", "page-1").Return([]byte(`{"title": "a"}`), int64(3), nil)
+	mockService.On("SavePageState", "page-1", mock.Anything, int64(3), int64(4)).Return(nil).Once()
+	hub := NewHub(mockService)
+	go hub.Run()
+
+	var upgrader websocket.Upgrader
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		room, err := hub.GetOrCreateRoom("page-1")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := NewClient(hub, conn, room.ID, UserInfo{UserID: r.URL.Query().Get("user")})
+		if err := room.Register(client); err != nil {
+			conn.Close()
+			return
+		}
+		go client.WritePump()
+		go client.ReadPump()
+	}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	conns := make([]*websocket.Conn, 0, 2)
+	for _, user := range []string{"alice", "bob"} {
+		conn, _, err := websocket.DefaultDialer.Dial(url+"?user="+user, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		readFrameOfType(t, conn, TypeSync)
+		conns = append(conns, conn)
+	}
+
+	room := hub.GetRoom("page-1")
+	require.NotNil(t, room)
+	_, err := room.SubmitEdit(UserInfo{UserID: "alice"}, []byte(`[{"op": "replace", "path": "/title", "value": "b"}]`), 3)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, hub.Shutdown(ctx))
+	mockService.AssertExpectations(t)
+	assert.Nil(t, hub.GetRoom("page-1"))
+
+	for _, conn := range conns {
+		_, msg := readFrameOfType(t, conn, TypeServerRestarting)
+		var payload ServerRestartingPayload
+		require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+		assert.Equal(t, int64(4), payload.Version)
+
+		var err error
+		for err == nil {
+			_, _, err = conn.ReadMessage()
+		}
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, websocket.CloseServiceRestart, closeErr.Code)
+		assert.Equal(t, restartCloseReason, closeErr.Text)
+	}
+
+	_, err = hub.GetOrCreateRoom("page-1")
+	assert.ErrorIs(t, err, domainErrors.ErrServerShuttingDown)
+	_, err = room.ApplyEdit(UserInfo{UserID: "alice"}, []byte(`[{"op": "replace", "path": "/title", "value": "c"}]`), 4)
+	assert.ErrorIs(t, err, ErrRoomClosed)
+}
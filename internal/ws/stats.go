@@ -0,0 +1,146 @@
+package ws
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LogSampleInterval 同一房间同类高频日志（Patch 应用、Patch 失败、刷盘完成）的最小输出间隔。
+// 间隔内的日志只计数，下一条输出时附带省略条数和房间累计计数。
+const LogSampleInterval = 10 * time.Second
+
+// patchMetrics 全部房间的 Patch 与刷盘计数，通过 expvar 暴露
+var patchMetrics = expvar.NewMap("ws_patches")
+
+// roomStats 房间级累计计数，使用原子操作，可在任意 goroutine 中读写
+type roomStats struct {
+	applied   atomic.Int64
+	conflicts atomic.Int64
+	failed    atomic.Int64
+	flushes   atomic.Int64
+}
+
+// recordPatch 按 Patch 应用结果累加房间和全局计数
+func (s *roomStats) recordPatch(err error) {
+	var conflict *VersionConflictError
+	switch {
+	case err == nil:
+		s.applied.Add(1)
+		patchMetrics.Add("applied", 1)
+	case errors.As(err, &conflict):
+		s.conflicts.Add(1)
+		patchMetrics.Add("conflicts", 1)
+	default:
+		s.failed.Add(1)
+		patchMetrics.Add("failed", 1)
+	}
+}
+
+// recordFlush 记录一次成功刷盘
+func (s *roomStats) recordFlush() {
+	s.flushes.Add(1)
+	patchMetrics.Add("flushes", 1)
+}
+
+// summary 房间累计计数的单行摘要，附加在采样日志末尾
+func (s *roomStats) summary() string {
+	return fmt.Sprintf("累计 应用 %d / 冲突 %d / 失败 %d / 刷盘 %d",
+		s.applied.Load(), s.conflicts.Load(), s.failed.Load(), s.flushes.Load())
+}
+
+// logSampler 日志采样器，零值可用
+type logSampler struct {
+	mu         sync.Mutex
+	last       time.Time
+	suppressed int
+}
+
+// allow 距上次输出超过 LogSampleInterval 时放行，并返回期间省略的条数；否则只计数
+func (s *logSampler) allow(now time.Time) (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.last.IsZero() && now.Sub(s.last) < LogSampleInterval {
+		s.suppressed++
+		patchMetrics.Add("logs_suppressed", 1)
+		return false, 0
+	}
+	skipped := s.suppressed
+	s.last = now
+	s.suppressed = 0
+	return true, skipped
+}
+
+// logSampled 经 sampler 采样后输出日志，放行时附带省略条数和房间累计计数
+func (r *Room) logSampled(sampler *logSampler, format string, args ...interface{}) {
+	ok, skipped := sampler.allow(time.Now())
+	if !ok {
+		return
+	}
+	log.Printf("%s（%s 内省略 %d 条，%s）",
+		fmt.Sprintf(format, args...), LogSampleInterval, skipped, r.stats.summary())
+}
+
+// RoomStats 房间运行计数，供运维接口展示
+type RoomStats struct {
+	PageID         string `json:"pageId"`
+	Clients        int    `json:"clients"`
+	Version        int64  `json:"version"`
+	PatchesApplied int64  `json:"patchesApplied"`
+	PatchConflicts int64  `json:"patchConflicts"`
+	PatchFailures  int64  `json:"patchFailures"`
+	Flushes        int64  `json:"flushes"`
+
+	// Flush 当前的刷盘节奏，见 FlushPolicy
+	Flush FlushPace `json:"flush"`
+
+	// Messages 按方向（in / out）和消息类型统计的条数、字节数与大小分布
+	Messages map[string]map[MessageType]TypeStats `json:"messages,omitempty"`
+
+	// Compression 已协商 permessage-deflate 的连接压缩发送的计数，没有压缩过任何帧时省略
+	Compression *CompressionStats `json:"compression,omitempty"`
+}
+
+// Stats 返回房间当前的运行计数
+func (r *Room) Stats() RoomStats {
+	r.stateMu.RLock()
+	version := r.Version
+	flush := r.flushPaceLocked()
+	r.stateMu.RUnlock()
+
+	return RoomStats{
+		PageID:         r.ID,
+		Clients:        r.ClientCount(),
+		Version:        version,
+		PatchesApplied: r.stats.applied.Load(),
+		PatchConflicts: r.stats.conflicts.Load(),
+		PatchFailures:  r.stats.failed.Load(),
+		Flushes:        r.stats.flushes.Load(),
+		Flush:          flush,
+		Messages:       r.messages.snapshot(),
+		Compression:    r.compression.snapshot(),
+	}
+}
+
+// RoomStats 返回所有房间的运行计数，按已应用 Patch 数降序排列，便于定位繁忙房间
+func (h *Hub) RoomStats() []RoomStats {
+	rooms := h.allRooms()
+
+	stats := make([]RoomStats, 0, len(rooms))
+	for _, room := range rooms {
+		stats = append(stats, room.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].PatchesApplied != stats[j].PatchesApplied {
+			return stats[i].PatchesApplied > stats[j].PatchesApplied
+		}
+		return stats[i].PageID < stats[j].PageID
+	})
+	return stats
+}
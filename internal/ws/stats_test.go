@@ -0,0 +1,55 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== 房间计数与日志采样单元测试 ==========
+
+func TestLogSampler(t *testing.T) {
+	// 测试场景：首条日志放行；采样间隔内的日志只计数；
+	// 间隔过后放行并返回期间省略的条数
+
+	var s logSampler
+	start := time.Now()
+
+	ok, skipped := s.allow(start)
+	assert.True(t, ok)
+	assert.Equal(t, 0, skipped)
+
+	for i := 1; i <= 3; i++ {
+		ok, _ = s.allow(start.Add(time.Duration(i) * time.Second))
+		assert.False(t, ok)
+	}
+
+	ok, skipped = s.allow(start.Add(LogSampleInterval))
+	assert.True(t, ok)
+	assert.Equal(t, 3, skipped)
+
+	ok, _ = s.allow(start.Add(LogSampleInterval + time.Second))
+	assert.False(t, ok)
+}
+
+func TestRoom_Stats_CountsPatchResults(t *testing.T) {
+	// 测试场景：成功、版本冲突、Patch 无效分别计入应用、冲突、失败计数
+
+	room := newTestRoom("test-room", []byte(`{"components": {}}`), new(MockPageService))
+
+	assert.NoError(t, room.ApplyPatch([]byte(`[{"op": "add", "path": "/components/a", "value": 1}]`), 1))
+	assert.NoError(t, room.ApplyPatch([]byte(`[{"op": "add", "path": "/components/b", "value": 2}]`), 2))
+	assert.Error(t, room.ApplyPatch([]byte(`[{"op": "add", "path": "/components/c", "value": 3}]`), 1))
+	assert.Error(t, room.ApplyPatch([]byte(`[{"op": "remove", "path": "/missing"}]`), 3))
+
+	assert.Equal(t, RoomStats{
+		PageID:         "test-room",
+		Version:        3,
+		PatchesApplied: 2,
+		PatchConflicts: 1,
+		PatchFailures:  1,
+		Flush:          FlushPace{Interval: "0s", Threshold: DefaultFlushPolicy.MinThreshold, Latency: "0s"},
+		Messages:       map[string]map[MessageType]TypeStats{},
+	}, room.Stats())
+}
@@ -0,0 +1,224 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"lowercode-go-server/internal/jsondiff"
+	"lowercode-go-server/internal/ot"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// --- 能力协商 ---
+
+// CapabilityTextOT 文本属性的 OT 协同能力
+const CapabilityTextOT = "text-ot"
+
+// SupportedCapabilities 服务端支持的全部可选能力
+var SupportedCapabilities = []string{CapabilityTextOT}
+
+// NegotiateCapabilities 解析客户端声明的能力列表（逗号分隔），返回双方都支持的部分
+func NegotiateCapabilities(requested string) map[string]bool {
+	negotiated := make(map[string]bool)
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.TrimSpace(name)
+		for _, supported := range SupportedCapabilities {
+			if name == supported {
+				negotiated[name] = true
+			}
+		}
+	}
+	return negotiated
+}
+
+// --- 文本 OT 状态 ---
+
+// maxTextHistory 每个文本属性保留的历史操作数，基准修订号早于窗口的操作需重新同步
+const maxTextHistory = 100
+
+// textDoc 单个文本属性的 OT 状态，受 Room.stateMu 保护
+type textDoc struct {
+	revision int                // 当前修订号
+	floor    int                // history[0] 的基准修订号
+	history  []ot.TextOperation // 已应用的操作，用于变换并发操作
+}
+
+// TextOpPayload text-op / text-ack 消息的 payload 结构
+type TextOpPayload struct {
+	ComponentID string           `json:"componentId"`
+	Prop        string           `json:"prop"`
+	Revision    int              `json:"revision"`          // 发送时为基准修订号，广播/确认时为新修订号
+	Ops         ot.TextOperation `json:"ops,omitempty"`     // 确认消息不携带
+	Version     int64            `json:"version,omitempty"` // 应用后的页面版本号
+
+	// Attribution 服务端追加的编辑归属操作（JSON Patch），确认和广播时携带，
+	// 客户端应用后与服务端状态保持一致；无追加时省略
+	Attribution json.RawMessage `json:"attribution,omitempty"`
+}
+
+// TextOpResult 文本操作应用结果
+type TextOpResult struct {
+	Ops      ot.TextOperation // 变换后的操作，广播给支持 text-ot 的客户端
+	Revision int              // 新修订号
+	Version  int64            // 新页面版本号
+	Patch    []byte           // 等价的 JSON Patch（含编辑归属），广播给不支持 text-ot 的客户端
+
+	Attribution json.RawMessage // 追加的编辑归属操作，未追加时为 nil
+}
+
+// textPropPath 返回文本属性在 Schema 中的 JSON Pointer
+func textPropPath(componentID, prop string) string {
+	return jsondiff.Pointer("components", componentID, "props", prop)
+}
+
+// ApplyTextOp 将基于 baseRevision 的文本操作变换到最新修订后应用。
+// 与 ApplyPatch 不同，并发的文本操作不会产生版本冲突，只要基准修订号仍在历史窗口内。
+func (r *Room) ApplyTextOp(componentID, prop string, baseRevision int, op ot.TextOperation) (*TextOpResult, error) {
+	return r.ApplyTextOpAs(UserInfo{}, componentID, prop, baseRevision, op)
+}
+
+// ApplyTextOpAs 以 author 的身份应用文本操作，author 会写入操作日志用于审计，
+// 并与 ApplyLabeledEdit 一样记录组件的编辑归属和作者的编辑时间
+func (r *Room) ApplyTextOpAs(author UserInfo, componentID, prop string, baseRevision int, op ot.TextOperation) (*TextOpResult, error) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	if r.sealed {
+		return nil, ErrRoomClosed
+	}
+
+	path := textPropPath(componentID, prop)
+	if r.textDocs == nil {
+		r.textDocs = make(map[string]*textDoc)
+	}
+	doc, ok := r.textDocs[path]
+	if !ok {
+		doc = &textDoc{}
+		r.textDocs[path] = doc
+	}
+
+	if baseRevision < doc.floor || baseRevision > doc.revision {
+		return nil, &TextRevisionError{CurrentRevision: doc.revision, BaseRevision: baseRevision}
+	}
+
+	// 依次对基准修订之后的并发操作做变换
+	for _, concurrent := range doc.history[baseRevision-doc.floor:] {
+		transformed, _, err := ot.Transform(op, concurrent)
+		if err != nil {
+			return nil, &PatchError{Reason: fmt.Sprintf("文本操作变换失败: %v", err)}
+		}
+		op = transformed
+	}
+
+	text, err := readTextProp(r.CurrentState, componentID, prop)
+	if err != nil {
+		return nil, err
+	}
+	newText, err := op.Apply(text)
+	if err != nil {
+		return nil, &PatchError{Reason: fmt.Sprintf("文本操作应用失败: %v", err)}
+	}
+
+	patchBytes, _ := json.Marshal([]jsondiff.Operation{{Op: "replace", Path: path, Value: newText}})
+	patch, err := jsonpatch.DecodePatch(patchBytes)
+	if err != nil {
+		return nil, &PatchError{Reason: fmt.Sprintf("patch 解析失败: %v", err)}
+	}
+	modified, err := patch.Apply(r.CurrentState)
+	if err != nil {
+		return nil, &PatchError{Reason: fmt.Sprintf("patch 应用失败: %v", err)}
+	}
+	modified, attribution := r.attributeLocked(modified, patch, author, r.Version+1)
+	patchBytes = concatPatches(patchBytes, attribution)
+
+	r.CurrentState = modified
+	r.Version++
+	r.noteDirtyLocked()
+	r.recordOpLocked(patchBytes, author, "")
+	r.rememberPatchLocked(patchBytes, author.UserID)
+	r.relayPatchLocked(patchBytes, author, "")
+	r.noteEditorLocked(author)
+	r.bufferPatchLocked(patchBytes)
+
+	doc.revision++
+	doc.history = append(doc.history, op)
+	if len(doc.history) > maxTextHistory {
+		drop := len(doc.history) - maxTextHistory
+		doc.history = doc.history[drop:]
+		doc.floor += drop
+	}
+
+	r.maybeFlushLocked()
+
+	return &TextOpResult{
+		Ops:         op,
+		Revision:    doc.revision,
+		Version:     r.Version,
+		Patch:       patchBytes,
+		Attribution: attribution,
+	}, nil
+}
+
+// readTextProp 读取组件的字符串属性
+func readTextProp(state []byte, componentID, prop string) (string, error) {
+	var schema struct {
+		Components map[string]struct {
+			Props map[string]json.RawMessage `json:"props"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(state, &schema); err != nil {
+		return "", &PatchError{Reason: fmt.Sprintf("schema 解析失败: %v", err)}
+	}
+
+	raw, ok := schema.Components[componentID].Props[prop]
+	if !ok {
+		return "", &PatchError{Reason: fmt.Sprintf("属性 %s 不存在", textPropPath(componentID, prop))}
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return "", &PatchError{Reason: fmt.Sprintf("属性 %s 不是字符串", textPropPath(componentID, prop))}
+	}
+	return text, nil
+}
+
+// textRevisionsLocked 返回各文本属性的当前修订号（JSON Pointer → 修订号），没有编辑过的属性省略。
+// 随 sync / catch-up 下发给协商了 text-ot 的客户端，作为之后 text-op 的基准修订号；调用方需持有 stateMu
+func (r *Room) textRevisionsLocked(client *Client) map[string]int {
+	if len(r.textDocs) == 0 || !client.HasCapability(CapabilityTextOT) {
+		return nil
+	}
+	revisions := make(map[string]int, len(r.textDocs))
+	for path, doc := range r.textDocs {
+		if doc.revision > 0 {
+			revisions[path] = doc.revision
+		}
+	}
+	if len(revisions) == 0 {
+		return nil
+	}
+	return revisions
+}
+
+// invalidateTextDocs 普通 Patch 覆盖了文本属性时，清空其 OT 历史。
+// 之后基于旧修订号的文本操作会被拒绝，客户端需重新同步。调用方需持有 stateMu 写锁。
+func (r *Room) invalidateTextDocs(patch jsonpatch.Patch) {
+	if len(r.textDocs) == 0 {
+		return
+	}
+
+	for _, operation := range patch {
+		opPath, err := operation.Path()
+		if err != nil {
+			continue
+		}
+		for path, doc := range r.textDocs {
+			if path == opPath || strings.HasPrefix(path, opPath+"/") {
+				doc.floor = doc.revision
+				doc.history = nil
+			}
+		}
+	}
+}
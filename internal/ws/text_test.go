@@ -0,0 +1,158 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/ot"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 文本 OT 单元测试 ==========
+// 测试重点：并发文本操作不产生版本冲突，普通 Patch 覆盖后要求重新同步
+
+func textOp(t *testing.T, s string) ot.TextOperation {
+	t.Helper()
+	var op ot.TextOperation
+	assert.NoError(t, json.Unmarshal([]byte(s), &op))
+	return op
+}
+
+func TestRoom_ApplyTextOp_Concurrent(t *testing.T) {
+	// 测试场景：两个用户基于同一修订号同时输入，服务端变换后均成功应用
+
+	initialState := []byte(`{"components": {"1": {"props": {"text": "Hello"}}}}`)
+	room := newTestRoom("test-room", initialState, new(MockPageService))
+
+	// Alice 在末尾追加 " World"，Bob 在开头插入 ">"，都基于修订号 0
+	res1, err := room.ApplyTextOp("1", "text", 0, textOp(t, `[5, " World"]`))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res1.Revision)
+	assert.Equal(t, int64(2), res1.Version)
+
+	res2, err := room.ApplyTextOp("1", "text", 0, textOp(t, `[">", 5]`))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, res2.Revision)
+	assert.Equal(t, int64(3), res2.Version)
+
+	text, err := readTextProp(room.CurrentState, "1", "text")
+	assert.NoError(t, err)
+	assert.Equal(t, ">Hello World", text)
+
+	// 广播的是变换后的操作（基于 "Hello World"）
+	assert.Equal(t, 11, res2.Ops.BaseLen())
+}
+
+func TestRoom_ApplyTextOpAs_Attribution(t *testing.T) {
+	// 测试场景：与 ApplyLabeledEdit 相同，文本操作写入组件的 editedBy，归属操作随结果返回并包含在等价 Patch 中；
+	// 作者记入待刷盘的编辑者
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	hub := NewHub(mockService, WithActivity(NewActivityWriter(&MockActivityStore{})))
+	room := NewRoom("page-1", []byte(`{"components": {"1": {"id": 1, "props": {"text": "Hello"}}}}`), mockService, hub)
+	defer room.Stop()
+
+	alice := UserInfo{UserID: "alice", UserName: "Alice"}
+	res, err := room.ApplyTextOpAs(alice, "1", "text", 0, textOp(t, `[5, "!"]`))
+	require.NoError(t, err)
+	assert.NotEmpty(t, res.Attribution)
+
+	var ops []json.RawMessage
+	require.NoError(t, json.Unmarshal(res.Patch, &ops))
+	assert.Greater(t, len(ops), 1) // 文本替换 + 编辑归属
+
+	state, version := room.GetSnapshot()
+	editedBy := readEditedBy(t, state)
+	require.Contains(t, editedBy, "1")
+	assert.Equal(t, "alice", editedBy["1"].UserID)
+	assert.Equal(t, version, editedBy["1"].Version)
+
+	room.stateMu.RLock()
+	assert.Contains(t, room.pendingEditors, "alice")
+	room.stateMu.RUnlock()
+}
+
+func TestRoom_ApplyTextOp_InvalidatedByPatch(t *testing.T) {
+	// 测试场景：op-patch 整体替换了文本属性后，旧修订号的文本操作被拒绝
+
+	initialState := []byte(`{"components": {"1": {"props": {"text": "abc"}}}}`)
+	room := newTestRoom("test-room", initialState, new(MockPageService))
+
+	_, err := room.ApplyTextOp("1", "text", 0, textOp(t, `[3, "d"]`))
+	assert.NoError(t, err)
+
+	patch := []byte(`[{"op": "replace", "path": "/components/1/props", "value": {"text": "xyz"}}]`)
+	assert.NoError(t, room.ApplyPatch(patch, room.Version))
+
+	_, err = room.ApplyTextOp("1", "text", 0, textOp(t, `[3, "!"]`))
+	var revisionErr *TextRevisionError
+	assert.ErrorAs(t, err, &revisionErr)
+
+	// 基于最新修订号的操作仍然可以应用
+	_, err = room.ApplyTextOp("1", "text", 1, textOp(t, `[3, "!"]`))
+	assert.NoError(t, err)
+}
+
+func TestRoom_ApplyTextOp_NotString(t *testing.T) {
+	initialState := []byte(`{"components": {"1": {"props": {"count": 1}}}}`)
+	room := newTestRoom("test-room", initialState, new(MockPageService))
+
+	_, err := room.ApplyTextOp("1", "count", 0, textOp(t, `[1, "x"]`))
+	var patchErr *PatchError
+	assert.ErrorAs(t, err, &patchErr)
+	assert.Equal(t, int64(1), room.Version)
+}
+
+func TestNegotiateCapabilities(t *testing.T) {
+	caps := NegotiateCapabilities("text-ot, unknown")
+	assert.Equal(t, map[string]bool{CapabilityTextOT: true}, caps)
+	assert.Empty(t, NegotiateCapabilities(""))
+}
+
+func TestClient_TextOp_LateJoinerRevision(t *testing.T) {
+	// 测试场景：属性已有编辑后加入的 text-ot 客户端从 sync 取得修订号，基于它输入不需要变换；
+	// 修订号不合法时错误中带回当前修订号
+
+	service := new(MockPageService)
+	service.On("GetPageState", "page-1").Return([]byte(`{"rootId":1,"components":{"1":{"id":1,"props":{"text":"hello"}}}}`), int64(1), nil)
+	service.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	hub := NewHub(service)
+	room, err := hub.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	t.Cleanup(room.Stop)
+
+	_, err = room.ApplyTextOp("1", "text", 0, textOp(t, `[5, " world"]`))
+	require.NoError(t, err)
+	_, err = room.ApplyTextOp("1", "text", 1, textOp(t, `[11, "!"]`))
+	require.NoError(t, err)
+
+	carol := &Client{Hub: hub, RoomID: "page-1", send: make(chan []byte, 64),
+		Capabilities: map[string]bool{CapabilityTextOT: true},
+		UserInfo:     UserInfo{UserID: "carol", UserName: "Carol", Role: entity.RoleEditor}}
+	require.NoError(t, room.Register(carol))
+	var sync SyncPayload
+	require.NoError(t, json.Unmarshal(nextTestMessageOfType(t, carol, TypeSync).Payload, &sync))
+	revision := sync.TextRevisions["/components/1/props/text"]
+	assert.Equal(t, 2, revision)
+
+	// 在快照 "hello world!" 末尾输入
+	sendModerationMessage(carol, TypeTextOp, fmt.Sprintf(`{"componentId":"1","prop":"text","revision":%d,"ops":[12,"?"]}`, revision))
+	var ack TextOpPayload
+	require.NoError(t, json.Unmarshal(nextTestMessageOfType(t, carol, TypeTextAck).Payload, &ack))
+	assert.Equal(t, 3, ack.Revision)
+	text, err := readTextProp(room.CurrentState, "1", "text")
+	require.NoError(t, err)
+	assert.Equal(t, "hello world!?", text)
+
+	sendModerationMessage(carol, TypeTextOp, `{"componentId":"1","prop":"text","revision":7,"ops":[13,"."]}`)
+	rejected := nextAIError(t, carol)
+	assert.Equal(t, ErrTextRevision, rejected.Code)
+	require.NotNil(t, rejected.CurrentRevision)
+	assert.Equal(t, 3, *rejected.CurrentRevision)
+}
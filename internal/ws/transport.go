@@ -0,0 +1,32 @@
+package ws
+
+import "time"
+
+// Transport 客户端的底层连接，Client 只通过它收发帧，
+// 因此 WebSocket、WebTransport 以及今后的 SSE + POST 等传输都接入同一套房间逻辑，
+// 测试中也可以用内存实现替代真实连接。*websocket.Conn 直接满足该接口。
+//
+// 帧类型沿用 websocket 包的常量：TextMessage / BinaryMessage 承载消息，
+// PingMessage / CloseMessage 是控制帧，没有对应概念的传输可以直接忽略
+type Transport interface {
+	// ReadMessage 阻塞读取下一帧，连接关闭或超时时返回错误
+	ReadMessage() (frameType int, data []byte, err error)
+	WriteMessage(frameType int, data []byte) error
+	Close() error
+
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+	// SetReadLimit 设置单帧的最大字节数，超出时 ReadMessage 返回错误
+	SetReadLimit(limit int64)
+}
+
+// pongTransport 有协议层心跳回应的传输，ReadPump 收到 Pong 时延长读超时
+type pongTransport interface {
+	SetPongHandler(h func(appData string) error)
+}
+
+// compressionTransport 支持逐帧开关压缩的传输，见 EnableCompression
+type compressionTransport interface {
+	SetCompressionLevel(level int) error
+	EnableWriteCompression(enable bool)
+}
@@ -0,0 +1,44 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// handleOpValidate 处理 op-validate：在当前状态的副本上试应用 Patch，只把结果发给请求者，
+// 不推进版本、不写操作日志也不广播，供集成方预检复杂修改
+func (c *Client) handleOpValidate(payload json.RawMessage) {
+	if c.Room == nil {
+		c.sendError(ErrRoomNotFound, c.RoomID)
+		return
+	}
+
+	var patchPayload OpPatchPayload
+	if err := json.Unmarshal(payload, &patchPayload); err != nil {
+		c.sendError(ErrInvalidMessage, "op-validate 格式错误")
+		return
+	}
+	if len(patchPayload.ClientMsgID) > MaxClientMsgIDLength {
+		c.sendError(ErrInvalidMessage, fmt.Sprintf("clientMsgId 不能超过 %d 个字符", MaxClientMsgIDLength))
+		return
+	}
+
+	result := ValidateResultPayload{ClientMsgID: patchPayload.ClientMsgID}
+	applied, err := c.Room.ValidateEdit(c.UserInfo, patchPayload.Patches, patchPayload.Version)
+	if err != nil {
+		// 版本校验在试应用之前，其余错误发生时当前版本即为请求的基准版本
+		result.Version = patchPayload.Version
+		var versionErr *VersionConflictError
+		if errors.As(err, &versionErr) {
+			result.Version = versionErr.CurrentVersion
+		}
+		result.Code, result.Message = editErrorCode(err)
+	} else {
+		result.Valid = true
+		result.Version = applied.Version
+		result.Patches = applied.Patches
+	}
+
+	c.send <- encodeServerMessage(TypeValidateResult, result)
+}
@@ -0,0 +1,50 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== 试应用（op-validate）单元测试 ==========
+
+func TestClient_OpValidate(t *testing.T) {
+	// 测试场景：试应用成功时返回将得到的版本和 Patch，失败时返回错误码；
+	// 两种情况都不改变房间状态，也不广播
+
+	room := newTestRoom("test-room", []byte(`{"title": "a", "components": {"1": {"id": 1}}}`), new(MockPageService))
+	alice := newAckTestClient(room)
+	bob := &Client{UserInfo: UserInfo{UserID: "bob"}, RoomID: room.ID, send: make(chan []byte, 8), Room: room}
+	room.clients[bob] = true
+
+	alice.handleOpValidate([]byte(`{"patches": [{"op": "add", "path": "/components/1/name", "value": "Button"}],
+		"version": 1, "clientMsgId": "v-1"}`))
+
+	var ok ValidateResultPayload
+	assert.Equal(t, TypeValidateResult, readTestMessage(t, alice, &ok))
+	assert.True(t, ok.Valid)
+	assert.Equal(t, "v-1", ok.ClientMsgID)
+	assert.Equal(t, int64(2), ok.Version)
+	assert.Contains(t, string(ok.Patches), `"/editedBy"`)
+
+	alice.handleOpValidate([]byte(`{"patches": [{"op": "remove", "path": "/missing"}], "version": 1, "clientMsgId": "v-2"}`))
+
+	var failed ValidateResultPayload
+	readTestMessage(t, alice, &failed)
+	assert.False(t, failed.Valid)
+	assert.Equal(t, ErrPatchFailed, failed.Code)
+	assert.Equal(t, int64(1), failed.Version)
+
+	alice.handleOpValidate([]byte(`{"patches": [], "version": 7}`))
+
+	var conflict ValidateResultPayload
+	readTestMessage(t, alice, &conflict)
+	assert.Equal(t, ErrVersionConflict, conflict.Code)
+	assert.Equal(t, int64(1), conflict.Version)
+
+	snapshot, version := room.GetSnapshot()
+	assert.Equal(t, int64(1), version)
+	assert.JSONEq(t, `{"title": "a", "components": {"1": {"id": 1}}}`, string(snapshot))
+	require.Len(t, bob.send, 0)
+}
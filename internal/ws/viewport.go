@@ -0,0 +1,31 @@
+package ws
+
+import "math"
+
+// 视口缩放比例的合法范围，超出视为无效消息
+const (
+	ViewportMinZoom = 0.01
+	ViewportMaxZoom = 100
+)
+
+// ViewportPayload viewport-update 消息的 payload 结构：画布滚动位置与缩放比例。
+// 跟随者按 senderId 过滤，只应用被跟随用户的视口。
+type ViewportPayload struct {
+	ScrollX float64 `json:"scrollX"`
+	ScrollY float64 `json:"scrollY"`
+	Zoom    float64 `json:"zoom"`
+
+	// 可视区域尺寸（画布坐标），跟随者窗口大小不同时用于居中对齐，可选
+	Width  float64 `json:"width,omitempty"`
+	Height float64 `json:"height,omitempty"`
+}
+
+// valid 检查数值是否有限且在合理范围内，避免转发 NaN/Inf 等导致跟随者画布异常
+func (p ViewportPayload) valid() bool {
+	for _, v := range []float64{p.ScrollX, p.ScrollY, p.Zoom, p.Width, p.Height} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return p.Zoom >= ViewportMinZoom && p.Zoom <= ViewportMaxZoom && p.Width >= 0 && p.Height >= 0
+}
@@ -0,0 +1,65 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== 视口跟随单元测试 ==========
+// 测试重点：合法视口以服务端确认的发送者身份转发，非法视口返回错误且不转发
+
+func TestClient_ViewportUpdate_Relay(t *testing.T) {
+	// 测试场景：Alice 上报视口，客户端伪造的 senderId 被替换为 Alice，作为非关键消息广播
+
+	room := newTestRoom("test-room", []byte(`{}`), new(MockPageService))
+	alice := &Client{
+		UserInfo: UserInfo{UserID: "alice", UserName: "Alice"},
+		send:     make(chan []byte, 8),
+		Room:     room,
+	}
+
+	alice.handleViewportUpdate(json.RawMessage(`{"scrollX":120.5,"scrollY":-40,"zoom":1.5,"width":1280,"height":720,"senderId":"bob"}`))
+
+	b := <-room.broadcast
+	assert.Equal(t, alice, b.Sender)
+	assert.False(t, b.IsCritical)
+
+	var msg WSMessage
+	assert.NoError(t, json.Unmarshal(b.Message, &msg))
+	assert.Equal(t, TypeViewportUpdate, msg.Type)
+	assert.Equal(t, "alice", msg.SenderID)
+
+	var viewport ViewportPayload
+	assert.NoError(t, json.Unmarshal(msg.Payload, &viewport))
+	assert.Equal(t, ViewportPayload{ScrollX: 120.5, ScrollY: -40, Zoom: 1.5, Width: 1280, Height: 720}, viewport)
+}
+
+func TestClient_ViewportUpdate_Invalid(t *testing.T) {
+	// 测试场景：缩放比例缺失或越界、尺寸为负时返回 INVALID_MESSAGE，不广播
+
+	room := newTestRoom("test-room", []byte(`{}`), new(MockPageService))
+	alice := &Client{
+		UserInfo: UserInfo{UserID: "alice"},
+		send:     make(chan []byte, 8),
+		Room:     room,
+	}
+
+	for _, payload := range []string{
+		`{"scrollX":0,"scrollY":0}`,
+		`{"scrollX":0,"scrollY":0,"zoom":1000}`,
+		`{"scrollX":0,"scrollY":0,"zoom":1,"width":-1}`,
+		`"not-an-object"`,
+	} {
+		alice.handleViewportUpdate(json.RawMessage(payload))
+
+		var msg WSMessage
+		assert.NoError(t, json.Unmarshal(<-alice.send, &msg), payload)
+		assert.Equal(t, TypeError, msg.Type, payload)
+		var errPayload ErrorPayload
+		assert.NoError(t, json.Unmarshal(msg.Payload, &errPayload))
+		assert.Equal(t, ErrInvalidMessage, errPayload.Code, payload)
+	}
+	assert.Empty(t, room.broadcast)
+}
@@ -0,0 +1,176 @@
+package ws
+
+import (
+	"expvar"
+	"log"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// watchdogRecoverRatio 过载后各项指标均回落到阈值的该比例以下才解除，避免在阈值附近反复切换
+const watchdogRecoverRatio = 0.8
+
+// watchdogMetrics 进入过载的次数、过载期间拒绝创建的房间数和被节流丢弃的感知类消息数，通过 expvar 暴露为 ws_watchdog
+var watchdogMetrics = expvar.NewMap("ws_watchdog")
+
+// WatchdogConfig 过载保护阈值，阈值为 0 时不检查对应指标
+type WatchdogConfig struct {
+	Interval      time.Duration // 采样间隔
+	MaxGoroutines int           // goroutine 数量上限
+	MaxHeapBytes  uint64        // 堆内存上限
+	MaxLoopLag    time.Duration // 调度延迟上限：采样定时器实际触发时间比预期晚的时长
+
+	// CursorInterval 过载期间每个连接的光标、视口广播的最小间隔，其间的消息被丢弃
+	CursorInterval time.Duration
+}
+
+// WatchdogStatus 最近一次采样的结果
+type WatchdogStatus struct {
+	Overloaded bool       `json:"overloaded"`
+	Reasons    []string   `json:"reasons,omitempty"` // 超过阈值的指标
+	Goroutines int        `json:"goroutines"`
+	HeapBytes  uint64     `json:"heapBytes"`
+	LoopLag    string     `json:"loopLag"`
+	Since      *time.Time `json:"since,omitempty"` // 本次进入过载的时间，未过载时为空
+	SampledAt  time.Time  `json:"sampledAt"`
+}
+
+// Watchdog 定期采样 goroutine 数量、堆内存和调度延迟，超过阈值时进入过载状态：
+// Hub 拒绝创建新房间（已有房间不受影响），感知类广播按 CursorInterval 降频，
+// 以牺牲部分体验换取进程不被 OOM 杀死；各项指标回落后自动解除
+type Watchdog struct {
+	cfg        WatchdogConfig
+	overloaded atomic.Bool
+
+	mu     sync.RWMutex
+	status WatchdogStatus
+}
+
+// NewWatchdog 创建过载保护，需调用 Run 开始采样
+func NewWatchdog(cfg WatchdogConfig) *Watchdog {
+	return &Watchdog{cfg: cfg}
+}
+
+// WithWatchdog 启用过载保护
+func WithWatchdog(w *Watchdog) HubOption {
+	return func(h *Hub) {
+		h.watchdog = w
+	}
+}
+
+// Run 按 Interval 采样，直到 stop 关闭
+func (w *Watchdog) Run(stop <-chan struct{}) {
+	log.Printf("[Watchdog] 已启动，采样间隔 %s", w.cfg.Interval)
+
+	timer := time.NewTimer(w.cfg.Interval)
+	defer timer.Stop()
+	expected := time.Now().Add(w.cfg.Interval)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-timer.C:
+			lag := time.Since(expected)
+			if lag < 0 {
+				lag = 0
+			}
+			w.observe(runtime.NumGoroutine(), heapBytes(), lag, now)
+
+			timer.Reset(w.cfg.Interval)
+			expected = time.Now().Add(w.cfg.Interval)
+		}
+	}
+}
+
+// heapBytes 当前堆上已分配的字节数
+func heapBytes() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// observe 根据一次采样更新过载状态。
+// 未过载时任一指标超过阈值即进入过载；过载时所有指标都回落到阈值的 watchdogRecoverRatio 以下才解除
+func (w *Watchdog) observe(goroutines int, heap uint64, lag time.Duration, now time.Time) {
+	ratio := 1.0
+	if w.overloaded.Load() {
+		ratio = watchdogRecoverRatio
+	}
+
+	var reasons []string
+	if w.cfg.MaxGoroutines > 0 && float64(goroutines) > float64(w.cfg.MaxGoroutines)*ratio {
+		reasons = append(reasons, "goroutines")
+	}
+	if w.cfg.MaxHeapBytes > 0 && float64(heap) > float64(w.cfg.MaxHeapBytes)*ratio {
+		reasons = append(reasons, "heap")
+	}
+	if w.cfg.MaxLoopLag > 0 && float64(lag) > float64(w.cfg.MaxLoopLag)*ratio {
+		reasons = append(reasons, "loopLag")
+	}
+	overloaded := len(reasons) > 0
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	since := w.status.Since
+	switch {
+	case overloaded && !w.overloaded.Load():
+		since = &now
+		watchdogMetrics.Add("overloads", 1)
+		log.Printf("[Watchdog] 进入过载保护 %v: goroutines=%d heap=%dMB lag=%s",
+			reasons, goroutines, heap>>20, lag)
+	case !overloaded && w.overloaded.Load():
+		since = nil
+		log.Printf("[Watchdog] 解除过载保护，持续 %s: goroutines=%d heap=%dMB lag=%s",
+			now.Sub(*w.status.Since).Round(time.Second), goroutines, heap>>20, lag)
+	}
+
+	w.status = WatchdogStatus{
+		Overloaded: overloaded,
+		Reasons:    reasons,
+		Goroutines: goroutines,
+		HeapBytes:  heap,
+		LoopLag:    lag.String(),
+		Since:      since,
+		SampledAt:  now,
+	}
+	w.overloaded.Store(overloaded)
+}
+
+// Overloaded 是否处于过载状态，w 为 nil 时总是 false
+func (w *Watchdog) Overloaded() bool {
+	return w != nil && w.overloaded.Load()
+}
+
+// Status 返回最近一次采样的结果
+func (w *Watchdog) Status() WatchdogStatus {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.status
+}
+
+// throttleCursor 过载期间按 CursorInterval 对 client 的感知类广播降频，返回 true 表示应丢弃本条。
+// 只在 client 的 ReadPump 中调用
+func (w *Watchdog) throttleCursor(client *Client, now time.Time) bool {
+	if !w.Overloaded() || w.cfg.CursorInterval <= 0 {
+		return false
+	}
+	if now.Sub(client.lastCursorBroadcast) < w.cfg.CursorInterval {
+		watchdogMetrics.Add("cursorThrottled", 1)
+		return true
+	}
+	client.lastCursorBroadcast = now
+	return false
+}
+
+// WatchdogStatus 返回过载保护最近一次采样的结果，未启用时返回 nil
+func (h *Hub) WatchdogStatus() *WatchdogStatus {
+	if h.watchdog == nil {
+		return nil
+	}
+	status := h.watchdog.Status()
+	return &status
+}
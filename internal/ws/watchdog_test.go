@@ -0,0 +1,101 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	domainErrors "lowercode-go-server/domain/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== Watchdog 单元测试 ==========
+
+func TestWatchdog_Observe(t *testing.T) {
+	// 测试场景：任一指标超过阈值即进入过载；回落到阈值以下但仍高于 80% 时保持过载，
+	// 全部回落到 80% 以下才解除；阈值为 0 的指标不检查
+
+	w := NewWatchdog(WatchdogConfig{MaxGoroutines: 100, MaxHeapBytes: 1000, MaxLoopLag: time.Second})
+	now := time.Now()
+
+	w.observe(50, 500, 0, now)
+	assert.False(t, w.Overloaded())
+
+	w.observe(50, 1500, 0, now)
+	assert.True(t, w.Overloaded())
+	status := w.Status()
+	assert.Equal(t, []string{"heap"}, status.Reasons)
+	require.NotNil(t, status.Since)
+
+	w.observe(50, 900, 0, now.Add(time.Second))
+	assert.True(t, w.Overloaded(), "未回落到 80% 以下，保持过载")
+	assert.Equal(t, now, *w.Status().Since)
+
+	w.observe(50, 700, 0, now.Add(2*time.Second))
+	assert.False(t, w.Overloaded())
+	assert.Nil(t, w.Status().Since)
+
+	w.observe(50, 700, 2*time.Second, now.Add(3*time.Second))
+	assert.Equal(t, []string{"loopLag"}, w.Status().Reasons)
+
+	unlimited := NewWatchdog(WatchdogConfig{})
+	unlimited.observe(1_000_000, 1<<40, time.Hour, now)
+	assert.False(t, unlimited.Overloaded())
+
+	var disabled *Watchdog
+	assert.False(t, disabled.Overloaded())
+}
+
+func TestWatchdog_ThrottleCursor(t *testing.T) {
+	// 测试场景：未过载时不限制；过载期间每个连接按 CursorInterval 最多广播一条
+
+	w := NewWatchdog(WatchdogConfig{MaxGoroutines: 1, CursorInterval: 100 * time.Millisecond})
+	client := &Client{}
+	now := time.Now()
+
+	assert.False(t, w.throttleCursor(client, now))
+	assert.False(t, w.throttleCursor(client, now))
+
+	w.observe(10, 0, 0, now)
+	require.True(t, w.Overloaded())
+
+	assert.False(t, w.throttleCursor(client, now))
+	assert.True(t, w.throttleCursor(client, now.Add(50*time.Millisecond)))
+	assert.False(t, w.throttleCursor(client, now.Add(100*time.Millisecond)))
+	assert.False(t, w.throttleCursor(&Client{}, now.Add(110*time.Millisecond)), "各连接分别计算")
+}
+
+func TestHub_GetOrCreateRoom_Overloaded(t *testing.T) {
+	// 测试场景：过载时拒绝创建新房间，已有房间仍然可以加入；解除后恢复创建
+
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", "page-1").Return([]byte(`{}`), int64(1), nil).Once()
+	mockService.On("GetPageState", "page-2").Return([]byte(`{}`), int64(1), nil).Once()
+
+	w := NewWatchdog(WatchdogConfig{MaxGoroutines: 100})
+	hub := NewHub(mockService, WithWatchdog(w))
+
+	existing, err := hub.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+
+	w.observe(1000, 0, 0, time.Now())
+
+	room, err := hub.GetOrCreateRoom("page-2")
+	assert.Nil(t, room)
+	assert.ErrorIs(t, err, domainErrors.ErrServerOverloaded)
+
+	room, err = hub.GetOrCreateRoom("page-1")
+	assert.NoError(t, err)
+	assert.Same(t, existing, room)
+
+	require.NotNil(t, hub.WatchdogStatus())
+	assert.True(t, hub.WatchdogStatus().Overloaded)
+	assert.True(t, hub.Features().Watchdog)
+
+	w.observe(10, 0, 0, time.Now())
+	room, err = hub.GetOrCreateRoom("page-2")
+	assert.NoError(t, err)
+	assert.NotNil(t, room)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,102 @@
+package ws
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebTransport 连接（实验性）：会话建立后客户端打开一条双向流，
+// 之后每条消息以 4 字节大端长度前缀 + JSON 编码，消息类型与 WebSocket 协议完全相同。
+// QUIC 自带保活与空闲超时，流上不发送应用层心跳
+
+// streamFrameHeader 长度前缀的字节数
+const streamFrameHeader = 4
+
+// ErrStreamFrameTooLarge 入站消息超过 ConnConfig.MaxMessageSize
+var ErrStreamFrameTooLarge = errors.New("stream frame too large")
+
+// StreamConn WebTransport 会话上的双向流，Close 应同时关闭整个会话
+type StreamConn interface {
+	io.ReadWriteCloser
+	SetWriteDeadline(t time.Time) error
+}
+
+// streamTransport 以长度前缀分帧的流传输
+type streamTransport struct {
+	stream StreamConn
+	reader *bufio.Reader
+	limit  int64
+}
+
+// NewStreamTransport 把 WebTransport 双向流包装为 Transport。
+// Ping 与关闭帧被忽略；流上没有 Pong 可以延长读超时，SetReadDeadline 不生效，由 QUIC 空闲超时代替
+func NewStreamTransport(stream StreamConn) Transport {
+	return &streamTransport{
+		stream: stream,
+		reader: bufio.NewReader(stream),
+		limit:  DefaultConnConfig.MaxMessageSize,
+	}
+}
+
+// ReadMessage 读取一条长度前缀消息，总是按文本帧返回
+func (t *streamTransport) ReadMessage() (int, []byte, error) {
+	message, err := readStreamFrame(t.reader, t.limit)
+	return websocket.TextMessage, message, err
+}
+
+func (t *streamTransport) WriteMessage(frameType int, data []byte) error {
+	if frameType != websocket.TextMessage && frameType != websocket.BinaryMessage {
+		return nil
+	}
+	return writeStreamFrame(t.stream, data)
+}
+
+func (t *streamTransport) Close() error {
+	return t.stream.Close()
+}
+
+func (t *streamTransport) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (t *streamTransport) SetWriteDeadline(deadline time.Time) error {
+	return t.stream.SetWriteDeadline(deadline)
+}
+
+func (t *streamTransport) SetReadLimit(limit int64) {
+	t.limit = limit
+}
+
+// writeStreamFrame 写入一条长度前缀消息，前缀与内容合并为一次写入
+func writeStreamFrame(w io.Writer, message []byte) error {
+	frame := make([]byte, streamFrameHeader+len(message))
+	binary.BigEndian.PutUint32(frame, uint32(len(message)))
+	copy(frame[streamFrameHeader:], message)
+	_, err := w.Write(frame)
+	return err
+}
+
+// readStreamFrame 读取一条长度前缀消息，超过 limit 时返回 ErrStreamFrameTooLarge
+func readStreamFrame(r io.Reader, limit int64) ([]byte, error) {
+	var header [streamFrameHeader]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if int64(size) > limit {
+		return nil, fmt.Errorf("%w: %d > %d", ErrStreamFrameTooLarge, size, limit)
+	}
+
+	message := make([]byte, size)
+	if _, err := io.ReadFull(r, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
@@ -0,0 +1,86 @@
+package ws
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== WebTransport 流单元测试 ==========
+// 测试重点：长度前缀帧的编解码，以及流客户端与 WebSocket 客户端共用同一套房间协议
+
+func TestStreamFrame(t *testing.T) {
+	// 测试场景：长度前缀帧可连续读出，超过上限的帧被拒绝
+
+	var buf bytes.Buffer
+	require.NoError(t, writeStreamFrame(&buf, []byte(`{"type":"cursor-move"}`)))
+	require.NoError(t, writeStreamFrame(&buf, []byte(`{}`)))
+	assert.Equal(t, streamFrameHeader*2+len(`{"type":"cursor-move"}`)+2, buf.Len())
+
+	first, err := readStreamFrame(&buf, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"cursor-move"}`, string(first))
+	second, err := readStreamFrame(&buf, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, string(second))
+
+	require.NoError(t, writeStreamFrame(&buf, make([]byte, 64)))
+	_, err = readStreamFrame(&buf, 32)
+	assert.ErrorIs(t, err, ErrStreamFrameTooLarge)
+}
+
+func TestStreamClient_OpPatch(t *testing.T) {
+	// 测试场景：经 WebTransport 流连接的客户端收到 sync，op-patch 得到 ack；
+	// 对端关闭流后客户端被注销
+
+	mockService := new(MockPageService)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	room := NewRoom("test-room", []byte(`{"count": 0}`), mockService, nil)
+	defer room.Stop()
+
+	serverSide, peer := net.Pipe()
+	defer peer.Close()
+	client := NewClient(nil, NewStreamTransport(serverSide), room.ID, UserInfo{UserID: "alice"})
+	require.NoError(t, room.Register(client))
+	go client.WritePump()
+	go client.ReadPump()
+
+	reader := bufio.NewReader(peer)
+	nextOfType := func(msgType MessageType) WSMessage {
+		t.Helper()
+		peer.SetReadDeadline(time.Now().Add(time.Second))
+		for {
+			frame, err := readStreamFrame(reader, DefaultConnConfig.MaxMessageSize)
+			require.NoError(t, err)
+			var msg WSMessage
+			require.NoError(t, json.Unmarshal(frame, &msg))
+			if msg.Type == msgType {
+				return msg
+			}
+		}
+	}
+
+	nextOfType(TypeSync)
+
+	peer.SetWriteDeadline(time.Now().Add(time.Second))
+	require.NoError(t, writeStreamFrame(peer, []byte(`{"type": "op-patch", "payload": {
+		"patches": [{"op": "replace", "path": "/count", "value": 1}],
+		"version": 1,
+		"clientMsgId": "m-1"
+	}}`)))
+
+	var ack AckPayload
+	require.NoError(t, json.Unmarshal(nextOfType(TypeAck).Payload, &ack))
+	assert.Equal(t, "m-1", ack.ClientMsgID)
+	assert.Equal(t, int64(2), ack.Version)
+
+	peer.Close()
+	assert.Eventually(t, func() bool { return room.ClientCount() == 0 }, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// activityRepository GORM 实现 ActivityRepository 接口
+type activityRepository struct {
+	db *gorm.DB
+}
+
+// NewActivityRepository 构造函数
+func NewActivityRepository(db *gorm.DB) domainRepo.ActivityRepository {
+	return &activityRepository{db: db}
+}
+
+// Record 批量记录活动
+// PostgreSQL 的 GREATEST 忽略 NULL，因此只记录了打开或编辑之一的活动不会清空另一个字段
+func (r *activityRepository) Record(activities []*entity.PageActivity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "page_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_opened_at": gorm.Expr("GREATEST(page_activities.last_opened_at, excluded.last_opened_at)"),
+			"last_edited_at": gorm.Expr("GREATEST(page_activities.last_edited_at, excluded.last_edited_at)"),
+		}),
+	}).Create(activities).Error
+}
+
+// ListRecent 按最近活动时间倒序返回用户的页面，与 pages 表关联以过滤已删除的页面
+func (r *activityRepository) ListRecent(userID string, limit int) ([]domainRepo.RecentPage, error) {
+	var pages []domainRepo.RecentPage
+	err := r.db.Table("page_activities AS a").
+		Select("a.page_id, p.creator_id, p.version, a.last_opened_at, a.last_edited_at").
+		Joins("JOIN pages AS p ON p.page_id = a.page_id").
+		Where("a.user_id = ? AND p.deleted_at IS NULL", userID).
+		Order("GREATEST(a.last_opened_at, a.last_edited_at) DESC").
+		Limit(limit).
+		Scan(&pages).Error
+	return pages, err
+}
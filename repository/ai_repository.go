@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// aiRepository GORM 实现 AIRepository 接口
+type aiRepository struct {
+	db *gorm.DB
+}
+
+// NewAIRepository 创建 AIRepository 实例
+func NewAIRepository(db *gorm.DB) domainRepo.AIRepository {
+	return &aiRepository{db: db}
+}
+
+// GetProvider 读取租户的模型服务配置
+func (r *aiRepository) GetProvider(tenantID string) (*entity.AIProviderConfig, error) {
+	var config entity.AIProviderConfig
+	err := r.db.Where("tenant_id = ?", tenantID).First(&config).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// SaveProvider 按租户 ID 创建或覆盖配置
+func (r *aiRepository) SaveProvider(config *entity.AIProviderConfig) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"kind", "base_url", "model", "api_version", "api_key", "api_key_mask", "updated_by", "updated_at"}),
+	}).Create(config).Error
+}
+
+// DeleteProvider 删除租户的模型服务配置
+func (r *aiRepository) DeleteProvider(tenantID string) error {
+	return r.db.Where("tenant_id = ?", tenantID).Delete(&entity.AIProviderConfig{}).Error
+}
+
+// RecordUsage 插入用量记录，已存在时在原有计数上累加
+func (r *aiRepository) RecordUsage(usage *entity.AIUsage) error {
+	add := func(column string) clause.Expr {
+		return gorm.Expr("ai_usages." + column + " + excluded." + column)
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}, {Name: "day"}, {Name: "source"}, {Name: "model"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"requests":          add("requests"),
+			"failures":          add("failures"),
+			"prompt_tokens":     add("prompt_tokens"),
+			"completion_tokens": add("completion_tokens"),
+			"request_bytes":     add("request_bytes"),
+			"response_bytes":    add("response_bytes"),
+		}),
+	}).Create(usage).Error
+}
+
+// ListUsage 按日期升序返回租户的用量
+func (r *aiRepository) ListUsage(tenantID string, since time.Time) ([]*entity.AIUsage, error) {
+	var usage []*entity.AIUsage
+	err := r.db.Where("tenant_id = ? AND day >= ?", tenantID, since.Format(time.DateOnly)).
+		Order("day, source, model").
+		Find(&usage).Error
+	return usage, err
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyRepository GORM 实现 APIKeyRepository 接口
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository 创建 APIKeyRepository 实例
+func NewAPIKeyRepository(db *gorm.DB) domainRepo.APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// Create 保存新的密钥
+func (r *apiKeyRepository) Create(key *entity.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+// GetByHash 按密钥哈希读取
+func (r *apiKeyRepository) GetByHash(hash string) (*entity.APIKey, error) {
+	var key entity.APIKey
+	err := r.db.Where("key_hash = ?", hash).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListByUser 按创建时间降序返回用户的密钥
+func (r *apiKeyRepository) ListByUser(userID string) ([]*entity.APIKey, error) {
+	var keys []*entity.APIKey
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC, id ASC").Find(&keys).Error
+	return keys, err
+}
+
+// CountActive 统计用户未撤销且未过期的密钥数
+func (r *apiKeyRepository) CountActive(userID string, now time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&entity.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, now).
+		Count(&count).Error
+	return count, err
+}
+
+// Revoke 撤销用户的密钥，已撤销的密钥保持原撤销时间
+func (r *apiKeyRepository) Revoke(userID, id string, at time.Time) (bool, error) {
+	result := r.db.Model(&entity.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Touch 记录密钥最近一次使用时间
+func (r *apiKeyRepository) Touch(id string, at time.Time) error {
+	return r.db.Model(&entity.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
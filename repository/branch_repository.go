@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"errors"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// branchRepository GORM 实现 BranchRepository 接口
+type branchRepository struct {
+	db *gorm.DB
+}
+
+// NewBranchRepository 创建 BranchRepository 实例
+func NewBranchRepository(db *gorm.DB) domainRepo.BranchRepository {
+	return &branchRepository{db: db}
+}
+
+// Create 在同一事务中保存分支记录、分支页面及其初始版本快照
+func (r *branchRepository) Create(branch *entity.PageBranch, page *entity.Page) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := createPage(tx, page); err != nil {
+			return err
+		}
+		return tx.Create(branch).Error
+	})
+}
+
+// Get 读取页面的一个分支
+func (r *branchRepository) Get(pageID, id string) (*entity.PageBranch, error) {
+	var branch entity.PageBranch
+	err := r.db.Where("id = ? AND page_id = ?", id, pageID).First(&branch).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &branch, nil
+}
+
+// ListByOwner 按创建时间降序返回用户在页面上的分支，不加载 BaseSchema
+func (r *branchRepository) ListByOwner(pageID, ownerID string) ([]*entity.PageBranch, error) {
+	var branches []*entity.PageBranch
+	err := r.db.Omit("base_schema").
+		Where("page_id = ? AND owner_id = ?", pageID, ownerID).
+		Order("created_at DESC, id ASC").
+		Find(&branches).Error
+	return branches, err
+}
+
+// CountByOwner 统计用户在页面上的分支数
+func (r *branchRepository) CountByOwner(pageID, ownerID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&entity.PageBranch{}).Where("page_id = ? AND owner_id = ?", pageID, ownerID).Count(&count).Error
+	return count, err
+}
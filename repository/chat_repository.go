@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// chatRepository GORM 实现 ChatRepository 接口
+// 同时实现 ws.ChatStore 接口供 Room 使用
+type chatRepository struct {
+	db *gorm.DB
+}
+
+// NewChatRepository 创建 ChatRepository 实例
+func NewChatRepository(db *gorm.DB) domainRepo.ChatRepository {
+	return &chatRepository{db: db}
+}
+
+// Save 保存一条聊天消息
+func (r *chatRepository) Save(msg *entity.ChatMessage) error {
+	return r.db.Create(msg).Error
+}
+
+// ListRecent 取最近 limit 条后按时间升序返回
+func (r *chatRepository) ListRecent(pageID string, limit int) ([]*entity.ChatMessage, error) {
+	var messages []*entity.ChatMessage
+	err := r.db.Where("page_id = ?", pageID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
+
+// ChatPersisted 读取 pages.chat_persisted
+func (r *chatRepository) ChatPersisted(pageID string) (bool, error) {
+	var flags []bool
+	err := r.db.Model(&entity.Page{}).
+		Where("page_id = ?", pageID).
+		Pluck("chat_persisted", &flags).Error
+	if err != nil || len(flags) == 0 {
+		return false, err
+	}
+	return flags[0], nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"errors"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// collaboratorRepository GORM 实现 CollaboratorRepository 接口
+type collaboratorRepository struct {
+	db *gorm.DB
+}
+
+// NewCollaboratorRepository 创建 CollaboratorRepository 实例
+func NewCollaboratorRepository(db *gorm.DB) domainRepo.CollaboratorRepository {
+	return &collaboratorRepository{db: db}
+}
+
+// Upsert 添加协作者，(page_id, user_id) 冲突时只更新角色和操作者
+func (r *collaboratorRepository) Upsert(collaborator *entity.PageCollaborator) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "page_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "added_by", "updated_at"}),
+	}).Create(collaborator).Error
+}
+
+// Remove 移除协作者
+func (r *collaboratorRepository) Remove(pageID, userID string) (bool, error) {
+	result := r.db.Where("page_id = ? AND user_id = ?", pageID, userID).Delete(&entity.PageCollaborator{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetRole 返回用户在页面上的协作角色
+func (r *collaboratorRepository) GetRole(pageID, userID string) (string, error) {
+	var collaborator entity.PageCollaborator
+	err := r.db.Select("role").Where("page_id = ? AND user_id = ?", pageID, userID).First(&collaborator).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return collaborator.Role, nil
+}
+
+// ListByPage 按添加时间升序返回页面的协作者
+func (r *collaboratorRepository) ListByPage(pageID string) ([]*entity.PageCollaborator, error) {
+	var collaborators []*entity.PageCollaborator
+	err := r.db.Where("page_id = ?", pageID).Order("created_at ASC, id ASC").Find(&collaborators).Error
+	return collaborators, err
+}
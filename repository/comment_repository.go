@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// commentRepository GORM 实现 CommentRepository 接口
+type commentRepository struct {
+	db *gorm.DB
+}
+
+// NewCommentRepository 创建 CommentRepository 实例
+func NewCommentRepository(db *gorm.DB) domainRepo.CommentRepository {
+	return &commentRepository{db: db}
+}
+
+// Create 创建评论
+func (r *commentRepository) Create(comment *entity.Comment) error {
+	return r.db.Create(comment).Error
+}
+
+// GetByID 根据 ID 获取评论
+func (r *commentRepository) GetByID(id uint) (*entity.Comment, error) {
+	var comment entity.Comment
+	err := r.db.First(&comment, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// ListByPage 按创建时间升序返回页面（或单个组件）的评论
+func (r *commentRepository) ListByPage(pageID, componentID string) ([]*entity.Comment, error) {
+	query := r.db.Where("page_id = ?", pageID)
+	if componentID != "" {
+		query = query.Where("component_id = ?", componentID)
+	}
+
+	var comments []*entity.Comment
+	err := query.Order("created_at ASC, id ASC").Find(&comments).Error
+	return comments, err
+}
+
+// UpdateText 修改评论内容
+func (r *commentRepository) UpdateText(id uint, text string) error {
+	return r.db.Model(&entity.Comment{}).Where("id = ?", id).Update("text", text).Error
+}
+
+// SetResolved 设置线程的解决状态，重新打开时清空解决人和时间
+func (r *commentRepository) SetResolved(id uint, resolved bool, userID string) error {
+	updates := map[string]interface{}{
+		"resolved":    resolved,
+		"resolved_by": "",
+		"resolved_at": nil,
+	}
+	if resolved {
+		updates["resolved_by"] = userID
+		updates["resolved_at"] = time.Now()
+	}
+	return r.db.Model(&entity.Comment{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// Delete 删除评论及其回复
+func (r *commentRepository) Delete(id uint) error {
+	return r.db.Where("id = ? OR parent_id = ?", id, id).Delete(&entity.Comment{}).Error
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"errors"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// conflictBackupRepository GORM 实现 ConflictBackupRepository 接口
+// 同时实现 ws.ConflictBackupStore 接口供客户端连接使用
+type conflictBackupRepository struct {
+	db *gorm.DB
+}
+
+// NewConflictBackupRepository 创建 ConflictBackupRepository 实例
+func NewConflictBackupRepository(db *gorm.DB) domainRepo.ConflictBackupRepository {
+	return &conflictBackupRepository{db: db}
+}
+
+// Save 保存冲突备份，并在同一事务中删除超出保留条数的旧备份、更新存储用量
+func (r *conflictBackupRepository) Save(backup *entity.ConflictBackup) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(backup).Error; err != nil {
+			return err
+		}
+		if err := addStorageRows(tx, "conflict_backups", "schema", usageAssets, []uint{backup.ID}); err != nil {
+			return err
+		}
+
+		var stale []uint
+		err := tx.Model(&entity.ConflictBackup{}).
+			Where("page_id = ?", backup.PageID).
+			Order("created_at DESC, id DESC").
+			Offset(entity.MaxConflictBackupsPerPage).
+			Pluck("id", &stale).Error
+		if err != nil || len(stale) == 0 {
+			return err
+		}
+		_, err = deleteCounted(tx, "conflict_backups", "schema", usageAssets, "id IN ?", stale)
+		return err
+	})
+}
+
+// Get 读取页面的一条冲突备份
+func (r *conflictBackupRepository) Get(pageID string, id uint) (*entity.ConflictBackup, error) {
+	var backup entity.ConflictBackup
+	err := r.db.Where("id = ? AND page_id = ?", id, pageID).First(&backup).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
+
+// ListByPage 按创建时间降序返回页面的冲突备份，不读取 Schema
+func (r *conflictBackupRepository) ListByPage(pageID string) ([]*entity.ConflictBackup, error) {
+	var backups []*entity.ConflictBackup
+	err := r.db.Omit("schema").
+		Where("page_id = ?", pageID).
+		Order("created_at DESC, id DESC").
+		Find(&backups).Error
+	return backups, err
+}
+
+// Delete 删除页面的一条冲突备份，同时扣减存储用量
+func (r *conflictBackupRepository) Delete(pageID string, id uint) (bool, error) {
+	deleted, err := deleteCounted(r.db, "conflict_backups", "schema", usageAssets, "id = ? AND page_id = ?", id, pageID)
+	return deleted > 0, err
+}
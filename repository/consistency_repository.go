@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// consistencyRepository GORM 实现 ConsistencyRepository 接口
+type consistencyRepository struct {
+	db *gorm.DB
+}
+
+// NewConsistencyRepository 创建 ConsistencyRepository 实例
+func NewConsistencyRepository(db *gorm.DB) domainRepo.ConsistencyRepository {
+	return &consistencyRepository{db: db}
+}
+
+// FindPagesBehindSnapshots 返回 pages.version 小于其最新快照版本的页面
+func (r *consistencyRepository) FindPagesBehindSnapshots() ([]domainRepo.SnapshotAheadRow, error) {
+	var rows []domainRepo.SnapshotAheadRow
+	err := r.db.Model(&entity.Page{}).
+		Select("pages.page_id, pages.version AS page_version, MAX(page_versions.version) AS snapshot_version").
+		Joins("JOIN page_versions ON page_versions.page_id = pages.page_id").
+		Group("pages.page_id, pages.version").
+		Having("MAX(page_versions.version) > pages.version").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// FindOrphanedSnapshots 返回页面已不存在的历史快照所属 pageId
+func (r *consistencyRepository) FindOrphanedSnapshots() ([]string, error) {
+	return r.findOrphans(&entity.PageVersion{}, "page_versions")
+}
+
+// FindOrphanedOps 返回页面已不存在的操作日志所属 pageId
+func (r *consistencyRepository) FindOrphanedOps() ([]string, error) {
+	return r.findOrphans(&entity.PageOp{}, "page_ops")
+}
+
+// findOrphans 查询 table 中 page_id 在 pages 表不存在的记录
+func (r *consistencyRepository) findOrphans(model interface{}, table string) ([]string, error) {
+	var pageIDs []string
+	err := r.db.Model(model).
+		Distinct(table+".page_id").
+		Where("NOT EXISTS (SELECT 1 FROM pages WHERE pages.page_id = " + table + ".page_id)").
+		Order(table + ".page_id").
+		Pluck(table+".page_id", &pageIDs).Error
+	return pageIDs, err
+}
+
+// GetPageVersions 批量读取页面版本号
+func (r *consistencyRepository) GetPageVersions(pageIDs []string) (map[string]int64, error) {
+	versions := make(map[string]int64, len(pageIDs))
+	if len(pageIDs) == 0 {
+		return versions, nil
+	}
+
+	var pages []entity.Page
+	if err := r.db.Select("page_id", "version").Where("page_id IN ?", pageIDs).Find(&pages).Error; err != nil {
+		return nil, err
+	}
+	for _, page := range pages {
+		versions[page.PageID] = page.Version
+	}
+	return versions, nil
+}
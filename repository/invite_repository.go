@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// inviteRepository GORM 实现 InviteRepository 接口
+type inviteRepository struct {
+	db *gorm.DB
+}
+
+// NewInviteRepository 创建 InviteRepository 实例
+func NewInviteRepository(db *gorm.DB) domainRepo.InviteRepository {
+	return &inviteRepository{db: db}
+}
+
+// Upsert 创建邀请，(page_id, email) 冲突时视为重新邀请
+func (r *inviteRepository) Upsert(invite *entity.PageInvite) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "page_id"}, {Name: "email"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "invited_by", "expires_at", "accepted_by", "accepted_at", "updated_at"}),
+	}).Create(invite).Error
+}
+
+// ListByPage 按创建时间降序返回页面的邀请
+func (r *inviteRepository) ListByPage(pageID string) ([]*entity.PageInvite, error) {
+	var invites []*entity.PageInvite
+	err := r.db.Where("page_id = ?", pageID).Order("created_at DESC, id DESC").Find(&invites).Error
+	return invites, err
+}
+
+// CountPending 统计页面待接受的邀请数
+func (r *inviteRepository) CountPending(pageID string, now time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&entity.PageInvite{}).
+		Where("page_id = ? AND accepted_at IS NULL AND expires_at > ?", pageID, now).
+		Count(&count).Error
+	return count, err
+}
+
+// ListPendingByEmail 返回发给该邮箱的待接受邀请
+func (r *inviteRepository) ListPendingByEmail(email string, now time.Time) ([]*entity.PageInvite, error) {
+	var invites []*entity.PageInvite
+	err := r.db.Where("email = ? AND accepted_at IS NULL AND expires_at > ?", email, now).
+		Order("created_at ASC, id ASC").
+		Find(&invites).Error
+	return invites, err
+}
+
+// MarkAccepted 记录邀请已被接受
+func (r *inviteRepository) MarkAccepted(id uint, userID string, at time.Time) error {
+	return r.db.Model(&entity.PageInvite{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"accepted_by": userID, "accepted_at": at}).Error
+}
+
+// Delete 删除页面的一条邀请
+func (r *inviteRepository) Delete(pageID string, id uint) (bool, error) {
+	result := r.db.Where("id = ? AND page_id = ?", id, pageID).Delete(&entity.PageInvite{})
+	return result.RowsAffected > 0, result.Error
+}
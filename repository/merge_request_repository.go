@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"errors"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// mergeRequestRepository GORM 实现 MergeRequestRepository 接口
+type mergeRequestRepository struct {
+	db *gorm.DB
+}
+
+// NewMergeRequestRepository 创建 MergeRequestRepository 实例
+func NewMergeRequestRepository(db *gorm.DB) domainRepo.MergeRequestRepository {
+	return &mergeRequestRepository{db: db}
+}
+
+// Create 保存新的合并请求
+func (r *mergeRequestRepository) Create(mr *entity.MergeRequest) error {
+	return r.db.Create(mr).Error
+}
+
+// Get 读取页面的一个合并请求
+func (r *mergeRequestRepository) Get(pageID string, id uint) (*entity.MergeRequest, error) {
+	return r.first(r.db.Where("id = ? AND page_id = ?", id, pageID))
+}
+
+// GetOpenByBranch 读取分支打开的合并请求
+func (r *mergeRequestRepository) GetOpenByBranch(branchID string) (*entity.MergeRequest, error) {
+	return r.first(r.db.Where("branch_id = ? AND status = ?", branchID, entity.MergeRequestOpen))
+}
+
+// ListByPage 按创建时间降序返回页面的合并请求
+func (r *mergeRequestRepository) ListByPage(pageID, status string) ([]*entity.MergeRequest, error) {
+	query := r.db.Where("page_id = ?", pageID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var mrs []*entity.MergeRequest
+	err := query.Order("created_at DESC, id DESC").Find(&mrs).Error
+	return mrs, err
+}
+
+// Finish 条件更新：只有仍处于打开状态的合并请求会被更新，避免并发合并、关闭互相覆盖
+func (r *mergeRequestRepository) Finish(mr *entity.MergeRequest) (bool, error) {
+	result := r.db.Model(&entity.MergeRequest{}).
+		Where("id = ? AND status = ?", mr.ID, entity.MergeRequestOpen).
+		Updates(map[string]interface{}{
+			"status":         mr.Status,
+			"strategy":       mr.Strategy,
+			"merged_by":      mr.MergedBy,
+			"base_version":   mr.BaseVersion,
+			"merged_version": mr.MergedVersion,
+			"merged_at":      mr.MergedAt,
+			"closed_at":      mr.ClosedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CreateComment 保存合并请求的讨论
+func (r *mergeRequestRepository) CreateComment(comment *entity.MergeRequestComment) error {
+	return r.db.Create(comment).Error
+}
+
+// ListComments 按时间升序返回合并请求的讨论
+func (r *mergeRequestRepository) ListComments(mergeRequestID uint) ([]*entity.MergeRequestComment, error) {
+	var comments []*entity.MergeRequestComment
+	err := r.db.Where("merge_request_id = ?", mergeRequestID).Order("created_at ASC, id ASC").Find(&comments).Error
+	return comments, err
+}
+
+func (r *mergeRequestRepository) first(query *gorm.DB) (*entity.MergeRequest, error) {
+	var mr entity.MergeRequest
+	err := query.First(&mr).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
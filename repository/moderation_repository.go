@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// moderationRepository GORM 实现 ModerationRepository 接口
+type moderationRepository struct {
+	db *gorm.DB
+}
+
+// NewModerationRepository 创建 ModerationRepository 实例
+func NewModerationRepository(db *gorm.DB) domainRepo.ModerationRepository {
+	return &moderationRepository{db: db}
+}
+
+// CreateFlags 批量插入审核记录
+func (r *moderationRepository) CreateFlags(flags []*entity.ModerationFlag) error {
+	if len(flags) == 0 {
+		return nil
+	}
+	return r.db.Create(&flags).Error
+}
+
+// ListFlags 按条件查询审核记录
+func (r *moderationRepository) ListFlags(filter domainRepo.ModerationFlagFilter) ([]*entity.ModerationFlag, error) {
+	query := r.db.Model(&entity.ModerationFlag{})
+	if filter.PageID != "" {
+		query = query.Where("page_id = ?", filter.PageID)
+	}
+	if filter.Pending {
+		query = query.Where("reviewed_at IS NULL")
+	}
+	if filter.BeforeID > 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+
+	var flags []*entity.ModerationFlag
+	err := query.Order("id DESC").Limit(filter.Limit).Find(&flags).Error
+	return flags, err
+}
+
+// MarkReviewed 写入复核时间，已复核的记录保留原时间
+func (r *moderationRepository) MarkReviewed(id uint) (*entity.ModerationFlag, error) {
+	var flag entity.ModerationFlag
+	if err := r.db.First(&flag, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainErrors.ErrModerationFlagNotFound
+		}
+		return nil, err
+	}
+	if flag.ReviewedAt != nil {
+		return &flag, nil
+	}
+
+	now := time.Now()
+	if err := r.db.Model(&flag).Update("reviewed_at", now).Error; err != nil {
+		return nil, err
+	}
+	flag.ReviewedAt = &now
+	return &flag, nil
+}
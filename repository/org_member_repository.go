@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// orgMemberRepository GORM 实现 OrgMemberRepository 接口
+type orgMemberRepository struct {
+	db *gorm.DB
+}
+
+// NewOrgMemberRepository 创建 OrgMemberRepository 实例
+func NewOrgMemberRepository(db *gorm.DB) domainRepo.OrgMemberRepository {
+	return &orgMemberRepository{db: db}
+}
+
+// Upsert 写入或更新用户在组织中的角色
+func (r *orgMemberRepository) Upsert(orgID, userID, role string) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "org_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(&entity.OrgMember{OrgID: orgID, UserID: userID, Role: role, UpdatedAt: time.Now()}).Error
+}
+
+// GetRole 返回用户在组织中的角色
+func (r *orgMemberRepository) GetRole(orgID, userID string) (string, error) {
+	var member entity.OrgMember
+	err := r.db.Select("role").Where("org_id = ? AND user_id = ?", orgID, userID).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// Remove 移除用户的组织成员关系
+func (r *orgMemberRepository) Remove(orgID, userID string) error {
+	return r.db.Where("org_id = ? AND user_id = ?", orgID, userID).Delete(&entity.OrgMember{}).Error
+}
+
+// RemoveOrg 移除组织的全部成员关系
+func (r *orgMemberRepository) RemoveOrg(orgID string) error {
+	return r.db.Where("org_id = ?", orgID).Delete(&entity.OrgMember{}).Error
+}
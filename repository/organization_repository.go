@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"errors"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// organizationRepository GORM 实现 OrganizationRepository 接口
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+// NewOrganizationRepository 创建 OrganizationRepository 实例
+func NewOrganizationRepository(db *gorm.DB) domainRepo.OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+// Upsert 创建或更新组织资料
+// 成员关系事件中的组织没有 created_by，此时保留 organization.created 写入的值
+func (r *organizationRepository) Upsert(org *entity.Organization) error {
+	columns := []string{"name", "slug", "image_url", "updated_at"}
+	if org.CreatedBy != "" {
+		columns = append(columns, "created_by")
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(org).Error
+}
+
+// GetByID 根据 Clerk org_id 查询组织
+func (r *organizationRepository) GetByID(orgID string) (*entity.Organization, error) {
+	var org entity.Organization
+	err := r.db.Where("id = ?", orgID).First(&org).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// Delete 删除组织资料
+func (r *organizationRepository) Delete(orgID string) error {
+	return r.db.Where("id = ?", orgID).Delete(&entity.Organization{}).Error
+}
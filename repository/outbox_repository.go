@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// outboxRepository GORM 实现 OutboxRepository 接口
+// 同时实现 ws.OutboxStore 接口供生命周期事件使用
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository 创建 OutboxRepository 实例
+func NewOutboxRepository(db *gorm.DB) domainRepo.OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// Append 追加一条事件
+func (r *outboxRepository) Append(event *entity.OutboxEvent) error {
+	return r.db.Create(event).Error
+}
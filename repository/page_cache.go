@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/pagecache"
+
+	"gorm.io/gorm"
+)
+
+// cachedPageRepository 在 pageRepository 前加一层 Redis 缓存，只缓存 GetByPageID。
+// 修改页面的方法在写入数据库后使缓存失效；Hub 加载房间（GetPageState）仍直接读数据库
+type cachedPageRepository struct {
+	*pageRepository
+	cache *pagecache.Cache
+}
+
+// NewCachedPageRepository 创建带读取缓存的 PageRepository 实例，
+// 与 NewPageRepository 一样实现 ws.PageService、ws.DeltaStore 和 ws.SettingsStore
+func NewCachedPageRepository(db *gorm.DB, cache *pagecache.Cache) domainRepo.PageRepository {
+	return &cachedPageRepository{pageRepository: &pageRepository{db: db}, cache: cache}
+}
+
+// GetByPageID 优先读取缓存，未命中时查询数据库（含差量回放）并写入缓存
+func (r *cachedPageRepository) GetByPageID(pageID string) (*entity.Page, error) {
+	return r.cache.Get(pageID, func() (*entity.Page, error) {
+		return r.pageRepository.GetByPageID(pageID)
+	})
+}
+
+// InvalidatePages 实现 PageCacheInvalidator
+func (r *cachedPageRepository) InvalidatePages(pageIDs ...string) {
+	r.cache.Invalidate(pageIDs...)
+}
+
+// UpdateSchema 写入全量快照后使缓存失效
+func (r *cachedPageRepository) UpdateSchema(pageID string, schema []byte, oldVersion, newVersion int64) error {
+	defer r.cache.Invalidate(pageID)
+	return r.pageRepository.UpdateSchema(pageID, schema, oldVersion, newVersion)
+}
+
+// SavePageState 刷盘后使缓存失效
+func (r *cachedPageRepository) SavePageState(pageID string, state []byte, oldVersion, newVersion int64) error {
+	return r.UpdateSchema(pageID, state, oldVersion, newVersion)
+}
+
+// SavePageDelta 差量刷盘后使缓存失效
+func (r *cachedPageRepository) SavePageDelta(pageID string, patches []byte, oldVersion, newVersion int64) error {
+	defer r.cache.Invalidate(pageID)
+	return r.pageRepository.SavePageDelta(pageID, patches, oldVersion, newVersion)
+}
+
+// Publish 写入发布副本后使缓存失效
+func (r *cachedPageRepository) Publish(pageID string, schema []byte, version int64) error {
+	defer r.cache.Invalidate(pageID)
+	return r.pageRepository.Publish(pageID, schema, version)
+}
+
+// SetLinkEdit 修改后使缓存失效
+func (r *cachedPageRepository) SetLinkEdit(pageID string, enabled bool) error {
+	defer r.cache.Invalidate(pageID)
+	return r.pageRepository.SetLinkEdit(pageID, enabled)
+}
+
+// SetChatPersisted 修改后使缓存失效
+func (r *cachedPageRepository) SetChatPersisted(pageID string, enabled bool) error {
+	defer r.cache.Invalidate(pageID)
+	return r.pageRepository.SetChatPersisted(pageID, enabled)
+}
+
+// SetCollabSettings 修改后使缓存失效
+func (r *cachedPageRepository) SetCollabSettings(pageID string, settings entity.CollabSettings) error {
+	defer r.cache.Invalidate(pageID)
+	return r.pageRepository.SetCollabSettings(pageID, settings)
+}
+
+// Delete 删除页面后使页面及其草稿分支的缓存失效
+func (r *cachedPageRepository) Delete(pageID string) error {
+	branchPageIDs, err := r.pageRepository.BranchPageIDs(pageID)
+	if err != nil {
+		return err
+	}
+	defer r.cache.Invalidate(append(branchPageIDs, pageID)...)
+	return r.pageRepository.Delete(pageID)
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"gorm.io/gorm"
+)
+
+// errDeltaGap 差量不连续，无法从快照回放到目标版本
+var errDeltaGap = errors.New("page deltas are not contiguous")
+
+// SavePageDelta 追加差量并推进页面版本号（供 Room 差量刷盘使用）
+// Schema 和 snapshot_version 保持不变，读取时由 replayDeltas 回放得到最新状态；差量计入操作日志用量
+func (r *pageRepository) SavePageDelta(pageID string, patches []byte, oldVersion, newVersion int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.Page{}).
+			Where("page_id = ? AND version = ?", pageID, oldVersion).
+			Update("version", newVersion)
+
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domainErrors.ErrOptimisticLock
+		}
+
+		delta := &entity.PageDelta{
+			PageID:      pageID,
+			Version:     newVersion,
+			BaseVersion: oldVersion,
+			Patches:     patches,
+		}
+		if err := tx.Create(delta).Error; err != nil {
+			return err
+		}
+		return addStorageRows(tx, "page_deltas", "patches", usageJournals, []uint{delta.ID})
+	})
+}
+
+// replayDeltas 从 baseVersion 的快照开始依次应用差量，返回 target 版本的状态
+// target 落在某条差量中间时只应用该差量的前若干个 Patch
+func replayDeltas(db *gorm.DB, pageID string, base []byte, baseVersion, target int64) ([]byte, error) {
+	var deltas []*entity.PageDelta
+	err := db.Where("page_id = ? AND version > ? AND base_version < ?", pageID, baseVersion, target).
+		Order("version ASC").
+		Find(&deltas).Error
+	if err != nil {
+		return nil, err
+	}
+
+	state, current := base, baseVersion
+	for _, delta := range deltas {
+		if delta.BaseVersion != current {
+			break
+		}
+
+		var patches []json.RawMessage
+		if err := json.Unmarshal(delta.Patches, &patches); err != nil {
+			return nil, fmt.Errorf("页面 %s 差量 %d 解析失败: %w", pageID, delta.Version, err)
+		}
+		if int64(len(patches)) != delta.Version-delta.BaseVersion {
+			return nil, fmt.Errorf("页面 %s 差量 %d 的 Patch 数量与版本跨度不一致", pageID, delta.Version)
+		}
+
+		for _, raw := range patches {
+			if current == target {
+				break
+			}
+			patch, err := jsonpatch.DecodePatch(raw)
+			if err != nil {
+				return nil, fmt.Errorf("页面 %s 版本 %d 的 Patch 解析失败: %w", pageID, current+1, err)
+			}
+			if state, err = patch.Apply(state); err != nil {
+				return nil, fmt.Errorf("页面 %s 版本 %d 的 Patch 回放失败: %w", pageID, current+1, err)
+			}
+			current++
+		}
+	}
+
+	if current != target {
+		return nil, fmt.Errorf("页面 %s 从版本 %d 回放到 %d 时停在 %d: %w", pageID, baseVersion, target, current, errDeltaGap)
+	}
+	return state, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// pageOpRepository GORM 实现 PageOpRepository 接口
+// 同时实现 ws.OpStore 接口供 OpLogWriter 使用
+type pageOpRepository struct {
+	db *gorm.DB
+}
+
+// NewPageOpRepository 创建 PageOpRepository 实例
+func NewPageOpRepository(db *gorm.DB) domainRepo.PageOpRepository {
+	return &pageOpRepository{db: db}
+}
+
+// CreateBatch 批量写入操作日志并计入存储用量。
+// 已存在的 (pageID, version) 被覆盖：未能刷盘的版本会被重新加载的房间以相同版本号再次产生，以后写入的为准；
+// 同时保证失败重试幂等
+func (r *pageOpRepository) CreateBatch(ops []*entity.PageOp) error {
+	if len(ops) == 0 {
+		return nil
+	}
+	keys := make([][]interface{}, len(ops))
+	for i, op := range ops {
+		op.ID = 0 // 上次失败的事务可能已回填主键
+		keys[i] = []interface{}{op.PageID, op.Version}
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := deleteCounted(tx, "page_ops", "patch", usageJournals, "(page_id, version) IN ?", keys); err != nil {
+			return err
+		}
+		if err := tx.Create(&ops).Error; err != nil {
+			return err
+		}
+		ids := make([]uint, len(ops))
+		for i, op := range ops {
+			ids[i] = op.ID
+		}
+		return addStorageRows(tx, "page_ops", "patch", usageJournals, ids)
+	})
+}
+
+// ListSince 按版本升序返回 sinceVersion 之后的操作
+func (r *pageOpRepository) ListSince(pageID string, sinceVersion int64, limit int) ([]*entity.PageOp, error) {
+	var ops []*entity.PageOp
+	err := r.db.Where("page_id = ? AND version > ?", pageID, sinceVersion).
+		Order("version ASC").
+		Limit(limit).
+		Find(&ops).Error
+	return ops, err
+}
+
+// DeleteBefore 删除早于 cutoff 的操作日志，同时扣减存储用量
+func (r *pageOpRepository) DeleteBefore(cutoff time.Time) (int64, error) {
+	return deleteCounted(r.db, "page_ops", "patch", usageJournals, "created_at < ?", cutoff)
+}
+
+// ListOversized 返回操作日志超过 maxOps 条的页面
+func (r *pageOpRepository) ListOversized(maxOps int) ([]domainRepo.JournalSize, error) {
+	var sizes []domainRepo.JournalSize
+	err := r.db.Model(&entity.PageOp{}).
+		Select("page_id, COUNT(*) AS count, MAX(version) AS max_version").
+		Group("page_id").
+		Having("COUNT(*) > ?", maxOps).
+		Scan(&sizes).Error
+	return sizes, err
+}
+
+// DeleteAfter 删除页面版本大于 version 的操作日志，同时扣减存储用量
+func (r *pageOpRepository) DeleteAfter(pageID string, version int64) (int64, error) {
+	return deleteCounted(r.db, "page_ops", "patch", usageJournals, "page_id = ? AND version > ?", pageID, version)
+}
+
+// DeleteThrough 删除页面版本不超过 version 的操作日志，同时扣减存储用量
+func (r *pageOpRepository) DeleteThrough(pageID string, version int64) (int64, error) {
+	return deleteCounted(r.db, "page_ops", "patch", usageJournals, "page_id = ? AND version <= ?", pageID, version)
+}
+
+// CountLabels 按标签统计版本在 (from, to] 之间的操作数，未标注的操作不计入
+func (r *pageOpRepository) CountLabels(pageID string, from, to int64) (map[string]int64, error) {
+	var rows []struct {
+		Label string
+		Count int64
+	}
+	err := r.db.Model(&entity.PageOp{}).
+		Select("label, COUNT(*) AS count").
+		Where("page_id = ? AND version > ? AND version <= ? AND label <> ''", pageID, from, to).
+		Group("label").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Label] = row.Count
+	}
+	return counts, nil
+}
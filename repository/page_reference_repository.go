@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// pageReferenceRepository GORM 实现 PageReferenceRepository 接口
+type pageReferenceRepository struct {
+	db *gorm.DB
+}
+
+// NewPageReferenceRepository 创建 PageReferenceRepository 实例
+func NewPageReferenceRepository(db *gorm.DB) domainRepo.PageReferenceRepository {
+	return &pageReferenceRepository{db: db}
+}
+
+// ReplaceForPage 在一个事务中删除页面原有的出站引用并写入 refs
+func (r *pageReferenceRepository) ReplaceForPage(sourcePageID string, refs []*entity.PageReference) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("source_page_id = ?", sourcePageID).Delete(&entity.PageReference{}).Error; err != nil {
+			return err
+		}
+		if len(refs) == 0 {
+			return nil
+		}
+		return tx.Create(refs).Error
+	})
+}
+
+// ListInbound 返回引用 targetPageID 的全部记录，不含草稿分支中的引用
+func (r *pageReferenceRepository) ListInbound(targetPageID string) ([]*entity.PageReference, error) {
+	var refs []*entity.PageReference
+	err := r.db.Select("page_references.*").
+		Joins("JOIN pages ON pages.page_id = page_references.source_page_id").
+		Where("page_references.target_page_id = ? AND pages.branch_of = '' AND pages.deleted_at IS NULL", targetPageID).
+		Order("page_references.source_page_id ASC, page_references.path ASC").
+		Find(&refs).Error
+	return refs, err
+}
+
+// UnindexedPages 返回 Schema 中可能含有引用、但还没有索引记录的页面
+// 差量持久化的页面只检查最近的全量快照，之后新增的引用在下次刷盘时索引
+func (r *pageReferenceRepository) UnindexedPages() ([]string, error) {
+	var pageIDs []string
+	err := r.db.Model(&entity.Page{}).
+		Where("branch_of = '' AND schema::text LIKE ?", `%"$page"%`).
+		Where("NOT EXISTS (SELECT 1 FROM page_references r WHERE r.source_page_id = pages.page_id)").
+		Order("id ASC").
+		Pluck("page_id", &pageIDs).Error
+	return pageIDs, err
+}
+
+// MissingPages 返回 pageIDs 中不存在的页面 ID
+func (r *pageReferenceRepository) MissingPages(pageIDs []string) ([]string, error) {
+	if len(pageIDs) == 0 {
+		return nil, nil
+	}
+
+	var existing []string
+	if err := r.db.Model(&entity.Page{}).Where("page_id IN ?", pageIDs).Pluck("page_id", &existing).Error; err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		found[id] = true
+	}
+
+	var missing []string
+	for _, id := range pageIDs {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
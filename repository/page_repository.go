@@ -1,18 +1,22 @@
 package repository
 
 import (
+	"encoding/json"
 	"errors"
 	"strings"
+	"time"
 
 	"lowercode-go-server/domain/entity"
 	domainErrors "lowercode-go-server/domain/errors"
 	domainRepo "lowercode-go-server/domain/repository"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // pageRepository GORM 实现 PageRepository 接口
-// 同时实现 ws.PageService 接口供 Hub 使用
+// 同时实现 ws.PageService、ws.DeltaStore 和 ws.SettingsStore 接口供 Hub 使用
 type pageRepository struct {
 	db *gorm.DB
 }
@@ -25,19 +29,81 @@ func NewPageRepository(db *gorm.DB) domainRepo.PageRepository {
 // --- domain.PageRepository 接口实现 ---
 
 // GetByPageID 根据业务 ID 查询页面
+// 最新版本尚未写入全量快照时，回放差量得到最新 Schema
 func (r *pageRepository) GetByPageID(pageID string) (*entity.Page, error) {
 	var page entity.Page
 	err := r.db.Where("page_id = ?", pageID).First(&page).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
-	return &page, err
+	if err != nil {
+		return nil, err
+	}
+
+	if page.SnapshotVersion < page.Version {
+		state, err := replayDeltas(r.db, pageID, page.Schema, page.SnapshotVersion, page.Version)
+		if err != nil {
+			return nil, err
+		}
+		page.Schema = datatypes.JSON(state)
+	}
+	return &page, nil
+}
+
+// GetAccessInfo 只查询权限检查需要的列，不读取 Schema 也不回放差量
+func (r *pageRepository) GetAccessInfo(pageID string) (*entity.Page, error) {
+	var page entity.Page
+	err := r.db.Select("page_id", "creator_id", "org_id", "branch_of", "link_edit_enabled").
+		Where("page_id = ?", pageID).First(&page).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
 }
 
-// Create 创建新页面
+// pageListColumns 页面列表读取的列，不含 Schema 和发布副本
+var pageListColumns = []string{"page_id", "version", "creator_id", "org_id", "published_version", "created_at", "updated_at"}
+
+// ListByCreator 按最近更新时间倒序返回用户的个人页面
+func (r *pageRepository) ListByCreator(creatorID string, limit int) ([]*entity.Page, error) {
+	var pages []*entity.Page
+	err := r.db.Select(pageListColumns).
+		Where("creator_id = ? AND org_id = '' AND branch_of = ''", creatorID).
+		Order("updated_at DESC").
+		Limit(limit).
+		Find(&pages).Error
+	return pages, err
+}
+
+// CountByCreator 返回用户创建的页面数，不含草稿分支
+func (r *pageRepository) CountByCreator(creatorID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&entity.Page{}).
+		Where("creator_id = ? AND branch_of = ''", creatorID).
+		Count(&count).Error
+	return count, err
+}
+
+// ListByOrg 按最近更新时间倒序返回组织的页面
+func (r *pageRepository) ListByOrg(orgID string, limit int) ([]*entity.Page, error) {
+	var pages []*entity.Page
+	err := r.db.Select(pageListColumns).
+		Where("org_id = ?", orgID).
+		Order("updated_at DESC").
+		Limit(limit).
+		Find(&pages).Error
+	return pages, err
+}
+
+// Create 创建新页面，并写入初始版本快照和存储用量记录
 // 注意：禁止使用 GORM Save，它会覆盖 schema 和 version
 func (r *pageRepository) Create(page *entity.Page) error {
-	err := r.db.Create(page).Error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return createPage(tx, page)
+	})
 	if err != nil {
 		// 检查唯一约束冲突（PostgreSQL 错误码 23505 = unique_violation）
 		if strings.Contains(err.Error(), "duplicate key") ||
@@ -54,26 +120,141 @@ func (r *pageRepository) Create(page *entity.Page) error {
 // 支持版本跳跃：内存中可能积累了多个版本，一次性刷盘
 // oldVersion: 上次持久化的版本号（用于 WHERE 条件）
 // newVersion: 要写入的新版本号（允许跳跃）
+// 同一事务内写入 newVersion 的版本快照并更新存储用量
 func (r *pageRepository) UpdateSchema(pageID string, schema []byte, oldVersion, newVersion int64) error {
-	result := r.db.Model(&entity.Page{}).
-		Where("page_id = ? AND version = ?", pageID, oldVersion).
-		Updates(map[string]interface{}{
-			"schema":  string(schema),
-			"version": newVersion,
-		})
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.Page{}).
+			Where("page_id = ? AND version = ?", pageID, oldVersion).
+			Updates(map[string]interface{}{
+				"schema":           string(schema),
+				"version":          newVersion,
+				"snapshot_version": newVersion,
+			})
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// 检查是否更新了记录，RowsAffected == 0 表示版本冲突或页面不存在
+		if result.RowsAffected == 0 {
+			return domainErrors.ErrOptimisticLock
+		}
+
+		if err := refreshSchemaStorage(tx, pageID); err != nil {
+			return err
+		}
+		return insertVersion(tx, pageID, newVersion, schema)
+	})
+}
 
+// Publish 写入发布副本，只更新 published_* 字段
+func (r *pageRepository) Publish(pageID string, schema []byte, version int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.Page{}).
+			Where("page_id = ?", pageID).
+			Updates(map[string]interface{}{
+				"published_schema":  string(schema),
+				"published_version": version,
+				"published_at":      time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domainErrors.ErrPageNotFound
+		}
+		return refreshSchemaStorage(tx, pageID)
+	})
+}
+
+// SetLinkEdit 更新 link_edit_enabled 字段
+func (r *pageRepository) SetLinkEdit(pageID string, enabled bool) error {
+	result := r.db.Model(&entity.Page{}).
+		Where("page_id = ?", pageID).
+		Update("link_edit_enabled", enabled)
 	if result.Error != nil {
 		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		return domainErrors.ErrPageNotFound
+	}
+	return nil
+}
 
-	// 检查是否更新了记录，RowsAffected == 0 表示版本冲突或页面不存在
+// SetChatPersisted 更新 chat_persisted 字段
+func (r *pageRepository) SetChatPersisted(pageID string, enabled bool) error {
+	result := r.db.Model(&entity.Page{}).
+		Where("page_id = ?", pageID).
+		Update("chat_persisted", enabled)
+	if result.Error != nil {
+		return result.Error
+	}
 	if result.RowsAffected == 0 {
-		return domainErrors.ErrOptimisticLock
+		return domainErrors.ErrPageNotFound
 	}
+	return nil
+}
 
+// SetCollabSettings 更新 collab_settings 字段
+func (r *pageRepository) SetCollabSettings(pageID string, settings entity.CollabSettings) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	result := r.db.Model(&entity.Page{}).
+		Where("page_id = ?", pageID).
+		Update("collab_settings", datatypes.JSON(data))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domainErrors.ErrPageNotFound
+	}
 	return nil
 }
 
+// CollabSettings 读取页面级协同设置，实现 ws.SettingsStore；页面不存在时返回默认设置
+func (r *pageRepository) CollabSettings(pageID string) (entity.CollabSettings, error) {
+	var values []datatypes.JSON
+	err := r.db.Model(&entity.Page{}).
+		Where("page_id = ?", pageID).
+		Pluck("collab_settings", &values).Error
+	if err != nil || len(values) == 0 {
+		return entity.DefaultCollabSettings(), err
+	}
+	return entity.ParseCollabSettings(values[0]), nil
+}
+
+// createPage 写入新页面（含草稿分支页面）、存储用量记录和初始版本快照
+func createPage(tx *gorm.DB, page *entity.Page) error {
+	page.SnapshotVersion = page.Version
+	if err := tx.Create(page).Error; err != nil {
+		return err
+	}
+	if err := createStorage(tx, page); err != nil {
+		return err
+	}
+	return insertVersion(tx, page.PageID, page.Version, page.Schema)
+}
+
+// insertVersion 写入版本快照并计入存储用量，同一版本重复写入时忽略
+func insertVersion(tx *gorm.DB, pageID string, version int64, schema []byte) error {
+	return insertVersionRow(tx, &entity.PageVersion{
+		PageID:  pageID,
+		Version: version,
+		Schema:  datatypes.JSON(schema),
+	})
+}
+
+// insertVersionRow 写入版本快照，确有写入时计入存储用量
+func insertVersionRow(tx *gorm.DB, v *entity.PageVersion) error {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(v)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+	return addStorageRows(tx, "page_versions", "schema", usageSnapshots, []uint{v.ID})
+}
+
 // --- ws.PageService 接口实现 ---
 
 // GetPageState 获取页面状态（供 Hub 使用）
@@ -105,8 +286,79 @@ func (r *pageRepository) SavePageState(pageID string, state []byte, oldVersion,
 	return r.UpdateSchema(pageID, state, oldVersion, newVersion)
 }
 
-// Delete 删除页面
+// Delete 删除页面及其历史版本、差量、操作日志、聊天记录、评论、存储用量记录和出站引用
 // 注意：调用前必须先调用 Hub.CloseRoom 关闭内存中的协同房间
 func (r *pageRepository) Delete(pageID string) error {
-	return r.db.Where("page_id = ?", pageID).Delete(&entity.Page{}).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return deletePage(tx, pageID)
+	})
+}
+
+// deletePage 在事务 tx 中删除页面及其草稿分支的全部数据，见 Delete。
+// 页面记录物理删除，包括已软删除的草稿分支
+func deletePage(tx *gorm.DB, pageID string) error {
+	// 草稿分支随页面一起删除
+	var pageIDs []string
+	if err := tx.Unscoped().Model(&entity.Page{}).Where("branch_of = ?", pageID).Pluck("page_id", &pageIDs).Error; err != nil {
+		return err
+	}
+	pageIDs = append(pageIDs, pageID)
+
+	if err := tx.Where("page_id = ? OR branch_page_id IN ?", pageID, pageIDs).Delete(&entity.PageBranch{}).Error; err != nil {
+		return err
+	}
+	// 被删除分支上打开的合并请求随之关闭，页面自身的合并请求和讨论一并删除
+	if err := tx.Model(&entity.MergeRequest{}).
+		Where("branch_page_id IN ? AND status = ?", pageIDs, entity.MergeRequestOpen).
+		Updates(map[string]interface{}{"status": entity.MergeRequestClosed, "closed_at": time.Now()}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("page_id IN ?", pageIDs).Delete(&entity.MergeRequestComment{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("page_id IN ?", pageIDs).Delete(&entity.MergeRequest{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("page_id IN ?", pageIDs).Delete(&entity.PageVersion{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("page_id IN ?", pageIDs).Delete(&entity.PageDelta{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("page_id IN ?", pageIDs).Delete(&entity.ChatMessage{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("page_id IN ?", pageIDs).Delete(&entity.Comment{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("page_id IN ?", pageIDs).Delete(&entity.PageOp{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("page_id IN ?", pageIDs).Delete(&entity.PageActivity{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("page_id IN ?", pageIDs).Delete(&entity.PageCollaborator{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("page_id IN ?", pageIDs).Delete(&entity.PageInvite{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("page_id IN ?", pageIDs).Delete(&entity.ConflictBackup{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("page_id IN ?", pageIDs).Delete(&entity.PageStorage{}).Error; err != nil {
+		return err
+	}
+	// 只删除出站引用；其他页面指向该页面的引用保留，查询时显示为失效
+	if err := tx.Where("source_page_id IN ?", pageIDs).Delete(&entity.PageReference{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("page_id IN ?", pageIDs).Delete(&entity.Page{}).Error
+}
+
+// BranchPageIDs 返回页面全部草稿分支的页面 ID
+func (r *pageRepository) BranchPageIDs(pageID string) ([]string, error) {
+	var pageIDs []string
+	err := r.db.Model(&entity.Page{}).Where("branch_of = ?", pageID).Pluck("page_id", &pageIDs).Error
+	return pageIDs, err
 }
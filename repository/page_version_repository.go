@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// pageVersionRepository GORM 实现 PageVersionRepository 接口
+type pageVersionRepository struct {
+	db *gorm.DB
+}
+
+// NewPageVersionRepository 创建 PageVersionRepository 实例
+func NewPageVersionRepository(db *gorm.DB) domainRepo.PageVersionRepository {
+	return &pageVersionRepository{db: db}
+}
+
+// GetByVersion 获取指定版本的快照
+// 该版本由差量刷盘写入、没有全量快照时，从之前最近的快照回放差量得到
+func (r *pageVersionRepository) GetByVersion(pageID string, version int64) (*entity.PageVersion, error) {
+	var v entity.PageVersion
+	err := r.db.Where("page_id = ? AND version = ?", pageID, version).First(&v).Error
+	if err == nil {
+		return &v, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var base entity.PageVersion
+	err = r.db.Where("page_id = ? AND version < ?", pageID, version).Order("version DESC").First(&base).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := replayDeltas(r.db, pageID, base.Schema, base.Version, version)
+	if errors.Is(err, errDeltaGap) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entity.PageVersion{
+		PageID:  pageID,
+		Version: version,
+		Schema:  datatypes.JSON(state),
+	}, nil
+}
+
+// ListPageIDsBefore 返回存在早于 cutoff 的快照的页面
+func (r *pageVersionRepository) ListPageIDsBefore(cutoff time.Time) ([]string, error) {
+	var pageIDs []string
+	err := r.db.Model(&entity.PageVersion{}).
+		Where("created_at < ?", cutoff).
+		Distinct("page_id").
+		Pluck("page_id", &pageIDs).Error
+	return pageIDs, err
+}
+
+// ListMetaBefore 按版本升序返回页面早于 cutoff 的快照，只读取 id、version、created_at
+func (r *pageVersionRepository) ListMetaBefore(pageID string, cutoff time.Time) ([]*entity.PageVersion, error) {
+	var versions []*entity.PageVersion
+	err := r.db.Select("id", "page_id", "version", "created_at").
+		Where("page_id = ? AND created_at < ?", pageID, cutoff).
+		Order("version ASC").
+		Find(&versions).Error
+	return versions, err
+}
+
+// DeleteByIDs 批量删除快照，同时扣减存储用量
+func (r *pageVersionRepository) DeleteByIDs(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := deleteCounted(r.db, "page_versions", "schema", usageSnapshots, "id IN ?", ids)
+	return err
+}
+
+// SaveCheckpoint 保存检查点快照
+// 使用 ON CONFLICT DO NOTHING，并发刷盘已写入同一版本的快照时保留已有记录
+func (r *pageVersionRepository) SaveCheckpoint(v *entity.PageVersion) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return insertVersionRow(tx, v)
+	})
+}
+
+// DeleteDeltasBefore 删除早于 cutoff 且已被页面当前全量快照覆盖的差量
+// 未被覆盖的差量是加载最新状态所必需的，始终保留
+func (r *pageVersionRepository) DeleteDeltasBefore(cutoff time.Time) (int64, error) {
+	return deleteCounted(r.db, "page_deltas", "patches", usageJournals,
+		"created_at < ? AND version <= (SELECT snapshot_version FROM pages WHERE pages.page_id = page_deltas.page_id)", cutoff)
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// revokedSessionRepository GORM 实现 RevokedSessionRepository 接口
+type revokedSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewRevokedSessionRepository 创建 RevokedSessionRepository 实例
+func NewRevokedSessionRepository(db *gorm.DB) domainRepo.RevokedSessionRepository {
+	return &revokedSessionRepository{db: db}
+}
+
+// Create 保存吊销记录
+func (r *revokedSessionRepository) Create(revoked *entity.RevokedSession) error {
+	return r.db.Create(revoked).Error
+}
+
+// ListAfter 按 ID 升序返回 afterID 之后尚未到期的记录
+func (r *revokedSessionRepository) ListAfter(afterID uint, now time.Time) ([]*entity.RevokedSession, error) {
+	var revoked []*entity.RevokedSession
+	err := r.db.Where("id > ? AND expires_at > ?", afterID, now).Order("id ASC").Find(&revoked).Error
+	return revoked, err
+}
+
+// DeleteExpired 删除已到期的记录
+func (r *revokedSessionRepository) DeleteExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at <= ?", now).Delete(&entity.RevokedSession{})
+	return result.RowsAffected, result.Error
+}
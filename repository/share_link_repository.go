@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// shareLinkRepository GORM 实现 ShareLinkRepository 接口
+type shareLinkRepository struct {
+	db *gorm.DB
+}
+
+// NewShareLinkRepository 创建 ShareLinkRepository 实例
+func NewShareLinkRepository(db *gorm.DB) domainRepo.ShareLinkRepository {
+	return &shareLinkRepository{db: db}
+}
+
+// Create 保存新的分享链接
+func (r *shareLinkRepository) Create(link *entity.ShareLink) error {
+	return r.db.Create(link).Error
+}
+
+// Get 按 ID 读取分享链接
+func (r *shareLinkRepository) Get(id string) (*entity.ShareLink, error) {
+	var link entity.ShareLink
+	err := r.db.Where("id = ?", id).First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// ListByPage 按创建时间降序返回页面的分享链接
+func (r *shareLinkRepository) ListByPage(pageID string) ([]*entity.ShareLink, error) {
+	var links []*entity.ShareLink
+	err := r.db.Where("page_id = ?", pageID).Order("created_at DESC, id ASC").Find(&links).Error
+	return links, err
+}
+
+// Revoke 撤销页面的分享链接，已撤销的链接保持原撤销时间
+func (r *shareLinkRepository) Revoke(pageID, id string, at time.Time) (bool, error) {
+	result := r.db.Model(&entity.ShareLink{}).
+		Where("id = ? AND page_id = ? AND revoked_at IS NULL", id, pageID).
+		Update("revoked_at", at)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// page_storages 中各类用量的列名
+const (
+	usageSnapshots = "snapshot_bytes"
+	usageAssets    = "asset_bytes"
+	usageJournals  = "journal_bytes"
+)
+
+// storageRepository GORM 实现 StorageRepository 接口
+type storageRepository struct {
+	db *gorm.DB
+}
+
+// NewStorageRepository 创建 StorageRepository 实例
+func NewStorageRepository(db *gorm.DB) domainRepo.StorageRepository {
+	return &storageRepository{db: db}
+}
+
+// usageColumns 汇总用量的列，发布副本计入 Schema
+const usageColumns = `tenant_id, COUNT(*) AS pages,
+	SUM(schema_bytes + published_bytes) AS schema_bytes,
+	SUM(snapshot_bytes) AS snapshot_bytes,
+	SUM(asset_bytes) AS asset_bytes,
+	SUM(journal_bytes) AS journal_bytes`
+
+// TenantUsage 汇总租户全部页面的用量
+func (r *storageRepository) TenantUsage(tenantID string) (*entity.StorageUsage, error) {
+	var usage []*entity.StorageUsage
+	err := r.db.Model(&entity.PageStorage{}).
+		Select(usageColumns).
+		Where("tenant_id = ?", tenantID).
+		Group("tenant_id").
+		Scan(&usage).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(usage) == 0 {
+		return &entity.StorageUsage{TenantID: tenantID}, nil
+	}
+	return usage[0], nil
+}
+
+// ListTopTenants 按总用量降序返回用量最大的租户
+func (r *storageRepository) ListTopTenants(limit int) ([]*entity.StorageUsage, error) {
+	var usage []*entity.StorageUsage
+	err := r.db.Model(&entity.PageStorage{}).
+		Select(usageColumns).
+		Group("tenant_id").
+		Order("SUM(schema_bytes + published_bytes + snapshot_bytes + asset_bytes + journal_bytes) DESC").
+		Limit(limit).
+		Scan(&usage).Error
+	return usage, err
+}
+
+// GetPlan 读取租户的套餐
+func (r *storageRepository) GetPlan(tenantID string) (string, error) {
+	var plan entity.TenantPlan
+	err := r.db.Where("tenant_id = ?", tenantID).First(&plan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return plan.Plan, nil
+}
+
+// SetPlan 设置租户的套餐，已有记录时覆盖
+func (r *storageRepository) SetPlan(tenantID, plan string) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"plan", "updated_at"}),
+	}).Create(&entity.TenantPlan{TenantID: tenantID, Plan: plan}).Error
+}
+
+// recountSQL 按源数据表统计未删除页面的用量，%s 为可选的附加过滤条件
+const recountSQL = `INSERT INTO page_storages
+	(page_id, tenant_id, schema_bytes, published_bytes, snapshot_bytes, asset_bytes, journal_bytes, updated_at)
+SELECT p.page_id,
+	CASE WHEN p.org_id <> '' THEN p.org_id ELSE p.creator_id END,
+	COALESCE(octet_length(p.schema::text), 0),
+	COALESCE(octet_length(p.published_schema::text), 0),
+	COALESCE((SELECT SUM(octet_length(v.schema::text)) FROM page_versions v WHERE v.page_id = p.page_id), 0),
+	COALESCE((SELECT SUM(octet_length(b.schema::text)) FROM conflict_backups b WHERE b.page_id = p.page_id), 0),
+	COALESCE((SELECT SUM(octet_length(o.patch::text)) FROM page_ops o WHERE o.page_id = p.page_id), 0) +
+		COALESCE((SELECT SUM(octet_length(d.patches::text)) FROM page_deltas d WHERE d.page_id = p.page_id), 0),
+	NOW()
+FROM pages p WHERE p.deleted_at IS NULL %s
+ON CONFLICT (page_id) DO UPDATE SET
+	tenant_id = EXCLUDED.tenant_id,
+	schema_bytes = EXCLUDED.schema_bytes,
+	published_bytes = EXCLUDED.published_bytes,
+	snapshot_bytes = EXCLUDED.snapshot_bytes,
+	asset_bytes = EXCLUDED.asset_bytes,
+	journal_bytes = EXCLUDED.journal_bytes,
+	updated_at = EXCLUDED.updated_at`
+
+// Recount 按源数据表重新统计页面用量，用于上线前已有页面的回填和校正
+func (r *storageRepository) Recount(missingOnly bool) (int64, error) {
+	filter := ""
+	if missingOnly {
+		filter = "AND NOT EXISTS (SELECT 1 FROM page_storages s WHERE s.page_id = p.page_id)"
+	}
+	result := r.db.Exec(fmt.Sprintf(recountSQL, filter))
+	return result.RowsAffected, result.Error
+}
+
+// --- 写入路径使用的用量维护函数，均在调用方的事务中执行 ---
+
+// createStorage 为新页面创建用量记录，Schema 的用量从刚写入的页面读取
+func createStorage(tx *gorm.DB, page *entity.Page) error {
+	if err := tx.Create(&entity.PageStorage{PageID: page.PageID, TenantID: entity.TenantOf(page)}).Error; err != nil {
+		return err
+	}
+	return refreshSchemaStorage(tx, page.PageID)
+}
+
+// refreshSchemaStorage 按页面当前的草稿和发布副本重新计算 Schema 用量
+func refreshSchemaStorage(tx *gorm.DB, pageID string) error {
+	return tx.Exec(`UPDATE page_storages s SET
+		schema_bytes = COALESCE(octet_length(p.schema::text), 0),
+		published_bytes = COALESCE(octet_length(p.published_schema::text), 0),
+		updated_at = NOW()
+	FROM pages p WHERE p.page_id = s.page_id AND s.page_id = ?`, pageID).Error
+}
+
+// addStorageRows 把刚写入的行（按主键）计入所属页面的 column 用量，sizeColumn 为行中的 JSON 列
+func addStorageRows(tx *gorm.DB, table, sizeColumn, column string, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Exec(fmt.Sprintf(`UPDATE page_storages s SET %[3]s = s.%[3]s + x.n, updated_at = NOW()
+	FROM (SELECT page_id, SUM(octet_length(%[2]s::text)) AS n FROM %[1]s WHERE id IN ? GROUP BY page_id) x
+	WHERE s.page_id = x.page_id`, table, sizeColumn, column), ids).Error
+}
+
+// deleteCounted 删除 table 中满足 where 的行，并在同一语句中从所属页面的 column 用量扣减，返回删除条数
+func deleteCounted(tx *gorm.DB, table, sizeColumn, column, where string, args ...interface{}) (int64, error) {
+	var deleted int64
+	err := tx.Raw(fmt.Sprintf(`WITH d AS (DELETE FROM %[1]s WHERE %[4]s RETURNING page_id, octet_length(%[2]s::text) AS n),
+	x AS (SELECT page_id, SUM(n) AS n FROM d GROUP BY page_id),
+	u AS (UPDATE page_storages s SET %[3]s = GREATEST(s.%[3]s - x.n, 0), updated_at = NOW() FROM x WHERE s.page_id = x.page_id)
+	SELECT COUNT(*) FROM d`, table, sizeColumn, column, where), args...).Scan(&deleted).Error
+	return deleted, err
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// usageRepository GORM 实现 UsageRepository 接口
+type usageRepository struct {
+	db *gorm.DB
+}
+
+// NewUsageRepository 创建 UsageRepository 实例
+func NewUsageRepository(db *gorm.DB) domainRepo.UsageRepository {
+	return &usageRepository{db: db}
+}
+
+// consumeSQL 插入计数为 1 的记录，已存在时只在未达上限时累加；达到上限时 WHERE 不成立，不返回行
+const consumeSQL = `INSERT INTO usage_counters (tenant_id, kind, period, count, updated_at)
+VALUES (?, ?, ?, 1, NOW())
+ON CONFLICT (tenant_id, kind, period) DO UPDATE SET count = usage_counters.count + 1, updated_at = NOW()
+WHERE ? <= 0 OR usage_counters.count < ?
+RETURNING count`
+
+// Consume 在上限内累加计数
+func (r *usageRepository) Consume(tenantID, kind string, period time.Time, limit int64) (int64, bool, error) {
+	day := period.Format(time.DateOnly)
+	var counts []int64
+	if err := r.db.Raw(consumeSQL, tenantID, kind, day, limit, limit).Scan(&counts).Error; err != nil {
+		return 0, false, err
+	}
+	if len(counts) > 0 {
+		return counts[0], true, nil
+	}
+
+	var counter entity.UsageCounter
+	err := r.db.Where("tenant_id = ? AND kind = ? AND period = ?", tenantID, kind, day).First(&counter).Error
+	return counter.Count, false, err
+}
+
+// ListUsage 返回租户在周期内的计数
+func (r *usageRepository) ListUsage(tenantID string, period time.Time) ([]*entity.UsageCounter, error) {
+	var counters []*entity.UsageCounter
+	err := r.db.Where("tenant_id = ? AND period = ?", tenantID, period.Format(time.DateOnly)).
+		Order("kind").
+		Find(&counters).Error
+	return counters, err
+}
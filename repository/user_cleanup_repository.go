@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainRepo "lowercode-go-server/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// userCleanupRepository GORM 实现 UserCleanupRepository 接口
+type userCleanupRepository struct {
+	db *gorm.DB
+}
+
+// NewUserCleanupRepository 创建 UserCleanupRepository 实例
+func NewUserCleanupRepository(db *gorm.DB) domainRepo.UserCleanupRepository {
+	return &userCleanupRepository{db: db}
+}
+
+// OwnedPages 返回用户创建的全部页面（含草稿分支）
+func (r *userCleanupRepository) OwnedPages(userID string) ([]*entity.Page, error) {
+	var pages []*entity.Page
+	err := r.db.Select("page_id", "creator_id", "org_id", "branch_of").
+		Where("creator_id = ?", userID).
+		Order("id ASC").
+		Find(&pages).Error
+	return pages, err
+}
+
+// Successor 返回页面的接手人
+func (r *userCleanupRepository) Successor(page *entity.Page, userID string) (string, error) {
+	var candidates []string
+	if page.OrgID != "" {
+		err := r.db.Model(&entity.OrgMember{}).
+			Joins("JOIN users ON users.id = org_members.user_id").
+			Where("org_members.org_id = ? AND org_members.role = ? AND org_members.user_id <> ?", page.OrgID, entity.OrgRoleAdmin, userID).
+			Order("org_members.id ASC").
+			Limit(1).
+			Pluck("org_members.user_id", &candidates).Error
+		if err != nil || len(candidates) > 0 {
+			return firstOrEmpty(candidates), err
+		}
+	}
+
+	err := r.db.Model(&entity.PageCollaborator{}).
+		Joins("JOIN users ON users.id = page_collaborators.user_id").
+		Where("page_collaborators.page_id = ? AND page_collaborators.role = ? AND page_collaborators.user_id <> ?", page.PageID, entity.RoleEditor, userID).
+		Order("page_collaborators.id ASC").
+		Limit(1).
+		Pluck("page_collaborators.user_id", &candidates).Error
+	return firstOrEmpty(candidates), err
+}
+
+// DeleteUser 在一个事务中执行删除计划
+func (r *userCleanupRepository) DeleteUser(plan *domainRepo.UserDeletionPlan) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, t := range plan.Transfers {
+			if err := tx.Model(&entity.Page{}).
+				Where("page_id = ? AND creator_id = ?", t.PageID, plan.UserID).
+				Update("creator_id", t.NewOwnerID).Error; err != nil {
+				return err
+			}
+			// 接手人成为创建者，不再需要协作者记录
+			if err := tx.Where("page_id = ? AND user_id = ?", t.PageID, t.NewOwnerID).Delete(&entity.PageCollaborator{}).Error; err != nil {
+				return err
+			}
+		}
+		for _, pageID := range plan.DeletedPages {
+			if err := r.deletePage(tx, pageID, plan.UserID); err != nil {
+				return err
+			}
+		}
+
+		// 页面的 creator_id 外键级联删除，不能留下计划之外的页面（包括已软删除但仍关联该用户的页面）
+		var remaining int64
+		if err := tx.Unscoped().Model(&entity.Page{}).Where("creator_id = ?", plan.UserID).Count(&remaining).Error; err != nil {
+			return err
+		}
+		if remaining > 0 {
+			return fmt.Errorf("user %s still owns %d pages not covered by the deletion plan", plan.UserID, remaining)
+		}
+
+		if err := tx.Where("user_id = ?", plan.UserID).Delete(&entity.PageCollaborator{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", plan.UserID).Delete(&entity.OrgMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", plan.UserID).Delete(&entity.APIKey{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", plan.UserID).Delete(&entity.PageActivity{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", plan.UserID).Delete(&entity.User{}).Error
+	})
+}
+
+// deletePage 删除计划中的页面：用户在其他页面上的草稿分支直接删除；
+// 没有接手人的页面连同其草稿分支软删除，数据全部保留，并解除与该用户的 creator_id 关联，
+// 避免删除用户记录时被外键级联删除。恢复时清除 deleted_at 并指定新的创建者
+func (r *userCleanupRepository) deletePage(tx *gorm.DB, pageID, userID string) error {
+	var page entity.Page
+	if err := tx.Select("page_id", "branch_of").Where("page_id = ?", pageID).Take(&page).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if page.BranchOf != "" {
+		return deletePage(tx, pageID)
+	}
+
+	if err := tx.Model(&entity.Page{}).
+		Where("page_id = ? OR branch_of = ?", pageID, pageID).
+		Update("deleted_at", time.Now()).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Model(&entity.Page{}).
+		Where("(page_id = ? OR branch_of = ?) AND creator_id = ?", pageID, pageID, userID).
+		Update("creator_id", nil).Error
+}
+
+// firstOrEmpty 返回第一个元素，切片为空时返回空字符串
+func firstOrEmpty(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
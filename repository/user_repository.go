@@ -29,6 +29,11 @@ func (r *userRepository) Upsert(user *entity.User) error {
 	}).Create(user).Error
 }
 
+// UpdateCursorColor 更新协作光标颜色，不修改 updated_at（该字段记录 Clerk 资料同步时间）
+func (r *userRepository) UpdateCursorColor(userID, color string) error {
+	return r.db.Model(&entity.User{}).Where("id = ?", userID).UpdateColumn("cursor_color", color).Error
+}
+
 // GetByID 根据 Clerk user_id 查询用户
 func (r *userRepository) GetByID(userID string) (*entity.User, error) {
 	var user entity.User
@@ -38,3 +43,13 @@ func (r *userRepository) GetByID(userID string) (*entity.User, error) {
 	}
 	return &user, err
 }
+
+// GetByEmail 根据邮箱查询用户，不区分大小写
+func (r *userRepository) GetByEmail(email string) (*entity.User, error) {
+	var user entity.User
+	err := r.db.Where("LOWER(email) = LOWER(?)", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &user, err
+}
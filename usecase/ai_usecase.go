@@ -0,0 +1,931 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/llm"
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/secretprop"
+	"lowercode-go-server/internal/ws"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+const (
+	// MaxAIPromptLength 提示词最大字符数
+	MaxAIPromptLength = 2000
+	// MaxAIContextBytes 随提示词发送给模型的页面 Schema 上限，超出时拒绝，避免超出模型上下文
+	MaxAIContextBytes = 256 << 10
+	// maxAIProblems 错误详情中最多列出的结构问题数
+	maxAIProblems = 5
+	// AIProposalTTL 修改建议的保留时间，过期后需重新生成
+	AIProposalTTL = 15 * time.Minute
+	// maxAIProposals 内存中保留的修改建议上限，超出时淘汰最早过期的建议
+	maxAIProposals = 1000
+
+	// DefaultAIUsageDays / MaxAIUsageDays 查询 AI 用量的默认天数与上限
+	DefaultAIUsageDays = 30
+	MaxAIUsageDays     = 90
+)
+
+// AI 修改建议中组件的变化类型
+const (
+	AIChangeAdded    = "added"
+	AIChangeRemoved  = "removed"
+	AIChangeModified = "modified"
+)
+
+// AIProposal 待用户审阅的 AI 修改建议。只保存在内存中，接受后才提交到页面，
+// 放弃或过期的建议不会产生版本和操作日志
+type AIProposal struct {
+	ID          string          `json:"id"`
+	PageID      string          `json:"pageId"`
+	Prompt      string          `json:"prompt"`
+	BaseVersion int64           `json:"baseVersion"` // 生成建议时的页面版本，接受时按该版本做版本校验
+	Patches     json.RawMessage `json:"patches"`     // 接受后将提交的 JSON Patch
+	Changes     []AIChange      `json:"changes"`     // 按组件汇总的修改，供用户审阅
+	CreatedBy   string          `json:"createdBy"`
+	ExpiresAt   time.Time       `json:"expiresAt"`
+}
+
+// AIChange 修改建议对单个组件的变化
+type AIChange struct {
+	Action      string   `json:"action"` // added / removed / modified
+	ComponentID int64    `json:"componentId"`
+	Name        string   `json:"name"`
+	Fields      []string `json:"fields,omitempty"` // modified 时变化的字段，如 props.text、children
+	Summary     string   `json:"summary"`          // 可直接展示的说明，如"新增组件 Button（#3）"
+}
+
+// aiSystemPrompt 约定模型只输出修改 components 的 JSON Patch
+const aiSystemPrompt = `你是低代码编辑器的页面生成助手。用户会给出当前页面的 Schema 和修改需求，你只输出一个 RFC 6902 JSON Patch 数组，不要输出任何解释。
+Schema 格式：{"rootId": 1, "components": {"<id>": {"id": <id>, "name": "Button", "desc": "按钮", "parentId": <父组件 id>, "children": [<子组件 id>], "props": {}, "styles": {}}}}
+规则：
+1. 只能修改 /components 下的内容，不要修改 rootId 和其他顶层字段
+2. 新组件的 id 使用 13 位毫秒时间戳形式的整数，components 的 key 为 id 的字符串形式
+3. 新增组件时同时把 id 追加到父组件的 children 中（如 {"op": "add", "path": "/components/1/children/-", "value": <id>}），并设置 parentId
+4. 删除组件时同时从父组件的 children 中移除，并删除它的全部子组件
+5. props 和 styles 中不要出现 "$secret" 字段`
+
+// AIUseCase 基于大语言模型的页面生成：把提示词和当前 Schema 发给模型，
+// 校验返回的组件 Patch 后以 ai-generate 标签提交到协同房间，与其他编辑一样广播和记录操作日志
+type AIUseCase struct {
+	pages    PageAccess
+	hub      *ws.Hub
+	provider llm.Provider // 平台默认的模型服务，为 nil 时只有配置了自带模型服务的租户可以使用 AI 生成
+
+	// 租户自带的模型服务与用量计量，见 EnableTenantProviders；tenants 为 nil 时所有页面使用平台默认服务且不计量
+	pageRepo    repository.PageRepository
+	tenants     repository.AIRepository
+	box         *secretprop.Box
+	policy      AIProviderPolicy
+	meter       UsageMeter // 可选，为 nil 时不限制平台默认服务的调用次数
+	newProvider func(cfg llm.Config) (llm.Provider, error)
+
+	mu        sync.Mutex
+	proposals map[string]*AIProposal // 待审阅的修改建议，按建议 ID 索引
+}
+
+// NewAIUseCase 创建 AIUseCase 实例
+func NewAIUseCase(pages PageAccess, hub *ws.Hub, provider llm.Provider) *AIUseCase {
+	return &AIUseCase{
+		pages:     pages,
+		hub:       hub,
+		provider:  provider,
+		proposals: make(map[string]*AIProposal),
+		newProvider: func(cfg llm.Config) (llm.Provider, error) {
+			return llm.New(cfg)
+		},
+	}
+}
+
+// AIProviderPolicy 租户自带模型服务的限制
+type AIProviderPolicy struct {
+	Limits        llm.Limits // 请求和响应的大小上限，平台默认服务使用同样的上限
+	PlatformModel string     // 平台默认服务的模型名，用于用量记录
+
+	// AllowPrivateEndpoints 允许租户把服务地址设为本机或内网地址（如自建的 Ollama），
+	// 只应在租户可信的私有化部署中开启，否则租户可以借 AI 生成让服务端访问内部服务
+	AllowPrivateEndpoints bool
+}
+
+// EnableTenantProviders 按页面所属租户（见 entity.TenantOf）选择模型服务：租户配置了自带的模型服务时调用租户的服务，
+// 否则使用平台默认服务。每次调用的请求数、Token 数和字节数按天累加到租户的用量记录。
+// 租户的 API Key 使用 box 加密保存，box 为 nil 时不能保存配置
+func (uc *AIUseCase) EnableTenantProviders(pageRepo repository.PageRepository, tenants repository.AIRepository, box *secretprop.Box, policy AIProviderPolicy) {
+	uc.pageRepo = pageRepo
+	uc.tenants = tenants
+	uc.box = box
+	uc.policy = policy
+}
+
+// EnableUsageLimits 调用平台默认模型服务前按页面所属租户的套餐计量，超出本月额度时拒绝；
+// 租户自带模型服务的调用费用由租户承担，不计入额度。需同时启用 EnableTenantProviders 才能确定租户
+func (uc *AIUseCase) EnableUsageLimits(meter UsageMeter) {
+	uc.meter = meter
+}
+
+// Generate 按提示词生成页面修改，作为一个版本提交到协同房间并广播，与实时编辑冲突时在最新状态上重试。
+// 模型的输出必须是只修改 /components 的 JSON Patch，且应用后不能引入新的组件树结构问题（见 PageSchema.Problems），
+// 否则返回 ErrAIInvalidPatch。dryRun 为 true 时只校验并返回将要应用的 Patch，不提交。
+// 所有者和编辑者可以使用，协作时段外只有所有者可以使用
+func (uc *AIUseCase) Generate(ctx context.Context, pageID, userID, prompt string, dryRun bool) (*ws.PatchResult, error) {
+	return uc.generate(ctx, pageID, userID, prompt, dryRun, nil)
+}
+
+// generate 见 Generate，onChunk 非 nil 时流式调用模型服务
+func (uc *AIUseCase) generate(ctx context.Context, pageID, userID, prompt string, dryRun bool, onChunk func(string)) (*ws.PatchResult, error) {
+	prompt, err := uc.checkPrompt(prompt)
+	if err != nil {
+		return nil, err
+	}
+	room, err := uc.openRoom(pageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	// 无人在线时房间只为本次修改而创建，完成后交给 Hub 刷盘销毁
+	defer uc.hub.ReleaseIfIdle(room)
+
+	snapshot, version := room.GetSnapshot()
+	patch, patchBytes, err := uc.complete(ctx, pageID, snapshot, prompt, onChunk)
+	if err != nil {
+		return nil, err
+	}
+
+	submit := func(author ws.UserInfo, patch []byte, version int64) (*ws.PatchResult, error) {
+		return room.SubmitLabeledEdit(author, ws.LabelAIGenerate, patch, version)
+	}
+	if dryRun {
+		submit = room.ValidateEdit
+	}
+
+	author := ws.UserInfo{UserID: userID, UserName: userID}
+	for attempt := 0; ; attempt++ {
+		if _, err := checkAIPatch(snapshot, patch); err != nil {
+			return nil, err
+		}
+
+		result, err := submit(author, patchBytes, version)
+		var conflict *ws.VersionConflictError
+		var patchErr *ws.PatchError
+		switch {
+		case err == nil:
+			return result, nil
+		case errors.As(err, &conflict):
+			if attempt >= bulkOpsRetries {
+				return nil, domainErrors.ErrOptimisticLock
+			}
+		case errors.As(err, &patchErr):
+			return nil, fmt.Errorf("%w: %s", domainErrors.ErrAIInvalidPatch, patchErr.Reason)
+		case errors.Is(err, ws.ErrRoomClosed):
+			return nil, domainErrors.ErrRoomClosing
+		default:
+			return nil, err
+		}
+		// 生成期间页面有其他编辑，在最新状态上重新校验
+		snapshot, version = room.GetSnapshot()
+	}
+}
+
+// Propose 按提示词生成页面修改建议但不提交，返回 Patch 和按组件汇总的修改供用户审阅。
+// 建议在内存中保留 AIProposalTTL，由生成者通过 AcceptProposal 接受或 DiscardProposal 放弃；
+// 校验规则与 Generate 相同
+func (uc *AIUseCase) Propose(ctx context.Context, pageID, userID, prompt string) (*AIProposal, error) {
+	return uc.propose(ctx, pageID, userID, prompt, nil)
+}
+
+// propose 见 Propose，onChunk 非 nil 时流式调用模型服务
+func (uc *AIUseCase) propose(ctx context.Context, pageID, userID, prompt string, onChunk func(string)) (*AIProposal, error) {
+	prompt, err := uc.checkPrompt(prompt)
+	if err != nil {
+		return nil, err
+	}
+	room, err := uc.openRoom(pageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer uc.hub.ReleaseIfIdle(room)
+
+	snapshot, version := room.GetSnapshot()
+	patch, patchBytes, err := uc.complete(ctx, pageID, snapshot, prompt, onChunk)
+	if err != nil {
+		return nil, err
+	}
+	modified, err := checkAIPatch(snapshot, patch)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := describeAIChanges(snapshot, modified)
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	proposal := &AIProposal{
+		ID:          hex.EncodeToString(id),
+		PageID:      pageID,
+		Prompt:      prompt,
+		BaseVersion: version,
+		Patches:     patchBytes,
+		Changes:     changes,
+		CreatedBy:   userID,
+		ExpiresAt:   time.Now().Add(AIProposalTTL),
+	}
+	uc.saveProposal(proposal)
+	return proposal, nil
+}
+
+// AcceptProposal 接受修改建议，以 ai-generate 标签按建议的基准版本提交到协同房间并广播。
+// 与实时编辑一样经过版本校验：生成建议后页面被修改过时由页面的冲突策略处理，
+// 无法应用时返回 ErrAIProposalStale，不会在最新状态上自动重试。
+// 建议只能由生成者接受一次，提交前重新校验编辑权限
+func (uc *AIUseCase) AcceptProposal(pageID, proposalID, userID string) (*ws.PatchResult, error) {
+	proposal, err := uc.takeProposal(pageID, proposalID, userID)
+	if err != nil {
+		return nil, err
+	}
+	room, err := uc.openRoom(pageID, userID)
+	if err != nil {
+		if !errors.Is(err, domainErrors.ErrUnauthorized) && !errors.Is(err, domainErrors.ErrPageNotFound) {
+			uc.saveProposal(proposal) // 暂时无法提交（如协作时段外、服务繁忙），保留建议供稍后重试
+		}
+		return nil, err
+	}
+	defer uc.hub.ReleaseIfIdle(room)
+
+	author := ws.UserInfo{UserID: userID, UserName: userID}
+	result, err := room.SubmitLabeledEdit(author, ws.LabelAIGenerate, proposal.Patches, proposal.BaseVersion)
+	var conflict *ws.VersionConflictError
+	var patchErr *ws.PatchError
+	switch {
+	case err == nil:
+		return result, nil
+	case errors.As(err, &conflict), errors.As(err, &patchErr):
+		return nil, domainErrors.ErrAIProposalStale
+	case errors.Is(err, ws.ErrRoomClosed):
+		uc.saveProposal(proposal)
+		return nil, domainErrors.ErrRoomClosing
+	default:
+		return nil, err
+	}
+}
+
+// AIStreamResult 流式生成以 apply 模式提交后的结果，作为 ai-done 的 result 下发
+type AIStreamResult struct {
+	Version int64           `json:"version"`
+	Patches json.RawMessage `json:"patches"`
+	Rebased bool            `json:"rebased,omitempty"`
+}
+
+// StreamAI 实现 ws.AIStreamer：在协同连接内按 mode 执行 Generate（apply）或 Propose（propose），
+// 模型输出的增量文本交给 onChunk。权限、校验、用量计量与 HTTP 接口相同；
+// ctx 被取消（用户取消或连接断开）时中止模型调用，返回 ctx.Err()，不提交也不保存建议
+func (uc *AIUseCase) StreamAI(ctx context.Context, pageID, userID, prompt, mode string, onChunk func(delta string)) (json.RawMessage, error) {
+	var result any
+	switch mode {
+	case ws.AIModePropose:
+		proposal, err := uc.propose(ctx, pageID, userID, prompt, onChunk)
+		if err != nil {
+			return nil, streamError(ctx, err)
+		}
+		result = proposal
+	default:
+		patched, err := uc.generate(ctx, pageID, userID, prompt, false, onChunk)
+		if err != nil {
+			return nil, streamError(ctx, err)
+		}
+		result = AIStreamResult{Version: patched.Version, Patches: patched.Patches, Rebased: patched.Rebased}
+	}
+	return json.Marshal(result)
+}
+
+// streamError 取消导致的模型调用失败统一返回 ctx.Err()
+func streamError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// DiscardProposal 放弃修改建议，只有生成者可以操作
+func (uc *AIUseCase) DiscardProposal(pageID, proposalID, userID string) error {
+	_, err := uc.takeProposal(pageID, proposalID, userID)
+	return err
+}
+
+// saveProposal 保存修改建议，顺带清理过期的建议；达到上限时淘汰最早过期的一个
+func (uc *AIUseCase) saveProposal(proposal *AIProposal) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	now := time.Now()
+	var oldest *AIProposal
+	for id, p := range uc.proposals {
+		if now.After(p.ExpiresAt) {
+			delete(uc.proposals, id)
+		} else if oldest == nil || p.ExpiresAt.Before(oldest.ExpiresAt) {
+			oldest = p
+		}
+	}
+	if len(uc.proposals) >= maxAIProposals && oldest != nil {
+		delete(uc.proposals, oldest.ID)
+	}
+	uc.proposals[proposal.ID] = proposal
+}
+
+// takeProposal 取出并移除生成者在页面上未过期的修改建议，保证同一建议只被处理一次。
+// 建议不存在或属于其他用户时都返回 ErrAIProposalNotFound，不泄露建议是否存在
+func (uc *AIUseCase) takeProposal(pageID, proposalID, userID string) (*AIProposal, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	proposal, ok := uc.proposals[proposalID]
+	if !ok || proposal.PageID != pageID || proposal.CreatedBy != userID {
+		return nil, domainErrors.ErrAIProposalNotFound
+	}
+	delete(uc.proposals, proposalID)
+	if time.Now().After(proposal.ExpiresAt) {
+		return nil, domainErrors.ErrAIProposalNotFound
+	}
+	return proposal, nil
+}
+
+// checkPrompt 检查模型服务是否可用并规范化提示词
+func (uc *AIUseCase) checkPrompt(prompt string) (string, error) {
+	if uc.provider == nil && uc.tenants == nil {
+		return "", domainErrors.ErrAIDisabled
+	}
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" || utf8.RuneCountInString(prompt) > MaxAIPromptLength {
+		return "", fmt.Errorf("%w: 提示词不能为空，最长 %d 个字符", domainErrors.ErrInvalidAIPrompt, MaxAIPromptLength)
+	}
+	return prompt, nil
+}
+
+// openRoom 校验编辑权限并获取页面的协同房间，协作时段外只有所有者可以编辑，演示模式下只有演示者可以编辑。
+// 调用方用完后需调用 hub.ReleaseIfIdle
+func (uc *AIUseCase) openRoom(pageID, userID string) (*ws.Room, error) {
+	role, err := uc.pages.PageRole(pageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == entity.RoleViewer {
+		return nil, domainErrors.ErrUnauthorized
+	}
+
+	room, err := uc.hub.GetOrCreateRoom(pageID)
+	if err != nil {
+		return nil, err
+	}
+	if role != entity.RoleOwner && !room.SessionOpen() {
+		uc.hub.ReleaseIfIdle(room)
+		return nil, domainErrors.ErrSessionClosed
+	}
+	if !room.MayEdit(userID) {
+		uc.hub.ReleaseIfIdle(room)
+		return nil, domainErrors.ErrPresentationActive
+	}
+	return room, nil
+}
+
+// complete 把页面 Schema 和提示词发给页面所属租户的模型服务，返回解析出的组件 Patch。
+// onChunk 非 nil 时流式调用，模型服务不支持流式输出时在完成后把整个回复作为一段交给 onChunk
+func (uc *AIUseCase) complete(ctx context.Context, pageID string, snapshot []byte, prompt string, onChunk func(string)) (jsonpatch.Patch, []byte, error) {
+	schemaContext, err := aiContext(snapshot)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(schemaContext) > MaxAIContextBytes {
+		return nil, nil, fmt.Errorf("%w: 页面 Schema 超出 %d KB，无法作为模型上下文", domainErrors.ErrInvalidAIPrompt, MaxAIContextBytes>>10)
+	}
+
+	provider, usage, err := uc.providerFor(pageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if usage != nil && usage.Source == entity.AIUsageSourcePlatform {
+		if err := consumeUsage(uc.meter, usage.TenantID, entity.UsageKindAI); err != nil {
+			return nil, nil, err
+		}
+	}
+	req := llm.Request{
+		System: aiSystemPrompt,
+		Prompt: "当前页面 Schema：\n" + string(schemaContext) + "\n\n修改需求：\n" + prompt,
+	}
+	var reply *llm.Response
+	if streamer, ok := provider.(llm.Streamer); ok && onChunk != nil {
+		reply, err = streamer.Stream(ctx, req, onChunk)
+	} else {
+		reply, err = provider.Complete(ctx, req)
+		if err == nil && onChunk != nil {
+			onChunk(reply.Text)
+		}
+	}
+	uc.recordUsage(usage, reply, err)
+	if errors.Is(err, llm.ErrRequestTooLarge) {
+		return nil, nil, fmt.Errorf("%w: %v", domainErrors.ErrInvalidAIPrompt, err)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", domainErrors.ErrAIProvider, err)
+	}
+	return parseAIPatch(reply.Text)
+}
+
+// providerFor 返回页面所属租户的模型服务，以及本次调用要累加的用量记录（未启用计量时为 nil）
+func (uc *AIUseCase) providerFor(pageID string) (llm.Provider, *entity.AIUsage, error) {
+	if uc.tenants == nil {
+		if uc.provider == nil {
+			return nil, nil, domainErrors.ErrAIDisabled
+		}
+		return uc.provider, nil, nil
+	}
+
+	page, err := uc.pageRepo.GetAccessInfo(pageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if page == nil {
+		return nil, nil, domainErrors.ErrPageNotFound
+	}
+	tenantID := entity.TenantOf(page)
+	config, err := uc.tenants.GetProvider(tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if config == nil {
+		if uc.provider == nil {
+			return nil, nil, domainErrors.ErrAIDisabled
+		}
+		return uc.provider, &entity.AIUsage{TenantID: tenantID, Source: entity.AIUsageSourcePlatform, Model: uc.policy.PlatformModel}, nil
+	}
+
+	// 地址在保存时已校验，这里再查一次，关闭 AllowPrivateEndpoints 后已保存的内网地址随即失效
+	if err := checkAIEndpoint(config.BaseURL, uc.policy.AllowPrivateEndpoints); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", domainErrors.ErrAIProvider, err)
+	}
+	var apiKey string
+	if config.APIKey != "" {
+		if uc.box == nil {
+			return nil, nil, domainErrors.ErrSecretsDisabled
+		}
+		if apiKey, err = uc.box.Open(aiKeyScope(tenantID), config.APIKey); err != nil {
+			return nil, nil, fmt.Errorf("%w: 已保存的 API Key 无法解密，请重新设置", domainErrors.ErrAIProvider)
+		}
+	}
+	provider, err := uc.newProvider(llm.Config{
+		Kind:       config.Kind,
+		BaseURL:    config.BaseURL,
+		APIKey:     apiKey,
+		Model:      config.Model,
+		APIVersion: config.APIVersion,
+		Limits:     uc.policy.Limits,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", domainErrors.ErrAIProvider, err)
+	}
+	return provider, &entity.AIUsage{TenantID: tenantID, Source: entity.AIUsageSourceTenant, Model: config.Model}, nil
+}
+
+// recordUsage 累加一次调用的用量，写入失败只记录日志，不影响生成结果
+func (uc *AIUseCase) recordUsage(usage *entity.AIUsage, reply *llm.Response, err error) {
+	if usage == nil {
+		return
+	}
+	usage.Day = time.Now().UTC().Truncate(24 * time.Hour)
+	usage.Requests = 1
+	if err != nil {
+		usage.Failures = 1
+	} else {
+		usage.PromptTokens = int64(reply.Usage.PromptTokens)
+		usage.CompletionTokens = int64(reply.Usage.CompletionTokens)
+		usage.RequestBytes = int64(reply.Usage.RequestBytes)
+		usage.ResponseBytes = int64(reply.Usage.ResponseBytes)
+	}
+	if err := uc.tenants.RecordUsage(usage); err != nil {
+		logging.Warnf("[AI] 记录租户 %s 的用量失败: %v", usage.TenantID, err)
+	}
+}
+
+// aiContext 生成发给模型的 Schema：去掉 editedBy，密钥属性只保留掩码
+func aiContext(snapshot []byte) ([]byte, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(snapshot, &doc); err != nil {
+		return nil, err
+	}
+	delete(doc, "editedBy")
+	trimmed, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return secretprop.Mask(trimmed)
+}
+
+// parseAIPatch 从模型回复中取出 JSON Patch 数组（容忍 Markdown 代码块和前后的说明文字），
+// 所有操作（包括 move / copy 的 from）都必须位于某个组件之下
+func parseAIPatch(reply string) (jsonpatch.Patch, []byte, error) {
+	start, end := strings.Index(reply, "["), strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil, nil, fmt.Errorf("%w: 回复中没有 JSON Patch 数组", domainErrors.ErrAIInvalidPatch)
+	}
+	patchBytes := []byte(reply[start : end+1])
+	patch, err := jsonpatch.DecodePatch(patchBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", domainErrors.ErrAIInvalidPatch, err)
+	}
+	if len(patch) == 0 {
+		return nil, nil, fmt.Errorf("%w: 模型没有给出任何修改", domainErrors.ErrAIInvalidPatch)
+	}
+
+	for _, op := range patch {
+		paths := make([]string, 0, 2)
+		path, err := op.Path()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", domainErrors.ErrAIInvalidPatch, err)
+		}
+		paths = append(paths, path)
+		if kind := op.Kind(); kind == "move" || kind == "copy" {
+			from, err := op.From()
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w: %v", domainErrors.ErrAIInvalidPatch, err)
+			}
+			paths = append(paths, from)
+		}
+		for _, p := range paths {
+			id, _, _ := strings.Cut(strings.TrimPrefix(p, "/components/"), "/")
+			if !strings.HasPrefix(p, "/components/") || id == "" {
+				return nil, nil, fmt.Errorf("%w: 只能修改组件，不能修改 %s", domainErrors.ErrAIInvalidPatch, p)
+			}
+		}
+	}
+	return patch, patchBytes, nil
+}
+
+// checkAIPatch 在 snapshot 的副本上应用 Patch 并返回应用后的 Schema，应用失败、写入了密钥属性或引入新的组件树结构问题时
+// 返回 ErrAIInvalidPatch。页面原有的结构问题不算在 Patch 头上
+func checkAIPatch(snapshot []byte, patch jsonpatch.Patch) ([]byte, error) {
+	modified, err := patch.Apply(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domainErrors.ErrAIInvalidPatch, err)
+	}
+
+	var before, after entity.PageSchema
+	if err := json.Unmarshal(snapshot, &before); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(modified, &after); err != nil {
+		return nil, fmt.Errorf("%w: %v", domainErrors.ErrAIInvalidPatch, err)
+	}
+
+	// 模型看到的密钥属性只有掩码，不能伪造密文，也不能把掩码后的空信封写回页面
+	secrets, err := secretprop.Find(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make(map[string]bool, len(secrets))
+	for _, ref := range secrets {
+		tokens[ref.Token] = true
+	}
+	written, err := secretprop.Find(modified)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domainErrors.ErrAIInvalidPatch, err)
+	}
+	for _, ref := range written {
+		if !tokens[ref.Token] {
+			return nil, fmt.Errorf("%w: 不能修改密钥属性 %s", domainErrors.ErrAIInvalidPatch, ref.Path)
+		}
+	}
+
+	existing := make(map[string]bool)
+	for _, problem := range before.Problems() {
+		existing[problem] = true
+	}
+	var introduced []string
+	for _, problem := range after.Problems() {
+		if !existing[problem] {
+			introduced = append(introduced, problem)
+		}
+	}
+	if len(introduced) == 0 {
+		return modified, nil
+	}
+	if len(introduced) > maxAIProblems {
+		introduced = append(introduced[:maxAIProblems], fmt.Sprintf("等 %d 个问题", len(introduced)))
+	}
+	return nil, fmt.Errorf("%w: %s", domainErrors.ErrAIInvalidPatch, strings.Join(introduced, "；"))
+}
+
+// describeAIChanges 对比修改前后的 Schema，按组件 ID 升序列出新增、删除和修改的组件。
+// props、styles 精确到顶层属性，如 props.text
+func describeAIChanges(before, after []byte) ([]AIChange, error) {
+	var from, to entity.PageSchema
+	if err := json.Unmarshal(before, &from); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(after, &to); err != nil {
+		return nil, err
+	}
+
+	changes := make([]AIChange, 0)
+	for key, comp := range to.Components {
+		old, existed := from.Components[key]
+		if !existed {
+			changes = append(changes, AIChange{Action: AIChangeAdded, ComponentID: comp.ID, Name: comp.Name})
+			continue
+		}
+		if fields := componentChanges(old, comp); len(fields) > 0 {
+			changes = append(changes, AIChange{Action: AIChangeModified, ComponentID: comp.ID, Name: comp.Name, Fields: fields})
+		}
+	}
+	for key, comp := range from.Components {
+		if _, kept := to.Components[key]; !kept {
+			changes = append(changes, AIChange{Action: AIChangeRemoved, ComponentID: comp.ID, Name: comp.Name})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ComponentID < changes[j].ComponentID })
+
+	for i := range changes {
+		change := &changes[i]
+		target := change.Name + "（#" + strconv.FormatInt(change.ComponentID, 10) + "）"
+		switch change.Action {
+		case AIChangeAdded:
+			change.Summary = "新增组件 " + target
+		case AIChangeRemoved:
+			change.Summary = "删除组件 " + target
+		default:
+			change.Summary = "修改组件 " + target + "：" + strings.Join(change.Fields, "、")
+		}
+	}
+	return changes, nil
+}
+
+// componentChanges 返回组件变化的字段
+func componentChanges(old, cur entity.Component) []string {
+	var fields []string
+	if old.Name != cur.Name {
+		fields = append(fields, "name")
+	}
+	if old.Desc != cur.Desc {
+		fields = append(fields, "desc")
+	}
+	if !reflect.DeepEqual(old.ParentID, cur.ParentID) {
+		fields = append(fields, "parentId")
+	}
+	if !reflect.DeepEqual(old.Children, cur.Children) {
+		fields = append(fields, "children")
+	}
+	fields = append(fields, changedKeys("props", old.Props, cur.Props)...)
+	fields = append(fields, changedKeys("styles", old.Styles, cur.Styles)...)
+	return fields
+}
+
+// changedKeys 按顶层 key 比较两个 JSON 对象，返回值变化的 key（prefix.key，升序）；
+// 任一方不是对象时整体比较，变化时返回 prefix
+func changedKeys(prefix string, old, cur json.RawMessage) []string {
+	var a, b map[string]interface{}
+	errA := json.Unmarshal(nonEmptyJSON(old), &a)
+	errB := json.Unmarshal(nonEmptyJSON(cur), &b)
+	if errA != nil || errB != nil {
+		if bytes.Equal(bytes.TrimSpace(old), bytes.TrimSpace(cur)) {
+			return nil
+		}
+		return []string{prefix}
+	}
+
+	var keys []string
+	for key, value := range b {
+		if prev, ok := a[key]; !ok || !reflect.DeepEqual(prev, value) {
+			keys = append(keys, prefix+"."+key)
+		}
+	}
+	for key := range a {
+		if _, ok := b[key]; !ok {
+			keys = append(keys, prefix+"."+key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// nonEmptyJSON 省略的 props / styles 视为空对象
+func nonEmptyJSON(raw json.RawMessage) []byte {
+	if len(bytes.TrimSpace(raw)) == 0 || string(raw) == "null" {
+		return []byte("{}")
+	}
+	return raw
+}
+
+// AIProviderInput 租户自带模型服务的配置
+type AIProviderInput struct {
+	Kind       string // openai / azure / local，为空时为 openai
+	BaseURL    string
+	Model      string // Azure 为部署名
+	APIVersion string // 仅 Azure，为空时使用 llm.DefaultAzureAPIVersion
+	APIKey     string // 为空时保留已保存的密钥；local 类型可以不设置
+}
+
+// AIProviderSettings 租户的模型服务配置，API Key 只返回掩码
+type AIProviderSettings struct {
+	TenantID          string     `json:"tenantId"`
+	Configured        bool       `json:"configured"`        // 为 false 时使用平台默认的模型服务
+	PlatformAvailable bool       `json:"platformAvailable"` // 平台是否配置了默认的模型服务
+	Kind              string     `json:"kind,omitempty"`
+	BaseURL           string     `json:"baseUrl,omitempty"`
+	Model             string     `json:"model,omitempty"`
+	APIVersion        string     `json:"apiVersion,omitempty"`
+	APIKeyMask        string     `json:"apiKeyMask,omitempty"`
+	UpdatedBy         string     `json:"updatedBy,omitempty"`
+	UpdatedAt         *time.Time `json:"updatedAt,omitempty"`
+}
+
+// ProviderSettings 返回当前租户的模型服务配置，激活组织时只有组织管理员可以查看
+func (uc *AIUseCase) ProviderSettings(userID string, org OrgClaims) (*AIProviderSettings, error) {
+	tenantID, err := uc.providerTenant(userID, org)
+	if err != nil {
+		return nil, err
+	}
+	config, err := uc.tenants.GetProvider(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.providerSettings(tenantID, config), nil
+}
+
+// SetProvider 设置当前租户自带的模型服务，之后该租户页面上的 AI 生成都调用这里的服务，用量记为 tenant 来源。
+// 激活组织时只有组织管理员可以设置；API Key 加密保存，不会再返回明文
+func (uc *AIUseCase) SetProvider(userID string, org OrgClaims, input AIProviderInput) (*AIProviderSettings, error) {
+	tenantID, err := uc.providerTenant(userID, org)
+	if err != nil {
+		return nil, err
+	}
+	if uc.box == nil {
+		return nil, domainErrors.ErrSecretsDisabled
+	}
+
+	config := &entity.AIProviderConfig{
+		TenantID:   tenantID,
+		Kind:       strings.TrimSpace(input.Kind),
+		BaseURL:    strings.TrimRight(strings.TrimSpace(input.BaseURL), "/"),
+		Model:      strings.TrimSpace(input.Model),
+		APIVersion: strings.TrimSpace(input.APIVersion),
+		UpdatedBy:  userID,
+		UpdatedAt:  time.Now(),
+	}
+	if config.Kind == "" {
+		config.Kind = llm.KindOpenAI
+	}
+	switch {
+	case !llm.ValidKind(config.Kind):
+		return nil, fmt.Errorf("%w: kind 必须为 openai、azure 或 local", domainErrors.ErrInvalidAIProvider)
+	case config.Model == "" || len(config.Model) > 128:
+		return nil, fmt.Errorf("%w: model 不能为空，最长 128 个字符", domainErrors.ErrInvalidAIProvider)
+	case len(config.BaseURL) > 512 || len(config.APIVersion) > 32:
+		return nil, fmt.Errorf("%w: baseUrl 最长 512 个字符，apiVersion 最长 32 个字符", domainErrors.ErrInvalidAIProvider)
+	case len(input.APIKey) > secretprop.MaxLength:
+		return nil, fmt.Errorf("%w: apiKey 最长 %d 字节", domainErrors.ErrInvalidAIProvider, secretprop.MaxLength)
+	}
+	if err := checkAIEndpoint(config.BaseURL, uc.policy.AllowPrivateEndpoints); err != nil {
+		return nil, fmt.Errorf("%w: %v", domainErrors.ErrInvalidAIProvider, err)
+	}
+	if config.Kind == llm.KindAzure && config.APIVersion == "" {
+		config.APIVersion = llm.DefaultAzureAPIVersion
+	} else if config.Kind != llm.KindAzure {
+		config.APIVersion = ""
+	}
+
+	if apiKey := strings.TrimSpace(input.APIKey); apiKey != "" {
+		if config.APIKey, err = uc.box.Seal(aiKeyScope(tenantID), apiKey); err != nil {
+			return nil, err
+		}
+		config.APIKeyMask = secretprop.MaskOf(apiKey)
+	} else {
+		existing, err := uc.tenants.GetProvider(tenantID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			config.APIKey, config.APIKeyMask = existing.APIKey, existing.APIKeyMask
+		}
+	}
+	if config.APIKey == "" && config.Kind != llm.KindLocal {
+		return nil, fmt.Errorf("%w: %s 类型必须设置 apiKey", domainErrors.ErrInvalidAIProvider, config.Kind)
+	}
+
+	if err := uc.tenants.SaveProvider(config); err != nil {
+		return nil, err
+	}
+	return uc.providerSettings(tenantID, config), nil
+}
+
+// DeleteProvider 删除当前租户自带的模型服务，之后改用平台默认服务；激活组织时只有组织管理员可以删除
+func (uc *AIUseCase) DeleteProvider(userID string, org OrgClaims) error {
+	tenantID, err := uc.providerTenant(userID, org)
+	if err != nil {
+		return err
+	}
+	return uc.tenants.DeleteProvider(tenantID)
+}
+
+// Usage 返回当前租户最近 days 天（含今天，按 UTC 日期）的 AI 用量，激活组织时只有组织管理员可以查看
+func (uc *AIUseCase) Usage(userID string, org OrgClaims, days int) ([]*entity.AIUsage, error) {
+	tenantID, err := uc.providerTenant(userID, org)
+	if err != nil {
+		return nil, err
+	}
+	return uc.TenantUsage(tenantID, days)
+}
+
+// TenantUsage 返回任意租户最近 days 天的 AI 用量，供运维接口使用，不做权限检查。
+// days <= 0 时取 DefaultAIUsageDays，超过 MaxAIUsageDays 时截断
+func (uc *AIUseCase) TenantUsage(tenantID string, days int) ([]*entity.AIUsage, error) {
+	if uc.tenants == nil {
+		return nil, domainErrors.ErrAIDisabled
+	}
+	if days <= 0 {
+		days = DefaultAIUsageDays
+	}
+	days = min(days, MaxAIUsageDays)
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-days)
+	return uc.tenants.ListUsage(tenantID, since)
+}
+
+// providerTenant 返回模型服务配置所属的租户：激活组织时为组织，只有组织管理员可以管理；否则为用户本人
+func (uc *AIUseCase) providerTenant(userID string, org OrgClaims) (string, error) {
+	if uc.tenants == nil {
+		return "", domainErrors.ErrAIDisabled
+	}
+	if org.OrgID == "" {
+		return userID, nil
+	}
+	if org.OrgRole != entity.OrgRoleAdmin {
+		return "", domainErrors.ErrUnauthorized
+	}
+	return org.OrgID, nil
+}
+
+// providerSettings 生成配置的展示视图，config 为 nil 时表示未配置
+func (uc *AIUseCase) providerSettings(tenantID string, config *entity.AIProviderConfig) *AIProviderSettings {
+	settings := &AIProviderSettings{TenantID: tenantID, PlatformAvailable: uc.provider != nil}
+	if config == nil {
+		return settings
+	}
+	updatedAt := config.UpdatedAt
+	settings.Configured = true
+	settings.Kind = config.Kind
+	settings.BaseURL = config.BaseURL
+	settings.Model = config.Model
+	settings.APIVersion = config.APIVersion
+	settings.APIKeyMask = config.APIKeyMask
+	settings.UpdatedBy = config.UpdatedBy
+	settings.UpdatedAt = &updatedAt
+	return settings
+}
+
+// aiKeyScope 租户 API Key 密文绑定的作用域，与页面 ID 区分，密文不能挪作密钥属性或给其他租户使用
+func aiKeyScope(tenantID string) string {
+	return "ai-provider:" + tenantID
+}
+
+// checkAIEndpoint 校验租户模型服务的地址。未开启 allowPrivate 时只接受 https，
+// 且主机不能是 localhost 或本机、内网、链路本地的 IP 地址；解析到内网的域名不在检查范围内，需由出口网络策略限制
+func checkAIEndpoint(raw string, allowPrivate bool) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return fmt.Errorf("baseUrl 必须为 http(s) 地址: %q", raw)
+	}
+	if allowPrivate {
+		return nil
+	}
+	if u.Scheme != "https" {
+		return errors.New("baseUrl 必须使用 https")
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("baseUrl 不能指向本机")
+	}
+	if ip := net.ParseIP(host); ip != nil &&
+		(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified()) {
+		return errors.New("baseUrl 不能指向本机或内网地址")
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/logging"
+)
+
+// APIKeyUseCase 服务端集成 API Key 业务逻辑
+type APIKeyUseCase struct {
+	keys       repository.APIKeyRepository
+	orgMembers repository.OrgMemberRepository
+}
+
+// NewAPIKeyUseCase 创建 APIKeyUseCase 实例，orgMembers 为 nil 时属于组织的密钥一律不可用
+func NewAPIKeyUseCase(keys repository.APIKeyRepository, orgMembers repository.OrgMemberRepository) *APIKeyUseCase {
+	return &APIKeyUseCase{keys: keys, orgMembers: orgMembers}
+}
+
+// Create 为用户创建密钥，返回密钥记录和明文（只在此时返回一次）。
+// org 为创建时激活的组织，密钥随之限定在该组织内；scopes 为空时默认只读；
+// ttl 为 0 表示永不过期，超出 MaxAPIKeyTTL 或为负数时返回 ErrInvalidAPIKey
+func (uc *APIKeyUseCase) Create(userID string, org OrgClaims, name string, scopes []string, ttl time.Duration) (*entity.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return nil, "", fmt.Errorf("%w: 名称不能为空且不超过 100 个字符", domainErrors.ErrInvalidAPIKey)
+	}
+	if len(scopes) == 0 {
+		scopes = []string{entity.APIKeyScopeRead}
+	}
+	for _, scope := range scopes {
+		if !entity.ValidAPIKeyScope(scope) {
+			return nil, "", fmt.Errorf("%w: 不支持的权限 %q", domainErrors.ErrInvalidAPIKey, scope)
+		}
+	}
+	if ttl < 0 || ttl > entity.MaxAPIKeyTTL {
+		return nil, "", fmt.Errorf("%w: 有效期超出范围", domainErrors.ErrInvalidAPIKey)
+	}
+
+	now := time.Now()
+	active, err := uc.keys.CountActive(userID, now)
+	if err != nil {
+		return nil, "", err
+	}
+	if active >= entity.MaxAPIKeysPerUser {
+		return nil, "", domainErrors.ErrAPIKeyLimit
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, "", err
+	}
+	secretPart, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	secret := entity.APIKeyPrefix + secretPart
+
+	key := &entity.APIKey{
+		ID:      id,
+		UserID:  userID,
+		OrgID:   org.OrgID,
+		Name:    name,
+		KeyHash: hashAPIKey(secret),
+		Prefix:  secret[:len(entity.APIKeyPrefix)+6],
+		Scopes:  strings.Join(scopes, ","),
+	}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := uc.keys.Create(key); err != nil {
+		return nil, "", err
+	}
+	return key, secret, nil
+}
+
+// List 返回用户的全部密钥（包括已过期和已撤销的），不含明文
+func (uc *APIKeyUseCase) List(userID string) ([]*entity.APIKey, error) {
+	keys, err := uc.keys.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		keys = []*entity.APIKey{}
+	}
+	return keys, nil
+}
+
+// Revoke 撤销用户自己的密钥，立即生效
+func (uc *APIKeyUseCase) Revoke(userID, id string) error {
+	revoked, err := uc.keys.Revoke(userID, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return domainErrors.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate 校验请求携带的密钥明文：存在、未过期、未撤销；
+// 属于组织的密钥还要求创建者仍是该组织成员，并填充其当前组织角色。
+// 校验失败统一返回 ErrAPIKeyInvalid，不区分原因
+func (uc *APIKeyUseCase) Authenticate(secret string) (*entity.APIKey, error) {
+	if !strings.HasPrefix(secret, entity.APIKeyPrefix) {
+		return nil, domainErrors.ErrAPIKeyInvalid
+	}
+
+	key, err := uc.keys.GetByHash(hashAPIKey(secret))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if key == nil || !key.Active(now) {
+		return nil, domainErrors.ErrAPIKeyInvalid
+	}
+
+	if key.OrgID != "" {
+		if uc.orgMembers == nil {
+			return nil, domainErrors.ErrAPIKeyInvalid
+		}
+		role, err := uc.orgMembers.GetRole(key.OrgID, key.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if role == "" {
+			return nil, domainErrors.ErrAPIKeyInvalid
+		}
+		key.OrgRole = role
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= entity.APIKeyTouchInterval {
+		if err := uc.keys.Touch(key.ID, now); err != nil {
+			logging.Warnf("[APIKey] 记录密钥 %s 使用时间失败: %v", key.ID, err)
+		}
+	}
+	return key, nil
+}
+
+// hashAPIKey 计算密钥明文的 SHA-256，数据库只保存哈希
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex 生成 n 字节随机数的十六进制表示
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ========== APIKeyUseCase 单元测试 ==========
+
+func TestAPIKeyUseCase_Create(t *testing.T) {
+	// 测试场景：名称、权限和有效期无效时拒绝；省略权限时默认只读；
+	// 返回的明文以 lck_ 开头，数据库只保存其哈希；有效密钥达到上限时拒绝
+
+	keys := new(MockAPIKeyRepository)
+	keys.On("CountActive", "alice", mock.Anything).Return(int64(0), nil)
+	keys.On("CountActive", "busy", mock.Anything).Return(int64(entity.MaxAPIKeysPerUser), nil)
+	keys.On("Create", mock.Anything).Return(nil)
+	uc := NewAPIKeyUseCase(keys, nil)
+
+	_, _, err := uc.Create("alice", OrgClaims{}, " ", nil, 0)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidAPIKey)
+	_, _, err = uc.Create("alice", OrgClaims{}, "CI", []string{"pages:delete"}, 0)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidAPIKey)
+	_, _, err = uc.Create("alice", OrgClaims{}, "CI", nil, entity.MaxAPIKeyTTL+time.Hour)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidAPIKey)
+
+	key, secret, err := uc.Create("alice", OrgClaims{OrgID: "org-1", OrgRole: entity.OrgRoleMember}, "CI", nil, 0)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, entity.APIKeyPrefix))
+	assert.True(t, strings.HasPrefix(secret, key.Prefix))
+	assert.Equal(t, hashAPIKey(secret), key.KeyHash)
+	assert.Equal(t, []string{entity.APIKeyScopeRead}, key.ScopeList())
+	assert.Equal(t, "org-1", key.OrgID)
+	assert.Nil(t, key.ExpiresAt)
+
+	key, _, err = uc.Create("alice", OrgClaims{}, "deploy", []string{entity.APIKeyScopeWrite}, 24*time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, key.Allows(entity.APIKeyScopeRead))
+	assert.True(t, key.Allows(entity.APIKeyScopeWrite))
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), *key.ExpiresAt, time.Minute)
+
+	_, _, err = uc.Create("busy", OrgClaims{}, "CI", nil, 0)
+	assert.ErrorIs(t, err, domainErrors.ErrAPIKeyLimit)
+}
+
+func TestAPIKeyUseCase_Authenticate(t *testing.T) {
+	// 测试场景：有效密钥通过并记录使用时间，刚使用过的不重复写库；
+	// 格式错误、不存在、已撤销、已过期的密钥统一返回 ErrAPIKeyInvalid；
+	// 组织密钥填充创建者当前的组织角色，创建者离开组织后密钥失效
+
+	keys := new(MockAPIKeyRepository)
+	orgMembers := new(MockOrgMemberRepository)
+	uc := NewAPIKeyUseCase(keys, orgMembers)
+
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	recent := now.Add(-time.Second)
+	stored := map[string]*entity.APIKey{
+		"lck_valid":   {ID: "valid", UserID: "alice", Scopes: entity.APIKeyScopeRead},
+		"lck_recent":  {ID: "recent", UserID: "alice", LastUsedAt: &recent},
+		"lck_revoked": {ID: "revoked", UserID: "alice", RevokedAt: &past},
+		"lck_expired": {ID: "expired", UserID: "alice", ExpiresAt: &past},
+		"lck_org":     {ID: "org", UserID: "alice", OrgID: "org-1"},
+		"lck_left":    {ID: "left", UserID: "bob", OrgID: "org-1"},
+	}
+	for secret, key := range stored {
+		keys.On("GetByHash", hashAPIKey(secret)).Return(key, nil)
+	}
+	keys.On("GetByHash", mock.Anything).Return(nil, nil)
+	keys.On("Touch", mock.Anything, mock.Anything).Return(nil)
+	orgMembers.On("GetRole", "org-1", "alice").Return(entity.OrgRoleAdmin, nil)
+	orgMembers.On("GetRole", "org-1", "bob").Return("", nil)
+
+	key, err := uc.Authenticate("lck_valid")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", key.UserID)
+	keys.AssertCalled(t, "Touch", "valid", mock.Anything)
+
+	_, err = uc.Authenticate("lck_recent")
+	assert.NoError(t, err)
+	keys.AssertNotCalled(t, "Touch", "recent", mock.Anything)
+
+	for _, secret := range []string{"valid", "lck_unknown", "lck_revoked", "lck_expired", "lck_left"} {
+		_, err := uc.Authenticate(secret)
+		assert.ErrorIs(t, err, domainErrors.ErrAPIKeyInvalid, secret)
+	}
+
+	key, err = uc.Authenticate("lck_org")
+	assert.NoError(t, err)
+	assert.Equal(t, entity.OrgRoleAdmin, key.OrgRole)
+}
+
+func TestAPIKeyUseCase_Revoke(t *testing.T) {
+	// 测试场景：只能撤销自己未撤销的密钥，否则返回 ErrAPIKeyNotFound
+
+	keys := new(MockAPIKeyRepository)
+	keys.On("Revoke", "alice", "key-1", mock.Anything).Return(true, nil).Once()
+	keys.On("Revoke", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+	uc := NewAPIKeyUseCase(keys, nil)
+
+	assert.NoError(t, uc.Revoke("alice", "key-1"))
+	assert.ErrorIs(t, uc.Revoke("alice", "key-1"), domainErrors.ErrAPIKeyNotFound)
+	assert.ErrorIs(t, uc.Revoke("bob", "key-2"), domainErrors.ErrAPIKeyNotFound)
+}
@@ -0,0 +1,338 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/jsondiff"
+	"lowercode-go-server/internal/merge"
+	"lowercode-go-server/internal/ws"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// BranchPages 草稿分支依赖的页面能力，由 PageUseCase 实现
+type BranchPages interface {
+	PageAccess
+	ReadPage(pageID string) (*entity.Page, error)
+	EnsureUser(userID string) error
+}
+
+// BranchMerge 分支合并预览或合并结果
+type BranchMerge struct {
+	PageVersion   int64    // 页面当前版本，合并成功后为合并产生的版本
+	BranchVersion int64    // 分支当前版本
+	Changes       []string // 分支相对分叉点修改的位置（JSON Pointer）
+	Conflicts     []string // 页面在分叉后也修改了、且结果不同的位置
+}
+
+// BranchUseCase 页面私有草稿分支业务逻辑。
+// 分支内容保存在独立的分支页面中，编辑走普通的协同房间（pageId 为分支页面 ID），
+// 合并时用 internal/merge 把分支的修改移植到页面的最新状态上，作为一次普通编辑提交到页面房间
+type BranchUseCase struct {
+	branches repository.BranchRepository
+	pageRepo repository.PageRepository
+	pages    BranchPages
+	hub      *ws.Hub
+	quota    StorageQuota // 可选，为 nil 时不检查存储配额
+}
+
+// NewBranchUseCase 创建 BranchUseCase 实例
+func NewBranchUseCase(branches repository.BranchRepository, pageRepo repository.PageRepository, pages BranchPages, hub *ws.Hub) *BranchUseCase {
+	return &BranchUseCase{branches: branches, pageRepo: pageRepo, pages: pages, hub: hub}
+}
+
+// EnableQuota 创建分支前检查分支创建者的存储配额（分支页面属于创建者个人）
+func (uc *BranchUseCase) EnableQuota(quota StorageQuota) {
+	uc.quota = quota
+}
+
+// Fork 以页面的最新状态（协同房间内存优先）创建私有草稿分支，页面所有者和编辑者可以操作
+func (uc *BranchUseCase) Fork(pageID, userID, name string) (*entity.PageBranch, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > entity.MaxBranchNameLength {
+		return nil, fmt.Errorf("%w: 名称不能为空且不超过 %d 个字符", domainErrors.ErrInvalidBranch, entity.MaxBranchNameLength)
+	}
+	if err := uc.requireRole(pageID, userID, true); err != nil {
+		return nil, err
+	}
+
+	info, err := uc.pageRepo.GetAccessInfo(pageID)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, domainErrors.ErrPageNotFound
+	}
+	if info.BranchOf != "" {
+		return nil, fmt.Errorf("%w: 不能从分支再分叉", domainErrors.ErrInvalidBranch)
+	}
+
+	count, err := uc.branches.CountByOwner(pageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= entity.MaxBranchesPerUser {
+		return nil, domainErrors.ErrBranchLimit
+	}
+
+	page, err := uc.pages.ReadPage(pageID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, domainErrors.ErrPageNotFound
+	}
+	if err := uc.pages.EnsureUser(userID); err != nil {
+		return nil, err
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	branch := &entity.PageBranch{
+		ID:           id,
+		PageID:       pageID,
+		BranchPageID: entity.BranchPagePrefix + id,
+		OwnerID:      userID,
+		Name:         name,
+		BaseVersion:  page.Version,
+		BaseSchema:   page.Schema,
+	}
+	branchPage := &entity.Page{
+		PageID:    branch.BranchPageID,
+		Schema:    page.Schema,
+		Version:   1,
+		CreatorID: userID,
+		BranchOf:  pageID,
+	}
+	// 分支页面占用草稿和初始版本快照两份 Schema
+	if err := checkQuota(uc.quota, entity.TenantOf(branchPage), 2*int64(len(page.Schema))); err != nil {
+		return nil, err
+	}
+	if err := uc.branches.Create(branch, branchPage); err != nil {
+		return nil, err
+	}
+	return branch, nil
+}
+
+// List 按创建时间降序返回用户自己在页面上的分支
+func (uc *BranchUseCase) List(pageID, userID string) ([]*entity.PageBranch, error) {
+	if err := uc.requireRole(pageID, userID, false); err != nil {
+		return nil, err
+	}
+
+	branches, err := uc.branches.ListByOwner(pageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if branches == nil {
+		branches = []*entity.PageBranch{}
+	}
+	return branches, nil
+}
+
+// Preview 预览分支合并：分支修改了哪些位置、哪些与页面分叉后的修改冲突。只读取，不创建房间
+func (uc *BranchUseCase) Preview(pageID, branchID, userID string) (*entity.PageBranch, *BranchMerge, error) {
+	branch, err := uc.ownBranch(pageID, branchID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := uc.requireRole(pageID, userID, false); err != nil {
+		return nil, nil, err
+	}
+
+	page, err := uc.pages.ReadPage(pageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if page == nil {
+		return nil, nil, domainErrors.ErrPageNotFound
+	}
+	branchPage, err := uc.branchPage(branch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := merge.ThreeWay(branch.BaseSchema, page.Schema, branchPage.Schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	return branch, &BranchMerge{
+		PageVersion:   page.Version,
+		BranchVersion: branchPage.Version,
+		Changes:       result.Changes,
+		Conflicts:     result.Conflicts,
+	}, nil
+}
+
+// Merge 把分支的修改合并到页面的最新状态上，作为一次编辑提交到页面的协同房间，在线协作者实时收到；
+// 合并成功后分支被删除。存在冲突时不做任何修改，返回冲突位置和 ErrBranchConflict
+func (uc *BranchUseCase) Merge(pageID, branchID, userID string) (*BranchMerge, error) {
+	branch, err := uc.ownBranch(pageID, branchID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.requireRole(pageID, userID, true); err != nil {
+		return nil, err
+	}
+	branchPage, err := uc.branchPage(branch)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, _, err := uc.mergeInto(branch, branchPage, userID, "", nil, 0)
+	if err != nil {
+		return merged, err
+	}
+	if err := uc.discard(branch); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// mergeInto 把分支合并到页面的协同房间，返回合并结果和合并产生的页面状态（没有可合并的修改时为 nil）。
+// strategy 为空时存在冲突即返回 ErrBranchConflict，ours / theirs 按策略取舍冲突位置；
+// manual 时直接应用评审者基于页面 version 版本提交的 patch，版本不符返回 ErrOptimisticLock；
+// 页面处于演示模式且合并者不是演示者时返回 ErrPresentationActive
+func (uc *BranchUseCase) mergeInto(branch *entity.PageBranch, branchPage *entity.Page, userID, strategy string, patch []byte, version int64) (*BranchMerge, []byte, error) {
+	room, err := uc.hub.GetOrCreateRoom(branch.PageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 无人在线时房间只为本次合并而创建，完成后交给 Hub 刷盘销毁
+	defer uc.hub.ReleaseIfIdle(room)
+
+	if !room.MayEdit(userID) {
+		return nil, nil, domainErrors.ErrPresentationActive
+	}
+
+	author := ws.UserInfo{UserID: userID, UserName: userID}
+	for attempt := 0; ; attempt++ {
+		snapshot, current := room.GetSnapshot()
+		result, err := merge.ThreeWay(branch.BaseSchema, snapshot, branchPage.Schema)
+		if err != nil {
+			return nil, nil, err
+		}
+		merged := &BranchMerge{
+			PageVersion:   current,
+			BranchVersion: branchPage.Version,
+			Changes:       result.Changes,
+			Conflicts:     result.Conflicts,
+		}
+
+		expected := current
+		if strategy == entity.MergeStrategyManual {
+			expected = version
+		} else {
+			if len(result.Conflicts) > 0 && strategy == "" {
+				return merged, nil, domainErrors.ErrBranchConflict
+			}
+			target := result.Merged
+			if strategy == entity.MergeStrategyTheirs {
+				target = result.Theirs
+			}
+			diff, err := jsondiff.Diff(snapshot, target)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(diff) == 0 {
+				return merged, nil, nil
+			}
+			if patch, err = json.Marshal(diff); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		applied, err := room.SubmitLabeledEdit(author, ws.LabelMerge, patch, expected)
+		var conflict *ws.VersionConflictError
+		var patchErr *ws.PatchError
+		switch {
+		case err == nil:
+			state, err := applyPatch(snapshot, applied.Patches)
+			if err != nil {
+				return nil, nil, err
+			}
+			merged.PageVersion = applied.Version
+			return merged, state, nil
+		case errors.As(err, &conflict):
+			// 与实时编辑并发，基于新状态重新合并；手动解决的 Patch 只对应提交时的版本
+			if strategy == entity.MergeStrategyManual || attempt >= bulkOpsRetries {
+				return nil, nil, domainErrors.ErrOptimisticLock
+			}
+		case errors.As(err, &patchErr):
+			return nil, nil, fmt.Errorf("%w: %s", domainErrors.ErrInvalidMergeRequest, patchErr.Reason)
+		case errors.Is(err, ws.ErrRoomClosed):
+			return nil, nil, domainErrors.ErrRoomClosing
+		default:
+			return nil, nil, err
+		}
+	}
+}
+
+// Delete 放弃分支，分支内容和协同房间一并删除
+func (uc *BranchUseCase) Delete(pageID, branchID, userID string) error {
+	branch, err := uc.ownBranch(pageID, branchID, userID)
+	if err != nil {
+		return err
+	}
+	return uc.discard(branch)
+}
+
+// discard 关闭分支的协同房间并删除分支页面，分支记录随之删除
+func (uc *BranchUseCase) discard(branch *entity.PageBranch) error {
+	uc.hub.CloseRoom(branch.BranchPageID)
+	return uc.pageRepo.Delete(branch.BranchPageID)
+}
+
+// ownBranch 读取用户自己的分支，不存在或属于他人时返回 ErrBranchNotFound
+func (uc *BranchUseCase) ownBranch(pageID, branchID, userID string) (*entity.PageBranch, error) {
+	branch, err := uc.branches.Get(pageID, branchID)
+	if err != nil {
+		return nil, err
+	}
+	if branch == nil || branch.OwnerID != userID {
+		return nil, domainErrors.ErrBranchNotFound
+	}
+	return branch, nil
+}
+
+// branchPage 读取分支内容的最新状态（协同房间内存优先）
+func (uc *BranchUseCase) branchPage(branch *entity.PageBranch) (*entity.Page, error) {
+	page, err := uc.pages.ReadPage(branch.BranchPageID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, domainErrors.ErrBranchNotFound
+	}
+	return page, nil
+}
+
+// applyPatch 将房间实际应用的 Patch（含编辑归属）应用到提交前的快照，得到提交后的页面状态
+func applyPatch(snapshot []byte, patchBytes []byte) ([]byte, error) {
+	patch, err := jsonpatch.DecodePatch(patchBytes)
+	if err != nil {
+		return nil, err
+	}
+	return patch.Apply(snapshot)
+}
+
+// requireRole 检查用户能否访问页面，edit 为 true 时还要求不是只读协作者
+func (uc *BranchUseCase) requireRole(pageID, userID string, edit bool) error {
+	role, err := uc.pages.PageRole(pageID, userID)
+	if err != nil {
+		return err
+	}
+	if edit && role == entity.RoleViewer {
+		return domainErrors.ErrUnauthorized
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/ws"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ========== BranchUseCase 单元测试 ==========
+
+var branchBaseSchema = []byte(`{"rootId": 1, "components": {
+	"1": {"id": 1, "name": "Page", "desc": "页面", "children": [2]},
+	"2": {"id": 2, "name": "Button", "desc": "按钮", "parentId": 1, "props": {"text": "a"}}
+}}`)
+
+func TestBranchUseCase_Fork(t *testing.T) {
+	// 测试场景：名称无效、只读协作者、从分支再分叉、分支数达到上限时拒绝；
+	// 成功时分支以页面最新状态为分叉点，分支页面归创建者所有并指向原页面
+
+	pageRepo := new(MockPageRepository)
+	pageRepo.On("GetAccessInfo", "page-1").Return(&entity.Page{PageID: "page-1", CreatorID: "alice"}, nil)
+	pageRepo.On("GetAccessInfo", "branch-x").Return(&entity.Page{PageID: "branch-x", CreatorID: "alice", BranchOf: "page-1"}, nil)
+	pages := new(MockBranchPages)
+	pages.On("PageRole", mock.Anything, "alice").Return(entity.RoleOwner, nil)
+	pages.On("PageRole", mock.Anything, "bob").Return(entity.RoleViewer, nil)
+	pages.On("PageRole", mock.Anything, "carol").Return(entity.RoleEditor, nil)
+	pages.On("ReadPage", "page-1").Return(&entity.Page{PageID: "page-1", Schema: branchBaseSchema, Version: 7}, nil)
+	pages.On("EnsureUser", "alice").Return(nil)
+	branches := new(MockBranchRepository)
+	branches.On("CountByOwner", "page-1", "alice").Return(int64(0), nil)
+	branches.On("CountByOwner", "page-1", "carol").Return(int64(entity.MaxBranchesPerUser), nil)
+	branches.On("Create", mock.Anything, mock.Anything).Return(nil)
+	uc := NewBranchUseCase(branches, pageRepo, pages, nil)
+
+	_, err := uc.Fork("page-1", "alice", " ")
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidBranch)
+	_, err = uc.Fork("page-1", "bob", "草稿")
+	assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+	_, err = uc.Fork("branch-x", "alice", "草稿")
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidBranch)
+	_, err = uc.Fork("page-1", "carol", "草稿")
+	assert.ErrorIs(t, err, domainErrors.ErrBranchLimit)
+
+	branch, err := uc.Fork("page-1", "alice", " 新布局 ")
+	assert.NoError(t, err)
+	assert.Equal(t, "新布局", branch.Name)
+	assert.Equal(t, entity.BranchPagePrefix+branch.ID, branch.BranchPageID)
+	assert.Equal(t, int64(7), branch.BaseVersion)
+	branches.AssertCalled(t, "Create", branch, mock.MatchedBy(func(p *entity.Page) bool {
+		return p.PageID == branch.BranchPageID && p.CreatorID == "alice" && p.BranchOf == "page-1" && p.Version == 1
+	}))
+}
+
+func TestBranchUseCase_Merge(t *testing.T) {
+	// 测试场景：分支和页面在分叉后修改了不同位置时合并为一次编辑并删除分支；
+	// 修改了同一位置时返回冲突位置，页面和分支都不变；他人的分支视为不存在
+
+	pageSchema := []byte(`{"rootId": 1, "components": {
+		"1": {"id": 1, "name": "Page", "desc": "首页", "children": [2]},
+		"2": {"id": 2, "name": "Button", "desc": "按钮", "parentId": 1, "props": {"text": "a"}}
+	}}`)
+	var saved []byte
+	mockPageService := new(MockPageService)
+	mockPageService.On("GetPageState", "page-1").Return(pageSchema, int64(5), nil)
+	mockPageService.On("SavePageState", "page-1", mock.Anything, int64(5), int64(6)).
+		Run(func(args mock.Arguments) { saved = args.Get(1).([]byte) }).Return(nil).Once()
+	hub := ws.NewHub(mockPageService)
+	go hub.Run()
+
+	pageRepo := new(MockPageRepository)
+	pageRepo.On("Delete", "branch-b1").Return(nil)
+	pages := new(MockBranchPages)
+	pages.On("PageRole", "page-1", mock.Anything).Return(entity.RoleEditor, nil)
+	pages.On("ReadPage", "branch-b1").Return(&entity.Page{PageID: "branch-b1", Version: 3, Schema: []byte(`{"rootId": 1, "components": {
+		"1": {"id": 1, "name": "Page", "desc": "页面", "children": [2]},
+		"2": {"id": 2, "name": "Button", "desc": "按钮", "parentId": 1, "props": {"text": "b"}}
+	}}`)}, nil)
+	pages.On("ReadPage", "branch-b2").Return(&entity.Page{PageID: "branch-b2", Version: 2, Schema: []byte(`{"rootId": 1, "components": {
+		"1": {"id": 1, "name": "Page", "desc": "草稿", "children": [2]},
+		"2": {"id": 2, "name": "Button", "desc": "按钮", "parentId": 1, "props": {"text": "a"}}
+	}}`)}, nil)
+	branches := new(MockBranchRepository)
+	branches.On("Get", "page-1", "b1").Return(&entity.PageBranch{ID: "b1", PageID: "page-1", BranchPageID: "branch-b1", OwnerID: "alice", BaseSchema: branchBaseSchema}, nil)
+	branches.On("Get", "page-1", "b2").Return(&entity.PageBranch{ID: "b2", PageID: "page-1", BranchPageID: "branch-b2", OwnerID: "alice", BaseSchema: branchBaseSchema}, nil)
+	uc := NewBranchUseCase(branches, pageRepo, pages, hub)
+
+	_, err := uc.Merge("page-1", "b1", "bob")
+	assert.ErrorIs(t, err, domainErrors.ErrBranchNotFound)
+
+	merged, err := uc.Merge("page-1", "b2", "alice")
+	assert.ErrorIs(t, err, domainErrors.ErrBranchConflict)
+	assert.Equal(t, []string{"/components/1/desc"}, merged.Conflicts)
+	pageRepo.AssertNotCalled(t, "Delete", "branch-b2")
+
+	merged, err = uc.Merge("page-1", "b1", "alice")
+	assert.NoError(t, err)
+	assert.Empty(t, merged.Conflicts)
+	assert.Equal(t, []string{"/components/2/props"}, merged.Changes)
+	assert.Equal(t, int64(6), merged.PageVersion)
+	pageRepo.AssertCalled(t, "Delete", "branch-b1")
+
+	// 房间释放时刷盘，保存的是合并后的状态
+	assert.Eventually(t, func() bool { return hub.GetRoom("page-1") == nil }, time.Second, 10*time.Millisecond)
+	var state struct {
+		Components map[string]struct {
+			Desc  string         `json:"desc"`
+			Props map[string]any `json:"props"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.Unmarshal(saved, &state))
+	assert.Equal(t, "首页", state.Components["1"].Desc)
+	assert.Equal(t, "b", state.Components["2"].Props["text"])
+}
+
+func TestBranchUseCase_List(t *testing.T) {
+	// 测试场景：只返回用户自己的分支，没有分支时返回空切片而不是 nil
+
+	pages := new(MockBranchPages)
+	pages.On("PageRole", "page-1", mock.Anything).Return(entity.RoleViewer, nil)
+	branches := new(MockBranchRepository)
+	branches.On("ListByOwner", "page-1", "alice").Return([]*entity.PageBranch{{ID: "b1", OwnerID: "alice"}}, nil)
+	branches.On("ListByOwner", "page-1", "bob").Return(nil, nil)
+	uc := NewBranchUseCase(branches, nil, pages, nil)
+
+	list, err := uc.List("page-1", "alice")
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	list, err = uc.List("page-1", "bob")
+	assert.NoError(t, err)
+	assert.NotNil(t, list)
+	assert.Empty(t, list)
+}
@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/ws"
+)
+
+// CommentMaxLength 单条评论的最大字符数
+const CommentMaxLength = 2000
+
+// CommentUseCase 组件评论业务逻辑层
+// 评论修改后，若页面房间在线则广播给房间内所有用户
+type CommentUseCase struct {
+	commentRepo repository.CommentRepository
+	pageRepo    repository.PageRepository
+	access      PageAccess
+	hub         *ws.Hub
+}
+
+// PageAccess 查询用户在页面上的角色（owner/editor/viewer），由 PageUseCase 实现。
+// 无权访问时返回 ErrUnauthorized，页面不存在时返回 ErrPageNotFound
+type PageAccess interface {
+	PageRole(pageID, userID string) (string, error)
+}
+
+// NewCommentUseCase 创建 CommentUseCase 实例
+// access 为 nil 时不校验页面权限，只检查页面是否存在
+func NewCommentUseCase(commentRepo repository.CommentRepository, pageRepo repository.PageRepository, access PageAccess, hub *ws.Hub) *CommentUseCase {
+	return &CommentUseCase{commentRepo: commentRepo, pageRepo: pageRepo, access: access, hub: hub}
+}
+
+// List 返回页面的评论，componentID 非空时只返回该组件的评论，能读取页面的用户都可以查看
+func (uc *CommentUseCase) List(pageID, userID, componentID string) ([]*entity.Comment, error) {
+	if err := uc.checkAccess(pageID, userID); err != nil {
+		return nil, err
+	}
+	return uc.commentRepo.ListByPage(pageID, componentID)
+}
+
+// Create 发表评论。parentID 非 0 时为回复，必须指向同一页面的线程首条评论，
+// 回复的组件 ID 以线程为准。只读协作者也可以评论
+func (uc *CommentUseCase) Create(pageID, componentID, authorID, text string, parentID uint) (*entity.Comment, error) {
+	text, ok := normalizeCommentText(text)
+	if !ok || (componentID == "" && parentID == 0) {
+		return nil, domainErrors.ErrInvalidComment
+	}
+	if err := uc.checkAccess(pageID, authorID); err != nil {
+		return nil, err
+	}
+
+	if parentID != 0 {
+		parent, err := uc.comment(pageID, parentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.ParentID != 0 {
+			// 只支持一层回复，回复的回复挂到同一线程下
+			parentID = parent.ParentID
+		}
+		componentID = parent.ComponentID
+	}
+
+	comment := &entity.Comment{
+		PageID:      pageID,
+		ComponentID: componentID,
+		ParentID:    parentID,
+		AuthorID:    authorID,
+		Text:        text,
+	}
+	if err := uc.commentRepo.Create(comment); err != nil {
+		return nil, err
+	}
+
+	uc.notify(pageID, ws.TypeCommentAdded, authorID, comment)
+	return comment, nil
+}
+
+// UpdateText 修改评论内容，只有作者可以修改
+func (uc *CommentUseCase) UpdateText(pageID string, id uint, operatorID, text string) (*entity.Comment, error) {
+	text, ok := normalizeCommentText(text)
+	if !ok {
+		return nil, domainErrors.ErrInvalidComment
+	}
+
+	comment, err := uc.comment(pageID, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.AuthorID != operatorID {
+		return nil, domainErrors.ErrUnauthorized
+	}
+
+	if err := uc.commentRepo.UpdateText(id, text); err != nil {
+		return nil, err
+	}
+	comment.Text = text
+	comment.UpdatedAt = time.Now()
+
+	uc.notify(pageID, ws.TypeCommentUpdated, operatorID, comment)
+	return comment, nil
+}
+
+// SetResolved 解决或重新打开线程，任何登录用户都可以操作。
+// id 指向回复时作用于其所属线程。
+func (uc *CommentUseCase) SetResolved(pageID string, id uint, operatorID string, resolved bool) (*entity.Comment, error) {
+	comment, err := uc.comment(pageID, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.ParentID != 0 {
+		if comment, err = uc.comment(pageID, comment.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.commentRepo.SetResolved(comment.ID, resolved, operatorID); err != nil {
+		return nil, err
+	}
+	comment.Resolved = resolved
+	comment.ResolvedBy = ""
+	if resolved {
+		comment.ResolvedBy = operatorID
+	}
+
+	uc.notify(pageID, ws.TypeCommentResolved, operatorID, comment)
+	return comment, nil
+}
+
+// Delete 删除评论，作者或页面所有者可以删除；删除线程首条评论会同时删除所有回复。
+// operatorRole 为路由上 RequirePageRole 解析出的操作者角色
+func (uc *CommentUseCase) Delete(pageID string, id uint, operatorID, operatorRole string) error {
+	comment, err := uc.comment(pageID, id)
+	if err != nil {
+		return err
+	}
+	if comment.AuthorID != operatorID && operatorRole != entity.RoleOwner {
+		return domainErrors.ErrUnauthorized
+	}
+
+	if err := uc.commentRepo.Delete(id); err != nil {
+		return err
+	}
+
+	uc.notify(pageID, ws.TypeCommentDeleted, operatorID, comment)
+	return nil
+}
+
+// checkAccess 检查用户能否读取页面，access 为 nil 时只检查页面是否存在
+func (uc *CommentUseCase) checkAccess(pageID, userID string) error {
+	if uc.access == nil {
+		_, err := uc.page(pageID)
+		return err
+	}
+	_, err := uc.access.PageRole(pageID, userID)
+	return err
+}
+
+// page 获取页面，不存在时返回 ErrPageNotFound
+func (uc *CommentUseCase) page(pageID string) (*entity.Page, error) {
+	page, err := uc.pageRepo.GetByPageID(pageID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, domainErrors.ErrPageNotFound
+	}
+	return page, nil
+}
+
+// comment 获取页面下的评论，不存在或不属于该页面时返回 ErrCommentNotFound
+func (uc *CommentUseCase) comment(pageID string, id uint) (*entity.Comment, error) {
+	comment, err := uc.commentRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil || comment.PageID != pageID {
+		return nil, domainErrors.ErrCommentNotFound
+	}
+	return comment, nil
+}
+
+// notify 房间在线时广播评论变化，使用只读的 GetRoom 不会创建房间
+func (uc *CommentUseCase) notify(pageID string, msgType ws.MessageType, operatorID string, comment *entity.Comment) {
+	if room := uc.hub.GetRoom(pageID); room != nil {
+		room.NotifyComment(msgType, operatorID, CommentPayload(comment))
+	}
+}
+
+// CommentPayload 将评论实体转换为 WebSocket / REST 共用的结构
+func CommentPayload(c *entity.Comment) ws.CommentPayload {
+	return ws.CommentPayload{
+		ID:          c.ID,
+		ComponentID: c.ComponentID,
+		ParentID:    c.ParentID,
+		AuthorID:    c.AuthorID,
+		Text:        c.Text,
+		Resolved:    c.Resolved,
+		ResolvedBy:  c.ResolvedBy,
+		CreatedAt:   c.CreatedAt.UnixMilli(),
+		UpdatedAt:   c.UpdatedAt.UnixMilli(),
+	}
+}
+
+// normalizeCommentText 去除首尾空白并校验长度
+func normalizeCommentText(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	return text, text != "" && utf8.RuneCountInString(text) <= CommentMaxLength
+}
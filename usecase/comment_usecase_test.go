@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/ws"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ========== CommentUseCase 单元测试 ==========
+
+func newCommentTestUseCase() (*CommentUseCase, *MockCommentRepository, *MockPageRepository) {
+	commentRepo := new(MockCommentRepository)
+	pageRepo := new(MockPageRepository)
+	pageRepo.On("GetByPageID", "page-1").Return(&entity.Page{PageID: "page-1", CreatorID: "owner"}, nil).Maybe()
+	pageRepo.On("GetByPageID", "missing").Return(nil, nil).Maybe()
+
+	uc := NewCommentUseCase(commentRepo, pageRepo, nil, ws.NewHub(new(MockPageService)))
+	return uc, commentRepo, pageRepo
+}
+
+func TestCommentUseCase_Create(t *testing.T) {
+	// 测试场景：新评论需指定组件；回复继承线程的组件，回复的回复挂到线程首条评论下
+
+	uc, commentRepo, _ := newCommentTestUseCase()
+
+	commentRepo.On("GetByID", uint(1)).Return(&entity.Comment{ID: 1, PageID: "page-1", ComponentID: "c1"}, nil)
+	commentRepo.On("GetByID", uint(2)).Return(&entity.Comment{ID: 2, PageID: "page-1", ComponentID: "c1", ParentID: 1}, nil)
+	commentRepo.On("GetByID", uint(9)).Return(&entity.Comment{ID: 9, PageID: "other-page"}, nil)
+	commentRepo.On("Create", mock.Anything).Return(nil)
+
+	comment, err := uc.Create("page-1", "c1", "alice", "  需要改颜色  ", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "需要改颜色", comment.Text)
+
+	reply, err := uc.Create("page-1", "", "bob", "好的", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), reply.ParentID)
+	assert.Equal(t, "c1", reply.ComponentID)
+
+	_, err = uc.Create("page-1", "c1", "bob", "跨页面回复", 9)
+	assert.ErrorIs(t, err, domainErrors.ErrCommentNotFound)
+
+	_, err = uc.Create("page-1", "", "alice", "没有组件", 0)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidComment)
+	_, err = uc.Create("page-1", "c1", "alice", strings.Repeat("长", CommentMaxLength+1), 0)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidComment)
+	_, err = uc.Create("missing", "c1", "alice", "hi", 0)
+	assert.ErrorIs(t, err, domainErrors.ErrPageNotFound)
+
+	commentRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestCommentUseCase_ResolveReplyResolvesThread(t *testing.T) {
+	// 测试场景：对回复执行解决操作时作用于其所属线程
+
+	uc, commentRepo, _ := newCommentTestUseCase()
+
+	commentRepo.On("GetByID", uint(1)).Return(&entity.Comment{ID: 1, PageID: "page-1", ComponentID: "c1"}, nil)
+	commentRepo.On("GetByID", uint(2)).Return(&entity.Comment{ID: 2, PageID: "page-1", ComponentID: "c1", ParentID: 1}, nil)
+	commentRepo.On("SetResolved", uint(1), true, "bob").Return(nil).Once()
+
+	thread, err := uc.SetResolved("page-1", 2, "bob", true)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), thread.ID)
+	assert.True(t, thread.Resolved)
+	assert.Equal(t, "bob", thread.ResolvedBy)
+	commentRepo.AssertExpectations(t)
+}
+
+func TestCommentUseCase_Permissions(t *testing.T) {
+	// 测试场景：只有作者可以修改；作者或页面所有者可以删除，其他角色删除他人评论被拒绝
+
+	uc, commentRepo, _ := newCommentTestUseCase()
+
+	commentRepo.On("GetByID", uint(1)).Return(&entity.Comment{ID: 1, PageID: "page-1", AuthorID: "alice"}, nil)
+	commentRepo.On("UpdateText", uint(1), "改一下").Return(nil).Once()
+	commentRepo.On("Delete", uint(1)).Return(nil).Twice()
+
+	_, err := uc.UpdateText("page-1", 1, "owner", "改一下")
+	assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+	_, err = uc.UpdateText("page-1", 1, "alice", "改一下")
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, uc.Delete("page-1", 1, "bob", entity.RoleEditor), domainErrors.ErrUnauthorized)
+	assert.NoError(t, uc.Delete("page-1", 1, "alice", entity.RoleViewer))
+	assert.NoError(t, uc.Delete("page-1", 1, "owner", entity.RoleOwner))
+	assert.ErrorIs(t, uc.Delete("missing", 1, "alice", entity.RoleViewer), domainErrors.ErrCommentNotFound)
+
+	commentRepo.AssertExpectations(t)
+}
+
+func TestCommentUseCase_Access(t *testing.T) {
+	// 测试场景：配置页面权限后，只读协作者可以查看和发表评论，非协作者无权访问
+
+	commentRepo := new(MockCommentRepository)
+	commentRepo.On("ListByPage", "page-1", "").Return([]*entity.Comment{}, nil)
+	commentRepo.On("Create", mock.Anything).Return(nil).Once()
+	access := new(MockPageAccess)
+	access.On("PageRole", "page-1", "viewer").Return(entity.RoleViewer, nil)
+	access.On("PageRole", "page-1", "stranger").Return("", domainErrors.ErrUnauthorized)
+
+	uc := NewCommentUseCase(commentRepo, new(MockPageRepository), access, ws.NewHub(new(MockPageService)))
+
+	_, err := uc.List("page-1", "viewer", "")
+	assert.NoError(t, err)
+	_, err = uc.Create("page-1", "c1", "viewer", "看起来不错", 0)
+	assert.NoError(t, err)
+
+	_, err = uc.List("page-1", "stranger", "")
+	assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+	_, err = uc.Create("page-1", "c1", "stranger", "hi", 0)
+	assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+	commentRepo.AssertExpectations(t)
+}
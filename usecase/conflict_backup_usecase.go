@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+)
+
+// ConflictBackupUseCase 冲突备份业务逻辑层。
+// 备份由协同连接写入（见 ws.WithConflictBackups），这里只负责查看和清理
+type ConflictBackupUseCase struct {
+	backups repository.ConflictBackupRepository
+	access  PageAccess
+}
+
+// NewConflictBackupUseCase 创建 ConflictBackupUseCase 实例
+func NewConflictBackupUseCase(backups repository.ConflictBackupRepository, access PageAccess) *ConflictBackupUseCase {
+	return &ConflictBackupUseCase{backups: backups, access: access}
+}
+
+// List 按时间降序返回页面的冲突备份（不含 Schema），页面所有者和编辑者可以查看
+func (uc *ConflictBackupUseCase) List(pageID, userID string) ([]*entity.ConflictBackup, error) {
+	if _, err := uc.editorRole(pageID, userID); err != nil {
+		return nil, err
+	}
+	return uc.backups.ListByPage(pageID)
+}
+
+// Get 读取一条冲突备份（含 Schema），页面所有者和编辑者可以查看
+func (uc *ConflictBackupUseCase) Get(pageID, userID string, id uint) (*entity.ConflictBackup, error) {
+	if _, err := uc.editorRole(pageID, userID); err != nil {
+		return nil, err
+	}
+	return uc.backup(pageID, id)
+}
+
+// Delete 删除一条冲突备份，上传者本人或页面所有者可以删除
+func (uc *ConflictBackupUseCase) Delete(pageID, userID string, id uint) error {
+	role, err := uc.editorRole(pageID, userID)
+	if err != nil {
+		return err
+	}
+	backup, err := uc.backup(pageID, id)
+	if err != nil {
+		return err
+	}
+	if backup.UserID != userID && role != entity.RoleOwner {
+		return domainErrors.ErrUnauthorized
+	}
+
+	deleted, err := uc.backups.Delete(pageID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return domainErrors.ErrConflictBackupNotFound
+	}
+	return nil
+}
+
+// editorRole 返回用户在页面上的角色，只读协作者无权查看冲突备份
+func (uc *ConflictBackupUseCase) editorRole(pageID, userID string) (string, error) {
+	role, err := uc.access.PageRole(pageID, userID)
+	if err != nil {
+		return "", err
+	}
+	if role == entity.RoleViewer {
+		return "", domainErrors.ErrUnauthorized
+	}
+	return role, nil
+}
+
+// backup 读取页面的一条冲突备份，不存在时返回 ErrConflictBackupNotFound
+func (uc *ConflictBackupUseCase) backup(pageID string, id uint) (*entity.ConflictBackup, error) {
+	backup, err := uc.backups.Get(pageID, id)
+	if err != nil {
+		return nil, err
+	}
+	if backup == nil {
+		return nil, domainErrors.ErrConflictBackupNotFound
+	}
+	return backup, nil
+}
@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== ConflictBackupUseCase 单元测试 ==========
+
+func newConflictBackupTestUseCase() (*ConflictBackupUseCase, *MockConflictBackupRepository) {
+	backups := new(MockConflictBackupRepository)
+	access := new(MockPageAccess)
+	access.On("PageRole", "page-1", "owner").Return(entity.RoleOwner, nil).Maybe()
+	access.On("PageRole", "page-1", "alice").Return(entity.RoleEditor, nil).Maybe()
+	access.On("PageRole", "page-1", "bob").Return(entity.RoleEditor, nil).Maybe()
+	access.On("PageRole", "page-1", "viewer").Return(entity.RoleViewer, nil).Maybe()
+	access.On("PageRole", "page-1", "stranger").Return("", domainErrors.ErrUnauthorized).Maybe()
+	return NewConflictBackupUseCase(backups, access), backups
+}
+
+func TestConflictBackupUseCase_Read(t *testing.T) {
+	// 测试场景：所有者和编辑者可以查看列表和单条备份；只读协作者和无关用户无权查看；
+	// 备份不存在时返回 ErrConflictBackupNotFound
+
+	uc, backups := newConflictBackupTestUseCase()
+	backup := &entity.ConflictBackup{ID: 1, PageID: "page-1", UserID: "alice", Schema: []byte(`{"rootId":1}`)}
+	backups.On("ListByPage", "page-1").Return([]*entity.ConflictBackup{backup}, nil)
+	backups.On("Get", "page-1", uint(1)).Return(backup, nil)
+	backups.On("Get", "page-1", uint(2)).Return(nil, nil)
+
+	for _, userID := range []string{"owner", "bob"} {
+		list, err := uc.List("page-1", userID)
+		assert.NoError(t, err)
+		assert.Len(t, list, 1)
+
+		got, err := uc.Get("page-1", userID, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, backup, got)
+	}
+
+	for _, userID := range []string{"viewer", "stranger"} {
+		_, err := uc.List("page-1", userID)
+		assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+		_, err = uc.Get("page-1", userID, 1)
+		assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+	}
+
+	_, err := uc.Get("page-1", "owner", 2)
+	assert.ErrorIs(t, err, domainErrors.ErrConflictBackupNotFound)
+}
+
+func TestConflictBackupUseCase_Delete(t *testing.T) {
+	// 测试场景：上传者本人和页面所有者可以删除，其他编辑者无权删除
+
+	uc, backups := newConflictBackupTestUseCase()
+	backup := &entity.ConflictBackup{ID: 1, PageID: "page-1", UserID: "alice"}
+	backups.On("Get", "page-1", uint(1)).Return(backup, nil)
+	backups.On("Delete", "page-1", uint(1)).Return(true, nil)
+
+	assert.ErrorIs(t, uc.Delete("page-1", "bob", 1), domainErrors.ErrUnauthorized)
+	backups.AssertNotCalled(t, "Delete", "page-1", uint(1))
+
+	assert.NoError(t, uc.Delete("page-1", "alice", 1))
+	assert.NoError(t, uc.Delete("page-1", "owner", 1))
+	backups.AssertNumberOfCalls(t, "Delete", 2)
+}
@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"expvar"
+	"log"
+	"sync"
+	"time"
+
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/ws"
+)
+
+// consistencyMetrics 一致性巡检指标，通过 expvar 暴露
+var consistencyMetrics = expvar.NewMap("consistency")
+
+// ConsistencyReport 一致性巡检报告
+type ConsistencyReport struct {
+	StartedAt            time.Time                     `json:"startedAt"`
+	FinishedAt           time.Time                     `json:"finishedAt"`
+	Healthy              bool                          `json:"healthy"`
+	PagesBehindSnapshots []repository.SnapshotAheadRow `json:"pagesBehindSnapshots"`
+	RoomsAheadOfDB       []RoomAheadOfDB               `json:"roomsAheadOfDb"`
+	OrphanedSnapshots    []string                      `json:"orphanedSnapshots"`
+	OrphanedOps          []string                      `json:"orphanedOps"`
+}
+
+// RoomAheadOfDB 房间声称已落盘的版本高于数据库中的版本
+type RoomAheadOfDB struct {
+	PageID           string `json:"pageId"`
+	PersistedVersion int64  `json:"persistedVersion"`
+	DBVersion        int64  `json:"dbVersion"` // 页面已不存在时为 0
+}
+
+// ConsistencyUseCase 房间、历史快照与 pages 表之间的一致性巡检
+type ConsistencyUseCase struct {
+	repo repository.ConsistencyRepository
+	hub  *ws.Hub
+
+	mu   sync.Mutex // 保证同一时间只有一次巡检，并保护 last
+	last *ConsistencyReport
+}
+
+// NewConsistencyUseCase 创建 ConsistencyUseCase 实例
+func NewConsistencyUseCase(repo repository.ConsistencyRepository, hub *ws.Hub) *ConsistencyUseCase {
+	return &ConsistencyUseCase{repo: repo, hub: hub}
+}
+
+// Run 执行一次巡检并更新最近报告
+func (uc *ConsistencyUseCase) Run() (*ConsistencyReport, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	report := &ConsistencyReport{StartedAt: time.Now()}
+
+	// 先读房间再读数据库：房间只在数据库提交后才推进 lastPersistedVersion，
+	// 按此顺序读取时正常情况下数据库版本不会落后
+	roomsAhead, err := uc.findRoomsAheadOfDB()
+	if err != nil {
+		consistencyMetrics.Add("errors", 1)
+		return nil, err
+	}
+	report.RoomsAheadOfDB = roomsAhead
+
+	if report.PagesBehindSnapshots, err = uc.repo.FindPagesBehindSnapshots(); err != nil {
+		consistencyMetrics.Add("errors", 1)
+		return nil, err
+	}
+	if report.OrphanedSnapshots, err = uc.repo.FindOrphanedSnapshots(); err != nil {
+		consistencyMetrics.Add("errors", 1)
+		return nil, err
+	}
+	if report.OrphanedOps, err = uc.repo.FindOrphanedOps(); err != nil {
+		consistencyMetrics.Add("errors", 1)
+		return nil, err
+	}
+
+	report.FinishedAt = time.Now()
+	report.Healthy = len(report.RoomsAheadOfDB) == 0 &&
+		len(report.PagesBehindSnapshots) == 0 &&
+		len(report.OrphanedSnapshots) == 0 &&
+		len(report.OrphanedOps) == 0
+
+	uc.last = report
+	recordConsistencyMetrics(report)
+
+	log.Printf("[Consistency] 巡检完成: healthy=%v, 落后快照=%d, 房间超前=%d, 孤立快照=%d, 孤立操作日志=%d",
+		report.Healthy, len(report.PagesBehindSnapshots), len(report.RoomsAheadOfDB),
+		len(report.OrphanedSnapshots), len(report.OrphanedOps))
+	return report, nil
+}
+
+// LastReport 返回最近一次巡检报告，尚未执行过时返回 nil
+func (uc *ConsistencyUseCase) LastReport() *ConsistencyReport {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	return uc.last
+}
+
+// RunNightly 每天在 hour 点（本地时间）执行一次巡检，阻塞直到 stop 关闭
+func (uc *ConsistencyUseCase) RunNightly(hour int, stop <-chan struct{}) {
+	for {
+		timer := time.NewTimer(nextRunDelay(time.Now(), hour))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+			if _, err := uc.Run(); err != nil {
+				logging.Errorf("[Consistency] 巡检失败: %v", err)
+			}
+		}
+	}
+}
+
+// findRoomsAheadOfDB 找出 lastPersistedVersion 高于数据库版本的房间
+func (uc *ConsistencyUseCase) findRoomsAheadOfDB() ([]RoomAheadOfDB, error) {
+	states := uc.hub.RoomStates()
+	if len(states) == 0 {
+		return nil, nil
+	}
+
+	pageIDs := make([]string, 0, len(states))
+	for _, state := range states {
+		pageIDs = append(pageIDs, state.PageID)
+	}
+	dbVersions, err := uc.repo.GetPageVersions(pageIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var ahead []RoomAheadOfDB
+	for _, state := range states {
+		if dbVersion := dbVersions[state.PageID]; state.PersistedVersion > dbVersion {
+			ahead = append(ahead, RoomAheadOfDB{
+				PageID:           state.PageID,
+				PersistedVersion: state.PersistedVersion,
+				DBVersion:        dbVersion,
+			})
+		}
+	}
+	return ahead, nil
+}
+
+// recordConsistencyMetrics 将巡检结果写入 expvar 指标
+func recordConsistencyMetrics(report *ConsistencyReport) {
+	consistencyMetrics.Add("runs", 1)
+	setInt(consistencyMetrics, "last_run_unix", report.FinishedAt.Unix())
+	setInt(consistencyMetrics, "pages_behind_snapshots", int64(len(report.PagesBehindSnapshots)))
+	setInt(consistencyMetrics, "rooms_ahead_of_db", int64(len(report.RoomsAheadOfDB)))
+	setInt(consistencyMetrics, "orphaned_snapshots", int64(len(report.OrphanedSnapshots)))
+	setInt(consistencyMetrics, "orphaned_ops", int64(len(report.OrphanedOps)))
+}
+
+// setInt 设置 expvar.Map 中的整数指标（Map.Add 只能累加）
+func setInt(m *expvar.Map, key string, value int64) {
+	v := new(expvar.Int)
+	v.Set(value)
+	m.Set(key, v)
+}
+
+// nextRunDelay 计算距离下一个 hour 点的时长，已过则顺延到次日
+func nextRunDelay(now time.Time, hour int) time.Duration {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next.Sub(now)
+}
@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/ws"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ========== ConsistencyUseCase 单元测试 ==========
+
+// TestConsistencyUseCase_Run 测试巡检汇总各项检查结果
+func TestConsistencyUseCase_Run(t *testing.T) {
+	// 测试场景：房间 page-1 声称已落盘版本 7，但数据库只有 5，应被标记
+
+	mockService := new(MockPageService)
+	mockService.On("GetPageState", "page-1").Return([]byte(`{}`), int64(7), nil)
+	mockService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	hub := ws.NewHub(mockService)
+	_, err := hub.GetOrCreateRoom("page-1")
+	assert.NoError(t, err)
+
+	mockRepo := new(MockConsistencyRepository)
+	mockRepo.On("GetPageVersions", []string{"page-1"}).Return(map[string]int64{"page-1": 5}, nil)
+	mockRepo.On("FindPagesBehindSnapshots").Return([]repository.SnapshotAheadRow{
+		{PageID: "page-2", PageVersion: 3, SnapshotVersion: 4},
+	}, nil)
+	mockRepo.On("FindOrphanedSnapshots").Return([]string{"deleted-page"}, nil)
+	mockRepo.On("FindOrphanedOps").Return([]string{}, nil)
+
+	uc := NewConsistencyUseCase(mockRepo, hub)
+	assert.Nil(t, uc.LastReport())
+
+	report, err := uc.Run()
+
+	assert.NoError(t, err)
+	assert.False(t, report.Healthy)
+	assert.Equal(t, []RoomAheadOfDB{{PageID: "page-1", PersistedVersion: 7, DBVersion: 5}}, report.RoomsAheadOfDB)
+	assert.Len(t, report.PagesBehindSnapshots, 1)
+	assert.Equal(t, []string{"deleted-page"}, report.OrphanedSnapshots)
+	assert.Same(t, report, uc.LastReport())
+}
+
+// TestConsistencyUseCase_Run_Healthy 测试无房间且数据一致时报告健康
+func TestConsistencyUseCase_Run_Healthy(t *testing.T) {
+	mockRepo := new(MockConsistencyRepository)
+	mockRepo.On("FindPagesBehindSnapshots").Return([]repository.SnapshotAheadRow{}, nil)
+	mockRepo.On("FindOrphanedSnapshots").Return([]string{}, nil)
+	mockRepo.On("FindOrphanedOps").Return([]string{}, nil)
+
+	uc := NewConsistencyUseCase(mockRepo, ws.NewHub(new(MockPageService)))
+	report, err := uc.Run()
+
+	assert.NoError(t, err)
+	assert.True(t, report.Healthy)
+	mockRepo.AssertNotCalled(t, "GetPageVersions", mock.Anything)
+}
+
+// TestNextRunDelay 测试下一次巡检时间的计算
+func TestNextRunDelay(t *testing.T) {
+	now := time.Date(2024, 5, 1, 2, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, 30*time.Minute, nextRunDelay(now, 3))
+	assert.Equal(t, 23*time.Hour+30*time.Minute, nextRunDelay(now, 2))
+}
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+)
+
+// 临时访客 Token 有效期
+const (
+	DefaultGuestTokenTTL = 2 * time.Hour  // 未指定有效期时使用
+	MaxGuestTokenTTL     = 24 * time.Hour // 允许设置的最长有效期
+)
+
+// GuestGrant 临时访客 Token 授予的身份，Token 不落库，所有字段都由签名保证
+type GuestGrant struct {
+	GuestID   string    `json:"guestId"` // 访客在协同房间中的用户 ID
+	PageID    string    `json:"pageId"`
+	Role      string    `json:"role"` // editor 或 viewer
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// GuestTokenUseCase 协同演示用的临时访客 Token：持有 Token 即可免登录以指定角色加入页面的协同房间
+type GuestTokenUseCase struct {
+	secret []byte
+}
+
+// NewGuestTokenUseCase 创建 GuestTokenUseCase 实例，secret 为 Token 签名密钥，更换后已发出的 Token 全部失效
+func NewGuestTokenUseCase(secret []byte) *GuestTokenUseCase {
+	return &GuestTokenUseCase{secret: secret}
+}
+
+// Mint 为页面签发临时访客 Token，调用者是否为所有者由路由上的 RequirePageRole 校验。
+// role 为 editor 或 viewer（为空时为 viewer）；ttl 为 0 时使用 DefaultGuestTokenTTL，
+// 角色不合法、ttl 为负数或超出 MaxGuestTokenTTL 时返回 ErrInvalidGuestTokenRequest
+func (uc *GuestTokenUseCase) Mint(pageID, role string, ttl time.Duration) (*GuestGrant, string, error) {
+	if role == "" {
+		role = entity.RoleViewer
+	}
+	if ttl == 0 {
+		ttl = DefaultGuestTokenTTL
+	}
+	if (role != entity.RoleEditor && role != entity.RoleViewer) || ttl < 0 || ttl > MaxGuestTokenTTL {
+		return nil, "", domainErrors.ErrInvalidGuestTokenRequest
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, "", err
+	}
+	grant := &GuestGrant{
+		GuestID:   "guest-" + hex.EncodeToString(id),
+		PageID:    pageID,
+		Role:      role,
+		ExpiresAt: time.Now().Add(ttl).Truncate(time.Second),
+	}
+	return grant, uc.token(grant), nil
+}
+
+// Verify 校验页面的临时访客 Token：签名正确、属于该页面且未过期，
+// 校验失败统一返回 ErrGuestTokenInvalid，不区分原因
+func (uc *GuestTokenUseCase) Verify(pageID, token string) (*GuestGrant, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 {
+		return nil, domainErrors.ErrGuestTokenInvalid
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, domainErrors.ErrGuestTokenInvalid
+	}
+
+	grant := &GuestGrant{
+		GuestID:   "guest-" + parts[0],
+		PageID:    pageID,
+		Role:      parts[1],
+		ExpiresAt: time.Unix(expires, 0),
+	}
+	if !hmac.Equal([]byte(token), []byte(uc.token(grant))) || !time.Now().Before(grant.ExpiresAt) {
+		return nil, domainErrors.ErrGuestTokenInvalid
+	}
+	return grant, nil
+}
+
+// token 返回授予的签名 Token：<访客 ID>.<角色>.<过期时间>.<HMAC-SHA256>，
+// 签名带有用途前缀并绑定页面，不会与分享链接、删除确认 Token 混用
+func (uc *GuestTokenUseCase) token(grant *GuestGrant) string {
+	payload := strings.TrimPrefix(grant.GuestID, "guest-") + "." + grant.Role + "." +
+		strconv.FormatInt(grant.ExpiresAt.Unix(), 10)
+	mac := hmac.New(sha256.New, uc.secret)
+	mac.Write([]byte("guest." + grant.PageID + "." + payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
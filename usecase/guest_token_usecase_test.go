@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== GuestTokenUseCase 单元测试 ==========
+
+func newGuestTokenTestUseCase() *GuestTokenUseCase {
+	return NewGuestTokenUseCase([]byte("secret"))
+}
+
+func TestGuestTokenUseCase_Mint(t *testing.T) {
+	// 测试场景：角色缺省为 viewer，有效期缺省为 2 小时；
+	// 角色不合法、有效期为负数或超出 24 小时时拒绝；签发的 Token 可通过校验
+
+	uc := newGuestTokenTestUseCase()
+
+	grant, token, err := uc.Mint("page-1", "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.RoleViewer, grant.Role)
+	assert.True(t, strings.HasPrefix(grant.GuestID, "guest-"))
+	assert.WithinDuration(t, time.Now().Add(DefaultGuestTokenTTL), grant.ExpiresAt, time.Minute)
+
+	verified, err := uc.Verify("page-1", token)
+	assert.NoError(t, err)
+	assert.Equal(t, grant, verified)
+
+	grant, _, err = uc.Mint("page-1", entity.RoleEditor, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.RoleEditor, grant.Role)
+
+	_, _, err = uc.Mint("page-1", entity.RoleOwner, time.Hour)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidGuestTokenRequest)
+	for _, ttl := range []time.Duration{-time.Hour, MaxGuestTokenTTL + time.Hour} {
+		_, _, err = uc.Mint("page-1", entity.RoleViewer, ttl)
+		assert.ErrorIs(t, err, domainErrors.ErrInvalidGuestTokenRequest, ttl)
+	}
+}
+
+func TestGuestTokenUseCase_Verify(t *testing.T) {
+	// 测试场景：用于其他页面、篡改角色、已过期、格式错误或用其他密钥签名的 Token 均返回 ErrGuestTokenInvalid
+
+	uc := newGuestTokenTestUseCase()
+	_, token, err := uc.Mint("page-1", entity.RoleViewer, time.Hour)
+	assert.NoError(t, err)
+
+	_, err = uc.Verify("page-2", token)
+	assert.ErrorIs(t, err, domainErrors.ErrGuestTokenInvalid)
+
+	tampered := strings.Replace(token, "."+entity.RoleViewer+".", "."+entity.RoleEditor+".", 1)
+	_, err = uc.Verify("page-1", tampered)
+	assert.ErrorIs(t, err, domainErrors.ErrGuestTokenInvalid)
+
+	expired := uc.token(&GuestGrant{GuestID: "guest-abc", PageID: "page-1", Role: entity.RoleEditor, ExpiresAt: time.Now().Add(-time.Second)})
+	_, err = uc.Verify("page-1", expired)
+	assert.ErrorIs(t, err, domainErrors.ErrGuestTokenInvalid)
+
+	_, err = uc.Verify("page-1", "not-a-token")
+	assert.ErrorIs(t, err, domainErrors.ErrGuestTokenInvalid)
+
+	other := NewGuestTokenUseCase([]byte("other-secret"))
+	_, err = other.Verify("page-1", token)
+	assert.ErrorIs(t, err, domainErrors.ErrGuestTokenInvalid)
+}
@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/mailer"
+)
+
+// InvitePages 协作邀请依赖的页面能力，由 PageUseCase 实现
+type InvitePages interface {
+	PageAccess
+	AddCollaborator(pageID, operatorID, userID, role string) (*entity.PageCollaborator, error)
+}
+
+// InviteUseCase 通过邮箱邀请协作者。
+// 邀请保存为待接受记录并发送邮件；受邀者注册后由 Clerk Webhook 按已验证邮箱匹配，
+// 以邀请人的名义添加为协作者。邮箱已注册的用户直接添加
+type InviteUseCase struct {
+	invites   repository.InviteRepository
+	users     repository.UserRepository
+	pages     InvitePages
+	mailer    mailer.Mailer
+	inviteURL string // 邮件中的页面链接，{pageId} 替换为页面 ID
+}
+
+// NewInviteUseCase 创建 InviteUseCase 实例
+func NewInviteUseCase(invites repository.InviteRepository, users repository.UserRepository, pages InvitePages, m mailer.Mailer, inviteURL string) *InviteUseCase {
+	return &InviteUseCase{invites: invites, users: users, pages: pages, mailer: m, inviteURL: inviteURL}
+}
+
+// Create 页面所有者邀请邮箱成为协作者，同一邮箱重复邀请时更新角色并重新计算有效期。
+// inviterID 是否为所有者由路由上的 RequirePageRole 校验。
+// 邮箱已注册时直接添加协作者，邀请记录为已接受。
+// 邮件发送失败时邀请仍然保存，返回邀请和 ErrMailDelivery，可重新邀请以重发
+func (uc *InviteUseCase) Create(pageID, inviterID, email, role string) (*entity.PageInvite, error) {
+	if role != entity.RoleEditor && role != entity.RoleViewer {
+		return nil, domainErrors.ErrInvalidRole
+	}
+	email, ok := normalizeEmail(email)
+	if !ok {
+		return nil, domainErrors.ErrInvalidInvite
+	}
+	now := time.Now()
+	invite := &entity.PageInvite{
+		PageID:    pageID,
+		Email:     email,
+		Role:      role,
+		InvitedBy: inviterID,
+		ExpiresAt: now.Add(entity.InviteTTL),
+	}
+
+	user, err := uc.users.GetByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		if _, err := uc.pages.AddCollaborator(pageID, inviterID, user.ID, role); err != nil {
+			return nil, err
+		}
+		invite.AcceptedBy = user.ID
+		invite.AcceptedAt = &now
+	} else {
+		pending, err := uc.invites.CountPending(pageID, now)
+		if err != nil {
+			return nil, err
+		}
+		if pending >= entity.MaxPendingInvitesPerPage {
+			return nil, domainErrors.ErrInviteLimit
+		}
+	}
+
+	if err := uc.invites.Upsert(invite); err != nil {
+		return nil, err
+	}
+	if err := uc.mailer.Send(uc.inviteMessage(invite)); err != nil {
+		logging.Warnf("[Invite] 页面 %s 发送邀请邮件失败: %v", pageID, err)
+		return invite, fmt.Errorf("%w: %v", domainErrors.ErrMailDelivery, err)
+	}
+	return invite, nil
+}
+
+// List 按创建时间降序返回页面的邀请（包括已接受和已过期的）
+func (uc *InviteUseCase) List(pageID string) ([]*entity.PageInvite, error) {
+	invites, err := uc.invites.ListByPage(pageID)
+	if err != nil {
+		return nil, err
+	}
+	if invites == nil {
+		invites = []*entity.PageInvite{}
+	}
+	return invites, nil
+}
+
+// Revoke 撤销邀请，已接受的邀请撤销后协作者不受影响
+func (uc *InviteUseCase) Revoke(pageID string, id uint) error {
+	deleted, err := uc.invites.Delete(pageID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return domainErrors.ErrInviteNotFound
+	}
+	return nil
+}
+
+// AcceptForUser 用户注册或更新邮箱后，把发给其已验证邮箱的待接受邀请转为协作者，返回接受的邀请数。
+// 以邀请人的名义添加，邀请人已不再是页面所有者或页面已删除时跳过；
+// 用户已有不低于邀请角色的权限时只记录接受，不降级
+func (uc *InviteUseCase) AcceptForUser(userID string, emails []string) (int, error) {
+	now := time.Now()
+	accepted := 0
+	for _, raw := range emails {
+		email, ok := normalizeEmail(raw)
+		if !ok {
+			continue
+		}
+		invites, err := uc.invites.ListPendingByEmail(email, now)
+		if err != nil {
+			return accepted, err
+		}
+
+		for _, invite := range invites {
+			if err := uc.accept(invite, userID); err != nil {
+				logging.Warnf("[Invite] 用户 %s 接受页面 %s 的邀请失败: %v", userID, invite.PageID, err)
+				continue
+			}
+			if err := uc.invites.MarkAccepted(invite.ID, userID, now); err != nil {
+				return accepted, err
+			}
+			accepted++
+		}
+	}
+	return accepted, nil
+}
+
+// accept 按邀请添加协作者，用户已有同等或更高的权限时不做修改；
+// 接受发生在邀请人的请求之外，这里重新确认邀请人仍是页面所有者
+func (uc *InviteUseCase) accept(invite *entity.PageInvite, userID string) error {
+	inviterRole, err := uc.pages.PageRole(invite.PageID, invite.InvitedBy)
+	if err != nil {
+		return err
+	}
+	if inviterRole != entity.RoleOwner {
+		return domainErrors.ErrUnauthorized
+	}
+
+	role, err := uc.pages.PageRole(invite.PageID, userID)
+	if err != nil && !errors.Is(err, domainErrors.ErrUnauthorized) {
+		return err
+	}
+	if err == nil && entity.RoleRank(role) >= entity.RoleRank(invite.Role) {
+		return nil
+	}
+	_, err = uc.pages.AddCollaborator(invite.PageID, invite.InvitedBy, userID, invite.Role)
+	return err
+}
+
+// inviteMessage 生成邀请邮件，邀请人显示为其名称或邮箱
+func (uc *InviteUseCase) inviteMessage(invite *entity.PageInvite) mailer.Message {
+	inviter := invite.InvitedBy
+	if user, err := uc.users.GetByID(invite.InvitedBy); err == nil && user != nil {
+		if user.Name != "" {
+			inviter = user.Name
+		} else if user.Email != "" {
+			inviter = user.Email
+		}
+	}
+
+	roleName := "编辑"
+	if invite.Role == entity.RoleViewer {
+		roleName = "查看"
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s 邀请你%s低代码页面 %s。\n\n", inviter, roleName, invite.PageID)
+	if uc.inviteURL != "" {
+		fmt.Fprintf(&body, "打开页面：%s\n\n", strings.ReplaceAll(uc.inviteURL, "{pageId}", invite.PageID))
+	}
+	if invite.AcceptedAt != nil {
+		body.WriteString("你已被添加为页面协作者，登录后即可访问。\n")
+	} else {
+		fmt.Fprintf(&body, "使用本邮箱注册并完成验证后即可访问，邀请在 %s 前有效。\n", invite.ExpiresAt.Format("2006-01-02 15:04"))
+	}
+
+	return mailer.Message{
+		To:      invite.Email,
+		Subject: fmt.Sprintf("%s 邀请你协作%s页面", inviter, roleName),
+		Body:    body.String(),
+	}
+}
+
+// normalizeEmail 校验并规范化邮箱：只接受不带显示名的地址，转为小写
+func normalizeEmail(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	addr, err := mail.ParseAddress(raw)
+	if err != nil || addr.Name != "" || addr.Address != raw || len(raw) > 255 {
+		return "", false
+	}
+	return strings.ToLower(addr.Address), true
+}
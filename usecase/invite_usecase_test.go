@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/mailer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ========== InviteUseCase 单元测试 ==========
+
+func newInviteFixture() (*MockInviteRepository, *MockUserRepository, *MockInvitePages, *MockMailer) {
+	invites := new(MockInviteRepository)
+	users := new(MockUserRepository)
+	users.On("GetByID", "owner").Return(&entity.User{ID: "owner", Name: "Alice"}, nil)
+	pages := new(MockInvitePages)
+	pages.On("PageRole", "page-1", "owner").Return(entity.RoleOwner, nil)
+	pages.On("PageRole", "page-1", "bob").Return(entity.RoleEditor, nil)
+	return invites, users, pages, new(MockMailer)
+}
+
+func TestInviteUseCase_Create(t *testing.T) {
+	// 测试场景：角色、邮箱无效时拒绝；未注册的邮箱保存为待接受邀请并发送邮件，
+	// 邮箱统一为小写，邮件包含邀请人名称和页面链接；待接受邀请达到上限时拒绝
+
+	invites, users, pages, m := newInviteFixture()
+	users.On("GetByEmail", "carol@example.com").Return(nil, nil)
+	invites.On("CountPending", "page-1", mock.Anything).Return(int64(0), nil).Once()
+	invites.On("CountPending", "page-1", mock.Anything).Return(int64(entity.MaxPendingInvitesPerPage), nil)
+	invites.On("Upsert", mock.Anything).Return(nil)
+	m.On("Send", mock.Anything).Return(nil)
+	uc := NewInviteUseCase(invites, users, pages, m, "https://lowcode.example.com/?pageId={pageId}")
+
+	_, err := uc.Create("page-1", "owner", "carol@example.com", entity.RoleOwner)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidRole)
+	for _, email := range []string{"", "carol", "Carol <carol@example.com>"} {
+		_, err = uc.Create("page-1", "owner", email, entity.RoleEditor)
+		assert.ErrorIs(t, err, domainErrors.ErrInvalidInvite, email)
+	}
+
+	invite, err := uc.Create("page-1", "owner", " Carol@Example.com ", entity.RoleViewer)
+	assert.NoError(t, err)
+	assert.Equal(t, "carol@example.com", invite.Email)
+	assert.Equal(t, entity.RoleViewer, invite.Role)
+	assert.Equal(t, "owner", invite.InvitedBy)
+	assert.True(t, invite.Pending(time.Now()))
+	m.AssertCalled(t, "Send", mock.MatchedBy(func(msg mailer.Message) bool {
+		return msg.To == "carol@example.com" && strings.Contains(msg.Subject, "Alice") &&
+			strings.Contains(msg.Body, "https://lowcode.example.com/?pageId=page-1")
+	}))
+
+	_, err = uc.Create("page-1", "owner", "carol@example.com", entity.RoleEditor)
+	assert.ErrorIs(t, err, domainErrors.ErrInviteLimit)
+}
+
+func TestInviteUseCase_CreateExistingUser(t *testing.T) {
+	// 测试场景：邮箱已注册时直接添加为协作者，邀请记录为已接受，且不受待接受邀请数上限限制
+
+	invites, users, pages, m := newInviteFixture()
+	users.On("GetByEmail", "dave@example.com").Return(&entity.User{ID: "dave", Email: "Dave@example.com"}, nil)
+	pages.On("AddCollaborator", "page-1", "owner", "dave", entity.RoleEditor).Return(&entity.PageCollaborator{}, nil)
+	invites.On("Upsert", mock.Anything).Return(nil)
+	m.On("Send", mock.Anything).Return(nil)
+	uc := NewInviteUseCase(invites, users, pages, m, "")
+
+	invite, err := uc.Create("page-1", "owner", "dave@example.com", entity.RoleEditor)
+	assert.NoError(t, err)
+	assert.Equal(t, "dave", invite.AcceptedBy)
+	assert.NotNil(t, invite.AcceptedAt)
+	pages.AssertCalled(t, "AddCollaborator", "page-1", "owner", "dave", entity.RoleEditor)
+	invites.AssertNotCalled(t, "CountPending", mock.Anything, mock.Anything)
+}
+
+func TestInviteUseCase_CreateMailFailure(t *testing.T) {
+	// 测试场景：邮件发送失败时邀请仍然保存，返回邀请和 ErrMailDelivery
+
+	invites, users, pages, m := newInviteFixture()
+	users.On("GetByEmail", "carol@example.com").Return(nil, nil)
+	invites.On("CountPending", "page-1", mock.Anything).Return(int64(0), nil)
+	invites.On("Upsert", mock.Anything).Return(nil)
+	m.On("Send", mock.Anything).Return(errors.New("connection refused"))
+	uc := NewInviteUseCase(invites, users, pages, m, "")
+
+	invite, err := uc.Create("page-1", "owner", "carol@example.com", entity.RoleEditor)
+	assert.ErrorIs(t, err, domainErrors.ErrMailDelivery)
+	assert.NotNil(t, invite)
+	invites.AssertCalled(t, "Upsert", invite)
+}
+
+func TestInviteUseCase_Revoke(t *testing.T) {
+	// 测试场景：撤销成功；邀请不存在时返回 ErrInviteNotFound
+
+	invites, users, pages, m := newInviteFixture()
+	invites.On("Delete", "page-1", uint(1)).Return(true, nil)
+	invites.On("Delete", "page-1", uint(2)).Return(false, nil)
+	uc := NewInviteUseCase(invites, users, pages, m, "")
+
+	assert.NoError(t, uc.Revoke("page-1", 1))
+	assert.ErrorIs(t, uc.Revoke("page-1", 2), domainErrors.ErrInviteNotFound)
+}
+
+func TestInviteUseCase_AcceptForUser(t *testing.T) {
+	// 测试场景：新用户的已验证邮箱有待接受邀请时，以邀请人的名义添加为协作者并记录接受；
+	// 已有更高权限时只记录接受，不降级；邀请人已不再是所有者时跳过且不添加协作者，不影响其他邀请
+
+	invites, users, pages, m := newInviteFixture()
+	invites.On("ListPendingByEmail", "erin@example.com", mock.Anything).Return([]*entity.PageInvite{
+		{ID: 1, PageID: "page-1", Email: "erin@example.com", Role: entity.RoleEditor, InvitedBy: "owner"},
+		{ID: 2, PageID: "page-2", Email: "erin@example.com", Role: entity.RoleViewer, InvitedBy: "owner"},
+		{ID: 3, PageID: "page-3", Email: "erin@example.com", Role: entity.RoleEditor, InvitedBy: "former"},
+	}, nil)
+	invites.On("MarkAccepted", mock.Anything, "erin", mock.Anything).Return(nil)
+	pages.On("PageRole", "page-2", "owner").Return(entity.RoleOwner, nil)
+	pages.On("PageRole", "page-3", "former").Return(entity.RoleEditor, nil)
+	pages.On("PageRole", "page-1", "erin").Return("", domainErrors.ErrUnauthorized)
+	pages.On("PageRole", "page-2", "erin").Return(entity.RoleEditor, nil)
+	pages.On("AddCollaborator", "page-1", "owner", "erin", entity.RoleEditor).Return(&entity.PageCollaborator{}, nil)
+	uc := NewInviteUseCase(invites, users, pages, m, "")
+
+	accepted, err := uc.AcceptForUser("erin", []string{"Erin@Example.com", "not-an-email"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, accepted)
+	invites.AssertCalled(t, "MarkAccepted", uint(1), "erin", mock.Anything)
+	invites.AssertCalled(t, "MarkAccepted", uint(2), "erin", mock.Anything)
+	invites.AssertNotCalled(t, "MarkAccepted", uint(3), "erin", mock.Anything)
+	pages.AssertNotCalled(t, "AddCollaborator", "page-2", mock.Anything, mock.Anything, mock.Anything)
+	pages.AssertNotCalled(t, "AddCollaborator", "page-3", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,337 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/jsondiff"
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/merge"
+
+	"gorm.io/datatypes"
+)
+
+// MergePreview 合并请求的差异预览
+type MergePreview struct {
+	BranchMerge
+	Diff []jsondiff.Operation // 按所选策略合并时将应用到页面的 Patch（RFC 6902）
+}
+
+// MergeRequestUseCase 草稿分支合并请求业务逻辑。
+// 分支所有者发起合并请求后，页面的协作者都可以查看差异、参与讨论，编辑者可以按策略合并；
+// 合并复用 BranchUseCase 的合并流程，合并产生的版本另存一份快照，可通过版本对比查看
+type MergeRequestUseCase struct {
+	mrs         repository.MergeRequestRepository
+	branches    *BranchUseCase
+	versionRepo repository.PageVersionRepository
+}
+
+// NewMergeRequestUseCase 创建 MergeRequestUseCase 实例
+func NewMergeRequestUseCase(mrs repository.MergeRequestRepository, branches *BranchUseCase, versionRepo repository.PageVersionRepository) *MergeRequestUseCase {
+	return &MergeRequestUseCase{mrs: mrs, branches: branches, versionRepo: versionRepo}
+}
+
+// Open 为用户自己的分支发起合并请求，每个分支同时只能有一个打开的合并请求
+func (uc *MergeRequestUseCase) Open(pageID, branchID, userID, title, description string) (*entity.MergeRequest, *MergePreview, error) {
+	title = strings.TrimSpace(title)
+	description = strings.TrimSpace(description)
+	if title == "" || utf8.RuneCountInString(title) > entity.MaxMergeRequestTitleLength {
+		return nil, nil, fmt.Errorf("%w: 标题不能为空且不超过 %d 个字符", domainErrors.ErrInvalidMergeRequest, entity.MaxMergeRequestTitleLength)
+	}
+	if utf8.RuneCountInString(description) > entity.MaxMergeRequestDescriptionLength {
+		return nil, nil, fmt.Errorf("%w: 描述不超过 %d 个字符", domainErrors.ErrInvalidMergeRequest, entity.MaxMergeRequestDescriptionLength)
+	}
+
+	branch, err := uc.branches.ownBranch(pageID, branchID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := uc.branches.requireRole(pageID, userID, true); err != nil {
+		return nil, nil, err
+	}
+	existing, err := uc.mrs.GetOpenByBranch(branch.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if existing != nil {
+		return nil, nil, domainErrors.ErrMergeRequestExists
+	}
+
+	preview, err := uc.preview(branch, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mr := &entity.MergeRequest{
+		PageID:       pageID,
+		BranchID:     branch.ID,
+		BranchPageID: branch.BranchPageID,
+		BranchName:   branch.Name,
+		AuthorID:     userID,
+		Title:        title,
+		Description:  description,
+		Status:       entity.MergeRequestOpen,
+	}
+	if err := uc.mrs.Create(mr); err != nil {
+		return nil, nil, err
+	}
+	return mr, preview, nil
+}
+
+// List 按创建时间降序返回页面的合并请求，status 为空时返回全部，能读取页面的用户都可以查看
+func (uc *MergeRequestUseCase) List(pageID, userID, status string) ([]*entity.MergeRequest, error) {
+	switch status {
+	case "", entity.MergeRequestOpen, entity.MergeRequestMerged, entity.MergeRequestClosed:
+	default:
+		return nil, fmt.Errorf("%w: 不支持的状态 %q", domainErrors.ErrInvalidMergeRequest, status)
+	}
+	if err := uc.branches.requireRole(pageID, userID, false); err != nil {
+		return nil, err
+	}
+
+	mrs, err := uc.mrs.ListByPage(pageID, status)
+	if err != nil {
+		return nil, err
+	}
+	if mrs == nil {
+		mrs = []*entity.MergeRequest{}
+	}
+	return mrs, nil
+}
+
+// Get 读取合并请求；仍打开时附带按 strategy 合并的差异预览（strategy 为空时冲突位置保留页面的值）
+func (uc *MergeRequestUseCase) Get(pageID string, id uint, userID, strategy string) (*entity.MergeRequest, *MergePreview, error) {
+	if strategy == entity.MergeStrategyManual || !entity.ValidMergeStrategy(strategy) {
+		return nil, nil, fmt.Errorf("%w: 预览只支持 ours / theirs 策略", domainErrors.ErrInvalidMergeRequest)
+	}
+	mr, err := uc.mergeRequest(pageID, id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if mr.Status != entity.MergeRequestOpen {
+		return mr, nil, nil
+	}
+
+	branch, err := uc.branch(mr)
+	if err != nil {
+		return nil, nil, err
+	}
+	preview, err := uc.preview(branch, strategy)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mr, preview, nil
+}
+
+// Merge 由页面所有者或编辑者按策略合并，合并成功后分支被删除。
+// strategy 为空且存在冲突时不做任何修改，返回冲突位置和 ErrBranchConflict；
+// manual 时 patch 为评审者基于页面 version 版本解决冲突后的 Patch
+func (uc *MergeRequestUseCase) Merge(pageID string, id uint, userID, strategy string, patch json.RawMessage, version int64) (*entity.MergeRequest, *BranchMerge, error) {
+	if !entity.ValidMergeStrategy(strategy) {
+		return nil, nil, fmt.Errorf("%w: 不支持的合并策略 %q", domainErrors.ErrInvalidMergeRequest, strategy)
+	}
+	if strategy == entity.MergeStrategyManual {
+		var ops []json.RawMessage
+		if version <= 0 || json.Unmarshal(patch, &ops) != nil || len(ops) == 0 {
+			return nil, nil, fmt.Errorf("%w: manual 策略需要非空的 patch 和页面版本 version", domainErrors.ErrInvalidMergeRequest)
+		}
+	}
+
+	mr, err := uc.mergeRequest(pageID, id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := uc.branches.requireRole(pageID, userID, true); err != nil {
+		return nil, nil, err
+	}
+	if mr.Status != entity.MergeRequestOpen {
+		return nil, nil, domainErrors.ErrMergeRequestNotOpen
+	}
+	branch, err := uc.branch(mr)
+	if err != nil {
+		return nil, nil, err
+	}
+	branchPage, err := uc.branches.branchPage(branch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merged, state, err := uc.branches.mergeInto(branch, branchPage, userID, strategy, patch, version)
+	if err != nil {
+		return nil, merged, err
+	}
+
+	now := time.Now()
+	mr.Status = entity.MergeRequestMerged
+	mr.Strategy = strategy
+	mr.MergedBy = userID
+	mr.BaseVersion = merged.PageVersion
+	mr.MergedVersion = merged.PageVersion
+	mr.MergedAt = &now
+	if state != nil {
+		mr.BaseVersion = merged.PageVersion - 1
+		// 合并产生的版本另存快照，版本对比和回看不依赖房间之后的刷盘节奏
+		checkpoint := &entity.PageVersion{PageID: pageID, Version: merged.PageVersion, Schema: datatypes.JSON(state)}
+		if err := uc.versionRepo.SaveCheckpoint(checkpoint); err != nil {
+			logging.Warnf("[MergeRequest] 保存页面 %s 合并版本 %d 的快照失败: %v", pageID, merged.PageVersion, err)
+		}
+	}
+	finished, err := uc.mrs.Finish(mr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !finished {
+		return nil, nil, domainErrors.ErrMergeRequestNotOpen
+	}
+
+	if err := uc.branches.discard(branch); err != nil {
+		return nil, nil, err
+	}
+	return mr, merged, nil
+}
+
+// Close 关闭合并请求而不合并，合并请求的发起者和页面所有者可以操作；分支保留，可以再次发起
+func (uc *MergeRequestUseCase) Close(pageID string, id uint, userID string) (*entity.MergeRequest, error) {
+	mr, err := uc.mergeRequest(pageID, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mr.AuthorID != userID {
+		role, err := uc.branches.pages.PageRole(pageID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if role != entity.RoleOwner {
+			return nil, domainErrors.ErrUnauthorized
+		}
+	}
+	if mr.Status != entity.MergeRequestOpen {
+		return nil, domainErrors.ErrMergeRequestNotOpen
+	}
+
+	now := time.Now()
+	mr.Status = entity.MergeRequestClosed
+	mr.ClosedAt = &now
+	finished, err := uc.mrs.Finish(mr)
+	if err != nil {
+		return nil, err
+	}
+	if !finished {
+		return nil, domainErrors.ErrMergeRequestNotOpen
+	}
+	return mr, nil
+}
+
+// Comment 在合并请求下发表讨论，能读取页面的用户都可以参与
+func (uc *MergeRequestUseCase) Comment(pageID string, id uint, userID, text string) (*entity.MergeRequestComment, error) {
+	text, ok := normalizeCommentText(text)
+	if !ok {
+		return nil, fmt.Errorf("%w: 评论不能为空且不超过 %d 个字符", domainErrors.ErrInvalidMergeRequest, CommentMaxLength)
+	}
+	mr, err := uc.mergeRequest(pageID, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &entity.MergeRequestComment{
+		MergeRequestID: mr.ID,
+		PageID:         pageID,
+		AuthorID:       userID,
+		Text:           text,
+	}
+	if err := uc.mrs.CreateComment(comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// Comments 按时间升序返回合并请求的讨论
+func (uc *MergeRequestUseCase) Comments(pageID string, id uint, userID string) ([]*entity.MergeRequestComment, error) {
+	mr, err := uc.mergeRequest(pageID, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := uc.mrs.ListComments(mr.ID)
+	if err != nil {
+		return nil, err
+	}
+	if comments == nil {
+		comments = []*entity.MergeRequestComment{}
+	}
+	return comments, nil
+}
+
+// mergeRequest 校验用户能读取页面后读取合并请求
+func (uc *MergeRequestUseCase) mergeRequest(pageID string, id uint, userID string) (*entity.MergeRequest, error) {
+	if err := uc.branches.requireRole(pageID, userID, false); err != nil {
+		return nil, err
+	}
+	mr, err := uc.mrs.Get(pageID, id)
+	if err != nil {
+		return nil, err
+	}
+	if mr == nil {
+		return nil, domainErrors.ErrMergeRequestNotFound
+	}
+	return mr, nil
+}
+
+// branch 读取合并请求对应的分支，不要求是当前用户的分支
+func (uc *MergeRequestUseCase) branch(mr *entity.MergeRequest) (*entity.PageBranch, error) {
+	branch, err := uc.branches.branches.Get(mr.PageID, mr.BranchID)
+	if err != nil {
+		return nil, err
+	}
+	if branch == nil {
+		return nil, domainErrors.ErrBranchNotFound
+	}
+	return branch, nil
+}
+
+// preview 以页面和分支的最新状态计算合并预览，只读取，不创建房间
+func (uc *MergeRequestUseCase) preview(branch *entity.PageBranch, strategy string) (*MergePreview, error) {
+	page, err := uc.branches.pages.ReadPage(branch.PageID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, domainErrors.ErrPageNotFound
+	}
+	branchPage, err := uc.branches.branchPage(branch)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := merge.ThreeWay(branch.BaseSchema, page.Schema, branchPage.Schema)
+	if err != nil {
+		return nil, err
+	}
+	target := result.Merged
+	if strategy == entity.MergeStrategyTheirs {
+		target = result.Theirs
+	}
+	diff, err := jsondiff.Diff(page.Schema, target)
+	if err != nil {
+		return nil, err
+	}
+	if diff == nil {
+		diff = []jsondiff.Operation{}
+	}
+
+	return &MergePreview{
+		BranchMerge: BranchMerge{
+			PageVersion:   page.Version,
+			BranchVersion: branchPage.Version,
+			Changes:       result.Changes,
+			Conflicts:     result.Conflicts,
+		},
+		Diff: diff,
+	}, nil
+}
@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/ws"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ========== MergeRequestUseCase 单元测试 ==========
+
+// 页面和分支在分叉后都修改了组件 1 的 desc，分支另外修改了组件 2 的 props
+var (
+	mrPageSchema = []byte(`{"rootId": 1, "components": {
+		"1": {"id": 1, "name": "Page", "desc": "首页", "children": [2]},
+		"2": {"id": 2, "name": "Button", "desc": "按钮", "parentId": 1, "props": {"text": "a"}}
+	}}`)
+	mrBranchSchema = []byte(`{"rootId": 1, "components": {
+		"1": {"id": 1, "name": "Page", "desc": "草稿", "children": [2]},
+		"2": {"id": 2, "name": "Button", "desc": "按钮", "parentId": 1, "props": {"text": "b"}}
+	}}`)
+)
+
+func newMergeRequestFixture() (*MockMergeRequestRepository, *MockBranchRepository, *MockBranchPages) {
+	mrs := new(MockMergeRequestRepository)
+	branches := new(MockBranchRepository)
+	branches.On("Get", "page-1", "b1").Return(&entity.PageBranch{
+		ID: "b1", PageID: "page-1", BranchPageID: "branch-b1", OwnerID: "alice", Name: "新布局", BaseSchema: branchBaseSchema,
+	}, nil)
+	pages := new(MockBranchPages)
+	pages.On("PageRole", "page-1", "alice").Return(entity.RoleEditor, nil)
+	pages.On("PageRole", "page-1", "bob").Return(entity.RoleViewer, nil)
+	pages.On("PageRole", "page-1", "owner").Return(entity.RoleOwner, nil)
+	pages.On("ReadPage", "page-1").Return(&entity.Page{PageID: "page-1", Schema: mrPageSchema, Version: 5}, nil)
+	pages.On("ReadPage", "branch-b1").Return(&entity.Page{PageID: "branch-b1", Schema: mrBranchSchema, Version: 3}, nil)
+	return mrs, branches, pages
+}
+
+func TestMergeRequestUseCase_Open(t *testing.T) {
+	// 测试场景：标题为空、分支属于他人、分支已有打开的合并请求时拒绝；
+	// 成功时返回差异预览：分支修改的位置、冲突位置，以及冲突保留页面值时将应用的 Patch
+
+	mrs, branches, pages := newMergeRequestFixture()
+	mrs.On("GetOpenByBranch", "b1").Return(nil, nil).Once()
+	mrs.On("GetOpenByBranch", "b1").Return(&entity.MergeRequest{ID: 1}, nil)
+	mrs.On("Create", mock.Anything).Return(nil)
+	uc := NewMergeRequestUseCase(mrs, NewBranchUseCase(branches, nil, pages, nil), nil)
+
+	_, _, err := uc.Open("page-1", "b1", "alice", " ", "")
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidMergeRequest)
+	_, _, err = uc.Open("page-1", "b1", "bob", "新版首页", "")
+	assert.ErrorIs(t, err, domainErrors.ErrBranchNotFound)
+
+	mr, preview, err := uc.Open("page-1", "b1", "alice", " 新版首页 ", "按钮文案调整")
+	assert.NoError(t, err)
+	assert.Equal(t, "新版首页", mr.Title)
+	assert.Equal(t, entity.MergeRequestOpen, mr.Status)
+	assert.Equal(t, "branch-b1", mr.BranchPageID)
+	assert.Equal(t, "新布局", mr.BranchName)
+	assert.Equal(t, int64(5), preview.PageVersion)
+	assert.Equal(t, []string{"/components/1/desc", "/components/2/props"}, preview.Changes)
+	assert.Equal(t, []string{"/components/1/desc"}, preview.Conflicts)
+	if assert.Len(t, preview.Diff, 1) {
+		assert.Equal(t, "/components/2/props/text", preview.Diff[0].Path)
+	}
+
+	_, _, err = uc.Open("page-1", "b1", "alice", "再次发起", "")
+	assert.ErrorIs(t, err, domainErrors.ErrMergeRequestExists)
+}
+
+func TestMergeRequestUseCase_Merge(t *testing.T) {
+	// 测试场景：只读协作者不能合并；未指定策略且存在冲突时拒绝并返回冲突位置；
+	// theirs 策略下冲突位置采用分支的值，合并版本另存快照，合并请求记录合并前后版本，分支被删除
+
+	var saved []byte
+	mockPageService := new(MockPageService)
+	mockPageService.On("GetPageState", "page-1").Return(mrPageSchema, int64(5), nil)
+	mockPageService.On("SavePageState", "page-1", mock.Anything, int64(5), int64(6)).
+		Run(func(args mock.Arguments) { saved = args.Get(1).([]byte) }).Return(nil).Once()
+	hub := ws.NewHub(mockPageService)
+	go hub.Run()
+
+	mrs, branches, pages := newMergeRequestFixture()
+	open := &entity.MergeRequest{ID: 1, PageID: "page-1", BranchID: "b1", AuthorID: "alice", Status: entity.MergeRequestOpen}
+	mrs.On("Get", "page-1", uint(1)).Return(open, nil)
+	mrs.On("Finish", mock.Anything).Return(true, nil)
+	pageRepo := new(MockPageRepository)
+	pageRepo.On("Delete", "branch-b1").Return(nil)
+	versions := new(MockPageVersionRepository)
+	versions.On("SaveCheckpoint", mock.Anything).Return(nil)
+	uc := NewMergeRequestUseCase(mrs, NewBranchUseCase(branches, pageRepo, pages, hub), versions)
+
+	_, _, err := uc.Merge("page-1", 1, "bob", entity.MergeStrategyTheirs, nil, 0)
+	assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+	_, _, err = uc.Merge("page-1", 1, "alice", "rebase", nil, 0)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidMergeRequest)
+
+	_, merged, err := uc.Merge("page-1", 1, "alice", "", nil, 0)
+	assert.ErrorIs(t, err, domainErrors.ErrBranchConflict)
+	assert.Equal(t, []string{"/components/1/desc"}, merged.Conflicts)
+	mrs.AssertNotCalled(t, "Finish", mock.Anything)
+
+	mr, merged, err := uc.Merge("page-1", 1, "owner", entity.MergeStrategyTheirs, nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), merged.PageVersion)
+	assert.Equal(t, entity.MergeRequestMerged, mr.Status)
+	assert.Equal(t, entity.MergeStrategyTheirs, mr.Strategy)
+	assert.Equal(t, "owner", mr.MergedBy)
+	assert.Equal(t, int64(5), mr.BaseVersion)
+	assert.Equal(t, int64(6), mr.MergedVersion)
+	pageRepo.AssertCalled(t, "Delete", "branch-b1")
+
+	var checkpoint *entity.PageVersion
+	versions.AssertCalled(t, "SaveCheckpoint", mock.MatchedBy(func(v *entity.PageVersion) bool {
+		checkpoint = v
+		return v.PageID == "page-1" && v.Version == 6
+	}))
+
+	// 快照与房间刷盘的状态一致：冲突位置取分支的值，编辑归属记在合并者名下
+	assert.Eventually(t, func() bool { return hub.GetRoom("page-1") == nil }, time.Second, 10*time.Millisecond)
+	assert.JSONEq(t, string(saved), string(checkpoint.Schema))
+	var state struct {
+		Components map[string]struct {
+			Desc  string         `json:"desc"`
+			Props map[string]any `json:"props"`
+		} `json:"components"`
+		EditedBy map[string]struct {
+			UserID string `json:"userId"`
+		} `json:"editedBy"`
+	}
+	assert.NoError(t, json.Unmarshal(saved, &state))
+	assert.Equal(t, "草稿", state.Components["1"].Desc)
+	assert.Equal(t, "b", state.Components["2"].Props["text"])
+	assert.Equal(t, "owner", state.EditedBy["2"].UserID)
+
+	// 已合并的合并请求不能再次合并
+	_, _, err = uc.Merge("page-1", 1, "alice", entity.MergeStrategyOurs, nil, 0)
+	assert.ErrorIs(t, err, domainErrors.ErrMergeRequestNotOpen)
+}
+
+func TestMergeRequestUseCase_MergeManual(t *testing.T) {
+	// 测试场景：manual 策略必须提供非空 Patch 和页面版本；版本与页面不符时返回 ErrOptimisticLock，不重试
+
+	mockPageService := new(MockPageService)
+	mockPageService.On("GetPageState", "page-1").Return(mrPageSchema, int64(5), nil)
+	hub := ws.NewHub(mockPageService)
+	go hub.Run()
+
+	mrs, branches, pages := newMergeRequestFixture()
+	mrs.On("Get", "page-1", uint(1)).Return(&entity.MergeRequest{ID: 1, PageID: "page-1", BranchID: "b1", Status: entity.MergeRequestOpen}, nil)
+	uc := NewMergeRequestUseCase(mrs, NewBranchUseCase(branches, nil, pages, hub), nil)
+
+	_, _, err := uc.Merge("page-1", 1, "alice", entity.MergeStrategyManual, json.RawMessage(`[]`), 5)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidMergeRequest)
+	_, _, err = uc.Merge("page-1", 1, "alice", entity.MergeStrategyManual, json.RawMessage(`[{"op":"replace","path":"/components/1/desc","value":"x"}]`), 0)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidMergeRequest)
+
+	patch := json.RawMessage(`[{"op":"replace","path":"/components/1/desc","value":"首页草稿"}]`)
+	_, _, err = uc.Merge("page-1", 1, "alice", entity.MergeStrategyManual, patch, 4)
+	assert.ErrorIs(t, err, domainErrors.ErrOptimisticLock)
+	mrs.AssertNotCalled(t, "Finish", mock.Anything)
+	assert.Eventually(t, func() bool { return hub.GetRoom("page-1") == nil }, time.Second, 10*time.Millisecond)
+}
+
+func TestMergeRequestUseCase_CloseAndComment(t *testing.T) {
+	// 测试场景：只有发起者和页面所有者可以关闭，已关闭的不能再次关闭；
+	// 只读协作者也可以参与讨论，空评论被拒绝
+
+	mrs, branches, pages := newMergeRequestFixture()
+	mr := &entity.MergeRequest{ID: 1, PageID: "page-1", BranchID: "b1", AuthorID: "alice", Status: entity.MergeRequestOpen}
+	mrs.On("Get", "page-1", uint(1)).Return(mr, nil)
+	mrs.On("Get", "page-1", uint(2)).Return(nil, nil)
+	mrs.On("Finish", mock.Anything).Return(true, nil)
+	mrs.On("CreateComment", mock.Anything).Return(nil)
+	uc := NewMergeRequestUseCase(mrs, NewBranchUseCase(branches, nil, pages, nil), nil)
+
+	_, err := uc.Comment("page-1", 1, "bob", " ")
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidMergeRequest)
+	comment, err := uc.Comment("page-1", 1, "bob", " 看起来不错 ")
+	assert.NoError(t, err)
+	assert.Equal(t, "看起来不错", comment.Text)
+	assert.Equal(t, uint(1), comment.MergeRequestID)
+	_, err = uc.Comment("page-1", 2, "bob", "?")
+	assert.ErrorIs(t, err, domainErrors.ErrMergeRequestNotFound)
+
+	_, err = uc.Close("page-1", 1, "bob")
+	assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+
+	closed, err := uc.Close("page-1", 1, "owner")
+	assert.NoError(t, err)
+	assert.Equal(t, entity.MergeRequestClosed, closed.Status)
+	assert.NotNil(t, closed.ClosedAt)
+
+	_, err = uc.Close("page-1", 1, "alice")
+	assert.ErrorIs(t, err, domainErrors.ErrMergeRequestNotOpen)
+}
@@ -1,7 +1,13 @@
 package usecase
 
 import (
+	"context"
+	"time"
+
 	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/llm"
+	"lowercode-go-server/internal/mailer"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -22,6 +28,43 @@ func (m *MockPageRepository) GetByPageID(pageID string) (*entity.Page, error) {
 	return args.Get(0).(*entity.Page), args.Error(1)
 }
 
+func (m *MockPageRepository) GetAccessInfo(pageID string) (*entity.Page, error) {
+	args := m.Called(pageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Page), args.Error(1)
+}
+
+func (m *MockPageRepository) ListByCreator(creatorID string, limit int) ([]*entity.Page, error) {
+	args := m.Called(creatorID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Page), args.Error(1)
+}
+
+func (m *MockPageRepository) CountByCreator(creatorID string) (int64, error) {
+	args := m.Called(creatorID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPageRepository) ListByOrg(orgID string, limit int) ([]*entity.Page, error) {
+	args := m.Called(orgID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Page), args.Error(1)
+}
+
+func (m *MockPageRepository) BranchPageIDs(pageID string) ([]string, error) {
+	args := m.Called(pageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func (m *MockPageRepository) Create(page *entity.Page) error {
 	args := m.Called(page)
 	return args.Error(0)
@@ -32,32 +75,941 @@ func (m *MockPageRepository) UpdateSchema(pageID string, schema []byte, oldVersi
 	return args.Error(0)
 }
 
+func (m *MockPageRepository) Publish(pageID string, schema []byte, version int64) error {
+	args := m.Called(pageID, schema, version)
+	return args.Error(0)
+}
+
+func (m *MockPageRepository) SetLinkEdit(pageID string, enabled bool) error {
+	args := m.Called(pageID, enabled)
+	return args.Error(0)
+}
+
+func (m *MockPageRepository) SetChatPersisted(pageID string, enabled bool) error {
+	args := m.Called(pageID, enabled)
+	return args.Error(0)
+}
+
+func (m *MockPageRepository) SetCollabSettings(pageID string, settings entity.CollabSettings) error {
+	args := m.Called(pageID, settings)
+	return args.Error(0)
+}
+
 func (m *MockPageRepository) Delete(pageID string) error {
 	args := m.Called(pageID)
 	return args.Error(0)
 }
 
-// ========== MockPageService (用于 Hub) ==========
-// 因为 PageUseCase 需要真实的 Hub，而 Hub 需要 PageService
+// ========== MockUserRepository ==========
+// 实现 repository.UserRepository 接口
 
-type MockPageService struct {
+type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockPageService) GetPageState(pageID string) ([]byte, int64, error) {
+func (m *MockUserRepository) Upsert(user *entity.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateCursorColor(userID, color string) error {
+	args := m.Called(userID, color)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByID(userID string) (*entity.User, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(email string) (*entity.User, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+// newMockUserRepository 返回一个默认"用户已存在"的 MockUserRepository
+func newMockUserRepository() *MockUserRepository {
+	m := new(MockUserRepository)
+	m.On("GetByID", mock.Anything).Return(&entity.User{}, nil).Maybe()
+	return m
+}
+
+// ========== MockPageVersionRepository ==========
+// 实现 repository.PageVersionRepository 接口
+
+type MockPageVersionRepository struct {
+	mock.Mock
+}
+
+func (m *MockPageVersionRepository) GetByVersion(pageID string, version int64) (*entity.PageVersion, error) {
+	args := m.Called(pageID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.PageVersion), args.Error(1)
+}
+
+func (m *MockPageVersionRepository) ListPageIDsBefore(cutoff time.Time) ([]string, error) {
+	args := m.Called(cutoff)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockPageVersionRepository) ListMetaBefore(pageID string, cutoff time.Time) ([]*entity.PageVersion, error) {
+	args := m.Called(pageID, cutoff)
+	return args.Get(0).([]*entity.PageVersion), args.Error(1)
+}
+
+func (m *MockPageVersionRepository) DeleteByIDs(ids []uint) error {
+	args := m.Called(ids)
+	return args.Error(0)
+}
+
+func (m *MockPageVersionRepository) DeleteDeltasBefore(cutoff time.Time) (int64, error) {
+	args := m.Called(cutoff)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPageVersionRepository) SaveCheckpoint(v *entity.PageVersion) error {
+	args := m.Called(v)
+	return args.Error(0)
+}
+
+// ========== MockPageOpRepository ==========
+// 实现 repository.PageOpRepository 接口
+
+type MockPageOpRepository struct {
+	mock.Mock
+}
+
+func (m *MockPageOpRepository) CreateBatch(ops []*entity.PageOp) error {
+	args := m.Called(ops)
+	return args.Error(0)
+}
+
+func (m *MockPageOpRepository) ListSince(pageID string, sinceVersion int64, limit int) ([]*entity.PageOp, error) {
+	args := m.Called(pageID, sinceVersion, limit)
+	return args.Get(0).([]*entity.PageOp), args.Error(1)
+}
+
+func (m *MockPageOpRepository) DeleteBefore(cutoff time.Time) (int64, error) {
+	args := m.Called(cutoff)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPageOpRepository) ListOversized(maxOps int) ([]repository.JournalSize, error) {
+	args := m.Called(maxOps)
+	return args.Get(0).([]repository.JournalSize), args.Error(1)
+}
+
+func (m *MockPageOpRepository) DeleteAfter(pageID string, version int64) (int64, error) {
+	args := m.Called(pageID, version)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPageOpRepository) DeleteThrough(pageID string, version int64) (int64, error) {
+	args := m.Called(pageID, version)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPageOpRepository) CountLabels(pageID string, from, to int64) (map[string]int64, error) {
+	args := m.Called(pageID, from, to)
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+// ========== MockConsistencyRepository ==========
+// 实现 repository.ConsistencyRepository 接口
+
+type MockConsistencyRepository struct {
+	mock.Mock
+}
+
+func (m *MockConsistencyRepository) FindPagesBehindSnapshots() ([]repository.SnapshotAheadRow, error) {
+	args := m.Called()
+	return args.Get(0).([]repository.SnapshotAheadRow), args.Error(1)
+}
+
+func (m *MockConsistencyRepository) FindOrphanedSnapshots() ([]string, error) {
+	args := m.Called()
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockConsistencyRepository) FindOrphanedOps() ([]string, error) {
+	args := m.Called()
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockConsistencyRepository) GetPageVersions(pageIDs []string) (map[string]int64, error) {
+	args := m.Called(pageIDs)
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+// ========== MockCommentRepository ==========
+
+type MockCommentRepository struct {
+	mock.Mock
+}
+
+func (m *MockCommentRepository) Create(comment *entity.Comment) error {
+	args := m.Called(comment)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) GetByID(id uint) (*entity.Comment, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) ListByPage(pageID, componentID string) ([]*entity.Comment, error) {
+	args := m.Called(pageID, componentID)
+	return args.Get(0).([]*entity.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) UpdateText(id uint, text string) error {
+	args := m.Called(id, text)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) SetResolved(id uint, resolved bool, userID string) error {
+	args := m.Called(id, resolved, userID)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// ========== MockActivityRepository ==========
+// 实现 repository.ActivityRepository 接口
+
+type MockActivityRepository struct {
+	mock.Mock
+}
+
+func (m *MockActivityRepository) Record(activities []*entity.PageActivity) error {
+	args := m.Called(activities)
+	return args.Error(0)
+}
+
+func (m *MockActivityRepository) ListRecent(userID string, limit int) ([]repository.RecentPage, error) {
+	args := m.Called(userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.RecentPage), args.Error(1)
+}
+
+// ========== MockCollaboratorRepository ==========
+// 实现 repository.CollaboratorRepository 接口
+
+type MockCollaboratorRepository struct {
+	mock.Mock
+}
+
+func (m *MockCollaboratorRepository) Upsert(collaborator *entity.PageCollaborator) error {
+	args := m.Called(collaborator)
+	return args.Error(0)
+}
+
+func (m *MockCollaboratorRepository) Remove(pageID, userID string) (bool, error) {
+	args := m.Called(pageID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockCollaboratorRepository) GetRole(pageID, userID string) (string, error) {
+	args := m.Called(pageID, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCollaboratorRepository) ListByPage(pageID string) ([]*entity.PageCollaborator, error) {
 	args := m.Called(pageID)
 	if args.Get(0) == nil {
-		return nil, args.Get(1).(int64), args.Error(2)
+		return nil, args.Error(1)
 	}
-	return args.Get(0).([]byte), args.Get(1).(int64), args.Error(2)
+	return args.Get(0).([]*entity.PageCollaborator), args.Error(1)
 }
 
-func (m *MockPageService) PageExists(pageID string) (bool, error) {
+// ========== MockOrgMemberRepository ==========
+// 实现 OrgMemberRepository 接口
+
+type MockOrgMemberRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrgMemberRepository) Upsert(orgID, userID, role string) error {
+	args := m.Called(orgID, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockOrgMemberRepository) GetRole(orgID, userID string) (string, error) {
+	args := m.Called(orgID, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockOrgMemberRepository) Remove(orgID, userID string) error {
+	args := m.Called(orgID, userID)
+	return args.Error(0)
+}
+
+func (m *MockOrgMemberRepository) RemoveOrg(orgID string) error {
+	args := m.Called(orgID)
+	return args.Error(0)
+}
+
+// ========== MockAPIKeyRepository ==========
+// 实现 APIKeyRepository 接口
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Create(key *entity.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) GetByHash(hash string) (*entity.APIKey, error) {
+	args := m.Called(hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) ListByUser(userID string) ([]*entity.APIKey, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) CountActive(userID string, now time.Time) (int64, error) {
+	args := m.Called(userID, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Revoke(userID, id string, at time.Time) (bool, error) {
+	args := m.Called(userID, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Touch(id string, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+// ========== MockPageAccess ==========
+// 实现 PageAccess 接口
+
+type MockPageAccess struct {
+	mock.Mock
+}
+
+func (m *MockPageAccess) PageRole(pageID, userID string) (string, error) {
+	args := m.Called(pageID, userID)
+	return args.String(0), args.Error(1)
+}
+
+// ========== MockPageReader ==========
+// 实现 PageReader 接口
+
+type MockPageReader struct {
+	mock.Mock
+}
+
+func (m *MockPageReader) GetPage(pageID, userID string) (*entity.Page, error) {
+	args := m.Called(pageID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Page), args.Error(1)
+}
+
+// ========== MockShareLinkRepository ==========
+// 实现 ShareLinkRepository 接口
+
+type MockShareLinkRepository struct {
+	mock.Mock
+}
+
+func (m *MockShareLinkRepository) Create(link *entity.ShareLink) error {
+	args := m.Called(link)
+	return args.Error(0)
+}
+
+func (m *MockShareLinkRepository) Get(id string) (*entity.ShareLink, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.ShareLink), args.Error(1)
+}
+
+func (m *MockShareLinkRepository) ListByPage(pageID string) ([]*entity.ShareLink, error) {
 	args := m.Called(pageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.ShareLink), args.Error(1)
+}
+
+func (m *MockShareLinkRepository) Revoke(pageID, id string, at time.Time) (bool, error) {
+	args := m.Called(pageID, id, at)
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockPageService) SavePageState(pageID string, state []byte, oldVersion, newVersion int64) error {
-	args := m.Called(pageID, state, oldVersion, newVersion)
+// ========== MockConflictBackupRepository ==========
+// 实现 ConflictBackupRepository 接口
+
+type MockConflictBackupRepository struct {
+	mock.Mock
+}
+
+func (m *MockConflictBackupRepository) Save(backup *entity.ConflictBackup) error {
+	args := m.Called(backup)
+	return args.Error(0)
+}
+
+func (m *MockConflictBackupRepository) Get(pageID string, id uint) (*entity.ConflictBackup, error) {
+	args := m.Called(pageID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.ConflictBackup), args.Error(1)
+}
+
+func (m *MockConflictBackupRepository) ListByPage(pageID string) ([]*entity.ConflictBackup, error) {
+	args := m.Called(pageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.ConflictBackup), args.Error(1)
+}
+
+func (m *MockConflictBackupRepository) Delete(pageID string, id uint) (bool, error) {
+	args := m.Called(pageID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+// ========== MockSharedPageReader ==========
+// 实现 SharedPageReader 接口
+
+type MockSharedPageReader struct {
+	mock.Mock
+}
+
+func (m *MockSharedPageReader) ReadPage(pageID string) (*entity.Page, error) {
+	args := m.Called(pageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Page), args.Error(1)
+}
+
+func (m *MockSharedPageReader) PageRole(pageID, userID string) (string, error) {
+	args := m.Called(pageID, userID)
+	return args.String(0), args.Error(1)
+}
+
+// ========== MockBranchRepository ==========
+// 实现 BranchRepository 接口
+
+type MockBranchRepository struct {
+	mock.Mock
+}
+
+func (m *MockBranchRepository) Create(branch *entity.PageBranch, page *entity.Page) error {
+	args := m.Called(branch, page)
+	return args.Error(0)
+}
+
+func (m *MockBranchRepository) Get(pageID, id string) (*entity.PageBranch, error) {
+	args := m.Called(pageID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.PageBranch), args.Error(1)
+}
+
+func (m *MockBranchRepository) ListByOwner(pageID, ownerID string) ([]*entity.PageBranch, error) {
+	args := m.Called(pageID, ownerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.PageBranch), args.Error(1)
+}
+
+func (m *MockBranchRepository) CountByOwner(pageID, ownerID string) (int64, error) {
+	args := m.Called(pageID, ownerID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// ========== MockBranchPages ==========
+// 实现 BranchPages 接口
+
+type MockBranchPages struct {
+	mock.Mock
+}
+
+func (m *MockBranchPages) PageRole(pageID, userID string) (string, error) {
+	args := m.Called(pageID, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockBranchPages) ReadPage(pageID string) (*entity.Page, error) {
+	args := m.Called(pageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Page), args.Error(1)
+}
+
+func (m *MockBranchPages) EnsureUser(userID string) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+// ========== MockMergeRequestRepository ==========
+// 实现 MergeRequestRepository 接口
+
+type MockMergeRequestRepository struct {
+	mock.Mock
+}
+
+func (m *MockMergeRequestRepository) Create(mr *entity.MergeRequest) error {
+	args := m.Called(mr)
+	return args.Error(0)
+}
+
+func (m *MockMergeRequestRepository) Get(pageID string, id uint) (*entity.MergeRequest, error) {
+	args := m.Called(pageID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.MergeRequest), args.Error(1)
+}
+
+func (m *MockMergeRequestRepository) GetOpenByBranch(branchID string) (*entity.MergeRequest, error) {
+	args := m.Called(branchID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.MergeRequest), args.Error(1)
+}
+
+func (m *MockMergeRequestRepository) ListByPage(pageID, status string) ([]*entity.MergeRequest, error) {
+	args := m.Called(pageID, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.MergeRequest), args.Error(1)
+}
+
+func (m *MockMergeRequestRepository) Finish(mr *entity.MergeRequest) (bool, error) {
+	args := m.Called(mr)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMergeRequestRepository) CreateComment(comment *entity.MergeRequestComment) error {
+	args := m.Called(comment)
+	return args.Error(0)
+}
+
+func (m *MockMergeRequestRepository) ListComments(mergeRequestID uint) ([]*entity.MergeRequestComment, error) {
+	args := m.Called(mergeRequestID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.MergeRequestComment), args.Error(1)
+}
+
+// ========== MockInviteRepository ==========
+// 实现 InviteRepository 接口
+
+type MockInviteRepository struct {
+	mock.Mock
+}
+
+func (m *MockInviteRepository) Upsert(invite *entity.PageInvite) error {
+	args := m.Called(invite)
+	return args.Error(0)
+}
+
+func (m *MockInviteRepository) ListByPage(pageID string) ([]*entity.PageInvite, error) {
+	args := m.Called(pageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.PageInvite), args.Error(1)
+}
+
+func (m *MockInviteRepository) CountPending(pageID string, now time.Time) (int64, error) {
+	args := m.Called(pageID, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockInviteRepository) ListPendingByEmail(email string, now time.Time) ([]*entity.PageInvite, error) {
+	args := m.Called(email, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.PageInvite), args.Error(1)
+}
+
+func (m *MockInviteRepository) MarkAccepted(id uint, userID string, at time.Time) error {
+	args := m.Called(id, userID, at)
+	return args.Error(0)
+}
+
+func (m *MockInviteRepository) Delete(pageID string, id uint) (bool, error) {
+	args := m.Called(pageID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+// ========== MockInvitePages ==========
+// 实现 InvitePages 接口
+
+type MockInvitePages struct {
+	mock.Mock
+}
+
+func (m *MockInvitePages) PageRole(pageID, userID string) (string, error) {
+	args := m.Called(pageID, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockInvitePages) AddCollaborator(pageID, operatorID, userID, role string) (*entity.PageCollaborator, error) {
+	args := m.Called(pageID, operatorID, userID, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.PageCollaborator), args.Error(1)
+}
+
+// ========== MockMailer ==========
+// 实现 mailer.Mailer 接口
+
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(msg mailer.Message) error {
+	args := m.Called(msg)
+	return args.Error(0)
+}
+
+// ========== MockSecretPages ==========
+// 实现 SecretPages 接口
+
+type MockSecretPages struct {
+	mock.Mock
+}
+
+func (m *MockSecretPages) PageRole(pageID, userID string) (string, error) {
+	args := m.Called(pageID, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSecretPages) ReadPage(pageID string) (*entity.Page, error) {
+	args := m.Called(pageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Page), args.Error(1)
+}
+
+// ========== MockStorageRepository ==========
+// 实现 StorageRepository 接口
+
+type MockStorageRepository struct {
+	mock.Mock
+}
+
+func (m *MockStorageRepository) TenantUsage(tenantID string) (*entity.StorageUsage, error) {
+	args := m.Called(tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.StorageUsage), args.Error(1)
+}
+
+func (m *MockStorageRepository) ListTopTenants(limit int) ([]*entity.StorageUsage, error) {
+	args := m.Called(limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.StorageUsage), args.Error(1)
+}
+
+func (m *MockStorageRepository) GetPlan(tenantID string) (string, error) {
+	args := m.Called(tenantID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockStorageRepository) SetPlan(tenantID, plan string) error {
+	args := m.Called(tenantID, plan)
+	return args.Error(0)
+}
+
+func (m *MockStorageRepository) Recount(missingOnly bool) (int64, error) {
+	args := m.Called(missingOnly)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// ========== MockRevokedSessionRepository ==========
+// 实现 RevokedSessionRepository 接口
+
+type MockRevokedSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockRevokedSessionRepository) Create(revoked *entity.RevokedSession) error {
+	args := m.Called(revoked)
+	return args.Error(0)
+}
+
+func (m *MockRevokedSessionRepository) ListAfter(afterID uint, now time.Time) ([]*entity.RevokedSession, error) {
+	args := m.Called(afterID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.RevokedSession), args.Error(1)
+}
+
+func (m *MockRevokedSessionRepository) DeleteExpired(now time.Time) (int64, error) {
+	args := m.Called(now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// ========== MockPageReferenceRepository ==========
+// 实现 PageReferenceRepository 接口
+
+type MockPageReferenceRepository struct {
+	mock.Mock
+}
+
+func (m *MockPageReferenceRepository) ReplaceForPage(sourcePageID string, refs []*entity.PageReference) error {
+	args := m.Called(sourcePageID, refs)
+	return args.Error(0)
+}
+
+func (m *MockPageReferenceRepository) ListInbound(targetPageID string) ([]*entity.PageReference, error) {
+	args := m.Called(targetPageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.PageReference), args.Error(1)
+}
+
+func (m *MockPageReferenceRepository) UnindexedPages() ([]string, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockPageReferenceRepository) MissingPages(pageIDs []string) ([]string, error) {
+	args := m.Called(pageIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// ========== MockUserCleanupRepository ==========
+// 实现 UserCleanupRepository 接口
+
+type MockUserCleanupRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserCleanupRepository) OwnedPages(userID string) ([]*entity.Page, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Page), args.Error(1)
+}
+
+func (m *MockUserCleanupRepository) Successor(page *entity.Page, userID string) (string, error) {
+	args := m.Called(page.PageID, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserCleanupRepository) DeleteUser(plan *repository.UserDeletionPlan) error {
+	args := m.Called(plan)
+	return args.Error(0)
+}
+
+// ========== MockLLMProvider ==========
+// 实现 llm.Provider 接口
+
+type MockLLMProvider struct {
+	mock.Mock
+}
+
+func (m *MockLLMProvider) Complete(ctx context.Context, req llm.Request) (*llm.Response, error) {
+	args := m.Called(req)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return &llm.Response{Text: args.String(0), Usage: llm.Usage{PromptTokens: 100, CompletionTokens: 20, RequestBytes: 400, ResponseBytes: 80}}, nil
+}
+
+// ========== MockAIRepository ==========
+// 实现 AIRepository 接口
+
+type MockAIRepository struct {
+	mock.Mock
+}
+
+func (m *MockAIRepository) GetProvider(tenantID string) (*entity.AIProviderConfig, error) {
+	args := m.Called(tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.AIProviderConfig), args.Error(1)
+}
+
+func (m *MockAIRepository) SaveProvider(config *entity.AIProviderConfig) error {
+	args := m.Called(config)
+	return args.Error(0)
+}
+
+func (m *MockAIRepository) DeleteProvider(tenantID string) error {
+	args := m.Called(tenantID)
+	return args.Error(0)
+}
+
+func (m *MockAIRepository) RecordUsage(usage *entity.AIUsage) error {
+	args := m.Called(usage)
+	return args.Error(0)
+}
+
+func (m *MockAIRepository) ListUsage(tenantID string, since time.Time) ([]*entity.AIUsage, error) {
+	args := m.Called(tenantID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.AIUsage), args.Error(1)
+}
+
+// ========== MockPageService (用于 Hub) ==========
+// 因为 PageUseCase 需要真实的 Hub，而 Hub 需要 PageService
+
+type MockPageService struct {
+	mock.Mock
+}
+
+func (m *MockPageService) GetPageState(pageID string) ([]byte, int64, error) {
+	args := m.Called(pageID)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]byte), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPageService) PageExists(pageID string) (bool, error) {
+	args := m.Called(pageID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPageService) SavePageState(pageID string, state []byte, oldVersion, newVersion int64) error {
+	args := m.Called(pageID, state, oldVersion, newVersion)
+	return args.Error(0)
+}
+
+// ========== MockLLMStreamer ==========
+// 实现 llm.Provider 和 llm.Streamer 接口，流式输出时把回复按 chunkSize 个字节切分
+
+type MockLLMStreamer struct {
+	MockLLMProvider
+	chunkSize int
+}
+
+func (m *MockLLMStreamer) Stream(ctx context.Context, req llm.Request, onDelta func(delta string)) (*llm.Response, error) {
+	reply, err := m.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for text := reply.Text; text != ""; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n := min(m.chunkSize, len(text))
+		onDelta(text[:n])
+		text = text[n:]
+	}
+	return reply, nil
+}
+
+// ========== MockModerationRepository ==========
+// 实现 ModerationRepository 接口
+
+type MockModerationRepository struct {
+	mock.Mock
+}
+
+func (m *MockModerationRepository) CreateFlags(flags []*entity.ModerationFlag) error {
+	args := m.Called(flags)
+	return args.Error(0)
+}
+
+func (m *MockModerationRepository) ListFlags(filter repository.ModerationFlagFilter) ([]*entity.ModerationFlag, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.ModerationFlag), args.Error(1)
+}
+
+func (m *MockModerationRepository) MarkReviewed(id uint) (*entity.ModerationFlag, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.ModerationFlag), args.Error(1)
+}
+
+// ========== MockUsageRepository ==========
+// 实现 UsageRepository 接口
+
+type MockUsageRepository struct {
+	mock.Mock
+}
+
+func (m *MockUsageRepository) Consume(tenantID, kind string, period time.Time, limit int64) (int64, bool, error) {
+	args := m.Called(tenantID, kind, period, limit)
+	return args.Get(0).(int64), args.Bool(1), args.Error(2)
+}
+
+func (m *MockUsageRepository) ListUsage(tenantID string, period time.Time) ([]*entity.UsageCounter, error) {
+	args := m.Called(tenantID, period)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.UsageCounter), args.Error(1)
+}
+
+// ========== MockUsageMeter ==========
+// 实现 UsageMeter 接口
+
+type MockUsageMeter struct {
+	mock.Mock
+}
+
+func (m *MockUsageMeter) Consume(tenantID, kind string) error {
+	args := m.Called(tenantID, kind)
 	return args.Error(0)
 }
@@ -0,0 +1,217 @@
+package usecase
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/mailer"
+	"lowercode-go-server/internal/moderation"
+	"lowercode-go-server/internal/ratelimit"
+	"lowercode-go-server/internal/ws"
+)
+
+const (
+	// ModerationNotifyInterval 同一页面两封审核通知邮件的最小间隔，期间的命中只记录不通知
+	ModerationNotifyInterval = 10 * time.Minute
+
+	// DefaultModerationFlagLimit / MaxModerationFlagLimit 查询审核记录的默认条数与上限
+	DefaultModerationFlagLimit = 50
+	MaxModerationFlagLimit     = 200
+)
+
+// moderationMetrics 内容审核计数，通过 expvar 暴露：checked、flagged、rejected、errors（审核服务调用失败）、notified
+var moderationMetrics = expvar.NewMap("moderation")
+
+// ModerationPolicy 内容命中审核规则后的处理方式
+type ModerationPolicy struct {
+	Actions    moderation.Actions
+	FailClosed bool   // 审核服务不可用时拒绝提交，默认放行
+	PageURL    string // 通知邮件中的页面链接，{pageId} 替换为页面 ID
+}
+
+// ModerationUseCase 审核协同房间内的聊天消息和组件属性文本，实现 ws.ContentModerator。
+// 命中后按配置拒绝提交、保存审核记录供运维复核、邮件通知页面所有者。
+// 属性修改前已经命中的内容不重复处理，避免编辑同一段文本时每次输入都触发。
+// filter 为 nil（未配置词表和审核服务）时不审核，只提供已有审核记录的查询
+type ModerationUseCase struct {
+	filter   moderation.Filter
+	policy   ModerationPolicy
+	flags    repository.ModerationRepository
+	pages    repository.PageRepository
+	users    repository.UserRepository
+	mailer   mailer.Mailer
+	notifies *ratelimit.Limiter // 按页面限制通知邮件频率
+}
+
+// NewModerationUseCase 创建 ModerationUseCase 实例
+func NewModerationUseCase(filter moderation.Filter, policy ModerationPolicy, flags repository.ModerationRepository, pages repository.PageRepository, users repository.UserRepository, m mailer.Mailer) *ModerationUseCase {
+	return &ModerationUseCase{
+		filter:   filter,
+		policy:   policy,
+		flags:    flags,
+		pages:    pages,
+		users:    users,
+		mailer:   m,
+		notifies: ratelimit.NewLimiter(1/ModerationNotifyInterval.Seconds(), 1),
+	}
+}
+
+// Moderate 审核一次提交中的全部文本，开启 reject 且有文本命中时返回 ErrContentRejected
+func (uc *ModerationUseCase) Moderate(ctx context.Context, pageID string, author ws.UserInfo, texts []ws.ModeratedText) error {
+	if uc.filter == nil {
+		return nil
+	}
+	var hits []*entity.ModerationFlag
+	for _, text := range texts {
+		moderationMetrics.Add("checked", 1)
+		verdict, err := uc.filter.Check(ctx, text.Text)
+		if err != nil {
+			moderationMetrics.Add("errors", 1)
+			logging.Warnf("[Moderation] 页面 %s 的内容审核失败: %v", pageID, err)
+			if uc.policy.FailClosed {
+				return fmt.Errorf("%w: 审核服务不可用", domainErrors.ErrContentRejected)
+			}
+			continue
+		}
+		if !verdict.Flagged || uc.alreadyFlagged(ctx, text.Previous, verdict) {
+			continue
+		}
+		hits = append(hits, &entity.ModerationFlag{
+			PageID:   pageID,
+			UserID:   author.UserID,
+			UserName: author.UserName,
+			Guest:    author.Guest,
+			Source:   text.Source,
+			Path:     text.Path,
+			Text:     truncateRunes(text.Text, entity.MaxModerationFlagTextLength),
+			Reasons:  strings.Join(verdict.Reasons, ","),
+		})
+	}
+	if len(hits) == 0 {
+		return nil
+	}
+
+	moderationMetrics.Add("flagged", int64(len(hits)))
+	action := entity.ModerationActionAllowed
+	if uc.policy.Actions.Reject {
+		action = entity.ModerationActionRejected
+	}
+	for _, hit := range hits {
+		hit.Action = action
+	}
+
+	if uc.policy.Actions.Flag {
+		if err := uc.flags.CreateFlags(hits); err != nil {
+			logging.Errorf("[Moderation] 保存页面 %s 的审核记录失败: %v", pageID, err)
+		}
+	}
+	if uc.policy.Actions.Notify {
+		if allowed, _ := uc.notifies.Allow(pageID); allowed {
+			go uc.notifyOwner(pageID, hits)
+		}
+	}
+	log.Printf("[Moderation] 页面 %s 中用户 [%s] 提交的 %d 段内容命中审核规则，处理结果: %s",
+		pageID, author.UserName, len(hits), action)
+
+	if uc.policy.Actions.Reject {
+		moderationMetrics.Add("rejected", 1)
+		return fmt.Errorf("%w: %s", domainErrors.ErrContentRejected, hits[0].Reasons)
+	}
+	return nil
+}
+
+// alreadyFlagged 修改前的值已经命中全部相同原因时返回 true，只有新引入的内容才处理
+func (uc *ModerationUseCase) alreadyFlagged(ctx context.Context, previous string, verdict moderation.Verdict) bool {
+	if previous == "" {
+		return false
+	}
+	before, err := uc.filter.Check(ctx, previous)
+	if err != nil || !before.Flagged {
+		return false
+	}
+	for _, reason := range verdict.Reasons {
+		if !slices.Contains(before.Reasons, reason) {
+			return false
+		}
+	}
+	return true
+}
+
+// notifyOwner 邮件通知页面所有者，所有者没有邮箱时跳过，失败只记录日志
+func (uc *ModerationUseCase) notifyOwner(pageID string, hits []*entity.ModerationFlag) {
+	page, err := uc.pages.GetAccessInfo(pageID)
+	if err != nil || page == nil {
+		logging.Warnf("[Moderation] 读取页面 %s 失败，跳过审核通知: %v", pageID, err)
+		return
+	}
+	owner, err := uc.users.GetByID(page.CreatorID)
+	if err != nil || owner == nil || owner.Email == "" {
+		logging.Warnf("[Moderation] 页面 %s 的所有者没有可用邮箱，跳过审核通知", pageID)
+		return
+	}
+
+	if err := uc.mailer.Send(uc.notifyMessage(owner.Email, pageID, hits)); err != nil {
+		logging.Errorf("[Moderation] 发送页面 %s 的审核通知失败: %v", pageID, err)
+		return
+	}
+	moderationMetrics.Add("notified", 1)
+}
+
+// notifyMessage 生成审核通知邮件
+func (uc *ModerationUseCase) notifyMessage(to, pageID string, hits []*entity.ModerationFlag) mailer.Message {
+	var body strings.Builder
+	fmt.Fprintf(&body, "你的低代码页面 %s 中有内容命中了审核规则：\n\n", pageID)
+	for _, hit := range hits {
+		source := "聊天"
+		if hit.Source == ws.ModerationSourceProp {
+			source = "组件属性 " + hit.Path
+		}
+		result := "已拒绝"
+		if hit.Action == entity.ModerationActionAllowed {
+			result = "已保存，请检查"
+		}
+		fmt.Fprintf(&body, "- %s 在%s中提交（%s）：%s\n  命中：%s\n", hit.UserName, source, result, hit.Text, hit.Reasons)
+	}
+	if uc.policy.PageURL != "" {
+		fmt.Fprintf(&body, "\n打开页面：%s\n", strings.ReplaceAll(uc.policy.PageURL, "{pageId}", pageID))
+	}
+	fmt.Fprintf(&body, "\n同一页面每 %d 分钟最多发送一封通知。\n", int(ModerationNotifyInterval.Minutes()))
+
+	return mailer.Message{
+		To:      to,
+		Subject: fmt.Sprintf("页面 %s 有内容命中审核规则", pageID),
+		Body:    body.String(),
+	}
+}
+
+// ListFlags 查询审核记录，limit <= 0 时取 DefaultModerationFlagLimit，超过上限时截断
+func (uc *ModerationUseCase) ListFlags(filter repository.ModerationFlagFilter) ([]*entity.ModerationFlag, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultModerationFlagLimit
+	}
+	filter.Limit = min(filter.Limit, MaxModerationFlagLimit)
+	return uc.flags.ListFlags(filter)
+}
+
+// ReviewFlag 把审核记录标记为已复核
+func (uc *ModerationUseCase) ReviewFlag(id uint) (*entity.ModerationFlag, error) {
+	return uc.flags.MarkReviewed(id)
+}
+
+// truncateRunes 截断到 n 个字符
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/mailer"
+	"lowercode-go-server/internal/moderation"
+	"lowercode-go-server/internal/ws"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== ModerationUseCase 单元测试 ==========
+
+var moderationAuthor = ws.UserInfo{UserID: "user-2", UserName: "Bob", Guest: true}
+
+// failingModerationFilter 总是调用失败的过滤器，模拟审核服务不可用
+type failingModerationFilter struct{}
+
+func (failingModerationFilter) Check(context.Context, string) (moderation.Verdict, error) {
+	return moderation.Verdict{}, errors.New("unavailable")
+}
+
+func TestModerationUseCase_Reject(t *testing.T) {
+	// 测试场景：开启 reject 和 flag 时命中的内容被拒绝并保存为 rejected 记录；未命中时放行且不保存
+
+	flags := new(MockModerationRepository)
+	uc := NewModerationUseCase(moderation.NewWordlist([]string{"spam"}), ModerationPolicy{
+		Actions: moderation.Actions{Reject: true, Flag: true},
+	}, flags, nil, nil, nil)
+
+	var saved []*entity.ModerationFlag
+	flags.On("CreateFlags", mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(0).([]*entity.ModerationFlag)
+	}).Return(nil).Once()
+
+	err := uc.Moderate(context.Background(), "page-1", moderationAuthor, []ws.ModeratedText{
+		{Source: ws.ModerationSourceProp, Path: "/components/1/props/text", Text: "hello"},
+		{Source: ws.ModerationSourceProp, Path: "/components/2/props/text", Text: "buy SPAM"},
+	})
+	assert.ErrorIs(t, err, domainErrors.ErrContentRejected)
+	require.Len(t, saved, 1)
+	assert.Equal(t, "page-1", saved[0].PageID)
+	assert.Equal(t, "user-2", saved[0].UserID)
+	assert.True(t, saved[0].Guest)
+	assert.Equal(t, "/components/2/props/text", saved[0].Path)
+	assert.Equal(t, "buy SPAM", saved[0].Text)
+	assert.Equal(t, []string{"spam"}, saved[0].ReasonList())
+	assert.Equal(t, entity.ModerationActionRejected, saved[0].Action)
+
+	err = uc.Moderate(context.Background(), "page-1", moderationAuthor, []ws.ModeratedText{{Source: ws.ModerationSourceChat, Text: "hi"}})
+	assert.NoError(t, err)
+	flags.AssertNumberOfCalls(t, "CreateFlags", 1)
+}
+
+func TestModerationUseCase_Notify(t *testing.T) {
+	// 测试场景：只开启 notify 时命中的内容放行，邮件发给页面所有者；同一页面在间隔内只通知一次
+
+	pages := new(MockPageRepository)
+	users := new(MockUserRepository)
+	m := new(MockMailer)
+	uc := NewModerationUseCase(moderation.NewWordlist([]string{"spam"}), ModerationPolicy{
+		Actions: moderation.Actions{Notify: true},
+		PageURL: "https://app.example.com/?pageId={pageId}",
+	}, nil, pages, users, m)
+
+	pages.On("GetAccessInfo", "page-1").Return(&entity.Page{PageID: "page-1", CreatorID: "owner"}, nil)
+	users.On("GetByID", "owner").Return(&entity.User{ID: "owner", Email: "owner@example.com"}, nil)
+	sent := make(chan mailer.Message, 2)
+	m.On("Send", mock.Anything).Run(func(args mock.Arguments) {
+		sent <- args.Get(0).(mailer.Message)
+	}).Return(nil)
+
+	chat := []ws.ModeratedText{{Source: ws.ModerationSourceChat, Text: "spam"}}
+	assert.NoError(t, uc.Moderate(context.Background(), "page-1", moderationAuthor, chat))
+	assert.NoError(t, uc.Moderate(context.Background(), "page-1", moderationAuthor, chat))
+
+	select {
+	case msg := <-sent:
+		assert.Equal(t, "owner@example.com", msg.To)
+		assert.Contains(t, msg.Body, "Bob")
+		assert.Contains(t, msg.Body, "https://app.example.com/?pageId=page-1")
+	case <-time.After(time.Second):
+		t.Fatal("未发送审核通知")
+	}
+	select {
+	case <-sent:
+		t.Fatal("间隔内重复发送了审核通知")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestModerationUseCase_Previous(t *testing.T) {
+	// 测试场景：修改前已命中相同原因的属性不重复处理，新引入其他命中词时仍然拒绝
+
+	uc := NewModerationUseCase(moderation.NewWordlist([]string{"spam", "scam"}), ModerationPolicy{
+		Actions: moderation.Actions{Reject: true},
+	}, nil, nil, nil, nil)
+
+	err := uc.Moderate(context.Background(), "page-1", moderationAuthor, []ws.ModeratedText{
+		{Source: ws.ModerationSourceProp, Text: "spam!", Previous: "spam"},
+	})
+	assert.NoError(t, err)
+
+	err = uc.Moderate(context.Background(), "page-1", moderationAuthor, []ws.ModeratedText{
+		{Source: ws.ModerationSourceProp, Text: "spam scam", Previous: "spam"},
+	})
+	assert.ErrorIs(t, err, domainErrors.ErrContentRejected)
+}
+
+func TestModerationUseCase_FilterUnavailable(t *testing.T) {
+	// 测试场景：审核服务不可用时默认放行，开启 FailClosed 时拒绝
+
+	open := NewModerationUseCase(failingModerationFilter{}, ModerationPolicy{Actions: moderation.Actions{Reject: true}}, nil, nil, nil, nil)
+	chat := []ws.ModeratedText{{Source: ws.ModerationSourceChat, Text: "hi"}}
+	assert.NoError(t, open.Moderate(context.Background(), "page-1", moderationAuthor, chat))
+
+	closed := NewModerationUseCase(failingModerationFilter{}, ModerationPolicy{
+		Actions:    moderation.Actions{Reject: true},
+		FailClosed: true,
+	}, nil, nil, nil, nil)
+	assert.ErrorIs(t, closed.Moderate(context.Background(), "page-1", moderationAuthor, chat), domainErrors.ErrContentRejected)
+}
+
+func TestModerationUseCase_ListFlags(t *testing.T) {
+	// 测试场景：未指定条数时取默认值，超过上限时截断
+
+	flags := new(MockModerationRepository)
+	uc := NewModerationUseCase(moderation.NewWordlist(nil), ModerationPolicy{Actions: moderation.Actions{Flag: true}}, flags, nil, nil, nil)
+
+	flags.On("ListFlags", repository.ModerationFlagFilter{Pending: true, Limit: DefaultModerationFlagLimit}).Return([]*entity.ModerationFlag{}, nil).Once()
+	flags.On("ListFlags", repository.ModerationFlagFilter{PageID: "page-1", Limit: MaxModerationFlagLimit}).Return([]*entity.ModerationFlag{}, nil).Once()
+
+	_, err := uc.ListFlags(repository.ModerationFlagFilter{Pending: true})
+	assert.NoError(t, err)
+	_, err = uc.ListFlags(repository.ModerationFlagFilter{PageID: "page-1", Limit: 1000})
+	assert.NoError(t, err)
+	flags.AssertExpectations(t)
+}
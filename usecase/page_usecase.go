@@ -1,11 +1,26 @@
 package usecase
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"lowercode-go-server/domain/entity"
 	domainErrors "lowercode-go-server/domain/errors"
 	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/bulkops"
+	"lowercode-go-server/internal/jsondiff"
+	"lowercode-go-server/internal/legacy"
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/pageref"
+	"lowercode-go-server/internal/secretprop"
 	"lowercode-go-server/internal/ws"
 
 	"gorm.io/datatypes"
@@ -13,20 +28,152 @@ import (
 
 // PageUseCase 页面业务逻辑层
 type PageUseCase struct {
-	repo     repository.PageRepository
-	userRepo repository.UserRepository
-	hub      *ws.Hub
+	repo          repository.PageRepository
+	userRepo      repository.UserRepository
+	collaborators repository.CollaboratorRepository
+	orgMembers    repository.OrgMemberRepository
+	hub           *ws.Hub
+	secrets       *secretprop.Box                    // 可选，为 nil 时含密钥属性的页面不能发布
+	quota         StorageQuota                       // 可选，为 nil 时不检查存储配额
+	meter         UsageMeter                         // 可选，为 nil 时发布不计量
+	references    repository.PageReferenceRepository // 可选，为 nil 时发布不检查页面引用
+	deleteSecret  []byte                             // 可选，为 nil 时删除页面不需要确认
+	maxPages      int                                // 每个用户最多创建的页面数，0 表示不限
 }
 
 // NewPageUseCase 创建 PageUseCase 实例
-func NewPageUseCase(repo repository.PageRepository, userRepo repository.UserRepository, hub *ws.Hub) *PageUseCase {
-	return &PageUseCase{repo: repo, userRepo: userRepo, hub: hub}
+// orgMembers 为 nil 时不按组织成员关系授权，组织页面只有创建者和协作者可以访问
+func NewPageUseCase(repo repository.PageRepository, userRepo repository.UserRepository, collaborators repository.CollaboratorRepository, orgMembers repository.OrgMemberRepository, hub *ws.Hub) *PageUseCase {
+	return &PageUseCase{repo: repo, userRepo: userRepo, collaborators: collaborators, orgMembers: orgMembers, hub: hub}
 }
 
-// GetPage 获取页面
+// EnableSecrets 配置密钥属性的加密密钥，发布页面时校验、公开访问时解密其中的密钥属性
+func (uc *PageUseCase) EnableSecrets(box *secretprop.Box) {
+	uc.secrets = box
+}
+
+// EnableQuota 创建、导入和发布页面前检查租户的存储配额
+func (uc *PageUseCase) EnableQuota(quota StorageQuota) {
+	uc.quota = quota
+}
+
+// EnableUsageLimits 发布页面时按租户套餐计量，超出本月额度时拒绝发布
+func (uc *PageUseCase) EnableUsageLimits(meter UsageMeter) {
+	uc.meter = meter
+}
+
+// EnablePageQuota 限制每个用户最多创建的页面数（不含草稿分支），max 为 0 时不限
+func (uc *PageUseCase) EnablePageQuota(max int) {
+	uc.maxPages = max
+}
+
+// EnableDeleteConfirmation 删除有其他人在线或被其他页面引用的页面前要求确认，secret 为确认 Token 的签名密钥，
+// 多实例部署时各实例应相同
+func (uc *PageUseCase) EnableDeleteConfirmation(secret []byte) {
+	uc.deleteSecret = secret
+}
+
+// EnableReferences 发布页面前检查其中的页面引用，并支持查询页面的入站引用
+func (uc *PageUseCase) EnableReferences(references repository.PageReferenceRepository) {
+	uc.references = references
+}
+
+// OrgClaims 请求携带的 Clerk 组织声明（当前激活的组织），未激活组织时为空
+type OrgClaims struct {
+	OrgID   string
+	OrgRole string
+}
+
+// PageRole 返回用户在页面上的角色：创建者为 owner，组织页面的成员按组织角色（见 entity.PageRoleForOrgRole），
+// 协作者为其协作角色，同时满足多项时取权限最高的；
+// 页面开启"持有链接即可编辑"时，登录用户至少拥有与访客相同的编辑权限。
+// 页面不存在时返回 ErrPageNotFound，既不是创建者、组织成员也不是协作者时返回 ErrUnauthorized
+func (uc *PageUseCase) PageRole(pageID, userID string) (string, error) {
+	page, err := uc.repo.GetAccessInfo(pageID)
+	if err != nil {
+		return "", err
+	}
+	if page == nil {
+		return "", domainErrors.ErrPageNotFound
+	}
+	if page.CreatorID == userID {
+		return entity.RoleOwner, nil
+	}
+
+	role, err := uc.collaborators.GetRole(pageID, userID)
+	if err != nil {
+		return "", err
+	}
+	orgRole, err := uc.orgRole(page, userID)
+	if err != nil {
+		return "", err
+	}
+	if entity.RoleRank(orgRole) > entity.RoleRank(role) {
+		role = orgRole
+	}
+	if page.LinkEditEnabled && entity.RoleRank(role) < entity.RoleRank(entity.RoleEditor) {
+		return entity.RoleEditor, nil
+	}
+	if role == "" {
+		return "", domainErrors.ErrUnauthorized
+	}
+	return role, nil
+}
+
+// orgRole 返回用户因组织成员关系在页面上获得的角色，个人页面或非成员返回空字符串
+func (uc *PageUseCase) orgRole(page *entity.Page, userID string) (string, error) {
+	if page.OrgID == "" || uc.orgMembers == nil {
+		return "", nil
+	}
+	role, err := uc.orgMembers.GetRole(page.OrgID, userID)
+	if err != nil || role == "" {
+		return "", err
+	}
+	return entity.PageRoleForOrgRole(role), nil
+}
+
+// authorize 检查用户能否读取页面，edit 为 true 时还要求可以编辑
+func (uc *PageUseCase) authorize(pageID, userID string, edit bool) error {
+	role, err := uc.PageRole(pageID, userID)
+	if err != nil {
+		return err
+	}
+	if edit && role == entity.RoleViewer {
+		return domainErrors.ErrUnauthorized
+	}
+	return nil
+}
+
+// GetPage 获取页面，创建者、组织成员和协作者可以读取
 // 优先从 Hub 内存读取（保证读到最新协同状态），否则读数据库。
 // 使用只读的 GetRoom 不会创建房间，避免"观察者效应"。
-func (uc *PageUseCase) GetPage(pageID string) (*entity.Page, error) {
+// 非所有者读取时密钥属性只保留掩码
+func (uc *PageUseCase) GetPage(pageID, userID string) (*entity.Page, error) {
+	role, err := uc.PageRole(pageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	page, err := uc.ReadPage(pageID)
+	if err != nil || page == nil || role == entity.RoleOwner {
+		return page, err
+	}
+	return maskSecrets(page)
+}
+
+// maskSecrets 返回清空了密钥属性密文、只保留掩码的页面副本，不修改传入的页面
+func maskSecrets(page *entity.Page) (*entity.Page, error) {
+	schema, err := secretprop.Mask(page.Schema)
+	if err != nil {
+		return nil, err
+	}
+	masked := *page
+	masked.Schema = datatypes.JSON(schema)
+	return &masked, nil
+}
+
+// ReadPage 读取页面最新状态（协同房间内存优先），不校验访问权限，
+// 供已通过分享链接等方式校验过的调用方使用
+func (uc *PageUseCase) ReadPage(pageID string) (*entity.Page, error) {
 	// 优先从 Hub 内存读取
 	if room := uc.hub.GetRoom(pageID); room != nil {
 		snapshot, version := room.GetSnapshot()
@@ -41,11 +188,70 @@ func (uc *PageUseCase) GetPage(pageID string) (*entity.Page, error) {
 	return uc.repo.GetByPageID(pageID)
 }
 
+// GetPresence 获取页面当前的在线用户，只读取内存中的房间，不会创建房间。
+// 没有房间（无人在编辑）时返回空列表。
+func (uc *PageUseCase) GetPresence(pageID, userID string) ([]ws.UserInfo, error) {
+	if err := uc.authorize(pageID, userID, false); err != nil {
+		return nil, err
+	}
+
+	if room := uc.hub.GetRoom(pageID); room != nil {
+		if users := room.Users(); users != nil {
+			return users, nil
+		}
+	}
+	return []ws.UserInfo{}, nil
+}
+
+// PollPage 长轮询页面在 sinceVersion 之后的 Patch，供 WebSocket 不可用的客户端降级协同。
+// 等待期间没有新版本时返回 nil，ctx 决定最长等待时间。
+// 无人在线时房间只为本次轮询而创建，结束后交给 Hub 刷盘销毁。
+func (uc *PageUseCase) PollPage(ctx context.Context, pageID, userID string, sinceVersion int64) (*ws.PollResult, error) {
+	if err := uc.authorize(pageID, userID, false); err != nil {
+		return nil, err
+	}
+
+	room, err := uc.hub.GetOrCreateRoom(pageID)
+	if err != nil {
+		return nil, err
+	}
+	defer uc.hub.ReleaseIfIdle(room)
+
+	return room.Poll(ctx, sinceVersion), nil
+}
+
+// KickUser 将用户移出页面的协同房间，只有所有者可以操作，由路由上的 RequirePageRole 校验。
+// 用于清理占用组件锁的失效会话；被移出的用户仍可重新连接。
+// 用户不在线（或无人编辑）时返回 ErrUserNotInRoom。
+func (uc *PageUseCase) KickUser(pageID, targetUserID string) (int, error) {
+	page, err := uc.repo.GetByPageID(pageID)
+	if err != nil {
+		return 0, err
+	}
+	if page == nil {
+		return 0, domainErrors.ErrPageNotFound
+	}
+
+	room := uc.hub.GetRoom(pageID)
+	if room == nil {
+		return 0, domainErrors.ErrUserNotInRoom
+	}
+	kicked := room.Kick(targetUserID, "你已被页面创建者移出协同编辑")
+	if kicked == 0 {
+		return 0, domainErrors.ErrUserNotInRoom
+	}
+	return kicked, nil
+}
+
 // CreatePage 创建新页面
-// schemaBytes 可选，为 nil 时使用默认空白 schema
-func (uc *PageUseCase) CreatePage(pageID, creatorID string, schemaBytes []byte) (*entity.Page, error) {
+// schemaBytes 可选，为 nil 时使用默认空白 schema；
+// org 为请求激活的组织，非空时页面属于该组织，否则为创建者的个人页面
+func (uc *PageUseCase) CreatePage(pageID, creatorID string, org OrgClaims, schemaBytes []byte) (*entity.Page, error) {
 	// 确保用户存在（解决外键约束问题）
-	if err := uc.ensureUserExists(creatorID); err != nil {
+	if err := uc.EnsureUser(creatorID); err != nil {
+		return nil, err
+	}
+	if err := uc.SyncOrgMembership(creatorID, org); err != nil {
 		return nil, err
 	}
 
@@ -64,16 +270,758 @@ func (uc *PageUseCase) CreatePage(pageID, creatorID string, schemaBytes []byte)
 		Schema:    datatypes.JSON(schemaBytes),
 		Version:   1,
 		CreatorID: creatorID,
+		OrgID:     org.OrgID,
 	}
 
+	if err := uc.checkPageQuota(creatorID); err != nil {
+		return nil, err
+	}
+	// 新页面占用草稿和初始版本快照两份 Schema
+	if err := checkQuota(uc.quota, entity.TenantOf(page), 2*int64(len(schemaBytes))); err != nil {
+		return nil, err
+	}
 	if err := uc.repo.Create(page); err != nil {
 		return nil, err
 	}
+	uc.indexReferences(pageID, schemaBytes)
+	return page, nil
+}
+
+// PageQuota 用户的页面数配额
+type PageQuota struct {
+	Used  int64 // 已创建的页面数，包括组织页面，不含草稿分支
+	Limit int   // 最多可创建的页面数，0 表示不限
+}
+
+// Exceeded 页面数是否已达上限
+func (q *PageQuota) Exceeded() bool {
+	return q.Limit > 0 && q.Used >= int64(q.Limit)
+}
+
+// PageQuota 返回用户的页面数配额与用量
+func (uc *PageUseCase) PageQuota(userID string) (*PageQuota, error) {
+	used, err := uc.repo.CountByCreator(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &PageQuota{Used: used, Limit: uc.maxPages}, nil
+}
+
+// checkPageQuota 用户的页面数已达上限时返回 ErrQuotaExceeded。
+// 并发创建时可能略微超出上限，与存储配额一样不做强一致保证
+func (uc *PageUseCase) checkPageQuota(userID string) error {
+	if uc.maxPages <= 0 {
+		return nil
+	}
+	quota, err := uc.PageQuota(userID)
+	if err != nil {
+		return err
+	}
+	if quota.Exceeded() {
+		return fmt.Errorf("%w: 已创建 %d / %d 个页面", domainErrors.ErrQuotaExceeded, quota.Used, quota.Limit)
+	}
+	return nil
+}
+
+// 页面列表的条数
+const (
+	DefaultListPages = 50
+	MaxListPages     = 200
+)
+
+// ListPages 按最近更新时间倒序返回页面列表（不含 Schema）：
+// 请求激活了组织时返回该组织的页面，否则返回用户的个人页面。
+// limit <= 0 时取默认条数，超过上限时截断
+func (uc *PageUseCase) ListPages(userID string, org OrgClaims, limit int) ([]*entity.Page, error) {
+	if limit <= 0 {
+		limit = DefaultListPages
+	}
+	if limit > MaxListPages {
+		limit = MaxListPages
+	}
+
+	if org.OrgID == "" {
+		return uc.repo.ListByCreator(userID, limit)
+	}
+	if err := uc.SyncOrgMembership(userID, org); err != nil {
+		return nil, err
+	}
+	return uc.repo.ListByOrg(org.OrgID, limit)
+}
+
+// SyncOrgMembership 按请求携带的组织声明记录成员关系。
+// 声明由 Clerk 签发，可以证明用户当前属于该组织，避免等待 Webhook 期间无法访问组织页面；
+// 成员被移除或角色变化以 Webhook 为准
+func (uc *PageUseCase) SyncOrgMembership(userID string, org OrgClaims) error {
+	if org.OrgID == "" || uc.orgMembers == nil {
+		return nil
+	}
+	return uc.orgMembers.Upsert(org.OrgID, userID, org.OrgRole)
+}
+
+// PublishPage 将当前草稿发布为公开版本
+// 房间在线时发布内存中的最新状态，只有所有者可以发布，由路由上的 RequirePageRole 校验
+func (uc *PageUseCase) PublishPage(pageID string) (*entity.Page, error) {
+	page, err := uc.repo.GetByPageID(pageID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, domainErrors.ErrPageNotFound
+	}
+
+	schema, version := []byte(page.Schema), page.Version
+	if room := uc.hub.GetRoom(pageID); room != nil {
+		schema, version = room.GetSnapshot()
+	}
+	// 密钥属性在发布副本中仍以密文保存，公开访问时才解密；无法解密时拒绝发布
+	if _, err := uc.resolveSecrets(pageID, schema); err != nil {
+		return nil, err
+	}
+	if err := uc.checkReferences(schema); err != nil {
+		return nil, err
+	}
+	if err := checkQuota(uc.quota, entity.TenantOf(page), int64(len(schema)-len(page.PublishedSchema))); err != nil {
+		return nil, err
+	}
+	// 所有检查通过后才计量，被拒绝的发布不占用额度
+	if err := consumeUsage(uc.meter, entity.TenantOf(page), entity.UsageKindPublish); err != nil {
+		return nil, err
+	}
+
+	if err := uc.repo.Publish(pageID, schema, version); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	page.PublishedSchema = datatypes.JSON(schema)
+	page.PublishedVersion = version
+	page.PublishedAt = &now
+	return page, nil
+}
+
+// checkReferences 检查 Schema 中引用的页面是否都存在，存在失效的引用时返回 ErrDanglingReferences
+func (uc *PageUseCase) checkReferences(schema []byte) error {
+	if uc.references == nil {
+		return nil
+	}
+
+	refs, err := pageref.Find(schema)
+	if err != nil || len(refs) == 0 {
+		return err
+	}
+	missing, err := uc.references.MissingPages(pageref.Targets(refs))
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+
+	dangling := make(map[string]bool, len(missing))
+	for _, id := range missing {
+		dangling[id] = true
+	}
+	var details []string
+	for _, ref := range refs {
+		if dangling[ref.PageID] {
+			details = append(details, fmt.Sprintf("%s -> %s", ref.Path, ref.PageID))
+		}
+	}
+	return fmt.Errorf("%w: %s", domainErrors.ErrDanglingReferences, strings.Join(details, ", "))
+}
+
+// PageReferences 页面的出站引用（Schema 中引用的页面）和入站引用（引用了该页面的页面）
+type PageReferences struct {
+	Outbound      []OutboundReference `json:"outbound"`
+	Inbound       []InboundReference  `json:"inbound"`
+	HiddenInbound int                 `json:"hiddenInbound"` // 用户无权访问的来源页面中的入站引用数
+}
+
+// OutboundReference 页面中的一个引用
+type OutboundReference struct {
+	pageref.Ref
+	Dangling bool `json:"dangling"` // 目标页面不存在，修复前页面不能发布
+}
+
+// InboundReference 其他页面中指向该页面的一个引用
+type InboundReference struct {
+	SourcePageID string `json:"sourcePageId"`
+	Path         string `json:"path"`
+	ComponentID  int64  `json:"componentId,omitempty"`
+	Kind         string `json:"kind"` // link 或 embed
+}
+
+// References 查询页面的出站和入站引用，能读取页面的用户均可查询。
+// 出站引用取自页面最新状态（协同房间内存优先）；入站引用取自引用索引，
+// 在来源页面刷盘后才会更新，且只列出用户能读取的来源页面
+func (uc *PageUseCase) References(pageID, userID string) (*PageReferences, error) {
+	if uc.references == nil {
+		return nil, domainErrors.ErrReferencesDisabled
+	}
+	if err := uc.authorize(pageID, userID, false); err != nil {
+		return nil, err
+	}
+	page, err := uc.ReadPage(pageID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, domainErrors.ErrPageNotFound
+	}
+
+	refs, err := pageref.Find(page.Schema)
+	if err != nil {
+		return nil, err
+	}
+	missing, err := uc.references.MissingPages(pageref.Targets(refs))
+	if err != nil {
+		return nil, err
+	}
+	dangling := make(map[string]bool, len(missing))
+	for _, id := range missing {
+		dangling[id] = true
+	}
+	result := &PageReferences{
+		Outbound: make([]OutboundReference, len(refs)),
+		Inbound:  []InboundReference{},
+	}
+	for i, ref := range refs {
+		result.Outbound[i] = OutboundReference{Ref: ref, Dangling: dangling[ref.PageID]}
+	}
+
+	inbound, hidden, err := uc.inbound(pageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	result.Inbound = inbound
+	for _, n := range hidden {
+		result.HiddenInbound += n
+	}
+	return result, nil
+}
+
+// inbound 返回指向 pageID 的引用中用户能读取的部分，以及无法读取的来源页面 -> 引用数
+func (uc *PageUseCase) inbound(pageID, userID string) ([]InboundReference, map[string]int, error) {
+	refs, err := uc.references.ListInbound(pageID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	visible := []InboundReference{}
+	hidden := make(map[string]int)
+	readable := make(map[string]bool)
+	for _, ref := range refs {
+		ok, checked := readable[ref.SourcePageID]
+		if !checked {
+			ok, err = uc.canRead(ref.SourcePageID, userID)
+			if err != nil {
+				return nil, nil, err
+			}
+			readable[ref.SourcePageID] = ok
+		}
+		if !ok {
+			hidden[ref.SourcePageID]++
+			continue
+		}
+		visible = append(visible, InboundReference{
+			SourcePageID: ref.SourcePageID,
+			Path:         ref.Path,
+			ComponentID:  ref.ComponentID,
+			Kind:         ref.Kind,
+		})
+	}
+	return visible, hidden, nil
+}
+
+// PageUsage 引用了某个页面的一个来源页面
+type PageUsage struct {
+	PageID string             `json:"pageId"`
+	Embeds int                `json:"embeds"` // 嵌入该页面的次数
+	Links  int                `json:"links"`  // 跳转到该页面的链接数
+	Refs   []InboundReference `json:"refs"`
+}
+
+// PageUsages 页面被哪些其他页面使用，删除页面后这些引用将失效
+type PageUsages struct {
+	PageID      string      `json:"pageId"`
+	UsedBy      []PageUsage `json:"usedBy"`
+	HiddenPages int         `json:"hiddenPages"` // 用户无权访问的来源页面数，同样会受到删除的影响
+}
+
+// InUse 是否有其他页面引用该页面
+func (u *PageUsages) InUse() bool {
+	return len(u.UsedBy) > 0 || u.HiddenPages > 0
+}
+
+// Usages 按来源页面汇总其他页面对 pageID 的引用，供删除前提示。
+// 页面对自身的引用不计入；来源页面只在用户能读取时列出，其余计入 HiddenPages
+func (uc *PageUseCase) Usages(pageID, userID string) (*PageUsages, error) {
+	if uc.references == nil {
+		return nil, domainErrors.ErrReferencesDisabled
+	}
+	if err := uc.authorize(pageID, userID, false); err != nil {
+		return nil, err
+	}
+
+	inbound, hidden, err := uc.inbound(pageID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	usages := &PageUsages{PageID: pageID, UsedBy: []PageUsage{}, HiddenPages: len(hidden)}
+	bySource := make(map[string]int) // 来源页面 -> UsedBy 下标
+	for _, ref := range inbound {
+		if ref.SourcePageID == pageID {
+			continue
+		}
+		i, ok := bySource[ref.SourcePageID]
+		if !ok {
+			i = len(usages.UsedBy)
+			bySource[ref.SourcePageID] = i
+			usages.UsedBy = append(usages.UsedBy, PageUsage{PageID: ref.SourcePageID})
+		}
+		usage := &usages.UsedBy[i]
+		if ref.Kind == pageref.KindEmbed {
+			usage.Embeds++
+		} else {
+			usage.Links++
+		}
+		usage.Refs = append(usage.Refs, ref)
+	}
+	return usages, nil
+}
+
+// indexReferences 写入新页面的出站引用，失败时只记录日志；之后的修改由协同房间刷盘时索引
+func (uc *PageUseCase) indexReferences(pageID string, schema []byte) {
+	if uc.references == nil || !pageref.Contains(schema) {
+		return
+	}
+	if err := uc.replaceReferences(pageID, schema); err != nil {
+		logging.Warnf("[Page %s] 写入页面引用索引失败: %v", pageID, err)
+	}
+}
+
+// replaceReferences 按 schema 重建页面的出站引用
+func (uc *PageUseCase) replaceReferences(pageID string, schema []byte) error {
+	refs, err := pageref.Find(schema)
+	if err != nil {
+		return err
+	}
+	rows := make([]*entity.PageReference, len(refs))
+	for i, ref := range refs {
+		rows[i] = &entity.PageReference{
+			SourcePageID: pageID,
+			TargetPageID: ref.PageID,
+			Path:         ref.Path,
+			ComponentID:  ref.ComponentID,
+			Kind:         ref.Kind,
+		}
+	}
+	return uc.references.ReplaceForPage(pageID, rows)
+}
+
+// BackfillReferences 为引用索引上线前已有、尚未索引的页面建立索引，返回成功索引的页面数。
+// 单个页面失败时记录日志并继续
+func (uc *PageUseCase) BackfillReferences() (int, error) {
+	if uc.references == nil {
+		return 0, nil
+	}
+	pageIDs, err := uc.references.UnindexedPages()
+	if err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+	for _, pageID := range pageIDs {
+		page, err := uc.ReadPage(pageID)
+		if err == nil && page == nil {
+			continue
+		}
+		if err == nil {
+			err = uc.replaceReferences(pageID, page.Schema)
+		}
+		if err != nil {
+			logging.Warnf("[Page %s] 回填页面引用索引失败: %v", pageID, err)
+			continue
+		}
+		indexed++
+	}
+	return indexed, nil
+}
+
+// canRead 判断用户能否读取页面，页面不存在时视为不能读取
+func (uc *PageUseCase) canRead(pageID, userID string) (bool, error) {
+	err := uc.authorize(pageID, userID, false)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, domainErrors.ErrUnauthorized), errors.Is(err, domainErrors.ErrPageNotFound):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// GetPublishedPage 获取页面的发布副本，供公开访问使用
+func (uc *PageUseCase) GetPublishedPage(pageID string) (*entity.Page, error) {
+	page, err := uc.repo.GetByPageID(pageID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, domainErrors.ErrPageNotFound
+	}
+	if page.PublishedVersion == 0 {
+		return nil, domainErrors.ErrPageNotPublished
+	}
+
+	// 发布后更换了加密密钥等情况下无法解密时，只返回掩码，不影响页面其余部分的访问
+	resolved, err := uc.resolveSecrets(pageID, page.PublishedSchema)
+	if err != nil {
+		logging.Warnf("[Page %s] 发布副本中的密钥属性无法解密: %v", pageID, err)
+		resolved, err = secretprop.Mask(page.PublishedSchema)
+		if err != nil {
+			return nil, err
+		}
+	}
+	page.PublishedSchema = datatypes.JSON(resolved)
 	return page, nil
 }
 
-// ensureUserExists 确保用户存在，不存在则创建
-func (uc *PageUseCase) ensureUserExists(userID string) error {
+// resolveSecrets 解密 Schema 中的密钥属性；未配置加密密钥而 Schema 含密钥属性时返回 ErrSecretsDisabled，
+// 密文无法解密时返回 ErrInvalidSecret
+func (uc *PageUseCase) resolveSecrets(pageID string, schema []byte) ([]byte, error) {
+	if uc.secrets == nil {
+		refs, err := secretprop.Find(schema)
+		if err != nil {
+			return nil, err
+		}
+		if len(refs) > 0 {
+			return nil, domainErrors.ErrSecretsDisabled
+		}
+		return schema, nil
+	}
+
+	resolved, err := uc.secrets.Resolve(pageID, schema)
+	if errors.Is(err, secretprop.ErrUndecryptable) {
+		return nil, fmt.Errorf("%w: %v", domainErrors.ErrInvalidSecret, err)
+	}
+	return resolved, err
+}
+
+// SetLinkEdit 开启或关闭"持有链接即可编辑"，只有所有者可以修改，由路由上的 RequirePageRole 校验
+// 关闭后已连接的访客不受影响，重连时才会被拒绝
+func (uc *PageUseCase) SetLinkEdit(pageID string, enabled bool) error {
+	return uc.repo.SetLinkEdit(pageID, enabled)
+}
+
+// SetChatPersistence 设置房间聊天是否持久化，只有所有者可以修改，由路由上的 RequirePageRole 校验
+// 房间在线时立即生效，房间内所有人会收到新的设置
+func (uc *PageUseCase) SetChatPersistence(pageID string, enabled bool) error {
+	page, err := uc.repo.GetByPageID(pageID)
+	if err != nil {
+		return err
+	}
+	if page == nil {
+		return domainErrors.ErrPageNotFound
+	}
+	if err := uc.repo.SetChatPersisted(pageID, enabled); err != nil {
+		return err
+	}
+
+	if room := uc.hub.GetRoom(pageID); room != nil {
+		room.SetChatPersisted(enabled)
+	}
+	return nil
+}
+
+// CollabSettingsUpdate 协同设置的修改，为 nil 的字段保持不变
+type CollabSettingsUpdate struct {
+	Cursors              *bool
+	Selections           *bool
+	Chat                 *bool
+	FlushIntervalSeconds *int
+	FlushThreshold       *int
+	Conflict             *entity.ConflictStrategy
+}
+
+// GetCollabSettings 返回页面级协同设置，能读取页面的用户都可以查看
+func (uc *PageUseCase) GetCollabSettings(pageID, userID string) (entity.CollabSettings, error) {
+	if err := uc.authorize(pageID, userID, false); err != nil {
+		return entity.CollabSettings{}, err
+	}
+
+	page, err := uc.repo.GetByPageID(pageID)
+	if err != nil {
+		return entity.CollabSettings{}, err
+	}
+	if page == nil {
+		return entity.CollabSettings{}, domainErrors.ErrPageNotFound
+	}
+	return entity.ParseCollabSettings(page.CollabSettings), nil
+}
+
+// UpdateCollabSettings 修改页面级协同设置，只有所有者可以修改，由路由上的 RequirePageRole 校验
+// 房间在线时立即生效，房间内所有人会收到新的设置
+func (uc *PageUseCase) UpdateCollabSettings(pageID string, update CollabSettingsUpdate) (entity.CollabSettings, error) {
+	page, err := uc.repo.GetByPageID(pageID)
+	if err != nil {
+		return entity.CollabSettings{}, err
+	}
+	if page == nil {
+		return entity.CollabSettings{}, domainErrors.ErrPageNotFound
+	}
+
+	settings := entity.ParseCollabSettings(page.CollabSettings)
+	if update.Cursors != nil {
+		settings.Cursors = *update.Cursors
+	}
+	if update.Selections != nil {
+		settings.Selections = *update.Selections
+	}
+	if update.Chat != nil {
+		settings.Chat = *update.Chat
+	}
+	if update.FlushIntervalSeconds != nil {
+		settings.FlushIntervalSeconds = *update.FlushIntervalSeconds
+	}
+	if update.FlushThreshold != nil {
+		settings.FlushThreshold = *update.FlushThreshold
+	}
+	if update.Conflict != nil {
+		settings.Conflict = *update.Conflict
+	}
+	if !settings.Valid() {
+		return entity.CollabSettings{}, fmt.Errorf("%w: flushIntervalSeconds 为 0 或 %d-%d，flushThreshold 为 0-%d，conflict 为 strict/rebase/lww/crdt",
+			domainErrors.ErrInvalidCollabSettings,
+			int(entity.MinFlushInterval.Seconds()), int(entity.MaxFlushInterval.Seconds()), entity.MaxFlushThreshold)
+	}
+
+	if err := uc.repo.SetCollabSettings(pageID, settings); err != nil {
+		return entity.CollabSettings{}, err
+	}
+	if room := uc.hub.GetRoom(pageID); room != nil {
+		room.SetCollabSettings(settings)
+	}
+	return settings, nil
+}
+
+// SetSessionWindow 设置页面的协作时段，window 为 nil 时取消，只有所有者可以修改，由路由上的 RequirePageRole 校验。
+// 时段外所有者以外的用户不能加入房间，已在房间内的只读；房间在线时立即生效
+func (uc *PageUseCase) SetSessionWindow(pageID string, window *entity.SessionWindow) (entity.CollabSettings, error) {
+	page, err := uc.repo.GetByPageID(pageID)
+	if err != nil {
+		return entity.CollabSettings{}, err
+	}
+	if page == nil {
+		return entity.CollabSettings{}, domainErrors.ErrPageNotFound
+	}
+	if window != nil && (!window.Valid() || !window.EndsAt.After(time.Now())) {
+		return entity.CollabSettings{}, fmt.Errorf("%w: endsAt 需晚于 startsAt 和当前时间，时长不超过 %d 小时",
+			domainErrors.ErrInvalidSessionWindow, int(entity.MaxSessionDuration.Hours()))
+	}
+
+	settings := entity.ParseCollabSettings(page.CollabSettings)
+	settings.Session = window
+	if err := uc.repo.SetCollabSettings(pageID, settings); err != nil {
+		return entity.CollabSettings{}, err
+	}
+	if room := uc.hub.GetRoom(pageID); room != nil {
+		room.SetCollabSettings(settings)
+	}
+	return settings, nil
+}
+
+// GuestEditAllowed 判断页面是否允许未登录访客协同编辑
+// 页面不存在时返回 ErrPageNotFound
+func (uc *PageUseCase) GuestEditAllowed(pageID string) (bool, error) {
+	page, err := uc.repo.GetByPageID(pageID)
+	if err != nil {
+		return false, err
+	}
+	if page == nil {
+		return false, domainErrors.ErrPageNotFound
+	}
+	return page.LinkEditEnabled, nil
+}
+
+// ImportLegacyPage 将旧版 localStorage 数据转换为 Schema 后创建页面，org 含义同 CreatePage
+func (uc *PageUseCase) ImportLegacyPage(pageID, creatorID string, org OrgClaims, legacyData []byte) (*entity.Page, error) {
+	schema, err := legacy.Convert(legacyData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domainErrors.ErrInvalidLegacyData, err)
+	}
+
+	schemaBytes, err := schema.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	return uc.CreatePage(pageID, creatorID, org, schemaBytes)
+}
+
+// bulkOpsRetries 未指定基准版本时，与实时编辑冲突后的最大重试次数
+const bulkOpsRetries = 3
+
+// RunBulkOps 在服务端执行一组批量操作，作为一个版本原子地应用到协同房间，并以 op-patch 广播给在线用户。
+// expectedVersion > 0 时要求页面当前版本一致，否则返回 ErrOptimisticLock；
+// 为 0 时基于最新版本执行，与实时编辑冲突时重新计算并重试。
+// 操作没有产生任何修改时不推进版本，返回的 Patches 为空。
+// dryRun 为 true 时只校验并返回将要应用的 Patch 和版本号，不修改房间状态也不广播。
+// 只读协作者返回 ErrUnauthorized，协作时段外所有者以外的用户返回 ErrSessionClosed，
+// 演示模式下演示者以外的用户返回 ErrPresentationActive。
+func (uc *PageUseCase) RunBulkOps(pageID, operatorID string, expectedVersion int64, ops []bulkops.Op, dryRun bool) (*ws.PatchResult, error) {
+	role, err := uc.PageRole(pageID, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	if role == entity.RoleViewer {
+		return nil, domainErrors.ErrUnauthorized
+	}
+
+	room, err := uc.hub.GetOrCreateRoom(pageID)
+	if err != nil {
+		return nil, err
+	}
+	// 无人在线时房间只为本次修改而创建，完成后交给 Hub 刷盘销毁
+	defer uc.hub.ReleaseIfIdle(room)
+
+	if role != entity.RoleOwner && !room.SessionOpen() {
+		return nil, domainErrors.ErrSessionClosed
+	}
+	if !room.MayEdit(operatorID) {
+		return nil, domainErrors.ErrPresentationActive
+	}
+
+	submit := func(author ws.UserInfo, patch []byte, version int64) (*ws.PatchResult, error) {
+		return room.SubmitLabeledEdit(author, ws.LabelBulkOps, patch, version)
+	}
+	if dryRun {
+		submit = room.ValidateEdit
+	}
+
+	author := ws.UserInfo{UserID: operatorID, UserName: operatorID}
+	for attempt := 0; ; attempt++ {
+		snapshot, version := room.GetSnapshot()
+		if expectedVersion > 0 && version != expectedVersion {
+			return nil, domainErrors.ErrOptimisticLock
+		}
+
+		modified, err := bulkops.Apply(snapshot, ops)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domainErrors.ErrInvalidBulkOps, err)
+		}
+		diff, err := jsondiff.Diff(snapshot, modified)
+		if err != nil {
+			return nil, err
+		}
+		if len(diff) == 0 {
+			return &ws.PatchResult{Version: version}, nil
+		}
+		patch, err := json.Marshal(diff)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := submit(author, patch, version)
+		var conflict *ws.VersionConflictError
+		var patchErr *ws.PatchError
+		switch {
+		case err == nil:
+			return result, nil
+		case errors.As(err, &conflict):
+			if expectedVersion > 0 || attempt >= bulkOpsRetries {
+				return nil, domainErrors.ErrOptimisticLock
+			}
+		case errors.As(err, &patchErr):
+			return nil, fmt.Errorf("%w: %s", domainErrors.ErrInvalidBulkOps, patchErr.Reason)
+		case errors.Is(err, ws.ErrRoomClosed):
+			return nil, domainErrors.ErrRoomClosing
+		default:
+			return nil, err
+		}
+	}
+}
+
+// ListCollaborators 返回页面的协作者，能读取页面的用户都可以查看
+func (uc *PageUseCase) ListCollaborators(pageID, userID string) ([]*entity.PageCollaborator, error) {
+	if err := uc.authorize(pageID, userID, false); err != nil {
+		return nil, err
+	}
+
+	collaborators, err := uc.collaborators.ListByPage(pageID)
+	if err != nil {
+		return nil, err
+	}
+	if collaborators == nil {
+		collaborators = []*entity.PageCollaborator{}
+	}
+	return collaborators, nil
+}
+
+// AddCollaborator 添加协作者或修改其角色，只有所有者可以操作，由路由上的 RequirePageRole 校验；
+// 通过邀请添加时 operatorID 为邀请人，由 InviteUseCase 确认其仍是所有者。
+// 降为 viewer 时对方的在线连接立即被移出协同房间，重新连接后以只读身份加入；其他角色变化在对方下次连接时生效
+func (uc *PageUseCase) AddCollaborator(pageID, operatorID, userID, role string) (*entity.PageCollaborator, error) {
+	if role != entity.RoleEditor && role != entity.RoleViewer {
+		return nil, domainErrors.ErrInvalidRole
+	}
+
+	page, err := uc.repo.GetAccessInfo(pageID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, domainErrors.ErrPageNotFound
+	}
+	if userID == page.CreatorID {
+		return nil, fmt.Errorf("%w: 创建者已是页面所有者", domainErrors.ErrInvalidRole)
+	}
+
+	collaborator := &entity.PageCollaborator{
+		PageID:  pageID,
+		UserID:  userID,
+		Role:    role,
+		AddedBy: operatorID,
+	}
+	if err := uc.collaborators.Upsert(collaborator); err != nil {
+		return nil, err
+	}
+
+	if room := uc.hub.GetRoom(pageID); room != nil && role == entity.RoleViewer && !page.LinkEditEnabled {
+		room.Kick(userID, "你的页面权限已变更为只读，请重新加入")
+	}
+	return collaborator, nil
+}
+
+// RemoveCollaborator 移除协作者：所有者可以移除任何协作者，协作者可以移除自己（退出协作）。
+// operatorRole 为路由上 RequirePageRole 解析出的操作者角色。
+// 页面未开启"持有链接即可编辑"时，对方的在线连接立即被移出协同房间
+func (uc *PageUseCase) RemoveCollaborator(pageID, operatorID, operatorRole, userID string) error {
+	page, err := uc.repo.GetAccessInfo(pageID)
+	if err != nil {
+		return err
+	}
+	if page == nil {
+		return domainErrors.ErrPageNotFound
+	}
+	if operatorID != userID && operatorRole != entity.RoleOwner {
+		return domainErrors.ErrUnauthorized
+	}
+
+	removed, err := uc.collaborators.Remove(pageID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return domainErrors.ErrCollaboratorNotFound
+	}
+
+	if room := uc.hub.GetRoom(pageID); room != nil && !page.LinkEditEnabled {
+		room.Kick(userID, "你已不是该页面的协作者")
+	}
+	return nil
+}
+
+// EnsureUser 确保用户存在，不存在则创建占位记录（页面的 creator_id 有外键约束）
+func (uc *PageUseCase) EnsureUser(userID string) error {
 	user, err := uc.userRepo.GetByID(userID)
 	if err != nil {
 		return err
@@ -89,7 +1037,7 @@ func (uc *PageUseCase) ensureUserExists(userID string) error {
 	newUser := &entity.User{
 		ID:        userID,
 		Email:     "",
-		Name:      "Unknown User",
+		Name:      placeholderUserName,
 		AvatarURL: "",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
@@ -98,27 +1046,104 @@ func (uc *PageUseCase) ensureUserExists(userID string) error {
 	return uc.userRepo.Upsert(newUser)
 }
 
-// DeletePage 删除页面
-// 执行"先关房间后删数据"的安全删除流程：
-//  1. 检查权限：只有创建者才能删除
-//  2. 强制关闭内存中的协同房间
-//  3. 删除数据库记录
-func (uc *PageUseCase) DeletePage(pageID, operatorID string) error {
-	// 查出页面，检查权限
-	page, err := uc.repo.GetByPageID(pageID)
+// DeleteConfirmTTL 删除页面的确认 Token 有效期
+const DeleteConfirmTTL = 5 * time.Minute
+
+// DeleteImpact 删除页面的影响范围，需要确认时带有确认 Token
+type DeleteImpact struct {
+	PageID      string        `json:"pageId"`
+	OnlineUsers []ws.UserInfo `json:"onlineUsers"`      // 页面及其草稿分支中除操作者外的在线用户，删除时会被断开
+	Usages      *PageUsages   `json:"usages,omitempty"` // 引用该页面的其他页面，未启用页面引用索引时为 nil
+
+	ConfirmToken string    `json:"confirmToken,omitempty"`
+	ExpiresAt    time.Time `json:"expiresAt,omitempty"`
+}
+
+// RequiresConfirm 是否需要确认后才能删除
+func (i *DeleteImpact) RequiresConfirm() bool {
+	return len(i.OnlineUsers) > 0 || (i.Usages != nil && i.Usages.InUse())
+}
+
+// DeleteImpact 评估删除页面的影响：其他在线用户（只统计本实例的房间）和引用该页面的其他页面。
+// 启用删除确认且有影响时签发绑定页面和操作者的确认 Token，带上它调用 ConfirmDelete 后才能删除
+func (uc *PageUseCase) DeleteImpact(pageID, userID string) (*DeleteImpact, error) {
+	impact := &DeleteImpact{PageID: pageID, OnlineUsers: []ws.UserInfo{}}
+	if uc.deleteSecret == nil {
+		return impact, nil
+	}
+
+	branchPageIDs, err := uc.repo.BranchPageIDs(pageID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if page == nil {
-		return domainErrors.ErrPageNotFound
+	seen := map[string]bool{userID: true}
+	for _, roomID := range append([]string{pageID}, branchPageIDs...) {
+		room := uc.hub.GetRoom(roomID)
+		if room == nil {
+			continue
+		}
+		for _, user := range room.Users() {
+			if !seen[user.UserID] {
+				seen[user.UserID] = true
+				impact.OnlineUsers = append(impact.OnlineUsers, user)
+			}
+		}
 	}
 
-	// 权限检查：只有创建者才能删除
-	if page.CreatorID != operatorID {
-		return domainErrors.ErrUnauthorized
+	usages, err := uc.Usages(pageID, userID)
+	switch {
+	case err == nil:
+		impact.Usages = usages
+	case !errors.Is(err, domainErrors.ErrReferencesDisabled):
+		return nil, err
 	}
 
-	// 先关闭内存中的协同房间
+	if impact.RequiresConfirm() {
+		impact.ExpiresAt = time.Now().Add(DeleteConfirmTTL).Truncate(time.Second)
+		impact.ConfirmToken = uc.deleteToken(pageID, userID, impact.ExpiresAt)
+	}
+	return impact, nil
+}
+
+// ConfirmDelete 校验删除确认 Token 后删除页面，Token 不属于该页面和操作者或已过期时返回 ErrInvalidDeleteConfirmation
+func (uc *PageUseCase) ConfirmDelete(pageID, userID, token string) error {
+	if uc.deleteSecret == nil {
+		return uc.DeletePage(pageID)
+	}
+
+	expires, _, ok := strings.Cut(token, ".")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if !ok || err != nil {
+		return domainErrors.ErrInvalidDeleteConfirmation
+	}
+	expiresAt := time.Unix(unix, 0)
+	if !time.Now().Before(expiresAt) || !hmac.Equal([]byte(token), []byte(uc.deleteToken(pageID, userID, expiresAt))) {
+		return domainErrors.ErrInvalidDeleteConfirmation
+	}
+	return uc.DeletePage(pageID)
+}
+
+// deleteToken 删除确认 Token：<过期时间戳>.<HMAC-SHA256(页面 ID, 操作者, 过期时间戳)>，无需存储即可在任意实例校验
+func (uc *PageUseCase) deleteToken(pageID, userID string, expiresAt time.Time) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	mac := hmac.New(sha256.New, uc.deleteSecret)
+	mac.Write([]byte("delete." + pageID + "." + userID + "." + expires))
+	return expires + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// DeletePage 删除页面，只有所有者（创建者或组织管理员）才能删除，由路由上的 RequirePageRole 校验
+// 执行"先关房间后删数据"的安全删除流程：
+//  1. 强制关闭内存中的协同房间
+//  2. 删除数据库记录
+func (uc *PageUseCase) DeletePage(pageID string) error {
+	// 先关闭内存中的协同房间，草稿分支随页面一起删除
+	branchPageIDs, err := uc.repo.BranchPageIDs(pageID)
+	if err != nil {
+		return err
+	}
+	for _, branchPageID := range branchPageIDs {
+		uc.hub.CloseRoom(branchPageID)
+	}
 	uc.hub.CloseRoom(pageID)
 
 	// 删除数据库记录
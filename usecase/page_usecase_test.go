@@ -1,10 +1,14 @@
 package usecase
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"lowercode-go-server/domain/entity"
 	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/bulkops"
 	"lowercode-go-server/internal/ws"
 
 	"github.com/stretchr/testify/assert"
@@ -35,11 +39,12 @@ func TestPageUseCase_GetPage_HotPath(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, room)
 
-	// 4. 创建 PageUseCase
-	uc := NewPageUseCase(mockRepo, hub)
+	// 4. 创建 PageUseCase（权限检查只读取轻量的访问信息）
+	mockRepo.On("GetAccessInfo", "hot-page").Return(&entity.Page{PageID: "hot-page", CreatorID: "owner"}, nil)
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
 
 	// 5. 调用 GetPage（应该走热路径）
-	page, err := uc.GetPage("hot-page")
+	page, err := uc.GetPage("hot-page", "owner")
 
 	// 6. 断言
 	assert.NoError(t, err)
@@ -68,12 +73,13 @@ func TestPageUseCase_GetPage_ColdPath(t *testing.T) {
 		Version: 3,
 	}
 	mockRepo.On("GetByPageID", "cold-page").Return(dbPage, nil).Once()
+	mockRepo.On("GetAccessInfo", "cold-page").Return(&entity.Page{PageID: "cold-page", CreatorID: "owner"}, nil)
 
 	// 4. 创建 PageUseCase
-	uc := NewPageUseCase(mockRepo, hub)
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
 
 	// 5. 调用 GetPage（应该走冷路径）
-	page, err := uc.GetPage("cold-page")
+	page, err := uc.GetPage("cold-page", "owner")
 
 	// 6. 断言
 	assert.NoError(t, err)
@@ -92,15 +98,16 @@ func TestPageUseCase_GetPage_ColdPath_NotFound(t *testing.T) {
 	mockPageService := new(MockPageService)
 	hub := ws.NewHub(mockPageService)
 
-	// 设置 repo Mock：返回页面不存在错误
-	mockRepo.On("GetByPageID", "nonexistent").Return(nil, domainErrors.ErrPageNotFound)
+	// 设置 repo Mock：页面不存在，权限检查时即返回
+	mockRepo.On("GetAccessInfo", "nonexistent").Return(nil, nil)
 
-	uc := NewPageUseCase(mockRepo, hub)
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
 
-	page, err := uc.GetPage("nonexistent")
+	page, err := uc.GetPage("nonexistent", "owner")
 
 	assert.Nil(t, page)
 	assert.ErrorIs(t, err, domainErrors.ErrPageNotFound)
+	mockRepo.AssertNotCalled(t, "GetByPageID", mock.Anything)
 }
 
 // TestPageUseCase_CreatePage 测试创建新页面
@@ -119,10 +126,10 @@ func TestPageUseCase_CreatePage(t *testing.T) {
 			len(page.Schema) > 0
 	})).Return(nil).Once()
 
-	uc := NewPageUseCase(mockRepo, hub)
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
 
 	// 创建页面
-	page, err := uc.CreatePage("new-page", "user-123")
+	page, err := uc.CreatePage("new-page", "user-123", OrgClaims{}, nil)
 
 	// 断言
 	assert.NoError(t, err)
@@ -142,6 +149,31 @@ func TestPageUseCase_CreatePage(t *testing.T) {
 }
 
 // TestPageUseCase_CreatePage_Error 测试创建失败
+func TestPageUseCase_ImportLegacyPage(t *testing.T) {
+	mockRepo := new(MockPageRepository)
+	hub := ws.NewHub(new(MockPageService))
+
+	mockRepo.On("Create", mock.MatchedBy(func(page *entity.Page) bool {
+		return page.PageID == "legacy-page" && page.Version == 1
+	})).Return(nil).Once()
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
+
+	page, err := uc.ImportLegacyPage("legacy-page", "user-123", OrgClaims{},
+		[]byte(`{"state": {"components": [{"id": 1, "name": "Page", "children": [{"id": 2, "name": "Button"}]}]}}`))
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"rootId": 1, "components": {
+		"1": {"id": 1, "name": "Page", "desc": "", "children": [2], "props": {}, "styles": {}},
+		"2": {"id": 2, "name": "Button", "desc": "", "parentId": 1, "props": {}, "styles": {}}
+	}}`, string(page.Schema))
+
+	// 无法识别的数据不写库
+	_, err = uc.ImportLegacyPage("bad-page", "user-123", OrgClaims{}, []byte(`{"foo": 1}`))
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidLegacyData)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestPageUseCase_CreatePage_Error(t *testing.T) {
 	mockRepo := new(MockPageRepository)
 	mockPageService := new(MockPageService)
@@ -150,19 +182,50 @@ func TestPageUseCase_CreatePage_Error(t *testing.T) {
 	// 设置 repo Mock：Create 失败
 	mockRepo.On("Create", mock.Anything).Return(domainErrors.ErrOptimisticLock)
 
-	uc := NewPageUseCase(mockRepo, hub)
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
 
-	page, err := uc.CreatePage("new-page", "user-123")
+	page, err := uc.CreatePage("new-page", "user-123", OrgClaims{}, nil)
 
 	assert.Nil(t, page)
 	assert.Error(t, err)
 }
 
+// TestPageUseCase_CreatePage_PageQuota 测试每个用户的页面数上限
+func TestPageUseCase_CreatePage_PageQuota(t *testing.T) {
+	// 测试场景：未达上限时创建成功；达到上限后返回 ErrQuotaExceeded 且不写数据库；不限时不查询页面数
+
+	mockRepo := new(MockPageRepository)
+	hub := ws.NewHub(new(MockPageService))
+	mockRepo.On("CountByCreator", "alice").Return(int64(1), nil).Once()
+	mockRepo.On("CountByCreator", "alice").Return(int64(2), nil)
+	mockRepo.On("Create", mock.Anything).Return(nil).Twice()
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
+	uc.EnablePageQuota(2)
+
+	_, err := uc.CreatePage("page-2", "alice", OrgClaims{}, nil)
+	assert.NoError(t, err)
+	_, err = uc.CreatePage("page-3", "alice", OrgClaims{}, nil)
+	assert.ErrorIs(t, err, domainErrors.ErrQuotaExceeded)
+
+	quota, err := uc.PageQuota("alice")
+	assert.NoError(t, err)
+	assert.True(t, quota.Exceeded())
+	assert.Equal(t, 2, quota.Limit)
+
+	uc.EnablePageQuota(0)
+	_, err = uc.CreatePage("page-3", "alice", OrgClaims{}, nil)
+	assert.NoError(t, err)
+	mockRepo.AssertNumberOfCalls(t, "CountByCreator", 3)
+	mockRepo.AssertExpectations(t)
+}
+
 // TestPageUseCase_GetPage_TableDriven 表格驱动测试
 func TestPageUseCase_GetPage_TableDriven(t *testing.T) {
 	testCases := []struct {
 		name           string
 		pageID         string
+		userID         string
 		roomExists     bool
 		roomVersion    int64
 		dbPage         *entity.Page
@@ -199,6 +262,15 @@ func TestPageUseCase_GetPage_TableDriven(t *testing.T) {
 			repoShouldCall: true,
 			expectedErr:    domainErrors.ErrPageNotFound,
 		},
+		{
+			name:           "Not a collaborator",
+			pageID:         "private-page",
+			roomExists:     true,
+			roomVersion:    10,
+			userID:         "stranger",
+			repoShouldCall: false,
+			expectedErr:    domainErrors.ErrUnauthorized,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -222,9 +294,16 @@ func TestPageUseCase_GetPage_TableDriven(t *testing.T) {
 			if tc.repoShouldCall {
 				mockRepo.On("GetByPageID", tc.pageID).Return(tc.dbPage, tc.dbError)
 			}
+			mockRepo.On("GetAccessInfo", tc.pageID).Return(&entity.Page{PageID: tc.pageID, CreatorID: "owner"}, nil)
+			collaborators := new(MockCollaboratorRepository)
+			collaborators.On("GetRole", tc.pageID, mock.Anything).Return("", nil)
 
-			uc := NewPageUseCase(mockRepo, hub)
-			page, err := uc.GetPage(tc.pageID)
+			userID := tc.userID
+			if userID == "" {
+				userID = "owner"
+			}
+			uc := NewPageUseCase(mockRepo, newMockUserRepository(), collaborators, nil, hub)
+			page, err := uc.GetPage(tc.pageID, userID)
 
 			if tc.expectedErr != nil {
 				assert.ErrorIs(t, err, tc.expectedErr)
@@ -244,3 +323,821 @@ func TestPageUseCase_GetPage_TableDriven(t *testing.T) {
 		})
 	}
 }
+
+// TestPageUseCase_PublishPage 测试发布页面
+// 房间在线时发布内存中的最新草稿，而不是数据库中的旧版本
+func TestPageUseCase_PublishPage(t *testing.T) {
+	mockRepo := new(MockPageRepository)
+	mockPageService := new(MockPageService)
+
+	liveState := []byte(`{"title": "live"}`)
+	mockPageService.On("GetPageState", "page-1").Return(liveState, int64(8), nil).Once()
+	mockPageService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	hub := ws.NewHub(mockPageService)
+	_, err := hub.GetOrCreateRoom("page-1")
+	assert.NoError(t, err)
+
+	mockRepo.On("GetByPageID", "page-1").Return(&entity.Page{
+		PageID:    "page-1",
+		Schema:    datatypes.JSON(`{"title": "stale"}`),
+		Version:   5,
+		CreatorID: "owner",
+	}, nil)
+	mockRepo.On("Publish", "page-1", liveState, int64(8)).Return(nil).Once()
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
+
+	page, err := uc.PublishPage("page-1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8), page.PublishedVersion)
+	assert.NotNil(t, page.PublishedAt)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestPageUseCase_PublishPage_DanglingReferences 测试页面引用了不存在的页面时拒绝发布
+func TestPageUseCase_PublishPage_DanglingReferences(t *testing.T) {
+	// 测试场景：组件 3 引用的 page-gone 不存在，发布返回 ErrDanglingReferences 并指出引用位置；
+	// 目标页面都存在时正常发布
+
+	mockRepo := new(MockPageRepository)
+	mockRefs := new(MockPageReferenceRepository)
+	hub := ws.NewHub(new(MockPageService))
+
+	schema := datatypes.JSON(`{"components": {"3": {"props": {"link": {"$page": "page-gone"}, "embed": {"$page": "page-2"}}}}}`)
+	mockRepo.On("GetByPageID", "page-1").Return(&entity.Page{PageID: "page-1", Schema: schema, Version: 4, CreatorID: "owner"}, nil)
+	mockRefs.On("MissingPages", []string{"page-2", "page-gone"}).Return([]string{"page-gone"}, nil).Once()
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
+	uc.EnableReferences(mockRefs)
+
+	_, err := uc.PublishPage("page-1")
+	assert.ErrorIs(t, err, domainErrors.ErrDanglingReferences)
+	assert.Contains(t, err.Error(), "/components/3/props/link -> page-gone")
+	mockRepo.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+
+	mockRefs.On("MissingPages", []string{"page-2", "page-gone"}).Return(nil, nil).Once()
+	mockRepo.On("Publish", "page-1", []byte(schema), int64(4)).Return(nil).Once()
+	_, err = uc.PublishPage("page-1")
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestPageUseCase_PublishPage_UsageLimit 测试本月发布次数已达套餐额度时拒绝发布
+func TestPageUseCase_PublishPage_UsageLimit(t *testing.T) {
+	// 测试场景：按页面所属租户（组织）计量；超出额度时返回 ErrUsageLimitExceeded 且不发布，未超出时正常发布
+
+	mockRepo := new(MockPageRepository)
+	meter := new(MockUsageMeter)
+	hub := ws.NewHub(new(MockPageService))
+
+	schema := datatypes.JSON(`{"components": {}}`)
+	mockRepo.On("GetByPageID", "page-1").Return(&entity.Page{PageID: "page-1", Schema: schema, Version: 4, CreatorID: "owner", OrgID: "org_1"}, nil)
+	meter.On("Consume", "org_1", entity.UsageKindPublish).Return(&UsageLimitError{Kind: entity.UsageKindPublish, Plan: entity.PlanFree, Limit: 5}).Once()
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
+	uc.EnableUsageLimits(meter)
+
+	_, err := uc.PublishPage("page-1")
+	assert.ErrorIs(t, err, domainErrors.ErrUsageLimitExceeded)
+	mockRepo.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+
+	meter.On("Consume", "org_1", entity.UsageKindPublish).Return(nil).Once()
+	mockRepo.On("Publish", "page-1", []byte(schema), int64(4)).Return(nil).Once()
+	_, err = uc.PublishPage("page-1")
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	meter.AssertExpectations(t)
+}
+
+// TestPageUseCase_References 测试查询页面的出站和入站引用
+func TestPageUseCase_References(t *testing.T) {
+	// 测试场景：出站引用标出失效的目标；入站引用只列出用户能读取的来源页面，
+	// 无权访问和已删除的来源页面只计数
+
+	mockRepo := new(MockPageRepository)
+	mockRefs := new(MockPageReferenceRepository)
+	mockCollab := new(MockCollaboratorRepository)
+	hub := ws.NewHub(new(MockPageService))
+
+	mockRepo.On("GetAccessInfo", "page-1").Return(&entity.Page{PageID: "page-1", CreatorID: "alice"}, nil)
+	mockRepo.On("GetAccessInfo", "page-a").Return(&entity.Page{PageID: "page-a", CreatorID: "alice"}, nil)
+	mockRepo.On("GetAccessInfo", "page-b").Return(&entity.Page{PageID: "page-b", CreatorID: "bob"}, nil)
+	mockRepo.On("GetAccessInfo", "page-c").Return(nil, nil)
+	mockCollab.On("GetRole", "page-b", "alice").Return("", nil)
+	mockRepo.On("GetByPageID", "page-1").Return(&entity.Page{
+		PageID: "page-1",
+		Schema: datatypes.JSON(`{"components": {"7": {"props": {"href": {"$page": "page-gone"}}}}}`),
+	}, nil)
+	mockRefs.On("MissingPages", []string{"page-gone"}).Return([]string{"page-gone"}, nil)
+	mockRefs.On("ListInbound", "page-1").Return([]*entity.PageReference{
+		{SourcePageID: "page-a", TargetPageID: "page-1", Path: "/components/1/props/link", ComponentID: 1},
+		{SourcePageID: "page-a", TargetPageID: "page-1", Path: "/components/2/props/link", ComponentID: 2},
+		{SourcePageID: "page-b", TargetPageID: "page-1", Path: "/footer"},
+		{SourcePageID: "page-c", TargetPageID: "page-1", Path: "/footer"},
+	}, nil)
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), mockCollab, nil, hub)
+	_, err := uc.References("page-1", "alice")
+	assert.ErrorIs(t, err, domainErrors.ErrReferencesDisabled)
+
+	uc.EnableReferences(mockRefs)
+	refs, err := uc.References("page-1", "alice")
+	assert.NoError(t, err)
+	if assert.Len(t, refs.Outbound, 1) {
+		assert.Equal(t, "page-gone", refs.Outbound[0].PageID)
+		assert.Equal(t, int64(7), refs.Outbound[0].ComponentID)
+		assert.True(t, refs.Outbound[0].Dangling)
+	}
+	assert.Len(t, refs.Inbound, 2)
+	assert.Equal(t, "page-a", refs.Inbound[0].SourcePageID)
+	assert.Equal(t, 2, refs.HiddenInbound)
+	mockCollab.AssertNumberOfCalls(t, "GetRole", 1)
+}
+
+// TestPageUseCase_Usages 测试按来源页面汇总页面被使用的情况
+func TestPageUseCase_Usages(t *testing.T) {
+	// 测试场景：page-a 嵌入一次、链接一次，页面对自身的引用不计入，无权访问的 page-b 只计入 hiddenPages
+
+	mockRepo := new(MockPageRepository)
+	mockRefs := new(MockPageReferenceRepository)
+	mockCollab := new(MockCollaboratorRepository)
+	hub := ws.NewHub(new(MockPageService))
+
+	mockRepo.On("GetAccessInfo", "page-1").Return(&entity.Page{PageID: "page-1", CreatorID: "alice"}, nil)
+	mockRepo.On("GetAccessInfo", "page-a").Return(&entity.Page{PageID: "page-a", CreatorID: "alice"}, nil)
+	mockRepo.On("GetAccessInfo", "page-b").Return(&entity.Page{PageID: "page-b", CreatorID: "bob"}, nil)
+	mockCollab.On("GetRole", "page-b", "alice").Return("", nil)
+	mockRefs.On("ListInbound", "page-1").Return([]*entity.PageReference{
+		{SourcePageID: "page-1", TargetPageID: "page-1", Path: "/components/9/props/to", ComponentID: 9, Kind: "link"},
+		{SourcePageID: "page-a", TargetPageID: "page-1", Path: "/components/1/props/page", ComponentID: 1, Kind: "embed"},
+		{SourcePageID: "page-a", TargetPageID: "page-1", Path: "/components/2/props/to", ComponentID: 2, Kind: "link"},
+		{SourcePageID: "page-b", TargetPageID: "page-1", Path: "/footer", Kind: "link"},
+	}, nil)
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), mockCollab, nil, hub)
+	uc.EnableReferences(mockRefs)
+
+	usages, err := uc.Usages("page-1", "alice")
+	assert.NoError(t, err)
+	assert.True(t, usages.InUse())
+	if assert.Len(t, usages.UsedBy, 1) {
+		assert.Equal(t, "page-a", usages.UsedBy[0].PageID)
+		assert.Equal(t, 1, usages.UsedBy[0].Embeds)
+		assert.Equal(t, 1, usages.UsedBy[0].Links)
+		assert.Len(t, usages.UsedBy[0].Refs, 2)
+	}
+	assert.Equal(t, 1, usages.HiddenPages)
+
+	mockRefs.On("ListInbound", "page-a").Return([]*entity.PageReference{}, nil)
+	usages, err = uc.Usages("page-a", "alice")
+	assert.NoError(t, err)
+	assert.False(t, usages.InUse())
+}
+
+// TestPageUseCase_IndexReferences 测试创建页面时写入引用索引，以及回填尚未索引的页面
+func TestPageUseCase_IndexReferences(t *testing.T) {
+	// 测试场景：新页面含引用时立即写入索引，不含时不写；回填时跳过已删除的页面
+
+	mockRepo := new(MockPageRepository)
+	mockRefs := new(MockPageReferenceRepository)
+	hub := ws.NewHub(new(MockPageService))
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
+	uc.EnableReferences(mockRefs)
+
+	mockRepo.On("Create", mock.Anything).Return(nil)
+	mockRefs.On("ReplaceForPage", "linked", mock.MatchedBy(func(rows []*entity.PageReference) bool {
+		return len(rows) == 1 && rows[0].TargetPageID == "home" && rows[0].Kind == "embed"
+	})).Return(nil).Once()
+	_, err := uc.CreatePage("linked", "alice", OrgClaims{}, []byte(`{"header": {"$page": "home", "kind": "embed"}}`))
+	assert.NoError(t, err)
+	_, err = uc.CreatePage("plain", "alice", OrgClaims{}, nil)
+	assert.NoError(t, err)
+
+	mockRefs.On("UnindexedPages").Return([]string{"old", "gone"}, nil)
+	mockRepo.On("GetByPageID", "old").Return(&entity.Page{PageID: "old", Schema: datatypes.JSON(`{"a": {"$page": "home"}}`)}, nil)
+	mockRepo.On("GetByPageID", "gone").Return(nil, nil)
+	mockRefs.On("ReplaceForPage", "old", mock.MatchedBy(func(rows []*entity.PageReference) bool {
+		return len(rows) == 1 && rows[0].Path == "/a" && rows[0].Kind == "link"
+	})).Return(nil).Once()
+
+	n, err := uc.BackfillReferences()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	mockRefs.AssertExpectations(t)
+}
+
+// TestPageUseCase_GetPublishedPage_NotPublished 测试未发布的页面不能公开访问
+func TestPageUseCase_GetPublishedPage_NotPublished(t *testing.T) {
+	mockRepo := new(MockPageRepository)
+	hub := ws.NewHub(new(MockPageService))
+
+	mockRepo.On("GetByPageID", "draft-only").Return(&entity.Page{PageID: "draft-only", Version: 3}, nil)
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
+	_, err := uc.GetPublishedPage("draft-only")
+
+	assert.ErrorIs(t, err, domainErrors.ErrPageNotPublished)
+}
+
+// TestPageUseCase_SetLinkEdit 测试分享设置直接写入（所有者权限由路由上的 RequirePageRole 校验）
+func TestPageUseCase_SetLinkEdit(t *testing.T) {
+	mockRepo := new(MockPageRepository)
+	hub := ws.NewHub(new(MockPageService))
+
+	mockRepo.On("SetLinkEdit", "page-1", true).Return(nil).Once()
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
+
+	assert.NoError(t, uc.SetLinkEdit("page-1", true))
+	mockRepo.AssertExpectations(t)
+}
+
+// TestPageUseCase_DeletePage 测试删除页面：先关闭页面及其草稿分支的房间，再删除数据库记录
+func TestPageUseCase_DeletePage(t *testing.T) {
+	mockRepo := new(MockPageRepository)
+	mockPageService := new(MockPageService)
+	mockPageService.On("GetPageState", mock.Anything).Return([]byte(`{}`), int64(1), nil)
+	mockPageService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	hub := ws.NewHub(mockPageService)
+	go hub.Run()
+	_, err := hub.GetOrCreateRoom("page-1")
+	assert.NoError(t, err)
+	_, err = hub.GetOrCreateRoom("branch-1")
+	assert.NoError(t, err)
+
+	mockRepo.On("BranchPageIDs", "page-1").Return([]string{"branch-1"}, nil)
+	mockRepo.On("Delete", "page-1").Return(nil).Once()
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
+
+	assert.NoError(t, uc.DeletePage("page-1"))
+	assert.Nil(t, hub.GetRoom("page-1"))
+	assert.Nil(t, hub.GetRoom("branch-1"))
+	mockRepo.AssertExpectations(t)
+}
+
+// TestPageUseCase_ConfirmDelete 测试两步删除：有其他人在线时签发确认 Token，凭 Token 删除
+func TestPageUseCase_ConfirmDelete(t *testing.T) {
+	// 测试场景：bob 在草稿分支中在线，操作者自己不计入；Token 换了操作者、被篡改或已过期时拒绝，
+	// 有效时删除页面并关闭房间；没有影响的页面不需要确认
+
+	mockRepo := new(MockPageRepository)
+	mockRefs := new(MockPageReferenceRepository)
+	mockPageService := new(MockPageService)
+	mockPageService.On("GetPageState", mock.Anything).Return([]byte(`{}`), int64(1), nil)
+	mockPageService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	hub := ws.NewHub(mockPageService)
+	go hub.Run()
+	room, err := hub.GetOrCreateRoom("page-1")
+	assert.NoError(t, err)
+	assert.NoError(t, room.Register(ws.NewClient(hub, nil, "page-1", ws.UserInfo{UserID: "alice", Role: entity.RoleOwner})))
+	branch, err := hub.GetOrCreateRoom("branch-1")
+	assert.NoError(t, err)
+	assert.NoError(t, branch.Register(ws.NewClient(hub, nil, "branch-1", ws.UserInfo{UserID: "bob", Role: entity.RoleEditor})))
+
+	mockRepo.On("GetAccessInfo", mock.Anything).Return(&entity.Page{CreatorID: "alice"}, nil)
+	mockRepo.On("BranchPageIDs", "page-1").Return([]string{"branch-1"}, nil)
+	mockRepo.On("BranchPageIDs", "page-2").Return([]string{}, nil)
+	mockRefs.On("ListInbound", mock.Anything).Return([]*entity.PageReference{}, nil)
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
+	uc.EnableReferences(mockRefs)
+	uc.EnableDeleteConfirmation([]byte("test-secret"))
+
+	impact, err := uc.DeleteImpact("page-1", "alice")
+	assert.NoError(t, err)
+	assert.True(t, impact.RequiresConfirm())
+	if assert.Len(t, impact.OnlineUsers, 1) {
+		assert.Equal(t, "bob", impact.OnlineUsers[0].UserID)
+	}
+	assert.NotEmpty(t, impact.ConfirmToken)
+
+	assert.ErrorIs(t, uc.ConfirmDelete("page-1", "mallory", impact.ConfirmToken), domainErrors.ErrInvalidDeleteConfirmation)
+	assert.ErrorIs(t, uc.ConfirmDelete("page-1", "alice", impact.ConfirmToken+"x"), domainErrors.ErrInvalidDeleteConfirmation)
+	expired := uc.deleteToken("page-1", "alice", time.Now().Add(-time.Second))
+	assert.ErrorIs(t, uc.ConfirmDelete("page-1", "alice", expired), domainErrors.ErrInvalidDeleteConfirmation)
+	assert.NotNil(t, hub.GetRoom("page-1"))
+
+	mockRepo.On("Delete", "page-1").Return(nil).Once()
+	assert.NoError(t, uc.ConfirmDelete("page-1", "alice", impact.ConfirmToken))
+	assert.Nil(t, hub.GetRoom("page-1"))
+	assert.Nil(t, hub.GetRoom("branch-1"))
+
+	impact, err = uc.DeleteImpact("page-2", "alice")
+	assert.NoError(t, err)
+	assert.False(t, impact.RequiresConfirm())
+	assert.Empty(t, impact.ConfirmToken)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestPageUseCase_SetChatPersistence 测试聊天持久化设置：只有创建者可以修改
+func TestPageUseCase_SetChatPersistence(t *testing.T) {
+	mockRepo := new(MockPageRepository)
+	hub := ws.NewHub(new(MockPageService))
+
+	mockRepo.On("GetByPageID", "page-1").Return(&entity.Page{PageID: "page-1", CreatorID: "owner"}, nil)
+	mockRepo.On("GetByPageID", "missing").Return(nil, nil)
+	mockRepo.On("SetChatPersisted", "page-1", false).Return(nil).Once()
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
+
+	assert.ErrorIs(t, uc.SetChatPersistence("missing", false), domainErrors.ErrPageNotFound)
+	assert.NoError(t, uc.SetChatPersistence("page-1", false))
+	mockRepo.AssertExpectations(t)
+}
+
+// TestPageUseCase_GuestEditAllowed 测试访客准入判断
+func TestPageUseCase_GuestEditAllowed(t *testing.T) {
+	mockRepo := new(MockPageRepository)
+	hub := ws.NewHub(new(MockPageService))
+
+	mockRepo.On("GetByPageID", "shared").Return(&entity.Page{PageID: "shared", LinkEditEnabled: true}, nil)
+	mockRepo.On("GetByPageID", "private").Return(&entity.Page{PageID: "private"}, nil)
+	mockRepo.On("GetByPageID", "missing").Return(nil, nil)
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
+
+	allowed, err := uc.GuestEditAllowed("shared")
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = uc.GuestEditAllowed("private")
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+
+	_, err = uc.GuestEditAllowed("missing")
+	assert.ErrorIs(t, err, domainErrors.ErrPageNotFound)
+}
+
+func TestPageUseCase_GetPresence(t *testing.T) {
+	// 测试场景：房间存在时返回在线用户；没有房间时返回空列表且不创建房间
+
+	mockPageService := new(MockPageService)
+	mockPageService.On("GetPageState", "live-page").Return([]byte(`{}`), int64(1), nil).Once()
+	mockPageService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	hub := ws.NewHub(mockPageService)
+	room, err := hub.GetOrCreateRoom("live-page")
+	assert.NoError(t, err)
+
+	alice := ws.UserInfo{UserID: "alice", UserName: "Alice"}
+	assert.NoError(t, room.Register(ws.NewClient(hub, nil, "live-page", alice)))
+
+	mockRepo := new(MockPageRepository)
+	mockRepo.On("GetAccessInfo", mock.Anything).Return(&entity.Page{CreatorID: "alice"}, nil)
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
+
+	users, err := uc.GetPresence("live-page", "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, []ws.UserInfo{alice}, users)
+
+	users, err = uc.GetPresence("idle-page", "alice")
+	assert.NoError(t, err)
+	assert.NotNil(t, users)
+	assert.Empty(t, users)
+	assert.Nil(t, hub.GetRoom("idle-page"))
+}
+
+// TestPageUseCase_KickUser 测试移出用户：只有创建者可以操作，用户不在线时报错
+func TestPageUseCase_KickUser(t *testing.T) {
+	mockRepo := new(MockPageRepository)
+	mockPageService := new(MockPageService)
+	mockPageService.On("GetPageState", "page-1").Return([]byte(`{}`), int64(1), nil).Once()
+	mockPageService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockRepo.On("GetByPageID", "page-1").Return(&entity.Page{PageID: "page-1", CreatorID: "owner"}, nil)
+
+	hub := ws.NewHub(mockPageService)
+	room, err := hub.GetOrCreateRoom("page-1")
+	assert.NoError(t, err)
+	assert.NoError(t, room.Register(ws.NewClient(hub, nil, "page-1", ws.UserInfo{UserID: "stale"})))
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
+
+	kicked, err := uc.KickUser("page-1", "stale")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, kicked)
+
+	_, err = uc.KickUser("page-1", "stale")
+	assert.ErrorIs(t, err, domainErrors.ErrUserNotInRoom)
+}
+
+// TestPageUseCase_RunBulkOps 测试批量操作：整组作为一个版本应用，无人在线时临时房间随后被销毁
+func TestPageUseCase_RunBulkOps(t *testing.T) {
+	schema := []byte(`{"rootId": 1, "components": {
+		"1": {"id": 1, "name": "Page", "desc": "页面", "children": [2]},
+		"2": {"id": 2, "name": "Button", "desc": "按钮", "parentId": 1, "props": {"text": "a"}}
+	}}`)
+	mockPageService := new(MockPageService)
+	mockPageService.On("GetPageState", "page-1").Return(schema, int64(5), nil)
+	mockPageService.On("SavePageState", "page-1", mock.Anything, int64(5), int64(6)).Return(nil).Once()
+
+	hub := ws.NewHub(mockPageService)
+	go hub.Run()
+	mockRepo := new(MockPageRepository)
+	mockRepo.On("GetAccessInfo", "page-1").Return(&entity.Page{PageID: "page-1", CreatorID: "alice"}, nil)
+	collaborators := new(MockCollaboratorRepository)
+	collaborators.On("GetRole", "page-1", "bob").Return(entity.RoleViewer, nil)
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), collaborators, nil, hub)
+
+	ops := []bulkops.Op{
+		{Type: bulkops.OpDuplicate, ComponentID: 2, Count: 3},
+		{Type: bulkops.OpRenumber, ComponentID: 1, Prop: "text", Template: "按钮 {n}"},
+	}
+
+	// 房间无人在线，每次调用后都会被销毁
+	released := func() bool { return hub.GetRoom("page-1") == nil }
+
+	// 只读协作者不能编辑，也不会为此创建房间
+	_, err := uc.RunBulkOps("page-1", "bob", 5, ops, false)
+	assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+	assert.True(t, released())
+
+	_, err = uc.RunBulkOps("page-1", "alice", 4, ops, false)
+	assert.ErrorIs(t, err, domainErrors.ErrOptimisticLock)
+	assert.Eventually(t, released, time.Second, 10*time.Millisecond)
+
+	_, err = uc.RunBulkOps("page-1", "alice", 0, []bulkops.Op{{Type: bulkops.OpDuplicate, ComponentID: 1, Count: 1}}, false)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidBulkOps)
+	assert.Eventually(t, released, time.Second, 10*time.Millisecond)
+
+	// 试运行返回将要得到的版本，但不保存
+	preview, err := uc.RunBulkOps("page-1", "alice", 5, ops, true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), preview.Version)
+	assert.NotEmpty(t, preview.Patches)
+	assert.Eventually(t, released, time.Second, 10*time.Millisecond)
+
+	result, err := uc.RunBulkOps("page-1", "alice", 5, ops, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), result.Version)
+	assert.Eventually(t, released, time.Second, 10*time.Millisecond)
+	mockPageService.AssertExpectations(t)
+
+	saved := mockPageService.Calls[len(mockPageService.Calls)-1].Arguments.Get(1).([]byte)
+	var page entity.PageSchema
+	assert.NoError(t, json.Unmarshal(saved, &page))
+	assert.Len(t, page.Components, 5)
+	assert.Equal(t, []int64{2, 3, 4, 5}, page.Components["1"].Children)
+	assert.JSONEq(t, `{"text": "按钮 4"}`, string(page.Components["5"].Props))
+	assert.Contains(t, string(saved), `"userId":"alice"`)
+}
+
+func TestPageUseCase_PollPage(t *testing.T) {
+	// 测试场景：无人在线时为轮询临时创建房间，超时返回 nil 且房间随后销毁；
+	// 房间从数据库加载后内存窗口为空，无法追赶旧版本时要求重新同步
+
+	mockPageService := new(MockPageService)
+	mockPageService.On("GetPageState", "page-1").Return([]byte(`{}`), int64(5), nil)
+	mockPageService.On("SavePageState", "page-1", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockPageService.On("GetPageState", "missing").Return(nil, int64(0), domainErrors.ErrPageNotFound)
+
+	hub := ws.NewHub(mockPageService)
+	go hub.Run()
+	mockRepo := new(MockPageRepository)
+	mockRepo.On("GetAccessInfo", "page-1").Return(&entity.Page{PageID: "page-1", CreatorID: "alice"}, nil)
+	mockRepo.On("GetAccessInfo", "missing").Return(nil, nil)
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, hub)
+	released := func() bool { return hub.GetRoom("page-1") == nil }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	result, err := uc.PollPage(ctx, "page-1", "alice", 5)
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+	assert.Eventually(t, released, time.Second, 10*time.Millisecond)
+
+	result, err = uc.PollPage(context.Background(), "page-1", "alice", 3)
+	assert.NoError(t, err)
+	if assert.NotNil(t, result) {
+		assert.True(t, result.Resync)
+		assert.Equal(t, int64(5), result.Version)
+	}
+	assert.Eventually(t, released, time.Second, 10*time.Millisecond)
+
+	_, err = uc.PollPage(context.Background(), "missing", "alice", 1)
+	assert.ErrorIs(t, err, domainErrors.ErrPageNotFound)
+}
+
+func TestPageUseCase_PageRole(t *testing.T) {
+	// 测试场景：创建者为 owner；协作者按记录的角色；开启链接编辑后任何登录用户至少为 editor；
+	// 其他用户无权访问，页面不存在时报错
+
+	mockRepo := new(MockPageRepository)
+	mockRepo.On("GetAccessInfo", "private").Return(&entity.Page{PageID: "private", CreatorID: "owner"}, nil)
+	mockRepo.On("GetAccessInfo", "shared").Return(&entity.Page{PageID: "shared", CreatorID: "owner", LinkEditEnabled: true}, nil)
+	mockRepo.On("GetAccessInfo", "missing").Return(nil, nil)
+
+	collaborators := new(MockCollaboratorRepository)
+	collaborators.On("GetRole", mock.Anything, "editor-user").Return(entity.RoleEditor, nil)
+	collaborators.On("GetRole", mock.Anything, "viewer-user").Return(entity.RoleViewer, nil)
+	collaborators.On("GetRole", mock.Anything, "stranger").Return("", nil)
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), collaborators, nil, ws.NewHub(new(MockPageService)))
+
+	testCases := []struct {
+		pageID, userID string
+		expectedRole   string
+		expectedErr    error
+	}{
+		{"private", "owner", entity.RoleOwner, nil},
+		{"private", "editor-user", entity.RoleEditor, nil},
+		{"private", "viewer-user", entity.RoleViewer, nil},
+		{"private", "stranger", "", domainErrors.ErrUnauthorized},
+		{"shared", "viewer-user", entity.RoleEditor, nil},
+		{"shared", "stranger", entity.RoleEditor, nil},
+		{"missing", "owner", "", domainErrors.ErrPageNotFound},
+	}
+	for _, tc := range testCases {
+		role, err := uc.PageRole(tc.pageID, tc.userID)
+		assert.ErrorIs(t, err, tc.expectedErr, "%s/%s", tc.pageID, tc.userID)
+		assert.Equal(t, tc.expectedRole, role, "%s/%s", tc.pageID, tc.userID)
+	}
+}
+
+func TestPageUseCase_PageRole_Org(t *testing.T) {
+	// 测试场景：组织页面按组织角色授权，admin 为 owner、member 为 editor、其他角色为 viewer；
+	// 协作者角色与组织角色取较高者；个人页面不查询组织成员关系
+
+	mockRepo := new(MockPageRepository)
+	mockRepo.On("GetAccessInfo", "team").Return(&entity.Page{PageID: "team", CreatorID: "owner", OrgID: "org-1"}, nil)
+	mockRepo.On("GetAccessInfo", "personal").Return(&entity.Page{PageID: "personal", CreatorID: "owner"}, nil)
+
+	collaborators := new(MockCollaboratorRepository)
+	collaborators.On("GetRole", mock.Anything, "guest").Return(entity.RoleEditor, nil)
+	collaborators.On("GetRole", mock.Anything, mock.Anything).Return("", nil)
+
+	orgMembers := new(MockOrgMemberRepository)
+	orgMembers.On("GetRole", "org-1", "admin").Return(entity.OrgRoleAdmin, nil)
+	orgMembers.On("GetRole", "org-1", "member").Return(entity.OrgRoleMember, nil)
+	orgMembers.On("GetRole", "org-1", "guest").Return("org:guest", nil)
+	orgMembers.On("GetRole", "org-1", "stranger").Return("", nil)
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), collaborators, orgMembers, ws.NewHub(new(MockPageService)))
+
+	testCases := []struct {
+		pageID, userID string
+		expectedRole   string
+		expectedErr    error
+	}{
+		{"team", "admin", entity.RoleOwner, nil},
+		{"team", "member", entity.RoleEditor, nil},
+		{"team", "guest", entity.RoleEditor, nil},
+		{"team", "stranger", "", domainErrors.ErrUnauthorized},
+		{"personal", "admin", "", domainErrors.ErrUnauthorized},
+	}
+	for _, tc := range testCases {
+		role, err := uc.PageRole(tc.pageID, tc.userID)
+		assert.ErrorIs(t, err, tc.expectedErr, "%s/%s", tc.pageID, tc.userID)
+		assert.Equal(t, tc.expectedRole, role, "%s/%s", tc.pageID, tc.userID)
+	}
+	orgMembers.AssertNotCalled(t, "GetRole", "", mock.Anything)
+}
+
+func TestPageUseCase_ListPages(t *testing.T) {
+	// 测试场景：未激活组织时列出个人页面；激活组织时记录成员关系并列出组织页面；limit 取默认值并截断到上限
+
+	mockRepo := new(MockPageRepository)
+	personal := []*entity.Page{{PageID: "mine", CreatorID: "alice"}}
+	team := []*entity.Page{{PageID: "team", CreatorID: "bob", OrgID: "org-1"}}
+	mockRepo.On("ListByCreator", "alice", DefaultListPages).Return(personal, nil).Once()
+	mockRepo.On("ListByOrg", "org-1", MaxListPages).Return(team, nil).Once()
+
+	orgMembers := new(MockOrgMemberRepository)
+	orgMembers.On("Upsert", "org-1", "alice", entity.OrgRoleMember).Return(nil).Once()
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), orgMembers, ws.NewHub(new(MockPageService)))
+
+	pages, err := uc.ListPages("alice", OrgClaims{}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, personal, pages)
+
+	pages, err = uc.ListPages("alice", OrgClaims{OrgID: "org-1", OrgRole: entity.OrgRoleMember}, MaxListPages+1)
+	assert.NoError(t, err)
+	assert.Equal(t, team, pages)
+
+	mockRepo.AssertExpectations(t)
+	orgMembers.AssertExpectations(t)
+}
+
+// TestPageUseCase_AddCollaborator 测试添加协作者：角色必须有效，不能把创建者添加为协作者
+func TestPageUseCase_AddCollaborator(t *testing.T) {
+	mockRepo := new(MockPageRepository)
+	mockRepo.On("GetAccessInfo", "page-1").Return(&entity.Page{PageID: "page-1", CreatorID: "owner"}, nil)
+	collaborators := new(MockCollaboratorRepository)
+	collaborators.On("Upsert", mock.MatchedBy(func(c *entity.PageCollaborator) bool {
+		return c.PageID == "page-1" && c.UserID == "bob" && c.Role == entity.RoleViewer && c.AddedBy == "owner"
+	})).Return(nil).Once()
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), collaborators, nil, ws.NewHub(new(MockPageService)))
+
+	_, err := uc.AddCollaborator("page-1", "owner", "bob", entity.RoleOwner)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidRole)
+
+	_, err = uc.AddCollaborator("page-1", "owner", "owner", entity.RoleEditor)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidRole)
+
+	collaborator, err := uc.AddCollaborator("page-1", "owner", "bob", entity.RoleViewer)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.RoleViewer, collaborator.Role)
+	collaborators.AssertExpectations(t)
+}
+
+func TestPageUseCase_RemoveCollaborator(t *testing.T) {
+	// 测试场景：协作者可以退出，其他人只能由所有者移除；被移除的在线用户立即被移出房间
+
+	mockPageService := new(MockPageService)
+	mockPageService.On("GetPageState", "page-1").Return([]byte(`{}`), int64(1), nil).Once()
+	mockPageService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	hub := ws.NewHub(mockPageService)
+	room, err := hub.GetOrCreateRoom("page-1")
+	assert.NoError(t, err)
+	assert.NoError(t, room.Register(ws.NewClient(hub, nil, "page-1", ws.UserInfo{UserID: "bob"})))
+
+	mockRepo := new(MockPageRepository)
+	mockRepo.On("GetAccessInfo", "page-1").Return(&entity.Page{PageID: "page-1", CreatorID: "owner"}, nil)
+	collaborators := new(MockCollaboratorRepository)
+	collaborators.On("Remove", "page-1", "carol").Return(true, nil).Once()
+	collaborators.On("Remove", "page-1", "bob").Return(true, nil).Once()
+	collaborators.On("Remove", "page-1", "bob").Return(false, nil).Once()
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), collaborators, nil, hub)
+
+	assert.ErrorIs(t, uc.RemoveCollaborator("page-1", "bob", entity.RoleEditor, "carol"), domainErrors.ErrUnauthorized)
+	assert.NoError(t, uc.RemoveCollaborator("page-1", "carol", entity.RoleViewer, "carol"))
+
+	assert.NoError(t, uc.RemoveCollaborator("page-1", "owner", entity.RoleOwner, "bob"))
+	assert.Empty(t, room.Users())
+
+	assert.ErrorIs(t, uc.RemoveCollaborator("page-1", "owner", entity.RoleOwner, "bob"), domainErrors.ErrCollaboratorNotFound)
+	collaborators.AssertExpectations(t)
+}
+
+// TestPageUseCase_UpdateCollabSettings 测试协同设置：省略的字段保持不变，超出范围时报错
+func TestPageUseCase_UpdateCollabSettings(t *testing.T) {
+	mockRepo := new(MockPageRepository)
+	mockRepo.On("GetByPageID", "page-1").Return(&entity.Page{
+		PageID:         "page-1",
+		CreatorID:      "owner",
+		CollabSettings: datatypes.JSON(`{"cursors": false, "selections": true, "chat": true}`),
+	}, nil)
+	mockRepo.On("SetCollabSettings", "page-1", entity.CollabSettings{
+		Cursors: false, Selections: true, Chat: false, FlushIntervalSeconds: 60,
+	}).Return(nil).Once()
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, ws.NewHub(new(MockPageService)))
+	disabled, interval, tooShort := false, 60, 1
+
+	_, err := uc.UpdateCollabSettings("page-1", CollabSettingsUpdate{FlushIntervalSeconds: &tooShort})
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidCollabSettings)
+
+	unknown := entity.ConflictStrategy("ot")
+	_, err = uc.UpdateCollabSettings("page-1", CollabSettingsUpdate{Conflict: &unknown})
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidCollabSettings)
+
+	settings, err := uc.UpdateCollabSettings("page-1", CollabSettingsUpdate{Chat: &disabled, FlushIntervalSeconds: &interval})
+	assert.NoError(t, err)
+	assert.False(t, settings.Cursors)
+	assert.False(t, settings.Chat)
+	assert.Equal(t, 60, settings.FlushIntervalSeconds)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPageUseCase_SetSessionWindow(t *testing.T) {
+	// 测试场景：已结束或超长的时段无效；设置时保留其他协同设置，传 nil 取消
+
+	mockRepo := new(MockPageRepository)
+	mockRepo.On("GetByPageID", "page-1").Return(&entity.Page{
+		PageID:         "page-1",
+		CreatorID:      "owner",
+		CollabSettings: datatypes.JSON(`{"cursors": false, "selections": true, "chat": true}`),
+	}, nil)
+
+	start := time.Now().Add(time.Hour).Truncate(time.Second)
+	window := &entity.SessionWindow{StartsAt: start, EndsAt: start.Add(2 * time.Hour)}
+	mockRepo.On("SetCollabSettings", "page-1", entity.CollabSettings{
+		Cursors: false, Selections: true, Chat: true, Session: window,
+	}).Return(nil).Once()
+	mockRepo.On("SetCollabSettings", "page-1", entity.CollabSettings{
+		Cursors: false, Selections: true, Chat: true,
+	}).Return(nil).Once()
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, ws.NewHub(new(MockPageService)))
+
+	ended := &entity.SessionWindow{StartsAt: time.Now().Add(-2 * time.Hour), EndsAt: time.Now().Add(-time.Hour)}
+	_, err := uc.SetSessionWindow("page-1", ended)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidSessionWindow)
+
+	tooLong := &entity.SessionWindow{StartsAt: start, EndsAt: start.Add(entity.MaxSessionDuration + time.Hour)}
+	_, err = uc.SetSessionWindow("page-1", tooLong)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidSessionWindow)
+
+	settings, err := uc.SetSessionWindow("page-1", window)
+	assert.NoError(t, err)
+	assert.Equal(t, window, settings.Session)
+	assert.False(t, settings.Cursors)
+
+	settings, err = uc.SetSessionWindow("page-1", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, settings.Session)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPageUseCase_RunBulkOps_SessionClosed(t *testing.T) {
+	// 测试场景：协作时段外编辑者的批量操作返回 ErrSessionClosed，所有者不受限
+
+	schema := []byte(`{"rootId": 1, "components": {"1": {"id": 1, "name": "Page", "props": {"text": "a"}}}}`)
+	mockPageService := new(MockPageService)
+	mockPageService.On("GetPageState", "page-1").Return(schema, int64(1), nil).Once()
+	mockPageService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	hub := ws.NewHub(mockPageService)
+	room, err := hub.GetOrCreateRoom("page-1")
+	assert.NoError(t, err)
+	defer room.Stop()
+	assert.NoError(t, room.Register(ws.NewClient(hub, nil, "page-1", ws.UserInfo{UserID: "alice", Role: entity.RoleOwner})))
+
+	start := time.Now().Add(time.Hour)
+	settings := entity.DefaultCollabSettings()
+	settings.Session = &entity.SessionWindow{StartsAt: start, EndsAt: start.Add(time.Hour)}
+	room.SetCollabSettings(settings)
+	assert.Eventually(t, func() bool { return !room.SessionOpen() }, time.Second, 10*time.Millisecond)
+
+	mockRepo := new(MockPageRepository)
+	mockRepo.On("GetAccessInfo", "page-1").Return(&entity.Page{PageID: "page-1", CreatorID: "alice"}, nil)
+	collaborators := new(MockCollaboratorRepository)
+	collaborators.On("GetRole", "page-1", "bob").Return(entity.RoleEditor, nil)
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), collaborators, nil, hub)
+
+	ops := []bulkops.Op{{Type: bulkops.OpRenumber, ComponentID: 1, Prop: "text", Template: "按钮 {n}"}}
+	_, err = uc.RunBulkOps("page-1", "bob", 0, ops, false)
+	assert.ErrorIs(t, err, domainErrors.ErrSessionClosed)
+
+	_, err = uc.RunBulkOps("page-1", "alice", 0, ops, true)
+	assert.NoError(t, err)
+}
+
+func TestPageUseCase_RunBulkOps_Presentation(t *testing.T) {
+	// 测试场景：演示模式下非演示者的批量操作返回 ErrPresentationActive，演示者不受限
+
+	schema := []byte(`{"rootId": 1, "components": {"1": {"id": 1, "name": "Page", "props": {"text": "a"}}}}`)
+	mockPageService := new(MockPageService)
+	mockPageService.On("GetPageState", "page-1").Return(schema, int64(1), nil).Once()
+	mockPageService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	hub := ws.NewHub(mockPageService)
+	room, err := hub.GetOrCreateRoom("page-1")
+	assert.NoError(t, err)
+	defer room.Stop()
+	alice := ws.NewClient(hub, nil, "page-1", ws.UserInfo{UserID: "alice", Role: entity.RoleOwner})
+	assert.NoError(t, room.Register(alice))
+
+	room.SetPresentation(alice, true, "alice")
+	assert.Eventually(t, func() bool { return !room.MayEdit("bob") }, time.Second, 10*time.Millisecond)
+
+	mockRepo := new(MockPageRepository)
+	mockRepo.On("GetAccessInfo", "page-1").Return(&entity.Page{PageID: "page-1", CreatorID: "alice"}, nil)
+	collaborators := new(MockCollaboratorRepository)
+	collaborators.On("GetRole", "page-1", "bob").Return(entity.RoleEditor, nil)
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), collaborators, nil, hub)
+
+	ops := []bulkops.Op{{Type: bulkops.OpRenumber, ComponentID: 1, Prop: "text", Template: "按钮 {n}"}}
+	_, err = uc.RunBulkOps("page-1", "bob", 0, ops, false)
+	assert.ErrorIs(t, err, domainErrors.ErrPresentationActive)
+
+	_, err = uc.RunBulkOps("page-1", "alice", 0, ops, true)
+	assert.NoError(t, err)
+}
+
+func TestPageUseCase_AddCollaborator_Downgrade(t *testing.T) {
+	// 测试场景：在线的协作者被降为 viewer 时立即被移出房间，重新连接后以只读身份加入；
+	// 升为 editor 不影响在线连接
+
+	mockPageService := new(MockPageService)
+	mockPageService.On("GetPageState", "page-1").Return([]byte(`{}`), int64(1), nil).Once()
+	mockPageService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	hub := ws.NewHub(mockPageService)
+	room, err := hub.GetOrCreateRoom("page-1")
+	assert.NoError(t, err)
+	assert.NoError(t, room.Register(ws.NewClient(hub, nil, "page-1", ws.UserInfo{UserID: "bob", Role: entity.RoleEditor})))
+	assert.NoError(t, room.Register(ws.NewClient(hub, nil, "page-1", ws.UserInfo{UserID: "carol", Role: entity.RoleViewer})))
+
+	mockRepo := new(MockPageRepository)
+	mockRepo.On("GetAccessInfo", "page-1").Return(&entity.Page{PageID: "page-1", CreatorID: "owner"}, nil)
+	collaborators := new(MockCollaboratorRepository)
+	collaborators.On("Upsert", mock.Anything).Return(nil)
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), collaborators, nil, hub)
+
+	_, err = uc.AddCollaborator("page-1", "owner", "carol", entity.RoleEditor)
+	assert.NoError(t, err)
+	assert.Len(t, room.Users(), 2)
+
+	_, err = uc.AddCollaborator("page-1", "owner", "bob", entity.RoleViewer)
+	assert.NoError(t, err)
+	users := room.Users()
+	if assert.Len(t, users, 1) {
+		assert.Equal(t, "carol", users[0].UserID)
+	}
+}
@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"log"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/logging"
+)
+
+// RetentionPolicy 历史版本保留策略
+//   - 最近 KeepAll 内的快照全部保留
+//   - KeepAll ~ KeepHourly 之间每小时保留最新一个
+//   - 更早的快照每天保留最新一个
+//
+// 已被全量快照覆盖的差量只保留 KeepAll 内的部分，更早的编辑以快照为准。
+//
+// 操作日志只保留 JournalMaxAge 内（为 0 时取 KeepAll）、且每个页面最近 JournalMaxOps 条
+// （<= 0 不限条数）。按条数截断前先在截断点补写检查点快照，历史版本对比不受影响。
+type RetentionPolicy struct {
+	KeepAll    time.Duration
+	KeepHourly time.Duration
+
+	JournalMaxOps int
+	JournalMaxAge time.Duration
+}
+
+// journalCutoff 操作日志按时间清理的截止时刻
+func (p RetentionPolicy) journalCutoff(now time.Time) time.Time {
+	if p.JournalMaxAge > 0 {
+		return now.Add(-p.JournalMaxAge)
+	}
+	return now.Add(-p.KeepAll)
+}
+
+// CompactResult 一次压缩的结果
+type CompactResult struct {
+	Pages           int   `json:"pages"`
+	VersionsDeleted int   `json:"versionsDeleted"`
+	OpsDeleted      int64 `json:"opsDeleted"`
+	DeltasDeleted   int64 `json:"deltasDeleted"`
+
+	JournalsTruncated  int `json:"journalsTruncated"`  // 按条数截断操作日志的页面数
+	CheckpointsCreated int `json:"checkpointsCreated"` // 截断前补写的检查点快照数
+}
+
+// RetentionUseCase 历史版本与操作日志的保留和压缩
+type RetentionUseCase struct {
+	versionRepo repository.PageVersionRepository
+	opRepo      repository.PageOpRepository
+	policy      RetentionPolicy
+}
+
+// NewRetentionUseCase 创建 RetentionUseCase 实例
+func NewRetentionUseCase(versionRepo repository.PageVersionRepository, opRepo repository.PageOpRepository, policy RetentionPolicy) *RetentionUseCase {
+	return &RetentionUseCase{versionRepo: versionRepo, opRepo: opRepo, policy: policy}
+}
+
+// Compact 按保留策略删除多余的快照和过期的操作日志。
+// 单个页面失败只记录日志，继续处理其余页面。
+func (uc *RetentionUseCase) Compact(now time.Time) (*CompactResult, error) {
+	cutoff := now.Add(-uc.policy.KeepAll)
+	result := &CompactResult{}
+
+	pageIDs, err := uc.versionRepo.ListPageIDsBefore(cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pageID := range pageIDs {
+		versions, err := uc.versionRepo.ListMetaBefore(pageID, cutoff)
+		if err != nil {
+			logging.Errorf("[Retention] 读取页面 %s 的快照失败: %v", pageID, err)
+			continue
+		}
+
+		ids := selectExpiredVersions(versions, now, uc.policy)
+		if err := uc.versionRepo.DeleteByIDs(ids); err != nil {
+			logging.Errorf("[Retention] 删除页面 %s 的 %d 个快照失败: %v", pageID, len(ids), err)
+			continue
+		}
+		result.Pages++
+		result.VersionsDeleted += len(ids)
+	}
+
+	if result.OpsDeleted, err = uc.opRepo.DeleteBefore(uc.policy.journalCutoff(now)); err != nil {
+		return result, err
+	}
+	if err := uc.truncateJournals(now, result); err != nil {
+		return result, err
+	}
+	if result.DeltasDeleted, err = uc.versionRepo.DeleteDeltasBefore(cutoff); err != nil {
+		return result, err
+	}
+
+	log.Printf("[Retention] 压缩完成: 页面 %d，删除快照 %d，删除操作日志 %d（截断 %d 个页面，补写检查点 %d），删除差量 %d",
+		result.Pages, result.VersionsDeleted, result.OpsDeleted,
+		result.JournalsTruncated, result.CheckpointsCreated, result.DeltasDeleted)
+	return result, nil
+}
+
+// truncateJournals 将操作日志超过 JournalMaxOps 条的页面截断到最近 JournalMaxOps 条。
+// 截断点没有全量快照时，先回放差量得到该版本的状态并保存为检查点；
+// 无法重建检查点的页面保持原样，避免历史版本出现无法回放的空洞。
+func (uc *RetentionUseCase) truncateJournals(now time.Time, result *CompactResult) error {
+	if uc.policy.JournalMaxOps <= 0 {
+		return nil
+	}
+
+	sizes, err := uc.opRepo.ListOversized(uc.policy.JournalMaxOps)
+	if err != nil {
+		return err
+	}
+
+	for _, size := range sizes {
+		through := size.MaxVersion - int64(uc.policy.JournalMaxOps)
+
+		checkpoint, err := uc.versionRepo.GetByVersion(size.PageID, through)
+		if err != nil {
+			logging.Errorf("[Retention] 读取页面 %s 版本 %d 的快照失败: %v", size.PageID, through, err)
+			continue
+		}
+		if checkpoint == nil {
+			logging.Warnf("[Retention] 页面 %s 无法重建版本 %d，跳过操作日志截断", size.PageID, through)
+			continue
+		}
+		// ID 为 0 表示由差量回放得到，需要落盘为检查点
+		if checkpoint.ID == 0 {
+			checkpoint.CreatedAt = now
+			if err := uc.versionRepo.SaveCheckpoint(checkpoint); err != nil {
+				logging.Errorf("[Retention] 保存页面 %s 版本 %d 的检查点失败: %v", size.PageID, through, err)
+				continue
+			}
+			result.CheckpointsCreated++
+		}
+
+		deleted, err := uc.opRepo.DeleteThrough(size.PageID, through)
+		if err != nil {
+			logging.Errorf("[Retention] 截断页面 %s 的操作日志失败: %v", size.PageID, err)
+			continue
+		}
+		result.OpsDeleted += deleted
+		result.JournalsTruncated++
+	}
+	return nil
+}
+
+// RunPeriodic 每隔 interval 执行一次压缩，阻塞直到 stop 关闭
+func (uc *RetentionUseCase) RunPeriodic(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			if _, err := uc.Compact(now); err != nil {
+				logging.Errorf("[Retention] 压缩失败: %v", err)
+			}
+		}
+	}
+}
+
+// selectExpiredVersions 返回应删除的快照 ID。
+// versions 需按版本升序且均早于 KeepAll 窗口；每个时间桶保留版本最大的一个，
+// 因此页面最新的快照总会被保留。
+func selectExpiredVersions(versions []*entity.PageVersion, now time.Time, policy RetentionPolicy) []uint {
+	hourlyCutoff := now.Add(-policy.KeepHourly)
+
+	type bucketKey struct {
+		daily bool
+		start time.Time
+	}
+
+	// 倒序遍历，每个桶第一次遇到的即为该桶最新版本
+	kept := make(map[bucketKey]bool)
+	var expired []uint
+	for i := len(versions) - 1; i >= 0; i-- {
+		v := versions[i]
+		created := v.CreatedAt.UTC()
+
+		var bucket bucketKey
+		if created.After(hourlyCutoff) {
+			bucket = bucketKey{start: created.Truncate(time.Hour)}
+		} else {
+			bucket = bucketKey{daily: true, start: time.Date(created.Year(), created.Month(), created.Day(), 0, 0, 0, 0, time.UTC)}
+		}
+
+		if kept[bucket] {
+			expired = append(expired, v.ID)
+			continue
+		}
+		kept[bucket] = true
+	}
+	return expired
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/domain/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== RetentionUseCase 单元测试 ==========
+
+var testPolicy = RetentionPolicy{KeepAll: 24 * time.Hour, KeepHourly: 7 * 24 * time.Hour}
+
+// TestSelectExpiredVersions 测试按小时 / 按天分桶，每桶保留最新版本
+func TestSelectExpiredVersions(t *testing.T) {
+	now := time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return now.Add(-d) }
+
+	versions := []*entity.PageVersion{
+		// 10 天前同一天的两个版本：只保留较新的 2
+		{ID: 1, Version: 1, CreatedAt: time.Date(2024, 5, 10, 8, 0, 0, 0, time.UTC)},
+		{ID: 2, Version: 2, CreatedAt: time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)},
+		// 2 天前同一小时内的三个版本：只保留 5
+		{ID: 3, Version: 3, CreatedAt: at(48*time.Hour - 10*time.Minute)},
+		{ID: 4, Version: 4, CreatedAt: at(48*time.Hour - 20*time.Minute)},
+		{ID: 5, Version: 5, CreatedAt: at(48*time.Hour - 30*time.Minute)},
+		// 2 天前另一个小时：保留
+		{ID: 6, Version: 6, CreatedAt: at(46 * time.Hour)},
+	}
+
+	expired := selectExpiredVersions(versions, now, testPolicy)
+
+	assert.ElementsMatch(t, []uint{1, 3, 4}, expired)
+}
+
+// TestRetentionUseCase_Compact 测试压缩流程：逐页删除多余快照，并清理过期操作日志和差量
+func TestRetentionUseCase_Compact(t *testing.T) {
+	now := time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+	cutoff := now.Add(-testPolicy.KeepAll)
+
+	mockVersionRepo := new(MockPageVersionRepository)
+	mockOpRepo := new(MockPageOpRepository)
+
+	mockVersionRepo.On("ListPageIDsBefore", cutoff).Return([]string{"page-1"}, nil)
+	mockVersionRepo.On("ListMetaBefore", "page-1", cutoff).Return([]*entity.PageVersion{
+		{ID: 10, Version: 1, CreatedAt: now.Add(-30*time.Hour - 30*time.Minute)},
+		{ID: 11, Version: 2, CreatedAt: now.Add(-30*time.Hour - 10*time.Minute)},
+	}, nil)
+	mockVersionRepo.On("DeleteByIDs", []uint{10}).Return(nil)
+	mockOpRepo.On("DeleteBefore", cutoff).Return(int64(42), nil)
+	mockVersionRepo.On("DeleteDeltasBefore", cutoff).Return(int64(3), nil)
+
+	uc := NewRetentionUseCase(mockVersionRepo, mockOpRepo, testPolicy)
+	result, err := uc.Compact(now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &CompactResult{Pages: 1, VersionsDeleted: 1, OpsDeleted: 42, DeltasDeleted: 3}, result)
+	mockVersionRepo.AssertExpectations(t)
+	mockOpRepo.AssertExpectations(t)
+}
+
+// TestRetentionUseCase_Compact_TruncatesJournal 测试按条数截断操作日志：
+// 截断点只有差量时先补写检查点，无法重建的页面跳过
+func TestRetentionUseCase_Compact_TruncatesJournal(t *testing.T) {
+	now := time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+	policy := testPolicy
+	policy.JournalMaxOps = 100
+	policy.JournalMaxAge = 6 * time.Hour
+	cutoff := now.Add(-policy.KeepAll)
+
+	mockVersionRepo := new(MockPageVersionRepository)
+	mockOpRepo := new(MockPageOpRepository)
+
+	mockVersionRepo.On("ListPageIDsBefore", cutoff).Return([]string{}, nil)
+	mockOpRepo.On("DeleteBefore", now.Add(-policy.JournalMaxAge)).Return(int64(5), nil)
+	mockOpRepo.On("ListOversized", 100).Return([]repository.JournalSize{
+		{PageID: "page-1", Count: 150, MaxVersion: 300}, // 版本 200 由差量回放得到
+		{PageID: "page-2", Count: 120, MaxVersion: 120}, // 版本 20 已有快照
+		{PageID: "page-3", Count: 101, MaxVersion: 101}, // 版本 1 无法重建
+	}, nil)
+
+	replayed := &entity.PageVersion{PageID: "page-1", Version: 200}
+	mockVersionRepo.On("GetByVersion", "page-1", int64(200)).Return(replayed, nil)
+	mockVersionRepo.On("SaveCheckpoint", replayed).Return(nil)
+	mockOpRepo.On("DeleteThrough", "page-1", int64(200)).Return(int64(50), nil)
+
+	mockVersionRepo.On("GetByVersion", "page-2", int64(20)).Return(&entity.PageVersion{ID: 7, PageID: "page-2", Version: 20}, nil)
+	mockOpRepo.On("DeleteThrough", "page-2", int64(20)).Return(int64(20), nil)
+
+	mockVersionRepo.On("GetByVersion", "page-3", int64(1)).Return(nil, nil)
+
+	mockVersionRepo.On("DeleteDeltasBefore", cutoff).Return(int64(0), nil)
+
+	uc := NewRetentionUseCase(mockVersionRepo, mockOpRepo, policy)
+	result, err := uc.Compact(now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &CompactResult{OpsDeleted: 75, JournalsTruncated: 2, CheckpointsCreated: 1}, result)
+	assert.Equal(t, now, replayed.CreatedAt)
+	mockVersionRepo.AssertExpectations(t)
+	mockOpRepo.AssertExpectations(t)
+	mockOpRepo.AssertNotCalled(t, "DeleteThrough", "page-3", int64(1))
+}
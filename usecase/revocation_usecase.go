@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/authn"
+	"lowercode-go-server/internal/logging"
+	"lowercode-go-server/internal/ws"
+)
+
+// RevocationUseCase 登录会话吊销。
+// WebSocket 连接只在握手时验证一次 Token，之后可以远远超过 Token 的有效期；
+// Clerk 通知会话结束或用户被删除后，拒绝其尚未过期的 Token，并断开在线的协同连接。
+// 吊销记录写入数据库，各实例通过 Sync 定期同步，Webhook 只到达其中一个实例时其他实例也能断开连接
+type RevocationUseCase struct {
+	repo repository.RevokedSessionRepository
+	list *authn.Revocations
+	hub  *ws.Hub
+	ttl  time.Duration // 记录保留时长，应不短于 Token 的最长有效期
+
+	mu     sync.Mutex
+	lastID uint // 已同步的最大记录 ID
+}
+
+// NewRevocationUseCase 创建 RevocationUseCase 实例，list 应与验证 Token 时使用的吊销列表相同
+func NewRevocationUseCase(repo repository.RevokedSessionRepository, list *authn.Revocations, hub *ws.Hub, ttl time.Duration) *RevocationUseCase {
+	return &RevocationUseCase{repo: repo, list: list, hub: hub, ttl: ttl}
+}
+
+// RevokeSession 吊销登录会话：本实例立即生效，其他实例在下一次同步时生效
+func (uc *RevocationUseCase) RevokeSession(sessionID, userID string) error {
+	now := time.Now()
+	return uc.revoke(&entity.RevokedSession{
+		SessionID: sessionID,
+		UserID:    userID,
+		RevokedAt: now,
+		ExpiresAt: now.Add(uc.ttl),
+	})
+}
+
+// RevokeUser 吊销用户当前的全部登录会话，之后重新登录签发的 Token 不受影响
+func (uc *RevocationUseCase) RevokeUser(userID string) error {
+	now := time.Now()
+	return uc.revoke(&entity.RevokedSession{
+		UserID:    userID,
+		RevokedAt: now,
+		ExpiresAt: now.Add(uc.ttl),
+	})
+}
+
+// revoke 保存吊销记录并在本实例生效
+func (uc *RevocationUseCase) revoke(revoked *entity.RevokedSession) error {
+	if err := uc.repo.Create(revoked); err != nil {
+		return err
+	}
+	uc.apply(revoked)
+	return nil
+}
+
+// Sync 读取上次同步之后新增的吊销记录，加入吊销列表并断开对应的在线连接；
+// 同时清理已到期的记录。启动时调用一次可加载全部未到期的记录
+func (uc *RevocationUseCase) Sync(now time.Time) error {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	revoked, err := uc.repo.ListAfter(uc.lastID, now)
+	if err != nil {
+		return err
+	}
+	for _, r := range revoked {
+		// 本实例写入的记录已经生效，重复执行没有副作用
+		uc.apply(r)
+		uc.lastID = r.ID
+	}
+
+	uc.list.Prune(now)
+	if deleted, err := uc.repo.DeleteExpired(now); err != nil {
+		logging.Warnf("[Revocation] 清理到期的吊销记录失败: %v", err)
+	} else if deleted > 0 {
+		log.Printf("[Revocation] 清理 %d 条到期的吊销记录", deleted)
+	}
+	return nil
+}
+
+// apply 将吊销记录加入吊销列表，并断开对应的在线连接
+func (uc *RevocationUseCase) apply(revoked *entity.RevokedSession) {
+	if revoked.SessionID != "" {
+		uc.list.RevokeSession(revoked.SessionID, revoked.ExpiresAt)
+		uc.hub.DisconnectSession(revoked.SessionID)
+		return
+	}
+	uc.list.RevokeUser(revoked.UserID, revoked.RevokedAt, revoked.ExpiresAt)
+	uc.hub.DisconnectUser(revoked.UserID)
+}
+
+// RunPeriodic 每隔 interval 同步一次吊销记录，阻塞直到 stop 关闭
+func (uc *RevocationUseCase) RunPeriodic(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			if err := uc.Sync(now); err != nil {
+				logging.Errorf("[Revocation] 同步吊销记录失败: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/internal/authn"
+	"lowercode-go-server/internal/ws"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== RevocationUseCase 单元测试 ==========
+
+// newRevocationTestRoom 创建 page-1 的房间并加入 alice 的两个会话
+func newRevocationTestRoom(t *testing.T) (*ws.Hub, *ws.Room) {
+	t.Helper()
+	mockPageService := new(MockPageService)
+	mockPageService.On("GetPageState", "page-1").Return([]byte(`{}`), int64(1), nil)
+	mockPageService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	hub := ws.NewHub(mockPageService)
+	room, err := hub.GetOrCreateRoom("page-1")
+	require.NoError(t, err)
+	t.Cleanup(room.Stop)
+	for _, sid := range []string{"sess-1", "sess-2"} {
+		require.NoError(t, room.Register(ws.NewClient(hub, nil, "page-1", ws.UserInfo{UserID: "alice", SessionID: sid})))
+	}
+	return hub, room
+}
+
+func TestRevocationUseCase_RevokeSession(t *testing.T) {
+	// 测试场景：吊销会话后立即写入记录、拒绝该会话的 Token，并断开该会话的连接
+
+	hub, room := newRevocationTestRoom(t)
+	repo := new(MockRevokedSessionRepository)
+	repo.On("Create", mock.MatchedBy(func(r *entity.RevokedSession) bool {
+		return r.SessionID == "sess-1" && r.UserID == "alice" && r.ExpiresAt.Sub(r.RevokedAt) == time.Hour
+	})).Return(nil).Once()
+
+	list := authn.NewRevocations()
+	uc := NewRevocationUseCase(repo, list, hub, time.Hour)
+	require.NoError(t, uc.RevokeSession("sess-1", "alice"))
+
+	assert.True(t, list.Revoked(&authn.Claims{Subject: "alice", SessionID: "sess-1"}, time.Now()))
+	assert.False(t, list.Revoked(&authn.Claims{Subject: "alice", SessionID: "sess-2"}, time.Now()))
+	users := room.Users()
+	require.Len(t, users, 1)
+	assert.Equal(t, "sess-2", users[0].SessionID)
+	repo.AssertExpectations(t)
+}
+
+func TestRevocationUseCase_Sync(t *testing.T) {
+	// 测试场景：同步其他实例写入的记录后断开对应连接，下次只读取之后的记录，并清理到期记录
+
+	hub, room := newRevocationTestRoom(t)
+	now := time.Now()
+	repo := new(MockRevokedSessionRepository)
+	repo.On("ListAfter", uint(0), now).Return([]*entity.RevokedSession{
+		{ID: 3, SessionID: "sess-9", UserID: "bob", RevokedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{ID: 5, UserID: "alice", RevokedAt: now, ExpiresAt: now.Add(time.Hour)},
+	}, nil).Once()
+	repo.On("ListAfter", uint(5), now).Return([]*entity.RevokedSession{}, nil).Once()
+	repo.On("DeleteExpired", now).Return(int64(2), nil)
+
+	list := authn.NewRevocations()
+	uc := NewRevocationUseCase(repo, list, hub, time.Hour)
+	require.NoError(t, uc.Sync(now))
+	assert.Empty(t, room.Users())
+	assert.True(t, list.Revoked(&authn.Claims{Subject: "bob", SessionID: "sess-9"}, now))
+	assert.True(t, list.Revoked(&authn.Claims{Subject: "alice", IssuedAt: now.Add(-time.Minute)}, now))
+
+	require.NoError(t, uc.Sync(now))
+	repo.AssertExpectations(t)
+}
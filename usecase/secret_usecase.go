@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/secretprop"
+	"lowercode-go-server/internal/ws"
+)
+
+// SecretPages 密钥属性依赖的页面能力，由 PageUseCase 实现
+type SecretPages interface {
+	PageAccess
+	ReadPage(pageID string) (*entity.Page, error)
+}
+
+// SecretUseCase 组件的密钥属性（如数据源的 API Key）。
+// 明文经服务端加密后以信封写入 Schema，协同房间、历史版本和操作日志中都只有密文；
+// 非所有者读取页面时密文被清空，只保留掩码，发布的页面在公开访问时才解密
+type SecretUseCase struct {
+	pages SecretPages
+	hub   *ws.Hub
+	box   *secretprop.Box // 为 nil 时未配置加密密钥，不能设置密钥属性
+}
+
+// NewSecretUseCase 创建 SecretUseCase 实例
+func NewSecretUseCase(pages SecretPages, hub *ws.Hub, box *secretprop.Box) *SecretUseCase {
+	return &SecretUseCase{pages: pages, hub: hub, box: box}
+}
+
+// SecretProp 已写入 Schema 的密钥属性
+type SecretProp struct {
+	ComponentID int64
+	Prop        string
+	Mask        string
+	Version     int64 // 写入后的页面版本
+}
+
+// SecretValue 所有者查看的密钥明文，Valid 为 false 时密文已脱敏、来自其他页面或加密密钥已更换
+type SecretValue struct {
+	Path  string
+	Mask  string
+	Value string
+	Valid bool
+}
+
+// Set 把组件的 prop 属性设为密钥，作为一个版本提交到协同房间并广播（广播中只有密文），
+// 与实时编辑冲突时重试。所有者和编辑者可以设置，只读协作者返回 ErrUnauthorized，
+// 演示模式下演示者以外的用户返回 ErrPresentationActive
+func (uc *SecretUseCase) Set(pageID, userID string, componentID int64, prop, value string) (*SecretProp, error) {
+	if uc.box == nil {
+		return nil, domainErrors.ErrSecretsDisabled
+	}
+	prop = strings.TrimSpace(prop)
+	if prop == "" || value == "" || len(value) > secretprop.MaxLength {
+		return nil, fmt.Errorf("%w: prop 和 value 不能为空，value 最长 %d 字节", domainErrors.ErrInvalidSecret, secretprop.MaxLength)
+	}
+	role, err := uc.pages.PageRole(pageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == entity.RoleViewer {
+		return nil, domainErrors.ErrUnauthorized
+	}
+
+	envelope, err := uc.box.Envelope(pageID, value)
+	if err != nil {
+		return nil, err
+	}
+
+	room, err := uc.hub.GetOrCreateRoom(pageID)
+	if err != nil {
+		return nil, err
+	}
+	// 无人在线时房间只为本次修改而创建，完成后交给 Hub 刷盘销毁
+	defer uc.hub.ReleaseIfIdle(room)
+
+	if !room.MayEdit(userID) {
+		return nil, domainErrors.ErrPresentationActive
+	}
+
+	author := ws.UserInfo{UserID: userID, UserName: userID}
+	for attempt := 0; ; attempt++ {
+		snapshot, version := room.GetSnapshot()
+		patch, err := secretprop.Patch(snapshot, componentID, prop, envelope)
+		if errors.Is(err, secretprop.ErrComponentNotFound) {
+			return nil, fmt.Errorf("%w: 组件 %d 不存在", domainErrors.ErrInvalidSecret, componentID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := room.SubmitLabeledEdit(author, ws.LabelSecret, patch, version)
+		var conflict *ws.VersionConflictError
+		var patchErr *ws.PatchError
+		switch {
+		case err == nil:
+			return &SecretProp{
+				ComponentID: componentID,
+				Prop:        prop,
+				Mask:        envelope[secretprop.MaskKey],
+				Version:     result.Version,
+			}, nil
+		case errors.As(err, &conflict):
+			if attempt >= bulkOpsRetries {
+				return nil, domainErrors.ErrOptimisticLock
+			}
+		case errors.As(err, &patchErr):
+			return nil, fmt.Errorf("%w: %s", domainErrors.ErrInvalidSecret, patchErr.Reason)
+		case errors.Is(err, ws.ErrRoomClosed):
+			return nil, domainErrors.ErrRoomClosing
+		default:
+			return nil, err
+		}
+	}
+}
+
+// Reveal 解密页面中的全部密钥属性，只有所有者可以查看
+func (uc *SecretUseCase) Reveal(pageID, userID string) ([]SecretValue, error) {
+	if uc.box == nil {
+		return nil, domainErrors.ErrSecretsDisabled
+	}
+	role, err := uc.pages.PageRole(pageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role != entity.RoleOwner {
+		return nil, domainErrors.ErrUnauthorized
+	}
+
+	page, err := uc.pages.ReadPage(pageID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, domainErrors.ErrPageNotFound
+	}
+	refs, err := secretprop.Find(page.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]SecretValue, 0, len(refs))
+	for _, ref := range refs {
+		value, err := uc.box.Open(pageID, ref.Token)
+		values = append(values, SecretValue{Path: ref.Path, Mask: ref.Mask, Value: value, Valid: err == nil})
+	}
+	return values, nil
+}
@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/secretprop"
+	"lowercode-go-server/internal/ws"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+// ========== SecretUseCase 单元测试 ==========
+
+func newSecretBox(t *testing.T) *secretprop.Box {
+	box, err := secretprop.NewBox(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	return box
+}
+
+func TestSecretUseCase_Set(t *testing.T) {
+	// 测试场景：未配置加密密钥、参数为空、只读协作者或组件不存在时拒绝；
+	// 编辑者设置后作为一个版本提交，刷盘的 Schema 中只有密文和掩码，没有明文
+
+	schema := []byte(`{"rootId": 1, "components": {
+		"1": {"id": 1, "name": "Page", "children": [2]},
+		"2": {"id": 2, "name": "Fetch", "parentId": 1, "props": {"url": "https://api.example.com"}}
+	}}`)
+	var saved []byte
+	mockPageService := new(MockPageService)
+	mockPageService.On("GetPageState", "page-1").Return(schema, int64(5), nil)
+	mockPageService.On("SavePageState", "page-1", mock.Anything, mock.Anything, int64(6)).
+		Run(func(args mock.Arguments) { saved = args.Get(1).([]byte) }).Return(nil).Once()
+	mockPageService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	hub := ws.NewHub(mockPageService)
+	go hub.Run()
+
+	pages := new(MockSecretPages)
+	pages.On("PageRole", "page-1", "bob").Return(entity.RoleEditor, nil)
+	pages.On("PageRole", "page-1", "carol").Return(entity.RoleViewer, nil)
+	box := newSecretBox(t)
+
+	_, err := NewSecretUseCase(pages, hub, nil).Set("page-1", "bob", 2, "apiKey", "sk-1234567890")
+	assert.ErrorIs(t, err, domainErrors.ErrSecretsDisabled)
+
+	uc := NewSecretUseCase(pages, hub, box)
+	_, err = uc.Set("page-1", "bob", 2, " ", "sk-1234567890")
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidSecret)
+	_, err = uc.Set("page-1", "bob", 2, "apiKey", strings.Repeat("x", secretprop.MaxLength+1))
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidSecret)
+	_, err = uc.Set("page-1", "carol", 2, "apiKey", "sk-1234567890")
+	assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+	_, err = uc.Set("page-1", "bob", 9, "apiKey", "sk-1234567890")
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidSecret)
+
+	secret, err := uc.Set("page-1", "bob", 2, "apiKey", "sk-1234567890")
+	require.NoError(t, err)
+	assert.Equal(t, "••••7890", secret.Mask)
+	assert.Equal(t, int64(6), secret.Version)
+
+	// 房间释放时刷盘，保存的 Schema 中只有密文
+	assert.Eventually(t, func() bool { return hub.GetRoom("page-1") == nil }, time.Second, 10*time.Millisecond)
+	require.NotNil(t, saved)
+	assert.NotContains(t, string(saved), "sk-1234567890")
+	refs, err := secretprop.Find(saved)
+	require.NoError(t, err)
+	require.Len(t, refs, 1)
+	assert.Equal(t, "/components/2/props/apiKey", refs[0].Path)
+	plaintext, err := box.Open("page-1", refs[0].Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-1234567890", plaintext)
+}
+
+func TestSecretUseCase_Reveal(t *testing.T) {
+	// 测试场景：只有所有者可以查看明文；无法解密的密文（如从其他页面复制）标记为无效
+
+	box := newSecretBox(t)
+	own, err := box.Envelope("page-1", "sk-1234567890")
+	require.NoError(t, err)
+	copied, err := box.Envelope("page-2", "copied-secret")
+	require.NoError(t, err)
+
+	pages := new(MockSecretPages)
+	pages.On("PageRole", "page-1", "owner").Return(entity.RoleOwner, nil)
+	pages.On("PageRole", "page-1", "bob").Return(entity.RoleEditor, nil)
+	pages.On("ReadPage", "page-1").Return(&entity.Page{PageID: "page-1", Schema: datatypes.JSON(`{"components": {
+		"2": {"id": 2, "props": {"apiKey": {"$secret": "` + own[secretprop.Key] + `", "mask": "••••7890"}}},
+		"3": {"id": 3, "props": {"token": {"$secret": "` + copied[secretprop.Key] + `", "mask": "••••"}}}
+	}}`)}, nil)
+	uc := NewSecretUseCase(pages, ws.NewHub(new(MockPageService)), box)
+
+	_, err = uc.Reveal("page-1", "bob")
+	assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+
+	values, err := uc.Reveal("page-1", "owner")
+	require.NoError(t, err)
+	assert.Equal(t, []SecretValue{
+		{Path: "/components/2/props/apiKey", Mask: "••••7890", Value: "sk-1234567890", Valid: true},
+		{Path: "/components/3/props/token", Mask: "••••"},
+	}, values)
+}
+
+func TestPageUseCase_Secrets(t *testing.T) {
+	// 测试场景：非所有者读取页面时密文被清空；Schema 含无法解密的密钥属性或未配置加密密钥时拒绝发布；
+	// 公开访问发布副本时密钥属性解密为明文
+
+	box := newSecretBox(t)
+	envelope, err := box.Envelope("page-1", "sk-1234567890")
+	require.NoError(t, err)
+	schema := datatypes.JSON(`{"components": {"2": {"id": 2, "props": {"apiKey": {"$secret": "` + envelope[secretprop.Key] + `", "mask": "••••7890"}}}}}`)
+	foreign, err := box.Envelope("page-3", "sk-1234567890") // 从其他页面复制的密文
+	require.NoError(t, err)
+
+	mockRepo := new(MockPageRepository)
+	mockRepo.On("GetAccessInfo", "page-1").Return(&entity.Page{PageID: "page-1", CreatorID: "owner"}, nil)
+	mockRepo.On("GetByPageID", "page-1").Return(&entity.Page{
+		PageID: "page-1", CreatorID: "owner", Schema: schema, Version: 4,
+		PublishedSchema: schema, PublishedVersion: 4,
+	}, nil)
+	mockRepo.On("GetByPageID", "page-2").Return(&entity.Page{
+		PageID: "page-2", CreatorID: "owner", Version: 2,
+		Schema: datatypes.JSON(`{"components": {"2": {"id": 2, "props": {"apiKey": {"$secret": "` + foreign[secretprop.Key] + `"}}}}}`),
+	}, nil)
+	mockRepo.On("Publish", "page-1", []byte(schema), int64(4)).Return(nil)
+	collaborators := new(MockCollaboratorRepository)
+	collaborators.On("GetRole", "page-1", "bob").Return(entity.RoleEditor, nil)
+	hub := ws.NewHub(new(MockPageService))
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), collaborators, nil, hub)
+	_, err = uc.PublishPage("page-1")
+	assert.ErrorIs(t, err, domainErrors.ErrSecretsDisabled)
+
+	uc.EnableSecrets(box)
+	page, err := uc.GetPage("page-1", "bob")
+	require.NoError(t, err)
+	assert.NotContains(t, string(page.Schema), envelope[secretprop.Key])
+	assert.Contains(t, string(page.Schema), "••••7890")
+	page, err = uc.GetPage("page-1", "owner")
+	require.NoError(t, err)
+	assert.Contains(t, string(page.Schema), envelope[secretprop.Key])
+
+	_, err = uc.PublishPage("page-2")
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidSecret)
+	mockRepo.AssertNotCalled(t, "Publish", "page-2", mock.Anything, mock.Anything)
+
+	page, err = uc.PublishPage("page-1")
+	require.NoError(t, err)
+	assert.Contains(t, string(page.PublishedSchema), envelope[secretprop.Key])
+
+	page, err = uc.GetPublishedPage("page-1")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"components": {"2": {"id": 2, "props": {"apiKey": "sk-1234567890"}}}}`, string(page.PublishedSchema))
+}
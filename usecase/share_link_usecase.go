@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/ws"
+)
+
+// SharedPageReader 不经权限校验读取页面最新状态（协同房间内存优先），由 PageUseCase 实现
+type SharedPageReader interface {
+	ReadPage(pageID string) (*entity.Page, error)
+}
+
+// ShareLinkUseCase 页面公开只读分享链接业务逻辑
+type ShareLinkUseCase struct {
+	links  repository.ShareLinkRepository
+	pages  SharedPageReader
+	hub    *ws.Hub
+	secret []byte
+}
+
+// NewShareLinkUseCase 创建 ShareLinkUseCase 实例，secret 为 Token 签名密钥，更换后已发出的链接全部失效
+func NewShareLinkUseCase(links repository.ShareLinkRepository, pages SharedPageReader, hub *ws.Hub, secret []byte) *ShareLinkUseCase {
+	return &ShareLinkUseCase{links: links, pages: pages, hub: hub, secret: secret}
+}
+
+// Create 为页面创建分享链接，返回链接和签名 Token；调用者是否为所有者由路由上的 RequirePageRole 校验。
+// ttl 为 0 时使用 DefaultShareLinkTTL，超出 MaxShareLinkTTL 或为负数时返回 ErrInvalidShareLinkTTL
+func (uc *ShareLinkUseCase) Create(pageID, operatorID string, ttl time.Duration) (*entity.ShareLink, string, error) {
+	if ttl == 0 {
+		ttl = entity.DefaultShareLinkTTL
+	}
+	if ttl < 0 || ttl > entity.MaxShareLinkTTL {
+		return nil, "", domainErrors.ErrInvalidShareLinkTTL
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, "", err
+	}
+	link := &entity.ShareLink{
+		ID:        hex.EncodeToString(id),
+		PageID:    pageID,
+		CreatedBy: operatorID,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := uc.links.Create(link); err != nil {
+		return nil, "", err
+	}
+	return link, uc.Token(link), nil
+}
+
+// List 返回页面的全部分享链接（包括已过期和已撤销的）
+func (uc *ShareLinkUseCase) List(pageID string) ([]*entity.ShareLink, error) {
+	links, err := uc.links.ListByPage(pageID)
+	if err != nil {
+		return nil, err
+	}
+	if links == nil {
+		links = []*entity.ShareLink{}
+	}
+	return links, nil
+}
+
+// Revoke 撤销分享链接，通过该链接在线观看的连接立即被移出协同房间
+func (uc *ShareLinkUseCase) Revoke(pageID, linkID string) error {
+	revoked, err := uc.links.Revoke(pageID, linkID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return domainErrors.ErrShareLinkNotFound
+	}
+
+	if room := uc.hub.GetRoom(pageID); room != nil {
+		room.Kick((&entity.ShareLink{ID: linkID}).VisitorID(), "分享链接已被撤销")
+	}
+	return nil
+}
+
+// Verify 校验页面的分享 Token：签名正确、链接属于该页面、未过期且未撤销。
+// 校验失败统一返回 ErrShareLinkInvalid，不区分原因
+func (uc *ShareLinkUseCase) Verify(pageID, token string) (*entity.ShareLink, error) {
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(uc.sign(pageID, id))) {
+		return nil, domainErrors.ErrShareLinkInvalid
+	}
+
+	link, err := uc.links.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil || link.PageID != pageID || !link.Active(time.Now()) {
+		return nil, domainErrors.ErrShareLinkInvalid
+	}
+	return link, nil
+}
+
+// GetSharedPage 凭分享 Token 读取页面最新状态，密钥属性只保留掩码
+func (uc *ShareLinkUseCase) GetSharedPage(pageID, token string) (*entity.Page, error) {
+	if _, err := uc.Verify(pageID, token); err != nil {
+		return nil, err
+	}
+	page, err := uc.pages.ReadPage(pageID)
+	if err != nil || page == nil {
+		return page, err
+	}
+	return maskSecrets(page)
+}
+
+// Token 返回链接的签名 Token：<链接 ID>.<HMAC-SHA256(页面 ID, 链接 ID)>，
+// 签名绑定页面，无需查库即可拒绝伪造或用于其他页面的 Token
+func (uc *ShareLinkUseCase) Token(link *entity.ShareLink) string {
+	return link.ID + "." + uc.sign(link.PageID, link.ID)
+}
+
+// sign 计算页面与链接 ID 的签名
+func (uc *ShareLinkUseCase) sign(pageID, id string) string {
+	mac := hmac.New(sha256.New, uc.secret)
+	mac.Write([]byte(pageID + "." + id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
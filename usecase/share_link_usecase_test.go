@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/ws"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ========== ShareLinkUseCase 单元测试 ==========
+
+func newShareLinkTestUseCase() (*ShareLinkUseCase, *MockShareLinkRepository, *MockSharedPageReader) {
+	links := new(MockShareLinkRepository)
+	pages := new(MockSharedPageReader)
+	uc := NewShareLinkUseCase(links, pages, ws.NewHub(new(MockPageService)), []byte("secret"))
+	return uc, links, pages
+}
+
+func TestShareLinkUseCase_Create(t *testing.T) {
+	// 测试场景：有效期缺省为 7 天，超出 30 天或为负数时拒绝；返回的 Token 可通过校验
+
+	uc, links, _ := newShareLinkTestUseCase()
+	links.On("Create", mock.Anything).Return(nil)
+
+	link, token, err := uc.Create("page-1", "owner", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "page-1", link.PageID)
+	assert.Equal(t, "owner", link.CreatedBy)
+	assert.WithinDuration(t, time.Now().Add(entity.DefaultShareLinkTTL), link.ExpiresAt, time.Minute)
+	assert.Equal(t, uc.Token(link), token)
+
+	links.On("Get", link.ID).Return(link, nil)
+	verified, err := uc.Verify("page-1", token)
+	assert.NoError(t, err)
+	assert.Equal(t, link.ID, verified.ID)
+
+	for _, ttl := range []time.Duration{-time.Hour, entity.MaxShareLinkTTL + time.Hour} {
+		_, _, err = uc.Create("page-1", "owner", ttl)
+		assert.ErrorIs(t, err, domainErrors.ErrInvalidShareLinkTTL, ttl)
+	}
+	links.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestShareLinkUseCase_Verify(t *testing.T) {
+	// 测试场景：签名错误、用于其他页面、已过期或已撤销的 Token 均返回 ErrShareLinkInvalid，
+	// 签名错误时不查库；有效 Token 可读取页面
+
+	uc, links, pages := newShareLinkTestUseCase()
+	revokedAt := time.Now()
+	active := &entity.ShareLink{ID: "active", PageID: "page-1", ExpiresAt: time.Now().Add(time.Hour)}
+	expired := &entity.ShareLink{ID: "expired", PageID: "page-1", ExpiresAt: time.Now().Add(-time.Hour)}
+	revoked := &entity.ShareLink{ID: "revoked", PageID: "page-1", ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt}
+	for _, link := range []*entity.ShareLink{active, expired, revoked} {
+		links.On("Get", link.ID).Return(link, nil)
+	}
+	links.On("Get", "unknown").Return(nil, nil)
+	pages.On("ReadPage", "page-1").Return(&entity.Page{PageID: "page-1", Version: 3}, nil)
+
+	page, err := uc.GetSharedPage("page-1", uc.Token(active))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), page.Version)
+
+	invalid := []struct {
+		pageID string
+		token  string
+	}{
+		{"page-1", "active.forged"},
+		{"page-1", "no-signature"},
+		{"page-2", uc.Token(&entity.ShareLink{ID: "active", PageID: "page-2"})},
+		{"page-1", uc.Token(expired)},
+		{"page-1", uc.Token(revoked)},
+		{"page-1", uc.Token(&entity.ShareLink{ID: "unknown", PageID: "page-1"})},
+	}
+	for _, tc := range invalid {
+		_, err := uc.GetSharedPage(tc.pageID, tc.token)
+		assert.ErrorIs(t, err, domainErrors.ErrShareLinkInvalid, tc.token)
+	}
+
+	links.AssertNotCalled(t, "Get", "no-signature")
+	pages.AssertNumberOfCalls(t, "ReadPage", 1)
+}
+
+func TestShareLinkUseCase_Revoke(t *testing.T) {
+	// 测试场景：撤销成功；不存在或已撤销的链接返回 ErrShareLinkNotFound
+
+	uc, links, _ := newShareLinkTestUseCase()
+	links.On("Revoke", "page-1", "link-1", mock.Anything).Return(true, nil).Once()
+	links.On("Revoke", "page-1", "link-1", mock.Anything).Return(false, nil)
+
+	assert.NoError(t, uc.Revoke("page-1", "link-1"))
+	assert.ErrorIs(t, uc.Revoke("page-1", "link-1"), domainErrors.ErrShareLinkNotFound)
+	links.AssertNumberOfCalls(t, "Revoke", 2)
+}
@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"fmt"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+)
+
+// StorageQuota 写入新数据前的配额检查，由 StorageUseCase 实现
+type StorageQuota interface {
+	// CheckQuota 租户写入 extra 字节后超出套餐上限时返回 ErrStorageQuotaExceeded
+	CheckQuota(tenantID string, extra int64) error
+}
+
+// checkQuota 未启用配额时放行
+func checkQuota(quota StorageQuota, tenantID string, extra int64) error {
+	if quota == nil {
+		return nil
+	}
+	return quota.CheckQuota(tenantID, extra)
+}
+
+// 租户用量列表的条数
+const (
+	DefaultListTenants = 20
+	MaxListTenants     = 100
+)
+
+// TenantStorage 租户的存储用量与套餐
+type TenantStorage struct {
+	Usage      *entity.StorageUsage
+	Plan       string
+	LimitBytes int64 // 0 表示不限
+}
+
+// Exceeded 用量是否已达套餐上限
+func (t *TenantStorage) Exceeded() bool {
+	return t.LimitBytes > 0 && t.Usage.Total() >= t.LimitBytes
+}
+
+// StorageUseCase 租户存储用量与套餐配额。
+// 用量由各写入路径在同一事务中维护（见 repository.PageStorage），这里只读取汇总；
+// 配额在创建页面、导入、创建分支和发布时检查，协同编辑的刷盘不受限制，避免丢失已提交的修改
+type StorageUseCase struct {
+	storage     repository.StorageRepository
+	limits      map[string]int64 // 套餐 → 存储上限（字节），0 表示不限
+	defaultPlan string
+}
+
+// NewStorageUseCase 创建 StorageUseCase 实例
+func NewStorageUseCase(storage repository.StorageRepository, limits map[string]int64, defaultPlan string) *StorageUseCase {
+	return &StorageUseCase{storage: storage, limits: limits, defaultPlan: defaultPlan}
+}
+
+// Get 返回租户的用量、套餐和上限
+func (uc *StorageUseCase) Get(tenantID string) (*TenantStorage, error) {
+	usage, err := uc.storage.TenantUsage(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.withPlan(usage)
+}
+
+// ListTop 按总用量降序返回用量最大的租户，供运维排查滥用
+func (uc *StorageUseCase) ListTop(limit int) ([]*TenantStorage, error) {
+	if limit <= 0 {
+		limit = DefaultListTenants
+	}
+	if limit > MaxListTenants {
+		limit = MaxListTenants
+	}
+
+	usages, err := uc.storage.ListTopTenants(limit)
+	if err != nil {
+		return nil, err
+	}
+	tenants := make([]*TenantStorage, 0, len(usages))
+	for _, usage := range usages {
+		tenant, err := uc.withPlan(usage)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenant)
+	}
+	return tenants, nil
+}
+
+// SetPlan 设置租户的套餐，立即按新上限检查配额
+func (uc *StorageUseCase) SetPlan(tenantID, plan string) (*TenantStorage, error) {
+	if tenantID == "" || !entity.ValidPlan(plan) {
+		return nil, fmt.Errorf("%w: %q", domainErrors.ErrInvalidPlan, plan)
+	}
+	if err := uc.storage.SetPlan(tenantID, plan); err != nil {
+		return nil, err
+	}
+	return uc.Get(tenantID)
+}
+
+// CheckQuota 租户写入 extra 字节后超出套餐上限时返回 ErrStorageQuotaExceeded
+func (uc *StorageUseCase) CheckQuota(tenantID string, extra int64) error {
+	tenant, err := uc.Get(tenantID)
+	if err != nil {
+		return err
+	}
+	if tenant.LimitBytes > 0 && tenant.Usage.Total()+extra > tenant.LimitBytes {
+		return fmt.Errorf("%w: 已用 %d / %d 字节（%s 套餐）",
+			domainErrors.ErrStorageQuotaExceeded, tenant.Usage.Total(), tenant.LimitBytes, tenant.Plan)
+	}
+	return nil
+}
+
+// Recount 按源数据表重新统计全部页面的用量，校正统计偏差，返回统计的页面数
+func (uc *StorageUseCase) Recount() (int64, error) {
+	return uc.storage.Recount(false)
+}
+
+// Plan 返回租户的套餐，未设置时为默认套餐。计量额度（见 UsageUseCase）也按这里的套餐计算
+func (uc *StorageUseCase) Plan(tenantID string) (string, error) {
+	plan, err := uc.storage.GetPlan(tenantID)
+	if err != nil {
+		return "", err
+	}
+	if plan == "" {
+		plan = uc.defaultPlan
+	}
+	return plan, nil
+}
+
+// withPlan 补充租户的套餐和上限，未设置套餐时使用默认套餐
+func (uc *StorageUseCase) withPlan(usage *entity.StorageUsage) (*TenantStorage, error) {
+	plan, err := uc.Plan(usage.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	return &TenantStorage{Usage: usage, Plan: plan, LimitBytes: uc.limits[plan]}, nil
+}
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/ws"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== StorageUseCase 单元测试 ==========
+
+var testPlanLimits = map[string]int64{
+	entity.PlanFree:       1000,
+	entity.PlanPro:        10000,
+	entity.PlanEnterprise: 0,
+}
+
+func TestStorageUseCase_Get(t *testing.T) {
+	// 测试场景：未设置套餐的租户使用默认套餐；企业版上限为 0，不会超限
+	repo := new(MockStorageRepository)
+	repo.On("TenantUsage", "org-1").Return(&entity.StorageUsage{TenantID: "org-1", Pages: 2, SchemaBytes: 600, SnapshotBytes: 400}, nil)
+	repo.On("GetPlan", "org-1").Return("", nil)
+	repo.On("TenantUsage", "org-2").Return(&entity.StorageUsage{TenantID: "org-2", JournalBytes: 5000}, nil)
+	repo.On("GetPlan", "org-2").Return(entity.PlanEnterprise, nil)
+
+	uc := NewStorageUseCase(repo, testPlanLimits, entity.PlanFree)
+
+	tenant, err := uc.Get("org-1")
+	require.NoError(t, err)
+	assert.Equal(t, entity.PlanFree, tenant.Plan)
+	assert.Equal(t, int64(1000), tenant.LimitBytes)
+	assert.Equal(t, int64(1000), tenant.Usage.Total())
+	assert.True(t, tenant.Exceeded())
+
+	tenant, err = uc.Get("org-2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), tenant.LimitBytes)
+	assert.False(t, tenant.Exceeded())
+}
+
+func TestStorageUseCase_CheckQuota(t *testing.T) {
+	// 测试场景：写入后不超过上限时放行，超过时返回 ErrStorageQuotaExceeded；不限套餐始终放行
+	repo := new(MockStorageRepository)
+	repo.On("TenantUsage", "user-1").Return(&entity.StorageUsage{TenantID: "user-1", SchemaBytes: 900}, nil)
+	repo.On("GetPlan", "user-1").Return(entity.PlanFree, nil)
+	repo.On("TenantUsage", "user-2").Return(&entity.StorageUsage{TenantID: "user-2", SchemaBytes: 900}, nil)
+	repo.On("GetPlan", "user-2").Return(entity.PlanEnterprise, nil)
+
+	uc := NewStorageUseCase(repo, testPlanLimits, entity.PlanFree)
+
+	assert.NoError(t, uc.CheckQuota("user-1", 100))
+	assert.ErrorIs(t, uc.CheckQuota("user-1", 101), domainErrors.ErrStorageQuotaExceeded)
+	assert.NoError(t, uc.CheckQuota("user-2", 1<<30))
+	assert.NoError(t, checkQuota(nil, "user-1", 1<<30))
+}
+
+func TestStorageUseCase_SetPlan(t *testing.T) {
+	// 测试场景：未知套餐或租户为空时拒绝；设置后返回新套餐的上限
+	repo := new(MockStorageRepository)
+	repo.On("SetPlan", "org-1", entity.PlanPro).Return(nil).Once()
+	repo.On("TenantUsage", "org-1").Return(&entity.StorageUsage{TenantID: "org-1", SchemaBytes: 2000}, nil)
+	repo.On("GetPlan", "org-1").Return(entity.PlanPro, nil)
+
+	uc := NewStorageUseCase(repo, testPlanLimits, entity.PlanFree)
+
+	_, err := uc.SetPlan("org-1", "gold")
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidPlan)
+	_, err = uc.SetPlan("", entity.PlanPro)
+	assert.ErrorIs(t, err, domainErrors.ErrInvalidPlan)
+
+	tenant, err := uc.SetPlan("org-1", entity.PlanPro)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10000), tenant.LimitBytes)
+	assert.False(t, tenant.Exceeded())
+	repo.AssertExpectations(t)
+}
+
+func TestStorageUseCase_ListTop(t *testing.T) {
+	// 测试场景：条数未指定时使用默认值，超过上限时截断
+	repo := new(MockStorageRepository)
+	repo.On("ListTopTenants", DefaultListTenants).Return([]*entity.StorageUsage{{TenantID: "org-1"}}, nil).Once()
+	repo.On("ListTopTenants", MaxListTenants).Return([]*entity.StorageUsage{}, nil).Once()
+	repo.On("GetPlan", "org-1").Return("", nil)
+
+	uc := NewStorageUseCase(repo, testPlanLimits, entity.PlanFree)
+
+	tenants, err := uc.ListTop(0)
+	require.NoError(t, err)
+	require.Len(t, tenants, 1)
+	assert.Equal(t, entity.PlanFree, tenants[0].Plan)
+
+	tenants, err = uc.ListTop(MaxListTenants + 1)
+	require.NoError(t, err)
+	assert.Empty(t, tenants)
+	repo.AssertExpectations(t)
+}
+
+func TestPageUseCase_CreatePage_QuotaExceeded(t *testing.T) {
+	// 测试场景：激活组织时按组织检查配额，超出上限时不创建页面
+	mockRepo := new(MockPageRepository)
+	storage := new(MockStorageRepository)
+	storage.On("TenantUsage", "org-1").Return(&entity.StorageUsage{TenantID: "org-1", SchemaBytes: 1000}, nil)
+	storage.On("GetPlan", "org-1").Return("", nil)
+
+	uc := NewPageUseCase(mockRepo, newMockUserRepository(), new(MockCollaboratorRepository), nil, ws.NewHub(new(MockPageService)))
+	uc.EnableQuota(NewStorageUseCase(storage, testPlanLimits, entity.PlanFree))
+
+	page, err := uc.CreatePage("new-page", "user-123", OrgClaims{OrgID: "org-1", OrgRole: "org:admin"}, nil)
+
+	assert.Nil(t, page)
+	assert.ErrorIs(t, err, domainErrors.ErrStorageQuotaExceeded)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
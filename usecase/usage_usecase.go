@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+)
+
+// UsageMeter 按套餐计量的调用额度，由 UsageUseCase 实现
+type UsageMeter interface {
+	// Consume 为租户记一次 kind 调用，本周期已达套餐额度时返回 *UsageLimitError（ErrUsageLimitExceeded）
+	Consume(tenantID, kind string) error
+}
+
+// consumeUsage 未启用计量时放行
+func consumeUsage(meter UsageMeter, tenantID, kind string) error {
+	if meter == nil {
+		return nil
+	}
+	return meter.Consume(tenantID, kind)
+}
+
+// PlanResolver 查询租户的套餐，由 StorageUseCase 实现，计量额度与存储配额共用同一套餐设置
+type PlanResolver interface {
+	Plan(tenantID string) (string, error)
+}
+
+// UsageLimits 套餐 → 计量类型 → 每个周期（UTC 自然月）允许的次数，缺省或 0 表示不限
+type UsageLimits map[string]map[string]int64
+
+// UsageLimitError 租户本周期某类调用已达套餐额度，errors.Is(err, ErrUsageLimitExceeded) 成立
+type UsageLimitError struct {
+	TenantID string
+	Kind     string
+	Plan     string
+	Limit    int64
+	ResetAt  time.Time // 下一周期开始的时间，额度在此时恢复
+}
+
+func (e *UsageLimitError) Error() string {
+	return fmt.Sprintf("%v: %s 本月已达 %d 次额度（%s 套餐），%s 恢复",
+		domainErrors.ErrUsageLimitExceeded, e.Kind, e.Limit, e.Plan, e.ResetAt.Format(time.DateOnly))
+}
+
+func (e *UsageLimitError) Unwrap() error {
+	return domainErrors.ErrUsageLimitExceeded
+}
+
+// UsageAllowance 一类调用在本周期的用量与额度
+type UsageAllowance struct {
+	Kind  string
+	Used  int64
+	Limit int64 // 0 表示不限
+}
+
+// TenantUsage 租户本周期的计量用量
+type TenantUsage struct {
+	TenantID    string
+	Plan        string
+	PeriodStart time.Time
+	ResetAt     time.Time
+	Allowances  []UsageAllowance // 按 entity.UsageKinds 的顺序
+}
+
+// UsageUseCase 按套餐计量昂贵的调用（AI 生成、发布），超出本月额度时拒绝，提示升级套餐。
+// 套餐来自存储配额的租户套餐设置（见 StorageUseCase.SetPlan），计数保存在数据库中，多实例部署共享额度
+type UsageUseCase struct {
+	usage  repository.UsageRepository
+	plans  PlanResolver
+	limits UsageLimits
+
+	now func() time.Time // 便于测试替换
+}
+
+// NewUsageUseCase 创建 UsageUseCase 实例
+func NewUsageUseCase(usage repository.UsageRepository, plans PlanResolver, limits UsageLimits) *UsageUseCase {
+	return &UsageUseCase{usage: usage, plans: plans, limits: limits, now: time.Now}
+}
+
+// Consume 为租户记一次 kind 调用，本周期已达套餐额度时返回 *UsageLimitError
+func (uc *UsageUseCase) Consume(tenantID, kind string) error {
+	plan, err := uc.plans.Plan(tenantID)
+	if err != nil {
+		return err
+	}
+	period := entity.UsagePeriod(uc.now())
+	limit := uc.limits[plan][kind]
+	_, ok, err := uc.usage.Consume(tenantID, kind, period, limit)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &UsageLimitError{TenantID: tenantID, Kind: kind, Plan: plan, Limit: limit, ResetAt: period.AddDate(0, 1, 0)}
+	}
+	return nil
+}
+
+// Get 返回租户本周期各类调用的用量与额度
+func (uc *UsageUseCase) Get(tenantID string) (*TenantUsage, error) {
+	plan, err := uc.plans.Plan(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	period := entity.UsagePeriod(uc.now())
+	counters, err := uc.usage.ListUsage(tenantID, period)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]int64, len(counters))
+	for _, counter := range counters {
+		used[counter.Kind] = counter.Count
+	}
+
+	usage := &TenantUsage{
+		TenantID:    tenantID,
+		Plan:        plan,
+		PeriodStart: period,
+		ResetAt:     period.AddDate(0, 1, 0),
+		Allowances:  make([]UsageAllowance, 0, len(entity.UsageKinds)),
+	}
+	for _, kind := range entity.UsageKinds {
+		usage.Allowances = append(usage.Allowances, UsageAllowance{Kind: kind, Used: used[kind], Limit: uc.limits[plan][kind]})
+	}
+	return usage, nil
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== UsageUseCase 单元测试 ==========
+
+var testUsageLimits = UsageLimits{
+	entity.PlanFree: {entity.UsageKindAI: 2, entity.UsageKindPublish: 5},
+	entity.PlanPro:  {entity.UsageKindAI: 100},
+}
+
+// newUsageTestUseCase 套餐来自 StorageUseCase，当前时间固定为 2026-03-15
+func newUsageTestUseCase(storage *MockStorageRepository, usage *MockUsageRepository) *UsageUseCase {
+	uc := NewUsageUseCase(usage, NewStorageUseCase(storage, testPlanLimits, entity.PlanFree), testUsageLimits)
+	uc.now = func() time.Time { return time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC) }
+	return uc
+}
+
+func TestUsagePeriod(t *testing.T) {
+	// 测试场景：计量周期为 UTC 自然月，按 UTC 时间归入周期
+	shanghai := time.FixedZone("CST", 8*3600)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), entity.UsagePeriod(time.Date(2026, 3, 31, 23, 59, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), entity.UsagePeriod(time.Date(2026, 3, 1, 7, 0, 0, 0, shanghai)))
+}
+
+func TestUsageUseCase_Consume(t *testing.T) {
+	// 测试场景：按租户套餐的额度计量；达到额度时返回 UsageLimitError，带套餐、额度和下月恢复时间；
+	// 套餐未列出的类型以 0（不限）计量
+	period := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	storage := new(MockStorageRepository)
+	storage.On("GetPlan", "user-1").Return("", nil)
+	storage.On("GetPlan", "org-1").Return(entity.PlanPro, nil)
+	usage := new(MockUsageRepository)
+	usage.On("Consume", "user-1", entity.UsageKindAI, period, int64(2)).Return(int64(2), true, nil).Once()
+	usage.On("Consume", "user-1", entity.UsageKindAI, period, int64(2)).Return(int64(2), false, nil).Once()
+	usage.On("Consume", "org-1", entity.UsageKindPublish, period, int64(0)).Return(int64(42), true, nil).Once()
+
+	uc := newUsageTestUseCase(storage, usage)
+
+	require.NoError(t, uc.Consume("user-1", entity.UsageKindAI))
+
+	err := uc.Consume("user-1", entity.UsageKindAI)
+	assert.ErrorIs(t, err, domainErrors.ErrUsageLimitExceeded)
+	var limitErr *UsageLimitError
+	require.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, UsageLimitError{
+		TenantID: "user-1", Kind: entity.UsageKindAI, Plan: entity.PlanFree, Limit: 2,
+		ResetAt: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+	}, *limitErr)
+
+	require.NoError(t, uc.Consume("org-1", entity.UsageKindPublish))
+	assert.NoError(t, consumeUsage(nil, "user-1", entity.UsageKindAI))
+	usage.AssertExpectations(t)
+}
+
+func TestUsageUseCase_Get(t *testing.T) {
+	// 测试场景：按 entity.UsageKinds 的顺序返回每类调用的用量和额度，没有调用的类型用量为 0
+	period := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	storage := new(MockStorageRepository)
+	storage.On("GetPlan", "org-1").Return(entity.PlanPro, nil)
+	usage := new(MockUsageRepository)
+	usage.On("ListUsage", "org-1", period).Return([]*entity.UsageCounter{
+		{TenantID: "org-1", Kind: entity.UsageKindPublish, Period: period, Count: 7},
+	}, nil)
+
+	got, err := newUsageTestUseCase(storage, usage).Get("org-1")
+	require.NoError(t, err)
+	assert.Equal(t, &TenantUsage{
+		TenantID:    "org-1",
+		Plan:        entity.PlanPro,
+		PeriodStart: period,
+		ResetAt:     time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		Allowances: []UsageAllowance{
+			{Kind: entity.UsageKindAI, Used: 0, Limit: 100},
+			{Kind: entity.UsageKindPublish, Used: 7, Limit: 0},
+		},
+	}, got)
+}
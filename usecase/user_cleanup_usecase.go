@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"log"
+
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/ws"
+)
+
+// UserCleanupUseCase 用户账号被删除后的级联清理。
+// 用户创建的页面转给接手人（组织管理员或编辑者协作者），没有接手人的页面被软删除（可由管理员恢复），
+// 用户自己的草稿分支被删除；
+// 随后删除用户的协作者记录、组织成员关系、API Key、页面活动和用户记录
+type UserCleanupUseCase struct {
+	repo   repository.UserCleanupRepository
+	pages  repository.PageRepository
+	hub    *ws.Hub
+	dryRun bool // 只生成并记录计划，不关闭房间也不修改数据
+}
+
+// NewUserCleanupUseCase 创建 UserCleanupUseCase 实例
+func NewUserCleanupUseCase(repo repository.UserCleanupRepository, pages repository.PageRepository, hub *ws.Hub, dryRun bool) *UserCleanupUseCase {
+	return &UserCleanupUseCase{repo: repo, pages: pages, hub: hub, dryRun: dryRun}
+}
+
+// DeleteUser 清理用户的数据，返回执行（或 dry-run 时将要执行）的计划。
+// 先关闭受影响页面的协同房间并刷盘，再在一个事务中修改数据库
+func (uc *UserCleanupUseCase) DeleteUser(userID string) (*repository.UserDeletionPlan, error) {
+	plan, err := uc.Plan(userID)
+	if err != nil {
+		return nil, err
+	}
+	if uc.dryRun {
+		plan.DryRun = true
+		log.Printf("[UserCleanup] dry-run：用户 %s 的 %d 个页面将转移、%d 个页面将删除，未做任何修改",
+			userID, len(plan.Transfers), len(plan.DeletedPages))
+		return plan, nil
+	}
+
+	// 转移的页面关闭后在线用户按新的角色重连；删除的页面连同其他用户的草稿分支一起关闭
+	affected := make([]string, 0, len(plan.Transfers)+len(plan.DeletedPages))
+	for _, t := range plan.Transfers {
+		uc.hub.CloseRoomWithReason(t.PageID, ws.ErrOwnerChanged, "页面所有者已变更，请重新连接")
+		affected = append(affected, t.PageID)
+	}
+	for _, pageID := range plan.DeletedPages {
+		branchPageIDs, err := uc.pages.BranchPageIDs(pageID)
+		if err != nil {
+			return nil, err
+		}
+		for _, branchPageID := range branchPageIDs {
+			uc.hub.CloseRoom(branchPageID)
+		}
+		uc.hub.CloseRoom(pageID)
+		affected = append(append(affected, branchPageIDs...), pageID)
+	}
+
+	err = uc.repo.DeleteUser(plan)
+	// 所有者和页面在 UserCleanupRepository 的事务中修改，不经过 PageRepository，需要单独使缓存失效
+	if invalidator, ok := uc.pages.(repository.PageCacheInvalidator); ok {
+		invalidator.InvalidatePages(affected...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[UserCleanup] 用户 %s 已删除：%d 个页面已转移、%d 个页面已删除",
+		userID, len(plan.Transfers), len(plan.DeletedPages))
+	return plan, nil
+}
+
+// Plan 生成用户的删除计划，不做任何修改
+func (uc *UserCleanupUseCase) Plan(userID string) (*repository.UserDeletionPlan, error) {
+	owned, err := uc.repo.OwnedPages(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &repository.UserDeletionPlan{
+		UserID:       userID,
+		Transfers:    []repository.PageTransfer{},
+		DeletedPages: []string{},
+	}
+	deleted := make(map[string]bool)
+	for _, page := range owned {
+		if page.BranchOf != "" {
+			continue
+		}
+		successor, err := uc.repo.Successor(page, userID)
+		if err != nil {
+			return nil, err
+		}
+		if successor == "" {
+			deleted[page.PageID] = true
+			plan.DeletedPages = append(plan.DeletedPages, page.PageID)
+			continue
+		}
+		plan.Transfers = append(plan.Transfers, repository.PageTransfer{PageID: page.PageID, NewOwnerID: successor})
+	}
+
+	// 草稿分支是私有的，不转移；所属页面被删除时随之删除，无需单独列出
+	for _, page := range owned {
+		if page.BranchOf != "" && !deleted[page.BranchOf] {
+			plan.DeletedPages = append(plan.DeletedPages, page.PageID)
+		}
+	}
+	return plan, nil
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/ws"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========== UserCleanupUseCase 单元测试 ==========
+
+// newUserCleanupTest 准备 alice 的页面：组织页面 org-page 有接手人 bob，个人页面 solo-page 没有接手人，
+// solo-page 和别人的页面 other-page 上各有 alice 的一个草稿分支；org-page 和 solo-page 的房间在线
+func newUserCleanupTest(t *testing.T) (*ws.Hub, *MockUserCleanupRepository, *MockPageRepository) {
+	t.Helper()
+	mockPageService := new(MockPageService)
+	mockPageService.On("GetPageState", mock.Anything).Return([]byte(`{}`), int64(1), nil)
+	mockPageService.On("SavePageState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	hub := ws.NewHub(mockPageService)
+	for _, pageID := range []string{"org-page", "solo-page"} {
+		_, err := hub.GetOrCreateRoom(pageID)
+		require.NoError(t, err)
+	}
+
+	repo := new(MockUserCleanupRepository)
+	repo.On("OwnedPages", "alice").Return([]*entity.Page{
+		{PageID: "org-page", CreatorID: "alice", OrgID: "org-1"},
+		{PageID: "solo-page", CreatorID: "alice"},
+		{PageID: "solo-branch", CreatorID: "alice", BranchOf: "solo-page"},
+		{PageID: "other-branch", CreatorID: "alice", BranchOf: "other-page"},
+	}, nil)
+	repo.On("Successor", "org-page", "alice").Return("bob", nil)
+	repo.On("Successor", "solo-page", "alice").Return("", nil)
+
+	pages := new(MockPageRepository)
+	pages.On("BranchPageIDs", "solo-page").Return([]string{"solo-branch", "carol-branch"}, nil)
+	pages.On("BranchPageIDs", "other-branch").Return([]string{}, nil)
+	return hub, repo, pages
+}
+
+func TestUserCleanupUseCase_DeleteUser(t *testing.T) {
+	// 测试场景：有接手人的页面转移，没有的删除；所属页面被删除的草稿分支不单独列出，
+	// 别人页面上的草稿分支被删除；受影响页面的房间先关闭，再执行删除
+
+	hub, repo, pages := newUserCleanupTest(t)
+	repo.On("DeleteUser", mock.Anything).Run(func(args mock.Arguments) {
+		assert.Nil(t, hub.GetRoom("org-page"), "转移页面的房间应在修改数据库前关闭")
+		assert.Nil(t, hub.GetRoom("solo-page"), "删除页面的房间应在修改数据库前关闭")
+	}).Return(nil).Once()
+
+	uc := NewUserCleanupUseCase(repo, pages, hub, false)
+	plan, err := uc.DeleteUser("alice")
+	require.NoError(t, err)
+
+	assert.False(t, plan.DryRun)
+	assert.Equal(t, []repository.PageTransfer{{PageID: "org-page", NewOwnerID: "bob"}}, plan.Transfers)
+	assert.Equal(t, []string{"solo-page", "other-branch"}, plan.DeletedPages)
+	repo.AssertExpectations(t)
+	pages.AssertExpectations(t)
+}
+
+func TestUserCleanupUseCase_DryRun(t *testing.T) {
+	// 测试场景：dry-run 只返回计划，不关闭房间也不修改数据库
+
+	hub, repo, pages := newUserCleanupTest(t)
+
+	uc := NewUserCleanupUseCase(repo, pages, hub, true)
+	plan, err := uc.DeleteUser("alice")
+	require.NoError(t, err)
+
+	assert.True(t, plan.DryRun)
+	assert.Len(t, plan.Transfers, 1)
+	assert.Len(t, plan.DeletedPages, 2)
+	assert.NotNil(t, hub.GetRoom("org-page"))
+	assert.NotNil(t, hub.GetRoom("solo-page"))
+	repo.AssertNotCalled(t, "DeleteUser", mock.Anything)
+	pages.AssertNotCalled(t, "BranchPageIDs", mock.Anything)
+
+	for _, pageID := range []string{"org-page", "solo-page"} {
+		hub.CloseRoom(pageID)
+	}
+}
+
+// cachedPages 带读取缓存的 PageRepository，记录失效的页面
+type cachedPages struct {
+	*MockPageRepository
+	invalidated []string
+}
+
+func (p *cachedPages) InvalidatePages(pageIDs ...string) {
+	p.invalidated = append(p.invalidated, pageIDs...)
+}
+
+func TestUserCleanupUseCase_InvalidatesCache(t *testing.T) {
+	// 测试场景：PageRepository 带缓存时，转移和删除的页面（含草稿分支）在修改数据库后失效
+
+	hub, repo, pages := newUserCleanupTest(t)
+	repo.On("DeleteUser", mock.Anything).Return(nil).Once()
+	cached := &cachedPages{MockPageRepository: pages}
+
+	uc := NewUserCleanupUseCase(repo, cached, hub, false)
+	_, err := uc.DeleteUser("alice")
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"org-page", "solo-page", "solo-branch", "carol-branch", "other-branch"}, cached.invalidated)
+}
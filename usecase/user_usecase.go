@@ -0,0 +1,228 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/logging"
+)
+
+// CursorPalette 未自定义颜色的用户按用户 ID 从中分配协作光标颜色
+var CursorPalette = []string{
+	"#FF6B6B", // 红色
+	"#4ECDC4", // 青色
+	"#45B7D1", // 蓝色
+	"#96CEB4", // 绿色
+	"#FFEAA7", // 黄色
+	"#DDA0DD", // 梅红
+	"#98D8C8", // 薄荷
+	"#F7DC6F", // 金色
+}
+
+// placeholderUserName Webhook 同步前创建的占位用户记录使用的名字，不作为显示名
+const placeholderUserName = "Unknown User"
+
+// colorPattern 合法的协作光标颜色
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// DefaultCursorColor 根据用户 ID 从调色板中分配颜色，同一 ID 总是得到同一颜色
+func DefaultCursorColor(userID string) string {
+	hash := 0
+	for _, c := range userID {
+		hash = hash*31 + int(c)
+	}
+	if hash < 0 {
+		hash = -hash
+	}
+
+	return CursorPalette[hash%len(CursorPalette)]
+}
+
+// 最近页面列表的条数
+const (
+	DefaultRecentPages = 20
+	MaxRecentPages     = 100
+)
+
+// 最近页面附带 Schema 时的限制，避免一次返回 N 个数 MB 的 Schema
+const (
+	MaxRecentPagesWithSchema = 10      // 附带 Schema 时的最大条数
+	MaxIncludedSchemaBytes   = 4 << 20 // 单次响应附带的 Schema 总字节数上限
+)
+
+// PageReader 以指定用户的身份读取页面最新状态（协同房间内存优先），由 PageUseCase 实现
+type PageReader interface {
+	GetPage(pageID, userID string) (*entity.Page, error)
+}
+
+// UserUseCase 用户资料、偏好与最近活动业务逻辑
+type UserUseCase struct {
+	userRepo     repository.UserRepository
+	activityRepo repository.ActivityRepository
+	pages        PageReader
+}
+
+// NewUserUseCase 创建 UserUseCase 实例
+// pages 用于最近页面附带 Schema，为 nil 时不支持
+func NewUserUseCase(userRepo repository.UserRepository, activityRepo repository.ActivityRepository, pages PageReader) *UserUseCase {
+	return &UserUseCase{userRepo: userRepo, activityRepo: activityRepo, pages: pages}
+}
+
+// RecentPages 返回用户最近打开或编辑过的页面，按最近活动时间倒序。
+// limit <= 0 时取默认条数，超过上限时截断。
+func (uc *UserUseCase) RecentPages(userID string, limit int) ([]repository.RecentPage, error) {
+	if limit <= 0 {
+		limit = DefaultRecentPages
+	}
+	if limit > MaxRecentPages {
+		limit = MaxRecentPages
+	}
+
+	pages, err := uc.activityRepo.ListRecent(userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if pages == nil {
+		pages = []repository.RecentPage{}
+	}
+	return pages, nil
+}
+
+// RecentPagesWithSchema 同 RecentPages，并附带各页面的最新 Schema，版本号与 Schema 一致。
+// 条数上限为 MaxRecentPagesWithSchema；Schema 累计超过 MaxIncludedSchemaBytes 后，
+// 其余页面不再附带 Schema，只标记 SchemaOmitted。
+func (uc *UserUseCase) RecentPagesWithSchema(userID string, limit int) ([]repository.RecentPage, error) {
+	if uc.pages == nil {
+		return nil, errors.New("未配置页面读取，无法附带 Schema")
+	}
+	if limit <= 0 || limit > MaxRecentPagesWithSchema {
+		limit = MaxRecentPagesWithSchema
+	}
+
+	pages, err := uc.RecentPages(userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	budget := MaxIncludedSchemaBytes
+	for i := range pages {
+		if budget <= 0 {
+			pages[i].SchemaOmitted = true
+			continue
+		}
+		page, err := uc.pages.GetPage(pages[i].PageID, userID)
+		if errors.Is(err, domainErrors.ErrPageNotFound) || errors.Is(err, domainErrors.ErrUnauthorized) {
+			// 列表查询之后被删除，或已不再是协作者
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if page == nil {
+			continue
+		}
+		if len(page.Schema) > budget {
+			pages[i].SchemaOmitted = true
+			budget = 0
+			continue
+		}
+		pages[i].Schema = json.RawMessage(page.Schema)
+		pages[i].Version = page.Version
+		budget -= len(page.Schema)
+	}
+	return pages, nil
+}
+
+// GetProfile 获取当前用户资料，CursorColor 总是有值。
+// Clerk Webhook 尚未同步的用户返回只含 ID 和颜色的资料。
+func (uc *UserUseCase) GetProfile(userID string) (*entity.User, error) {
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return &entity.User{ID: userID, CursorColor: DefaultCursorColor(userID)}, nil
+	}
+	if user.CursorColor == "" {
+		user.CursorColor = uc.assignCursorColor(userID)
+	}
+	return user, nil
+}
+
+// Presence 用户在协同房间中展示的身份
+type Presence struct {
+	Name      string // 显示名，用户未同步或只有占位记录时为空
+	AvatarURL string
+	Color     string // 协作光标颜色，总是有值
+}
+
+// Presence 一次查询返回用户的显示名、头像和协作光标颜色，供 WebSocket 连接时使用。
+// 颜色规则同 CursorColor；读取失败时只有按 ID 计算的颜色，不阻塞连接。
+func (uc *UserUseCase) Presence(userID string) Presence {
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		logging.Warnf("[User] 读取用户 %s 的资料失败: %v", userID, err)
+		return Presence{Color: DefaultCursorColor(userID)}
+	}
+	if user == nil {
+		return Presence{Color: DefaultCursorColor(userID)}
+	}
+
+	presence := Presence{AvatarURL: user.AvatarURL, Color: user.CursorColor}
+	if user.Name != placeholderUserName {
+		presence.Name = strings.TrimSpace(user.Name)
+	}
+	if presence.Color == "" {
+		presence.Color = uc.assignCursorColor(userID)
+	}
+	return presence
+}
+
+// CursorColor 返回用户的协作光标颜色。
+// 已保存的颜色优先；用户尚未分配颜色时分配一个并保存，之后在任何设备上都保持不变。
+// 读取失败时退回按 ID 计算的颜色。
+func (uc *UserUseCase) CursorColor(userID string) string {
+	return uc.Presence(userID).Color
+}
+
+// SetCursorColor 自定义协作光标颜色，返回规范化（大写）后的颜色。
+// 新颜色在下次连接协同房间时生效。
+func (uc *UserUseCase) SetCursorColor(userID, color string) (string, error) {
+	if !colorPattern.MatchString(color) {
+		return "", domainErrors.ErrInvalidColor
+	}
+	color = strings.ToUpper(color)
+
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return "", err
+	}
+
+	// 用户不存在，创建占位记录，后续 Clerk Webhook 会补全资料且不会覆盖颜色
+	if user == nil {
+		return color, uc.userRepo.Upsert(&entity.User{
+			ID:          userID,
+			Name:        placeholderUserName,
+			CursorColor: color,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		})
+	}
+
+	return color, uc.userRepo.UpdateCursorColor(userID, color)
+}
+
+// assignCursorColor 为尚未分配颜色的已同步用户保存默认颜色，保存失败只记录日志
+func (uc *UserUseCase) assignCursorColor(userID string) string {
+	color := DefaultCursorColor(userID)
+	if err := uc.userRepo.UpdateCursorColor(userID, color); err != nil {
+		logging.Warnf("[User] 保存用户 %s 的光标颜色失败: %v", userID, err)
+	}
+	return color
+}
@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/datatypes"
+)
+
+// ========== UserUseCase 单元测试 ==========
+
+func TestDefaultCursorColor(t *testing.T) {
+	// 测试场景：同一用户 ID 总是分配到同一颜色，且颜色来自调色板
+
+	color := DefaultCursorColor("user_123")
+	assert.Equal(t, color, DefaultCursorColor("user_123"))
+	assert.Contains(t, CursorPalette, color)
+}
+
+func TestUserUseCase_CursorColor(t *testing.T) {
+	// 测试场景：已保存的颜色优先；已同步但未分配颜色的用户保存默认颜色；
+	// 未同步的用户和读取失败时退回默认颜色且不写库
+
+	userRepo := new(MockUserRepository)
+	userRepo.On("GetByID", "custom").Return(&entity.User{ID: "custom", CursorColor: "#123ABC"}, nil)
+	userRepo.On("GetByID", "synced").Return(&entity.User{ID: "synced"}, nil)
+	userRepo.On("UpdateCursorColor", "synced", DefaultCursorColor("synced")).Return(nil)
+	userRepo.On("GetByID", "unknown").Return(nil, nil)
+	userRepo.On("GetByID", "broken").Return(nil, errors.New("db down"))
+
+	uc := NewUserUseCase(userRepo, new(MockActivityRepository), nil)
+
+	assert.Equal(t, "#123ABC", uc.CursorColor("custom"))
+	assert.Equal(t, DefaultCursorColor("synced"), uc.CursorColor("synced"))
+	assert.Equal(t, DefaultCursorColor("unknown"), uc.CursorColor("unknown"))
+	assert.Equal(t, DefaultCursorColor("broken"), uc.CursorColor("broken"))
+
+	userRepo.AssertExpectations(t)
+	userRepo.AssertNumberOfCalls(t, "UpdateCursorColor", 1)
+}
+
+func TestUserUseCase_Presence(t *testing.T) {
+	// 测试场景：返回同步的显示名和头像；占位记录的名字不作为显示名；
+	// 未同步的用户和读取失败时只有默认颜色
+
+	userRepo := new(MockUserRepository)
+	userRepo.On("GetByID", "alice").Return(&entity.User{ID: "alice", Name: "Alice Zhang", AvatarURL: "https://img.example.com/alice.png", CursorColor: "#123ABC"}, nil)
+	userRepo.On("GetByID", "placeholder").Return(&entity.User{ID: "placeholder", Name: placeholderUserName, CursorColor: "#00FF00"}, nil)
+	userRepo.On("GetByID", "unknown").Return(nil, nil)
+	userRepo.On("GetByID", "broken").Return(nil, errors.New("db down"))
+
+	uc := NewUserUseCase(userRepo, new(MockActivityRepository), nil)
+
+	assert.Equal(t, Presence{Name: "Alice Zhang", AvatarURL: "https://img.example.com/alice.png", Color: "#123ABC"}, uc.Presence("alice"))
+	assert.Equal(t, Presence{Color: "#00FF00"}, uc.Presence("placeholder"))
+	assert.Equal(t, Presence{Color: DefaultCursorColor("unknown")}, uc.Presence("unknown"))
+	assert.Equal(t, Presence{Color: DefaultCursorColor("broken")}, uc.Presence("broken"))
+
+	userRepo.AssertNotCalled(t, "UpdateCursorColor", mock.Anything, mock.Anything)
+}
+
+func TestUserUseCase_SetCursorColor(t *testing.T) {
+	// 测试场景：颜色规范化为大写；非法格式被拒绝；未同步的用户创建带颜色的占位记录
+
+	userRepo := new(MockUserRepository)
+	userRepo.On("GetByID", "synced").Return(&entity.User{ID: "synced"}, nil)
+	userRepo.On("UpdateCursorColor", "synced", "#ABCDEF").Return(nil)
+	userRepo.On("GetByID", "unknown").Return(nil, nil)
+	userRepo.On("Upsert", mock.MatchedBy(func(u *entity.User) bool {
+		return u.ID == "unknown" && u.CursorColor == "#00FF00"
+	})).Return(nil)
+
+	uc := NewUserUseCase(userRepo, new(MockActivityRepository), nil)
+
+	color, err := uc.SetCursorColor("synced", "#abcdef")
+	assert.NoError(t, err)
+	assert.Equal(t, "#ABCDEF", color)
+
+	color, err = uc.SetCursorColor("unknown", "#00ff00")
+	assert.NoError(t, err)
+	assert.Equal(t, "#00FF00", color)
+
+	for _, invalid := range []string{"red", "#FFF", "#GGGGGG", "FF6B6B"} {
+		_, err = uc.SetCursorColor("synced", invalid)
+		assert.ErrorIs(t, err, domainErrors.ErrInvalidColor)
+	}
+
+	userRepo.AssertExpectations(t)
+}
+
+func TestUserUseCase_RecentPages(t *testing.T) {
+	// 测试场景：未指定条数时取默认值，超过上限时截断；没有记录时返回空列表而非 nil
+
+	activityRepo := new(MockActivityRepository)
+	activityRepo.On("ListRecent", "alice", DefaultRecentPages).Return([]repository.RecentPage{{PageID: "page-1"}}, nil)
+	activityRepo.On("ListRecent", "alice", MaxRecentPages).Return(nil, nil)
+
+	uc := NewUserUseCase(new(MockUserRepository), activityRepo, nil)
+
+	pages, err := uc.RecentPages("alice", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []repository.RecentPage{{PageID: "page-1"}}, pages)
+
+	pages, err = uc.RecentPages("alice", 1000)
+	assert.NoError(t, err)
+	assert.NotNil(t, pages)
+	assert.Empty(t, pages)
+
+	activityRepo.AssertExpectations(t)
+}
+
+func TestUserUseCase_RecentPagesWithSchema(t *testing.T) {
+	// 测试场景：附带最新 Schema 与对应版本，条数不超过上限；
+	// Schema 累计超过体积上限后其余页面只标记 SchemaOmitted，不再读取
+
+	activityRepo := new(MockActivityRepository)
+	activityRepo.On("ListRecent", "alice", MaxRecentPagesWithSchema).Return([]repository.RecentPage{
+		{PageID: "small", Version: 3},
+		{PageID: "large", Version: 1},
+		{PageID: "after", Version: 1},
+	}, nil)
+
+	large := `{"title": "` + strings.Repeat("a", MaxIncludedSchemaBytes) + `"}`
+	pages := new(MockPageReader)
+	pages.On("GetPage", "small", "alice").Return(&entity.Page{PageID: "small", Schema: datatypes.JSON(`{"title": "s"}`), Version: 5}, nil)
+	pages.On("GetPage", "large", "alice").Return(&entity.Page{PageID: "large", Schema: datatypes.JSON(large), Version: 1}, nil)
+
+	uc := NewUserUseCase(new(MockUserRepository), activityRepo, pages)
+
+	recent, err := uc.RecentPagesWithSchema("alice", 50)
+	assert.NoError(t, err)
+	assert.Len(t, recent, 3)
+
+	assert.JSONEq(t, `{"title": "s"}`, string(recent[0].Schema))
+	assert.Equal(t, int64(5), recent[0].Version)
+	assert.False(t, recent[0].SchemaOmitted)
+
+	assert.Nil(t, recent[1].Schema)
+	assert.True(t, recent[1].SchemaOmitted)
+	assert.True(t, recent[2].SchemaOmitted)
+
+	activityRepo.AssertExpectations(t)
+	pages.AssertExpectations(t)
+	pages.AssertNotCalled(t, "GetPage", "after", mock.Anything)
+}
@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"time"
+
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/domain/repository"
+	"lowercode-go-server/internal/jsondiff"
+	"lowercode-go-server/internal/ws"
+)
+
+// VersionUseCase 页面历史版本业务逻辑层
+type VersionUseCase struct {
+	pageRepo    repository.PageRepository
+	versionRepo repository.PageVersionRepository
+	access      PageAccess
+	hub         *ws.Hub
+
+	// ops 操作日志，可选，为 nil 时不提供编辑记录和标签统计
+	ops repository.PageOpRepository
+}
+
+// MaxHistoryLimit 单次最多返回的编辑记录数
+const MaxHistoryLimit = 200
+
+// HistoryEntry 操作日志中的一次编辑，不含 Patch 内容
+type HistoryEntry struct {
+	Version   int64     `json:"version"` // 应用后的版本号
+	AuthorID  string    `json:"authorId,omitempty"`
+	Guest     bool      `json:"guest,omitempty"`
+	Label     string    `json:"label,omitempty"` // 产生该编辑的编辑器功能，客户端未标注时省略
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NewVersionUseCase 创建 VersionUseCase 实例，access 为 nil 时不校验页面权限
+func NewVersionUseCase(pageRepo repository.PageRepository, versionRepo repository.PageVersionRepository, access PageAccess, hub *ws.Hub) *VersionUseCase {
+	return &VersionUseCase{pageRepo: pageRepo, versionRepo: versionRepo, access: access, hub: hub}
+}
+
+// EnableOpHistory 启用基于操作日志的编辑记录和标签统计
+func (uc *VersionUseCase) EnableOpHistory(ops repository.PageOpRepository) {
+	uc.ops = ops
+}
+
+// History 返回页面 since 版本之后的编辑记录（按版本升序，最多 limit 条），能读取页面的用户都可以查看。
+// limit 超出 [1, MaxHistoryLimit] 时取边界值；操作日志异步写入，最近几秒的编辑可能尚未出现。
+// 未启用操作日志时返回 ErrOpHistoryDisabled
+func (uc *VersionUseCase) History(pageID, userID string, since int64, limit int) ([]HistoryEntry, error) {
+	if uc.ops == nil {
+		return nil, domainErrors.ErrOpHistoryDisabled
+	}
+	if uc.access != nil {
+		if _, err := uc.access.PageRole(pageID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	ops, err := uc.ops.ListSince(pageID, since, min(max(limit, 1), MaxHistoryLimit))
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]HistoryEntry, 0, len(ops))
+	for _, op := range ops {
+		entries = append(entries, HistoryEntry{
+			Version:   op.Version,
+			AuthorID:  op.AuthorID,
+			Guest:     op.Guest,
+			Label:     op.Label,
+			CreatedAt: op.CreatedAt,
+		})
+	}
+	return entries, nil
+}
+
+// EditLabels 按标签统计版本在 (from, to] 之间的编辑次数，用于版本对比中展示"这段修改来自哪些功能"。
+// 调用方需已校验页面权限（如先调用 Diff）；未启用操作日志时返回 nil
+func (uc *VersionUseCase) EditLabels(pageID string, from, to int64) (map[string]int64, error) {
+	if uc.ops == nil || from >= to {
+		return nil, nil
+	}
+	return uc.ops.CountLabels(pageID, from, to)
+}
+
+// Diff 计算页面两个版本之间的 JSON Patch（RFC 6902），能读取页面的用户都可以查看。
+// to <= 0 表示当前最新版本；若房间在线且版本尚未刷盘，使用内存快照。
+func (uc *VersionUseCase) Diff(pageID, userID string, from, to int64) ([]jsondiff.Operation, int64, error) {
+	if uc.access != nil {
+		if _, err := uc.access.PageRole(pageID, userID); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	current, currentVersion, err := uc.currentState(pageID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if to <= 0 {
+		to = currentVersion
+	}
+
+	fromSchema, err := uc.schemaAt(pageID, from, current, currentVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+	toSchema, err := uc.schemaAt(pageID, to, current, currentVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ops, err := jsondiff.Diff(fromSchema, toSchema)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ops, to, nil
+}
+
+// currentState 获取页面最新状态，优先读 Hub 内存
+func (uc *VersionUseCase) currentState(pageID string) ([]byte, int64, error) {
+	if room := uc.hub.GetRoom(pageID); room != nil {
+		snapshot, version := room.GetSnapshot()
+		return snapshot, version, nil
+	}
+
+	page, err := uc.pageRepo.GetByPageID(pageID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if page == nil {
+		return nil, 0, domainErrors.ErrPageNotFound
+	}
+	return []byte(page.Schema), page.Version, nil
+}
+
+// schemaAt 获取指定版本的 Schema，当前版本直接复用已读取的最新状态
+func (uc *VersionUseCase) schemaAt(pageID string, version int64, current []byte, currentVersion int64) ([]byte, error) {
+	if version == currentVersion {
+		return current, nil
+	}
+
+	v, err := uc.versionRepo.GetByVersion(pageID, version)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, domainErrors.ErrVersionNotFound
+	}
+	return []byte(v.Schema), nil
+}
@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"testing"
+
+	"lowercode-go-server/domain/entity"
+	domainErrors "lowercode-go-server/domain/errors"
+	"lowercode-go-server/internal/ws"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+// ========== VersionUseCase 单元测试 ==========
+
+// TestVersionUseCase_Diff 测试两个历史版本之间的对比
+func TestVersionUseCase_Diff(t *testing.T) {
+	mockRepo := new(MockPageRepository)
+	mockVersionRepo := new(MockPageVersionRepository)
+	hub := ws.NewHub(new(MockPageService))
+
+	mockRepo.On("GetByPageID", "page-1").Return(&entity.Page{
+		PageID:  "page-1",
+		Schema:  datatypes.JSON(`{"title": "v5"}`),
+		Version: 5,
+	}, nil)
+	mockVersionRepo.On("GetByVersion", "page-1", int64(2)).Return(&entity.PageVersion{
+		PageID:  "page-1",
+		Version: 2,
+		Schema:  datatypes.JSON(`{"title": "v2"}`),
+	}, nil)
+
+	uc := NewVersionUseCase(mockRepo, mockVersionRepo, nil, hub)
+
+	// to 缺省时对比到当前版本
+	ops, to, err := uc.Diff("page-1", "alice", 2, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), to)
+	assert.Len(t, ops, 1)
+	assert.Equal(t, "replace", ops[0].Op)
+	assert.Equal(t, "/title", ops[0].Path)
+	assert.Equal(t, "v5", ops[0].Value)
+}
+
+// TestVersionUseCase_Diff_VersionNotFound 测试历史版本不存在
+func TestVersionUseCase_Diff_VersionNotFound(t *testing.T) {
+	mockRepo := new(MockPageRepository)
+	mockVersionRepo := new(MockPageVersionRepository)
+	hub := ws.NewHub(new(MockPageService))
+
+	mockRepo.On("GetByPageID", "page-1").Return(&entity.Page{
+		PageID:  "page-1",
+		Schema:  datatypes.JSON(`{}`),
+		Version: 5,
+	}, nil)
+	mockVersionRepo.On("GetByVersion", "page-1", int64(1)).Return(nil, nil)
+
+	uc := NewVersionUseCase(mockRepo, mockVersionRepo, nil, hub)
+
+	_, _, err := uc.Diff("page-1", "alice", 1, 0)
+
+	assert.ErrorIs(t, err, domainErrors.ErrVersionNotFound)
+}
+
+// TestVersionUseCase_Diff_Unauthorized 测试非协作者无权查看版本对比，不读取页面
+func TestVersionUseCase_Diff_Unauthorized(t *testing.T) {
+	mockRepo := new(MockPageRepository)
+	access := new(MockPageAccess)
+	access.On("PageRole", "page-1", "stranger").Return("", domainErrors.ErrUnauthorized)
+
+	uc := NewVersionUseCase(mockRepo, new(MockPageVersionRepository), access, ws.NewHub(new(MockPageService)))
+
+	_, _, err := uc.Diff("page-1", "stranger", 1, 0)
+
+	assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+	mockRepo.AssertNotCalled(t, "GetByPageID", "page-1")
+}
+
+// TestVersionUseCase_History 测试编辑记录带标签返回且不含 Patch，limit 超出上限时截断
+func TestVersionUseCase_History(t *testing.T) {
+	access := new(MockPageAccess)
+	access.On("PageRole", "page-1", "alice").Return(entity.RoleViewer, nil)
+	ops := new(MockPageOpRepository)
+	ops.On("ListSince", "page-1", int64(3), MaxHistoryLimit).Return([]*entity.PageOp{
+		{PageID: "page-1", Version: 4, AuthorID: "bob", Label: "drag-resize", Patch: datatypes.JSON(`[]`)},
+		{PageID: "page-1", Version: 5, AuthorID: "guest-1", Guest: true},
+	}, nil)
+
+	uc := NewVersionUseCase(new(MockPageRepository), new(MockPageVersionRepository), access, ws.NewHub(new(MockPageService)))
+
+	_, err := uc.History("page-1", "alice", 3, 1000)
+	assert.ErrorIs(t, err, domainErrors.ErrOpHistoryDisabled)
+
+	uc.EnableOpHistory(ops)
+	entries, err := uc.History("page-1", "alice", 3, 1000)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []HistoryEntry{
+		{Version: 4, AuthorID: "bob", Label: "drag-resize"},
+		{Version: 5, AuthorID: "guest-1", Guest: true},
+	}, entries)
+}
+
+// TestVersionUseCase_EditLabels 测试按标签统计区间内的编辑次数，未启用或区间为空时不查询
+func TestVersionUseCase_EditLabels(t *testing.T) {
+	ops := new(MockPageOpRepository)
+	ops.On("CountLabels", "page-1", int64(2), int64(5)).Return(map[string]int64{"undo": 2, "AI-assist": 1}, nil)
+
+	uc := NewVersionUseCase(new(MockPageRepository), new(MockPageVersionRepository), nil, ws.NewHub(new(MockPageService)))
+
+	labels, err := uc.EditLabels("page-1", 2, 5)
+	assert.NoError(t, err)
+	assert.Nil(t, labels)
+
+	uc.EnableOpHistory(ops)
+	labels, err = uc.EditLabels("page-1", 2, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"undo": 2, "AI-assist": 1}, labels)
+
+	labels, err = uc.EditLabels("page-1", 5, 5)
+	assert.NoError(t, err)
+	assert.Nil(t, labels)
+	ops.AssertNumberOfCalls(t, "CountLabels", 1)
+}